@@ -2,16 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/rsmanito/developstoday-test-assessment/internal/app"
+	"github.com/rsmanito/developstoday-test-assessment/internal/blobs"
 	"github.com/rsmanito/developstoday-test-assessment/internal/config"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+	"github.com/rsmanito/developstoday-test-assessment/internal/outbox"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
 	"github.com/rsmanito/developstoday-test-assessment/internal/server"
 	"github.com/rsmanito/developstoday-test-assessment/internal/service"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/memory"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/sqlite"
+	"github.com/rsmanito/developstoday-test-assessment/internal/webhooks"
 )
 
 func main() {
@@ -22,23 +35,122 @@ func main() {
 
 	cfg := config.MustLoad()
 
-	storage := storage.New(cfg)
+	reportingCurrency, err := currency.Parse(cfg.ReportingCurrency)
+	if err != nil {
+		panic(fmt.Sprintf("invalid REPORTING_CURRENCY %q: %v", cfg.ReportingCurrency, err))
+	}
+
+	storage := openStorage(cfg)
+
+	blobStore := openBlobStore(cfg)
+
+	bus := events.NewBus(cfg.EventBufferSize)
+
+	// Invalid rules fail the startup, later they are only logged on reload.
+	rules, err := rules.NewStore(cfg.RulesPath)
+	if err != nil {
+		panic(err)
+	}
 
 	service := service.NewService(
 		storage,
 		storage,
 		storage,
 		storage,
+		service.WithWebhookStorage(storage),
+		service.WithOutboxStorage(storage),
+		service.WithSearchStorage(storage),
+		service.WithTemplateStorage(storage),
+		service.WithRules(rules),
+		service.WithSnapshotStorage(storage),
+		service.WithReportStorage(storage),
+		service.WithAnalyticsStorage(storage),
+		service.WithAnalyticsCacheTTL(cfg.AnalyticsCacheTTL),
+		service.WithAvailabilityStorage(storage),
+		service.WithSkillStorage(storage),
+		service.WithCostStorage(storage),
+		service.WithRateStorage(storage),
+		service.WithLocationStorage(storage),
+		service.WithSubjectStorage(storage),
+		service.WithAttachments(storage, blobStore),
+		service.WithMaxAttachmentSize(cfg.AttachmentMaxSize),
+		service.WithReportingCurrency(reportingCurrency),
 	)
 
+	// `sca-service restore <file>` restores a snapshot and
+	// `sca-service import-rates <file>` imports exchange rates instead of serving.
+	if len(os.Args) > 1 {
+		err := runCommand(service, os.Args[1:])
+		storage.Close()
+		if err != nil {
+			slog.Error("Command failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	server := server.New(
 		service,
 		service,
 		service,
+		server.WithEventStream(bus),
+		server.WithWebhookService(service),
+		server.WithOutboxService(service),
+		server.WithSearchService(service),
+		server.WithTemplateService(service),
+		server.WithRules(rules),
+		server.WithImportService(service),
+		server.WithSnapshotService(service),
+		server.WithReportService(service),
+		server.WithAnalyticsService(service),
+		server.WithAvailabilityService(service),
+		server.WithSkillService(service),
+		server.WithAssignmentService(service),
+		server.WithCostService(service),
+		server.WithRateService(service),
+		server.WithLocationService(service),
+		server.WithSubjectService(service),
+		server.WithAttachmentService(service, cfg.AttachmentMaxSize),
+		server.WithNoteService(service),
 	)
 
 	app := app.New(server)
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+
+	dispatcher := webhooks.NewDispatcher(storage, webhooks.Config{
+		PollInterval: cfg.WebhookPollInterval,
+		BatchSize:    int32(cfg.WebhookBatchSize),
+		MaxAttempts:  int32(cfg.WebhookMaxAttempts),
+		BaseBackoff:  cfg.WebhookBaseBackoff,
+		MaxBackoff:   cfg.WebhookMaxBackoff,
+		Timeout:      cfg.WebhookTimeout,
+	})
+
+	sinks, err := outbox.NewSinks(cfg.OutboxSinks, bus, outbox.SinkConfig{
+		FilePath:          cfg.OutboxFilePath,
+		NatsUrl:           cfg.NatsUrl,
+		NatsSubjectPrefix: cfg.NatsSubjectPrefix,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	relay := outbox.NewRelay(storage, sinks, outbox.Config{
+		PollInterval:   cfg.OutboxPollInterval,
+		BatchSize:      int32(cfg.OutboxBatchSize),
+		GapGracePeriod: cfg.OutboxGapGracePeriod,
+	})
+
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){dispatcher.Run, relay.Run, rules.Run} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
 	errChan := make(chan error, 1)
 	go func() {
 		if err := app.Run(":" + cfg.HttpPort); err != nil {
@@ -59,7 +171,14 @@ func main() {
 		slog.Error("Received signal", "sig", sig)
 	}
 
-	storage.Close(context.Background())
+	stopWorkers()
+	workers.Wait()
+	relay.Close()
+
+	storage.Close()
+
+	// Close event streams so open connections don't block the shutdown.
+	bus.Close()
 
 	if err := app.Shutdown(); err != nil {
 		slog.Error("Received shutdown error", "err", err)
@@ -67,3 +186,126 @@ func main() {
 		slog.Info("Service shutdown gracefully")
 	}
 }
+
+// openStorage opens the storage backend selected by DB_DRIVER.
+func openStorage(cfg *config.Config) storage.Backend {
+	switch cfg.DbDriver {
+	case "postgres":
+		return storage.New(cfg)
+	case "sqlite":
+		return sqlite.New(cfg)
+	case "memory":
+		slog.Warn("Using in-memory storage, data is lost on shutdown")
+		return memory.New()
+	default:
+		panic(fmt.Sprintf("unknown DB_DRIVER %q", cfg.DbDriver))
+	}
+}
+
+// openBlobStore opens the attachment blob store selected by BLOB_STORE.
+func openBlobStore(cfg *config.Config) service.BlobStore {
+	switch cfg.BlobStore {
+	case "local":
+		bs, err := blobs.NewLocalStore(cfg.BlobDir)
+		if err != nil {
+			panic(err)
+		}
+		return bs
+	case "s3":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		bs, err := blobs.NewS3Store(ctx, blobs.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Region:    cfg.S3Region,
+			UseSSL:    cfg.S3UseSSL,
+		})
+		if err != nil {
+			panic(err)
+		}
+		return bs
+	default:
+		panic(fmt.Sprintf("unknown BLOB_STORE %q", cfg.BlobStore))
+	}
+}
+
+// commandService is the service of the commands.
+type commandService interface {
+	server.SnapshotService
+	server.RateService
+}
+
+// runCommand runs the command of the arguments.
+func runCommand(cs commandService, args []string) error {
+	switch args[0] {
+	case "restore":
+		if len(args) != 2 {
+			return errors.New("usage: restore <file>, - reads the snapshot from stdin")
+		}
+		return restore(cs, args[1])
+	case "import-rates":
+		if len(args) != 2 {
+			return errors.New("usage: import-rates <file>, - reads the CSV from stdin")
+		}
+		return importRates(cs, args[1])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// restore restores the snapshot of the file into the empty database.
+func restore(ss server.SnapshotService, path string) error {
+	r, closeFile, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	res, err := ss.RestoreSnapshot(context.Background(), r)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Restored snapshot", "schema_version", res.SchemaVersion, "cats", res.Cats, "missions", res.Missions, "targets", res.Targets)
+
+	return nil
+}
+
+// importRates sets the exchange rates of the CSV file.
+func importRates(rs server.RateService, path string) error {
+	r, closeFile, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	rates, err := currency.ReadRates(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := rs.ImportExchangeRates(context.Background(), rates)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Imported exchange rates", "reporting_currency", res.ReportingCurrency, "rates", len(rates))
+
+	return nil
+}
+
+// openInput opens the file of the path, - is stdin.
+func openInput(path string) (io.Reader, func(), error) {
+	if path == "-" {
+		return os.Stdin, func() {}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
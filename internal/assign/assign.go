@@ -0,0 +1,229 @@
+// Package assign solves the assignment of cats to missions.
+//
+// A Problem scores each pair of a mission and a cat. A Strategy assigns at
+// most one cat to each mission and at most one mission to each cat, covering
+// as many missions as it can, and then maximising the total score, within
+// the salary budget. Both strategies are heuristics with a budget: neither
+// searches the assignments within it.
+package assign
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+)
+
+// Forbidden is the score of a pair that can't be assigned.
+var Forbidden = math.Inf(-1)
+
+// NoBudget is the budget of a problem without a salary budget.
+const NoBudget = math.MaxInt64
+
+// ErrUnknownStrategy is returned for a name of no known strategy.
+var ErrUnknownStrategy = errors.New("unknown strategy, expected greedy or hungarian")
+
+// Problem is the assignment of cats to missions.
+type Problem struct {
+	// Scores[i][j] is the score of cat j for mission i, Forbidden if the cat
+	// can't be assigned to the mission. The scores are not negative.
+	Scores [][]float64
+	// Salaries[j] is the salary of cat j.
+	Salaries []int64
+	// Budget bounds the total salary of the assigned cats.
+	Budget int64
+}
+
+// Pair is the assignment of the cat to the mission, by their index.
+type Pair struct {
+	Mission int
+	Cat     int
+}
+
+// Strategy solves a problem.
+type Strategy interface {
+	Solve(p Problem) []Pair
+}
+
+// Strategies are the known strategies by name.
+var Strategies = map[string]Strategy{
+	"greedy":    Greedy{},
+	"hungarian": Hungarian{},
+}
+
+// Lookup returns the strategy of the name.
+func Lookup(name string) (Strategy, error) {
+	s, ok := Strategies[name]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return s, nil
+}
+
+// Greedy assigns the best scoring pairs first, the cheapest cat of a tie,
+// skipping the pairs over the budget.
+type Greedy struct{}
+
+func (Greedy) Solve(p Problem) []Pair {
+	var pairs []Pair
+	for i, row := range p.Scores {
+		for j, score := range row {
+			if score != Forbidden {
+				pairs = append(pairs, Pair{Mission: i, Cat: j})
+			}
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b Pair) int {
+		if c := cmp.Compare(p.Scores[b.Mission][b.Cat], p.Scores[a.Mission][a.Cat]); c != 0 {
+			return c
+		}
+		return cmp.Compare(p.Salaries[a.Cat], p.Salaries[b.Cat])
+	})
+
+	missions := make(map[int]bool)
+	cats := make(map[int]bool)
+	var total int64
+	res := make([]Pair, 0)
+	for _, pair := range pairs {
+		salary := p.Salaries[pair.Cat]
+		if missions[pair.Mission] || cats[pair.Cat] || salary > p.Budget-total {
+			continue
+		}
+		missions[pair.Mission], cats[pair.Cat] = true, true
+		total += salary
+		res = append(res, pair)
+	}
+
+	return sorted(res)
+}
+
+// Hungarian finds the optimal assignment with the Hungarian algorithm, in
+// O(n²m) for n missions and m cats, or the reverse.
+//
+// The assignment is optimal only without a budget. The budget isn't part of
+// the optimisation: while the assignment is over the budget, the pair of the
+// best paid cat, the lowest scoring of a tie, is dropped, which may leave
+// missions a cheaper assignment would cover.
+type Hungarian struct{}
+
+func (Hungarian) Solve(p Problem) []Pair {
+	n := len(p.Scores)
+	if n == 0 || len(p.Salaries) == 0 {
+		return make([]Pair, 0)
+	}
+	m := len(p.Salaries)
+
+	// Each assigned pair outweighs the scores of all the others, to assign
+	// as many missions as possible first.
+	weight := 1.0
+	for _, row := range p.Scores {
+		for _, score := range row {
+			if score != Forbidden {
+				weight = max(weight, score)
+			}
+		}
+	}
+	weight = weight*float64(min(n, m)) + 1
+
+	cost := func(i, j int) float64 {
+		if p.Scores[i][j] == Forbidden {
+			return 0
+		}
+		return -(weight + p.Scores[i][j])
+	}
+
+	res := make([]Pair, 0)
+	if n <= m {
+		for i, j := range hungarian(n, m, cost) {
+			res = append(res, Pair{Mission: i, Cat: j})
+		}
+	} else {
+		for j, i := range hungarian(m, n, func(j, i int) float64 { return cost(i, j) }) {
+			res = append(res, Pair{Mission: i, Cat: j})
+		}
+	}
+	res = slices.DeleteFunc(res, func(pair Pair) bool { return p.Scores[pair.Mission][pair.Cat] == Forbidden })
+
+	var total int64
+	for _, pair := range res {
+		total += p.Salaries[pair.Cat]
+	}
+	for total > p.Budget {
+		drop := slices.MaxFunc(res, func(a, b Pair) int {
+			if c := cmp.Compare(p.Salaries[a.Cat], p.Salaries[b.Cat]); c != 0 {
+				return c
+			}
+			return cmp.Compare(p.Scores[b.Mission][b.Cat], p.Scores[a.Mission][a.Cat])
+		})
+		total -= p.Salaries[drop.Cat]
+		res = slices.DeleteFunc(res, func(pair Pair) bool { return pair == drop })
+	}
+
+	return sorted(res)
+}
+
+// hungarian returns the columns assigned to the n rows of the n by m cost
+// matrix, n <= m, of the minimal total cost.
+func hungarian(n, m int, cost func(i, j int) float64) []int {
+	// The potentials of the rows and the columns, and the row of each column,
+	// 1-based so that the column 0 is the row being assigned.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	row := make([]int, m+1)
+	way := make([]int, m+1)
+
+	for i := 1; i <= n; i++ {
+		row[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for row[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := row[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost(i0-1, j-1) - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[row[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			row[j0] = row[j1]
+			j0 = j1
+		}
+	}
+
+	cols := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if row[j] != 0 {
+			cols[row[j]-1] = j - 1
+		}
+	}
+
+	return cols
+}
+
+func sorted(pairs []Pair) []Pair {
+	slices.SortFunc(pairs, func(a, b Pair) int { return cmp.Compare(a.Mission, b.Mission) })
+	return pairs
+}
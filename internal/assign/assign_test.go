@@ -0,0 +1,106 @@
+package assign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	s, err := Lookup("hungarian")
+	require.NoError(t, err)
+	assert.Equal(t, Hungarian{}, s)
+
+	_, err = Lookup("random")
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
+
+func TestStrategies(t *testing.T) {
+	f := Forbidden
+	tests := []struct {
+		name      string
+		problem   Problem
+		greedy    []Pair
+		hungarian []Pair
+	}{
+		{
+			name:      "empty",
+			problem:   Problem{Budget: NoBudget},
+			greedy:    []Pair{},
+			hungarian: []Pair{},
+		},
+		{
+			// Greedy takes the best pair, leaving mission 1 without a cat.
+			name: "greedy is not optimal",
+			problem: Problem{
+				Scores:   [][]float64{{1, 0.9}, {0.8, f}},
+				Salaries: []int64{10, 10},
+				Budget:   NoBudget,
+			},
+			greedy:    []Pair{{Mission: 0, Cat: 0}},
+			hungarian: []Pair{{Mission: 0, Cat: 1}, {Mission: 1, Cat: 0}},
+		},
+		{
+			name: "more missions than cats",
+			problem: Problem{
+				Scores:   [][]float64{{0.2}, {0.5}, {0.1}},
+				Salaries: []int64{10},
+				Budget:   NoBudget,
+			},
+			greedy:    []Pair{{Mission: 1, Cat: 0}},
+			hungarian: []Pair{{Mission: 1, Cat: 0}},
+		},
+		{
+			name: "budget",
+			problem: Problem{
+				Scores:   [][]float64{{1, 0.5}, {1, 0.5}},
+				Salaries: []int64{100, 10},
+				Budget:   50,
+			},
+			greedy:    []Pair{{Mission: 0, Cat: 1}},
+			hungarian: []Pair{{Mission: 1, Cat: 1}},
+		},
+		{
+			// Hungarian drops the best paid cat of the best assignment, when
+			// the two cheap cats would cover both missions.
+			name: "hungarian is not optimal with a budget",
+			problem: Problem{
+				Scores:   [][]float64{{1, 0.1, f}, {0.5, f, 0.1}},
+				Salaries: []int64{100, 10, 10},
+				Budget:   20,
+			},
+			greedy:    []Pair{{Mission: 0, Cat: 1}, {Mission: 1, Cat: 2}},
+			hungarian: []Pair{{Mission: 1, Cat: 2}},
+		},
+		{
+			name: "all forbidden",
+			problem: Problem{
+				Scores:   [][]float64{{f, f}},
+				Salaries: []int64{10, 10},
+				Budget:   NoBudget,
+			},
+			greedy:    []Pair{},
+			hungarian: []Pair{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.greedy, Greedy{}.Solve(tt.problem))
+			assert.Equal(t, tt.hungarian, Hungarian{}.Solve(tt.problem))
+		})
+	}
+}
+
+func TestHungarian_Optimal(t *testing.T) {
+	scores := [][]float64{
+		{0.9, 0.8, 0.1, 0.3},
+		{0.7, 0.2, 0.6, 0.4},
+		{0.8, 0.9, 0.5, 0.2},
+	}
+
+	pairs := Hungarian{}.Solve(Problem{Scores: scores, Salaries: []int64{1, 1, 1, 1}, Budget: NoBudget})
+
+	// 0.9 + 0.6 + 0.9 is the best of all the assignments.
+	assert.Equal(t, []Pair{{Mission: 0, Cat: 0}, {Mission: 1, Cat: 2}, {Mission: 2, Cat: 1}}, pairs)
+}
@@ -0,0 +1,84 @@
+package blobs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store is implemented by the LocalStore and the S3Store.
+type store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// testStore runs the tests every store must pass.
+func testStore(t *testing.T, s store) {
+	ctx := context.Background()
+
+	read := func(t *testing.T, key string) string {
+		t.Helper()
+
+		r, err := s.Get(ctx, key)
+		require.NoError(t, err)
+		defer r.Close()
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("PutGet", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, "targets/2/photo", strings.NewReader("jpeg data"), 9, "image/jpeg"))
+		assert.Equal(t, "jpeg data", read(t, "targets/2/photo"))
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := s.Get(ctx, "targets/2/missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, "targets/3/report", strings.NewReader("%PDF-1.4"), 8, "application/pdf"))
+		require.NoError(t, s.Delete(ctx, "targets/3/report"))
+
+		_, err := s.Get(ctx, "targets/3/report")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		assert.NoError(t, s.Delete(ctx, "targets/3/report"), "deleting a missing blob isn't an error")
+	})
+}
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(filepath.Join(dir, "blobs"))
+	require.NoError(t, err)
+
+	testStore(t, s)
+
+	t.Run("ShortWrite", func(t *testing.T) {
+		err := s.Put(context.Background(), "targets/4/short", strings.NewReader("abc"), 4, "text/plain")
+		assert.EqualError(t, err, "blob targets/4/short: wrote 3 bytes, expected 4")
+
+		_, err = s.Get(context.Background(), "targets/4/short")
+		assert.ErrorIs(t, err, ErrNotFound, "a failed upload leaves no blob")
+
+		entries, err := os.ReadDir(filepath.Join(dir, "blobs", "targets", "4"))
+		require.NoError(t, err)
+		assert.Empty(t, entries, "the temporary file is removed")
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		for _, key := range []string{"../escape", "/abs", "targets/../../escape", `targets\2`, ""} {
+			_, err := s.Get(context.Background(), key)
+			assert.ErrorContains(t, err, "invalid blob key", key)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+// Package currency validates ISO 4217 currencies and converts the amounts in
+// their minor units, like cents, with exchange rates.
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/currency"
+)
+
+// ErrUnknown is returned for a code of no ISO 4217 currency.
+var ErrUnknown = errors.New("unknown ISO 4217 currency")
+
+// NoRateError is returned for a conversion from or to a currency without an
+// exchange rate.
+type NoRateError struct {
+	Currency string
+}
+
+func (e *NoRateError) Error() string {
+	return "no exchange rate for " + e.Currency
+}
+
+// Parse returns the upper case code of the ISO 4217 currency of the code.
+func Parse(code string) (string, error) {
+	u, err := currency.ParseISO(code)
+	if err != nil {
+		return "", ErrUnknown
+	}
+	return u.String(), nil
+}
+
+// Digits returns the digits of the minor unit of the currency, 2 for USD and
+// 0 for JPY.
+func Digits(code string) int {
+	u, err := currency.ParseISO(code)
+	if err != nil {
+		return 2
+	}
+	scale, _ := currency.Standard.Rounding(u)
+	return scale
+}
+
+// MinorUnits converts the amount in major units of the currency to its minor
+// units, 12 USD to 1200.
+func MinorUnits(amount int64, code string) int64 {
+	return amount * int64(math.Pow10(Digits(code)))
+}
+
+// MajorUnits converts the amount in minor units of the currency to whole major
+// units, truncated: 1250 USD to 12.
+func MajorUnits(amount int64, code string) int64 {
+	return amount / int64(math.Pow10(Digits(code)))
+}
+
+// Format formats the amount in minor units of the currency, like "12.50 USD".
+func Format(amount int64, code string) string {
+	digits := Digits(code)
+	return strconv.FormatFloat(float64(amount)/math.Pow10(digits), 'f', digits, 64) + " " + code
+}
+
+// Rates are the exchange rates to a base currency: the value of a major unit
+// of each currency in the base currency. The base currency has a rate of 1.
+type Rates struct {
+	base  string
+	rates map[string]float64
+}
+
+// NewRates returns the rates to the base currency.
+func NewRates(base string, rates map[string]float64) Rates {
+	return Rates{base: base, rates: rates}
+}
+
+// Base returns the base currency of the rates.
+func (r Rates) Base() string {
+	return r.base
+}
+
+// Rate returns the rate of the currency and whether it has one.
+func (r Rates) Rate(code string) (float64, bool) {
+	if code == r.base {
+		return 1, true
+	}
+	rate, ok := r.rates[code]
+	return rate, ok
+}
+
+// Convert converts the amount in minor units of a currency to the minor
+// units of another, rounded to the nearest.
+func (r Rates) Convert(amount int64, from, to string) (int64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, ok := r.Rate(from)
+	if !ok {
+		return 0, &NoRateError{Currency: from}
+	}
+	toRate, ok := r.Rate(to)
+	if !ok {
+		return 0, &NoRateError{Currency: to}
+	}
+
+	major := float64(amount) / math.Pow10(Digits(from)) * fromRate / toRate
+	return int64(math.Round(major * math.Pow10(Digits(to)))), nil
+}
+
+// ReadRates reads a CSV file of exchange rates, with the columns currency and
+// rate, in any order.
+func ReadRates(r io.Reader) (map[string]float64, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing CSV header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	currencyCol, rateCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "currency":
+			currencyCol = i
+		case "rate":
+			rateCol = i
+		default:
+			return nil, fmt.Errorf("unknown column: %s", h)
+		}
+	}
+	if currencyCol < 0 || rateCol < 0 {
+		return nil, errors.New("the columns must be currency and rate")
+	}
+
+	rates := make(map[string]float64)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		code, err := Parse(strings.TrimSpace(record[currencyCol]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: %s", line, err, record[currencyCol])
+		}
+		if _, ok := rates[code]; ok {
+			return nil, fmt.Errorf("line %d: duplicate currency: %s", line, code)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[rateCol]), 64)
+		if err != nil || !ValidRate(rate) {
+			return nil, fmt.Errorf("line %d: rate must be a positive number", line)
+		}
+		rates[code] = rate
+	}
+}
+
+// ValidRate returns whether the rate is a positive finite number.
+func ValidRate(rate float64) bool {
+	return rate > 0 && !math.IsInf(rate, 1)
+}
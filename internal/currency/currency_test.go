@@ -0,0 +1,88 @@
+package currency
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	code, err := Parse("eur")
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", code)
+
+	for _, code := range []string{"", "EU", "EURO", "ABC"} {
+		_, err := Parse(code)
+		assert.ErrorIs(t, err, ErrUnknown, code)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "12.50 USD", Format(1250, "USD"))
+	assert.Equal(t, "-0.05 EUR", Format(-5, "EUR"))
+	assert.Equal(t, "1250 JPY", Format(1250, "JPY"))
+	assert.Equal(t, "1.250 BHD", Format(1250, "BHD"))
+}
+
+func TestUnits(t *testing.T) {
+	assert.Equal(t, int64(1200), MinorUnits(12, "USD"))
+	assert.Equal(t, int64(12), MinorUnits(12, "JPY"))
+	assert.Equal(t, int64(12000), MinorUnits(12, "BHD"))
+
+	assert.Equal(t, int64(12), MajorUnits(1250, "USD"))
+	assert.Equal(t, int64(1250), MajorUnits(1250, "JPY"))
+	assert.Equal(t, int64(1), MajorUnits(1250, "BHD"))
+}
+
+func TestConvert(t *testing.T) {
+	rates := NewRates("USD", map[string]float64{"EUR": 1.25, "JPY": 0.01})
+
+	tests := []struct {
+		amount   int64
+		from, to string
+		want     int64
+	}{
+		{1000, "EUR", "EUR", 1000},
+		{1000, "EUR", "USD", 1250},
+		{1250, "USD", "EUR", 1000},
+		// 10.00 EUR is 12.50 USD, 1250 JPY.
+		{1000, "EUR", "JPY", 1250},
+		{1250, "JPY", "EUR", 1000},
+		{1, "USD", "EUR", 1},
+	}
+	for _, tt := range tests {
+		got, err := rates.Convert(tt.amount, tt.from, tt.to)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%d %s to %s", tt.amount, tt.from, tt.to)
+	}
+
+	_, err := rates.Convert(1000, "GBP", "USD")
+	var noRate *NoRateError
+	require.ErrorAs(t, err, &noRate)
+	assert.Equal(t, "no exchange rate for GBP", err.Error())
+}
+
+func TestReadRates(t *testing.T) {
+	rates, err := ReadRates(strings.NewReader("rate,currency\n1.08,eur\n0.0067, JPY\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"EUR": 1.08, "JPY": 0.0067}, rates)
+
+	tests := []struct {
+		csv     string
+		wantErr string
+	}{
+		{"", "missing CSV header"},
+		{"currency,value\n", "unknown column: value"},
+		{"currency\n", "the columns must be currency and rate"},
+		{"currency,rate\nEUR,1\nEURO,1\n", "line 3: unknown ISO 4217 currency: EURO"},
+		{"currency,rate\nEUR,1\neur,2\n", "line 3: duplicate currency: EUR"},
+		{"currency,rate\nEUR,0\n", "line 2: rate must be a positive number"},
+		{"currency,rate\nEUR,much\n", "line 2: rate must be a positive number"},
+	}
+	for _, tt := range tests {
+		_, err := ReadRates(strings.NewReader(tt.csv))
+		assert.EqualError(t, err, tt.wantErr, tt.csv)
+	}
+}
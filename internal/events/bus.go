@@ -0,0 +1,226 @@
+package events
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types published by the service.
+const (
+	TargetCompleted    = "target.completed"
+	TargetNotesUpdated = "target.notes_updated"
+	CatAssigned        = "mission.cat_assigned"
+	MissionCompleted   = "mission.completed"
+)
+
+// subscriptionBuffer is the number of events a subscriber may lag behind
+// before it is dropped.
+const subscriptionBuffer = 64
+
+var (
+	// ErrInvalidEventID is returned for a malformed last event ID.
+	ErrInvalidEventID = errors.New("invalid event id")
+	// ErrStaleEventID is returned for a last event ID of another bus, e.g.
+	// from before a restart, or followed by events no longer buffered. Its
+	// events are lost, the subscriber must resync.
+	ErrStaleEventID = errors.New("stale event id")
+)
+
+type Event struct {
+	ID uint64 `json:"id"`
+	// Epoch identifies the bus the event was published on, as IDs restart
+	// at 1 with every bus.
+	Epoch     string    `json:"-"`
+	Type      string    `json:"type"`
+	MissionID int32     `json:"mission_id"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Bus is an in-process event bus.
+//
+// The last published events are kept in a bounded replay buffer, so
+// subscribers can resume from a known event ID.
+type Bus struct {
+	mu     sync.Mutex
+	epoch  string
+	nextID uint64
+	buffer []Event
+	size   int
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription receives events matching its mission filter.
+//
+// C is closed when the subscription is closed, the bus is closed or the
+// subscriber falls too far behind.
+type Subscription struct {
+	C <-chan Event
+
+	ch        chan Event
+	missionID int32
+	bus       *Bus
+}
+
+// NewBus returns a new Bus keeping up to size events for replay.
+func NewBus(size int) *Bus {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Bus{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		nextID: 1,
+		buffer: make([]Event, 0, size),
+		size:   size,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns an ID to the event and delivers it to all matching subscribers.
+func (b *Bus) Publish(eventType string, missionID int32, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	e := Event{
+		ID:        b.nextID,
+		Epoch:     b.epoch,
+		Type:      eventType,
+		MissionID: missionID,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+	b.nextID++
+
+	// Drop the oldest event once the buffer is full.
+	if len(b.buffer) == b.size {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:len(b.buffer)-1]
+	}
+	b.buffer = append(b.buffer, e)
+
+	for sub := range b.subs {
+		if !sub.matches(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			// Slow subscriber. Drop it, it can resume with the last event ID it got.
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the given mission (0 means all missions).
+//
+// Returns buffered events published after lastEventID, so no event is lost
+// between the replay and the live stream. An empty lastEventID replays
+// nothing. Returns ErrStaleEventID if lastEventID is of another bus or if
+// events after it were dropped from the buffer.
+func (b *Bus) Subscribe(missionID int32, lastEventID string) (*Subscription, []Event, error) {
+	var lastID uint64
+	if lastEventID != "" {
+		epoch, id, err := ParseEventID(lastEventID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if epoch != b.epoch {
+			return nil, nil, ErrStaleEventID
+		}
+		lastID = id
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{
+		C:         ch,
+		ch:        ch,
+		missionID: missionID,
+		bus:       b,
+	}
+
+	if b.closed {
+		close(ch)
+		return sub, nil, nil
+	}
+
+	var replay []Event
+	if lastID > 0 {
+		if len(b.buffer) > 0 && lastID+1 < b.buffer[0].ID {
+			return nil, nil, ErrStaleEventID
+		}
+		for _, e := range b.buffer {
+			if e.ID > lastID && sub.matches(e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	b.subs[sub] = struct{}{}
+
+	return sub, replay, nil
+}
+
+// Close closes all subscriptions and stops accepting new events.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// StreamID returns the ID of the event in a stream, made of its epoch and
+// its ID.
+func (e Event) StreamID() string {
+	return e.Epoch + "-" + strconv.FormatUint(e.ID, 10)
+}
+
+// ParseEventID parses a stream ID into its epoch and event ID.
+func ParseEventID(s string) (string, uint64, error) {
+	epoch, id, ok := strings.Cut(s, "-")
+	if !ok || epoch == "" {
+		return "", 0, ErrInvalidEventID
+	}
+
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidEventID
+	}
+
+	return epoch, n, nil
+}
+
+// remove unregisters the subscription. Caller must hold the lock.
+func (b *Bus) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
+
+// Close unregisters the subscription.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.remove(s)
+}
+
+func (s *Subscription) matches(e Event) bool {
+	return s.missionID == 0 || s.missionID == e.MissionID
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_PublishToMatchingSubscribers(t *testing.T) {
+	bus := NewBus(10)
+
+	all, _, err := bus.Subscribe(0, "")
+	require.NoError(t, err)
+	mission, _, err := bus.Subscribe(1, "")
+	require.NoError(t, err)
+
+	bus.Publish(TargetCompleted, 1, nil)
+	bus.Publish(MissionCompleted, 2, nil)
+
+	assert.Equal(t, uint64(1), (<-all.C).ID)
+	assert.Equal(t, uint64(2), (<-all.C).ID)
+
+	e := <-mission.C
+	assert.Equal(t, TargetCompleted, e.Type)
+	assert.Len(t, mission.C, 0)
+}
+
+func TestBus_ReplayAfterLastEventID(t *testing.T) {
+	bus := NewBus(3)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(TargetNotesUpdated, 1, nil)
+	}
+
+	// Only the last 3 events are kept, the 2nd is lost.
+	_, _, err := bus.Subscribe(0, Event{ID: 1, Epoch: bus.epoch}.StreamID())
+	assert.ErrorIs(t, err, ErrStaleEventID)
+
+	_, replay, err := bus.Subscribe(0, Event{ID: 2, Epoch: bus.epoch}.StreamID())
+	require.NoError(t, err)
+	assert.Len(t, replay, 3)
+	assert.Equal(t, uint64(3), replay[0].ID)
+
+	_, replay, err = bus.Subscribe(1, replay[1].StreamID())
+	require.NoError(t, err)
+	assert.Len(t, replay, 1)
+	assert.Equal(t, uint64(5), replay[0].ID)
+
+	_, replay, err = bus.Subscribe(2, Event{ID: 2, Epoch: bus.epoch}.StreamID())
+	require.NoError(t, err)
+	assert.Empty(t, replay)
+}
+
+func TestBus_RejectsStaleEventID(t *testing.T) {
+	bus := NewBus(3)
+	bus.Publish(TargetNotesUpdated, 1, nil)
+
+	// The IDs of a previous bus restart at 1 too.
+	_, _, err := bus.Subscribe(0, "previous-1")
+	assert.ErrorIs(t, err, ErrStaleEventID)
+
+	for _, id := range []string{"1", "-1", "previous-first"} {
+		_, _, err = bus.Subscribe(0, id)
+		assert.ErrorIs(t, err, ErrInvalidEventID, id)
+	}
+}
+
+func TestBus_DropsSlowSubscriber(t *testing.T) {
+	bus := NewBus(10)
+
+	sub, _, err := bus.Subscribe(0, "")
+	require.NoError(t, err)
+	for i := 0; i < subscriptionBuffer+1; i++ {
+		bus.Publish(CatAssigned, 1, nil)
+	}
+
+	count := 0
+	for range sub.C {
+		count++
+	}
+	assert.Equal(t, subscriptionBuffer, count)
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus(10)
+
+	sub, _, err := bus.Subscribe(0, "")
+	require.NoError(t, err)
+	bus.Close()
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	// Closing a subscription twice is a no-op.
+	sub.Close()
+}
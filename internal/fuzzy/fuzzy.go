@@ -0,0 +1,47 @@
+// Package fuzzy compares names by their trigrams, like the pg_trgm extension
+// of Postgres.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Similarity returns the similarity of two strings from 0 to 1, the share of
+// the trigrams they have in common. The strings are compared case-insensitively
+// word by word, ignoring the characters other than letters and digits, so
+// "Ivan Ivanov" and "ivanov, ivan" are alike.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// trigrams returns the set of the trigrams of the words of s. Each word is
+// padded with two spaces in front and one behind, so short words have
+// trigrams too and the starts of the words weigh more.
+func trigrams(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	res := make(map[string]bool)
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			res[string(padded[i:i+3])] = true
+		}
+	}
+
+	return res
+}
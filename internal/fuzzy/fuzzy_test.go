@@ -0,0 +1,31 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Ivan", "Ivan", 1},
+		{"Ivan", "IVAN", 1},
+		// The order of the words and the punctuation don't matter.
+		{"Ivan Ivanov", "ivanov, ivan", 1},
+		// The example of the pg_trgm documentation.
+		{"word", "two words", 4.0 / 11},
+		{"Ivan", "Olga", 0},
+		{"", "Ivan", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, Similarity(tt.a, tt.b), 1e-9, "%q and %q", tt.a, tt.b)
+	}
+
+	// Typos keep most of the trigrams.
+	assert.Greater(t, Similarity("Ivan Petrenko", "Ivan Petrenco"), 0.6)
+	assert.Less(t, Similarity("Ivan Petrenko", "Olga Petrenko"), Similarity("Ivan Petrenko", "Ivan Petrenco"))
+}
@@ -0,0 +1,79 @@
+// Package geo computes the distances between coordinates and writes the
+// targets of a mission as GeoJSON.
+package geo
+
+import (
+	"math"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// EarthRadiusKm is the mean radius of the Earth.
+const EarthRadiusKm = 6371.0088
+
+// ValidLatitude reports whether the latitude is within -90 and 90 degrees.
+func ValidLatitude(lat float64) bool {
+	return lat >= -90 && lat <= 90
+}
+
+// ValidLongitude reports whether the longitude is within -180 and 180 degrees.
+func ValidLongitude(lng float64) bool {
+	return lng >= -180 && lng <= 180
+}
+
+// Distance returns the haversine distance in kilometers between two points of
+// a sphere of the radius of the Earth, in degrees.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	sinLat := math.Sin((lat2 - lat1) * rad / 2)
+	sinLng := math.Sin((lng2 - lng1) * rad / 2)
+	h := sinLat*sinLat + math.Cos(lat1*rad)*math.Cos(lat2*rad)*sinLng*sinLng
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// FeatureCollection is a GeoJSON feature collection, RFC 7946.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature.
+type Feature struct {
+	Type       string         `json:"type"`
+	ID         int32          `json:"id"`
+	Geometry   Point          `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Point is a GeoJSON point. Its coordinates are the longitude then the
+// latitude.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// MissionTargets returns the targets of the mission with coordinates as
+// point features. The targets without coordinates are left out.
+func MissionTargets(m models.Mission) FeatureCollection {
+	fc := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(m.Targets))}
+	for _, t := range m.Targets {
+		if t.Latitude == nil || t.Longitude == nil {
+			continue
+		}
+		fc.Features = append(fc.Features, Feature{
+			Type:     "Feature",
+			ID:       t.ID,
+			Geometry: Point{Type: "Point", Coordinates: [2]float64{*t.Longitude, *t.Latitude}},
+			Properties: map[string]any{
+				"mission":   m.ID,
+				"name":      t.Name,
+				"country":   t.Country,
+				"address":   t.Address,
+				"completed": t.Completed,
+			},
+		})
+	}
+
+	return fc
+}
@@ -0,0 +1,56 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		lat1, lng1, lat2, lng2 float64
+		want                   float64
+	}{
+		{50.4501, 30.5234, 50.4501, 30.5234, 0},
+		// Kyiv to Lviv.
+		{50.4501, 30.5234, 49.8397, 24.0297, 467.5},
+		// A degree of latitude.
+		{0, 0, 1, 0, 111.2},
+		// Across the antimeridian.
+		{0, 179.5, 0, -179.5, 111.2},
+		// Antipodes.
+		{0, 0, 0, 180, 20015.1},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, Distance(tt.lat1, tt.lng1, tt.lat2, tt.lng2), 0.1)
+	}
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, ValidLatitude(-90))
+	assert.True(t, ValidLatitude(90))
+	assert.False(t, ValidLatitude(90.01))
+	assert.True(t, ValidLongitude(-180))
+	assert.False(t, ValidLongitude(180.01))
+}
+
+func TestMissionTargets(t *testing.T) {
+	lat, lng := 50.4501, 30.5234
+	fc := MissionTargets(models.Mission{ID: 1, Targets: []models.Target{
+		{ID: 2, Name: "Ivan", Country: "UA", Latitude: &lat, Longitude: &lng, Address: "Kyiv"},
+		{ID: 3, Name: "Olga", Country: "UA"},
+	}})
+
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	require.Len(t, fc.Features, 1)
+	assert.Equal(t, int32(2), fc.Features[0].ID)
+	assert.Equal(t, [2]float64{lng, lat}, fc.Features[0].Geometry.Coordinates, "GeoJSON points are longitude first")
+	assert.Equal(t, "Kyiv", fc.Features[0].Properties["address"])
+
+	// A mission without coordinates is an empty collection, not null.
+	fc = MissionTargets(models.Mission{ID: 1, Targets: []models.Target{{ID: 3}}})
+	assert.NotNil(t, fc.Features)
+	assert.Empty(t, fc.Features)
+}
@@ -0,0 +1,289 @@
+// Package imports reads the files of the bulk imports and writes their error reports.
+//
+// The files are CSV with a header, or NDJSON with an object per line. The
+// problems of a row, like a malformed number, are kept in the row to be
+// reported with the validation errors; only a malformed file is an error.
+package imports
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// Format is the format of an import file.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// ErrUnsupportedFormat is returned for a content type of no known format.
+var ErrUnsupportedFormat = errors.New("unsupported content type, expected text/csv or application/x-ndjson")
+
+// maxLineLength is the maximum length of an NDJSON line.
+const maxLineLength = 1 << 20
+
+// FormatOf returns the format of the content type.
+func FormatOf(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+
+	switch mediaType {
+	case "text/csv":
+		return CSV, nil
+	case "application/x-ndjson", "application/ndjson", "application/json":
+		return NDJSON, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+var (
+	catColumns         = []string{"name", "breed", "years_of_experience", "salary"}
+	optionalCatColumns = []string{"salary_currency"}
+)
+
+// ReadCats reads the cats of an import file.
+//
+// The CSV columns are name, breed, years_of_experience, salary and the
+// optional salary_currency, in any order.
+func ReadCats(r io.Reader, f Format) ([]models.CatImportRow, error) {
+	var rows []models.CatImportRow
+
+	switch f {
+	case CSV:
+		err := readCSV(r, catColumns, optionalCatColumns, func(line int, record map[string]string) {
+			row := models.CatImportRow{
+				Line: line,
+				Cat: models.CreateCatRequest{
+					Name:           record["name"],
+					Breed:          record["breed"],
+					SalaryCurrency: record["salary_currency"],
+				},
+			}
+			row.Cat.YearsOfExperience, row.Errors = parseInt32(record, "years_of_experience", row.Errors)
+			row.Cat.Salary, row.Errors = parseInt32(record, "salary", row.Errors)
+
+			rows = append(rows, row)
+		})
+		if err != nil {
+			return nil, err
+		}
+	case NDJSON:
+		err := readNDJSON(r, func(line int, data []byte) {
+			row := models.CatImportRow{Line: line}
+			if err := json.Unmarshal(data, &row.Cat); err != nil {
+				row.Errors = append(row.Errors, "invalid JSON")
+			}
+
+			rows = append(rows, row)
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	return rows, nil
+}
+
+var (
+	missionColumns         = []string{"mission", "name", "country", "notes"}
+	optionalMissionColumns = []string{"latitude", "longitude", "address"}
+)
+
+// ReadMissions reads the missions of an import file.
+//
+// A CSV record is a target, with the columns mission, name, country, notes
+// and the optional latitude, longitude and address. The records of the same mission key are the targets of a mission,
+// which is reported at the line of its first target.
+func ReadMissions(r io.Reader, f Format) ([]models.MissionImportRow, error) {
+	var rows []models.MissionImportRow
+
+	switch f {
+	case CSV:
+		missions := make(map[string]int)
+		err := readCSV(r, missionColumns, optionalMissionColumns, func(line int, record map[string]string) {
+			key := record["mission"]
+			i, ok := missions[key]
+			if !ok {
+				i = len(rows)
+				missions[key] = i
+				rows = append(rows, models.MissionImportRow{Line: line})
+				if key == "" {
+					rows[i].Errors = append(rows[i].Errors, "missing field: mission")
+				}
+			}
+
+			target := models.CreateTargetRequest{
+				Name:    record["name"],
+				Country: record["country"],
+				Notes:   record["notes"],
+				Address: record["address"],
+			}
+			target.Latitude, rows[i].Errors = parseFloat64(record, "latitude", rows[i].Errors)
+			target.Longitude, rows[i].Errors = parseFloat64(record, "longitude", rows[i].Errors)
+
+			rows[i].Mission.Targets = append(rows[i].Mission.Targets, target)
+		})
+		if err != nil {
+			return nil, err
+		}
+	case NDJSON:
+		err := readNDJSON(r, func(line int, data []byte) {
+			row := models.MissionImportRow{Line: line}
+			if err := json.Unmarshal(data, &row.Mission); err != nil {
+				row.Errors = append(row.Errors, "invalid JSON")
+			}
+
+			rows = append(rows, row)
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	return rows, nil
+}
+
+// readCSV calls fn with every record of the CSV, keyed by the columns of
+// the header. The header must have all the columns, and may have the
+// optional ones, and only them.
+func readCSV(r io.Reader, columns, optional []string, fn func(line int, record map[string]string)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return errors.New("missing CSV header")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if !slices.Contains(columns, h) && !slices.Contains(optional, h) {
+			return fmt.Errorf("unknown column: %s", h)
+		}
+		if _, ok := index[h]; ok {
+			return fmt.Errorf("duplicate column: %s", h)
+		}
+		index[h] = i
+	}
+	for _, c := range columns {
+		if _, ok := index[c]; !ok {
+			return fmt.Errorf("missing column: %s", c)
+		}
+	}
+
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		if len(fields) != len(header) {
+			return fmt.Errorf("invalid CSV: line %d: wrong number of fields", line)
+		}
+
+		record := make(map[string]string, len(columns))
+		for c, i := range index {
+			record[c] = strings.TrimSpace(fields[i])
+		}
+
+		fn(line, record)
+	}
+}
+
+// readNDJSON calls fn with every non-blank line of the NDJSON.
+func readNDJSON(r io.Reader, fn func(line int, data []byte)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	for line := 1; sc.Scan(); line++ {
+		data := sc.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		fn(line, data)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("invalid NDJSON: %w", err)
+	}
+
+	return nil
+}
+
+// parseInt32 parses the field of the record, appending the problem to errs.
+func parseInt32(record map[string]string, field string, errs []string) (int32, []string) {
+	if record[field] == "" {
+		return 0, errs
+	}
+
+	v, err := strconv.ParseInt(record[field], 10, 32)
+	if err != nil {
+		return 0, append(errs, "invalid value for field: "+field)
+	}
+
+	return int32(v), errs
+}
+
+// parseFloat64 parses the optional field of the record, appending the
+// problem to errs. It is nil for an empty or missing field.
+func parseFloat64(record map[string]string, field string, errs []string) (*float64, []string) {
+	if record[field] == "" {
+		return nil, errs
+	}
+
+	v, err := strconv.ParseFloat(record[field], 64)
+	if err != nil {
+		return nil, append(errs, "invalid value for field: "+field)
+	}
+
+	return &v, errs
+}
+
+// WriteReport writes the errors of the invalid rows of the result as a CSV
+// of line and error, a record per error.
+func WriteReport(w io.Writer, res models.ImportResult) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"line", "error"}); err != nil {
+		return err
+	}
+	for _, row := range res.Rows {
+		for _, e := range row.Errors {
+			if err := cw.Write([]string{strconv.Itoa(row.Line), e}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,141 @@
+package imports
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        Format
+		wantErr     bool
+	}{
+		{contentType: "text/csv", want: CSV},
+		{contentType: "text/csv; charset=utf-8", want: CSV},
+		{contentType: "application/x-ndjson", want: NDJSON},
+		{contentType: "application/json", want: NDJSON},
+		{contentType: "application/xml", wantErr: true},
+		{contentType: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, err := FormatOf(tt.contentType)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsupportedFormat)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadCats_CSV(t *testing.T) {
+	data := "salary, Name,breed,years_of_experience,salary_currency\n" +
+		"100,Tom,Abyssinian,3,eur\n" +
+		"many,\"Kitty\nthe Second\",Bengal,x,\n" +
+		"90,Leo,Bengal,1,\n"
+
+	rows, err := ReadCats(strings.NewReader(data), CSV)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CatImportRow{
+		{Line: 2, Cat: models.CreateCatRequest{Name: "Tom", Breed: "Abyssinian", YearsOfExperience: 3, Salary: 100, SalaryCurrency: "eur"}},
+		{Line: 3, Cat: models.CreateCatRequest{Name: "Kitty\nthe Second", Breed: "Bengal"}, Errors: []string{
+			"invalid value for field: years_of_experience",
+			"invalid value for field: salary",
+		}},
+		// The quoted name spans two lines.
+		{Line: 5, Cat: models.CreateCatRequest{Name: "Leo", Breed: "Bengal", YearsOfExperience: 1, Salary: 90}},
+	}, rows)
+}
+
+func TestReadCats_CSVHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "empty", data: "", wantErr: "missing CSV header"},
+		{name: "missing column", data: "name,breed,salary\n", wantErr: "missing column: years_of_experience"},
+		{name: "unknown column", data: "name,breed,salary,years_of_experience,color\n", wantErr: "unknown column: color"},
+		{name: "duplicate column", data: "name,name,breed,salary,years_of_experience\n", wantErr: "duplicate column: name"},
+		{name: "wrong number of fields", data: "name,breed,salary,years_of_experience\nTom,Bengal\n", wantErr: "line 2: wrong number of fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCats(strings.NewReader(tt.data), CSV)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReadCats_NDJSON(t *testing.T) {
+	data := `{"name":"Tom","breed":"Abyssinian","years_of_experience":3,"salary":100}` + "\n\n" +
+		`{"name":"Kitty",` + "\n"
+
+	rows, err := ReadCats(strings.NewReader(data), NDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CatImportRow{
+		{Line: 1, Cat: models.CreateCatRequest{Name: "Tom", Breed: "Abyssinian", YearsOfExperience: 3, Salary: 100}},
+		{Line: 3, Errors: []string{"invalid JSON"}},
+	}, rows)
+}
+
+func TestReadMissions_CSV(t *testing.T) {
+	data := "mission,name,country,notes\n" +
+		"a,Ivan,UA,Notes of Ivan\n" +
+		"b,Anna,DE,Notes of Anna\n" +
+		"a,Olga,PL,Notes of Olga\n" +
+		",Hans,DE,Notes of Hans\n"
+
+	rows, err := ReadMissions(strings.NewReader(data), CSV)
+	require.NoError(t, err)
+	assert.Equal(t, []models.MissionImportRow{
+		{Line: 2, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Ivan", Country: "UA", Notes: "Notes of Ivan"},
+			{Name: "Olga", Country: "PL", Notes: "Notes of Olga"},
+		}}},
+		{Line: 3, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Anna", Country: "DE", Notes: "Notes of Anna"},
+		}}},
+		{Line: 5, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Hans", Country: "DE", Notes: "Notes of Hans"},
+		}}, Errors: []string{"missing field: mission"}},
+	}, rows)
+}
+
+func TestReadMissions_CSVLocation(t *testing.T) {
+	data := "mission,name,country,notes,latitude,longitude,address\n" +
+		"a,Ivan,UA,Notes of Ivan,50.4501,30.5234,\"Khreshchatyk 1, Kyiv\"\n" +
+		"a,Olga,PL,Notes of Olga,,,\n" +
+		"b,Anna,DE,Notes of Anna,north,13.4,\n"
+
+	rows, err := ReadMissions(strings.NewReader(data), CSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	lat, lng := 50.4501, 30.5234
+	assert.Equal(t, []models.CreateTargetRequest{
+		{Name: "Ivan", Country: "UA", Notes: "Notes of Ivan", Latitude: &lat, Longitude: &lng, Address: "Khreshchatyk 1, Kyiv"},
+		{Name: "Olga", Country: "PL", Notes: "Notes of Olga"},
+	}, rows[0].Mission.Targets)
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, []string{"invalid value for field: latitude"}, rows[1].Errors)
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteReport(&buf, models.ImportResult{Rows: []models.ImportRowResult{
+		{Line: 2, Status: models.ImportRowCreated, ID: 1},
+		{Line: 3, Status: models.ImportRowInvalid, Errors: []string{"Unknown breed", "missing field: name"}},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "line,error\n3,Unknown breed\n3,missing field: name\n", buf.String())
+}
@@ -0,0 +1,190 @@
+// Package markdown renders the Markdown notes of the targets to sanitized
+// HTML.
+//
+// Notes may mention cats and other targets as @cat:12 or @target:7. The
+// mentions are rendered as links when they are resolved, and as the text as
+// written otherwise. Mentions in code spans and blocks are left alone.
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// The kinds of the mentions.
+const (
+	KindCat    = "cat"
+	KindTarget = "target"
+)
+
+// Mention is a reference to a cat or a target in the notes.
+type Mention struct {
+	Kind string
+	ID   int32
+}
+
+// Link is a resolved mention.
+type Link struct {
+	Href string
+	Text string
+}
+
+// mentionPattern matches a mention at the start of the text.
+var mentionPattern = regexp.MustCompile(`^@(cat|target):([0-9]+)`)
+
+// policy sanitizes the rendered HTML. Raw HTML isn't rendered in the first
+// place, so it is a second line of defence against unsafe links and
+// attributes.
+var policy = newPolicy()
+
+// newPolicy returns the policy of user generated content, allowing the
+// checkboxes of the task lists and the class of the mentions too.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^mention$`)).OnElements("a")
+	return p
+}
+
+// Mentions returns the mentions of the notes in order, without duplicates.
+func Mentions(src string) []Mention {
+	doc := newMarkdown(nil).Parser().Parse(text.NewReader([]byte(src)))
+
+	var res []Mention
+	seen := make(map[Mention]bool)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if m, ok := n.(*mentionNode); ok && entering && !seen[m.Mention] {
+			seen[m.Mention] = true
+			res = append(res, m.Mention)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return res
+}
+
+// Render renders the notes to sanitized HTML, with the mentions of links as
+// links.
+func Render(src string, links map[Mention]Link) (string, error) {
+	var buf bytes.Buffer
+	if err := newMarkdown(links).Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
+
+// newMarkdown returns a GitHub-flavoured Markdown converter resolving the
+// mentions to the links. Raw HTML is omitted.
+func newMarkdown(links map[Mention]Link) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(
+			extension.Table,
+			extension.Strikethrough,
+			extension.TaskList,
+			&mentions{links: links},
+		),
+	)
+}
+
+var kindMention = ast.NewNodeKind("Mention")
+
+// mentionNode is a mention in the notes, with its link if it is resolved.
+type mentionNode struct {
+	ast.BaseInline
+	Mention
+	// source is the mention as written.
+	source []byte
+	link   *Link
+}
+
+func (n *mentionNode) Kind() ast.NodeKind {
+	return kindMention
+}
+
+func (n *mentionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Kind": n.Mention.Kind, "ID": strconv.Itoa(int(n.ID))}, nil)
+}
+
+// mentions is the goldmark extension of the mentions.
+type mentions struct {
+	links map[Mention]Link
+}
+
+func (e *mentions) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(e, 500),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(e, 500),
+	))
+}
+
+func (e *mentions) Trigger() []byte {
+	return []byte{'@'}
+}
+
+// Parse parses a mention, which must not follow a letter or a digit so
+// e-mail addresses aren't mentions.
+func (e *mentions) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if prev := block.PrecendingCharacter(); unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+		return nil
+	}
+
+	line, _ := block.PeekLine()
+	match := mentionPattern.FindSubmatch(line)
+	if match == nil {
+		return nil
+	}
+	id, err := strconv.ParseInt(string(match[2]), 10, 32)
+	if err != nil {
+		return nil
+	}
+	block.Advance(len(match[0]))
+
+	n := &mentionNode{
+		Mention: Mention{Kind: string(match[1]), ID: int32(id)},
+		source:  match[0],
+	}
+	if link, ok := e.links[n.Mention]; ok {
+		n.link = &link
+	}
+
+	return n
+}
+
+func (e *mentions) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMention, e.renderMention)
+}
+
+func (e *mentions) renderMention(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*mentionNode)
+	if n.link == nil {
+		_, _ = w.Write(util.EscapeHTML(n.source))
+		return ast.WalkSkipChildren, nil
+	}
+
+	_, _ = w.WriteString(`<a href="`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(n.link.Href), true)))
+	_, _ = w.WriteString(`" class="mention">`)
+	_, _ = w.Write(util.EscapeHTML([]byte(n.link.Text)))
+	_, _ = w.WriteString("</a>")
+
+	return ast.WalkSkipChildren, nil
+}
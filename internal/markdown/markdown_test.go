@@ -0,0 +1,98 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Mention
+	}{
+		{name: "cat and target", src: "Seen with @cat:12 near @target:7", want: []Mention{{KindCat, 12}, {KindTarget, 7}}},
+		{name: "duplicates", src: "@cat:12, then @cat:12 again", want: []Mention{{KindCat, 12}}},
+		{name: "in emphasis", src: "**@cat:3**", want: []Mention{{KindCat, 3}}},
+		{name: "code span", src: "`@cat:12`", want: nil},
+		{name: "code block", src: "```\n@cat:12\n```", want: nil},
+		{name: "e-mail", src: "write to tom@cat:12", want: nil},
+		{name: "unknown kind", src: "@mission:2", want: nil},
+		{name: "no id", src: "@cat:", want: nil},
+		{name: "id out of range", src: "@cat:99999999999", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Mentions(tt.src))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	links := map[Mention]Link{
+		{KindCat, 12}:   {Href: "/cats/12", Text: "@Tom"},
+		{KindTarget, 7}: {Href: "/missions/2", Text: "@Ivan"},
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "markdown",
+			src:  "# Ivan\n\n**Seen** at the _square_, ~~not~~ at the [station](https://example.com/station).",
+			want: "<h1>Ivan</h1>\n<p><strong>Seen</strong> at the <em>square</em>, <del>not</del> at the <a href=\"https://example.com/station\" rel=\"nofollow\">station</a>.</p>\n",
+		},
+		{
+			name: "mentions",
+			src:  "With @cat:12 near @target:7",
+			want: "<p>With <a href=\"/cats/12\" class=\"mention\" rel=\"nofollow\">@Tom</a> near <a href=\"/missions/2\" class=\"mention\" rel=\"nofollow\">@Ivan</a></p>\n",
+		},
+		{
+			name: "unresolved mention",
+			src:  "With @cat:13",
+			want: "<p>With @cat:13</p>\n",
+		},
+		{
+			name: "mention in code",
+			src:  "`@cat:12`",
+			want: "<p><code>@cat:12</code></p>\n",
+		},
+		{
+			name: "task list",
+			src:  "- [x] photo\n- [ ] address",
+			want: "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> photo</li>\n<li><input disabled=\"\" type=\"checkbox\"> address</li>\n</ul>\n",
+		},
+		{
+			name: "raw html",
+			src:  "Ivan <script>alert(1)</script><b onclick=\"alert(1)\">left</b>",
+			want: "<p>Ivan alert(1)left</p>\n",
+		},
+		{
+			name: "html block",
+			src:  "<div>Ivan</div>\n\nleft",
+			want: "\n<p>left</p>\n",
+		},
+		{
+			name: "unsafe link",
+			src:  "[square](javascript:alert(1))",
+			want: "<p>square</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.src, links)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("link text", func(t *testing.T) {
+		got, err := Render("@cat:1", map[Mention]Link{{KindCat, 1}: {Href: "/cats/1", Text: "<Tom & Jerry>"}})
+		require.NoError(t, err)
+		assert.Equal(t, "<p><a href=\"/cats/1\" class=\"mention\" rel=\"nofollow\">&lt;Tom &amp; Jerry&gt;</a></p>\n", got)
+	})
+}
@@ -1,36 +1,153 @@
 package models
 
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Cat is a spy cat. The salary is in whole units of its currency, like
+// dollars.
 type Cat struct {
 	Name              string `json:"name"`
 	Breed             string `json:"breed"`
 	YearsOfExperience int32  `json:"years_of_experience"`
 	Salary            int32  `json:"salary"`
-	ID                int32  `json:"id"`
+	SalaryCurrency    string `json:"salary_currency"`
+	// ReportingSalary is the salary in the reporting currency, in the lists
+	// of cats, if the currency has an exchange rate.
+	ReportingSalary *Money `json:"reporting_salary,omitempty"`
+	ID              int32  `json:"id"`
 }
 
+// CreateCatRequest creates a cat. The salary is in the reporting currency
+// without a salary currency.
 type CreateCatRequest struct {
 	Name              string `json:"name" validate:"required"`
 	Breed             string `json:"breed" validate:"required"`
 	YearsOfExperience int32  `json:"years_of_experience" validate:"required"`
 	Salary            int32  `json:"salary" validate:"required"`
+	SalaryCurrency    string `json:"salary_currency"`
 }
 
+// UpdateCatSalaryRequest updates the salary of a cat, in its current
+// currency without a salary currency.
 type UpdateCatSalaryRequest struct {
-	Salary int32 `json:"salary" validate:"required"`
+	Salary         int32  `json:"salary" validate:"required"`
+	SalaryCurrency string `json:"salary_currency"`
+}
+
+// Money is an amount in the minor units of its currency.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
 }
 
+// Target is a target of a mission. The latitude and the longitude, in
+// degrees, are both nil for a target without coordinates, and the location
+// is left out of its JSON.
 type Target struct {
-	ID        int32  `json:"id"`
-	Name      string `json:"name"`
-	Country   string `json:"country"`
-	Notes     string `json:"notes"`
-	Completed bool   `json:"completed"`
+	ID        int32    `json:"id"`
+	Name      string   `json:"name"`
+	Country   string   `json:"country"`
+	Notes     string   `json:"notes"`
+	Completed bool     `json:"completed"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Address   string   `json:"address,omitempty"`
+	// SubjectID is the subject of the target, zero for none.
+	SubjectID int32 `json:"subject_id,omitempty"`
+	// Suggestions are the known subjects similar to a new target, only in
+	// the responses of the requests creating it.
+	Suggestions []SubjectMatch `json:"suggestions,omitempty"`
+	// NotesHTML is the Markdown of the notes rendered to sanitized HTML, only
+	// in the responses of the requests with ?render=html.
+	NotesHTML string `json:"notes_html,omitempty"`
 }
 
 type CreateTargetRequest struct {
 	Name    string `json:"name" validate:"required"`
 	Country string `json:"country" validate:"required"`
 	Notes   string `json:"notes" validate:"required"`
+	// Latitude and Longitude are optional, but one requires the other.
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Address   string   `json:"address"`
+	// SubjectID links the target to a known subject. A new subject is
+	// created for the target otherwise.
+	SubjectID *int32 `json:"subject_id"`
+}
+
+// Subject is the person behind targets, who may show up in several missions
+// under slightly different names.
+type Subject struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Country   string    `json:"country"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SubjectMatch is a subject similar to a target, with the score of the
+// similarity from 0 to 1.
+type SubjectMatch struct {
+	Subject Subject `json:"subject"`
+	Score   float64 `json:"score"`
+}
+
+// SubjectDetails is a subject with the missions of its targets, each with
+// only the targets of the subject, and the history of their notes.
+type SubjectDetails struct {
+	Subject
+	Missions     []Mission          `json:"missions"`
+	NotesHistory []TargetNoteChange `json:"notes_history"`
+}
+
+// TargetNoteChange is a record of the audit log of the notes of the targets.
+type TargetNoteChange struct {
+	TargetID      int32     `json:"target_id"`
+	MissionID     int32     `json:"mission_id"`
+	PreviousNotes string    `json:"previous_notes"`
+	Notes         string    `json:"notes"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// MergeSubjectsRequest merges the subjects into another one, which takes over
+// their targets.
+type MergeSubjectsRequest struct {
+	Subjects []int32 `json:"subjects" validate:"required"`
+}
+
+// Attachment is a file attached to a target, such as a photo or a document.
+// The content type is sniffed from the content and the size is in bytes.
+type Attachment struct {
+	ID          int32     `json:"id"`
+	TargetID    int32     `json:"target_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentUpload is a file uploaded to a target.
+type AttachmentUpload struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
+
+// TargetLocationRequest replaces the location of a target. Without the
+// latitude and the longitude the coordinates are removed.
+type TargetLocationRequest struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Address   string   `json:"address"`
+}
+
+// NearbyTarget is a target within the radius of a point.
+type NearbyTarget struct {
+	Target     Target  `json:"target"`
+	MissionID  int32   `json:"mission_id"`
+	DistanceKm float64 `json:"distance_km"`
 }
 
 type Mission struct {
@@ -38,16 +155,469 @@ type Mission struct {
 	Assignee  int32    `json:"assignee"`
 	Targets   []Target `json:"targets"`
 	Completed bool     `json:"completed"`
+	// Warnings are the checks an assignment overrode.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type CreateMissionRequest struct {
 	Targets []CreateTargetRequest `json:"targets" validate:"required"`
 }
 
+type CloneMissionRequest struct {
+	// Notes copies the notes of the targets, left empty otherwise.
+	Notes bool `json:"notes"`
+}
+
+type SplitMissionRequest struct {
+	Targets []int32 `json:"targets" validate:"required"`
+}
+
 type AssignCatRequest struct {
 	Assignee int32 `json:"assignee" validate:"required"`
+	// Until is the expected end of the mission. The cat must be available
+	// from now until then, or from now on without it.
+	Until *time.Time `json:"until"`
+	// Override assigns an unavailable cat with a warning instead of failing.
+	Override bool `json:"override"`
 }
 
 type UpdateTargetNotesRequest struct {
 	Notes string `json:"notes" validate:"required"`
 }
+
+type MoveTargetRequest struct {
+	Mission int32 `json:"mission" validate:"required"`
+}
+
+// TargetMove is a record of the audit log of targets moved between missions.
+type TargetMove struct {
+	ID          int32     `json:"id"`
+	Target      int32     `json:"target"`
+	FromMission int32     `json:"from_mission"`
+	ToMission   int32     `json:"to_mission"`
+	MovedAt     time.Time `json:"moved_at"`
+}
+
+type Webhook struct {
+	ID         int32     `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required"`
+	Secret     string   `json:"secret"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url" validate:"omitempty,url"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active"`
+}
+
+type WebhookDelivery struct {
+	ID               int32           `json:"id"`
+	Webhook          int32           `json:"webhook"`
+	EventType        string          `json:"event_type"`
+	Payload          json.RawMessage `json:"payload"`
+	Status           string          `json:"status"`
+	Attempts         int32           `json:"attempts"`
+	NextAttemptAt    time.Time       `json:"next_attempt_at"`
+	LastError        string          `json:"last_error"`
+	LastResponseCode *int32          `json:"last_response_code"`
+	CreatedAt        time.Time       `json:"created_at"`
+	DeliveredAt      *time.Time      `json:"delivered_at"`
+}
+
+type OutboxEvent struct {
+	Offset        int64           `json:"offset"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int32           `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at"`
+}
+
+type ReplayOutboxRequest struct {
+	FromOffset int64 `json:"from_offset" validate:"required"`
+}
+
+type TargetSearchResult struct {
+	Target  Target  `json:"target"`
+	Mission Mission `json:"mission"`
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// TargetBlueprint is a target of a mission template.
+//
+// The fields may contain {{variable}} placeholders, substituted when a
+// mission is created from the template.
+type TargetBlueprint struct {
+	Name    string `json:"name"`
+	Country string `json:"country"`
+	Notes   string `json:"notes"`
+}
+
+type MissionTemplate struct {
+	ID        int32             `json:"id"`
+	Name      string            `json:"name"`
+	Targets   []TargetBlueprint `json:"targets"`
+	Variables []string          `json:"variables"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type CreateMissionTemplateRequest struct {
+	Name    string            `json:"name" validate:"required"`
+	Targets []TargetBlueprint `json:"targets" validate:"required"`
+}
+
+type UpdateMissionTemplateRequest struct {
+	Name    *string           `json:"name"`
+	Targets []TargetBlueprint `json:"targets"`
+}
+
+type CreateMissionFromTemplateRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// Modes of a bulk import.
+const (
+	// ImportAllOrNothing creates no row if any row is invalid.
+	ImportAllOrNothing = "all_or_nothing"
+	// ImportBestEffort creates the valid rows and skips the invalid ones.
+	ImportBestEffort = "best_effort"
+)
+
+// Statuses of a row of a bulk import.
+const (
+	ImportRowCreated = "created"
+	ImportRowValid   = "valid"
+	ImportRowInvalid = "invalid"
+)
+
+type ImportOptions struct {
+	Mode string
+	// DryRun validates the rows without creating them.
+	DryRun bool
+}
+
+// CatImportRow is a cat read from an import file.
+//
+// Errors are the problems found reading the row, like a malformed number.
+type CatImportRow struct {
+	Line   int
+	Cat    CreateCatRequest
+	Errors []string
+}
+
+// MissionImportRow is a mission read from an import file.
+type MissionImportRow struct {
+	Line    int
+	Mission CreateMissionRequest
+	Errors  []string
+}
+
+type ImportResult struct {
+	Mode    string            `json:"mode"`
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Created int               `json:"created"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+type ImportRowResult struct {
+	Line   int      `json:"line"`
+	Status string   `json:"status"`
+	ID     int32    `json:"id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// RestoreResult counts the entities restored from a snapshot.
+type RestoreResult struct {
+	SchemaVersion int64 `json:"schema_version"`
+	Cats          int   `json:"cats"`
+	Subjects      int   `json:"subjects"`
+	Missions      int   `json:"missions"`
+	Targets       int   `json:"targets"`
+}
+
+// MissionTargetRow is a target of the mission targets report, with its mission.
+type MissionTargetRow struct {
+	MissionID        int32  `json:"mission_id"`
+	Assignee         int32  `json:"assignee"`
+	MissionCompleted bool   `json:"mission_completed"`
+	TargetID         int32  `json:"target_id"`
+	Name             string `json:"name"`
+	Country          string `json:"country"`
+	Notes            string `json:"notes"`
+	TargetCompleted  bool   `json:"target_completed"`
+}
+
+// CatWorkload counts the missions and the targets assigned to a cat.
+type CatWorkload struct {
+	CatID             int32  `json:"cat_id"`
+	Name              string `json:"name"`
+	Breed             string `json:"breed"`
+	Salary            int32  `json:"salary"`
+	SalaryCurrency    string `json:"salary_currency"`
+	ReportingSalary   *Money `json:"reporting_salary,omitempty"`
+	ActiveMissions    int64  `json:"active_missions"`
+	CompletedMissions int64  `json:"completed_missions"`
+	OpenTargets       int64  `json:"open_targets"`
+	CompletedTargets  int64  `json:"completed_targets"`
+}
+
+// CompletionRate is the share of the missions created in each period of a
+// window that are completed.
+type CompletionRate struct {
+	Window  string                 `json:"window"`
+	Periods []CompletionRatePeriod `json:"periods"`
+}
+
+// CompletionRatePeriod counts the missions created in a period.
+type CompletionRatePeriod struct {
+	Start     time.Time `json:"start"`
+	Created   int64     `json:"created"`
+	Completed int64     `json:"completed"`
+	Rate      float64   `json:"rate"`
+}
+
+// CompletionTimes is the average time from the creation to the completion of
+// the completed missions and targets.
+type CompletionTimes struct {
+	CompletedMissions int64   `json:"completed_missions"`
+	AvgMissionSeconds float64 `json:"avg_mission_seconds"`
+	CompletedTargets  int64   `json:"completed_targets"`
+	AvgTargetSeconds  float64 `json:"avg_target_seconds"`
+}
+
+// CatUtilization is the time a cat has spent assigned to missions, up to their
+// completion.
+type CatUtilization struct {
+	CatID         int32   `json:"cat_id"`
+	Name          string  `json:"name"`
+	Missions      int64   `json:"missions"`
+	DaysOnMission float64 `json:"days_on_mission"`
+}
+
+// CountryTargets counts the targets of a country.
+type CountryTargets struct {
+	Country   string `json:"country"`
+	Targets   int64  `json:"targets"`
+	Completed int64  `json:"completed"`
+}
+
+// SalaryPerformance is the Pearson correlation of the salaries of the cats with
+// the missions and the targets they completed. A correlation is null when it
+// is undefined, with fewer than two cats or the same values for all of them.
+//
+// The salaries are compared in the reporting currency. The cats paid in a
+// currency without an exchange rate are excluded.
+type SalaryPerformance struct {
+	Cats                int64    `json:"cats"`
+	ExcludedCats        int64    `json:"excluded_cats"`
+	MissionsCorrelation *float64 `json:"missions_correlation"`
+	TargetsCorrelation  *float64 `json:"targets_correlation"`
+}
+
+// AvailabilityPeriod is a period a cat is unavailable for missions, from
+// StartsAt up to EndsAt.
+type AvailabilityPeriod struct {
+	ID       int32     `json:"id"`
+	CatID    int32     `json:"cat_id"`
+	Kind     string    `json:"kind"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+type AvailabilityPeriodRequest struct {
+	// Kind is one of vacation, sick or training.
+	Kind     string    `json:"kind" validate:"required"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// Skill is a skill of the catalog, like a language or lockpicking.
+type Skill struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type CreateSkillRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// CatSkill is a skill of a cat at a level from 1 to 5. Certified tells whether
+// the cat holds a certification of the skill valid now.
+type CatSkill struct {
+	SkillID        int32      `json:"skill_id"`
+	Name           string     `json:"name"`
+	Level          int32      `json:"level"`
+	CertifiedUntil *time.Time `json:"certified_until"`
+	Certified      bool       `json:"certified"`
+}
+
+type CatSkillRequest struct {
+	Level int32 `json:"level"`
+	// CertifiedUntil is the expiry of the certification of the skill, if any.
+	CertifiedUntil *time.Time `json:"certified_until"`
+}
+
+// TargetSkill is a skill required by a target, at least at MinLevel and, if
+// Certified, with a valid certification.
+type TargetSkill struct {
+	SkillID   int32  `json:"skill_id"`
+	Name      string `json:"name"`
+	MinLevel  int32  `json:"min_level"`
+	Certified bool   `json:"certified"`
+}
+
+type TargetSkillRequest struct {
+	MinLevel  int32 `json:"min_level"`
+	Certified bool  `json:"certified"`
+}
+
+// Candidate is an unassigned cat ranked for a mission. SkillMatch is the
+// fraction of the skills required by the pending targets the cat meets, 1
+// when none are required.
+type Candidate struct {
+	Cat           Cat      `json:"cat"`
+	SkillMatch    float64  `json:"skill_match"`
+	MatchedSkills []string `json:"matched_skills"`
+	MissingSkills []string `json:"missing_skills"`
+	Available     bool     `json:"available"`
+}
+
+type AutoAssignRequest struct {
+	// Strategy is greedy, the default, or hungarian.
+	Strategy string `json:"strategy"`
+	// MinYearsOfExperience is the experience required of the cats.
+	MinYearsOfExperience int32 `json:"min_years_of_experience"`
+	// SalaryBudget bounds the total salary of the assigned cats, if any, in
+	// the minor units of the salary budget currency.
+	SalaryBudget *int64 `json:"salary_budget"`
+	// SalaryBudgetCurrency is the ISO 4217 currency of the salary budget, the
+	// reporting currency by default. The budget is converted to the reporting
+	// currency to compare it to the salaries.
+	SalaryBudgetCurrency string `json:"salary_budget_currency"`
+	// DryRun proposes the assignments without making them.
+	DryRun bool `json:"dry_run"`
+}
+
+// AutoAssignment is an assignment of a cat to a mission by an auto-assignment.
+type AutoAssignment struct {
+	MissionID  int32   `json:"mission_id"`
+	Cat        Cat     `json:"cat"`
+	SkillMatch float64 `json:"skill_match"`
+}
+
+// AutoAssignResult is the result of an auto-assignment. Unassigned are the
+// missions left without a cat. The total salary is in the reporting currency.
+type AutoAssignResult struct {
+	Strategy    string           `json:"strategy"`
+	DryRun      bool             `json:"dry_run"`
+	Assignments []AutoAssignment `json:"assignments"`
+	Unassigned  []int32          `json:"unassigned"`
+	TotalSalary int64            `json:"total_salary"`
+	Currency    string           `json:"currency"`
+}
+
+// The amounts of money are in the minor units of their currency, like cents.
+
+// MissionBudget is the budget of the costs of a mission.
+type MissionBudget struct {
+	MissionID int32  `json:"mission_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+type MissionBudgetRequest struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency" validate:"required"`
+}
+
+// Receipt is the metadata of the receipt of an expense.
+type Receipt struct {
+	Number string `json:"number"`
+	Vendor string `json:"vendor"`
+	URL    string `json:"url"`
+}
+
+// Expense is an expense of a mission, of a category of travel, equipment
+// or informants.
+type Expense struct {
+	ID          int32     `json:"id"`
+	MissionID   int32     `json:"mission_id"`
+	Category    string    `json:"category"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description"`
+	Receipt     *Receipt  `json:"receipt"`
+	IncurredAt  time.Time `json:"incurred_at"`
+	// Warnings are the checks the expense failed, like the budget.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type CreateExpenseRequest struct {
+	Category    string   `json:"category" validate:"required"`
+	Amount      int64    `json:"amount" validate:"required"`
+	Currency    string   `json:"currency" validate:"required"`
+	Description string   `json:"description"`
+	Receipt     *Receipt `json:"receipt"`
+	// IncurredAt is the time of the expense, now by default.
+	IncurredAt *time.Time `json:"incurred_at"`
+}
+
+// SalaryCost is the salary of the assigned cat prorated over the days of
+// the mission, from the assignment until the completion or now. The salary
+// is in whole units of its currency and the amount in the minor units of the
+// currency of the costs.
+type SalaryCost struct {
+	CatID          int32   `json:"cat_id"`
+	Salary         int32   `json:"salary"`
+	SalaryCurrency string  `json:"salary_currency"`
+	Days           float64 `json:"days"`
+	Amount         int64   `json:"amount"`
+}
+
+// MissionCosts is the breakdown of the costs of a mission, in the currency of
+// its budget or else the reporting currency. Budget and Remaining are nil
+// without a budget.
+type MissionCosts struct {
+	MissionID int32  `json:"mission_id"`
+	Currency  string `json:"currency"`
+	Budget    *int64 `json:"budget"`
+	// Expenses are the totals of the expenses by category.
+	Expenses      map[string]int64 `json:"expenses"`
+	ExpensesTotal int64            `json:"expenses_total"`
+	Salary        *SalaryCost      `json:"salary"`
+	Total         int64            `json:"total"`
+	Remaining     *int64           `json:"remaining"`
+	OverBudget    bool             `json:"over_budget"`
+}
+
+// ExchangeRate is the value of a major unit of the currency in the reporting
+// currency.
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeRates are the exchange rates to the reporting currency.
+type ExchangeRates struct {
+	ReportingCurrency string         `json:"reporting_currency"`
+	Rates             []ExchangeRate `json:"rates"`
+}
+
+type SetExchangeRateRequest struct {
+	Rate float64 `json:"rate" validate:"required"`
+}
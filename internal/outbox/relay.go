@@ -0,0 +1,257 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// Aggregate types of outbox events.
+const (
+	AggregateMission = "mission"
+)
+
+// Event is an outbox row handed to sinks.
+type Event struct {
+	Offset        int64           `json:"offset"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int32           `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Sink receives published outbox events.
+//
+// Delivery is at-least-once: a sink may receive the same event again after a failure.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e Event) error
+}
+
+// Storage controls the outbox storage.
+type Storage interface {
+	GetUnpublishedOutboxEvents(ctx context.Context, arg postgres.GetUnpublishedOutboxEventsParams) ([]postgres.Outbox, error)
+	GetOutboxEvents(ctx context.Context, arg postgres.GetOutboxEventsParams) ([]postgres.Outbox, error)
+	MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error)
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int32
+	// GapGracePeriod is how long a missing offset is waited for before the
+	// rows after it are relayed.
+	GapGracePeriod time.Duration
+}
+
+// Relay publishes outbox rows to sinks.
+//
+// Rows are processed in offset order. When a row fails to publish, later rows of
+// the same aggregate are held back until it succeeds, so ordering per aggregate is kept.
+// The rows of the other aggregates are still relayed.
+//
+// Offsets are assigned before the rows are committed, so a missing offset may
+// be a row still to be committed. The rows after it are held back until it
+// shows up or the grace period has passed, as it was most likely rolled back.
+type Relay struct {
+	storage Storage
+	sinks   []Sink
+	cfg     Config
+	// next is the offset of the first row not relayed yet. The offsets before
+	// it are published or given up.
+	next int64
+	// gaps are the missing offsets after next by the time they were first seen.
+	gaps map[int64]time.Time
+}
+
+// NewRelay returns a new Relay.
+func NewRelay(st Storage, sinks []Sink, cfg Config) *Relay {
+	return &Relay{
+		storage: st,
+		sinks:   sinks,
+		cfg:     cfg,
+		gaps:    make(map[int64]time.Time),
+	}
+}
+
+// Run relays events until the context is canceled.
+func (r *Relay) Run(ctx context.Context) {
+	slog.Info("Outbox relay started", "sinks", len(r.sinks))
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = r.relay(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the sinks holding resources.
+func (r *Relay) Close() {
+	for _, sink := range r.sinks {
+		if c, ok := sink.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				slog.Error("Failed to close sink", "sink", sink.Name(), "err", err)
+			}
+		}
+	}
+}
+
+// relay publishes the unpublished rows, a batch at a time.
+//
+// The batches go past the rows of the aggregates held back, so these don't
+// stop the others, but not past a missing offset within its grace period.
+// Returns the number of rows published.
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	log := slog.With(
+		slog.String("op", "outbox.relay"),
+	)
+
+	// The first unpublished row is the start on the first run, or before next
+	// if the rows were reset for a replay.
+	first, err := r.storage.GetUnpublishedOutboxEvents(ctx, postgres.GetUnpublishedOutboxEventsParams{
+		MaxEvents: 1,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to get unpublished events", "err", err)
+		}
+		return 0, err
+	}
+	if len(first) == 0 {
+		return 0, nil
+	}
+	if r.next == 0 || first[0].ID < r.next {
+		r.next = first[0].ID
+		clear(r.gaps)
+	}
+
+	blocked := make(map[string]bool)
+	// settled is set while the rows read so far are all published, so next
+	// can follow them.
+	settled := true
+	from := r.next
+	total := 0
+
+	for {
+		rows, err := r.storage.GetOutboxEvents(ctx, postgres.GetOutboxEventsParams{
+			FromOffset: from,
+			MaxEvents:  r.cfg.BatchSize,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error("Failed to get outbox events", "err", err)
+			}
+			return total, err
+		}
+
+		published := make([]int64, 0, len(rows))
+		next := r.next
+		gap := false
+
+		for _, row := range rows {
+			if !r.skipGap(from, row.ID) {
+				log.Debug("Waiting for missing offsets", "from", from, "to", row.ID)
+				gap = true
+				break
+			}
+			from = row.ID + 1
+
+			key := fmt.Sprintf("%s:%d", row.AggregateType, row.AggregateID)
+			switch {
+			case row.PublishedAt.Valid:
+			case blocked[key]:
+				settled = false
+				continue
+			default:
+				e := sqlcOutboxToEvent(row)
+				if err := r.publish(ctx, e); err != nil {
+					log.Warn("Failed to publish event", "err", err, "offset", e.Offset)
+					blocked[key] = true
+					settled = false
+					continue
+				}
+				published = append(published, row.ID)
+			}
+
+			if settled {
+				next = from
+			}
+		}
+
+		if len(published) > 0 {
+			// If this fails, the rows are published again on the next run.
+			if _, err := r.storage.MarkOutboxEventsPublished(ctx, published); err != nil {
+				log.Error("Failed to mark events as published", "err", err)
+				return total, err
+			}
+
+			total += len(published)
+			log.Debug("Relayed events", "count", len(published))
+		}
+
+		for offset := range r.gaps {
+			if offset < next {
+				delete(r.gaps, offset)
+			}
+		}
+		r.next = next
+
+		if gap || len(rows) < int(r.cfg.BatchSize) {
+			return total, nil
+		}
+	}
+}
+
+// skipGap reports whether the rows from offset up to the row at next can be
+// skipped, as they are not missing or missing for longer than the grace period.
+func (r *Relay) skipGap(offset, next int64) bool {
+	now := time.Now()
+
+	skip := true
+	for ; offset < next; offset++ {
+		seen, ok := r.gaps[offset]
+		if !ok {
+			r.gaps[offset] = now
+			seen = now
+		}
+		if now.Sub(seen) < r.cfg.GapGracePeriod {
+			skip = false
+		}
+	}
+
+	return skip
+}
+
+// publish hands the event to every sink.
+func (r *Relay) publish(ctx context.Context, e Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, e); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+func sqlcOutboxToEvent(o postgres.Outbox) Event {
+	return Event{
+		Offset:        o.ID,
+		AggregateType: o.AggregateType,
+		AggregateID:   o.AggregateID,
+		Type:          o.EventType,
+		Payload:       o.Payload,
+		CreatedAt:     o.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,218 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStorage struct {
+	mu        sync.Mutex
+	rows      []postgres.Outbox
+	published []int64
+	queries   int
+}
+
+func (s *fakeStorage) GetUnpublishedOutboxEvents(_ context.Context, arg postgres.GetUnpublishedOutboxEventsParams) ([]postgres.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries++
+
+	var res []postgres.Outbox
+	for _, row := range s.rows {
+		if row.ID > arg.AfterOffset && !slices.Contains(s.published, row.ID) && len(res) < int(arg.MaxEvents) {
+			res = append(res, row)
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeStorage) GetOutboxEvents(_ context.Context, arg postgres.GetOutboxEventsParams) ([]postgres.Outbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries++
+
+	var res []postgres.Outbox
+	for _, row := range s.rows {
+		if row.ID >= arg.FromOffset && len(res) < int(arg.MaxEvents) {
+			row.PublishedAt.Valid = slices.Contains(s.published, row.ID)
+			res = append(res, row)
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeStorage) MarkOutboxEventsPublished(_ context.Context, ids []int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.published = append(s.published, ids...)
+	return int64(len(ids)), nil
+}
+
+// failingSink fails to publish the given offsets, or every offset if all is set.
+type failingSink struct {
+	fail []int64
+	all  bool
+	got  []int64
+}
+
+func (s *failingSink) Name() string {
+	return "failing"
+}
+
+func (s *failingSink) Publish(_ context.Context, e Event) error {
+	if s.all || slices.Contains(s.fail, e.Offset) {
+		return errors.New("unavailable")
+	}
+	s.got = append(s.got, e.Offset)
+	return nil
+}
+
+func TestRelay_KeepsOrderPerAggregate(t *testing.T) {
+	st := &fakeStorage{
+		rows: []postgres.Outbox{
+			{ID: 1, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 2, AggregateType: AggregateMission, AggregateID: 2},
+			{ID: 3, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 4, AggregateType: AggregateMission, AggregateID: 2},
+		},
+	}
+	sink := &failingSink{fail: []int64{1}}
+
+	relay := NewRelay(st, []Sink{sink}, Config{BatchSize: 10})
+
+	n, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// Mission 1 is held back after its first event failed.
+	assert.Equal(t, []int64{2, 4}, sink.got)
+	assert.Equal(t, []int64{2, 4}, st.published)
+}
+
+func TestRelay_SkipsBlockedAggregate(t *testing.T) {
+	st := &fakeStorage{
+		rows: []postgres.Outbox{
+			{ID: 1, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 2, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 3, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 4, AggregateType: AggregateMission, AggregateID: 2},
+			{ID: 5, AggregateType: AggregateMission, AggregateID: 2},
+		},
+	}
+	sink := &failingSink{fail: []int64{1}}
+
+	// Mission 1 alone fills the first batches.
+	relay := NewRelay(st, []Sink{sink}, Config{BatchSize: 2})
+
+	n, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{4, 5}, st.published)
+}
+
+func TestRelay_FailingSink(t *testing.T) {
+	st := &fakeStorage{}
+	for i := range 5 {
+		st.rows = append(st.rows, postgres.Outbox{ID: int64(i + 1), AggregateType: AggregateMission, AggregateID: int32(i%2 + 1)})
+	}
+	sink := &failingSink{all: true}
+
+	relay := NewRelay(st, []Sink{sink}, Config{BatchSize: 2, PollInterval: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	relay.Run(ctx)
+
+	// A single pass over the rows, then the relay waits for the next poll.
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	assert.Equal(t, 4, st.queries)
+	assert.Empty(t, st.published)
+}
+
+func TestRelay_WaitsForMissingOffsets(t *testing.T) {
+	st := &fakeStorage{
+		rows: []postgres.Outbox{
+			{ID: 1, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 3, AggregateType: AggregateMission, AggregateID: 1},
+		},
+	}
+	sink := &failingSink{}
+
+	relay := NewRelay(st, []Sink{sink}, Config{BatchSize: 10, GapGracePeriod: time.Hour})
+
+	// The 2nd row may still be committed.
+	n, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1}, sink.got)
+
+	st.rows = slices.Insert(st.rows, 1, postgres.Outbox{ID: 2, AggregateType: AggregateMission, AggregateID: 1})
+
+	n, err = relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{1, 2, 3}, sink.got)
+	assert.Empty(t, relay.gaps)
+}
+
+func TestRelay_SkipsMissingOffsetsAfterGracePeriod(t *testing.T) {
+	st := &fakeStorage{
+		rows: []postgres.Outbox{
+			{ID: 1, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 4, AggregateType: AggregateMission, AggregateID: 2},
+		},
+	}
+	sink := &failingSink{}
+
+	relay := NewRelay(st, []Sink{sink}, Config{BatchSize: 10, GapGracePeriod: time.Hour})
+
+	_, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, sink.got)
+
+	// The rows were rolled back.
+	for offset := range relay.gaps {
+		relay.gaps[offset] = time.Now().Add(-time.Hour)
+	}
+
+	n, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1, 4}, sink.got)
+	assert.Equal(t, int64(5), relay.next)
+	assert.Empty(t, relay.gaps)
+}
+
+func TestRelay_RestartsFromReplayedRows(t *testing.T) {
+	st := &fakeStorage{
+		rows: []postgres.Outbox{
+			{ID: 1, AggregateType: AggregateMission, AggregateID: 1},
+			{ID: 2, AggregateType: AggregateMission, AggregateID: 1},
+		},
+	}
+	sink := &failingSink{}
+
+	relay := NewRelay(st, []Sink{sink}, Config{BatchSize: 10})
+
+	_, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+
+	// A replay resets the rows from the 2nd.
+	st.published = []int64{1}
+
+	n, err := relay.relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1, 2, 2}, sink.got)
+}
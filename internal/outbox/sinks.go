@@ -0,0 +1,186 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+)
+
+// BusSink publishes events to the in-process event bus.
+type BusSink struct {
+	bus *events.Bus
+}
+
+// NewBusSink returns a new BusSink.
+func NewBusSink(bus *events.Bus) *BusSink {
+	return &BusSink{bus: bus}
+}
+
+func (s *BusSink) Name() string {
+	return "bus"
+}
+
+func (s *BusSink) Publish(_ context.Context, e Event) error {
+	if e.AggregateType == AggregateMission {
+		s.bus.Publish(e.Type, e.AggregateID, e.Payload)
+	}
+	return nil
+}
+
+// WriterSink writes events as newline-delimited JSON.
+type WriterSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+	sync func() error
+}
+
+// NewStdoutSink returns a sink writing to the standard output.
+func NewStdoutSink() *WriterSink {
+	return &WriterSink{
+		name: "stdout",
+		w:    os.Stdout,
+	}
+}
+
+// NewFileSink returns a sink appending to the file at path.
+//
+// Every event is synced to disk before it is reported as published.
+func NewFileSink(path string) (*WriterSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WriterSink{
+		name: "file",
+		w:    f,
+		sync: f.Sync,
+	}, nil
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Publish(_ context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if s.sync != nil {
+		return s.sync()
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it can be closed.
+func (s *WriterSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok && s.w != os.Stdout {
+		return c.Close()
+	}
+	return nil
+}
+
+// NATSSink publishes events to a NATS-compatible server.
+//
+// Events are published on "<prefix>.<event type>".
+type NATSSink struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSSink connects to the server at url.
+func NewNATSSink(url, prefix string) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name("sca-outbox-relay"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSSink{
+		conn:   conn,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+func (s *NATSSink) Publish(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(s.prefix + "." + e.Type)
+	msg.Data = data
+	// Lets consumers with deduplication drop redelivered events.
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatInt(e.Offset, 10))
+
+	if err := s.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	// Wait for the server to acknowledge the published messages.
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.conn.FlushWithContext(ctx)
+}
+
+// Close drains the connection.
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
+
+// SinkConfig configures the sinks created by NewSinks.
+type SinkConfig struct {
+	FilePath          string
+	NatsUrl           string
+	NatsSubjectPrefix string
+}
+
+// NewSinks creates the sinks with the given names (bus, stdout, file, nats).
+func NewSinks(names []string, bus *events.Bus, cfg SinkConfig) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(names))
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "bus":
+			sinks = append(sinks, NewBusSink(bus))
+		case "stdout":
+			sinks = append(sinks, NewStdoutSink())
+		case "file":
+			sink, err := NewFileSink(cfg.FilePath)
+			if err != nil {
+				return nil, fmt.Errorf("file sink: %w", err)
+			}
+			sinks = append(sinks, sink)
+		case "nats":
+			sink, err := NewNATSSink(cfg.NatsUrl, cfg.NatsSubjectPrefix)
+			if err != nil {
+				return nil, fmt.Errorf("nats sink: %w", err)
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unknown sink: %s", name)
+		}
+	}
+	return sinks, nil
+}
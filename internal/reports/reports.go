@@ -0,0 +1,268 @@
+// Package reports writes the spreadsheet exports, CSV or XLSX, a row at a time.
+//
+// A report is a Table of columns, each naming a value of the row. The
+// columns of an export can be a selection of the ones of its table.
+package reports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is the format of an export.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for a format of no known export.
+var ErrUnsupportedFormat = errors.New("unsupported format, expected csv or xlsx")
+
+// ParseFormat returns the format of its name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case CSV, XLSX:
+		return f, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the content type of the files of the format.
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv"
+}
+
+// Column is a column of a report of rows of type T.
+type Column[T any] struct {
+	Name string
+	// Value returns the value of the row, nil for an empty cell.
+	Value func(T) any
+}
+
+// Table is the columns of a report.
+type Table[T any] []Column[T]
+
+// Select returns the columns of the comma separated names, in their order,
+// or all the columns if there are no names.
+func (t Table[T]) Select(names string) (Table[T], error) {
+	if strings.TrimSpace(names) == "" {
+		return t, nil
+	}
+
+	var selected Table[T]
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		i := slices.IndexFunc(t, func(c Column[T]) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+		if slices.ContainsFunc(selected, func(c Column[T]) bool { return c.Name == name }) {
+			return nil, fmt.Errorf("duplicate column: %s", name)
+		}
+		selected = append(selected, t[i])
+	}
+
+	return selected, nil
+}
+
+// Header returns the names of the columns.
+func (t Table[T]) Header() []string {
+	header := make([]string, len(t))
+	for i, c := range t {
+		header[i] = c.Name
+	}
+	return header
+}
+
+var Cats = Table[models.Cat]{
+	{"id", func(c models.Cat) any { return c.ID }},
+	{"name", func(c models.Cat) any { return c.Name }},
+	{"breed", func(c models.Cat) any { return c.Breed }},
+	{"years_of_experience", func(c models.Cat) any { return c.YearsOfExperience }},
+	{"salary", func(c models.Cat) any { return c.Salary }},
+	{"salary_currency", func(c models.Cat) any { return c.SalaryCurrency }},
+	{"reporting_salary", func(c models.Cat) any { return amount(c.ReportingSalary) }},
+}
+
+var Missions = Table[models.Mission]{
+	{"id", func(m models.Mission) any { return m.ID }},
+	{"assignee", func(m models.Mission) any { return assignee(m.Assignee) }},
+	{"completed", func(m models.Mission) any { return m.Completed }},
+}
+
+// MissionTargets is the report of the targets with their missions.
+var MissionTargets = Table[models.MissionTargetRow]{
+	{"mission_id", func(r models.MissionTargetRow) any { return r.MissionID }},
+	{"assignee", func(r models.MissionTargetRow) any { return assignee(r.Assignee) }},
+	{"mission_completed", func(r models.MissionTargetRow) any { return r.MissionCompleted }},
+	{"target_id", func(r models.MissionTargetRow) any { return r.TargetID }},
+	{"name", func(r models.MissionTargetRow) any { return r.Name }},
+	{"country", func(r models.MissionTargetRow) any { return r.Country }},
+	{"notes", func(r models.MissionTargetRow) any { return r.Notes }},
+	{"target_completed", func(r models.MissionTargetRow) any { return r.TargetCompleted }},
+}
+
+// CatWorkload is the report of the missions and the targets of the cats.
+var CatWorkload = Table[models.CatWorkload]{
+	{"cat_id", func(w models.CatWorkload) any { return w.CatID }},
+	{"name", func(w models.CatWorkload) any { return w.Name }},
+	{"breed", func(w models.CatWorkload) any { return w.Breed }},
+	{"salary", func(w models.CatWorkload) any { return w.Salary }},
+	{"salary_currency", func(w models.CatWorkload) any { return w.SalaryCurrency }},
+	{"reporting_salary", func(w models.CatWorkload) any { return amount(w.ReportingSalary) }},
+	{"active_missions", func(w models.CatWorkload) any { return w.ActiveMissions }},
+	{"completed_missions", func(w models.CatWorkload) any { return w.CompletedMissions }},
+	{"open_targets", func(w models.CatWorkload) any { return w.OpenTargets }},
+	{"completed_targets", func(w models.CatWorkload) any { return w.CompletedTargets }},
+}
+
+// assignee returns the assignee of a mission, nil if there is none.
+func assignee(id int32) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// amount returns the amount of money, nil if there is none.
+func amount(m *models.Money) any {
+	if m == nil {
+		return nil
+	}
+	return m.Amount
+}
+
+// Writer writes the rows of a report.
+type Writer[T any] struct {
+	table Table[T]
+	rows  rowWriter
+	cells []any
+}
+
+type rowWriter interface {
+	writeRow(cells []any) error
+	close() error
+}
+
+// NewWriter writes the header of the table and returns a Writer of its rows.
+//
+// The rows of a CSV are written through; an XLSX is a zip archive, so its
+// rows are buffered by excelize, spilling to a temporary file, and the
+// archive is written on Close.
+func NewWriter[T any](w io.Writer, f Format, table Table[T]) (*Writer[T], error) {
+	var rows rowWriter
+	switch f {
+	case CSV:
+		rows = &csvWriter{w: csv.NewWriter(w)}
+	case XLSX:
+		xw, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		rows = xw
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	rw := &Writer[T]{table: table, rows: rows, cells: make([]any, len(table))}
+	for i, name := range table.Header() {
+		rw.cells[i] = name
+	}
+	if err := rows.writeRow(rw.cells); err != nil {
+		return nil, err
+	}
+
+	return rw, nil
+}
+
+// Write writes a row.
+func (w *Writer[T]) Write(row T) error {
+	for i, c := range w.table {
+		w.cells[i] = c.Value(row)
+	}
+	return w.rows.writeRow(w.cells)
+}
+
+// Close writes the end of the report.
+func (w *Writer[T]) Close() error {
+	return w.rows.close()
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (cw *csvWriter) writeRow(cells []any) error {
+	cw.record = cw.record[:0]
+	for _, cell := range cells {
+		if cell == nil {
+			cw.record = append(cw.record, "")
+		} else {
+			cw.record = append(cw.record, fmt.Sprint(cell))
+		}
+	}
+
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// sheet is the name of the sheet of an XLSX report.
+const sheet = "Sheet1"
+
+type xlsxWriter struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &xlsxWriter{w: w, file: file, sw: sw}, nil
+}
+
+func (xw *xlsxWriter) writeRow(cells []any) error {
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+
+	return xw.sw.SetRow(cell, cells)
+}
+
+func (xw *xlsxWriter) close() error {
+	// Close removes the temporary files.
+	defer func() { _ = xw.file.Close() }()
+
+	if err := xw.sw.Flush(); err != nil {
+		return err
+	}
+
+	return xw.file.Write(xw.w)
+}
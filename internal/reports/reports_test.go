@@ -0,0 +1,83 @@
+package reports
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("XLSX")
+	require.NoError(t, err)
+	assert.Equal(t, XLSX, f)
+
+	_, err = ParseFormat("json")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestTable_Select(t *testing.T) {
+	tests := []struct {
+		names   string
+		want    []string
+		wantErr string
+	}{
+		{names: "", want: []string{"id", "assignee", "completed"}},
+		{names: "completed, ID", want: []string{"completed", "id"}},
+		{names: "id,color", wantErr: "unknown column: color"},
+		{names: "id,id", wantErr: "duplicate column: id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.names, func(t *testing.T) {
+			got, err := Missions.Select(tt.names)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Header())
+		})
+	}
+}
+
+var missions = []models.Mission{
+	{ID: 1, Assignee: 3, Completed: true},
+	{ID: 2},
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, CSV, Missions)
+	require.NoError(t, err)
+	for _, m := range missions {
+		require.NoError(t, w.Write(m))
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "id,assignee,completed\n1,3,true\n2,,false\n", buf.String())
+}
+
+func TestWriter_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, XLSX, Missions)
+	require.NoError(t, err)
+	for _, m := range missions {
+		require.NoError(t, w.Write(m))
+	}
+	require.NoError(t, w.Close())
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer f.Close()
+
+	rows, err := f.GetRows(sheet)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "assignee", "completed"},
+		{"1", "3", "TRUE"},
+		{"2", "", "FALSE"},
+	}, rows)
+}
@@ -0,0 +1,202 @@
+// Package rules holds the configurable business rules.
+package rules
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// notesLengthLimit is the highest limit of the notes of the targets. Their
+// column is unbounded TEXT, so it only keeps the requests reasonable.
+const notesLengthLimit = 65536
+
+// defaultMaxNotesLength is the default limit of the notes of the targets.
+const defaultMaxNotesLength = 4096
+
+// Rules are the business rules checked by the service.
+type Rules struct {
+	Targets  TargetRules  `json:"targets" yaml:"targets"`
+	Cats     CatRules     `json:"cats" yaml:"cats"`
+	Missions MissionRules `json:"missions" yaml:"missions"`
+}
+
+type TargetRules struct {
+	// Min and Max limit the number of targets of a mission.
+	Min int `json:"min" yaml:"min"`
+	Max int `json:"max" yaml:"max"`
+	// MaxNotesLength is the maximum number of characters of the Markdown notes.
+	MaxNotesLength int `json:"max_notes_length" yaml:"max_notes_length"`
+}
+
+type CatRules struct {
+	// MinSalary is in whole units of the reporting currency.
+	MinSalary            int32 `json:"min_salary" yaml:"min_salary"`
+	MinYearsOfExperience int32 `json:"min_years_of_experience" yaml:"min_years_of_experience"`
+}
+
+type MissionRules struct {
+	// DeleteAssigned allows to delete missions with an assigned cat.
+	DeleteAssigned bool `json:"delete_assigned" yaml:"delete_assigned"`
+	// OverBudget is the policy of the expenses over the budget of their
+	// mission: warn about them or block them.
+	OverBudget string `json:"over_budget" yaml:"over_budget"`
+	// SalaryPeriodDays is the number of days a salary pays, to prorate the
+	// salary of the assigned cat over a mission.
+	SalaryPeriodDays int `json:"salary_period_days" yaml:"salary_period_days"`
+}
+
+// The policies of the expenses over budget.
+const (
+	OverBudgetWarn  = "warn"
+	OverBudgetBlock = "block"
+)
+
+// Default returns the rules used when no rules file is configured.
+func Default() Rules {
+	return Rules{
+		Targets: TargetRules{
+			Min:            1,
+			Max:            3,
+			MaxNotesLength: defaultMaxNotesLength,
+		},
+		Missions: MissionRules{
+			OverBudget:       OverBudgetWarn,
+			SalaryPeriodDays: 30,
+		},
+	}
+}
+
+// Validate checks that the rules are consistent.
+func (r Rules) Validate() error {
+	var errs []error
+
+	if r.Targets.Min < 1 {
+		errs = append(errs, errors.New("targets.min must be at least 1"))
+	}
+	if r.Targets.Max < r.Targets.Min {
+		errs = append(errs, errors.New("targets.max must be at least targets.min"))
+	}
+	if r.Targets.MaxNotesLength < 1 || r.Targets.MaxNotesLength > notesLengthLimit {
+		errs = append(errs, fmt.Errorf("targets.max_notes_length must be between 1 and %d", notesLengthLimit))
+	}
+	if r.Cats.MinSalary < 0 {
+		errs = append(errs, errors.New("cats.min_salary can't be negative"))
+	}
+	if r.Cats.MinYearsOfExperience < 0 {
+		errs = append(errs, errors.New("cats.min_years_of_experience can't be negative"))
+	}
+	if r.Missions.OverBudget != OverBudgetWarn && r.Missions.OverBudget != OverBudgetBlock {
+		errs = append(errs, errors.New("missions.over_budget must be warn or block"))
+	}
+	if r.Missions.SalaryPeriodDays < 1 {
+		errs = append(errs, errors.New("missions.salary_period_days must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Parse decodes and validates YAML or JSON rules.
+//
+// Missing fields keep their default values, unknown fields are an error.
+func Parse(data []byte) (Rules, error) {
+	r := Default()
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&r); err != nil && !errors.Is(err, io.EOF) {
+		return Rules{}, fmt.Errorf("decode rules: %w", err)
+	}
+
+	if err := r.Validate(); err != nil {
+		return Rules{}, fmt.Errorf("invalid rules: %w", err)
+	}
+
+	return r, nil
+}
+
+// Load reads the rules from the YAML or JSON file.
+func Load(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, err
+	}
+
+	return Parse(data)
+}
+
+// Store holds the current rules and reloads them from the file.
+//
+// It's safe for concurrent use.
+type Store struct {
+	path    string
+	current atomic.Pointer[Rules]
+}
+
+// NewStore returns a Store with the rules of the file, or the default rules
+// if the path is empty.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	r := Default()
+	if path != "" {
+		var err error
+		if r, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	s.current.Store(&r)
+
+	return s, nil
+}
+
+// Get returns the current rules.
+func (s *Store) Get() Rules {
+	return *s.current.Load()
+}
+
+// Reload reads the rules from the file again.
+//
+// Invalid rules are rejected and the current rules are kept.
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	r, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+	s.current.Store(&r)
+
+	return nil
+}
+
+// Run reloads the rules on SIGHUP until ctx is done.
+func (s *Store) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := s.Reload(); err != nil {
+				slog.Error("Failed to reload rules, keeping the current ones", "path", s.path, "err", err)
+				continue
+			}
+			slog.Info("Reloaded rules", "path", s.path, "rules", s.Get())
+		}
+	}
+}
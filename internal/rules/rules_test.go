@@ -0,0 +1,93 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    func(r *Rules)
+		wantErr string
+	}{
+		{name: "empty", data: ""},
+		{
+			name: "yaml",
+			data: "targets:\n  max: 5\n  max_notes_length: 128\ncats:\n  min_salary: 100\nmissions:\n  delete_assigned: true\n  over_budget: block\n",
+			want: func(r *Rules) {
+				r.Targets.Max = 5
+				r.Targets.MaxNotesLength = 128
+				r.Cats.MinSalary = 100
+				r.Missions.DeleteAssigned = true
+				r.Missions.OverBudget = OverBudgetBlock
+			},
+		},
+		{
+			name: "json",
+			data: `{"targets": {"min": 2}, "cats": {"min_years_of_experience": 1}}`,
+			want: func(r *Rules) {
+				r.Targets.Min = 2
+				r.Cats.MinYearsOfExperience = 1
+			},
+		},
+		{name: "unknown field", data: "targets:\n  maximum: 5\n", wantErr: "field maximum not found"},
+		{name: "malformed", data: "targets: [", wantErr: "decode rules"},
+		{name: "min above max", data: "targets:\n  min: 4\n", wantErr: "targets.max must be at least targets.min"},
+		{name: "no targets", data: "targets:\n  min: 0\n", wantErr: "targets.min must be at least 1"},
+		{name: "notes over the limit", data: "targets:\n  max_notes_length: 100000\n", wantErr: "targets.max_notes_length must be between 1 and 65536"},
+		{name: "negative salary", data: "cats:\n  min_salary: -1\n", wantErr: "cats.min_salary can't be negative"},
+		{name: "unknown over budget policy", data: "missions:\n  over_budget: ignore\n", wantErr: "missions.over_budget must be warn or block"},
+		{name: "no salary period", data: "missions:\n  salary_period_days: 0\n", wantErr: "missions.salary_period_days must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			want := Default()
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			assert.Equal(t, want, r)
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("targets:\n  max: 5\n"), 0o644))
+
+	s, err := NewStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, s.Get().Targets.Max)
+
+	require.NoError(t, os.WriteFile(path, []byte("targets:\n  max: 4\n"), 0o644))
+	require.NoError(t, s.Reload())
+	assert.Equal(t, 4, s.Get().Targets.Max)
+
+	// Invalid rules are rejected and the current ones are kept.
+	require.NoError(t, os.WriteFile(path, []byte("targets:\n  max: 0\n"), 0o644))
+	assert.Error(t, s.Reload())
+	assert.Equal(t, 4, s.Get().Targets.Max)
+}
+
+func TestStore_Default(t *testing.T) {
+	s, err := NewStore("")
+	require.NoError(t, err)
+	assert.Equal(t, Default(), s.Get())
+	assert.NoError(t, s.Reload())
+
+	_, err = NewStore(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
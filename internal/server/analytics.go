@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// AnalyticsService controls the analytics service.
+type AnalyticsService interface {
+	GetCompletionRate(ctx context.Context, window string, periods int) (models.CompletionRate, error)
+	GetCompletionTimes(ctx context.Context) (models.CompletionTimes, error)
+	GetCatUtilization(ctx context.Context) ([]models.CatUtilization, error)
+	GetTargetsPerCountry(ctx context.Context) ([]models.CountryTargets, error)
+	GetSalaryPerformance(ctx context.Context) (models.SalaryPerformance, error)
+}
+
+// WithAnalyticsService enables the analytics routes.
+func WithAnalyticsService(as AnalyticsService) Option {
+	return func(s *Server) {
+		s.analyticsService = as
+	}
+}
+
+// registerAnalyticsRoutes registers the analytics routes.
+func (s *Server) registerAnalyticsRoutes() {
+	analytics := s.R.Group("/analytics")
+	{
+		analytics.Get("/completion-rate", s.handleGetCompletionRate)
+		analytics.Get("/completion-times", s.handleGetCompletionTimes)
+		analytics.Get("/utilization", s.handleGetCatUtilization)
+		analytics.Get("/countries", s.handleGetTargetsPerCountry)
+		analytics.Get("/salary-performance", s.handleGetSalaryPerformance)
+	}
+}
+
+func (s *Server) handleGetCompletionRate(c fiber.Ctx) error {
+	periods, err := strconv.Atoi(c.Query("periods", "12"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid periods"})
+	}
+
+	res, err := s.analyticsService.GetCompletionRate(c.Context(), c.Query("window", "week"), periods)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleGetCompletionTimes(c fiber.Ctx) error {
+	res, err := s.analyticsService.GetCompletionTimes(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleGetCatUtilization(c fiber.Ctx) error {
+	res, err := s.analyticsService.GetCatUtilization(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"cats": res})
+}
+
+func (s *Server) handleGetTargetsPerCountry(c fiber.Ctx) error {
+	res, err := s.analyticsService.GetTargetsPerCountry(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"countries": res})
+}
+
+func (s *Server) handleGetSalaryPerformance(c fiber.Ctx) error {
+	res, err := s.analyticsService.GetSalaryPerformance(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// AssignmentService controls the automatic assignment of cats to missions.
+type AssignmentService interface {
+	AutoAssignMission(ctx context.Context, missionID int32, req models.AutoAssignRequest) (models.AutoAssignResult, error)
+	AutoAssignMissions(ctx context.Context, req models.AutoAssignRequest) (models.AutoAssignResult, error)
+}
+
+// WithAssignmentService enables the auto-assignment routes.
+func WithAssignmentService(as AssignmentService) Option {
+	return func(s *Server) {
+		s.assignmentService = as
+	}
+}
+
+// registerAssignmentRoutes registers the auto-assignment routes.
+func (s *Server) registerAssignmentRoutes() {
+	s.R.Post("/missions/auto-assign", s.handleAutoAssignMissions)
+	s.R.Post("/missions/:id/auto-assign", s.handleAutoAssignMission)
+}
+
+func (s *Server) handleAutoAssignMission(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	// The body is optional.
+	var r models.AutoAssignRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&r); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	res, err := s.assignmentService.AutoAssignMission(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleAutoAssignMissions(c fiber.Ctx) error {
+	// The body is optional.
+	var r models.AutoAssignRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&r); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	res, err := s.assignmentService.AutoAssignMissions(c.Context(), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"regexp"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/valyala/fasthttp"
+)
+
+// multipartOverhead is the room left in the body limit for the headers and
+// the boundaries of a multipart upload.
+const multipartOverhead = 64 << 10
+
+// AttachmentService controls the attachments of the targets.
+type AttachmentService interface {
+	UploadAttachment(ctx context.Context, targetId int32, upload models.AttachmentUpload) (models.Attachment, error)
+	GetTargetAttachments(ctx context.Context, targetId int32) ([]models.Attachment, error)
+	GetAttachment(ctx context.Context, targetId, id int32) (models.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, targetId, id int32) error
+}
+
+// uploadPath matches the paths of the attachment uploads.
+var uploadPath = regexp.MustCompile(`^/missions/[^/]+/targets/[^/]+/attachments/?$`)
+
+// WithAttachmentService enables the target attachment routes. maxSize is the
+// size limit of an attachment, the body limit of the uploads is raised to fit
+// it.
+func WithAttachmentService(as AttachmentService, maxSize int64) Option {
+	return func(s *Server) {
+		s.attachmentService = as
+		s.uploadLimit = max(fiber.DefaultBodyLimit, int(maxSize)+multipartOverhead)
+	}
+}
+
+// registerAttachmentRoutes registers the target attachment routes.
+func (s *Server) registerAttachmentRoutes() {
+	// The body limit applies when the headers are read, before the routing,
+	// so the uploads are told apart by their method and path.
+	s.R.Server().HeaderReceived = func(h *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		path, _, _ := bytes.Cut(h.RequestURI(), []byte("?"))
+		if string(h.Method()) == fiber.MethodPost && uploadPath.Match(path) {
+			return fasthttp.RequestConfig{MaxRequestBodySize: s.uploadLimit}
+		}
+		return fasthttp.RequestConfig{}
+	}
+
+	attachments := s.R.Group("/missions/:id/targets/:targetId/attachments")
+	{
+		attachments.Post("/", s.handleUploadAttachment)
+		attachments.Get("/", s.handleGetTargetAttachments)
+		attachments.Get("/:attachmentId", s.handleGetAttachment)
+		attachments.Delete("/:attachmentId", s.handleDeleteAttachment)
+	}
+}
+
+// handleUploadAttachment attaches the "file" field of a multipart form to the
+// target.
+func (s *Server) handleUploadAttachment(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing file"})
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return handleError(c, err)
+	}
+	defer f.Close()
+
+	res, err := s.attachmentService.UploadAttachment(c.Context(), int32(targetId), models.AttachmentUpload{
+		Filename: fh.Filename,
+		Size:     fh.Size,
+		Content:  f,
+	})
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleGetTargetAttachments(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.attachmentService.GetTargetAttachments(c.Context(), int32(targetId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"attachments": res})
+}
+
+// handleGetAttachment downloads the content of the attachment.
+func (s *Server) handleGetAttachment(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	id, err := strconv.Atoi(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, content, err := s.attachmentService.GetAttachment(c.Context(), int32(targetId), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, res.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": res.Filename}))
+	// The content type was sniffed on upload, browsers mustn't guess another one.
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// The stream is closed once sent.
+	return c.Status(fiber.StatusOK).SendStream(content, int(res.Size))
+}
+
+func (s *Server) handleDeleteAttachment(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	id, err := strconv.Atoi(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	err = s.attachmentService.DeleteAttachment(c.Context(), int32(targetId), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// AvailabilityService controls the availability of the cats.
+type AvailabilityService interface {
+	GetCatAvailability(ctx context.Context, catID int32) ([]models.AvailabilityPeriod, error)
+	CreateAvailabilityPeriod(ctx context.Context, catID int32, req models.AvailabilityPeriodRequest) (models.AvailabilityPeriod, error)
+	UpdateAvailabilityPeriod(ctx context.Context, catID, id int32, req models.AvailabilityPeriodRequest) (models.AvailabilityPeriod, error)
+	DeleteAvailabilityPeriod(ctx context.Context, catID, id int32) error
+	GetAvailableCats(ctx context.Context, at time.Time) ([]models.Cat, error)
+}
+
+// WithAvailabilityService enables the availability routes and the
+// available_at filter of the list of cats.
+func WithAvailabilityService(as AvailabilityService) Option {
+	return func(s *Server) {
+		s.availabilityService = as
+	}
+}
+
+// registerAvailabilityRoutes registers the availability routes.
+func (s *Server) registerAvailabilityRoutes() {
+	availability := s.R.Group("/cats/:id/availability")
+	{
+		availability.Get("/", s.handleGetCatAvailability)
+		availability.Post("/", s.handleCreateAvailabilityPeriod)
+		availability.Put("/:periodId", s.handleUpdateAvailabilityPeriod)
+		availability.Delete("/:periodId", s.handleDeleteAvailabilityPeriod)
+	}
+}
+
+func (s *Server) handleGetCatAvailability(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.availabilityService.GetCatAvailability(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"periods": res})
+}
+
+func (s *Server) handleCreateAvailabilityPeriod(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.AvailabilityPeriodRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.availabilityService.CreateAvailabilityPeriod(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleUpdateAvailabilityPeriod(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	periodId, err := strconv.Atoi(c.Params("periodId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid period id"})
+	}
+
+	var r models.AvailabilityPeriodRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.availabilityService.UpdateAvailabilityPeriod(c.Context(), int32(id), int32(periodId), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteAvailabilityPeriod(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	periodId, err := strconv.Atoi(c.Params("periodId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid period id"})
+	}
+
+	err = s.availabilityService.DeleteAvailabilityPeriod(c.Context(), int32(id), int32(periodId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+// handleGetAvailableCats lists the cats available at the time of the
+// available_at query parameter.
+func (s *Server) handleGetAvailableCats(c fiber.Ctx) error {
+	if s.availabilityService == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "availability is not enabled"})
+	}
+
+	at, err := time.Parse(time.RFC3339, c.Query("available_at"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid available_at, expected an RFC 3339 time"})
+	}
+
+	res, err := s.availabilityService.GetAvailableCats(c.Context(), at)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"cats": res})
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// CostService controls the budgets and the expenses of the missions.
+type CostService interface {
+	SetMissionBudget(ctx context.Context, missionID int32, req models.MissionBudgetRequest) (models.MissionBudget, error)
+	DeleteMissionBudget(ctx context.Context, missionID int32) error
+	GetMissionExpenses(ctx context.Context, missionID int32) ([]models.Expense, error)
+	CreateMissionExpense(ctx context.Context, missionID int32, req models.CreateExpenseRequest) (models.Expense, error)
+	DeleteMissionExpense(ctx context.Context, missionID, id int32) error
+	GetMissionCosts(ctx context.Context, missionID int32) (models.MissionCosts, error)
+}
+
+// WithCostService enables the budget, expense and cost routes of the missions.
+func WithCostService(cs CostService) Option {
+	return func(s *Server) {
+		s.costService = cs
+	}
+}
+
+// registerCostRoutes registers the cost routes.
+func (s *Server) registerCostRoutes() {
+	mission := s.R.Group("/missions/:id")
+	{
+		mission.Put("/budget", s.handleSetMissionBudget)
+		mission.Delete("/budget", s.handleDeleteMissionBudget)
+		mission.Get("/expenses", s.handleGetMissionExpenses)
+		mission.Post("/expenses", s.handleCreateMissionExpense)
+		mission.Delete("/expenses/:expenseId", s.handleDeleteMissionExpense)
+		mission.Get("/costs", s.handleGetMissionCosts)
+	}
+}
+
+func (s *Server) handleSetMissionBudget(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.MissionBudgetRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.costService.SetMissionBudget(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteMissionBudget(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	err = s.costService.DeleteMissionBudget(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleGetMissionExpenses(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.costService.GetMissionExpenses(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"expenses": res})
+}
+
+func (s *Server) handleCreateMissionExpense(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.CreateExpenseRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.costService.CreateMissionExpense(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleDeleteMissionExpense(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	expenseId, err := strconv.Atoi(c.Params("expenseId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid expense id"})
+	}
+
+	err = s.costService.DeleteMissionExpense(c.Context(), int32(id), int32(expenseId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleGetMissionCosts(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.costService.GetMissionCosts(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+)
+
+// heartbeatInterval is how often an idle stream is pinged to detect closed connections.
+const heartbeatInterval = 15 * time.Second
+
+// registerEventRoutes registers the mission event stream routes.
+func (s *Server) registerEventRoutes() {
+	s.R.Get("/events", s.handleGetEvents)
+	s.R.Get("/missions/:id/events", s.handleGetMissionEvents)
+}
+
+func (s *Server) handleGetEvents(c fiber.Ctx) error {
+	return s.streamEvents(c, 0)
+}
+
+func (s *Server) handleGetMissionEvents(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	// Make sure the mission exists before opening the stream.
+	if _, err := s.missionService.GetMission(c.Context(), int32(id)); err != nil {
+		return handleError(c, err)
+	}
+
+	return s.streamEvents(c, int32(id))
+}
+
+// streamEvents streams mission events as Server-Sent Events.
+//
+// Events published after the one in the Last-Event-ID header are replayed
+// first. An ID from before a restart or followed by events no longer buffered
+// is rejected as stale.
+func (s *Server) streamEvents(c fiber.Ctx, missionID int32) error {
+	sub, replay, err := s.eventStream.Subscribe(missionID, c.Get("Last-Event-ID"))
+	switch {
+	case errors.Is(err, events.ErrInvalidEventID):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid Last-Event-ID"})
+	case errors.Is(err, events.ErrStaleEventID):
+		// The events since are lost, the client must reload the missions.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "stale Last-Event-ID"})
+	case err != nil:
+		return handleError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		for _, e := range replay {
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+			}
+
+			// Flush fails once the client has gone away.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func writeEvent(w *bufio.Writer, e events.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.StreamID(), e.Type, data)
+	return err
+}
@@ -0,0 +1,2402 @@
+package server
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/assign"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+	"github.com/rsmanito/developstoday-test-assessment/internal/fuzzy"
+	"github.com/rsmanito/developstoday-test-assessment/internal/geo"
+	"github.com/rsmanito/developstoday-test-assessment/internal/markdown"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/snapshot"
+)
+
+// fakeTime is used for all timestamps, so responses are stable.
+var fakeTime = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeEpoch is the epoch of the events of the fake.
+const fakeEpoch = "boot"
+
+// errFake is returned by the fake when asked to fail with an unexpected error.
+var errFake = errors.New("unexpected error")
+
+// fakeService is an in-process service layer for the server tests.
+//
+// It keeps just enough state for the scenarios to chain requests and mimics
+// the errors of the real service. Ids start from 1 in every fake.
+type fakeService struct {
+	mu sync.Mutex
+
+	nextID    int32
+	cats      map[int32]models.Cat
+	missions  map[int32]models.Mission
+	webhooks  map[int32]models.Webhook
+	templates map[int32]models.MissionTemplate
+	periods   map[int32]models.AvailabilityPeriod
+	skills    map[int32]models.Skill
+	// catSkills and targetSkills are keyed by the cat or the target and the skill.
+	catSkills    map[[2]int32]models.CatSkill
+	targetSkills map[[2]int32]models.TargetSkill
+	budgets      map[int32]models.MissionBudget
+	expenses     map[int32]models.Expense
+	// blockOverBudget blocks the expenses over budget instead of warning.
+	blockOverBudget bool
+	// rates are the exchange rates to USD, the reporting currency.
+	rates map[string]models.ExchangeRate
+	// subjects have IDs of their own, so linking the targets to subjects
+	// doesn't shift the IDs of the other entities.
+	subjects      map[int32]models.Subject
+	nextSubjectID int32
+	notes         []models.TargetNoteChange
+	attachments   map[int32]fakeAttachment
+
+	deliveries []models.WebhookDelivery
+	moves      []models.TargetMove
+	outbox     []models.OutboxEvent
+	events     []events.Event
+}
+
+var (
+	_ CatService          = (*fakeService)(nil)
+	_ MissionService      = (*fakeService)(nil)
+	_ TargetService       = (*fakeService)(nil)
+	_ EventStream         = (*fakeService)(nil)
+	_ WebhookService      = (*fakeService)(nil)
+	_ OutboxService       = (*fakeService)(nil)
+	_ SearchService       = (*fakeService)(nil)
+	_ TemplateService     = (*fakeService)(nil)
+	_ ImportService       = (*fakeService)(nil)
+	_ SnapshotService     = (*fakeService)(nil)
+	_ ReportService       = (*fakeService)(nil)
+	_ AnalyticsService    = (*fakeService)(nil)
+	_ AvailabilityService = (*fakeService)(nil)
+	_ SkillService        = (*fakeService)(nil)
+	_ AssignmentService   = (*fakeService)(nil)
+	_ CostService         = (*fakeService)(nil)
+	_ RateService         = (*fakeService)(nil)
+	_ LocationService     = (*fakeService)(nil)
+	_ SubjectService      = (*fakeService)(nil)
+	_ AttachmentService   = (*fakeService)(nil)
+	_ NoteService         = (*fakeService)(nil)
+)
+
+// fakeReportingCurrency is the reporting currency of the fake.
+const fakeReportingCurrency = "USD"
+
+// fakeMaxAttachmentSize is the size limit of the attachments of the fake.
+const fakeMaxAttachmentSize = 64
+
+// fakeSchemaVersion is the schema version of the fake database.
+const fakeSchemaVersion = 20250324120000
+
+func newFakeService() *fakeService {
+	return &fakeService{
+		nextID:        1,
+		cats:          make(map[int32]models.Cat),
+		missions:      make(map[int32]models.Mission),
+		webhooks:      make(map[int32]models.Webhook),
+		templates:     make(map[int32]models.MissionTemplate),
+		periods:       make(map[int32]models.AvailabilityPeriod),
+		skills:        make(map[int32]models.Skill),
+		catSkills:     make(map[[2]int32]models.CatSkill),
+		targetSkills:  make(map[[2]int32]models.TargetSkill),
+		budgets:       make(map[int32]models.MissionBudget),
+		expenses:      make(map[int32]models.Expense),
+		rates:         make(map[string]models.ExchangeRate),
+		subjects:      make(map[int32]models.Subject),
+		nextSubjectID: 1,
+		attachments:   make(map[int32]fakeAttachment),
+	}
+}
+
+func (f *fakeService) id() int32 {
+	id := f.nextID
+	f.nextID++
+	return id
+}
+
+func sorted[T any](m map[int32]T) []T {
+	ids := make([]int32, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	res := make([]T, len(ids))
+	for i, id := range ids {
+		res[i] = m[id]
+	}
+	return res
+}
+
+func (f *fakeService) GetAllCats(ctx context.Context) ([]models.Cat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cats := sorted(f.cats)
+	rates := f.exchangeRates()
+	for i, c := range cats {
+		cats[i].ReportingSalary = fakeReporting(rates, c.Salary, c.SalaryCurrency)
+	}
+	return cats, nil
+}
+
+func (f *fakeService) CreateCat(ctx context.Context, req models.CreateCatRequest) (models.Cat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Breed == "Unknown" {
+		return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "Unknown breed")
+	}
+	code := fakeReportingCurrency
+	if req.SalaryCurrency != "" {
+		var err error
+		if code, err = currency.Parse(req.SalaryCurrency); err != nil {
+			return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "salary_currency must be an ISO 4217 code")
+		}
+	}
+
+	cat := models.Cat{
+		ID:                f.id(),
+		Name:              req.Name,
+		Breed:             req.Breed,
+		YearsOfExperience: req.YearsOfExperience,
+		Salary:            req.Salary,
+		SalaryCurrency:    code,
+	}
+	f.cats[cat.ID] = cat
+
+	return cat, nil
+}
+
+func (f *fakeService) GetCat(ctx context.Context, id int32) (models.Cat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cat, ok := f.cats[id]
+	if !ok {
+		return models.Cat{}, models.ErrNotFound
+	}
+	return cat, nil
+}
+
+func (f *fakeService) UpdateCatSalary(ctx context.Context, req models.UpdateCatSalaryRequest, id int32) (models.Cat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	cat, ok := f.cats[id]
+	if !ok {
+		return models.Cat{}, models.ErrNotFound
+	}
+	if req.Salary < 0 {
+		return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "salary must be greater than or equal to 0")
+	}
+
+	if req.SalaryCurrency != "" {
+		code, err := currency.Parse(req.SalaryCurrency)
+		if err != nil {
+			return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "salary_currency must be an ISO 4217 code")
+		}
+		cat.SalaryCurrency = code
+	}
+	cat.Salary = req.Salary
+	f.cats[id] = cat
+
+	return cat, nil
+}
+
+func (f *fakeService) DeleteCat(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cats[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.cats, id)
+	for pid, p := range f.periods {
+		if p.CatID == id {
+			delete(f.periods, pid)
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeService) GetAllMissions(ctx context.Context) ([]models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return sorted(f.missions), nil
+}
+
+func (f *fakeService) CreateMission(ctx context.Context, req models.CreateMissionRequest) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(req.Targets) < 1 || len(req.Targets) > 3 {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "incorrect number of targets (1..3)")
+	}
+
+	for _, t := range req.Targets {
+		if err := f.checkSubject(t.SubjectID); err != nil {
+			return models.Mission{}, err
+		}
+	}
+
+	m := models.Mission{ID: f.id(), Targets: make([]models.Target, 0, len(req.Targets))}
+	var suggestions [][]models.SubjectMatch
+	for _, t := range req.Targets {
+		subject, matches := f.linkSubject(t.SubjectID, t.Name, t.Country)
+		m.Targets = append(m.Targets, models.Target{ID: f.id(), Name: t.Name, Country: t.Country, Notes: t.Notes, Latitude: t.Latitude, Longitude: t.Longitude, Address: t.Address, SubjectID: subject})
+		suggestions = append(suggestions, matches)
+	}
+	f.missions[m.ID] = m
+
+	// Only the response has the suggestions.
+	res := m
+	res.Targets = slices.Clone(m.Targets)
+	for i := range res.Targets {
+		res.Targets[i].Suggestions = suggestions[i]
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) GetMission(ctx context.Context, id int32) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.missions[id]
+	if !ok {
+		return models.Mission{}, models.ErrNotFound
+	}
+	return m, nil
+}
+
+// The fake assigns the missions at fakeTime.
+
+func (f *fakeService) AssignCatToMission(ctx context.Context, missionID int32, req models.AssignCatRequest) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cats[req.Assignee]; !ok {
+		return models.Mission{}, models.NewError(http.StatusNotFound, "cat not found")
+	}
+	m, ok := f.missions[missionID]
+	if !ok {
+		return models.Mission{}, models.NewError(http.StatusNotFound, "mission not found")
+	}
+
+	until := fakeTime
+	if req.Until != nil {
+		if !req.Until.After(fakeTime) {
+			return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "until must be in the future")
+		}
+		until = *req.Until
+	}
+
+	var warnings []string
+	var conflicts []string
+	for _, p := range sorted(f.periods) {
+		if p.CatID == req.Assignee && p.EndsAt.After(fakeTime) && !p.StartsAt.After(until) {
+			conflicts = append(conflicts, fmt.Sprintf("%s from %s to %s", p.Kind, p.StartsAt.Format(time.RFC3339), p.EndsAt.Format(time.RFC3339)))
+		}
+	}
+	if len(conflicts) > 0 {
+		conflict := "cat is unavailable: " + strings.Join(conflicts, ", ")
+		if !req.Override {
+			return models.Mission{}, models.NewError(http.StatusConflict, conflict)
+		}
+		warnings = append(warnings, conflict)
+	}
+
+	m.Assignee = req.Assignee
+	f.missions[missionID] = m
+
+	m.Warnings = warnings
+	return m, nil
+}
+
+func (f *fakeService) CompleteMission(ctx context.Context, id int32) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.missions[id]
+	if !ok {
+		return models.Mission{}, models.ErrNotFound
+	}
+	for _, t := range m.Targets {
+		if !t.Completed {
+			return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission has pending targets")
+		}
+	}
+
+	m.Completed = true
+	f.missions[id] = m
+
+	return m, nil
+}
+
+func (f *fakeService) DeleteMission(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.missions[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+	if m.Assignee != 0 {
+		return models.NewError(http.StatusUnprocessableEntity, "can't delete an assigned mission")
+	}
+	delete(f.missions, id)
+
+	return nil
+}
+
+func (f *fakeService) AddTarget(ctx context.Context, missionID int32, req models.CreateTargetRequest) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.missions[missionID]
+	if !ok {
+		return models.Mission{}, models.ErrNotFound
+	}
+	if len(m.Targets) >= 3 {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission has maximum targets (3)")
+	}
+
+	if err := f.checkSubject(req.SubjectID); err != nil {
+		return models.Mission{}, err
+	}
+
+	subject, suggestions := f.linkSubject(req.SubjectID, req.Name, req.Country)
+	m.Targets = append(m.Targets, models.Target{ID: f.id(), Name: req.Name, Country: req.Country, Notes: req.Notes, Latitude: req.Latitude, Longitude: req.Longitude, Address: req.Address, SubjectID: subject})
+	f.missions[missionID] = m
+
+	res := m
+	res.Targets = slices.Clone(m.Targets)
+	res.Targets[len(res.Targets)-1].Suggestions = suggestions
+
+	return res, nil
+}
+
+func (f *fakeService) CloneMission(ctx context.Context, id int32, req models.CloneMissionRequest) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, ok := f.missions[id]
+	if !ok {
+		return models.Mission{}, models.ErrNotFound
+	}
+
+	m := models.Mission{ID: f.id()}
+	for _, t := range source.Targets {
+		if t.Completed {
+			continue
+		}
+		clone := models.Target{ID: f.id(), Name: t.Name, Country: t.Country, Latitude: t.Latitude, Longitude: t.Longitude, Address: t.Address, SubjectID: t.SubjectID}
+		if req.Notes {
+			clone.Notes = t.Notes
+		}
+		m.Targets = append(m.Targets, clone)
+	}
+	if len(m.Targets) == 0 {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission has no pending targets")
+	}
+	f.missions[m.ID] = m
+
+	return m, nil
+}
+
+func (f *fakeService) SplitMission(ctx context.Context, id int32, req models.SplitMissionRequest) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, ok := f.missions[id]
+	if !ok {
+		return models.Mission{}, models.ErrNotFound
+	}
+	if len(req.Targets) == 0 || len(req.Targets) >= len(source.Targets) {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "can't move all targets of the mission")
+	}
+
+	var m models.Mission
+	var left []models.Target
+	for _, t := range source.Targets {
+		if !slices.Contains(req.Targets, t.ID) {
+			left = append(left, t)
+			continue
+		}
+		if t.Completed {
+			return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("can't move completed target %d", t.ID))
+		}
+		m.Targets = append(m.Targets, t)
+	}
+	if len(m.Targets) != len(req.Targets) {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "target doesn't belong to the mission")
+	}
+
+	source.Targets = left
+	f.missions[id] = source
+	m.ID = f.id()
+	f.missions[m.ID] = m
+
+	return m, nil
+}
+
+// target calls fn with the target and its mission. Changes fn makes to the target are saved.
+func (f *fakeService) target(id int32, fn func(m *models.Mission, t *models.Target) error) (models.Target, error) {
+	for _, m := range f.missions {
+		for i := range m.Targets {
+			if m.Targets[i].ID != id {
+				continue
+			}
+
+			// Don't share the targets with the missions returned before.
+			m.Targets = append([]models.Target(nil), m.Targets...)
+			if err := fn(&m, &m.Targets[i]); err != nil {
+				return models.Target{}, err
+			}
+			f.missions[m.ID] = m
+
+			return m.Targets[i], nil
+		}
+	}
+
+	return models.Target{}, models.ErrNotFound
+}
+
+func (f *fakeService) DeleteTarget(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, m := range f.missions {
+		for i, t := range m.Targets {
+			if t.ID == id {
+				m.Targets = append(m.Targets[:i:i], m.Targets[i+1:]...)
+				f.missions[m.ID] = m
+				return nil
+			}
+		}
+	}
+
+	return models.ErrNotFound
+}
+
+func (f *fakeService) UpdateTargetNotes(ctx context.Context, id int32, notes string) (models.Target, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.target(id, func(m *models.Mission, t *models.Target) error {
+		if t.Completed {
+			return models.NewError(http.StatusUnprocessableEntity, "Can't change notes of a completed target")
+		}
+		f.notes = append(f.notes, models.TargetNoteChange{TargetID: t.ID, MissionID: m.ID, PreviousNotes: t.Notes, Notes: notes, UpdatedAt: fakeTime})
+		t.Notes = notes
+		return nil
+	})
+}
+
+func (f *fakeService) CompleteTarget(ctx context.Context, id int32) (models.Target, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.target(id, func(m *models.Mission, t *models.Target) error {
+		t.Completed = true
+		return nil
+	})
+}
+
+func (f *fakeService) MoveTarget(ctx context.Context, missionID, targetID int32, req models.MoveTargetRequest) (models.Mission, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, ok := f.missions[missionID]
+	i := slices.IndexFunc(source.Targets, func(t models.Target) bool { return t.ID == targetID })
+	if !ok || i < 0 {
+		return models.Mission{}, models.ErrNotFound
+	}
+	if req.Mission == missionID {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "target is already in the mission")
+	}
+	destination, ok := f.missions[req.Mission]
+	if !ok {
+		return models.Mission{}, models.NewError(http.StatusNotFound, "destination mission not found")
+	}
+	if source.Completed || destination.Completed {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "can't move targets of a completed mission")
+	}
+	if len(source.Targets) <= 1 {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission must keep at least one target")
+	}
+	if len(destination.Targets) >= 3 {
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission has maximum targets (3)")
+	}
+
+	target := source.Targets[i]
+	source.Targets = slices.Delete(slices.Clone(source.Targets), i, i+1)
+	destination.Targets = append(slices.Clone(destination.Targets), target)
+	f.missions[missionID] = source
+	f.missions[req.Mission] = destination
+
+	f.moves = append(f.moves, models.TargetMove{
+		ID:          int32(len(f.moves) + 1),
+		Target:      targetID,
+		FromMission: missionID,
+		ToMission:   req.Mission,
+		MovedAt:     fakeTime,
+	})
+
+	return destination, nil
+}
+
+func (f *fakeService) GetTargetMoves(ctx context.Context, targetID int32) ([]models.TargetMove, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	moves := make([]models.TargetMove, 0)
+	for _, m := range f.moves {
+		if m.Target == targetID {
+			moves = append(moves, m)
+		}
+	}
+
+	return moves, nil
+}
+
+// Subscribe replays the events of the fake and ends the stream, so the
+// response of a stream request is complete.
+func (f *fakeService) Subscribe(missionID int32, lastEventID string) (*events.Subscription, []events.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var lastID uint64
+	if lastEventID != "" {
+		epoch, id, err := events.ParseEventID(lastEventID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if epoch != fakeEpoch {
+			return nil, nil, events.ErrStaleEventID
+		}
+		lastID = id
+	}
+
+	bus := events.NewBus(1)
+	bus.Close()
+	sub, _, _ := bus.Subscribe(missionID, "")
+
+	var replay []events.Event
+	for _, e := range f.events {
+		if lastID > 0 && e.ID > lastID && (missionID == 0 || e.MissionID == missionID) {
+			replay = append(replay, e)
+		}
+	}
+
+	return sub, replay, nil
+}
+
+func (f *fakeService) GetAllWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return sorted(f.webhooks), nil
+}
+
+func (f *fakeService) CreateWebhook(ctx context.Context, req models.CreateWebhookRequest) (models.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(req.EventTypes) == 0 {
+		return models.Webhook{}, models.NewError(http.StatusUnprocessableEntity, "at least one event type is required")
+	}
+
+	w := models.Webhook{
+		ID:         f.id(),
+		URL:        req.URL,
+		Secret:     "secret",
+		EventTypes: req.EventTypes,
+		Active:     true,
+		CreatedAt:  fakeTime,
+	}
+	f.webhooks[w.ID] = w
+
+	// The secret is only returned on creation.
+	res := w
+	w.Secret = ""
+	f.webhooks[w.ID] = w
+
+	return res, nil
+}
+
+func (f *fakeService) GetWebhook(ctx context.Context, id int32) (models.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w, ok := f.webhooks[id]
+	if !ok {
+		return models.Webhook{}, models.ErrNotFound
+	}
+	return w, nil
+}
+
+func (f *fakeService) UpdateWebhook(ctx context.Context, id int32, req models.UpdateWebhookRequest) (models.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w, ok := f.webhooks[id]
+	if !ok {
+		return models.Webhook{}, models.ErrNotFound
+	}
+	if req.URL != nil {
+		w.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		w.EventTypes = req.EventTypes
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+	f.webhooks[id] = w
+
+	return w, nil
+}
+
+func (f *fakeService) DeleteWebhook(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.webhooks[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.webhooks, id)
+
+	return nil
+}
+
+func (f *fakeService) GetWebhookDeliveries(ctx context.Context, id int32) ([]models.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.webhooks[id]; !ok {
+		return nil, models.ErrNotFound
+	}
+
+	res := make([]models.WebhookDelivery, 0)
+	for _, d := range f.deliveries {
+		if d.Webhook == id {
+			res = append(res, d)
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) RedeliverWebhookDelivery(ctx context.Context, id, deliveryID int32) (models.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, d := range f.deliveries {
+		if d.ID == deliveryID && d.Webhook == id {
+			d.Status = "pending"
+			d.NextAttemptAt = fakeTime
+			f.deliveries[i] = d
+			return d, nil
+		}
+	}
+
+	return models.WebhookDelivery{}, models.ErrNotFound
+}
+
+func (f *fakeService) GetOutboxEvents(ctx context.Context, fromOffset int64, limit int32) ([]models.OutboxEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if limit < 1 || limit > 1000 {
+		return make([]models.OutboxEvent, 0), models.NewError(http.StatusUnprocessableEntity, "limit must be between 1 and 1000")
+	}
+
+	res := make([]models.OutboxEvent, 0)
+	for _, e := range f.outbox {
+		if e.Offset >= fromOffset && len(res) < int(limit) {
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) ReplayOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if fromOffset < 1 {
+		return 0, models.NewError(http.StatusUnprocessableEntity, "offset must be greater than 0")
+	}
+
+	var n int64
+	for i, e := range f.outbox {
+		if e.Offset >= fromOffset {
+			f.outbox[i].PublishedAt = nil
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (f *fakeService) SearchTargets(ctx context.Context, query string, limit int32) ([]models.TargetSearchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case limit < 1 || limit > 100:
+		return make([]models.TargetSearchResult, 0), models.NewError(http.StatusUnprocessableEntity, "limit must be between 1 and 100")
+	case query == "":
+		return make([]models.TargetSearchResult, 0), models.NewError(http.StatusUnprocessableEntity, "query must contain at least one word")
+	case query == "fail":
+		return nil, errFake
+	}
+
+	// Matches the target names exactly.
+	res := make([]models.TargetSearchResult, 0)
+	for _, m := range sorted(f.missions) {
+		for _, t := range m.Targets {
+			if t.Name == query && len(res) < int(limit) {
+				res = append(res, models.TargetSearchResult{
+					Target:  t,
+					Mission: models.Mission{ID: m.ID, Assignee: m.Assignee, Completed: m.Completed},
+					Rank:    1,
+					Snippet: "<mark>" + t.Name + "</mark>",
+				})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) GetAllMissionTemplates(ctx context.Context) ([]models.MissionTemplate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return sorted(f.templates), nil
+}
+
+func (f *fakeService) CreateMissionTemplate(ctx context.Context, req models.CreateMissionTemplateRequest) (models.MissionTemplate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(req.Targets) < 1 || len(req.Targets) > 3 {
+		return models.MissionTemplate{}, models.NewError(http.StatusUnprocessableEntity, "incorrect number of targets (1..3)")
+	}
+
+	t := models.MissionTemplate{
+		ID:        f.id(),
+		Name:      req.Name,
+		Targets:   req.Targets,
+		Variables: fakeVariables(req.Targets),
+		CreatedAt: fakeTime,
+	}
+	f.templates[t.ID] = t
+
+	return t, nil
+}
+
+func (f *fakeService) GetMissionTemplate(ctx context.Context, id int32) (models.MissionTemplate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.templates[id]
+	if !ok {
+		return models.MissionTemplate{}, models.ErrNotFound
+	}
+	return t, nil
+}
+
+func (f *fakeService) UpdateMissionTemplate(ctx context.Context, id int32, req models.UpdateMissionTemplateRequest) (models.MissionTemplate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, ok := f.templates[id]
+	if !ok {
+		return models.MissionTemplate{}, models.ErrNotFound
+	}
+	if req.Name != nil {
+		t.Name = *req.Name
+	}
+	if req.Targets != nil {
+		t.Targets = req.Targets
+		t.Variables = fakeVariables(req.Targets)
+	}
+	f.templates[id] = t
+
+	return t, nil
+}
+
+func (f *fakeService) DeleteMissionTemplate(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.templates[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.templates, id)
+
+	return nil
+}
+
+func (f *fakeService) CreateMissionFromTemplate(ctx context.Context, id int32, req models.CreateMissionFromTemplateRequest) (models.Mission, error) {
+	t, err := f.GetMissionTemplate(ctx, id)
+	if err != nil {
+		return models.Mission{}, err
+	}
+
+	for _, v := range t.Variables {
+		if _, ok := req.Variables[v]; !ok {
+			return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "missing variables: "+v)
+		}
+	}
+
+	mission := models.CreateMissionRequest{}
+	for _, b := range t.Targets {
+		target := models.CreateTargetRequest{Name: b.Name, Country: b.Country, Notes: b.Notes}
+		for k, v := range req.Variables {
+			target.Name = strings.ReplaceAll(target.Name, "{{"+k+"}}", v)
+			target.Country = strings.ReplaceAll(target.Country, "{{"+k+"}}", v)
+			target.Notes = strings.ReplaceAll(target.Notes, "{{"+k+"}}", v)
+		}
+		mission.Targets = append(mission.Targets, target)
+	}
+
+	return f.CreateMission(ctx, mission)
+}
+
+func (f *fakeService) ImportCats(ctx context.Context, rows []models.CatImportRow, opts models.ImportOptions) (models.ImportResult, error) {
+	return fakeImport(opts, len(rows), func(i int) (int, []string) {
+		// Like the service, only rows read without problems are validated.
+		errs := rows[i].Errors
+		if len(errs) > 0 {
+			return rows[i].Line, errs
+		}
+		if rows[i].Cat.Name == "" {
+			errs = append(errs, "missing field: name")
+		}
+		if rows[i].Cat.Breed == "Unknown" {
+			errs = append(errs, "Unknown breed")
+		}
+		return rows[i].Line, errs
+	}, func(i int) (int32, error) {
+		cat, err := f.CreateCat(ctx, rows[i].Cat)
+		return cat.ID, err
+	})
+}
+
+func (f *fakeService) ImportMissions(ctx context.Context, rows []models.MissionImportRow, opts models.ImportOptions) (models.ImportResult, error) {
+	return fakeImport(opts, len(rows), func(i int) (int, []string) {
+		errs := rows[i].Errors
+		if len(errs) > 0 {
+			return rows[i].Line, errs
+		}
+		if n := len(rows[i].Mission.Targets); n < 1 || n > 3 {
+			errs = append(errs, "incorrect number of targets (1..3)")
+		}
+		return rows[i].Line, errs
+	}, func(i int) (int32, error) {
+		mission, err := f.CreateMission(ctx, rows[i].Mission)
+		return mission.ID, err
+	})
+}
+
+// fakeImport validates n rows and creates the valid ones like the service.
+func fakeImport(opts models.ImportOptions, n int, validate func(i int) (int, []string), create func(i int) (int32, error)) (models.ImportResult, error) {
+	if opts.Mode != models.ImportAllOrNothing && opts.Mode != models.ImportBestEffort {
+		return models.ImportResult{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("unknown import mode: %q", opts.Mode))
+	}
+	if n == 0 {
+		return models.ImportResult{}, models.NewError(http.StatusUnprocessableEntity, "nothing to import")
+	}
+
+	res := models.ImportResult{Mode: opts.Mode, DryRun: opts.DryRun, Total: n}
+	for i := range n {
+		line, errs := validate(i)
+		row := models.ImportRowResult{Line: line, Status: models.ImportRowValid, Errors: errs}
+		if len(errs) > 0 {
+			row.Status = models.ImportRowInvalid
+			res.Invalid++
+		} else {
+			res.Valid++
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	if opts.DryRun || (res.Invalid > 0 && opts.Mode == models.ImportAllOrNothing) {
+		return res, nil
+	}
+
+	for i := range res.Rows {
+		if res.Rows[i].Status != models.ImportRowValid {
+			continue
+		}
+		id, err := create(i)
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		res.Rows[i].Status, res.Rows[i].ID = models.ImportRowCreated, id
+		res.Created++
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) ExportSnapshot(ctx context.Context, w io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sw, err := snapshot.NewWriter(w, snapshot.Metadata{FormatVersion: snapshot.FormatVersion, SchemaVersion: fakeSchemaVersion, CreatedAt: fakeTime})
+	if err != nil {
+		return err
+	}
+	for _, c := range sorted(f.cats) {
+		if err := sw.WriteCat(snapshot.Cat{ID: c.ID, Name: c.Name, Breed: c.Breed, YearsOfExperience: c.YearsOfExperience, Salary: currency.MinorUnits(int64(c.Salary), c.SalaryCurrency), SalaryCurrency: c.SalaryCurrency}); err != nil {
+			return err
+		}
+	}
+	for _, s := range sorted(f.subjects) {
+		if err := sw.WriteSubject(snapshot.Subject{ID: s.ID, Name: s.Name, Country: s.Country, CreatedAt: fakeTime}); err != nil {
+			return err
+		}
+	}
+	missions := sorted(f.missions)
+	for _, m := range missions {
+		var assignee *int32
+		if m.Assignee != 0 {
+			assignee = &m.Assignee
+		}
+		if err := sw.WriteMission(snapshot.Mission{ID: m.ID, Assignee: assignee, Completed: m.Completed, CreatedAt: fakeTime}); err != nil {
+			return err
+		}
+	}
+	for _, m := range missions {
+		for _, t := range m.Targets {
+			var subject *int32
+			if t.SubjectID != 0 {
+				subject = &t.SubjectID
+			}
+			if err := sw.WriteTarget(snapshot.Target{ID: t.ID, Mission: m.ID, Name: t.Name, Country: t.Country, Notes: t.Notes, Completed: t.Completed, CreatedAt: fakeTime, Latitude: t.Latitude, Longitude: t.Longitude, Address: t.Address, Subject: subject}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return sw.Close()
+}
+
+func (f *fakeService) RestoreSnapshot(ctx context.Context, r io.Reader) (models.RestoreResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sr, md, err := snapshot.NewReader(r)
+	if err != nil {
+		return models.RestoreResult{}, models.NewError(http.StatusUnprocessableEntity, "invalid snapshot: "+err.Error())
+	}
+	if md.SchemaVersion != fakeSchemaVersion {
+		return models.RestoreResult{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("snapshot schema version %d doesn't match the database schema version %d", md.SchemaVersion, fakeSchemaVersion))
+	}
+	if len(f.cats) > 0 || len(f.subjects) > 0 || len(f.missions) > 0 {
+		return models.RestoreResult{}, models.NewError(http.StatusConflict, "database is not empty")
+	}
+
+	// Like a transaction, nothing is restored if the archive is invalid.
+	var entities []any
+	for {
+		e, err := sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return models.RestoreResult{}, models.NewError(http.StatusUnprocessableEntity, "invalid snapshot: "+err.Error())
+		}
+		entities = append(entities, e)
+	}
+
+	res := models.RestoreResult{SchemaVersion: fakeSchemaVersion}
+	for _, e := range entities {
+		switch e := e.(type) {
+		case snapshot.Cat:
+			f.cats[e.ID] = models.Cat{ID: e.ID, Name: e.Name, Breed: e.Breed, YearsOfExperience: e.YearsOfExperience, Salary: int32(currency.MajorUnits(e.Salary, e.SalaryCurrency)), SalaryCurrency: e.SalaryCurrency}
+			f.nextID = max(f.nextID, e.ID+1)
+			res.Cats++
+		case snapshot.Subject:
+			f.subjects[e.ID] = models.Subject{ID: e.ID, Name: e.Name, Country: e.Country, CreatedAt: e.CreatedAt}
+			f.nextSubjectID = max(f.nextSubjectID, e.ID+1)
+			res.Subjects++
+		case snapshot.Mission:
+			m := models.Mission{ID: e.ID, Completed: e.Completed, Targets: []models.Target{}}
+			if e.Assignee != nil {
+				m.Assignee = *e.Assignee
+			}
+			f.missions[e.ID] = m
+			f.nextID = max(f.nextID, e.ID+1)
+			res.Missions++
+		case snapshot.Target:
+			m := f.missions[e.Mission]
+			t := models.Target{ID: e.ID, Name: e.Name, Country: e.Country, Notes: e.Notes, Completed: e.Completed, Latitude: e.Latitude, Longitude: e.Longitude, Address: e.Address}
+			if e.Subject != nil {
+				t.SubjectID = *e.Subject
+			}
+			m.Targets = append(m.Targets, t)
+			f.missions[e.Mission] = m
+			f.nextID = max(f.nextID, e.ID+1)
+			res.Targets++
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) EachCat(ctx context.Context, fn func(models.Cat) error) error {
+	f.mu.Lock()
+	cats := sorted(f.cats)
+	rates := f.exchangeRates()
+	f.mu.Unlock()
+
+	for _, c := range cats {
+		c.ReportingSalary = fakeReporting(rates, c.Salary, c.SalaryCurrency)
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeService) EachMission(ctx context.Context, fn func(models.Mission) error) error {
+	f.mu.Lock()
+	missions := sorted(f.missions)
+	f.mu.Unlock()
+
+	for _, m := range missions {
+		m.Targets = nil
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeService) EachMissionTarget(ctx context.Context, fn func(models.MissionTargetRow) error) error {
+	f.mu.Lock()
+	missions := sorted(f.missions)
+	f.mu.Unlock()
+
+	for _, m := range missions {
+		for _, t := range m.Targets {
+			err := fn(models.MissionTargetRow{
+				MissionID:        m.ID,
+				Assignee:         m.Assignee,
+				MissionCompleted: m.Completed,
+				TargetID:         t.ID,
+				Name:             t.Name,
+				Country:          t.Country,
+				Notes:            t.Notes,
+				TargetCompleted:  t.Completed,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeService) EachCatWorkload(ctx context.Context, fn func(models.CatWorkload) error) error {
+	f.mu.Lock()
+	cats := sorted(f.cats)
+	missions := sorted(f.missions)
+	rates := f.exchangeRates()
+	f.mu.Unlock()
+
+	for _, c := range cats {
+		w := models.CatWorkload{CatID: c.ID, Name: c.Name, Breed: c.Breed, Salary: c.Salary, SalaryCurrency: c.SalaryCurrency}
+		w.ReportingSalary = fakeReporting(rates, c.Salary, c.SalaryCurrency)
+		for _, m := range missions {
+			if m.Assignee != c.ID {
+				continue
+			}
+			if m.Completed {
+				w.CompletedMissions++
+			} else {
+				w.ActiveMissions++
+			}
+			for _, t := range m.Targets {
+				if t.Completed {
+					w.CompletedTargets++
+				} else {
+					w.OpenTargets++
+				}
+			}
+		}
+		if err := fn(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeVariables returns the {{variable}} placeholders of the blueprints.
+func fakeVariables(targets []models.TargetBlueprint) []string {
+	variables := make([]string, 0)
+	for _, t := range targets {
+		for _, s := range []string{t.Name, t.Country, t.Notes} {
+			for {
+				start := strings.Index(s, "{{")
+				end := strings.Index(s, "}}")
+				if start < 0 || end < start {
+					break
+				}
+				if v := s[start+2 : end]; !slices.Contains(variables, v) {
+					variables = append(variables, v)
+				}
+				s = s[end+2:]
+			}
+		}
+	}
+
+	return variables
+}
+
+// seedEvents adds events to the outbox, the webhook deliveries and the event stream.
+func (f *fakeService) seedEvents(webhook int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payload := json.RawMessage(`{"mission_id":1}`)
+	code := int32(500)
+
+	f.outbox = append(f.outbox,
+		models.OutboxEvent{Offset: 1, AggregateType: "mission", AggregateID: 1, Type: events.TargetCompleted, Payload: payload, CreatedAt: fakeTime, PublishedAt: &fakeTime},
+		models.OutboxEvent{Offset: 2, AggregateType: "mission", AggregateID: 1, Type: events.MissionCompleted, Payload: payload, CreatedAt: fakeTime, PublishedAt: &fakeTime},
+	)
+	f.deliveries = append(f.deliveries, models.WebhookDelivery{
+		ID:               1,
+		Webhook:          webhook,
+		EventType:        events.MissionCompleted,
+		Payload:          payload,
+		Status:           "dead",
+		Attempts:         8,
+		NextAttemptAt:    fakeTime,
+		LastError:        "unexpected status code 500",
+		LastResponseCode: &code,
+		CreatedAt:        fakeTime,
+	})
+	f.events = append(f.events,
+		events.Event{ID: 1, Epoch: fakeEpoch, Type: events.TargetCompleted, MissionID: 1, Data: map[string]int32{"target_id": 2}, CreatedAt: fakeTime},
+		events.Event{ID: 2, Epoch: fakeEpoch, Type: events.MissionCompleted, MissionID: 1, Data: map[string]int32{"mission_id": 1}, CreatedAt: fakeTime},
+		events.Event{ID: 3, Epoch: fakeEpoch, Type: events.CatAssigned, MissionID: 5, Data: map[string]int32{"mission_id": 5}, CreatedAt: fakeTime},
+	)
+}
+
+// The fake creates everything at fakeTime, assigns the missions on creation
+// and completes them an hour later. The active missions have lasted a day.
+
+func (f *fakeService) GetCompletionRate(ctx context.Context, window string, periods int) (models.CompletionRate, error) {
+	var start time.Time
+	switch window {
+	case "day":
+		start = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	case "week":
+		start = time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC)
+	case "month":
+		start = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return models.CompletionRate{}, models.NewError(http.StatusUnprocessableEntity, "window must be one of day, week, month")
+	}
+	if periods < 1 || periods > 366 {
+		return models.CompletionRate{}, models.NewError(http.StatusUnprocessableEntity, "periods must be between 1 and 366")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rate := models.CompletionRate{Window: window, Periods: make([]models.CompletionRatePeriod, periods)}
+	for i := range rate.Periods {
+		n := i - periods + 1
+		switch window {
+		case "day":
+			rate.Periods[i].Start = start.AddDate(0, 0, n)
+		case "week":
+			rate.Periods[i].Start = start.AddDate(0, 0, 7*n)
+		case "month":
+			rate.Periods[i].Start = start.AddDate(0, n, 0)
+		}
+	}
+
+	last := &rate.Periods[periods-1]
+	for _, m := range f.missions {
+		last.Created++
+		if m.Completed {
+			last.Completed++
+		}
+	}
+	if last.Created > 0 {
+		last.Rate = float64(last.Completed) / float64(last.Created)
+	}
+
+	return rate, nil
+}
+
+func (f *fakeService) GetCompletionTimes(ctx context.Context) (models.CompletionTimes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var times models.CompletionTimes
+	for _, m := range f.missions {
+		if m.Completed {
+			times.CompletedMissions++
+			times.AvgMissionSeconds = time.Hour.Seconds()
+		}
+		for _, t := range m.Targets {
+			if t.Completed {
+				times.CompletedTargets++
+				times.AvgTargetSeconds = time.Hour.Seconds()
+			}
+		}
+	}
+
+	return times, nil
+}
+
+func (f *fakeService) GetCatUtilization(ctx context.Context) ([]models.CatUtilization, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	utilization := make([]models.CatUtilization, 0)
+	for _, c := range sorted(f.cats) {
+		u := models.CatUtilization{CatID: c.ID, Name: c.Name}
+		for _, m := range f.missions {
+			if m.Assignee != c.ID {
+				continue
+			}
+			u.Missions++
+			if m.Completed {
+				u.DaysOnMission += time.Hour.Hours() / 24
+			} else {
+				u.DaysOnMission++
+			}
+		}
+		utilization = append(utilization, u)
+	}
+
+	return utilization, nil
+}
+
+func (f *fakeService) GetTargetsPerCountry(ctx context.Context) ([]models.CountryTargets, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	countries := make([]models.CountryTargets, 0)
+	for _, m := range f.missions {
+		for _, t := range m.Targets {
+			i := slices.IndexFunc(countries, func(c models.CountryTargets) bool { return c.Country == t.Country })
+			if i < 0 {
+				countries = append(countries, models.CountryTargets{Country: t.Country})
+				i = len(countries) - 1
+			}
+			countries[i].Targets++
+			if t.Completed {
+				countries[i].Completed++
+			}
+		}
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		if countries[i].Targets != countries[j].Targets {
+			return countries[i].Targets > countries[j].Targets
+		}
+		return countries[i].Country < countries[j].Country
+	})
+
+	return countries, nil
+}
+
+func (f *fakeService) GetSalaryPerformance(ctx context.Context) (models.SalaryPerformance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rates := f.exchangeRates()
+	var excluded int64
+	var salaries, missions, targets []float64
+	for _, c := range sorted(f.cats) {
+		salary := fakeReporting(rates, c.Salary, c.SalaryCurrency)
+		if salary == nil {
+			excluded++
+			continue
+		}
+		var completedMissions, completedTargets float64
+		for _, m := range f.missions {
+			if m.Assignee != c.ID {
+				continue
+			}
+			if m.Completed {
+				completedMissions++
+			}
+			for _, t := range m.Targets {
+				if t.Completed {
+					completedTargets++
+				}
+			}
+		}
+		salaries = append(salaries, float64(salary.Amount))
+		missions = append(missions, completedMissions)
+		targets = append(targets, completedTargets)
+	}
+
+	return models.SalaryPerformance{
+		Cats:                int64(len(salaries)),
+		ExcludedCats:        excluded,
+		MissionsCorrelation: fakePearson(salaries, missions),
+		TargetsCorrelation:  fakePearson(salaries, targets),
+	}, nil
+}
+
+// fakePearson returns the Pearson correlation of x and y, nil if it is undefined.
+func fakePearson(x, y []float64) *float64 {
+	n := float64(len(x))
+	var sx, sy, sxx, syy, sxy float64
+	for i := range x {
+		sx, sy = sx+x[i], sy+y[i]
+		sxx, syy, sxy = sxx+x[i]*x[i], syy+y[i]*y[i], sxy+x[i]*y[i]
+	}
+	d := (n*sxx - sx*sx) * (n*syy - sy*sy)
+	if n < 2 || d <= 0 {
+		return nil
+	}
+	r := (n*sxy - sx*sy) / math.Sqrt(d)
+	return &r
+}
+
+func (f *fakeService) GetCatAvailability(ctx context.Context, catID int32) ([]models.AvailabilityPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cats[catID]; !ok {
+		return make([]models.AvailabilityPeriod, 0), models.ErrNotFound
+	}
+
+	res := make([]models.AvailabilityPeriod, 0)
+	for _, p := range sorted(f.periods) {
+		if p.CatID == catID {
+			res = append(res, p)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].StartsAt.Before(res[j].StartsAt) })
+
+	return res, nil
+}
+
+func (f *fakeService) CreateAvailabilityPeriod(ctx context.Context, catID int32, req models.AvailabilityPeriodRequest) (models.AvailabilityPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := fakeValidatePeriod(req); err != nil {
+		return models.AvailabilityPeriod{}, err
+	}
+	if _, ok := f.cats[catID]; !ok {
+		return models.AvailabilityPeriod{}, models.ErrNotFound
+	}
+
+	p := models.AvailabilityPeriod{ID: f.id(), CatID: catID, Kind: req.Kind, StartsAt: req.StartsAt.UTC(), EndsAt: req.EndsAt.UTC()}
+	f.periods[p.ID] = p
+
+	return p, nil
+}
+
+func (f *fakeService) UpdateAvailabilityPeriod(ctx context.Context, catID, id int32, req models.AvailabilityPeriodRequest) (models.AvailabilityPeriod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := fakeValidatePeriod(req); err != nil {
+		return models.AvailabilityPeriod{}, err
+	}
+	p, ok := f.periods[id]
+	if !ok || p.CatID != catID {
+		return models.AvailabilityPeriod{}, models.ErrNotFound
+	}
+
+	p.Kind, p.StartsAt, p.EndsAt = req.Kind, req.StartsAt.UTC(), req.EndsAt.UTC()
+	f.periods[id] = p
+
+	return p, nil
+}
+
+func (f *fakeService) DeleteAvailabilityPeriod(ctx context.Context, catID, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.periods[id]
+	if !ok || p.CatID != catID {
+		return models.ErrNotFound
+	}
+	delete(f.periods, id)
+
+	return nil
+}
+
+func (f *fakeService) GetAvailableCats(ctx context.Context, at time.Time) ([]models.Cat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := make([]models.Cat, 0)
+	for _, c := range sorted(f.cats) {
+		available := true
+		for _, p := range f.periods {
+			if p.CatID == c.ID && !p.StartsAt.After(at) && p.EndsAt.After(at) {
+				available = false
+			}
+		}
+		if available {
+			res = append(res, c)
+		}
+	}
+
+	return res, nil
+}
+
+func fakeValidatePeriod(req models.AvailabilityPeriodRequest) error {
+	if req.Kind != "vacation" && req.Kind != "sick" && req.Kind != "training" {
+		return models.NewError(http.StatusUnprocessableEntity, "kind must be one of vacation, sick, training")
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		return models.NewError(http.StatusUnprocessableEntity, "starts_at and ends_at are required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return models.NewError(http.StatusUnprocessableEntity, "ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// The certifications of the fake are checked at fakeTime.
+
+func (f *fakeService) GetAllSkills(ctx context.Context) ([]models.Skill, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	res := sorted(f.skills)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res, nil
+}
+
+func (f *fakeService) CreateSkill(ctx context.Context, req models.CreateSkillRequest) (models.Skill, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, sk := range f.skills {
+		if sk.Name == req.Name {
+			return models.Skill{}, models.NewError(http.StatusConflict, "skill already exists")
+		}
+	}
+
+	sk := models.Skill{ID: f.id(), Name: req.Name}
+	f.skills[sk.ID] = sk
+
+	return sk, nil
+}
+
+func (f *fakeService) DeleteSkill(ctx context.Context, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.skills[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.skills, id)
+	for k := range f.catSkills {
+		if k[1] == id {
+			delete(f.catSkills, k)
+		}
+	}
+	for k := range f.targetSkills {
+		if k[1] == id {
+			delete(f.targetSkills, k)
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeService) GetCatSkills(ctx context.Context, catID int32) ([]models.CatSkill, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.cats[catID]; !ok {
+		return make([]models.CatSkill, 0), models.ErrNotFound
+	}
+
+	res := make([]models.CatSkill, 0)
+	for k, cs := range f.catSkills {
+		if k[0] == catID {
+			res = append(res, cs)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res, nil
+}
+
+func (f *fakeService) SetCatSkill(ctx context.Context, catID, skillID int32, req models.CatSkillRequest) (models.CatSkill, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Level < 1 || req.Level > 5 {
+		return models.CatSkill{}, models.NewError(http.StatusUnprocessableEntity, "level must be between 1 and 5")
+	}
+	if _, ok := f.cats[catID]; !ok {
+		return models.CatSkill{}, models.ErrNotFound
+	}
+	sk, ok := f.skills[skillID]
+	if !ok {
+		return models.CatSkill{}, models.NewError(http.StatusNotFound, "skill not found")
+	}
+
+	cs := models.CatSkill{
+		SkillID:        skillID,
+		Name:           sk.Name,
+		Level:          req.Level,
+		CertifiedUntil: req.CertifiedUntil,
+		Certified:      req.CertifiedUntil != nil && req.CertifiedUntil.After(fakeTime),
+	}
+	f.catSkills[[2]int32{catID, skillID}] = cs
+
+	return cs, nil
+}
+
+func (f *fakeService) DeleteCatSkill(ctx context.Context, catID, skillID int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.catSkills[[2]int32{catID, skillID}]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.catSkills, [2]int32{catID, skillID})
+
+	return nil
+}
+
+func (f *fakeService) GetTargetSkills(ctx context.Context, targetID int32) ([]models.TargetSkill, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.target(targetID, func(*models.Mission, *models.Target) error { return nil }); err != nil {
+		return make([]models.TargetSkill, 0), err
+	}
+
+	res := make([]models.TargetSkill, 0)
+	for k, ts := range f.targetSkills {
+		if k[0] == targetID {
+			res = append(res, ts)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res, nil
+}
+
+func (f *fakeService) SetTargetSkill(ctx context.Context, targetID, skillID int32, req models.TargetSkillRequest) (models.TargetSkill, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.MinLevel < 1 || req.MinLevel > 5 {
+		return models.TargetSkill{}, models.NewError(http.StatusUnprocessableEntity, "min_level must be between 1 and 5")
+	}
+	t, err := f.target(targetID, func(*models.Mission, *models.Target) error { return nil })
+	if err != nil {
+		return models.TargetSkill{}, err
+	}
+	if t.Completed {
+		return models.TargetSkill{}, models.NewError(http.StatusUnprocessableEntity, "can't change the skills of a completed target")
+	}
+	sk, ok := f.skills[skillID]
+	if !ok {
+		return models.TargetSkill{}, models.NewError(http.StatusNotFound, "skill not found")
+	}
+
+	ts := models.TargetSkill{SkillID: skillID, Name: sk.Name, MinLevel: req.MinLevel, Certified: req.Certified}
+	f.targetSkills[[2]int32{targetID, skillID}] = ts
+
+	return ts, nil
+}
+
+func (f *fakeService) DeleteTargetSkill(ctx context.Context, targetID, skillID int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.targetSkills[[2]int32{targetID, skillID}]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.targetSkills, [2]int32{targetID, skillID})
+
+	return nil
+}
+
+func (f *fakeService) GetMissionCandidates(ctx context.Context, missionID int32) ([]models.Candidate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.missions[missionID]
+	if !ok {
+		return make([]models.Candidate, 0), models.ErrNotFound
+	}
+	if m.Completed {
+		return make([]models.Candidate, 0), models.NewError(http.StatusUnprocessableEntity, "mission is completed")
+	}
+
+	// The skills of the pending targets, at the highest level required.
+	required := make(map[int32]models.TargetSkill)
+	for _, t := range m.Targets {
+		if t.Completed {
+			continue
+		}
+		for k, ts := range f.targetSkills {
+			if k[0] != t.ID {
+				continue
+			}
+			r, ok := required[ts.SkillID]
+			if !ok {
+				r = ts
+			}
+			r.MinLevel = max(r.MinLevel, ts.MinLevel)
+			r.Certified = r.Certified || ts.Certified
+			required[ts.SkillID] = r
+		}
+	}
+	skills := make([]models.TargetSkill, 0, len(required))
+	for _, r := range required {
+		skills = append(skills, r)
+	}
+	sort.Slice(skills, func(i, j int) bool { return skills[i].Name < skills[j].Name })
+
+	res := make([]models.Candidate, 0)
+	for _, c := range sorted(f.cats) {
+		if slices.ContainsFunc(sorted(f.missions), func(m models.Mission) bool { return m.Assignee == c.ID && !m.Completed }) {
+			continue
+		}
+
+		cand := models.Candidate{Cat: c, SkillMatch: 1, MatchedSkills: make([]string, 0), MissingSkills: make([]string, 0), Available: true}
+		for _, r := range skills {
+			cs, ok := f.catSkills[[2]int32{c.ID, r.SkillID}]
+			if ok && cs.Level >= r.MinLevel && (!r.Certified || cs.Certified) {
+				cand.MatchedSkills = append(cand.MatchedSkills, r.Name)
+			} else {
+				cand.MissingSkills = append(cand.MissingSkills, r.Name)
+			}
+		}
+		if len(skills) > 0 {
+			cand.SkillMatch = float64(len(cand.MatchedSkills)) / float64(len(skills))
+		}
+		for _, p := range f.periods {
+			if p.CatID == c.ID && !p.StartsAt.After(fakeTime) && p.EndsAt.After(fakeTime) {
+				cand.Available = false
+			}
+		}
+		res = append(res, cand)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Available != b.Available {
+			return a.Available
+		}
+		if a.SkillMatch != b.SkillMatch {
+			return a.SkillMatch > b.SkillMatch
+		}
+		return a.Cat.YearsOfExperience > b.Cat.YearsOfExperience
+	})
+
+	return res, nil
+}
+
+func (f *fakeService) AutoAssignMission(ctx context.Context, missionID int32, req models.AutoAssignRequest) (models.AutoAssignResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.missions[missionID]
+	if !ok {
+		return models.AutoAssignResult{}, models.ErrNotFound
+	}
+	if m.Completed {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "mission is completed")
+	}
+	if m.Assignee != 0 {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "mission is already assigned")
+	}
+
+	return f.autoAssign([]models.Mission{m}, req)
+}
+
+func (f *fakeService) AutoAssignMissions(ctx context.Context, req models.AutoAssignRequest) (models.AutoAssignResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	missions := slices.DeleteFunc(sorted(f.missions), func(m models.Mission) bool { return m.Completed || m.Assignee != 0 })
+
+	return f.autoAssign(missions, req)
+}
+
+// autoAssign assigns the idle cats to the missions in ascending order of ID,
+// skipping the cats over the budget. Skills are not considered.
+func (f *fakeService) autoAssign(missions []models.Mission, req models.AutoAssignRequest) (models.AutoAssignResult, error) {
+	if req.Strategy == "" {
+		req.Strategy = "greedy"
+	}
+	if _, err := assign.Lookup(req.Strategy); err != nil {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, err.Error())
+	}
+	rates := f.exchangeRates()
+	budget := int64(assign.NoBudget)
+	if req.SalaryBudget != nil {
+		code := fakeReportingCurrency
+		if req.SalaryBudgetCurrency != "" {
+			var err error
+			if code, err = currency.Parse(req.SalaryBudgetCurrency); err != nil {
+				return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "salary_budget_currency must be an ISO 4217 code")
+			}
+		}
+		var err error
+		if budget, err = rates.Convert(*req.SalaryBudget, code, fakeReportingCurrency); err != nil {
+			return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+
+	res := models.AutoAssignResult{
+		Strategy:    req.Strategy,
+		DryRun:      req.DryRun,
+		Assignments: make([]models.AutoAssignment, 0),
+		Unassigned:  make([]int32, 0),
+		Currency:    fakeReportingCurrency,
+	}
+	salary := func(c models.Cat) int64 {
+		s := fakeReporting(rates, c.Salary, c.SalaryCurrency)
+		if s == nil {
+			return math.MaxInt64
+		}
+		return s.Amount
+	}
+	cats := slices.DeleteFunc(sorted(f.cats), func(c models.Cat) bool {
+		return c.YearsOfExperience < req.MinYearsOfExperience ||
+			slices.ContainsFunc(sorted(f.missions), func(m models.Mission) bool { return m.Assignee == c.ID && !m.Completed })
+	})
+	for _, m := range missions {
+		i := slices.IndexFunc(cats, func(c models.Cat) bool { return salary(c) <= budget-res.TotalSalary })
+		if i < 0 {
+			res.Unassigned = append(res.Unassigned, m.ID)
+			continue
+		}
+		res.Assignments = append(res.Assignments, models.AutoAssignment{MissionID: m.ID, Cat: cats[i], SkillMatch: 1})
+		res.TotalSalary += salary(cats[i])
+		cats = slices.Delete(cats, i, i+1)
+	}
+
+	if !req.DryRun {
+		for _, a := range res.Assignments {
+			m := f.missions[a.MissionID]
+			m.Assignee = a.Cat.ID
+			f.missions[a.MissionID] = m
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) SetMissionBudget(ctx context.Context, missionID int32, req models.MissionBudgetRequest) (models.MissionBudget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Amount <= 0 {
+		return models.MissionBudget{}, models.NewError(http.StatusUnprocessableEntity, "amount must be positive")
+	}
+	if _, ok := f.missions[missionID]; !ok {
+		return models.MissionBudget{}, models.ErrNotFound
+	}
+
+	code, err := currency.Parse(req.Currency)
+	if err != nil {
+		return models.MissionBudget{}, models.NewError(http.StatusUnprocessableEntity, "currency must be an ISO 4217 code")
+	}
+
+	b := models.MissionBudget{MissionID: missionID, Amount: req.Amount, Currency: code}
+	f.budgets[missionID] = b
+	return b, nil
+}
+
+func (f *fakeService) DeleteMissionBudget(ctx context.Context, missionID int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.budgets[missionID]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.budgets, missionID)
+	return nil
+}
+
+func (f *fakeService) GetMissionExpenses(ctx context.Context, missionID int32) ([]models.Expense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.missions[missionID]; !ok {
+		return make([]models.Expense, 0), models.ErrNotFound
+	}
+
+	return slices.DeleteFunc(sorted(f.expenses), func(e models.Expense) bool { return e.MissionID != missionID }), nil
+}
+
+func (f *fakeService) CreateMissionExpense(ctx context.Context, missionID int32, req models.CreateExpenseRequest) (models.Expense, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !slices.Contains([]string{"travel", "equipment", "informants"}, req.Category) {
+		return models.Expense{}, models.NewError(http.StatusUnprocessableEntity, "category must be one of travel, equipment, informants")
+	}
+	if _, ok := f.missions[missionID]; !ok {
+		return models.Expense{}, models.ErrNotFound
+	}
+
+	code, err := currency.Parse(req.Currency)
+	if err != nil {
+		return models.Expense{}, models.NewError(http.StatusUnprocessableEntity, "currency must be an ISO 4217 code")
+	}
+	req.Currency = code
+
+	costs, err := f.missionCosts(missionID)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	amount, err := f.exchangeRates().Convert(req.Amount, req.Currency, costs.Currency)
+	if err != nil {
+		return models.Expense{}, models.NewError(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	e := models.Expense{
+		MissionID:   missionID,
+		Category:    req.Category,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Receipt:     req.Receipt,
+		IncurredAt:  fakeTime,
+	}
+	if req.IncurredAt != nil {
+		e.IncurredAt = req.IncurredAt.UTC()
+	}
+	if costs.Budget != nil && costs.Total+amount > *costs.Budget {
+		over := "costs exceed the budget of the mission by " + currency.Format(costs.Total+amount-*costs.Budget, costs.Currency)
+		if f.blockOverBudget {
+			return models.Expense{}, models.NewError(http.StatusUnprocessableEntity, over)
+		}
+		e.Warnings = []string{over}
+	}
+
+	e.ID = f.id()
+	stored := e
+	stored.Warnings = nil
+	f.expenses[e.ID] = stored
+	return e, nil
+}
+
+func (f *fakeService) DeleteMissionExpense(ctx context.Context, missionID, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.expenses[id]
+	if !ok || e.MissionID != missionID {
+		return models.ErrNotFound
+	}
+	delete(f.expenses, id)
+	return nil
+}
+
+func (f *fakeService) GetMissionCosts(ctx context.Context, missionID int32) (models.MissionCosts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.missions[missionID]; !ok {
+		return models.MissionCosts{}, models.ErrNotFound
+	}
+
+	return f.missionCosts(missionID)
+}
+
+// missionCosts sums the expenses of the mission. The fake missions have no
+// assignment time, so there is no salary.
+func (f *fakeService) missionCosts(missionID int32) (models.MissionCosts, error) {
+	costs := models.MissionCosts{
+		MissionID: missionID,
+		Currency:  fakeReportingCurrency,
+		Expenses:  map[string]int64{"travel": 0, "equipment": 0, "informants": 0},
+	}
+	if b, ok := f.budgets[missionID]; ok {
+		costs.Budget = &b.Amount
+		costs.Currency = b.Currency
+	}
+	rates := f.exchangeRates()
+	for _, e := range f.expenses {
+		if e.MissionID == missionID {
+			amount, err := rates.Convert(e.Amount, e.Currency, costs.Currency)
+			if err != nil {
+				return models.MissionCosts{}, models.NewError(http.StatusUnprocessableEntity, err.Error())
+			}
+			costs.Expenses[e.Category] += amount
+			costs.ExpensesTotal += amount
+		}
+	}
+	costs.Total = costs.ExpensesTotal
+	if costs.Budget != nil {
+		remaining := *costs.Budget - costs.Total
+		costs.Remaining = &remaining
+		costs.OverBudget = remaining < 0
+	}
+
+	return costs, nil
+}
+
+func (f *fakeService) GetExchangeRates(ctx context.Context) (models.ExchangeRates, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.exchangeRateList(), nil
+}
+
+func (f *fakeService) SetExchangeRate(ctx context.Context, code string, req models.SetExchangeRateRequest) (models.ExchangeRate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code, err := fakeValidateRate(code, req.Rate)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+
+	r := models.ExchangeRate{Currency: code, Rate: req.Rate, UpdatedAt: fakeTime}
+	f.rates[code] = r
+	return r, nil
+}
+
+func (f *fakeService) DeleteExchangeRate(ctx context.Context, code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code, err := currency.Parse(code)
+	if err != nil {
+		return models.ErrNotFound
+	}
+	if _, ok := f.rates[code]; !ok {
+		return models.ErrNotFound
+	}
+	delete(f.rates, code)
+	return nil
+}
+
+func (f *fakeService) ImportExchangeRates(ctx context.Context, rates map[string]float64) (models.ExchangeRates, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for key, rate := range rates {
+		if _, err := fakeValidateRate(key, rate); err != nil {
+			return models.ExchangeRates{}, err
+		}
+	}
+	for key, rate := range rates {
+		code, _ := currency.Parse(key)
+		f.rates[code] = models.ExchangeRate{Currency: code, Rate: rate, UpdatedAt: fakeTime}
+	}
+
+	return f.exchangeRateList(), nil
+}
+
+func (f *fakeService) exchangeRateList() models.ExchangeRates {
+	rates := models.ExchangeRates{ReportingCurrency: fakeReportingCurrency, Rates: make([]models.ExchangeRate, 0, len(f.rates))}
+	for _, code := range slices.Sorted(maps.Keys(f.rates)) {
+		rates.Rates = append(rates.Rates, f.rates[code])
+	}
+	return rates
+}
+
+// exchangeRates returns the rates of the fake to the reporting currency.
+func (f *fakeService) exchangeRates() currency.Rates {
+	rates := make(map[string]float64, len(f.rates))
+	for code, r := range f.rates {
+		rates[code] = r.Rate
+	}
+	return currency.NewRates(fakeReportingCurrency, rates)
+}
+
+// fakeReporting returns the salary in the reporting currency, nil without
+// an exchange rate.
+func fakeReporting(rates currency.Rates, salary int32, code string) *models.Money {
+	amount, err := rates.Convert(currency.MinorUnits(int64(salary), code), code, fakeReportingCurrency)
+	if err != nil {
+		return nil
+	}
+	return &models.Money{Amount: amount, Currency: fakeReportingCurrency}
+}
+
+func fakeValidateRate(code string, rate float64) (string, error) {
+	code, err := currency.Parse(code)
+	if err != nil {
+		return "", models.NewError(http.StatusUnprocessableEntity, "currency must be an ISO 4217 code")
+	}
+	if code == fakeReportingCurrency {
+		return "", models.NewError(http.StatusUnprocessableEntity, code+" is the reporting currency")
+	}
+	if !currency.ValidRate(rate) {
+		return "", models.NewError(http.StatusUnprocessableEntity, "rate must be a positive number")
+	}
+	return code, nil
+}
+
+// GetTargetsNear scans the targets of the fake missions, the nearest first.
+func (f *fakeService) GetTargetsNear(ctx context.Context, lat, lng, radiusKm float64, limit int32) ([]models.NearbyTarget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := fakeValidateLocation(&lat, &lng); err != nil {
+		return nil, err
+	}
+	if radiusKm <= 0 || radiusKm > 20016 {
+		return nil, models.NewError(http.StatusUnprocessableEntity, "radius_km must be positive and at most 20016")
+	}
+	if limit <= 0 || limit > 100 {
+		return nil, models.NewError(http.StatusUnprocessableEntity, "limit must be between 1 and 100")
+	}
+
+	res := make([]models.NearbyTarget, 0)
+	for _, m := range sorted(f.missions) {
+		for _, t := range m.Targets {
+			if t.Latitude == nil {
+				continue
+			}
+			if d := geo.Distance(lat, lng, *t.Latitude, *t.Longitude); d <= radiusKm {
+				res = append(res, models.NearbyTarget{Target: t, MissionID: m.ID, DistanceKm: d})
+			}
+		}
+	}
+	slices.SortStableFunc(res, func(a, b models.NearbyTarget) int { return cmp.Compare(a.DistanceKm, b.DistanceKm) })
+	if len(res) > int(limit) {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) UpdateTargetLocation(ctx context.Context, id int32, req models.TargetLocationRequest) (models.Target, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := fakeValidateLocation(req.Latitude, req.Longitude); err != nil {
+		return models.Target{}, err
+	}
+
+	return f.target(id, func(m *models.Mission, t *models.Target) error {
+		if t.Completed {
+			return models.NewError(http.StatusUnprocessableEntity, "Can't change location of a completed target")
+		}
+		t.Latitude, t.Longitude, t.Address = req.Latitude, req.Longitude, req.Address
+		return nil
+	})
+}
+
+func fakeValidateLocation(lat, lng *float64) error {
+	if (lat == nil) != (lng == nil) {
+		return models.NewError(http.StatusUnprocessableEntity, "latitude and longitude must be set together")
+	}
+	if lat != nil && !geo.ValidLatitude(*lat) {
+		return models.NewError(http.StatusUnprocessableEntity, "latitude must be between -90 and 90")
+	}
+	if lng != nil && !geo.ValidLongitude(*lng) {
+		return models.NewError(http.StatusUnprocessableEntity, "longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// checkSubject checks the subject a new target is linked to exists.
+func (f *fakeService) checkSubject(id *int32) error {
+	if id == nil {
+		return nil
+	}
+	if _, ok := f.subjects[*id]; !ok {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("subject %d not found", *id))
+	}
+	return nil
+}
+
+// linkSubject returns the subject of a new target, created unless it is set,
+// with the known subjects similar to it, scored like the service.
+func (f *fakeService) linkSubject(id *int32, name, country string) (int32, []models.SubjectMatch) {
+	if id != nil {
+		return *id, nil
+	}
+
+	var matches []models.SubjectMatch
+	for _, s := range sorted(f.subjects) {
+		countryScore := 1.0
+		if !strings.EqualFold(s.Country, country) {
+			countryScore = fuzzy.Similarity(s.Country, country)
+		}
+		score := math.Round((0.8*fuzzy.Similarity(s.Name, name)+0.2*countryScore)*100) / 100
+		if score >= 0.6 {
+			matches = append(matches, models.SubjectMatch{Subject: s, Score: score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b models.SubjectMatch) int { return cmp.Compare(b.Score, a.Score) })
+	if len(matches) > 5 {
+		matches = matches[:5]
+	}
+
+	s := models.Subject{ID: f.nextSubjectID, Name: name, Country: country, CreatedAt: fakeTime}
+	f.nextSubjectID++
+	f.subjects[s.ID] = s
+
+	return s.ID, matches
+}
+
+func (f *fakeService) GetSubject(ctx context.Context, id int32) (models.SubjectDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.subject(id)
+}
+
+func (f *fakeService) subject(id int32) (models.SubjectDetails, error) {
+	s, ok := f.subjects[id]
+	if !ok {
+		return models.SubjectDetails{}, models.ErrNotFound
+	}
+
+	res := models.SubjectDetails{Subject: s, Missions: []models.Mission{}, NotesHistory: []models.TargetNoteChange{}}
+	targets := make(map[int32]bool)
+	for _, m := range sorted(f.missions) {
+		var own []models.Target
+		for _, t := range m.Targets {
+			if t.SubjectID == id {
+				own = append(own, t)
+				targets[t.ID] = true
+			}
+		}
+		if len(own) > 0 {
+			m.Targets = own
+			res.Missions = append(res.Missions, m)
+		}
+	}
+	for _, n := range f.notes {
+		if targets[n.TargetID] {
+			res.NotesHistory = append(res.NotesHistory, n)
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) MergeSubjects(ctx context.Context, id int32, req models.MergeSubjectsRequest) (models.SubjectDetails, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(req.Subjects) == 0 {
+		return models.SubjectDetails{}, models.NewError(http.StatusUnprocessableEntity, "no subjects to merge")
+	}
+	for i, from := range req.Subjects {
+		if from == id {
+			return models.SubjectDetails{}, models.NewError(http.StatusUnprocessableEntity, "can't merge a subject into itself")
+		}
+		if slices.Contains(req.Subjects[:i], from) {
+			return models.SubjectDetails{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("subject %d is listed twice", from))
+		}
+	}
+	if _, ok := f.subjects[id]; !ok {
+		return models.SubjectDetails{}, models.ErrNotFound
+	}
+	for _, from := range req.Subjects {
+		if _, ok := f.subjects[from]; !ok {
+			return models.SubjectDetails{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("subject %d not found", from))
+		}
+	}
+
+	for _, m := range f.missions {
+		m.Targets = slices.Clone(m.Targets)
+		for i, t := range m.Targets {
+			if slices.Contains(req.Subjects, t.SubjectID) {
+				m.Targets[i].SubjectID = id
+			}
+		}
+		f.missions[m.ID] = m
+	}
+	for _, from := range req.Subjects {
+		delete(f.subjects, from)
+	}
+
+	return f.subject(id)
+}
+
+// fakeAttachment is an attachment with its content.
+type fakeAttachment struct {
+	models.Attachment
+	content []byte
+}
+
+// fakeAttachmentTypes are the content types of the service.
+var fakeAttachmentTypes = map[string]bool{
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+func (f *fakeService) UploadAttachment(ctx context.Context, targetId int32, upload models.AttachmentUpload) (models.Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if upload.Size == 0 {
+		return models.Attachment{}, models.NewError(http.StatusUnprocessableEntity, "attachment is empty")
+	}
+	if upload.Size > fakeMaxAttachmentSize {
+		return models.Attachment{}, models.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("attachment is too large (max %d bytes)", fakeMaxAttachmentSize))
+	}
+	filename := path.Base(strings.ReplaceAll(upload.Filename, `\`, "/"))
+	if filename == "." || filename == "/" {
+		return models.Attachment{}, models.NewError(http.StatusUnprocessableEntity, "filename is required")
+	}
+
+	content, err := io.ReadAll(upload.Content)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	contentType := http.DetectContentType(content)
+	if !fakeAttachmentTypes[contentType] {
+		return models.Attachment{}, models.NewError(http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type %s", contentType))
+	}
+
+	if err := f.checkAttachmentsEditable(targetId); err != nil {
+		return models.Attachment{}, err
+	}
+
+	a := fakeAttachment{
+		Attachment: models.Attachment{
+			ID:          f.id(),
+			TargetID:    targetId,
+			Filename:    filename,
+			ContentType: contentType,
+			Size:        int64(len(content)),
+			CreatedAt:   fakeTime,
+		},
+		content: content,
+	}
+	f.attachments[a.ID] = a
+
+	return a.Attachment, nil
+}
+
+func (f *fakeService) GetTargetAttachments(ctx context.Context, targetId int32) ([]models.Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.target(targetId, func(m *models.Mission, t *models.Target) error { return nil }); err != nil {
+		return nil, err
+	}
+
+	res := []models.Attachment{}
+	for _, a := range sorted(f.attachments) {
+		if a.TargetID == targetId {
+			res = append(res, a.Attachment)
+		}
+	}
+
+	return res, nil
+}
+
+func (f *fakeService) GetAttachment(ctx context.Context, targetId, id int32) (models.Attachment, io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, ok := f.attachments[id]
+	if !ok || a.TargetID != targetId {
+		return models.Attachment{}, nil, models.ErrNotFound
+	}
+
+	return a.Attachment, io.NopCloser(bytes.NewReader(a.content)), nil
+}
+
+func (f *fakeService) DeleteAttachment(ctx context.Context, targetId, id int32) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkAttachmentsEditable(targetId); err != nil {
+		return err
+	}
+	a, ok := f.attachments[id]
+	if !ok || a.TargetID != targetId {
+		return models.ErrNotFound
+	}
+	delete(f.attachments, id)
+
+	return nil
+}
+
+// checkAttachmentsEditable freezes the attachments of completed targets and
+// missions, like the service.
+func (f *fakeService) checkAttachmentsEditable(targetId int32) error {
+	_, err := f.target(targetId, func(m *models.Mission, t *models.Target) error {
+		if t.Completed {
+			return models.NewError(http.StatusUnprocessableEntity, "Can't change attachments of a completed target")
+		}
+		if m.Completed {
+			return models.NewError(http.StatusUnprocessableEntity, "Can't change target attachments of a completed mission")
+		}
+		return nil
+	})
+	return err
+}
+
+// RenderNotes renders the notes with the mentions of the fake cats and
+// targets, like the service.
+func (f *fakeService) RenderNotes(ctx context.Context, targets []models.Target) ([]models.Target, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	links := make(map[markdown.Mention]markdown.Link)
+	for _, c := range f.cats {
+		links[markdown.Mention{Kind: markdown.KindCat, ID: c.ID}] = markdown.Link{Href: fmt.Sprintf("/cats/%d", c.ID), Text: "@" + c.Name}
+	}
+	for _, m := range f.missions {
+		for _, t := range m.Targets {
+			links[markdown.Mention{Kind: markdown.KindTarget, ID: t.ID}] = markdown.Link{Href: fmt.Sprintf("/missions/%d", m.ID), Text: "@" + t.Name}
+		}
+	}
+
+	res := make([]models.Target, len(targets))
+	for i, t := range targets {
+		html, err := markdown.Render(t.Notes, links)
+		if err != nil {
+			return nil, err
+		}
+		t.NotesHTML = html
+		res[i] = t
+	}
+
+	return res, nil
+}
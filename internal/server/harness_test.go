@@ -0,0 +1,302 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The server tests are scenarios of requests to a Server on a fakeService.
+//
+// Run `go test ./internal/server -update` to rewrite the golden files after
+// an intended change of the responses, and review the diff.
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// scenario is a sequence of requests to a new Server.
+type scenario struct {
+	name string
+	// setup prepares the fake before the first step.
+	setup func(f *fakeService)
+	steps []step
+}
+
+// step is a request and the expected response.
+type step struct {
+	name   string
+	method string
+	path   string
+	header map[string]string
+	// body is sent as is if it's a string, as JSON otherwise.
+	body   any
+	status int
+	// json maps dotted paths of the response to the expected values.
+	// "targets.0.name" is the name of the first target, "targets.#" the number of targets.
+	json map[string]any
+	// respHeader maps the response headers to the expected values.
+	respHeader map[string]string
+	// golden compares the response to testdata/<scenario>/<step>.golden.
+	golden bool
+}
+
+// covered records the routes requested by the scenarios of all tests.
+var covered = struct {
+	sync.Mutex
+	routes map[string]bool
+}{routes: make(map[string]bool)}
+
+// newTestServer returns a Server with all the optional routes on the fake
+// and the default business rules.
+func newTestServer(f *fakeService) Server {
+	rs, err := rules.NewStore("")
+	if err != nil {
+		panic(err)
+	}
+
+	return New(f, f, f,
+		WithEventStream(f),
+		WithWebhookService(f),
+		WithOutboxService(f),
+		WithSearchService(f),
+		WithTemplateService(f),
+		WithRules(rs),
+		WithImportService(f),
+		WithSnapshotService(f),
+		WithReportService(f),
+		WithAnalyticsService(f),
+		WithAvailabilityService(f),
+		WithSkillService(f),
+		WithAssignmentService(f),
+		WithCostService(f),
+		WithRateService(f),
+		WithLocationService(f),
+		WithSubjectService(f),
+		WithAttachmentService(f, fakeMaxAttachmentSize),
+		WithNoteService(f),
+	)
+}
+
+// runScenarios runs every scenario on its own Server and fake.
+func runScenarios(t *testing.T, scenarios []scenario) {
+	t.Helper()
+
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			f := newFakeService()
+			if sc.setup != nil {
+				sc.setup(f)
+			}
+			srv := newTestServer(f)
+
+			for _, st := range sc.steps {
+				// Later steps depend on the earlier ones, so stop at the first failure.
+				if !t.Run(st.name, func(t *testing.T) { runStep(t, srv, sc.name, st) }) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func runStep(t *testing.T, srv Server, scenario string, st step) {
+	var body io.Reader
+	switch b := st.body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(t, err)
+		body = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(st.method, st.path, body)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for k, v := range st.header {
+		req.Header.Set(k, v)
+	}
+
+	markCovered(srv, st.method, req.URL.Path)
+
+	resp, err := srv.R.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, st.status, resp.StatusCode, "body: %s", data)
+
+	for k, want := range st.respHeader {
+		assert.Equal(t, want, resp.Header.Get(k), k)
+	}
+
+	if len(st.json) > 0 {
+		var got any
+		require.NoError(t, json.Unmarshal(data, &got), "body: %s", data)
+
+		for path, want := range st.json {
+			value, err := lookup(got, path)
+			if assert.NoError(t, err, path) {
+				assert.Equal(t, normalize(t, want), value, path)
+			}
+		}
+	}
+
+	if st.golden {
+		assertGolden(t, filepath.Join("testdata", fileName(scenario), fileName(st.name)+".golden"), resp, data)
+	}
+}
+
+// lookup returns the value at the dotted path of a decoded JSON document.
+func lookup(v any, path string) (any, error) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			if key == "#" {
+				return float64(len(node)), nil
+			}
+			value, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("no key %q", key)
+			}
+			v = value
+		case []any:
+			if key == "#" {
+				return float64(len(node)), nil
+			}
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("no index %q in an array of %d", key, len(node))
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("%q of a scalar", key)
+		}
+	}
+
+	return v, nil
+}
+
+// normalize converts an expected value to the types of a decoded JSON document.
+func normalize(t *testing.T, v any) any {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var res any
+	require.NoError(t, json.Unmarshal(data, &res))
+
+	return res
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+func fileName(name string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_")
+}
+
+// assertGolden compares the status, the content type and the body of the
+// response to the golden file. JSON bodies are indented for readable diffs.
+func assertGolden(t *testing.T, path string, resp *http.Response, body []byte) {
+	t.Helper()
+
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+
+	var got bytes.Buffer
+	fmt.Fprintf(&got, "%d %s\n", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	got.WriteString("\n")
+	got.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		got.WriteString("\n")
+	}
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got.Bytes(), 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run the tests with -update to create the golden file")
+	assert.Equal(t, string(want), got.String(), path)
+}
+
+// route is a registered route, the key of the coverage.
+func route(method, path string) string {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return method + " " + path
+}
+
+// markCovered records the route the request path matches.
+func markCovered(srv Server, method, path string) {
+	for _, r := range srv.R.GetRoutes(true) {
+		if r.Method == method && matchPath(r.Path, path) {
+			covered.Lock()
+			covered.routes[route(r.Method, r.Path)] = true
+			covered.Unlock()
+			return
+		}
+	}
+}
+
+// matchPath reports whether the path matches the route pattern, where a
+// :param matches any segment.
+func matchPath(pattern, path string) bool {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ps) != len(segs) {
+		return false
+	}
+
+	for i := range ps {
+		if !strings.HasPrefix(ps[i], ":") && ps[i] != segs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// uncoveredRoutes returns the routes of the server no scenario requested.
+func uncoveredRoutes(srv Server) []string {
+	covered.Lock()
+	defer covered.Unlock()
+
+	var res []string
+	for _, r := range srv.R.GetRoutes(true) {
+		// HEAD routes are added by fiber for every GET route.
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		if key := route(r.Method, r.Path); !covered.routes[key] {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
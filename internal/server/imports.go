@@ -0,0 +1,119 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/imports"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// ImportService controls the bulk import service.
+type ImportService interface {
+	ImportCats(ctx context.Context, rows []models.CatImportRow, opts models.ImportOptions) (models.ImportResult, error)
+	ImportMissions(ctx context.Context, rows []models.MissionImportRow, opts models.ImportOptions) (models.ImportResult, error)
+}
+
+// WithImportService enables the bulk import routes.
+func WithImportService(is ImportService) Option {
+	return func(s *Server) {
+		s.importService = is
+	}
+}
+
+// registerImportRoutes registers the bulk import routes.
+func (s *Server) registerImportRoutes() {
+	imports := s.R.Group("/import")
+	{
+		imports.Post("/cats", s.handleImportCats)
+		imports.Post("/missions", s.handleImportMissions)
+	}
+}
+
+func (s *Server) handleImportCats(c fiber.Ctx) error {
+	format, opts, err := importParams(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	rows, err := imports.ReadCats(bytes.NewReader(c.Body()), format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.importService.ImportCats(c.Context(), rows, opts)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return sendImportResult(c, res)
+}
+
+func (s *Server) handleImportMissions(c fiber.Ctx) error {
+	format, opts, err := importParams(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	rows, err := imports.ReadMissions(bytes.NewReader(c.Body()), format)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.importService.ImportMissions(c.Context(), rows, opts)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return sendImportResult(c, res)
+}
+
+// importParams returns the format of the body and the options of an import.
+func importParams(c fiber.Ctx) (imports.Format, models.ImportOptions, error) {
+	format, err := imports.FormatOf(c.Get(fiber.HeaderContentType))
+	if err != nil {
+		return "", models.ImportOptions{}, models.NewError(fiber.StatusUnsupportedMediaType, err.Error())
+	}
+
+	dryRun, err := strconv.ParseBool(c.Query("dry_run", "false"))
+	if err != nil {
+		return "", models.ImportOptions{}, models.NewError(fiber.StatusBadRequest, "invalid dry_run")
+	}
+
+	return format, models.ImportOptions{
+		Mode:   c.Query("mode", models.ImportAllOrNothing),
+		DryRun: dryRun,
+	}, nil
+}
+
+// sendImportResult sends the result, or its error report with ?report=csv.
+//
+// The status is 201 if rows were created, 422 if invalid rows prevented the
+// import, and 200 otherwise.
+func sendImportResult(c fiber.Ctx, res models.ImportResult) error {
+	status := fiber.StatusOK
+	switch {
+	case res.Created > 0:
+		status = fiber.StatusCreated
+	case !res.DryRun && res.Invalid > 0:
+		status = fiber.StatusUnprocessableEntity
+	}
+
+	switch c.Query("report") {
+	case "":
+		return c.Status(status).JSON(res)
+	case "csv":
+		var buf bytes.Buffer
+		if err := imports.WriteReport(&buf, res); err != nil {
+			return handleError(c, err)
+		}
+
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="import-report.csv"`)
+		return c.Status(status).Send(buf.Bytes())
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid report"})
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/geo"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// LocationService controls the locations of the targets.
+type LocationService interface {
+	GetTargetsNear(ctx context.Context, lat, lng, radiusKm float64, limit int32) ([]models.NearbyTarget, error)
+	UpdateTargetLocation(ctx context.Context, targetId int32, req models.TargetLocationRequest) (models.Target, error)
+}
+
+// WithLocationService enables the target location routes.
+func WithLocationService(ls LocationService) Option {
+	return func(s *Server) {
+		s.locationService = ls
+	}
+}
+
+// registerLocationRoutes registers the target location routes.
+func (s *Server) registerLocationRoutes() {
+	s.R.Get("/targets/near", s.handleGetTargetsNear)
+	s.R.Put("/missions/:id/targets/:targetId/location", s.handleUpdateTargetLocation)
+	s.R.Get("/missions/:id/targets.geojson", s.handleGetMissionGeoJSON)
+}
+
+func (s *Server) handleGetTargetsNear(c fiber.Ctx) error {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid lat"})
+	}
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid lng"})
+	}
+	radius, err := strconv.ParseFloat(c.Query("radius_km"), 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid radius_km"})
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid limit"})
+	}
+
+	res, err := s.locationService.GetTargetsNear(c.Context(), lat, lng, radius, int32(limit))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"results": res})
+}
+
+func (s *Server) handleUpdateTargetLocation(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.TargetLocationRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.locationService.UpdateTargetLocation(c.Context(), int32(targetId), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// handleGetMissionGeoJSON writes the targets of the mission with coordinates
+// as a GeoJSON feature collection.
+func (s *Server) handleGetMissionGeoJSON(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.missionService.GetMission(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(geo.MissionTargets(res), "application/geo+json")
+}
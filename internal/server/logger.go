@@ -13,6 +13,17 @@ func LoggerMiddleware() fiber.Handler {
 
 		err := c.Next()
 
+		// Reading the body of a streamed response would block until the stream ends.
+		if c.Response().IsBodyStream() {
+			log.Default().Printf("[%s] %s %d <stream> (%v)",
+				c.Method(),
+				c.OriginalURL(),
+				c.Response().StatusCode(),
+				time.Since(start))
+
+			return err
+		}
+
 		log.Default().Printf("[%s] %s %d %s (%v)",
 			c.Method(),
 			c.OriginalURL(),
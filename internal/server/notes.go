@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// NoteService renders the Markdown notes of the targets.
+type NoteService interface {
+	RenderNotes(ctx context.Context, targets []models.Target) ([]models.Target, error)
+}
+
+// WithNoteService enables the ?render=html parameter of the missions and of
+// the target notes.
+func WithNoteService(ns NoteService) Option {
+	return func(s *Server) {
+		s.noteService = ns
+	}
+}
+
+// renderHTML checks the render parameter of the request, true if the notes
+// are to be rendered to HTML with ?render=html.
+func (s *Server) renderHTML(c fiber.Ctx) (bool, error) {
+	switch c.Query("render") {
+	case "":
+		return false, nil
+	case "html":
+	default:
+		return false, models.NewError(fiber.StatusBadRequest, "invalid render, expected html")
+	}
+
+	if s.noteService == nil {
+		return false, models.NewError(fiber.StatusBadRequest, "note rendering is not enabled")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// OutboxService controls the outbox service.
+type OutboxService interface {
+	GetOutboxEvents(ctx context.Context, fromOffset int64, limit int32) ([]models.OutboxEvent, error)
+	ReplayOutboxEvents(ctx context.Context, fromOffset int64) (int64, error)
+}
+
+// WithOutboxService enables the outbox routes.
+func WithOutboxService(os OutboxService) Option {
+	return func(s *Server) {
+		s.outboxService = os
+	}
+}
+
+// registerOutboxRoutes registers the outbox routes.
+func (s *Server) registerOutboxRoutes() {
+	outbox := s.R.Group("/outbox")
+	{
+		outbox.Get("/", s.handleGetOutboxEvents)
+		outbox.Post("/replay", s.handleReplayOutboxEvents)
+	}
+}
+
+func (s *Server) handleGetOutboxEvents(c fiber.Ctx) error {
+	fromOffset, err := strconv.ParseInt(c.Query("from_offset", "1"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid from_offset"})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "100"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid limit"})
+	}
+
+	res, err := s.outboxService.GetOutboxEvents(c.Context(), fromOffset, int32(limit))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"events": res})
+}
+
+func (s *Server) handleReplayOutboxEvents(c fiber.Ctx) error {
+	var r models.ReplayOutboxRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.outboxService.ReplayOutboxEvents(c.Context(), r.FromOffset)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"replayed": res})
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// RateService controls the exchange rates to the reporting currency.
+type RateService interface {
+	GetExchangeRates(ctx context.Context) (models.ExchangeRates, error)
+	SetExchangeRate(ctx context.Context, code string, req models.SetExchangeRateRequest) (models.ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, code string) error
+	ImportExchangeRates(ctx context.Context, rates map[string]float64) (models.ExchangeRates, error)
+}
+
+// WithRateService enables the exchange rate routes.
+func WithRateService(rs RateService) Option {
+	return func(s *Server) {
+		s.rateService = rs
+	}
+}
+
+// registerRateRoutes registers the exchange rate routes.
+func (s *Server) registerRateRoutes() {
+	rates := s.R.Group("/exchange-rates")
+	{
+		rates.Get("/", s.handleGetExchangeRates)
+		rates.Post("/import", s.handleImportExchangeRates)
+		rates.Put("/:currency", s.handleSetExchangeRate)
+		rates.Delete("/:currency", s.handleDeleteExchangeRate)
+	}
+}
+
+func (s *Server) handleGetExchangeRates(c fiber.Ctx) error {
+	res, err := s.rateService.GetExchangeRates(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleSetExchangeRate(c fiber.Ctx) error {
+	var r models.SetExchangeRateRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.rateService.SetExchangeRate(c.Context(), c.Params("currency"), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteExchangeRate(c fiber.Ctx) error {
+	err := s.rateService.DeleteExchangeRate(c.Context(), c.Params("currency"))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+// handleImportExchangeRates sets the rates of a CSV body with the currency
+// and rate columns.
+func (s *Server) handleImportExchangeRates(c fiber.Ctx) error {
+	rates, err := currency.ReadRates(bytes.NewReader(c.Body()))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.rateService.ImportExchangeRates(c.Context(), rates)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/reports"
+)
+
+// ReportService controls the report service.
+type ReportService interface {
+	EachCat(ctx context.Context, fn func(models.Cat) error) error
+	EachMission(ctx context.Context, fn func(models.Mission) error) error
+	EachMissionTarget(ctx context.Context, fn func(models.MissionTargetRow) error) error
+	EachCatWorkload(ctx context.Context, fn func(models.CatWorkload) error) error
+}
+
+// WithReportService enables the report routes and the spreadsheet exports of
+// the lists of cats and missions.
+func WithReportService(rs ReportService) Option {
+	return func(s *Server) {
+		s.reportService = rs
+	}
+}
+
+// registerReportRoutes registers the report routes.
+func (s *Server) registerReportRoutes() {
+	reports := s.R.Group("/reports")
+	{
+		reports.Get("/mission-targets", s.handleMissionTargetsReport)
+		reports.Get("/cat-workload", s.handleCatWorkloadReport)
+	}
+}
+
+func (s *Server) handleMissionTargetsReport(c fiber.Ctx) error {
+	f, err := reports.ParseFormat(c.Query("format", string(reports.CSV)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return streamReport(c, f, "mission-targets", reports.MissionTargets, s.reportService.EachMissionTarget)
+}
+
+func (s *Server) handleCatWorkloadReport(c fiber.Ctx) error {
+	f, err := reports.ParseFormat(c.Query("format", string(reports.CSV)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return streamReport(c, f, "cat-workload", reports.CatWorkload, s.reportService.EachCatWorkload)
+}
+
+// exportFormat returns the spreadsheet format of a list requested by the
+// format query parameter, or else by the Accept header, and false for JSON.
+func (s *Server) exportFormat(c fiber.Ctx) (reports.Format, bool, error) {
+	f, ok, err := requestedFormat(c)
+	if ok && s.reportService == nil {
+		return "", false, errors.New("spreadsheet exports are not enabled")
+	}
+	return f, ok, err
+}
+
+func requestedFormat(c fiber.Ctx) (reports.Format, bool, error) {
+	if name := c.Query("format"); name != "" {
+		if name == "json" {
+			return "", false, nil
+		}
+		f, err := reports.ParseFormat(name)
+		if err != nil {
+			return "", false, err
+		}
+		return f, true, nil
+	}
+
+	// The first offer is the default for a missing or wildcard Accept header.
+	switch c.Accepts(fiber.MIMEApplicationJSON, reports.CSV.ContentType(), reports.XLSX.ContentType()) {
+	case reports.CSV.ContentType():
+		return reports.CSV, true, nil
+	case reports.XLSX.ContentType():
+		return reports.XLSX, true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// streamReport streams the rows of each as a downloadable report of the
+// columns selected by the columns query parameter.
+//
+// Like the snapshot export, the status is sent before the rows are read, so a
+// failed report is only logged and is cut short.
+func streamReport[T any](c fiber.Ctx, f reports.Format, name string, table reports.Table[T], each func(ctx context.Context, fn func(T) error) error) error {
+	table, err := table.Select(c.Query("columns"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, f.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, f))
+
+	return c.Status(fiber.StatusOK).SendStreamWriter(func(w *bufio.Writer) {
+		rw, err := reports.NewWriter(w, f, table)
+		if err == nil {
+			// The request context ends with the handler, before the stream is written.
+			err = each(context.Background(), rw.Write)
+		}
+		if err == nil {
+			err = rw.Close()
+		}
+		if err != nil {
+			slog.Error("Failed to export report", "report", name, "err", err)
+		}
+		if err := w.Flush(); err != nil {
+			slog.Error("Failed to send report", "report", name, "err", err)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+)
+
+// RuleSource provides the current business rules.
+type RuleSource interface {
+	Get() rules.Rules
+}
+
+// WithRules enables the business rules route.
+func WithRules(rs RuleSource) Option {
+	return func(s *Server) {
+		s.ruleSource = rs
+	}
+}
+
+// registerRulesRoutes registers the business rules routes.
+func (s *Server) registerRulesRoutes() {
+	s.R.Get("/rules", s.handleGetRules)
+}
+
+func (s *Server) handleGetRules(c fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(s.ruleSource.Get())
+}
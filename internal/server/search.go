@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// SearchService controls the search service.
+type SearchService interface {
+	SearchTargets(ctx context.Context, query string, limit int32) ([]models.TargetSearchResult, error)
+}
+
+// WithSearchService enables the search routes.
+func WithSearchService(ss SearchService) Option {
+	return func(s *Server) {
+		s.searchService = ss
+	}
+}
+
+// registerSearchRoutes registers the search routes.
+func (s *Server) registerSearchRoutes() {
+	s.R.Get("/search", s.handleSearch)
+}
+
+func (s *Server) handleSearch(c fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid limit"})
+	}
+
+	res, err := s.searchService.SearchTargets(c.Context(), c.Query("q"), int32(limit))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"results": res})
+}
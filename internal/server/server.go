@@ -7,7 +7,9 @@ import (
 
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
 	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/reports"
 )
 
 // CatService controls the cat service.
@@ -24,10 +26,12 @@ type MissionService interface {
 	GetAllMissions(ctx context.Context) ([]models.Mission, error)
 	CreateMission(ctx context.Context, req models.CreateMissionRequest) (models.Mission, error)
 	GetMission(ctx context.Context, id int32) (models.Mission, error)
-	AssignCatToMission(ctx context.Context, missionID int32, assignee int32) (models.Mission, error)
+	AssignCatToMission(ctx context.Context, missionID int32, req models.AssignCatRequest) (models.Mission, error)
 	CompleteMission(ctx context.Context, id int32) (models.Mission, error)
 	DeleteMission(ctx context.Context, id int32) error
 	AddTarget(ctx context.Context, missionID int32, req models.CreateTargetRequest) (models.Mission, error)
+	CloneMission(ctx context.Context, id int32, req models.CloneMissionRequest) (models.Mission, error)
+	SplitMission(ctx context.Context, id int32, req models.SplitMissionRequest) (models.Mission, error)
 }
 
 // TargetService controls the target service.
@@ -35,28 +39,74 @@ type TargetService interface {
 	DeleteTarget(ctx context.Context, id int32) error
 	UpdateTargetNotes(ctx context.Context, id int32, notes string) (models.Target, error)
 	CompleteTarget(ctx context.Context, id int32) (models.Target, error)
+	MoveTarget(ctx context.Context, missionID, targetID int32, req models.MoveTargetRequest) (models.Mission, error)
+	GetTargetMoves(ctx context.Context, targetID int32) ([]models.TargetMove, error)
+}
+
+// EventStream controls the mission event stream.
+type EventStream interface {
+	Subscribe(missionID int32, lastEventID string) (*events.Subscription, []events.Event, error)
 }
 
 type Server struct {
-	catService     CatService
-	missionService MissionService
-	targetService  TargetService
-	R              *fiber.App
+	catService          CatService
+	missionService      MissionService
+	targetService       TargetService
+	eventStream         EventStream
+	webhookService      WebhookService
+	outboxService       OutboxService
+	searchService       SearchService
+	templateService     TemplateService
+	ruleSource          RuleSource
+	importService       ImportService
+	snapshotService     SnapshotService
+	reportService       ReportService
+	analyticsService    AnalyticsService
+	availabilityService AvailabilityService
+	skillService        SkillService
+	assignmentService   AssignmentService
+	costService         CostService
+	rateService         RateService
+	locationService     LocationService
+	subjectService      SubjectService
+	attachmentService   AttachmentService
+	noteService         NoteService
+	// uploadLimit is the size limit of the bodies of the attachment uploads,
+	// the other bodies keep the default limit.
+	uploadLimit int
+	R           *fiber.App
+}
+
+// Option configures optional Server dependencies.
+//
+// Routes of an optional dependency are registered only when it is set.
+type Option func(*Server)
+
+// WithEventStream enables the mission event stream routes.
+func WithEventStream(es EventStream) Option {
+	return func(s *Server) {
+		s.eventStream = es
+	}
 }
 
 // New returns a new Server.
-func New(cs CatService, ms MissionService, ts TargetService) Server {
+func New(cs CatService, ms MissionService, ts TargetService, opts ...Option) Server {
 	server := Server{
 		catService:     cs,
 		missionService: ms,
 		targetService:  ts,
-		R: fiber.New(
-			fiber.Config{
-				StructValidator: &models.StructValidator{Validator: validator.New()},
-			},
-		),
 	}
 
+	for _, opt := range opts {
+		opt(&server)
+	}
+
+	server.R = fiber.New(
+		fiber.Config{
+			StructValidator: &models.StructValidator{Validator: validator.New()},
+		},
+	)
+
 	server.R.Use(LoggerMiddleware())
 
 	server.registerRoutes()
@@ -88,6 +138,8 @@ func (s *Server) registerRoutes() {
 			withId.Delete("/", s.handleDeleteMission)
 			withId.Patch("/assign", s.handleAssignCat)
 			withId.Patch("/complete", s.handleCompleteMission)
+			withId.Post("/clone", s.handleCloneMission)
+			withId.Post("/split", s.handleSplitMission)
 		}
 
 		targets := withId.Group("/targets")
@@ -96,11 +148,97 @@ func (s *Server) registerRoutes() {
 			targets.Delete("/:targetId", s.handleDeleteTarget)
 			targets.Patch("/:targetId/notes", s.handleUpdateTargetNotes)
 			targets.Patch("/:targetId/complete", s.handleCompleteTarget)
+			targets.Post("/:targetId/move", s.handleMoveTarget)
+			targets.Get("/:targetId/moves", s.handleGetTargetMoves)
 		}
 	}
+
+	if s.eventStream != nil {
+		s.registerEventRoutes()
+	}
+
+	if s.webhookService != nil {
+		s.registerWebhookRoutes()
+	}
+
+	if s.outboxService != nil {
+		s.registerOutboxRoutes()
+	}
+
+	if s.searchService != nil {
+		s.registerSearchRoutes()
+	}
+
+	if s.templateService != nil {
+		s.registerTemplateRoutes()
+	}
+
+	if s.ruleSource != nil {
+		s.registerRulesRoutes()
+	}
+
+	if s.importService != nil {
+		s.registerImportRoutes()
+	}
+
+	if s.snapshotService != nil {
+		s.registerSnapshotRoutes()
+	}
+
+	if s.reportService != nil {
+		s.registerReportRoutes()
+	}
+
+	if s.analyticsService != nil {
+		s.registerAnalyticsRoutes()
+	}
+
+	if s.availabilityService != nil {
+		s.registerAvailabilityRoutes()
+	}
+
+	if s.skillService != nil {
+		s.registerSkillRoutes()
+	}
+
+	if s.assignmentService != nil {
+		s.registerAssignmentRoutes()
+	}
+
+	if s.costService != nil {
+		s.registerCostRoutes()
+	}
+
+	if s.rateService != nil {
+		s.registerRateRoutes()
+	}
+
+	if s.locationService != nil {
+		s.registerLocationRoutes()
+	}
+
+	if s.subjectService != nil {
+		s.registerSubjectRoutes()
+	}
+
+	if s.attachmentService != nil {
+		s.registerAttachmentRoutes()
+	}
 }
 
 func (s *Server) handleGetCats(c fiber.Ctx) error {
+	f, ok, err := s.exportFormat(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": err.Error()})
+	}
+	if ok {
+		return streamReport(c, f, "cats", reports.Cats, s.reportService.EachCat)
+	}
+
+	if c.Query("available_at") != "" {
+		return s.handleGetAvailableCats(c)
+	}
+
 	res, err := s.catService.GetAllCats(c.Context())
 	if err != nil {
 		return handleError(c, err)
@@ -188,11 +326,40 @@ func (s *Server) handleCreateMission(c fiber.Ctx) error {
 }
 
 func (s *Server) handleGetMissions(c fiber.Ctx) error {
+	f, ok, err := s.exportFormat(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{"error": err.Error()})
+	}
+	if ok {
+		return streamReport(c, f, "missions", reports.Missions, s.reportService.EachMission)
+	}
+
+	html, err := s.renderHTML(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
 	res, err := s.missionService.GetAllMissions(c.Context())
 	if err != nil {
 		return handleError(c, err)
 	}
 
+	if html {
+		// The notes of all the missions are rendered at once, then split back
+		// by mission in the same order.
+		var targets []models.Target
+		for _, m := range res {
+			targets = append(targets, m.Targets...)
+		}
+		if targets, err = s.noteService.RenderNotes(c.Context(), targets); err != nil {
+			return handleError(c, err)
+		}
+		for i := range res {
+			n := len(res[i].Targets)
+			res[i].Targets, targets = targets[:n:n], targets[n:]
+		}
+	}
+
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
@@ -202,11 +369,22 @@ func (s *Server) handleGetSingleMission(c fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
 	}
 
+	html, err := s.renderHTML(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
 	res, err := s.missionService.GetMission(c.Context(), int32(id))
 	if err != nil {
 		return handleError(c, err)
 	}
 
+	if html {
+		if res.Targets, err = s.noteService.RenderNotes(c.Context(), res.Targets); err != nil {
+			return handleError(c, err)
+		}
+	}
+
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
@@ -236,7 +414,7 @@ func (s *Server) handleAssignCat(c fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	res, err := s.missionService.AssignCatToMission(c.Context(), int32(id), r.Assignee)
+	res, err := s.missionService.AssignCatToMission(c.Context(), int32(id), r)
 	if err != nil {
 		return handleError(c, err)
 	}
@@ -257,6 +435,49 @@ func (s *Server) handleCompleteMission(c fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
+func (s *Server) handleCloneMission(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	// The body is optional.
+	var r models.CloneMissionRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&r); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	res, err := s.missionService.CloneMission(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleSplitMission(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.SplitMissionRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.missionService.SplitMission(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
 func (s *Server) handleAddTarget(c fiber.Ctx) error {
 	missionId, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -303,11 +524,24 @@ func (s *Server) handleUpdateTargetNotes(c fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	html, err := s.renderHTML(c)
+	if err != nil {
+		return handleError(c, err)
+	}
+
 	res, err := s.targetService.UpdateTargetNotes(c.Context(), int32(targetId), r.Notes)
 	if err != nil {
 		return handleError(c, err)
 	}
 
+	if html {
+		rendered, err := s.noteService.RenderNotes(c.Context(), []models.Target{res})
+		if err != nil {
+			return handleError(c, err)
+		}
+		res = rendered[0]
+	}
+
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
@@ -325,6 +559,45 @@ func (s *Server) handleCompleteTarget(c fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(res)
 }
 
+func (s *Server) handleMoveTarget(c fiber.Ctx) error {
+	missionId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.MoveTargetRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.targetService.MoveTarget(c.Context(), int32(missionId), int32(targetId), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleGetTargetMoves(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.targetService.GetTargetMoves(c.Context(), int32(targetId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"moves": res})
+}
+
 func handleError(c fiber.Ctx, err error) error {
 	var customErr *models.Err
 	if errors.As(err, &customErr) {
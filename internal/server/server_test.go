@@ -0,0 +1,1086 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMain(m *testing.M) {
+	// The request logs drown the test output.
+	log.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+func TestServer(t *testing.T) {
+	runScenarios(t, catScenarios)
+	runScenarios(t, missionScenarios)
+	runScenarios(t, targetScenarios)
+	runScenarios(t, eventScenarios)
+	runScenarios(t, webhookScenarios)
+	runScenarios(t, outboxScenarios)
+	runScenarios(t, searchScenarios)
+	runScenarios(t, templateScenarios)
+	runScenarios(t, rulesScenarios)
+	runScenarios(t, importScenarios)
+	runScenarios(t, snapshotScenarios)
+	runScenarios(t, reportScenarios)
+	runScenarios(t, analyticsScenarios)
+	runScenarios(t, availabilityScenarios)
+	runScenarios(t, skillScenarios)
+	runScenarios(t, assignScenarios)
+	runScenarios(t, costScenarios)
+	runScenarios(t, rateScenarios)
+	runScenarios(t, locationScenarios)
+	runScenarios(t, subjectScenarios)
+	runScenarios(t, attachmentScenarios)
+	runScenarios(t, noteScenarios)
+
+	// Only a full run requests all the routes.
+	if f := flag.Lookup("test.run"); f != nil && f.Value.String() != "" {
+		return
+	}
+	assert.Empty(t, uncoveredRoutes(newTestServer(newFakeService())), "routes without a scenario")
+}
+
+// TestBodyLimit checks the attachments raise the body limit of the uploads
+// only, the other bodies keep the default limit. A body over the limit is
+// rejected while it is read, before the routing, which the server answers
+// with 413 and app.Test returns as an error.
+func TestBodyLimit(t *testing.T) {
+	f := newFakeService()
+	withCatAndMission(f)
+	srv := New(f, f, f, WithSnapshotService(f), WithImportService(f), WithAttachmentService(f, 2*fiber.DefaultBodyLimit))
+
+	large := strings.Repeat("a", fiber.DefaultBodyLimit+1)
+
+	for _, path := range []string{"/cats", "/import/snapshot", "/import/cats", "/missions/2/targets/3/attachments/1"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(large))
+		_, err := srv.R.Test(req)
+		assert.ErrorIs(t, err, fasthttp.ErrBodyTooLarge, path)
+	}
+
+	upload := func(path, content string) (*http.Response, error) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(multipartFile("file", "ivan.txt", content)))
+		for k, v := range multipartHeader {
+			req.Header.Set(k, v)
+		}
+		return srv.R.Test(req)
+	}
+
+	_, err := upload("/missions/2/targets/3/attachments", strings.Repeat("a", 2*fiber.DefaultBodyLimit+multipartOverhead))
+	assert.ErrorIs(t, err, fasthttp.ErrBodyTooLarge, "over the limit of the uploads")
+
+	// The fake limit is lower than the one of the server, so the service rejects it.
+	resp, err := upload("/missions/2/targets/3/attachments/?filename=ivan", large)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.JSONEq(t, `{"error":"attachment is too large (max 64 bytes)"}`, string(data))
+}
+
+// countingNotes counts the calls of a NoteService.
+type countingNotes struct {
+	NoteService
+	calls int
+}
+
+func (n *countingNotes) RenderNotes(ctx context.Context, targets []models.Target) ([]models.Target, error) {
+	n.calls++
+	return n.NoteService.RenderNotes(ctx, targets)
+}
+
+// TestRenderMissions checks the notes of all the missions are rendered in a
+// single call and given back to their missions.
+func TestRenderMissions(t *testing.T) {
+	f := newFakeService()
+	withCatAndMission(f)
+	f.missions[5] = models.Mission{ID: 5, Targets: []models.Target{{ID: 6, Name: "Piotr", Country: "PL", Notes: "Notes of *Piotr*"}}}
+	f.missions[7] = models.Mission{ID: 7}
+	notes := &countingNotes{NoteService: f}
+	srv := New(f, f, f, WithNoteService(notes))
+
+	resp, err := srv.R.Test(httptest.NewRequest(http.MethodGet, "/missions?render=html", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var missions []models.Mission
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&missions))
+	assert.Equal(t, 1, notes.calls)
+	require.Len(t, missions, 3)
+	require.Len(t, missions[0].Targets, 2)
+	assert.Equal(t, "<p>Notes of Ivan</p>\n", missions[0].Targets[0].NotesHTML)
+	assert.Equal(t, "<p>Notes of Olga</p>\n", missions[0].Targets[1].NotesHTML)
+	require.Len(t, missions[1].Targets, 1)
+	assert.Equal(t, "<p>Notes of <em>Piotr</em></p>\n", missions[1].Targets[0].NotesHTML)
+	assert.Empty(t, missions[2].Targets)
+}
+
+// withCatAndMission creates cat 1 and mission 2 with targets 3 and 4.
+func withCatAndMission(f *fakeService) {
+	f.cats[1] = catTom
+	f.missions[2] = missionIvanOlga()
+	f.nextID = 5
+}
+
+var catTom = models.Cat{ID: 1, Name: "Tom", Breed: "Abyssinian", YearsOfExperience: 3, Salary: 100, SalaryCurrency: "USD"}
+
+func missionIvanOlga() models.Mission {
+	return models.Mission{ID: 2, Targets: []models.Target{
+		{ID: 3, Name: "Ivan", Country: "UA", Notes: "Notes of Ivan"},
+		{ID: 4, Name: "Olga", Country: "PL", Notes: "Notes of Olga"},
+	}}
+}
+
+var createCatTom = map[string]any{"name": "Tom", "breed": "Abyssinian", "years_of_experience": 3, "salary": 100}
+
+var createMissionIvanOlga = map[string]any{"targets": []map[string]any{
+	{"name": "Ivan", "country": "UA", "notes": "Notes of Ivan"},
+	{"name": "Olga", "country": "PL", "notes": "Notes of Olga"},
+}}
+
+var catScenarios = []scenario{
+	{
+		name: "cats",
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/cats", status: http.StatusOK, json: map[string]any{"cats.#": 0}},
+			{name: "create", method: http.MethodPost, path: "/cats", body: createCatTom, status: http.StatusCreated, golden: true},
+			{name: "list", method: http.MethodGet, path: "/cats", status: http.StatusOK, golden: true},
+			{name: "get", method: http.MethodGet, path: "/cats/1", status: http.StatusOK, json: map[string]any{"id": 1, "name": "Tom"}},
+			{name: "update salary", method: http.MethodPatch, path: "/cats/1", body: map[string]any{"salary": 250}, status: http.StatusOK, json: map[string]any{"salary": 250, "salary_currency": "USD"}},
+			{name: "update salary currency", method: http.MethodPatch, path: "/cats/1", body: map[string]any{"salary": 300, "salary_currency": "eur"}, status: http.StatusOK, json: map[string]any{"salary": 300, "salary_currency": "EUR"}},
+			{name: "set euro rate", method: http.MethodPut, path: "/exchange-rates/EUR", body: map[string]any{"rate": 1.1}, status: http.StatusOK},
+			{name: "list in reporting currency", method: http.MethodGet, path: "/cats", status: http.StatusOK, json: map[string]any{"cats.0.reporting_salary.amount": 33000, "cats.0.reporting_salary.currency": "USD"}},
+			{name: "delete", method: http.MethodDelete, path: "/cats/1", status: http.StatusNoContent},
+			{name: "get deleted", method: http.MethodGet, path: "/cats/1", status: http.StatusNotFound, json: map[string]any{"error": "not found"}},
+			{name: "delete deleted", method: http.MethodDelete, path: "/cats/1", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "cats errors",
+		steps: []step{
+			{name: "create malformed", method: http.MethodPost, path: "/cats", body: `{"name":`, status: http.StatusBadRequest, golden: true},
+			{name: "create missing field", method: http.MethodPost, path: "/cats", body: map[string]any{"breed": "Abyssinian", "years_of_experience": 3, "salary": 100}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: name"}},
+			{name: "create unknown breed", method: http.MethodPost, path: "/cats", body: map[string]any{"name": "Tom", "breed": "Unknown", "years_of_experience": 3, "salary": 100}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "Unknown breed"}},
+			{name: "create unknown currency", method: http.MethodPost, path: "/cats", body: map[string]any{"name": "Tom", "breed": "Abyssinian", "years_of_experience": 3, "salary": 100, "salary_currency": "XYZ"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "salary_currency must be an ISO 4217 code"}},
+			{name: "get invalid id", method: http.MethodGet, path: "/cats/tom", status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "update invalid id", method: http.MethodPatch, path: "/cats/tom", body: map[string]any{"salary": 250}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "update missing", method: http.MethodPatch, path: "/cats/9", body: map[string]any{"salary": 250}, status: http.StatusNotFound},
+			{name: "update missing salary", method: http.MethodPatch, path: "/cats/9", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: salary"}},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/cats/tom", status: http.StatusBadRequest},
+		},
+	},
+}
+
+var missionScenarios = []scenario{
+	{
+		name: "missions",
+		setup: func(f *fakeService) {
+			f.cats[1] = catTom
+			f.nextID = 2
+		},
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/missions", status: http.StatusOK, json: map[string]any{"#": 0}},
+			{name: "create", method: http.MethodPost, path: "/missions", body: createMissionIvanOlga, status: http.StatusCreated, golden: true},
+			{name: "list", method: http.MethodGet, path: "/missions", status: http.StatusOK, json: map[string]any{"#": 1, "0.id": 2}},
+			{name: "get", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"targets.#": 2, "targets.1.name": "Olga"}},
+			{name: "assign", method: http.MethodPatch, path: "/missions/2/assign", body: map[string]any{"assignee": 1}, status: http.StatusOK, json: map[string]any{"assignee": 1}},
+			{name: "delete assigned", method: http.MethodDelete, path: "/missions/2", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "can't delete an assigned mission"}},
+			{name: "complete pending", method: http.MethodPatch, path: "/missions/2/complete", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "mission has pending targets"}},
+			{name: "complete target 3", method: http.MethodPatch, path: "/missions/2/targets/3/complete", status: http.StatusOK},
+			{name: "complete target 4", method: http.MethodPatch, path: "/missions/2/targets/4/complete", status: http.StatusOK},
+			{name: "complete", method: http.MethodPatch, path: "/missions/2/complete", status: http.StatusOK, golden: true},
+		},
+	},
+	{
+		// Unlike the other deletes, deleting a mission responds with 200 and an empty object.
+		name: "delete mission",
+		steps: []step{
+			{name: "create", method: http.MethodPost, path: "/missions", body: createMissionIvanOlga, status: http.StatusCreated},
+			{name: "delete", method: http.MethodDelete, path: "/missions/1", status: http.StatusOK, golden: true},
+			{name: "get deleted", method: http.MethodGet, path: "/missions/1", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "missions errors",
+		setup: func(f *fakeService) {
+			f.cats[1] = catTom
+			f.nextID = 2
+		},
+		steps: []step{
+			{name: "create malformed", method: http.MethodPost, path: "/missions", body: `[]`, status: http.StatusBadRequest},
+			{name: "create without targets", method: http.MethodPost, path: "/missions", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: targets"}},
+			{name: "create with no targets", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []any{}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "incorrect number of targets (1..3)"}},
+			{name: "get invalid id", method: http.MethodGet, path: "/missions/first", status: http.StatusBadRequest},
+			{name: "get missing", method: http.MethodGet, path: "/missions/9", status: http.StatusNotFound},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/missions/first", status: http.StatusBadRequest},
+			{name: "assign invalid id", method: http.MethodPatch, path: "/missions/first/assign", body: map[string]any{"assignee": 1}, status: http.StatusBadRequest},
+			{name: "assign without assignee", method: http.MethodPatch, path: "/missions/9/assign", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: assignee"}},
+			{name: "assign missing cat", method: http.MethodPatch, path: "/missions/9/assign", body: map[string]any{"assignee": 7}, status: http.StatusNotFound, json: map[string]any{"error": "cat not found"}},
+			{name: "assign to missing mission", method: http.MethodPatch, path: "/missions/9/assign", body: map[string]any{"assignee": 1}, status: http.StatusNotFound, json: map[string]any{"error": "mission not found"}},
+			{name: "complete invalid id", method: http.MethodPatch, path: "/missions/first/complete", status: http.StatusBadRequest},
+			{name: "complete missing", method: http.MethodPatch, path: "/missions/9/complete", status: http.StatusNotFound},
+		},
+	}, {
+		name:  "clone and split",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "clone", method: http.MethodPost, path: "/missions/2/clone", status: http.StatusCreated, golden: true},
+			{name: "complete target 3", method: http.MethodPatch, path: "/missions/2/targets/3/complete", status: http.StatusOK},
+			{name: "clone with notes", method: http.MethodPost, path: "/missions/2/clone", body: map[string]any{"notes": true}, status: http.StatusCreated, json: map[string]any{"targets.#": 1, "targets.0.name": "Olga", "targets.0.notes": "Notes of Olga"}},
+			{name: "split completed target", method: http.MethodPost, path: "/missions/2/split", body: map[string]any{"targets": []int{3}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "can't move completed target 3"}},
+			{name: "split", method: http.MethodPost, path: "/missions/2/split", body: map[string]any{"targets": []int{4}}, status: http.StatusCreated, golden: true},
+			{name: "mission after split", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"targets.#": 1, "targets.0.id": 3}},
+			{name: "clone completed", method: http.MethodPost, path: "/missions/2/clone", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "mission has no pending targets"}},
+		},
+	},
+	{
+		name:  "clone and split errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "clone invalid id", method: http.MethodPost, path: "/missions/first/clone", status: http.StatusBadRequest},
+			{name: "clone malformed", method: http.MethodPost, path: "/missions/2/clone", body: `{"notes": "yes"}`, status: http.StatusBadRequest},
+			{name: "clone missing", method: http.MethodPost, path: "/missions/9/clone", status: http.StatusNotFound},
+			{name: "split invalid id", method: http.MethodPost, path: "/missions/first/split", body: map[string]any{"targets": []int{4}}, status: http.StatusBadRequest},
+			{name: "split without targets", method: http.MethodPost, path: "/missions/2/split", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: targets"}},
+			{name: "split all targets", method: http.MethodPost, path: "/missions/2/split", body: map[string]any{"targets": []int{3, 4}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "can't move all targets of the mission"}},
+			{name: "split missing", method: http.MethodPost, path: "/missions/9/split", body: map[string]any{"targets": []int{4}}, status: http.StatusNotFound},
+		},
+	},
+}
+
+var targetScenarios = []scenario{
+	{
+		name:  "targets",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "add", method: http.MethodPost, path: "/missions/2/targets", body: map[string]any{"name": "Hans", "country": "DE", "notes": "Notes of Hans"}, status: http.StatusCreated, golden: true},
+			{name: "add over the limit", method: http.MethodPost, path: "/missions/2/targets", body: map[string]any{"name": "Anna", "country": "DE", "notes": "Notes of Anna"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "mission has maximum targets (3)"}},
+			{name: "update notes", method: http.MethodPatch, path: "/missions/2/targets/3/notes", body: map[string]any{"notes": "new notes"}, status: http.StatusOK, json: map[string]any{"id": 3, "notes": "new notes"}},
+			{name: "complete", method: http.MethodPatch, path: "/missions/2/targets/3/complete", status: http.StatusOK, golden: true},
+			{name: "update notes of completed", method: http.MethodPatch, path: "/missions/2/targets/3/notes", body: map[string]any{"notes": "newer notes"}, status: http.StatusUnprocessableEntity},
+			{name: "delete", method: http.MethodDelete, path: "/missions/2/targets/4", status: http.StatusNoContent},
+			{name: "mission after delete", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"targets.#": 2, "targets.0.notes": "new notes", "targets.1.name": "Hans"}},
+		},
+	},
+	{
+		name:  "targets errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "add invalid id", method: http.MethodPost, path: "/missions/first/targets", body: map[string]any{"name": "Hans", "country": "DE", "notes": "notes"}, status: http.StatusBadRequest},
+			{name: "add without country", method: http.MethodPost, path: "/missions/2/targets", body: map[string]any{"name": "Hans", "notes": "notes"}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: country"}},
+			{name: "add to missing mission", method: http.MethodPost, path: "/missions/9/targets", body: map[string]any{"name": "Hans", "country": "DE", "notes": "notes"}, status: http.StatusNotFound},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/missions/2/targets/first", status: http.StatusBadRequest},
+			{name: "delete missing", method: http.MethodDelete, path: "/missions/2/targets/9", status: http.StatusNotFound},
+			{name: "update notes invalid id", method: http.MethodPatch, path: "/missions/2/targets/first/notes", body: map[string]any{"notes": "notes"}, status: http.StatusBadRequest},
+			{name: "update empty notes", method: http.MethodPatch, path: "/missions/2/targets/3/notes", body: map[string]any{"notes": ""}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: notes"}},
+			{name: "complete invalid id", method: http.MethodPatch, path: "/missions/2/targets/first/complete", status: http.StatusBadRequest},
+			{name: "complete missing", method: http.MethodPatch, path: "/missions/2/targets/9/complete", status: http.StatusNotFound},
+		},
+	}, {
+		name: "move target",
+		setup: func(f *fakeService) {
+			withCatAndMission(f)
+			f.missions[5] = models.Mission{ID: 5, Targets: []models.Target{{ID: 6, Name: "Hans", Country: "DE", Notes: "Notes of Hans"}}}
+			f.nextID = 7
+		},
+		steps: []step{
+			{name: "no moves", method: http.MethodGet, path: "/missions/2/targets/4/moves", status: http.StatusOK, json: map[string]any{"moves.#": 0}},
+			{name: "move", method: http.MethodPost, path: "/missions/2/targets/4/move", body: map[string]any{"mission": 5}, status: http.StatusOK, golden: true},
+			{name: "source after move", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"targets.#": 1, "targets.0.id": 3}},
+			{name: "move the last target", method: http.MethodPost, path: "/missions/2/targets/3/move", body: map[string]any{"mission": 5}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "mission must keep at least one target"}},
+			{name: "move back", method: http.MethodPost, path: "/missions/5/targets/4/move", body: map[string]any{"mission": 2}, status: http.StatusOK, json: map[string]any{"targets.#": 2, "targets.1.notes": "Notes of Olga"}},
+			{name: "moves", method: http.MethodGet, path: "/missions/2/targets/4/moves", status: http.StatusOK, golden: true},
+		},
+	},
+	{
+		name:  "move target errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "invalid mission id", method: http.MethodPost, path: "/missions/first/targets/4/move", body: map[string]any{"mission": 5}, status: http.StatusBadRequest},
+			{name: "invalid target id", method: http.MethodPost, path: "/missions/2/targets/first/move", body: map[string]any{"mission": 5}, status: http.StatusBadRequest},
+			{name: "without mission", method: http.MethodPost, path: "/missions/2/targets/4/move", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: mission"}},
+			{name: "target of another mission", method: http.MethodPost, path: "/missions/9/targets/4/move", body: map[string]any{"mission": 2}, status: http.StatusNotFound},
+			{name: "missing destination", method: http.MethodPost, path: "/missions/2/targets/4/move", body: map[string]any{"mission": 9}, status: http.StatusNotFound, json: map[string]any{"error": "destination mission not found"}},
+			{name: "same mission", method: http.MethodPost, path: "/missions/2/targets/4/move", body: map[string]any{"mission": 2}, status: http.StatusUnprocessableEntity},
+			{name: "moves invalid id", method: http.MethodGet, path: "/missions/2/targets/first/moves", status: http.StatusBadRequest},
+		},
+	},
+}
+
+var eventScenarios = []scenario{
+	{
+		name: "events",
+		setup: func(f *fakeService) {
+			withCatAndMission(f)
+			f.missions[1] = f.missions[2]
+			f.seedEvents(0)
+		},
+		steps: []step{
+			{name: "replay all missions", method: http.MethodGet, path: "/events", header: map[string]string{"Last-Event-ID": "boot-1"}, status: http.StatusOK, golden: true},
+			{name: "replay mission", method: http.MethodGet, path: "/missions/1/events", header: map[string]string{"Last-Event-ID": "boot-1"}, status: http.StatusOK, golden: true},
+			{name: "without last event id", method: http.MethodGet, path: "/missions/1/events", status: http.StatusOK},
+			{name: "invalid last event id", method: http.MethodGet, path: "/events", header: map[string]string{"Last-Event-ID": "first"}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid Last-Event-ID"}},
+			{name: "stale last event id", method: http.MethodGet, path: "/missions/1/events", header: map[string]string{"Last-Event-ID": "previous-1"}, status: http.StatusConflict, json: map[string]any{"error": "stale Last-Event-ID"}},
+			{name: "invalid mission id", method: http.MethodGet, path: "/missions/first/events", status: http.StatusBadRequest},
+			{name: "missing mission", method: http.MethodGet, path: "/missions/9/events", status: http.StatusNotFound},
+		},
+	},
+}
+
+var webhookScenarios = []scenario{
+	{
+		name: "webhooks",
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/webhooks", status: http.StatusOK, json: map[string]any{"webhooks.#": 0}},
+			{name: "create", method: http.MethodPost, path: "/webhooks", body: map[string]any{"url": "https://example.com/hook", "event_types": []string{events.MissionCompleted}}, status: http.StatusCreated, golden: true},
+			{name: "list", method: http.MethodGet, path: "/webhooks", status: http.StatusOK, golden: true},
+			{name: "get", method: http.MethodGet, path: "/webhooks/1", status: http.StatusOK, json: map[string]any{"id": 1, "active": true}},
+			{name: "deactivate", method: http.MethodPatch, path: "/webhooks/1", body: map[string]any{"active": false}, status: http.StatusOK, json: map[string]any{"active": false, "url": "https://example.com/hook"}},
+			{name: "delete", method: http.MethodDelete, path: "/webhooks/1", status: http.StatusNoContent},
+			{name: "get deleted", method: http.MethodGet, path: "/webhooks/1", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "webhook deliveries",
+		setup: func(f *fakeService) {
+			f.webhooks[1] = webhookMissions
+			f.nextID = 2
+			f.seedEvents(1)
+		},
+		steps: []step{
+			{name: "list", method: http.MethodGet, path: "/webhooks/1/deliveries", status: http.StatusOK, golden: true},
+			{name: "redeliver", method: http.MethodPost, path: "/webhooks/1/deliveries/1/redeliver", status: http.StatusAccepted, json: map[string]any{"status": "pending"}},
+			{name: "redeliver missing", method: http.MethodPost, path: "/webhooks/1/deliveries/9/redeliver", status: http.StatusNotFound},
+			{name: "redeliver invalid delivery id", method: http.MethodPost, path: "/webhooks/1/deliveries/first/redeliver", status: http.StatusBadRequest},
+			{name: "redeliver invalid id", method: http.MethodPost, path: "/webhooks/first/deliveries/1/redeliver", status: http.StatusBadRequest},
+			{name: "list invalid id", method: http.MethodGet, path: "/webhooks/first/deliveries", status: http.StatusBadRequest},
+			{name: "list missing", method: http.MethodGet, path: "/webhooks/9/deliveries", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "webhooks errors",
+		steps: []step{
+			{name: "create invalid url", method: http.MethodPost, path: "/webhooks", body: map[string]any{"url": "example", "event_types": []string{events.MissionCompleted}}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid input: url"}},
+			{name: "create without event types", method: http.MethodPost, path: "/webhooks", body: map[string]any{"url": "https://example.com/hook", "event_types": []string{}}, status: http.StatusUnprocessableEntity},
+			{name: "get invalid id", method: http.MethodGet, path: "/webhooks/first", status: http.StatusBadRequest},
+			{name: "update invalid id", method: http.MethodPatch, path: "/webhooks/first", body: map[string]any{}, status: http.StatusBadRequest},
+			{name: "update missing", method: http.MethodPatch, path: "/webhooks/9", body: map[string]any{}, status: http.StatusNotFound},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/webhooks/first", status: http.StatusBadRequest},
+			{name: "delete missing", method: http.MethodDelete, path: "/webhooks/9", status: http.StatusNotFound},
+		},
+	},
+}
+
+var webhookMissions = models.Webhook{
+	ID:         1,
+	URL:        "https://example.com/hook",
+	EventTypes: []string{events.MissionCompleted},
+	Active:     true,
+	CreatedAt:  fakeTime,
+}
+
+var outboxScenarios = []scenario{
+	{
+		name:  "outbox",
+		setup: func(f *fakeService) { f.seedEvents(0) },
+		steps: []step{
+			{name: "list", method: http.MethodGet, path: "/outbox", status: http.StatusOK, golden: true},
+			{name: "list from offset", method: http.MethodGet, path: "/outbox?from_offset=2&limit=10", status: http.StatusOK, json: map[string]any{"events.#": 1, "events.0.offset": 2}},
+			{name: "replay", method: http.MethodPost, path: "/outbox/replay", body: map[string]any{"from_offset": 2}, status: http.StatusAccepted, json: map[string]any{"replayed": 1}},
+			{name: "replayed event", method: http.MethodGet, path: "/outbox?from_offset=2", status: http.StatusOK, json: map[string]any{"events.0.published_at": nil}},
+		},
+	},
+	{
+		name: "outbox errors",
+		steps: []step{
+			{name: "invalid offset", method: http.MethodGet, path: "/outbox?from_offset=first", status: http.StatusBadRequest, json: map[string]any{"error": "invalid from_offset"}},
+			{name: "invalid limit", method: http.MethodGet, path: "/outbox?limit=all", status: http.StatusBadRequest, json: map[string]any{"error": "invalid limit"}},
+			{name: "limit out of range", method: http.MethodGet, path: "/outbox?limit=0", status: http.StatusUnprocessableEntity},
+			{name: "replay without offset", method: http.MethodPost, path: "/outbox/replay", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: from_offset"}},
+			{name: "replay negative offset", method: http.MethodPost, path: "/outbox/replay", body: map[string]any{"from_offset": -1}, status: http.StatusUnprocessableEntity},
+		},
+	},
+}
+
+var searchScenarios = []scenario{
+	{
+		name:  "search",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "match", method: http.MethodGet, path: "/search?q=Olga", status: http.StatusOK, golden: true},
+			{name: "no match", method: http.MethodGet, path: "/search?q=Hans&limit=5", status: http.StatusOK, json: map[string]any{"results.#": 0}},
+			{name: "empty query", method: http.MethodGet, path: "/search", status: http.StatusUnprocessableEntity},
+			{name: "invalid limit", method: http.MethodGet, path: "/search?q=Olga&limit=all", status: http.StatusBadRequest},
+			{name: "limit out of range", method: http.MethodGet, path: "/search?q=Olga&limit=500", status: http.StatusUnprocessableEntity},
+			{name: "internal error", method: http.MethodGet, path: "/search?q=fail", status: http.StatusInternalServerError, json: map[string]any{"error": "unexpected error"}},
+		},
+	},
+}
+
+var createTemplateHarbour = map[string]any{
+	"name": "Harbour watch",
+	"targets": []map[string]any{
+		{"name": "{{name}}", "country": "PL", "notes": "Watch the {{place}}"},
+		{"name": "Courier of {{name}}", "country": "PL", "notes": "Follow to the {{place}}"},
+	},
+}
+
+var templateScenarios = []scenario{
+	{
+		name: "mission templates",
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/mission-templates", status: http.StatusOK, json: map[string]any{"templates.#": 0}},
+			{name: "create", method: http.MethodPost, path: "/mission-templates", body: createTemplateHarbour, status: http.StatusCreated, golden: true},
+			{name: "list", method: http.MethodGet, path: "/mission-templates", status: http.StatusOK, json: map[string]any{"templates.#": 1, "templates.0.id": 1}},
+			{name: "get", method: http.MethodGet, path: "/mission-templates/1", status: http.StatusOK, json: map[string]any{"variables": []string{"name", "place"}}},
+			{name: "rename", method: http.MethodPatch, path: "/mission-templates/1", body: map[string]any{"name": "Night harbour watch"}, status: http.StatusOK, json: map[string]any{"name": "Night harbour watch", "targets.#": 2}},
+			{name: "create mission", method: http.MethodPost, path: "/missions/from-template/1", body: map[string]any{"variables": map[string]string{"name": "Zbigniew", "place": "old harbour"}}, status: http.StatusCreated, golden: true},
+			{name: "create mission without variables", method: http.MethodPost, path: "/missions/from-template/1", body: map[string]any{}, status: http.StatusUnprocessableEntity},
+			{name: "delete", method: http.MethodDelete, path: "/mission-templates/1", status: http.StatusNoContent},
+			{name: "create mission from deleted", method: http.MethodPost, path: "/missions/from-template/1", body: map[string]any{}, status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "mission templates errors",
+		steps: []step{
+			{name: "create without name", method: http.MethodPost, path: "/mission-templates", body: map[string]any{"targets": []any{}}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: name"}},
+			{name: "create without targets", method: http.MethodPost, path: "/mission-templates", body: map[string]any{"name": "Empty", "targets": []any{}}, status: http.StatusUnprocessableEntity},
+			{name: "get invalid id", method: http.MethodGet, path: "/mission-templates/first", status: http.StatusBadRequest},
+			{name: "get missing", method: http.MethodGet, path: "/mission-templates/9", status: http.StatusNotFound},
+			{name: "update invalid id", method: http.MethodPatch, path: "/mission-templates/first", body: map[string]any{}, status: http.StatusBadRequest},
+			{name: "update malformed", method: http.MethodPatch, path: "/mission-templates/9", body: `{"name": 1}`, status: http.StatusBadRequest},
+			{name: "update missing", method: http.MethodPatch, path: "/mission-templates/9", body: map[string]any{}, status: http.StatusNotFound},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/mission-templates/first", status: http.StatusBadRequest},
+			{name: "delete missing", method: http.MethodDelete, path: "/mission-templates/9", status: http.StatusNotFound},
+			{name: "create mission invalid id", method: http.MethodPost, path: "/missions/from-template/first", body: map[string]any{}, status: http.StatusBadRequest},
+			{name: "create mission malformed", method: http.MethodPost, path: "/missions/from-template/9", body: `{"variables": []}`, status: http.StatusBadRequest},
+		},
+	},
+}
+
+var rulesScenarios = []scenario{
+	{
+		name: "rules",
+		steps: []step{
+			{name: "get", method: http.MethodGet, path: "/rules", status: http.StatusOK, golden: true},
+		},
+	},
+}
+
+var csvHeader = map[string]string{"Content-Type": "text/csv"}
+
+var ndjsonHeader = map[string]string{"Content-Type": "application/x-ndjson"}
+
+const importCatsCSV = `name,breed,years_of_experience,salary
+Tom,Abyssinian,3,100
+,Bengal,2,80
+Kitty,Unknown,1,90
+Leo,Bengal,1,many
+`
+
+const importMissionsCSV = `mission,name,country,notes
+a,Ivan,UA,Notes of Ivan
+b,Anna,DE,Notes of Anna
+a,Olga,PL,Notes of Olga
+c,P1,CZ,n
+c,P2,CZ,n
+c,P3,CZ,n
+c,P4,CZ,n
+`
+
+var importScenarios = []scenario{
+	{
+		name: "import cats",
+		steps: []step{
+			{name: "dry run", method: http.MethodPost, path: "/import/cats?dry_run=true", header: csvHeader, body: importCatsCSV, status: http.StatusOK, golden: true},
+			{name: "all or nothing", method: http.MethodPost, path: "/import/cats", header: csvHeader, body: importCatsCSV, status: http.StatusUnprocessableEntity, json: map[string]any{"created": 0, "invalid": 3}},
+			{name: "list after all or nothing", method: http.MethodGet, path: "/cats", status: http.StatusOK, json: map[string]any{"cats.#": 0}},
+			{name: "best effort", method: http.MethodPost, path: "/import/cats?mode=best_effort", header: csvHeader, body: importCatsCSV, status: http.StatusCreated, golden: true},
+			{name: "list after best effort", method: http.MethodGet, path: "/cats", status: http.StatusOK, json: map[string]any{"cats.#": 1, "cats.0.name": "Tom"}},
+			{name: "error report", method: http.MethodPost, path: "/import/cats?dry_run=true&report=csv", header: csvHeader, body: importCatsCSV, status: http.StatusOK, golden: true},
+			{name: "ndjson", method: http.MethodPost, path: "/import/cats", header: ndjsonHeader, body: `{"name":"Leo","breed":"Bengal","years_of_experience":4,"salary":120}` + "\n\n" + `{"name":"Max","breed":"Bengal","years_of_experience":2,"salary":80}`, status: http.StatusCreated, json: map[string]any{"created": 2, "rows.1.line": 3, "rows.1.id": 3}},
+		},
+	},
+	{
+		name: "import missions",
+		steps: []step{
+			{name: "best effort", method: http.MethodPost, path: "/import/missions?mode=best_effort", header: csvHeader, body: importMissionsCSV, status: http.StatusCreated, golden: true},
+			{name: "get grouped", method: http.MethodGet, path: "/missions/1", status: http.StatusOK, json: map[string]any{"targets.#": 2, "targets.1.name": "Olga"}},
+			{name: "ndjson", method: http.MethodPost, path: "/import/missions", header: ndjsonHeader, body: `{"targets":[{"name":"Ivan","country":"UA","notes":"n"}]}` + "\n" + `{"targets":`, status: http.StatusUnprocessableEntity, json: map[string]any{"rows.1.errors": []string{"invalid JSON"}}},
+		},
+	},
+	{
+		name: "import errors",
+		steps: []step{
+			{name: "unsupported content type", method: http.MethodPost, path: "/import/cats", header: map[string]string{"Content-Type": "application/xml"}, body: "<cats/>", status: http.StatusUnsupportedMediaType},
+			{name: "missing column", method: http.MethodPost, path: "/import/cats", header: csvHeader, body: "name,breed,salary\nTom,Bengal,100\n", status: http.StatusBadRequest, json: map[string]any{"error": "missing column: years_of_experience"}},
+			{name: "invalid dry run", method: http.MethodPost, path: "/import/cats?dry_run=maybe", header: csvHeader, body: importCatsCSV, status: http.StatusBadRequest},
+			{name: "unknown mode", method: http.MethodPost, path: "/import/missions?mode=some", header: csvHeader, body: importMissionsCSV, status: http.StatusUnprocessableEntity},
+			{name: "empty", method: http.MethodPost, path: "/import/missions", header: csvHeader, body: "mission,name,country,notes\n", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "nothing to import"}},
+			{name: "invalid report", method: http.MethodPost, path: "/import/cats?report=xml", header: csvHeader, body: importCatsCSV, status: http.StatusBadRequest},
+		},
+	},
+}
+
+// snapshotTomIvan is an archive of cat 1 assigned to mission 2 with target 3.
+const snapshotTomIvan = `{"kind":"metadata","data":{"format_version":1,"schema_version":20250324120000,"created_at":"2025-03-01T12:00:00Z"}}
+{"kind":"cat","data":{"id":1,"name":"Tom","breed":"Abyssinian","years_of_experience":3,"salary":100,"salary_currency":"USD"}}
+{"kind":"mission","data":{"id":2,"assignee":1,"completed":false,"created_at":"2025-03-01T12:00:00Z","assigned_at":"2025-03-01T12:00:00Z","completed_at":null}}
+{"kind":"target","data":{"id":3,"mission":2,"name":"Ivan","country":"UA","notes":"Notes of Ivan","completed":false,"created_at":"2025-03-01T12:00:00Z","completed_at":null}}
+{"kind":"end","data":{"cats":1,"missions":1,"targets":1}}
+`
+
+var snapshotScenarios = []scenario{
+	{
+		name:  "export snapshot",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "export", method: http.MethodGet, path: "/export", status: http.StatusOK, golden: true},
+		},
+	},
+	{
+		name: "restore snapshot",
+		steps: []step{
+			{name: "restore", method: http.MethodPost, path: "/import/snapshot", header: ndjsonHeader, body: snapshotTomIvan, status: http.StatusCreated, golden: true},
+			{name: "get restored mission", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"assignee": 1, "targets.0.id": 3}},
+			{name: "create after restore", method: http.MethodPost, path: "/cats", body: createCatTom, status: http.StatusCreated, json: map[string]any{"id": 4}},
+			{name: "restore not empty", method: http.MethodPost, path: "/import/snapshot", header: ndjsonHeader, body: snapshotTomIvan, status: http.StatusConflict, json: map[string]any{"error": "database is not empty"}},
+		},
+	},
+	{
+		name: "restore snapshot errors",
+		steps: []step{
+			{name: "empty", method: http.MethodPost, path: "/import/snapshot", header: ndjsonHeader, body: "", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "invalid snapshot: empty archive"}},
+			{name: "schema version", method: http.MethodPost, path: "/import/snapshot", header: ndjsonHeader, body: strings.Replace(snapshotTomIvan, "20250324120000", "20250101000000", 1), status: http.StatusUnprocessableEntity, golden: true},
+			{name: "truncated", method: http.MethodPost, path: "/import/snapshot", header: ndjsonHeader, body: snapshotTomIvan[:strings.Index(snapshotTomIvan, `{"kind":"end"`)], status: http.StatusUnprocessableEntity, json: map[string]any{"error": "invalid snapshot: truncated archive, no end record"}},
+		},
+	},
+}
+
+// withAssignedMissions assigns cat 1 to mission 2 and to the completed mission 5.
+func withAssignedMissions(f *fakeService) {
+	withCatAndMission(f)
+	m := f.missions[2]
+	m.Assignee = 1
+	m.Targets[0].Completed = true
+	f.missions[2] = m
+	f.missions[5] = models.Mission{ID: 5, Assignee: 1, Completed: true, Targets: []models.Target{
+		{ID: 6, Name: "Hans", Country: "DE", Notes: "Notes of Hans", Completed: true},
+	}}
+	f.nextID = 7
+}
+
+var reportScenarios = []scenario{
+	{
+		name:  "list exports",
+		setup: withAssignedMissions,
+		steps: []step{
+			{name: "cats csv", method: http.MethodGet, path: "/cats", header: map[string]string{"Accept": "text/csv"}, status: http.StatusOK, golden: true},
+			{name: "cats json", method: http.MethodGet, path: "/cats", header: map[string]string{"Accept": "application/json, text/csv"}, status: http.StatusOK, json: map[string]any{"cats.#": 1}},
+			{name: "cats columns", method: http.MethodGet, path: "/cats?format=csv&columns=name,salary", status: http.StatusOK, golden: true},
+			{name: "missions csv", method: http.MethodGet, path: "/missions?format=csv", status: http.StatusOK, golden: true},
+			{name: "missions xlsx", method: http.MethodGet, path: "/missions?format=xlsx", status: http.StatusOK},
+			{name: "missions json", method: http.MethodGet, path: "/missions?format=json", status: http.StatusOK, json: map[string]any{"#": 2}},
+		},
+	},
+	{
+		name:  "reports",
+		setup: withAssignedMissions,
+		steps: []step{
+			{name: "mission targets", method: http.MethodGet, path: "/reports/mission-targets", status: http.StatusOK, golden: true},
+			{name: "mission targets xlsx", method: http.MethodGet, path: "/reports/mission-targets?format=xlsx&columns=mission_id,target_id", status: http.StatusOK},
+			{name: "cat workload", method: http.MethodGet, path: "/reports/cat-workload", status: http.StatusOK, golden: true},
+		},
+	},
+	{
+		name: "report errors",
+		steps: []step{
+			{name: "unsupported format", method: http.MethodGet, path: "/cats?format=pdf", status: http.StatusNotAcceptable, json: map[string]any{"error": "unsupported format, expected csv or xlsx"}},
+			{name: "unknown column", method: http.MethodGet, path: "/missions?format=csv&columns=id,color", status: http.StatusBadRequest, json: map[string]any{"error": "unknown column: color"}},
+			{name: "report json", method: http.MethodGet, path: "/reports/cat-workload?format=json", status: http.StatusBadRequest},
+		},
+	},
+}
+
+// withPaidCats adds cat 7, better paid than cat 1 and without missions.
+func withPaidCats(f *fakeService) {
+	withAssignedMissions(f)
+	f.cats[7] = models.Cat{ID: 7, Name: "Felix", Breed: "Abyssinian", YearsOfExperience: 5, Salary: 300, SalaryCurrency: "USD"}
+	f.nextID = 8
+}
+
+var analyticsScenarios = []scenario{
+	{
+		name:  "analytics",
+		setup: withPaidCats,
+		steps: []step{
+			{name: "completion rate", method: http.MethodGet, path: "/analytics/completion-rate?window=month&periods=3", status: http.StatusOK, golden: true},
+			{name: "completion rate default", method: http.MethodGet, path: "/analytics/completion-rate", status: http.StatusOK, json: map[string]any{"window": "week", "periods.#": 12, "periods.11.rate": 0.5}},
+			{name: "completion times", method: http.MethodGet, path: "/analytics/completion-times", status: http.StatusOK, golden: true},
+			{name: "utilization", method: http.MethodGet, path: "/analytics/utilization", status: http.StatusOK, golden: true},
+			{name: "countries", method: http.MethodGet, path: "/analytics/countries", status: http.StatusOK, golden: true},
+			{name: "salary performance", method: http.MethodGet, path: "/analytics/salary-performance", status: http.StatusOK, golden: true},
+		},
+	},
+	{
+		name: "analytics errors",
+		steps: []step{
+			{name: "window", method: http.MethodGet, path: "/analytics/completion-rate?window=year", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "window must be one of day, week, month"}},
+			{name: "periods", method: http.MethodGet, path: "/analytics/completion-rate?periods=0", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "periods must be between 1 and 366"}},
+			{name: "invalid periods", method: http.MethodGet, path: "/analytics/completion-rate?periods=many", status: http.StatusBadRequest, json: map[string]any{"error": "invalid periods"}},
+			{name: "no cats", method: http.MethodGet, path: "/analytics/salary-performance", status: http.StatusOK, json: map[string]any{"cats": 0, "missions_correlation": nil}},
+		},
+	},
+}
+
+var vacationTom = map[string]any{"kind": "vacation", "starts_at": "2025-02-28T00:00:00Z", "ends_at": "2025-03-05T00:00:00Z"}
+
+var availabilityScenarios = []scenario{
+	{
+		name:  "availability",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/cats/1/availability", status: http.StatusOK, json: map[string]any{"periods.#": 0}},
+			{name: "create", method: http.MethodPost, path: "/cats/1/availability", body: vacationTom, status: http.StatusCreated, golden: true},
+			{name: "create training", method: http.MethodPost, path: "/cats/1/availability", body: map[string]any{"kind": "training", "starts_at": "2025-03-10T00:00:00Z", "ends_at": "2025-03-12T00:00:00Z"}, status: http.StatusCreated, json: map[string]any{"id": 6}},
+			{name: "list", method: http.MethodGet, path: "/cats/1/availability", status: http.StatusOK, golden: true},
+			{name: "unavailable cats", method: http.MethodGet, path: "/cats?available_at=2025-03-02T00:00:00Z", status: http.StatusOK, json: map[string]any{"cats.#": 0}},
+			{name: "available cats", method: http.MethodGet, path: "/cats?available_at=2025-03-06T00:00:00Z", status: http.StatusOK, json: map[string]any{"cats.#": 1, "cats.0.id": 1}},
+			{name: "assign unavailable", method: http.MethodPatch, path: "/missions/2/assign", body: map[string]any{"assignee": 1}, status: http.StatusConflict, golden: true},
+			{name: "assign override", method: http.MethodPatch, path: "/missions/2/assign", body: map[string]any{"assignee": 1, "override": true}, status: http.StatusOK, golden: true},
+			{name: "update", method: http.MethodPut, path: "/cats/1/availability/6", body: map[string]any{"kind": "sick", "starts_at": "2025-03-10T00:00:00Z", "ends_at": "2025-03-11T00:00:00Z"}, status: http.StatusOK, json: map[string]any{"kind": "sick", "ends_at": "2025-03-11T00:00:00Z"}},
+			{name: "delete", method: http.MethodDelete, path: "/cats/1/availability/5", status: http.StatusNoContent},
+			{name: "list after delete", method: http.MethodGet, path: "/cats/1/availability", status: http.StatusOK, json: map[string]any{"periods.#": 1, "periods.0.id": 6}},
+			{name: "assign until training", method: http.MethodPatch, path: "/missions/2/assign", body: map[string]any{"assignee": 1, "until": "2025-03-10T12:00:00Z"}, status: http.StatusConflict, json: map[string]any{"error": "cat is unavailable: sick from 2025-03-10T00:00:00Z to 2025-03-11T00:00:00Z"}},
+			{name: "assign until past", method: http.MethodPatch, path: "/missions/2/assign", body: map[string]any{"assignee": 1, "until": "2025-02-01T00:00:00Z"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "until must be in the future"}},
+		},
+	},
+	{
+		name:  "availability errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "list invalid id", method: http.MethodGet, path: "/cats/tom/availability", status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "list missing cat", method: http.MethodGet, path: "/cats/9/availability", status: http.StatusNotFound},
+			{name: "create malformed", method: http.MethodPost, path: "/cats/1/availability", body: `{"kind":`, status: http.StatusBadRequest},
+			{name: "create unknown kind", method: http.MethodPost, path: "/cats/1/availability", body: map[string]any{"kind": "nap", "starts_at": "2025-03-10T00:00:00Z", "ends_at": "2025-03-11T00:00:00Z"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "kind must be one of vacation, sick, training"}},
+			{name: "create without dates", method: http.MethodPost, path: "/cats/1/availability", body: map[string]any{"kind": "sick"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "starts_at and ends_at are required"}},
+			{name: "create ending first", method: http.MethodPost, path: "/cats/1/availability", body: map[string]any{"kind": "sick", "starts_at": "2025-03-10T00:00:00Z", "ends_at": "2025-03-09T00:00:00Z"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "ends_at must be after starts_at"}},
+			{name: "create missing cat", method: http.MethodPost, path: "/cats/9/availability", body: vacationTom, status: http.StatusNotFound},
+			{name: "create", method: http.MethodPost, path: "/cats/1/availability", body: vacationTom, status: http.StatusCreated},
+			{name: "update invalid period id", method: http.MethodPut, path: "/cats/1/availability/first", body: vacationTom, status: http.StatusBadRequest, json: map[string]any{"error": "invalid period id"}},
+			{name: "update of another cat", method: http.MethodPut, path: "/cats/9/availability/5", body: vacationTom, status: http.StatusNotFound},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/cats/tom/availability/5", status: http.StatusBadRequest},
+			{name: "delete missing", method: http.MethodDelete, path: "/cats/1/availability/9", status: http.StatusNotFound},
+			{name: "invalid available_at", method: http.MethodGet, path: "/cats?available_at=tomorrow", status: http.StatusBadRequest, json: map[string]any{"error": "invalid available_at, expected an RFC 3339 time"}},
+		},
+	},
+}
+
+// withSkilledCats creates cat 1 and mission 2 with targets 3 and 4, the more
+// experienced cat 5 and the skills 6 and 7.
+func withSkilledCats(f *fakeService) {
+	withCatAndMission(f)
+	f.cats[5] = models.Cat{ID: 5, Name: "Felix", Breed: "Siamese", YearsOfExperience: 7, Salary: 200, SalaryCurrency: "USD"}
+	f.skills[6] = models.Skill{ID: 6, Name: "lockpicking"}
+	f.skills[7] = models.Skill{ID: 7, Name: "climbing"}
+	f.nextID = 8
+}
+
+var skillScenarios = []scenario{
+	{
+		name:  "skills",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/skills", status: http.StatusOK, json: map[string]any{"skills.#": 0}},
+			{name: "create", method: http.MethodPost, path: "/skills", body: map[string]any{"name": "lockpicking"}, status: http.StatusCreated, golden: true},
+			{name: "create climbing", method: http.MethodPost, path: "/skills", body: map[string]any{"name": "climbing"}, status: http.StatusCreated, json: map[string]any{"id": 6}},
+			{name: "create duplicate", method: http.MethodPost, path: "/skills", body: map[string]any{"name": "climbing"}, status: http.StatusConflict, json: map[string]any{"error": "skill already exists"}},
+			{name: "list", method: http.MethodGet, path: "/skills", status: http.StatusOK, golden: true},
+			{name: "set cat skill", method: http.MethodPut, path: "/cats/1/skills/5", body: map[string]any{"level": 4, "certified_until": "2025-06-01T00:00:00Z"}, status: http.StatusOK, golden: true},
+			{name: "set expired cat skill", method: http.MethodPut, path: "/cats/1/skills/6", body: map[string]any{"level": 2, "certified_until": "2025-01-01T00:00:00Z"}, status: http.StatusOK, json: map[string]any{"certified": false}},
+			{name: "list cat skills", method: http.MethodGet, path: "/cats/1/skills", status: http.StatusOK, golden: true},
+			{name: "set target skill", method: http.MethodPut, path: "/missions/2/targets/3/skills/5", body: map[string]any{"min_level": 3, "certified": true}, status: http.StatusOK, golden: true},
+			{name: "list target skills", method: http.MethodGet, path: "/missions/2/targets/3/skills", status: http.StatusOK, json: map[string]any{"skills.#": 1, "skills.0.name": "lockpicking"}},
+			{name: "delete cat skill", method: http.MethodDelete, path: "/cats/1/skills/6", status: http.StatusNoContent},
+			{name: "delete target skill", method: http.MethodDelete, path: "/missions/2/targets/3/skills/5", status: http.StatusNoContent},
+			{name: "delete", method: http.MethodDelete, path: "/skills/5", status: http.StatusNoContent},
+			{name: "list cat skills after delete", method: http.MethodGet, path: "/cats/1/skills", status: http.StatusOK, json: map[string]any{"skills.#": 0}},
+		},
+	},
+	{
+		name:  "candidates",
+		setup: withSkilledCats,
+		steps: []step{
+			{name: "without skills", method: http.MethodGet, path: "/missions/2/candidates", status: http.StatusOK, json: map[string]any{"candidates.#": 2, "candidates.0.cat.id": 5, "candidates.0.skill_match": 1}},
+			{name: "require lockpicking", method: http.MethodPut, path: "/missions/2/targets/3/skills/6", body: map[string]any{"min_level": 3, "certified": true}, status: http.StatusOK},
+			{name: "require climbing", method: http.MethodPut, path: "/missions/2/targets/4/skills/7", body: map[string]any{"min_level": 2}, status: http.StatusOK},
+			{name: "certified lockpicking", method: http.MethodPut, path: "/cats/1/skills/6", body: map[string]any{"level": 4, "certified_until": "2025-06-01T00:00:00Z"}, status: http.StatusOK},
+			{name: "expired lockpicking", method: http.MethodPut, path: "/cats/5/skills/6", body: map[string]any{"level": 5, "certified_until": "2025-01-01T00:00:00Z"}, status: http.StatusOK},
+			{name: "climbing", method: http.MethodPut, path: "/cats/5/skills/7", body: map[string]any{"level": 1}, status: http.StatusOK},
+			{name: "ranked", method: http.MethodGet, path: "/missions/2/candidates", status: http.StatusOK, golden: true},
+			{name: "vacation", method: http.MethodPost, path: "/cats/1/availability", body: vacationTom, status: http.StatusCreated},
+			{name: "unavailable last", method: http.MethodGet, path: "/missions/2/candidates", status: http.StatusOK, json: map[string]any{"candidates.0.cat.id": 5, "candidates.1.available": false}},
+			{name: "assign", method: http.MethodPatch, path: "/missions/2/assign", body: map[string]any{"assignee": 5}, status: http.StatusOK},
+			{name: "assigned excluded", method: http.MethodGet, path: "/missions/2/candidates", status: http.StatusOK, json: map[string]any{"candidates.#": 1, "candidates.0.cat.id": 1}},
+		},
+	},
+	{
+		name:  "skills errors",
+		setup: withSkilledCats,
+		steps: []step{
+			{name: "create without name", method: http.MethodPost, path: "/skills", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: name"}},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/skills/lockpicking", status: http.StatusBadRequest},
+			{name: "delete missing", method: http.MethodDelete, path: "/skills/9", status: http.StatusNotFound},
+			{name: "cat skills invalid id", method: http.MethodGet, path: "/cats/tom/skills", status: http.StatusBadRequest},
+			{name: "cat skills missing cat", method: http.MethodGet, path: "/cats/9/skills", status: http.StatusNotFound},
+			{name: "set invalid skill id", method: http.MethodPut, path: "/cats/1/skills/climbing", body: map[string]any{"level": 1}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid skill id"}},
+			{name: "set level out of range", method: http.MethodPut, path: "/cats/1/skills/6", body: map[string]any{"level": 6}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "level must be between 1 and 5"}},
+			{name: "set missing skill", method: http.MethodPut, path: "/cats/1/skills/9", body: map[string]any{"level": 1}, status: http.StatusNotFound, json: map[string]any{"error": "skill not found"}},
+			{name: "delete missing cat skill", method: http.MethodDelete, path: "/cats/1/skills/6", status: http.StatusNotFound},
+			{name: "target skills missing target", method: http.MethodGet, path: "/missions/2/targets/9/skills", status: http.StatusNotFound},
+			{name: "set min_level out of range", method: http.MethodPut, path: "/missions/2/targets/3/skills/6", body: map[string]any{"min_level": 0}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "min_level must be between 1 and 5"}},
+			{name: "delete missing target skill", method: http.MethodDelete, path: "/missions/2/targets/3/skills/6", status: http.StatusNotFound},
+			{name: "candidates invalid id", method: http.MethodGet, path: "/missions/first/candidates", status: http.StatusBadRequest},
+			{name: "candidates missing mission", method: http.MethodGet, path: "/missions/9/candidates", status: http.StatusNotFound},
+		},
+	},
+}
+
+// withIdleCats creates the skilled cats and the unassigned missions 2 and 8.
+func withIdleCats(f *fakeService) {
+	withSkilledCats(f)
+	f.missions[8] = models.Mission{ID: 8, Targets: []models.Target{{ID: 9, Name: "Hans", Country: "DE", Notes: "Notes of Hans"}}}
+	f.nextID = 10
+}
+
+var assignScenarios = []scenario{
+	{
+		name:  "auto-assign",
+		setup: withIdleCats,
+		steps: []step{
+			{name: "dry run", method: http.MethodPost, path: "/missions/auto-assign", body: map[string]any{"dry_run": true}, status: http.StatusOK, golden: true},
+			{name: "still unassigned", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"assignee": 0}},
+			{name: "budget", method: http.MethodPost, path: "/missions/auto-assign", body: map[string]any{"dry_run": true, "salary_budget": 15000}, status: http.StatusOK, json: map[string]any{"assignments.#": 1, "unassigned.0": 8, "total_salary": 10000}},
+			{name: "experience", method: http.MethodPost, path: "/missions/2/auto-assign", body: map[string]any{"dry_run": true, "min_years_of_experience": 5}, status: http.StatusOK, json: map[string]any{"assignments.0.cat.id": 5}},
+			{name: "assign", method: http.MethodPost, path: "/missions/2/auto-assign", body: map[string]any{"strategy": "hungarian"}, status: http.StatusOK, golden: true},
+			{name: "assigned", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"assignee": 1}},
+			{name: "assign again", method: http.MethodPost, path: "/missions/2/auto-assign", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "mission is already assigned"}},
+			{name: "batch", method: http.MethodPost, path: "/missions/auto-assign", status: http.StatusOK, json: map[string]any{"strategy": "greedy", "assignments.#": 1, "assignments.0.mission_id": 8, "assignments.0.cat.id": 5}},
+			{name: "nothing left", method: http.MethodPost, path: "/missions/auto-assign", status: http.StatusOK, json: map[string]any{"assignments.#": 0, "unassigned.#": 0}},
+		},
+	},
+	{
+		name:  "auto-assign errors",
+		setup: withIdleCats,
+		steps: []step{
+			{name: "unknown strategy", method: http.MethodPost, path: "/missions/auto-assign", body: map[string]any{"strategy": "random"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "unknown strategy, expected greedy or hungarian"}},
+			{name: "unknown budget currency", method: http.MethodPost, path: "/missions/auto-assign", body: map[string]any{"salary_budget": 100, "salary_budget_currency": "euro"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "salary_budget_currency must be an ISO 4217 code"}},
+			{name: "budget currency without rate", method: http.MethodPost, path: "/missions/auto-assign", body: map[string]any{"salary_budget": 100, "salary_budget_currency": "GBP"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "no exchange rate for GBP"}},
+			{name: "invalid body", method: http.MethodPost, path: "/missions/auto-assign", body: map[string]any{"dry_run": "yes"}, status: http.StatusBadRequest},
+			{name: "invalid id", method: http.MethodPost, path: "/missions/first/auto-assign", status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "missing mission", method: http.MethodPost, path: "/missions/99/auto-assign", status: http.StatusNotFound},
+		},
+	},
+}
+
+var costScenarios = []scenario{
+	{
+		name:  "costs",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "no costs", method: http.MethodGet, path: "/missions/2/costs", status: http.StatusOK, json: map[string]any{"currency": "USD", "budget": nil, "total": 0}},
+			{name: "set budget", method: http.MethodPut, path: "/missions/2/budget", body: map[string]any{"amount": 50000, "currency": "eur"}, status: http.StatusOK, golden: true},
+			{name: "add travel", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{
+				"category":    "travel",
+				"amount":      32000,
+				"currency":    "EUR",
+				"description": "Flight to Kyiv",
+				"receipt":     map[string]any{"number": "A-17", "vendor": "Airline", "url": "https://example.com/a-17.pdf"},
+				"incurred_at": "2025-03-02T08:00:00Z",
+			}, status: http.StatusCreated, golden: true},
+			{name: "add informants", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "informants", "amount": 25000, "currency": "EUR"}, status: http.StatusCreated, json: map[string]any{"warnings.0": "costs exceed the budget of the mission by 70.00 EUR", "receipt": nil}},
+			{name: "without exchange rate", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "equipment", "amount": 100, "currency": "USD"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "no exchange rate for EUR"}},
+			{name: "list", method: http.MethodGet, path: "/missions/2/expenses", status: http.StatusOK, json: map[string]any{"expenses.#": 2, "expenses.0.receipt.number": "A-17", "expenses.1.category": "informants"}},
+			{name: "breakdown", method: http.MethodGet, path: "/missions/2/costs", status: http.StatusOK, golden: true},
+			{name: "delete expense", method: http.MethodDelete, path: "/missions/2/expenses/6", status: http.StatusNoContent},
+			{name: "within budget", method: http.MethodGet, path: "/missions/2/costs", status: http.StatusOK, json: map[string]any{"remaining": 18000, "over_budget": false}},
+			{name: "delete budget", method: http.MethodDelete, path: "/missions/2/budget", status: http.StatusNoContent},
+			{name: "without budget", method: http.MethodGet, path: "/missions/2/costs", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "no exchange rate for EUR"}},
+		},
+	},
+	{
+		name: "costs in several currencies",
+		setup: func(f *fakeService) {
+			withCatAndMission(f)
+			f.rates["EUR"] = models.ExchangeRate{Currency: "EUR", Rate: 1.1, UpdatedAt: fakeTime}
+			f.budgets[2] = models.MissionBudget{MissionID: 2, Amount: 2000, Currency: "USD"}
+		},
+		steps: []step{
+			{name: "add euros", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "travel", "amount": 1000, "currency": "eur"}, status: http.StatusCreated, json: map[string]any{"amount": 1000, "currency": "EUR"}},
+			{name: "converted", method: http.MethodGet, path: "/missions/2/costs", status: http.StatusOK, json: map[string]any{"currency": "USD", "expenses.travel": 1100, "remaining": 900}},
+			{name: "over budget in euros", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "equipment", "amount": 1000, "currency": "EUR"}, status: http.StatusCreated, json: map[string]any{"warnings.0": "costs exceed the budget of the mission by 2.00 USD"}},
+			{name: "without exchange rate", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "equipment", "amount": 1000, "currency": "JPY"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "no exchange rate for JPY"}},
+			{name: "unknown currency", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "equipment", "amount": 1000, "currency": "EURO"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "currency must be an ISO 4217 code"}},
+		},
+	},
+	{
+		name: "costs over budget blocked",
+		setup: func(f *fakeService) {
+			withCatAndMission(f)
+			f.blockOverBudget = true
+			f.budgets[2] = models.MissionBudget{MissionID: 2, Amount: 1000, Currency: "USD"}
+		},
+		steps: []step{
+			{name: "over budget", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "equipment", "amount": 1500, "currency": "USD"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "costs exceed the budget of the mission by 5.00 USD"}},
+			{name: "not recorded", method: http.MethodGet, path: "/missions/2/expenses", status: http.StatusOK, json: map[string]any{"expenses.#": 0}},
+		},
+	},
+	{
+		name:  "costs errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "budget invalid id", method: http.MethodPut, path: "/missions/first/budget", body: map[string]any{"amount": 1, "currency": "USD"}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "budget without currency", method: http.MethodPut, path: "/missions/2/budget", body: map[string]any{"amount": 1}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: currency"}},
+			{name: "budget not positive", method: http.MethodPut, path: "/missions/2/budget", body: map[string]any{"amount": -5, "currency": "USD"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "amount must be positive"}},
+			{name: "budget missing mission", method: http.MethodPut, path: "/missions/9/budget", body: map[string]any{"amount": 1, "currency": "USD"}, status: http.StatusNotFound},
+			{name: "delete missing budget", method: http.MethodDelete, path: "/missions/2/budget", status: http.StatusNotFound},
+			{name: "expense unknown category", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "snacks", "amount": 1, "currency": "USD"}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "category must be one of travel, equipment, informants"}},
+			{name: "expense without amount", method: http.MethodPost, path: "/missions/2/expenses", body: map[string]any{"category": "travel", "currency": "USD"}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: amount"}},
+			{name: "expenses missing mission", method: http.MethodGet, path: "/missions/9/expenses", status: http.StatusNotFound},
+			{name: "delete invalid expense id", method: http.MethodDelete, path: "/missions/2/expenses/rope", status: http.StatusBadRequest, json: map[string]any{"error": "invalid expense id"}},
+			{name: "delete missing expense", method: http.MethodDelete, path: "/missions/2/expenses/9", status: http.StatusNotFound},
+			{name: "costs missing mission", method: http.MethodGet, path: "/missions/9/costs", status: http.StatusNotFound},
+		},
+	},
+}
+
+var rateScenarios = []scenario{
+	{
+		name: "exchange rates",
+		steps: []step{
+			{name: "list empty", method: http.MethodGet, path: "/exchange-rates", status: http.StatusOK, json: map[string]any{"reporting_currency": "USD", "rates.#": 0}},
+			{name: "set", method: http.MethodPut, path: "/exchange-rates/eur", body: map[string]any{"rate": 1.08}, status: http.StatusOK, golden: true},
+			{name: "import", method: http.MethodPost, path: "/exchange-rates/import", header: csvHeader, body: "currency,rate\nGBP,1.27\nEUR,1.09\n", status: http.StatusOK, json: map[string]any{"rates.#": 2, "rates.0.currency": "EUR", "rates.0.rate": 1.09}},
+			{name: "list", method: http.MethodGet, path: "/exchange-rates", status: http.StatusOK, golden: true},
+			{name: "delete", method: http.MethodDelete, path: "/exchange-rates/GBP", status: http.StatusNoContent},
+			{name: "delete deleted", method: http.MethodDelete, path: "/exchange-rates/GBP", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "exchange rates errors",
+		steps: []step{
+			{name: "reporting currency", method: http.MethodPut, path: "/exchange-rates/USD", body: map[string]any{"rate": 2}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "USD is the reporting currency"}},
+			{name: "unknown currency", method: http.MethodPut, path: "/exchange-rates/XYZ", body: map[string]any{"rate": 2}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "currency must be an ISO 4217 code"}},
+			{name: "negative rate", method: http.MethodPut, path: "/exchange-rates/EUR", body: map[string]any{"rate": -1}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "rate must be a positive number"}},
+			{name: "missing rate", method: http.MethodPut, path: "/exchange-rates/EUR", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "missing field: rate"}},
+			{name: "import invalid rate", method: http.MethodPost, path: "/exchange-rates/import", header: csvHeader, body: "currency,rate\nEUR,many\n", status: http.StatusBadRequest, json: map[string]any{"error": "line 2: rate must be a positive number"}},
+			{name: "import reporting currency", method: http.MethodPost, path: "/exchange-rates/import", header: csvHeader, body: "currency,rate\nEUR,1.1\nUSD,1\n", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "USD is the reporting currency"}},
+			{name: "import unknown currency", method: http.MethodPost, path: "/exchange-rates/import", header: csvHeader, body: "currency,rate\nXYZ,2\n", status: http.StatusBadRequest, json: map[string]any{"error": "line 2: unknown ISO 4217 currency: XYZ"}},
+			{name: "import nothing set", method: http.MethodGet, path: "/exchange-rates", status: http.StatusOK, json: map[string]any{"rates.#": 0}},
+			{name: "delete unknown currency", method: http.MethodDelete, path: "/exchange-rates/XYZ", status: http.StatusNotFound},
+		},
+	},
+}
+
+var locationScenarios = []scenario{
+	{
+		name: "locations",
+		steps: []step{
+			{name: "create mission", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{
+				{"name": "Ivan", "country": "UA", "notes": "Watch the square", "latitude": 50.4501, "longitude": 30.5234, "address": "Khreshchatyk 1, Kyiv"},
+				{"name": "Olga", "country": "UA", "notes": "Watch the station"},
+			}}, status: http.StatusCreated, json: map[string]any{"targets.0.latitude": 50.4501, "targets.0.address": "Khreshchatyk 1, Kyiv"}},
+			{name: "create other mission", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{
+				{"name": "Anna", "country": "UA", "notes": "Watch the opera", "latitude": 49.8397, "longitude": 24.0297},
+			}}, status: http.StatusCreated},
+			{name: "near", method: http.MethodGet, path: "/targets/near?lat=50.4547&lng=30.5238&radius_km=5", status: http.StatusOK, golden: true},
+			{name: "update location", method: http.MethodPut, path: "/missions/1/targets/3/location", body: map[string]any{"latitude": 50.4410, "longitude": 30.4880, "address": "Vokzalna 1, Kyiv"}, status: http.StatusOK, golden: true},
+			{name: "near after update", method: http.MethodGet, path: "/targets/near?lat=50.4547&lng=30.5238&radius_km=500", status: http.StatusOK, json: map[string]any{"results.#": 3, "results.0.target.id": 2, "results.1.target.id": 3, "results.2.mission_id": 4}},
+			{name: "near with limit", method: http.MethodGet, path: "/targets/near?lat=50.4547&lng=30.5238&radius_km=500&limit=1", status: http.StatusOK, json: map[string]any{"results.#": 1}},
+			{name: "geojson", method: http.MethodGet, path: "/missions/1/targets.geojson", status: http.StatusOK, golden: true},
+			{name: "remove location", method: http.MethodPut, path: "/missions/1/targets/2/location", body: map[string]any{}, status: http.StatusOK, golden: true},
+			{name: "geojson after remove", method: http.MethodGet, path: "/missions/1/targets.geojson", status: http.StatusOK, json: map[string]any{"features.#": 1, "features.0.id": 3}},
+		},
+	},
+	{
+		name: "locations errors",
+		steps: []step{
+			{name: "create mission", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{{"name": "Ivan", "country": "UA", "notes": "Watch the square"}}}, status: http.StatusCreated},
+			{name: "latitude out of range", method: http.MethodPut, path: "/missions/1/targets/2/location", body: map[string]any{"latitude": 91, "longitude": 30}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "latitude must be between -90 and 90"}},
+			{name: "longitude out of range", method: http.MethodPut, path: "/missions/1/targets/2/location", body: map[string]any{"latitude": 50, "longitude": 181}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "longitude must be between -180 and 180"}},
+			{name: "latitude alone", method: http.MethodPut, path: "/missions/1/targets/2/location", body: map[string]any{"latitude": 50}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "latitude and longitude must be set together"}},
+			{name: "invalid target id", method: http.MethodPut, path: "/missions/1/targets/ivan/location", body: map[string]any{}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "missing target", method: http.MethodPut, path: "/missions/1/targets/9/location", body: map[string]any{}, status: http.StatusNotFound},
+			{name: "complete target", method: http.MethodPatch, path: "/missions/1/targets/2/complete", status: http.StatusOK},
+			{name: "completed target", method: http.MethodPut, path: "/missions/1/targets/2/location", body: map[string]any{"latitude": 50, "longitude": 30}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "Can't change location of a completed target"}},
+			{name: "near missing lat", method: http.MethodGet, path: "/targets/near?lng=30&radius_km=5", status: http.StatusBadRequest, json: map[string]any{"error": "invalid lat"}},
+			{name: "near invalid radius", method: http.MethodGet, path: "/targets/near?lat=50&lng=30&radius_km=far", status: http.StatusBadRequest, json: map[string]any{"error": "invalid radius_km"}},
+			{name: "near negative radius", method: http.MethodGet, path: "/targets/near?lat=50&lng=30&radius_km=-1", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "radius_km must be positive and at most 20016"}},
+			{name: "near latitude out of range", method: http.MethodGet, path: "/targets/near?lat=95&lng=30&radius_km=5", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "latitude must be between -90 and 90"}},
+			{name: "near invalid limit", method: http.MethodGet, path: "/targets/near?lat=50&lng=30&radius_km=5&limit=500", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "limit must be between 1 and 100"}},
+			{name: "geojson missing mission", method: http.MethodGet, path: "/missions/9/targets.geojson", status: http.StatusNotFound},
+			{name: "geojson invalid id", method: http.MethodGet, path: "/missions/one/targets.geojson", status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+		},
+	},
+}
+
+var subjectScenarios = []scenario{
+	{
+		name: "subjects",
+		steps: []step{
+			{name: "create mission", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{
+				{"name": "Ivan Petrenko", "country": "UA", "notes": "Watch the square"},
+				{"name": "Olga", "country": "PL", "notes": "Watch the station"},
+			}}, status: http.StatusCreated, json: map[string]any{"targets.0.subject_id": 1, "targets.1.subject_id": 2}},
+			{name: "create mission with similar target", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{
+				{"name": "Ivan Petrenco", "country": "UA", "notes": "Watch the bridge"},
+			}}, status: http.StatusCreated, golden: true},
+			{name: "add target of subject", method: http.MethodPost, path: "/missions/4/targets", body: map[string]any{"name": "I. Petrenko", "country": "UA", "notes": "Watch the port", "subject_id": 1}, status: http.StatusCreated, json: map[string]any{"targets.1.id": 6, "targets.1.subject_id": 1}},
+			{name: "update notes", method: http.MethodPatch, path: "/missions/1/targets/2/notes", body: map[string]any{"notes": "Left the square"}, status: http.StatusOK},
+			{name: "get subject", method: http.MethodGet, path: "/subjects/1", status: http.StatusOK, golden: true},
+			{name: "merge", method: http.MethodPost, path: "/subjects/1/merge", body: map[string]any{"subjects": []int{3}}, status: http.StatusOK, golden: true},
+			{name: "merged subject", method: http.MethodGet, path: "/subjects/3", status: http.StatusNotFound},
+			{name: "other subject", method: http.MethodGet, path: "/subjects/2", status: http.StatusOK, json: map[string]any{"missions.#": 1, "missions.0.targets.#": 1, "notes_history.#": 0}},
+		},
+	},
+	{
+		name: "subjects errors",
+		steps: []step{
+			{name: "create mission", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{{"name": "Ivan", "country": "UA", "notes": "Watch the square"}}}, status: http.StatusCreated},
+			{name: "invalid id", method: http.MethodGet, path: "/subjects/ivan", status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "missing subject", method: http.MethodGet, path: "/subjects/9", status: http.StatusNotFound},
+			{name: "merge invalid id", method: http.MethodPost, path: "/subjects/ivan/merge", body: map[string]any{"subjects": []int{1}}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "merge into missing", method: http.MethodPost, path: "/subjects/9/merge", body: map[string]any{"subjects": []int{1}}, status: http.StatusNotFound},
+			{name: "merge into itself", method: http.MethodPost, path: "/subjects/1/merge", body: map[string]any{"subjects": []int{1}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "can't merge a subject into itself"}},
+			{name: "merge listed twice", method: http.MethodPost, path: "/subjects/1/merge", body: map[string]any{"subjects": []int{9, 9}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "subject 9 is listed twice"}},
+			{name: "merge missing", method: http.MethodPost, path: "/subjects/1/merge", body: map[string]any{"subjects": []int{9}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "subject 9 not found"}},
+			{name: "merge nothing", method: http.MethodPost, path: "/subjects/1/merge", body: map[string]any{"subjects": []int{}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "no subjects to merge"}},
+			{name: "create mission with missing subject", method: http.MethodPost, path: "/missions", body: map[string]any{"targets": []map[string]any{{"name": "Olga", "country": "UA", "notes": "Watch the station", "subject_id": 9}}}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "subject 9 not found"}},
+			{name: "add target with missing subject", method: http.MethodPost, path: "/missions/1/targets", body: map[string]any{"name": "Olga", "country": "UA", "notes": "Watch the station", "subject_id": 9}, status: http.StatusUnprocessableEntity, json: map[string]any{"error": "subject 9 not found"}},
+		},
+	},
+}
+
+// multipartBoundary is the boundary of the multipart bodies of the tests.
+const multipartBoundary = "attachment-boundary"
+
+var multipartHeader = map[string]string{"Content-Type": "multipart/form-data; boundary=" + multipartBoundary}
+
+// multipartFile returns a multipart body with the file in the field.
+func multipartFile(field, filename, content string) string {
+	return "--" + multipartBoundary + "\r\n" +
+		`Content-Disposition: form-data; name="` + field + `"; filename="` + filename + `"` + "\r\n" +
+		"Content-Type: application/octet-stream\r\n\r\n" +
+		content + "\r\n" +
+		"--" + multipartBoundary + "--\r\n"
+}
+
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+var attachmentScenarios = []scenario{
+	{
+		name:  "attachments",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "upload photo", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("file", "ivan.png", pngHeader+"photo"), status: http.StatusCreated, golden: true},
+			{name: "upload notes", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("file", `C:\\notes\\ivan.txt`, "Seen at the square"), status: http.StatusCreated, json: map[string]any{"id": 6, "filename": "ivan.txt", "content_type": "text/plain; charset=utf-8", "size": 18}},
+			{name: "list", method: http.MethodGet, path: "/missions/2/targets/3/attachments", status: http.StatusOK, golden: true},
+			{name: "list other target", method: http.MethodGet, path: "/missions/2/targets/4/attachments", status: http.StatusOK, json: map[string]any{"attachments.#": 0}},
+			{name: "download", method: http.MethodGet, path: "/missions/2/targets/3/attachments/6", status: http.StatusOK, respHeader: map[string]string{
+				"Content-Disposition":    `attachment; filename=ivan.txt`,
+				"X-Content-Type-Options": "nosniff",
+			}, golden: true},
+			{name: "delete", method: http.MethodDelete, path: "/missions/2/targets/3/attachments/6", status: http.StatusNoContent},
+			{name: "list after delete", method: http.MethodGet, path: "/missions/2/targets/3/attachments", status: http.StatusOK, json: map[string]any{"attachments.#": 1, "attachments.0.id": 5}},
+			{name: "complete target", method: http.MethodPatch, path: "/missions/2/targets/3/complete", status: http.StatusOK},
+			{name: "upload to completed target", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("file", "ivan.txt", "Left the square"), status: http.StatusUnprocessableEntity, json: map[string]any{"error": "Can't change attachments of a completed target"}},
+			{name: "delete of completed target", method: http.MethodDelete, path: "/missions/2/targets/3/attachments/5", status: http.StatusUnprocessableEntity, json: map[string]any{"error": "Can't change attachments of a completed target"}},
+			{name: "download of completed target", method: http.MethodGet, path: "/missions/2/targets/3/attachments/5", status: http.StatusOK},
+		},
+	},
+	{
+		name: "attachments of completed mission",
+		setup: func(f *fakeService) {
+			withCatAndMission(f)
+			m := f.missions[2]
+			m.Completed = true
+			f.missions[2] = m
+		},
+		steps: []step{
+			{name: "upload", method: http.MethodPost, path: "/missions/2/targets/4/attachments", header: multipartHeader, body: multipartFile("file", "olga.txt", "Seen at the station"), status: http.StatusUnprocessableEntity, json: map[string]any{"error": "Can't change target attachments of a completed mission"}},
+		},
+	},
+	{
+		name:  "attachments errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "upload invalid id", method: http.MethodPost, path: "/missions/2/targets/first/attachments", header: multipartHeader, body: multipartFile("file", "ivan.txt", "notes"), status: http.StatusBadRequest, json: map[string]any{"error": "invalid id"}},
+			{name: "upload without file", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("photo", "ivan.txt", "notes"), status: http.StatusBadRequest, json: map[string]any{"error": "missing file"}},
+			{name: "upload json", method: http.MethodPost, path: "/missions/2/targets/3/attachments", body: map[string]any{"file": "notes"}, status: http.StatusBadRequest, json: map[string]any{"error": "missing file"}},
+			{name: "upload to missing target", method: http.MethodPost, path: "/missions/2/targets/9/attachments", header: multipartHeader, body: multipartFile("file", "ivan.txt", "notes"), status: http.StatusNotFound},
+			{name: "upload empty", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("file", "ivan.txt", ""), status: http.StatusUnprocessableEntity, json: map[string]any{"error": "attachment is empty"}},
+			{name: "upload too large", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("file", "ivan.txt", strings.Repeat("a", fakeMaxAttachmentSize+1)), status: http.StatusRequestEntityTooLarge, golden: true},
+			{name: "upload html", method: http.MethodPost, path: "/missions/2/targets/3/attachments", header: multipartHeader, body: multipartFile("file", "ivan.html", "<html><body>Ivan</body></html>"), status: http.StatusUnsupportedMediaType, json: map[string]any{"error": "unsupported content type text/html; charset=utf-8"}},
+			{name: "list invalid id", method: http.MethodGet, path: "/missions/2/targets/first/attachments", status: http.StatusBadRequest},
+			{name: "list missing target", method: http.MethodGet, path: "/missions/2/targets/9/attachments", status: http.StatusNotFound},
+			{name: "download invalid id", method: http.MethodGet, path: "/missions/2/targets/3/attachments/first", status: http.StatusBadRequest},
+			{name: "download invalid target id", method: http.MethodGet, path: "/missions/2/targets/first/attachments/5", status: http.StatusBadRequest},
+			{name: "download missing", method: http.MethodGet, path: "/missions/2/targets/3/attachments/9", status: http.StatusNotFound},
+			{name: "delete invalid id", method: http.MethodDelete, path: "/missions/2/targets/3/attachments/first", status: http.StatusBadRequest},
+			{name: "delete invalid target id", method: http.MethodDelete, path: "/missions/2/targets/first/attachments/5", status: http.StatusBadRequest},
+			{name: "delete missing", method: http.MethodDelete, path: "/missions/2/targets/3/attachments/9", status: http.StatusNotFound},
+		},
+	},
+}
+
+const ivanMarkdownNotes = "## Ivan\n\nSeen with @cat:1 near @target:4, not @cat:9.\n\n- [x] photo\n- [ ] address\n\n<script>alert(1)</script>"
+
+var noteScenarios = []scenario{
+	{
+		name:  "notes",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "update", method: http.MethodPatch, path: "/missions/2/targets/3/notes?render=html", body: map[string]any{"notes": ivanMarkdownNotes}, status: http.StatusOK, golden: true},
+			{name: "mission", method: http.MethodGet, path: "/missions/2?render=html", status: http.StatusOK, json: map[string]any{
+				"targets.1.notes_html": "<p>Notes of Olga</p>\n",
+			}},
+			{name: "missions", method: http.MethodGet, path: "/missions?render=html", status: http.StatusOK, json: map[string]any{
+				"0.targets.1.notes_html": "<p>Notes of Olga</p>\n",
+			}},
+			{name: "mission without render", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{
+				"targets.0.notes": ivanMarkdownNotes,
+			}},
+			{name: "long notes", method: http.MethodPatch, path: "/missions/2/targets/4/notes", body: map[string]any{"notes": strings.Repeat("Seen at the square. ", 50)}, status: http.StatusOK},
+		},
+	},
+	{
+		name:  "notes errors",
+		setup: withCatAndMission,
+		steps: []step{
+			{name: "invalid render", method: http.MethodGet, path: "/missions/2?render=pdf", status: http.StatusBadRequest, json: map[string]any{"error": "invalid render, expected html"}},
+			{name: "invalid render of missions", method: http.MethodGet, path: "/missions?render=pdf", status: http.StatusBadRequest, json: map[string]any{"error": "invalid render, expected html"}},
+			{name: "invalid render of update", method: http.MethodPatch, path: "/missions/2/targets/3/notes?render=pdf", body: map[string]any{"notes": "new notes"}, status: http.StatusBadRequest, json: map[string]any{"error": "invalid render, expected html"}},
+			{name: "not updated", method: http.MethodGet, path: "/missions/2", status: http.StatusOK, json: map[string]any{"targets.0.notes": "Notes of Ivan"}},
+		},
+	},
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// SkillService controls the skills of the cats and the targets.
+type SkillService interface {
+	GetAllSkills(ctx context.Context) ([]models.Skill, error)
+	CreateSkill(ctx context.Context, req models.CreateSkillRequest) (models.Skill, error)
+	DeleteSkill(ctx context.Context, id int32) error
+	GetCatSkills(ctx context.Context, catID int32) ([]models.CatSkill, error)
+	SetCatSkill(ctx context.Context, catID, skillID int32, req models.CatSkillRequest) (models.CatSkill, error)
+	DeleteCatSkill(ctx context.Context, catID, skillID int32) error
+	GetTargetSkills(ctx context.Context, targetID int32) ([]models.TargetSkill, error)
+	SetTargetSkill(ctx context.Context, targetID, skillID int32, req models.TargetSkillRequest) (models.TargetSkill, error)
+	DeleteTargetSkill(ctx context.Context, targetID, skillID int32) error
+	GetMissionCandidates(ctx context.Context, missionID int32) ([]models.Candidate, error)
+}
+
+// WithSkillService enables the skill routes and the candidates of the missions.
+func WithSkillService(ss SkillService) Option {
+	return func(s *Server) {
+		s.skillService = ss
+	}
+}
+
+// registerSkillRoutes registers the skill routes.
+func (s *Server) registerSkillRoutes() {
+	skills := s.R.Group("/skills")
+	{
+		skills.Get("/", s.handleGetSkills)
+		skills.Post("/", s.handleCreateSkill)
+		skills.Delete("/:id", s.handleDeleteSkill)
+	}
+
+	catSkills := s.R.Group("/cats/:id/skills")
+	{
+		catSkills.Get("/", s.handleGetCatSkills)
+		catSkills.Put("/:skillId", s.handleSetCatSkill)
+		catSkills.Delete("/:skillId", s.handleDeleteCatSkill)
+	}
+
+	targetSkills := s.R.Group("/missions/:id/targets/:targetId/skills")
+	{
+		targetSkills.Get("/", s.handleGetTargetSkills)
+		targetSkills.Put("/:skillId", s.handleSetTargetSkill)
+		targetSkills.Delete("/:skillId", s.handleDeleteTargetSkill)
+	}
+
+	s.R.Get("/missions/:id/candidates", s.handleGetMissionCandidates)
+}
+
+func (s *Server) handleGetSkills(c fiber.Ctx) error {
+	res, err := s.skillService.GetAllSkills(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"skills": res})
+}
+
+func (s *Server) handleCreateSkill(c fiber.Ctx) error {
+	var r models.CreateSkillRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.skillService.CreateSkill(c.Context(), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleDeleteSkill(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	err = s.skillService.DeleteSkill(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleGetCatSkills(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.skillService.GetCatSkills(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"skills": res})
+}
+
+func (s *Server) handleSetCatSkill(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	skillId, err := strconv.Atoi(c.Params("skillId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid skill id"})
+	}
+
+	var r models.CatSkillRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.skillService.SetCatSkill(c.Context(), int32(id), int32(skillId), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteCatSkill(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	skillId, err := strconv.Atoi(c.Params("skillId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid skill id"})
+	}
+
+	err = s.skillService.DeleteCatSkill(c.Context(), int32(id), int32(skillId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleGetTargetSkills(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.skillService.GetTargetSkills(c.Context(), int32(targetId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"skills": res})
+}
+
+func (s *Server) handleSetTargetSkill(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	skillId, err := strconv.Atoi(c.Params("skillId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid skill id"})
+	}
+
+	var r models.TargetSkillRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.skillService.SetTargetSkill(c.Context(), int32(targetId), int32(skillId), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteTargetSkill(c fiber.Ctx) error {
+	targetId, err := strconv.Atoi(c.Params("targetId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	skillId, err := strconv.Atoi(c.Params("skillId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid skill id"})
+	}
+
+	err = s.skillService.DeleteTargetSkill(c.Context(), int32(targetId), int32(skillId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleGetMissionCandidates(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.skillService.GetMissionCandidates(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"candidates": res})
+}
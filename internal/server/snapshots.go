@@ -0,0 +1,59 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// SnapshotService controls the database snapshot service.
+type SnapshotService interface {
+	ExportSnapshot(ctx context.Context, w io.Writer) error
+	RestoreSnapshot(ctx context.Context, r io.Reader) (models.RestoreResult, error)
+}
+
+// WithSnapshotService enables the snapshot routes.
+func WithSnapshotService(ss SnapshotService) Option {
+	return func(s *Server) {
+		s.snapshotService = ss
+	}
+}
+
+// registerSnapshotRoutes registers the snapshot routes.
+func (s *Server) registerSnapshotRoutes() {
+	s.R.Get("/export", s.handleExportSnapshot)
+	s.R.Post("/import/snapshot", s.handleRestoreSnapshot)
+}
+
+// handleExportSnapshot streams the snapshot.
+//
+// The status is sent before the snapshot is read, so a failed export is only
+// logged and leaves an archive without its end record.
+func (s *Server) handleExportSnapshot(c fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="sca-snapshot.ndjson"`)
+
+	return c.Status(fiber.StatusOK).SendStreamWriter(func(w *bufio.Writer) {
+		// The request context ends with the handler, before the stream is written.
+		if err := s.snapshotService.ExportSnapshot(context.Background(), w); err != nil {
+			slog.Error("Failed to export snapshot", "err", err)
+		}
+		if err := w.Flush(); err != nil {
+			slog.Error("Failed to send snapshot", "err", err)
+		}
+	})
+}
+
+func (s *Server) handleRestoreSnapshot(c fiber.Ctx) error {
+	res, err := s.snapshotService.RestoreSnapshot(c.Context(), bytes.NewReader(c.Body()))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
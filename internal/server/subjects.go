@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// SubjectService controls the subjects of the targets.
+type SubjectService interface {
+	GetSubject(ctx context.Context, id int32) (models.SubjectDetails, error)
+	MergeSubjects(ctx context.Context, id int32, req models.MergeSubjectsRequest) (models.SubjectDetails, error)
+}
+
+// WithSubjectService enables the subject routes.
+func WithSubjectService(ss SubjectService) Option {
+	return func(s *Server) {
+		s.subjectService = ss
+	}
+}
+
+// registerSubjectRoutes registers the subject routes.
+func (s *Server) registerSubjectRoutes() {
+	s.R.Get("/subjects/:id", s.handleGetSubject)
+	s.R.Post("/subjects/:id/merge", s.handleMergeSubjects)
+}
+
+func (s *Server) handleGetSubject(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.subjectService.GetSubject(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// handleMergeSubjects merges the subjects of the body into the subject of the
+// path.
+func (s *Server) handleMergeSubjects(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.MergeSubjectsRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.subjectService.MergeSubjects(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// TemplateService controls the mission template service.
+type TemplateService interface {
+	GetAllMissionTemplates(ctx context.Context) ([]models.MissionTemplate, error)
+	CreateMissionTemplate(ctx context.Context, req models.CreateMissionTemplateRequest) (models.MissionTemplate, error)
+	GetMissionTemplate(ctx context.Context, id int32) (models.MissionTemplate, error)
+	UpdateMissionTemplate(ctx context.Context, id int32, req models.UpdateMissionTemplateRequest) (models.MissionTemplate, error)
+	DeleteMissionTemplate(ctx context.Context, id int32) error
+	CreateMissionFromTemplate(ctx context.Context, id int32, req models.CreateMissionFromTemplateRequest) (models.Mission, error)
+}
+
+// WithTemplateService enables the mission template routes.
+func WithTemplateService(ts TemplateService) Option {
+	return func(s *Server) {
+		s.templateService = ts
+	}
+}
+
+// registerTemplateRoutes registers the mission template routes.
+func (s *Server) registerTemplateRoutes() {
+	templates := s.R.Group("/mission-templates")
+	{
+		templates.Get("/", s.handleGetMissionTemplates)
+		templates.Post("/", s.handleCreateMissionTemplate)
+		templates.Get("/:id", s.handleGetSingleMissionTemplate)
+		templates.Patch("/:id", s.handleUpdateMissionTemplate)
+		templates.Delete("/:id", s.handleDeleteMissionTemplate)
+	}
+
+	s.R.Post("/missions/from-template/:id", s.handleCreateMissionFromTemplate)
+}
+
+func (s *Server) handleGetMissionTemplates(c fiber.Ctx) error {
+	res, err := s.templateService.GetAllMissionTemplates(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"templates": res})
+}
+
+func (s *Server) handleCreateMissionTemplate(c fiber.Ctx) error {
+	var r models.CreateMissionTemplateRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.templateService.CreateMissionTemplate(c.Context(), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleGetSingleMissionTemplate(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.templateService.GetMissionTemplate(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleUpdateMissionTemplate(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.UpdateMissionTemplateRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.templateService.UpdateMissionTemplate(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteMissionTemplate(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	err = s.templateService.DeleteMissionTemplate(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleCreateMissionFromTemplate(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.CreateMissionFromTemplateRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.templateService.CreateMissionFromTemplate(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
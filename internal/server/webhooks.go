@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// WebhookService controls the webhook service.
+type WebhookService interface {
+	GetAllWebhooks(ctx context.Context) ([]models.Webhook, error)
+	CreateWebhook(ctx context.Context, req models.CreateWebhookRequest) (models.Webhook, error)
+	GetWebhook(ctx context.Context, id int32) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int32, req models.UpdateWebhookRequest) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int32) error
+	GetWebhookDeliveries(ctx context.Context, id int32) ([]models.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, id, deliveryID int32) (models.WebhookDelivery, error)
+}
+
+// WithWebhookService enables the webhook routes.
+func WithWebhookService(ws WebhookService) Option {
+	return func(s *Server) {
+		s.webhookService = ws
+	}
+}
+
+// registerWebhookRoutes registers the webhook routes.
+func (s *Server) registerWebhookRoutes() {
+	webhooks := s.R.Group("/webhooks")
+	{
+		webhooks.Get("/", s.handleGetWebhooks)
+		webhooks.Post("/", s.handleCreateWebhook)
+		webhooks.Get("/:id", s.handleGetSingleWebhook)
+		webhooks.Patch("/:id", s.handleUpdateWebhook)
+		webhooks.Delete("/:id", s.handleDeleteWebhook)
+		webhooks.Get("/:id/deliveries", s.handleGetWebhookDeliveries)
+		webhooks.Post("/:id/deliveries/:deliveryId/redeliver", s.handleRedeliverWebhookDelivery)
+	}
+}
+
+func (s *Server) handleGetWebhooks(c fiber.Ctx) error {
+	res, err := s.webhookService.GetAllWebhooks(c.Context())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"webhooks": res})
+}
+
+func (s *Server) handleCreateWebhook(c fiber.Ctx) error {
+	var r models.CreateWebhookRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.webhookService.CreateWebhook(c.Context(), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (s *Server) handleGetSingleWebhook(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.webhookService.GetWebhook(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleUpdateWebhook(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	var r models.UpdateWebhookRequest
+
+	if err := c.Bind().JSON(&r); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	res, err := s.webhookService.UpdateWebhook(c.Context(), int32(id), r)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (s *Server) handleDeleteWebhook(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	err = s.webhookService.DeleteWebhook(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{})
+}
+
+func (s *Server) handleGetWebhookDeliveries(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.webhookService.GetWebhookDeliveries(c.Context(), int32(id))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"deliveries": res})
+}
+
+func (s *Server) handleRedeliverWebhookDelivery(c fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	deliveryId, err := strconv.Atoi(c.Params("deliveryId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+	}
+
+	res, err := s.webhookService.RedeliverWebhookDelivery(c.Context(), int32(id), int32(deliveryId))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(res)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// defaultAnalyticsTTL is how long the analytics are cached by default.
+	defaultAnalyticsTTL = time.Minute
+	// analyticsTimeout bounds the aggregations of the analytics.
+	analyticsTimeout = 5 * time.Second
+	// maxCompletionRatePeriods bounds the periods of a completion rate.
+	maxCompletionRatePeriods = 366
+)
+
+// analyticsCache caches the analytics, which aggregate whole tables, for a
+// time to live. Failures are not cached.
+type analyticsCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]analyticsEntry
+}
+
+type analyticsEntry struct {
+	value   any
+	expires time.Time
+}
+
+func newAnalyticsCache(ttl time.Duration) *analyticsCache {
+	return &analyticsCache{ttl: ttl, entries: make(map[string]analyticsEntry)}
+}
+
+// cached returns the cached value of the key, or else computes and caches it.
+// Concurrent misses compute the value more than once.
+func cached[T any](c *analyticsCache, key string, compute func() (T, error)) (T, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.value.(T), nil
+	}
+
+	v, err := compute()
+	if err != nil || c.ttl <= 0 {
+		return v, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = analyticsEntry{value: v, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return v, nil
+}
+
+// analyticsError maps a failed aggregation to the error of the analytics.
+func analyticsError(log *slog.Logger, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrTimeoutExceeded
+	}
+	log.Error("Failed to compute analytics", "err", err)
+	return errors.New("failed to compute analytics")
+}
+
+// GetCompletionRate returns the completion rate of the missions created in
+// each of the last periods of the window, day, week or month, in UTC. The
+// weeks start on Monday and the last period is the current one.
+func (s Service) GetCompletionRate(ctx context.Context, window string, periods int) (models.CompletionRate, error) {
+	log := slog.With(
+		slog.String("op", "service.GetCompletionRate"),
+		slog.String("window", window),
+		slog.Int("periods", periods),
+	)
+	log.Debug("Computing completion rate")
+
+	if window != "day" && window != "week" && window != "month" {
+		return models.CompletionRate{}, models.NewError(http.StatusUnprocessableEntity, "window must be one of day, week, month")
+	}
+	if periods < 1 || periods > maxCompletionRatePeriods {
+		return models.CompletionRate{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("periods must be between 1 and %d", maxCompletionRatePeriods))
+	}
+
+	return cached(s.analyticsCache, fmt.Sprintf("completion-rate:%s:%d", window, periods), func() (models.CompletionRate, error) {
+		ctx, cancel := context.WithTimeout(ctx, analyticsTimeout)
+		defer cancel()
+
+		since := nextPeriod(periodStart(time.Now(), window), window, 1-periods)
+		rows, err := s.analyticsStorage.GetMissionCompletionRate(ctx, postgres.GetMissionCompletionRateParams{
+			TimeWindow: window,
+			Since:      pgtype.Timestamptz{Time: since, Valid: true},
+		})
+		if err != nil {
+			return models.CompletionRate{}, analyticsError(log, err)
+		}
+
+		// Periods without missions have no rows.
+		rate := models.CompletionRate{Window: window, Periods: make([]models.CompletionRatePeriod, periods)}
+		for i := range rate.Periods {
+			rate.Periods[i].Start = nextPeriod(since, window, i)
+		}
+		for _, r := range rows {
+			for i, p := range rate.Periods {
+				if !p.Start.Equal(r.Period.Time) {
+					continue
+				}
+				rate.Periods[i].Created = r.Created
+				rate.Periods[i].Completed = r.Completed
+				if r.Created > 0 {
+					rate.Periods[i].Rate = float64(r.Completed) / float64(r.Created)
+				}
+			}
+		}
+
+		return rate, nil
+	})
+}
+
+// periodStart returns the start of the period of the window of t in UTC.
+func periodStart(t time.Time, window string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch window {
+	case "day":
+		return day
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextPeriod returns the start of the n-th period of the window after the
+// one starting at start.
+func nextPeriod(start time.Time, window string, n int) time.Time {
+	switch window {
+	case "day":
+		return start.AddDate(0, 0, n)
+	case "week":
+		return start.AddDate(0, 0, 7*n)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
+
+// GetCompletionTimes returns the average time to complete a mission and a target.
+func (s Service) GetCompletionTimes(ctx context.Context) (models.CompletionTimes, error) {
+	log := slog.With(
+		slog.String("op", "service.GetCompletionTimes"),
+	)
+	log.Debug("Computing completion times")
+
+	return cached(s.analyticsCache, "completion-times", func() (models.CompletionTimes, error) {
+		ctx, cancel := context.WithTimeout(ctx, analyticsTimeout)
+		defer cancel()
+
+		row, err := s.analyticsStorage.GetCompletionTimes(ctx)
+		if err != nil {
+			return models.CompletionTimes{}, analyticsError(log, err)
+		}
+
+		return models.CompletionTimes{
+			CompletedMissions: row.CompletedMissions,
+			AvgMissionSeconds: row.MissionSeconds,
+			CompletedTargets:  row.CompletedTargets,
+			AvgTargetSeconds:  row.TargetSeconds,
+		}, nil
+	})
+}
+
+// GetCatUtilization returns the days each cat has spent on missions, the
+// active ones included.
+func (s Service) GetCatUtilization(ctx context.Context) ([]models.CatUtilization, error) {
+	log := slog.With(
+		slog.String("op", "service.GetCatUtilization"),
+	)
+	log.Debug("Computing cat utilization")
+
+	return cached(s.analyticsCache, "utilization", func() ([]models.CatUtilization, error) {
+		ctx, cancel := context.WithTimeout(ctx, analyticsTimeout)
+		defer cancel()
+
+		rows, err := s.analyticsStorage.GetCatUtilization(ctx, pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true})
+		if err != nil {
+			return nil, analyticsError(log, err)
+		}
+
+		utilization := make([]models.CatUtilization, len(rows))
+		for i, r := range rows {
+			utilization[i] = models.CatUtilization{
+				CatID:         r.ID,
+				Name:          r.Name,
+				Missions:      r.Missions,
+				DaysOnMission: r.DaysOnMission,
+			}
+		}
+
+		return utilization, nil
+	})
+}
+
+// GetTargetsPerCountry returns the targets of each country, most targeted first.
+func (s Service) GetTargetsPerCountry(ctx context.Context) ([]models.CountryTargets, error) {
+	log := slog.With(
+		slog.String("op", "service.GetTargetsPerCountry"),
+	)
+	log.Debug("Computing targets per country")
+
+	return cached(s.analyticsCache, "countries", func() ([]models.CountryTargets, error) {
+		ctx, cancel := context.WithTimeout(ctx, analyticsTimeout)
+		defer cancel()
+
+		rows, err := s.analyticsStorage.GetTargetsPerCountry(ctx)
+		if err != nil {
+			return nil, analyticsError(log, err)
+		}
+
+		countries := make([]models.CountryTargets, len(rows))
+		for i, r := range rows {
+			countries[i] = models.CountryTargets{Country: r.Country, Targets: r.Targets, Completed: r.Completed}
+		}
+
+		return countries, nil
+	})
+}
+
+// GetSalaryPerformance returns the correlation of the salaries of the cats
+// with the missions and the targets they completed.
+func (s Service) GetSalaryPerformance(ctx context.Context) (models.SalaryPerformance, error) {
+	log := slog.With(
+		slog.String("op", "service.GetSalaryPerformance"),
+	)
+	log.Debug("Computing salary performance")
+
+	return cached(s.analyticsCache, "salary-performance", func() (models.SalaryPerformance, error) {
+		ctx, cancel := context.WithTimeout(ctx, analyticsTimeout)
+		defer cancel()
+
+		rows, err := s.analyticsStorage.GetSalaryPerformance(ctx)
+		if err != nil {
+			return models.SalaryPerformance{}, analyticsError(log, err)
+		}
+		rates, err := s.exchangeRates(ctx)
+		if err != nil {
+			return models.SalaryPerformance{}, analyticsError(log, err)
+		}
+
+		// The sums of each currency are scaled to the minor units of the
+		// reporting currency.
+		var performance models.SalaryPerformance
+		var sum postgres.GetSalaryPerformanceRow
+		for _, row := range rows {
+			rate, ok := rates.Rate(row.SalaryCurrency)
+			if !ok {
+				performance.ExcludedCats += row.Cats
+				continue
+			}
+			k := rate * math.Pow10(currency.Digits(rates.Base())-currency.Digits(row.SalaryCurrency))
+
+			sum.Cats += row.Cats
+			sum.SumSalary += k * row.SumSalary
+			sum.SumSalarySquares += k * k * row.SumSalarySquares
+			sum.SumMissions += row.SumMissions
+			sum.SumMissionsSquares += row.SumMissionsSquares
+			sum.SumSalaryMissions += k * row.SumSalaryMissions
+			sum.SumTargets += row.SumTargets
+			sum.SumTargetsSquares += row.SumTargetsSquares
+			sum.SumSalaryTargets += k * row.SumSalaryTargets
+		}
+
+		n := float64(sum.Cats)
+		performance.Cats = sum.Cats
+		performance.MissionsCorrelation = pearson(n, sum.SumSalary, sum.SumMissions, sum.SumSalarySquares, sum.SumMissionsSquares, sum.SumSalaryMissions)
+		performance.TargetsCorrelation = pearson(n, sum.SumSalary, sum.SumTargets, sum.SumSalarySquares, sum.SumTargetsSquares, sum.SumSalaryTargets)
+
+		return performance, nil
+	})
+}
+
+// pearson returns the Pearson correlation coefficient of n pairs from their
+// sums, or nil if it is undefined.
+func pearson(n, sumX, sumY, sumXX, sumYY, sumXY float64) *float64 {
+	d := (n*sumXX - sumX*sumX) * (n*sumYY - sumY*sumY)
+	if n < 2 || d <= 0 {
+		return nil
+	}
+
+	r := (n*sumXY - sumX*sumY) / math.Sqrt(d)
+	return &r
+}
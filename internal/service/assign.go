@@ -0,0 +1,323 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/assign"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// defaultAssignStrategy is the strategy of an auto-assignment without one.
+	defaultAssignStrategy = "greedy"
+	// autoAssignTimeout bounds an auto-assignment, from the plan to the assignments.
+	autoAssignTimeout = 10 * time.Second
+	// maxScoreExperience caps the experience breaking the ties of the skill match.
+	maxScoreExperience = 100
+)
+
+// AutoAssignMission assigns the best idle cat to the unassigned mission.
+func (s Service) AutoAssignMission(ctx context.Context, missionID int32, req models.AutoAssignRequest) (models.AutoAssignResult, error) {
+	log := slog.With(
+		slog.String("op", "service.AutoAssignMission"),
+		slog.Any("missionId", missionID),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Auto-assigning mission")
+
+	ctx, cancel := context.WithTimeout(ctx, autoAssignTimeout)
+	defer cancel()
+
+	mission, err := s.missionStorage.GetMission(ctx, missionID)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.AutoAssignResult{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AutoAssignResult{}, models.ErrNotFound
+		}
+		log.Error("Failed to get mission", "err", err)
+		return models.AutoAssignResult{}, errors.New("failed to auto-assign")
+	}
+	if mission.Completed {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "mission is completed")
+	}
+	if mission.Assignee.Valid {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "mission is already assigned")
+	}
+
+	return s.autoAssign(ctx, log, []postgres.Mission{mission}, req)
+}
+
+// AutoAssignMissions assigns the idle cats to all the unassigned missions,
+// in ascending order of ID.
+func (s Service) AutoAssignMissions(ctx context.Context, req models.AutoAssignRequest) (models.AutoAssignResult, error) {
+	log := slog.With(
+		slog.String("op", "service.AutoAssignMissions"),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Auto-assigning missions")
+
+	ctx, cancel := context.WithTimeout(ctx, autoAssignTimeout)
+	defer cancel()
+
+	res, err := s.missionStorage.GetAllMissions(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.AutoAssignResult{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get missions", "err", err)
+		return models.AutoAssignResult{}, errors.New("failed to auto-assign")
+	}
+
+	missions := make([]postgres.Mission, 0)
+	for _, m := range res {
+		if !m.Completed && !m.Assignee.Valid {
+			missions = append(missions, m)
+		}
+	}
+	slices.SortFunc(missions, func(a, b postgres.Mission) int { return cmp.Compare(a.ID, b.ID) })
+
+	return s.autoAssign(ctx, log, missions, req)
+}
+
+// autoAssign solves the assignment of the idle cats to the missions and,
+// unless it is a dry run, makes the assignments in a transaction.
+//
+// The idle cats are the cats without an active mission, available now and
+// experienced enough. A cat scores its skill match for a mission, the ties
+// broken by experience. The salaries and the budget are compared in the
+// reporting currency.
+func (s Service) autoAssign(ctx context.Context, log *slog.Logger, missions []postgres.Mission, req models.AutoAssignRequest) (models.AutoAssignResult, error) {
+	if req.Strategy == "" {
+		req.Strategy = defaultAssignStrategy
+	}
+	strategy, err := assign.Lookup(req.Strategy)
+	if err != nil {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if req.MinYearsOfExperience < 0 {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "min_years_of_experience must not be negative")
+	}
+	if req.SalaryBudget != nil && *req.SalaryBudget < 0 {
+		return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "salary_budget must not be negative")
+	}
+	budgetCurrency := s.reportingCurrency
+	if req.SalaryBudgetCurrency != "" {
+		if budgetCurrency, err = currency.Parse(req.SalaryBudgetCurrency); err != nil {
+			return models.AutoAssignResult{}, models.NewError(http.StatusUnprocessableEntity, "salary_budget_currency must be an ISO 4217 code")
+		}
+	}
+
+	fail := func(err error) (models.AutoAssignResult, error) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.AutoAssignResult{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to auto-assign", "err", err)
+		return models.AutoAssignResult{}, errors.New("failed to auto-assign")
+	}
+
+	cats, err := s.idleCats(ctx, req.MinYearsOfExperience)
+	if err != nil {
+		return fail(err)
+	}
+	catSkills, err := s.skillStorage.GetUnassignedCatSkills(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	skillsOf := make(map[int32]map[int32]postgres.CatSkill)
+	for _, cs := range catSkills {
+		if skillsOf[cs.Cat] == nil {
+			skillsOf[cs.Cat] = make(map[int32]postgres.CatSkill)
+		}
+		skillsOf[cs.Cat][cs.Skill] = cs
+	}
+
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	budget := int64(assign.NoBudget)
+	if req.SalaryBudget != nil {
+		if budget, err = rates.Convert(*req.SalaryBudget, budgetCurrency, rates.Base()); err != nil {
+			log.Info("Budget without exchange rate", "currency", budgetCurrency)
+			return models.AutoAssignResult{}, conversionError(err)
+		}
+	}
+
+	now := time.Now()
+	problem := assign.Problem{
+		Scores:   make([][]float64, len(missions)),
+		Salaries: make([]int64, len(cats)),
+		Budget:   budget,
+	}
+	matches := make([][]float64, len(missions))
+	for j, c := range cats {
+		problem.Salaries[j], err = rates.Convert(c.Salary, c.SalaryCurrency, rates.Base())
+		if err != nil {
+			log.Info("Salary without exchange rate", "cat", c.ID)
+			return models.AutoAssignResult{}, conversionError(err)
+		}
+	}
+	for i, m := range missions {
+		required, err := s.skillStorage.GetMissionRequiredSkills(ctx, m.ID)
+		if err != nil {
+			return fail(err)
+		}
+		problem.Scores[i] = make([]float64, len(cats))
+		matches[i] = make([]float64, len(cats))
+		for j, c := range cats {
+			match := rankCandidate(sqlcCatToModel(c), required, skillsOf[c.ID], now).SkillMatch
+			matches[i][j] = match
+			problem.Scores[i][j] = match + float64(min(c.YearsOfExperience, maxScoreExperience))/(maxScoreExperience*1000)
+		}
+	}
+
+	result := models.AutoAssignResult{
+		Strategy:    req.Strategy,
+		DryRun:      req.DryRun,
+		Assignments: make([]models.AutoAssignment, 0),
+		Unassigned:  make([]int32, 0),
+		Currency:    rates.Base(),
+	}
+	assigned := make(map[int]bool)
+	for _, pair := range strategy.Solve(problem) {
+		assigned[pair.Mission] = true
+		result.Assignments = append(result.Assignments, models.AutoAssignment{
+			MissionID:  missions[pair.Mission].ID,
+			Cat:        sqlcCatToModel(cats[pair.Cat]),
+			SkillMatch: matches[pair.Mission][pair.Cat],
+		})
+		result.TotalSalary += problem.Salaries[pair.Cat]
+	}
+	for i, m := range missions {
+		if !assigned[i] {
+			result.Unassigned = append(result.Unassigned, m.ID)
+		}
+	}
+
+	log.Debug("Solved auto-assignment", "assignments", len(result.Assignments), "unassigned", len(result.Unassigned))
+
+	if req.DryRun || len(result.Assignments) == 0 {
+		return result, nil
+	}
+
+	if err := s.applyAutoAssignments(ctx, log, result.Assignments); err != nil {
+		return models.AutoAssignResult{}, err
+	}
+
+	log.Debug("Auto-assigned missions")
+
+	return result, nil
+}
+
+// idleCats returns the cats without an active mission, available from now on
+// and with the experience. An auto-assignment has no expected end, so a cat
+// with a future period is not idle, as in AssignCatToMission without until.
+func (s Service) idleCats(ctx context.Context, minExperience int32) ([]postgres.Cat, error) {
+	res, err := s.skillStorage.GetUnassignedCats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an availability storage, all the cats are available.
+	var available map[int32]bool
+	if s.availabilityStorage != nil {
+		cats, err := s.availabilityStorage.GetCatsAvailableBetween(ctx, postgres.GetCatsAvailableBetweenParams{
+			Since: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Until: pgtype.Timestamptz{Time: openEnded, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		available = make(map[int32]bool, len(cats))
+		for _, c := range cats {
+			available[c.ID] = true
+		}
+	}
+
+	cats := make([]postgres.Cat, 0, len(res))
+	for _, c := range res {
+		if c.YearsOfExperience >= minExperience && (available == nil || available[c.ID]) {
+			cats = append(cats, c)
+		}
+	}
+
+	return cats, nil
+}
+
+// applyAutoAssignments makes the assignments in a transaction, or none of them
+// if a mission or a cat was assigned since the plan.
+func (s Service) applyAutoAssignments(ctx context.Context, log *slog.Logger, assignments []models.AutoAssignment) (err error) {
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return errors.New("failed to auto-assign")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	fail := func(err error) error {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to assign cat", "err", err)
+		return errors.New("failed to auto-assign")
+	}
+
+	idle, err := withTx.GetUnassignedCats(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	isIdle := make(map[int32]bool, len(idle))
+	for _, c := range idle {
+		isIdle[c.ID] = true
+	}
+
+	for _, a := range assignments {
+		var mission postgres.Mission
+		mission, err = withTx.GetMission(ctx, a.MissionID)
+		if err != nil {
+			return fail(err)
+		}
+		if mission.Completed || mission.Assignee.Valid || !isIdle[a.Cat.ID] {
+			log.Info("Auto-assignment conflict", "missionId", a.MissionID, "cat", a.Cat.ID)
+			err = models.NewError(http.StatusConflict, fmt.Sprintf("mission %d or cat %d was assigned meanwhile, try again", a.MissionID, a.Cat.ID))
+			return err
+		}
+
+		mission, err = assignCat(ctx, withTx, a.MissionID, pgtype.Int4{Int32: a.Cat.ID, Valid: true})
+		if err != nil {
+			return fail(err)
+		}
+
+		err = recordEvent(ctx, withTx, events.CatAssigned, mission.ID, sqlcMissionToModel(mission))
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rsmanito/developstoday-test-assessment/internal/blobs"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// defaultMaxAttachmentSize is the size limit of an attachment in bytes,
+	// unless set with WithMaxAttachmentSize.
+	defaultMaxAttachmentSize = 10 << 20
+	// maxFilenameLength is the size of the filename column.
+	maxFilenameLength = 256
+	// sniffLength is the number of bytes the content type is sniffed from.
+	sniffLength = 512
+	// blobTimeout is the timeout of an upload to the blob store, longer than
+	// the one of the queries as the files are larger.
+	blobTimeout = 30 * time.Second
+)
+
+// attachmentTypes are the content types an attachment may have, as sniffed
+// by http.DetectContentType: photos, PDF documents and plain text.
+var attachmentTypes = map[string]bool{
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+// UploadAttachment stores the file and attaches it to the target. The
+// attachments of completed targets and missions can't be changed.
+func (s Service) UploadAttachment(ctx context.Context, targetId int32, upload models.AttachmentUpload) (models.Attachment, error) {
+	log := slog.With(
+		slog.String("op", "service.UploadAttachment"),
+		slog.Any("targetId", targetId),
+		slog.String("filename", upload.Filename),
+		slog.Int64("size", upload.Size),
+	)
+
+	log.Debug("Uploading attachment")
+
+	filename, err := s.checkUpload(upload)
+	if err != nil {
+		log.Info("Invalid attachment")
+		return models.Attachment{}, err
+	}
+
+	content, contentType, err := sniffContentType(upload.Content)
+	if err != nil {
+		log.Error("Failed to read attachment", "err", err)
+		return models.Attachment{}, errors.New("failed to upload attachment")
+	}
+	if !attachmentTypes[contentType] {
+		log.Info("Unsupported content type", "contentType", contentType)
+		return models.Attachment{}, models.NewError(http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type %s", contentType))
+	}
+
+	if err := s.checkAttachmentsEditable(ctx, log, targetId); err != nil {
+		return models.Attachment{}, err
+	}
+
+	key, err := newBlobKey(targetId)
+	if err != nil {
+		log.Error("Failed to generate blob key", "err", err)
+		return models.Attachment{}, errors.New("failed to upload attachment")
+	}
+
+	blobCtx, cancelBlob := context.WithTimeout(ctx, blobTimeout)
+	defer cancelBlob()
+
+	if err := s.blobStore.Put(blobCtx, key, content, upload.Size, contentType); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Attachment{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to store attachment", "key", key, "err", err)
+		return models.Attachment{}, errors.New("failed to upload attachment")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.createAttachment(ctx, log, postgres.CreateAttachmentParams{
+		Target:      targetId,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        upload.Size,
+		BlobKey:     key,
+	})
+	if err != nil {
+		// The row wasn't created, so nothing refers to the blob.
+		s.deleteBlobs(log, key)
+		return models.Attachment{}, err
+	}
+
+	log.Info("Uploaded attachment", "id", res.ID, "contentType", contentType)
+
+	return sqlcAttachmentToModel(res), nil
+}
+
+// GetTargetAttachments returns the attachments of the target, the oldest first.
+func (s Service) GetTargetAttachments(ctx context.Context, targetId int32) ([]models.Attachment, error) {
+	log := slog.With(
+		slog.String("op", "service.GetTargetAttachments"),
+		slog.Any("targetId", targetId),
+	)
+
+	log.Debug("Getting target attachments")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := s.targetStorage.GetTarget(ctx, targetId); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Attachment, 0), models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target not found")
+			return make([]models.Attachment, 0), models.ErrNotFound
+		}
+		log.Error("Failed to get target", "err", err)
+		return make([]models.Attachment, 0), errors.New("failed to get attachments")
+	}
+
+	res, err := s.attachmentStorage.GetTargetAttachments(ctx, targetId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Attachment, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get attachments", "err", err)
+		return make([]models.Attachment, 0), errors.New("failed to get attachments")
+	}
+
+	attachments := make([]models.Attachment, len(res))
+	for i, a := range res {
+		attachments[i] = sqlcAttachmentToModel(a)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment returns the attachment of the target with its content, which
+// the caller must close.
+func (s Service) GetAttachment(ctx context.Context, targetId, id int32) (models.Attachment, io.ReadCloser, error) {
+	log := slog.With(
+		slog.String("op", "service.GetAttachment"),
+		slog.Any("targetId", targetId),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Getting attachment")
+
+	attachment, err := s.getAttachment(ctx, log, targetId, id)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+
+	// The content is read after the return, so it isn't bound to a timeout.
+	content, err := s.blobStore.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		if errors.Is(err, blobs.ErrNotFound) {
+			log.Error("Attachment content is missing", "key", attachment.BlobKey)
+		} else {
+			log.Error("Failed to get attachment content", "key", attachment.BlobKey, "err", err)
+		}
+		return models.Attachment{}, nil, errors.New("failed to get attachment")
+	}
+
+	return sqlcAttachmentToModel(attachment), content, nil
+}
+
+// DeleteAttachment deletes the attachment of the target with its content. The
+// attachments of completed targets and missions can't be changed.
+func (s Service) DeleteAttachment(ctx context.Context, targetId, id int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteAttachment"),
+		slog.Any("targetId", targetId),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Deleting attachment")
+
+	if err := s.checkAttachmentsEditable(ctx, log, targetId); err != nil {
+		return err
+	}
+
+	attachment, err := s.getAttachment(ctx, log, targetId, id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.deleteAttachment(ctx, log, targetId, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		log.Debug("Attachment not found")
+		return models.ErrNotFound
+	}
+
+	// The content is deleted after the row: a failure leaves an unreferenced
+	// blob rather than an attachment without content.
+	s.deleteBlobs(log, attachment.BlobKey)
+
+	log.Info("Deleted attachment")
+
+	return nil
+}
+
+// createAttachment creates the attachment in a transaction with the mission of
+// the target locked, so neither can be completed before the commit.
+func (s Service) createAttachment(ctx context.Context, log *slog.Logger, params postgres.CreateAttachmentParams) (res postgres.Attachment, err error) {
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return postgres.Attachment{}, errors.New("failed to upload attachment")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	err = lockAttachmentsEditable(ctx, log, withTx, params.Target)
+	if err != nil {
+		return postgres.Attachment{}, err
+	}
+
+	res, err = withTx.CreateAttachment(ctx, params)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return postgres.Attachment{}, models.ErrTimeoutExceeded
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			log.Debug("Target deleted during the upload")
+			return postgres.Attachment{}, models.ErrNotFound
+		}
+		log.Error("Failed to create attachment", "err", err)
+		return postgres.Attachment{}, errors.New("failed to upload attachment")
+	}
+
+	return res, nil
+}
+
+// deleteAttachment deletes the attachment in a transaction with the mission
+// of the target locked, so neither can be completed before the commit.
+func (s Service) deleteAttachment(ctx context.Context, log *slog.Logger, targetId, id int32) (rows int64, err error) {
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return 0, errors.New("failed to delete attachment")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	err = lockAttachmentsEditable(ctx, log, withTx, targetId)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err = withTx.DeleteAttachment(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete attachment", "err", err)
+		return 0, errors.New("failed to delete attachment")
+	}
+
+	return rows, nil
+}
+
+// checkUpload checks the size and the name of an uploaded file and returns
+// the name to store.
+func (s Service) checkUpload(upload models.AttachmentUpload) (string, error) {
+	if upload.Size <= 0 {
+		return "", models.NewError(http.StatusUnprocessableEntity, "attachment is empty")
+	}
+	if upload.Size > s.maxAttachmentSize {
+		return "", models.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("attachment is too large (max %d bytes)", s.maxAttachmentSize))
+	}
+
+	filename := cleanFilename(upload.Filename)
+	if filename == "" {
+		return "", models.NewError(http.StatusUnprocessableEntity, "filename is required")
+	}
+	if utf8.RuneCountInString(filename) > maxFilenameLength {
+		return "", models.NewError(http.StatusUnprocessableEntity, "filename is too long (1..256)")
+	}
+
+	return filename, nil
+}
+
+// cleanFilename returns the base name of an uploaded file, without the
+// directories some clients send.
+func cleanFilename(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// sniffContentType returns the content type of the content, sniffed from its
+// first bytes, and a reader of the whole content.
+func sniffContentType(content io.Reader) (io.Reader, string, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, "", err
+	}
+	head = head[:n]
+
+	return io.MultiReader(bytes.NewReader(head), content), http.DetectContentType(head), nil
+}
+
+// checkAttachmentsEditable checks the target exists and neither it nor its
+// mission is completed.
+func (s Service) checkAttachmentsEditable(ctx context.Context, log *slog.Logger, targetId int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	target, err := s.targetStorage.GetTarget(ctx, targetId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target not found")
+			return models.ErrNotFound
+		}
+		log.Error("Failed to get target", "err", err)
+		return errors.New("failed to update attachments")
+	}
+	if target.Completed {
+		return completedTargetError(log)
+	}
+
+	mission, err := s.missionStorage.GetMissionByTargetID(ctx, targetId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get mission", "err", err)
+		return errors.New("failed to update attachments")
+	}
+	if mission.Completed {
+		return completedMissionError(log)
+	}
+
+	return nil
+}
+
+// lockAttachmentsEditable locks the mission of the target until the end of
+// the transaction and checks again that neither the target nor the mission is
+// completed, as they may have been since checkAttachmentsEditable.
+func lockAttachmentsEditable(ctx context.Context, log *slog.Logger, withTx postgres.Querier, targetId int32) error {
+	fail := func(err error) error {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target deleted meanwhile")
+			return models.ErrNotFound
+		}
+		log.Error("Failed to lock mission", "err", err)
+		return errors.New("failed to update attachments")
+	}
+
+	row, err := withTx.GetMissionByTargetID(ctx, targetId)
+	if err != nil {
+		return fail(err)
+	}
+	mission, err := withTx.GetMissionForUpdate(ctx, row.MissionID)
+	if err != nil {
+		return fail(err)
+	}
+	// The target is read under the lock, it may have been moved to another
+	// mission before.
+	target, err := withTx.GetTarget(ctx, targetId)
+	if err != nil {
+		return fail(err)
+	}
+	if target.Mission != mission.ID {
+		log.Info("Target moved meanwhile", "mission", target.Mission)
+		return models.NewError(http.StatusConflict, "target was moved meanwhile, try again")
+	}
+
+	if target.Completed {
+		return completedTargetError(log)
+	}
+	if mission.Completed {
+		return completedMissionError(log)
+	}
+
+	return nil
+}
+
+func completedTargetError(log *slog.Logger) error {
+	log.Debug("Can't change attachments of a completed target")
+	return models.NewError(http.StatusUnprocessableEntity, "Can't change attachments of a completed target")
+}
+
+func completedMissionError(log *slog.Logger) error {
+	log.Debug("Can't change target attachments of a completed mission")
+	return models.NewError(http.StatusUnprocessableEntity, "Can't change target attachments of a completed mission")
+}
+
+// getAttachment returns the attachment, which must belong to the target.
+func (s Service) getAttachment(ctx context.Context, log *slog.Logger, targetId, id int32) (postgres.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	attachment, err := s.attachmentStorage.GetAttachment(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return postgres.Attachment{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Attachment not found")
+			return postgres.Attachment{}, models.ErrNotFound
+		}
+		log.Error("Failed to get attachment", "err", err)
+		return postgres.Attachment{}, errors.New("failed to get attachment")
+	}
+	if attachment.Target != targetId {
+		log.Debug("Attachment belongs to another target", "target", attachment.Target)
+		return postgres.Attachment{}, models.ErrNotFound
+	}
+
+	return attachment, nil
+}
+
+// blobKeys returns the blob keys of the attachments.
+func blobKeys(attachments []postgres.Attachment) []string {
+	keys := make([]string, len(attachments))
+	for i, a := range attachments {
+		keys[i] = a.BlobKey
+	}
+
+	return keys
+}
+
+// deleteBlobs deletes the content of deleted attachments. Failures are only
+// logged, they leave unreferenced blobs behind.
+func (s Service) deleteBlobs(log *slog.Logger, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	// The request may be done, the blobs are deleted regardless.
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+
+	for _, key := range keys {
+		if err := s.blobStore.Delete(ctx, key); err != nil {
+			log.Warn("Failed to delete attachment content", "key", key, "err", err)
+		}
+	}
+}
+
+// newBlobKey returns a new random key for the content of an attachment of
+// the target.
+func newBlobKey(targetId int32) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("targets/%d/%s", targetId, hex.EncodeToString(b)), nil
+}
+
+func sqlcAttachmentToModel(a postgres.Attachment) models.Attachment {
+	return models.Attachment{
+		ID:          a.ID,
+		TargetID:    a.Target,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt.Time.UTC(),
+	}
+}
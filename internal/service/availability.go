@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// availabilityKinds are the kinds of the periods a cat is unavailable.
+var availabilityKinds = []string{"vacation", "sick", "training"}
+
+// openEnded is the end of an assignment without an expected end, so that
+// every future period of the cat conflicts with it.
+var openEnded = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func (s Service) GetCatAvailability(ctx context.Context, catID int32) ([]models.AvailabilityPeriod, error) {
+	log := slog.With(
+		slog.String("op", "service.GetCatAvailability"),
+		slog.Any("catId", catID),
+	)
+
+	log.Debug("Fetching cat availability")
+
+	if _, err := s.GetCat(ctx, catID); err != nil {
+		return make([]models.AvailabilityPeriod, 0), err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.availabilityStorage.GetCatAvailabilityPeriods(ctx, catID)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.AvailabilityPeriod, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get availability periods", "err", err)
+		return make([]models.AvailabilityPeriod, 0), errors.New("failed to get cat availability")
+	}
+
+	periods := make([]models.AvailabilityPeriod, len(res))
+	for i, p := range res {
+		periods[i] = sqlcAvailabilityPeriodToModel(p)
+	}
+
+	return periods, nil
+}
+
+func (s Service) CreateAvailabilityPeriod(ctx context.Context, catID int32, req models.AvailabilityPeriodRequest) (models.AvailabilityPeriod, error) {
+	log := slog.With(
+		slog.String("op", "service.CreateAvailabilityPeriod"),
+		slog.Any("catId", catID),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Creating availability period")
+
+	if err := validateAvailabilityPeriod(req); err != nil {
+		log.Info("Invalid availability period")
+		return models.AvailabilityPeriod{}, err
+	}
+
+	if _, err := s.GetCat(ctx, catID); err != nil {
+		return models.AvailabilityPeriod{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.availabilityStorage.CreateAvailabilityPeriod(ctx, postgres.CreateAvailabilityPeriodParams{
+		Cat:      catID,
+		Kind:     req.Kind,
+		StartsAt: pgtype.Timestamptz{Time: req.StartsAt, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: req.EndsAt, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.AvailabilityPeriod{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to create availability period", "err", err)
+		return models.AvailabilityPeriod{}, errors.New("failed to create availability period")
+	}
+
+	log.Debug("Created availability period", "id", res.ID)
+
+	return sqlcAvailabilityPeriodToModel(res), nil
+}
+
+func (s Service) UpdateAvailabilityPeriod(ctx context.Context, catID, id int32, req models.AvailabilityPeriodRequest) (models.AvailabilityPeriod, error) {
+	log := slog.With(
+		slog.String("op", "service.UpdateAvailabilityPeriod"),
+		slog.Any("catId", catID),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Updating availability period")
+
+	if err := validateAvailabilityPeriod(req); err != nil {
+		log.Info("Invalid availability period")
+		return models.AvailabilityPeriod{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if err := s.checkAvailabilityPeriodOwner(ctx, log, catID, id); err != nil {
+		return models.AvailabilityPeriod{}, err
+	}
+
+	res, err := s.availabilityStorage.UpdateAvailabilityPeriod(ctx, postgres.UpdateAvailabilityPeriodParams{
+		ID:       id,
+		Kind:     req.Kind,
+		StartsAt: pgtype.Timestamptz{Time: req.StartsAt, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: req.EndsAt, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.AvailabilityPeriod{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AvailabilityPeriod{}, models.ErrNotFound
+		}
+		log.Error("Failed to update availability period", "err", err)
+		return models.AvailabilityPeriod{}, errors.New("failed to update availability period")
+	}
+
+	log.Debug("Updated availability period")
+
+	return sqlcAvailabilityPeriodToModel(res), nil
+}
+
+func (s Service) DeleteAvailabilityPeriod(ctx context.Context, catID, id int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteAvailabilityPeriod"),
+		slog.Any("catId", catID),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Deleting availability period")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if err := s.checkAvailabilityPeriodOwner(ctx, log, catID, id); err != nil {
+		return err
+	}
+
+	rows, err := s.availabilityStorage.DeleteAvailabilityPeriod(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete availability period", "err", err)
+		return errors.New("failed to delete availability period")
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	log.Debug("Deleted availability period")
+
+	return nil
+}
+
+// checkAvailabilityPeriodOwner returns ErrNotFound unless the period is one of the cat.
+func (s Service) checkAvailabilityPeriodOwner(ctx context.Context, log *slog.Logger, catID, id int32) error {
+	period, err := s.availabilityStorage.GetAvailabilityPeriod(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Availability period not found")
+			return models.ErrNotFound
+		}
+		log.Error("Failed to get availability period", "err", err)
+		return errors.New("failed to get availability period")
+	}
+	if period.Cat != catID {
+		log.Debug("Availability period of another cat", "cat", period.Cat)
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetAvailableCats returns the cats without an availability period at the time.
+func (s Service) GetAvailableCats(ctx context.Context, at time.Time) ([]models.Cat, error) {
+	log := slog.With(
+		slog.String("op", "service.GetAvailableCats"),
+		slog.Time("at", at),
+	)
+
+	log.Debug("Fetching available cats")
+
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	res, err := s.availabilityStorage.GetAvailableCats(ctx, pgtype.Timestamptz{Time: at, Valid: true})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Cat, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get available cats", "err", err)
+		return make([]models.Cat, 0), errors.New("failed to fetch cats")
+	}
+
+	cats := make([]models.Cat, len(res))
+	for i, c := range res {
+		cats[i] = sqlcCatToModel(c)
+	}
+
+	return cats, nil
+}
+
+// checkCatAvailability returns the conflicts of an assignment of the cat
+// from since until the time, or "" if the cat is available. Without an
+// availability storage, all the cats are available.
+func (s Service) checkCatAvailability(ctx context.Context, catID int32, since, until time.Time) (string, error) {
+	if s.availabilityStorage == nil {
+		return "", nil
+	}
+
+	periods, err := s.availabilityStorage.GetOverlappingAvailabilityPeriods(ctx, postgres.GetOverlappingAvailabilityPeriodsParams{
+		Cat:   catID,
+		Since: pgtype.Timestamptz{Time: since, Valid: true},
+		Until: pgtype.Timestamptz{Time: until, Valid: true},
+	})
+	if err != nil || len(periods) == 0 {
+		return "", err
+	}
+
+	conflicts := make([]string, len(periods))
+	for i, p := range periods {
+		conflicts[i] = fmt.Sprintf("%s from %s to %s", p.Kind, p.StartsAt.Time.UTC().Format(time.RFC3339), p.EndsAt.Time.UTC().Format(time.RFC3339))
+	}
+
+	return "cat is unavailable: " + strings.Join(conflicts, ", "), nil
+}
+
+func validateAvailabilityPeriod(req models.AvailabilityPeriodRequest) error {
+	if !slices.Contains(availabilityKinds, req.Kind) {
+		return models.NewError(http.StatusUnprocessableEntity, "kind must be one of "+strings.Join(availabilityKinds, ", "))
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		return models.NewError(http.StatusUnprocessableEntity, "starts_at and ends_at are required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return models.NewError(http.StatusUnprocessableEntity, "ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+func sqlcAvailabilityPeriodToModel(p postgres.AvailabilityPeriod) models.AvailabilityPeriod {
+	return models.AvailabilityPeriod{
+		ID:       p.ID,
+		CatID:    p.Cat,
+		Kind:     p.Kind,
+		StartsAt: p.StartsAt.Time.UTC(),
+		EndsAt:   p.EndsAt.Time.UTC(),
+	}
+}
@@ -0,0 +1,968 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rsmanito/developstoday-test-assessment/internal/blobs"
+	"github.com/rsmanito/developstoday-test-assessment/internal/config"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/memory"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The service tests reading and writing data run on every storage backend
+// with forEachBackend, the mock storage is left for the failures of the
+// storage. Postgres is used only when DB_CONN_URL is set.
+
+// missingID is an ID of no row.
+const missingID int32 = 1 << 30
+
+// forEachBackend runs the test on each storage backend. The Postgres
+// database is shared by the tests, so they must not expect it to be empty.
+func forEachBackend(t *testing.T, test func(t *testing.T, st storage.Backend)) {
+	t.Run("Postgres", func(t *testing.T) {
+		url := os.Getenv("DB_CONN_URL")
+		if url == "" {
+			t.Skip("DB_CONN_URL is not set")
+		}
+
+		st := storage.New(&config.Config{DbConnUrl: url})
+		t.Cleanup(st.Close)
+
+		test(t, st)
+	})
+
+	t.Run("SQLite", func(t *testing.T) {
+		st := sqlite.New(&config.Config{SqlitePath: filepath.Join(t.TempDir(), "sca.db")})
+		t.Cleanup(st.Close)
+
+		test(t, st)
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		test(t, memory.New())
+	})
+}
+
+// rollbackBackend rolls back its transactions instead of committing them.
+type rollbackBackend struct {
+	storage.Backend
+}
+
+type rollbackTx struct {
+	storage.Tx
+}
+
+func (b rollbackBackend) Begin(ctx context.Context) (storage.Tx, error) {
+	tx, err := b.Backend.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rollbackTx{tx}, nil
+}
+
+func (b rollbackBackend) WithTx(tx storage.Tx) postgres.Querier {
+	return b.Backend.WithTx(tx.(rollbackTx).Tx)
+}
+
+func (t rollbackTx) Commit(ctx context.Context) error {
+	return t.Tx.Rollback(ctx)
+}
+
+// newTestCat creates a cat in the storage directly, to skip the validation
+// of CreateCat.
+func newTestCat(t *testing.T, st storage.Backend) models.Cat {
+	c, err := st.CreateCat(context.Background(), postgres.CreateCatParams{
+		Name:              "Tom",
+		YearsOfExperience: 3,
+		Breed:             "Abyssinian",
+		Salary:            10000,
+		SalaryCurrency:    "USD",
+	})
+	require.NoError(t, err)
+	return sqlcCatToModel(c)
+}
+
+// newTestMission creates a mission with a target of each name, and reads it
+// back as stored, without the suggestions.
+func newTestMission(t *testing.T, s Service, targets ...string) models.Mission {
+	req := models.CreateMissionRequest{}
+	for _, name := range targets {
+		req.Targets = append(req.Targets, models.CreateTargetRequest{Name: name, Country: "UA", Notes: "Notes of " + name})
+	}
+	created, err := s.CreateMission(context.Background(), req)
+	require.NoError(t, err)
+
+	m, err := s.GetMission(context.Background(), created.ID)
+	require.NoError(t, err)
+	return m
+}
+
+func TestBackend(t *testing.T) {
+	forEachBackend(t, runBackendSuite)
+}
+
+func runBackendSuite(t *testing.T, st storage.Backend) {
+	blobStore, err := blobs.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	s := NewService(st, st, st, st,
+		WithWebhookStorage(st),
+		WithOutboxStorage(st),
+		WithSearchStorage(st),
+		WithTemplateStorage(st),
+		WithBreedCatalog(newStaticBreeds("Abyssinian")),
+		WithSnapshotStorage(st),
+		WithReportStorage(st),
+		WithAnalyticsStorage(st),
+		WithAnalyticsCacheTTL(0),
+		WithAvailabilityStorage(st),
+		WithSkillStorage(st),
+		WithCostStorage(st),
+		WithRateStorage(st),
+		WithLocationStorage(st),
+		WithSubjectStorage(st),
+		WithAttachments(st, blobStore),
+		WithMaxAttachmentSize(1<<10),
+	)
+
+	t.Run("CreateMission", func(t *testing.T) {
+		ctx := context.Background()
+
+		created := newTestMission(t, s, "Ivan", "Olga")
+
+		m, err := s.GetMission(ctx, created.ID)
+		require.NoError(t, err)
+		require.Len(t, m.Targets, 2)
+		assert.Equal(t, "Ivan", m.Targets[0].Name)
+		assert.Equal(t, "Olga", m.Targets[1].Name)
+		assert.False(t, m.Completed)
+	})
+
+	t.Run("GetMission_NotFound", func(t *testing.T) {
+		_, err := s.GetMission(context.Background(), missingID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("AssignCatToMission", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		first := newTestMission(t, s, "Ivan")
+		second := newTestMission(t, s, "Olga")
+
+		_, err := s.AssignCatToMission(ctx, first.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+
+		// Assigning to another mission unassigns the cat from the first one.
+		m, err := s.AssignCatToMission(ctx, second.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+		assert.Equal(t, cat.ID, m.Assignee)
+
+		m, err = s.GetMission(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Zero(t, m.Assignee)
+
+		// Deleting the cat unassigns it.
+		require.NoError(t, s.DeleteCat(ctx, cat.ID))
+
+		m, err = s.GetMission(ctx, second.ID)
+		require.NoError(t, err)
+		assert.Zero(t, m.Assignee)
+	})
+
+	t.Run("CompleteMission", func(t *testing.T) {
+		ctx := context.Background()
+
+		m := newTestMission(t, s, "Ivan")
+
+		_, err := s.CompleteMission(ctx, m.ID)
+		assert.Error(t, err, "mission has pending targets")
+
+		_, err = s.CompleteTarget(ctx, m.Targets[0].ID)
+		require.NoError(t, err)
+
+		_, err = s.UpdateTargetNotes(ctx, m.Targets[0].ID, "new notes")
+		assert.Error(t, err, "target is completed")
+
+		completed, err := s.CompleteMission(ctx, m.ID)
+		require.NoError(t, err)
+		assert.True(t, completed.Completed)
+	})
+
+	t.Run("DeleteMission", func(t *testing.T) {
+		ctx := context.Background()
+
+		m := newTestMission(t, s, "Ivan")
+		require.NoError(t, s.DeleteMission(ctx, m.ID))
+
+		_, err := st.GetTarget(ctx, m.Targets[0].ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("RecordEvents", func(t *testing.T) {
+		ctx := context.Background()
+
+		webhook, err := s.CreateWebhook(ctx, models.CreateWebhookRequest{
+			URL:        "http://localhost/hook",
+			EventTypes: []string{events.MissionCompleted},
+		})
+		require.NoError(t, err)
+
+		m := newTestMission(t, s, "Ivan")
+		_, err = s.CompleteTarget(ctx, m.Targets[0].ID)
+		require.NoError(t, err)
+		_, err = s.CompleteMission(ctx, m.ID)
+		require.NoError(t, err)
+
+		// Both events are in the outbox, only the subscribed one is queued for the webhook.
+		evs, err := s.GetOutboxEvents(ctx, 1, maxOutboxEvents)
+		require.NoError(t, err)
+
+		var types []string
+		for _, e := range evs {
+			if e.AggregateID == m.ID {
+				types = append(types, e.Type)
+			}
+		}
+		assert.Equal(t, []string{events.TargetCompleted, events.MissionCompleted}, types)
+
+		deliveries, err := s.GetWebhookDeliveries(ctx, webhook.ID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, events.MissionCompleted, deliveries[0].EventType)
+
+		require.NoError(t, s.DeleteWebhook(ctx, webhook.ID))
+	})
+
+	t.Run("RecordEvents_RolledBack", func(t *testing.T) {
+		ctx := context.Background()
+
+		webhook, err := s.CreateWebhook(ctx, models.CreateWebhookRequest{
+			URL:        "http://localhost/hook",
+			EventTypes: []string{events.MissionCompleted},
+		})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, s.DeleteWebhook(ctx, webhook.ID)) }()
+
+		m := newTestMission(t, s, "Ivan")
+		_, err = s.CompleteTarget(ctx, m.Targets[0].ID)
+		require.NoError(t, err)
+
+		// The delivery is queued in the transaction of the change, so it is
+		// rolled back with it.
+		rb := rollbackBackend{st}
+		_, err = NewService(rb, rb, rb, rb, WithWebhookStorage(rb), WithOutboxStorage(rb)).CompleteMission(ctx, m.ID)
+		require.NoError(t, err)
+
+		got, err := s.GetMission(ctx, m.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Completed)
+		deliveries, err := s.GetWebhookDeliveries(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("SearchTargets", func(t *testing.T) {
+		ctx := context.Background()
+
+		created, err := s.CreateMission(ctx, models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Zbigniew", Country: "PL", Notes: "Meets the courier near the old harbour"},
+		}})
+		require.NoError(t, err)
+		m, err := s.GetMission(ctx, created.ID)
+		require.NoError(t, err)
+
+		res, err := s.SearchTargets(ctx, `"old harbour" zbig*`, 10)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, m.Targets[0].ID, res[0].Target.ID)
+		assert.Equal(t, m.ID, res[0].Mission.ID)
+		assert.Contains(t, res[0].Snippet, "<mark>harbour</mark>")
+
+		// The index follows the notes.
+		_, err = s.UpdateTargetNotes(ctx, m.Targets[0].ID, "Left the city")
+		require.NoError(t, err)
+
+		res, err = s.SearchTargets(ctx, "harbour", 10)
+		require.NoError(t, err)
+		assert.Empty(t, res)
+
+		// The notes are escaped in the snippet.
+		_, err = s.UpdateTargetNotes(ctx, m.Targets[0].ID, `<script>alert("harbour")</script>`)
+		require.NoError(t, err)
+
+		res, err = s.SearchTargets(ctx, "harbour", 10)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, "&lt;script&gt;alert(&#34;<mark>harbour</mark>&#34;)&lt;/script&gt;", res[0].Snippet)
+	})
+
+	t.Run("CreateMissionFromTemplate", func(t *testing.T) {
+		ctx := context.Background()
+
+		template, err := s.CreateMissionTemplate(ctx, models.CreateMissionTemplateRequest{
+			Name: "Harbour watch",
+			Targets: []models.TargetBlueprint{
+				{Name: "{{name}}", Country: "PL", Notes: "Watch the {{place}}"},
+				{Name: "Courier of {{name}}", Country: "PL", Notes: "Follow to the {{place}}"},
+			},
+		})
+		require.NoError(t, err)
+
+		m, err := s.CreateMissionFromTemplate(ctx, template.ID, models.CreateMissionFromTemplateRequest{
+			Variables: map[string]string{"name": "Zbigniew", "place": "old harbour"},
+		})
+		require.NoError(t, err)
+		require.Len(t, m.Targets, 2)
+		assert.Equal(t, "Zbigniew", m.Targets[0].Name)
+		assert.Equal(t, "Follow to the old harbour", m.Targets[1].Notes)
+
+		require.NoError(t, s.DeleteMissionTemplate(ctx, template.ID))
+
+		_, err = s.CreateMissionFromTemplate(ctx, template.ID, models.CreateMissionFromTemplateRequest{})
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("CloneMission", func(t *testing.T) {
+		ctx := context.Background()
+
+		m := newTestMission(t, s, "Ivan", "Olga")
+		_, err := s.CompleteTarget(ctx, m.Targets[0].ID)
+		require.NoError(t, err)
+
+		clone, err := s.CloneMission(ctx, m.ID, models.CloneMissionRequest{})
+		require.NoError(t, err)
+		require.Len(t, clone.Targets, 1)
+		assert.Equal(t, "Olga", clone.Targets[0].Name)
+		assert.Empty(t, clone.Targets[0].Notes)
+
+		clone, err = s.CloneMission(ctx, m.ID, models.CloneMissionRequest{Notes: true})
+		require.NoError(t, err)
+		assert.Equal(t, "Notes of Olga", clone.Targets[0].Notes)
+	})
+
+	t.Run("SplitMission", func(t *testing.T) {
+		ctx := context.Background()
+
+		m := newTestMission(t, s, "Ivan", "Olga", "Hans")
+		split, err := s.SplitMission(ctx, m.ID, models.SplitMissionRequest{Targets: []int32{m.Targets[1].ID, m.Targets[2].ID}})
+		require.NoError(t, err)
+		assert.NotEqual(t, m.ID, split.ID)
+		require.Len(t, split.Targets, 2)
+
+		got, err := s.GetMission(ctx, split.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Targets, 2)
+
+		got, err = s.GetMission(ctx, m.ID)
+		require.NoError(t, err)
+		require.Len(t, got.Targets, 1)
+		assert.Equal(t, "Ivan", got.Targets[0].Name)
+
+		// A failed split moves nothing.
+		_, err = s.SplitMission(ctx, split.ID, models.SplitMissionRequest{Targets: []int32{split.Targets[0].ID, m.Targets[0].ID}})
+		assert.Error(t, err)
+
+		got, err = s.GetMission(ctx, split.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Targets, 2)
+	})
+	t.Run("ImportCats", func(t *testing.T) {
+		ctx := context.Background()
+
+		rows := []models.CatImportRow{
+			{Line: 2, Cat: models.CreateCatRequest{Name: "Luna", Breed: "Abyssinian", YearsOfExperience: 2, Salary: 90}},
+			{Line: 3, Cat: models.CreateCatRequest{Name: "Kitty", Breed: "Sphinx", YearsOfExperience: 1, Salary: 80}},
+		}
+
+		res, err := s.ImportCats(ctx, rows, models.ImportOptions{Mode: models.ImportAllOrNothing})
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Created)
+
+		res, err = s.ImportCats(ctx, rows, models.ImportOptions{Mode: models.ImportBestEffort})
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Created)
+
+		cat, err := s.GetCat(ctx, res.Rows[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Luna", cat.Name)
+	})
+	t.Run("ImportMissions", func(t *testing.T) {
+		ctx := context.Background()
+
+		rows := []models.MissionImportRow{
+			{Line: 2, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+				{Name: "Ivan", Country: "UA", Notes: "Notes of Ivan"},
+				{Name: "Olga", Country: "PL", Notes: "Notes of Olga"},
+			}}},
+		}
+
+		res, err := s.ImportMissions(ctx, rows, models.ImportOptions{Mode: models.ImportAllOrNothing})
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Created)
+
+		m, err := s.GetMission(ctx, res.Rows[0].ID)
+		require.NoError(t, err)
+		assert.Len(t, m.Targets, 2)
+	})
+	t.Run("Snapshot", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		mission := newTestMission(t, s, "Ivan", "Olga")
+		_, err := s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, s.ExportSnapshot(ctx, &buf))
+		archive := buf.Bytes()
+
+		// The database of the suite isn't empty.
+		_, err = s.RestoreSnapshot(ctx, bytes.NewReader(archive))
+		assert.ErrorContains(t, err, "database is not empty")
+
+		// All the backends are at the same schema version, so the snapshot
+		// restores into an empty memory storage.
+		empty := memory.New()
+		restored := NewService(empty, empty, empty, empty, WithSnapshotStorage(empty))
+
+		res, err := restored.RestoreSnapshot(ctx, bytes.NewReader(archive))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, res.Missions, 1)
+
+		got, err := restored.GetMission(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cat.ID, got.Assignee)
+		assert.Equal(t, mission.Targets, got.Targets)
+
+		gotCat, err := restored.GetCat(ctx, cat.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cat, gotCat)
+
+		// New entities come after the restored ones.
+		created, err := restored.CreateMission(ctx, models.CreateMissionRequest{Targets: []models.CreateTargetRequest{{Name: "Anna", Country: "DE", Notes: "N"}}})
+		require.NoError(t, err)
+		assert.Greater(t, created.ID, mission.ID)
+
+		// The restored database exports the same entities.
+		buf.Reset()
+		require.NoError(t, restored.ExportSnapshot(ctx, &buf))
+		assert.Equal(t, res.Cats, strings.Count(string(archive), `"kind":"cat"`))
+		assert.Equal(t, res.Missions+1, strings.Count(buf.String(), `"kind":"mission"`))
+	})
+	t.Run("Reports", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		mission := newTestMission(t, s, "Ivan", "Olga")
+		_, err := s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+
+		var names []string
+		err = s.EachMissionTarget(ctx, func(r models.MissionTargetRow) error {
+			if r.MissionID == mission.ID {
+				assert.Equal(t, cat.ID, r.Assignee)
+				names = append(names, r.Name)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Ivan", "Olga"}, names)
+
+		var workload []models.CatWorkload
+		err = s.EachCatWorkload(ctx, func(w models.CatWorkload) error {
+			if w.CatID == cat.ID {
+				workload = append(workload, w)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, workload, 1)
+		assert.Equal(t, int64(1), workload[0].ActiveMissions)
+		assert.Equal(t, int64(2), workload[0].OpenTargets)
+	})
+	t.Run("Analytics", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		mission := newTestMission(t, s, "Ivan")
+		_, err := s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+		_, err = s.CompleteTarget(ctx, mission.Targets[0].ID)
+		require.NoError(t, err)
+		_, err = s.CompleteMission(ctx, mission.ID)
+		require.NoError(t, err)
+
+		rate, err := s.GetCompletionRate(ctx, "day", 1)
+		require.NoError(t, err)
+		require.Len(t, rate.Periods, 1)
+		assert.Positive(t, rate.Periods[0].Completed)
+
+		times, err := s.GetCompletionTimes(ctx)
+		require.NoError(t, err)
+		assert.Positive(t, times.CompletedMissions)
+		assert.Positive(t, times.CompletedTargets)
+
+		utilization, err := s.GetCatUtilization(ctx)
+		require.NoError(t, err)
+		i := slices.IndexFunc(utilization, func(u models.CatUtilization) bool { return u.CatID == cat.ID })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, int64(1), utilization[i].Missions)
+
+		// A cat keeps the time on the missions it was moved from or replaced on.
+		other := newTestMission(t, s, "Olga")
+		_, err = s.AssignCatToMission(ctx, other.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+		replacement := newTestCat(t, st)
+		_, err = s.AssignCatToMission(ctx, other.ID, models.AssignCatRequest{Assignee: replacement.ID})
+		require.NoError(t, err)
+
+		utilization, err = s.GetCatUtilization(ctx)
+		require.NoError(t, err)
+		i = slices.IndexFunc(utilization, func(u models.CatUtilization) bool { return u.CatID == cat.ID })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, int64(2), utilization[i].Missions)
+		i = slices.IndexFunc(utilization, func(u models.CatUtilization) bool { return u.CatID == replacement.ID })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, int64(1), utilization[i].Missions)
+
+		countries, err := s.GetTargetsPerCountry(ctx)
+		require.NoError(t, err)
+		assert.True(t, slices.ContainsFunc(countries, func(c models.CountryTargets) bool { return c.Country == "UA" && c.Completed > 0 }))
+	})
+	t.Run("Availability", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		mission := newTestMission(t, s, "Ivan")
+		now := time.Now().UTC().Truncate(time.Second)
+
+		period, err := s.CreateAvailabilityPeriod(ctx, cat.ID, models.AvailabilityPeriodRequest{
+			Kind:     "vacation",
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(time.Hour), period.EndsAt)
+
+		cats, err := s.GetAvailableCats(ctx, now)
+		require.NoError(t, err)
+		assert.False(t, slices.ContainsFunc(cats, func(c models.Cat) bool { return c.ID == cat.ID }))
+
+		cats, err = s.GetAvailableCats(ctx, now.Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, slices.ContainsFunc(cats, func(c models.Cat) bool { return c.ID == cat.ID }))
+
+		_, err = s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		assert.ErrorContains(t, err, "cat is unavailable: vacation from")
+
+		m, err := s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID, Override: true})
+		require.NoError(t, err)
+		assert.Equal(t, cat.ID, m.Assignee)
+		assert.Len(t, m.Warnings, 1)
+
+		// Deleting the cat deletes its periods.
+		require.NoError(t, s.DeleteCat(ctx, cat.ID))
+		err = s.DeleteAvailabilityPeriod(ctx, cat.ID, period.ID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+	t.Run("Availability_OpenEnded", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat, err := st.CreateCat(ctx, postgres.CreateCatParams{Name: "Tom", YearsOfExperience: 41, Breed: "Abyssinian", Salary: 100, SalaryCurrency: "USD"})
+		require.NoError(t, err)
+		mission := newTestMission(t, s, "Ivan")
+		now := time.Now().UTC().Truncate(time.Second)
+
+		_, err = s.CreateAvailabilityPeriod(ctx, cat.ID, models.AvailabilityPeriodRequest{
+			Kind:     "training",
+			StartsAt: now.Add(24 * time.Hour),
+			EndsAt:   now.Add(48 * time.Hour),
+		})
+		require.NoError(t, err)
+
+		// A cat with a future period is not idle.
+		preview, err := s.AutoAssignMission(ctx, mission.ID, models.AutoAssignRequest{MinYearsOfExperience: 41, DryRun: true})
+		require.NoError(t, err)
+		assert.False(t, slices.ContainsFunc(preview.Assignments, func(a models.AutoAssignment) bool { return a.Cat.ID == cat.ID }))
+
+		// Without until, the assignment conflicts with any future period.
+		_, err = s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		assert.ErrorContains(t, err, "cat is unavailable: training from")
+
+		until := now.Add(12 * time.Hour)
+		m, err := s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID, Until: &until})
+		require.NoError(t, err)
+		assert.Equal(t, cat.ID, m.Assignee)
+		assert.Empty(t, m.Warnings)
+	})
+	t.Run("Skills", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		mission := newTestMission(t, s, "Ivan")
+		skill, err := s.CreateSkill(ctx, models.CreateSkillRequest{Name: fmt.Sprintf("Lockpicking %d", time.Now().UnixNano())})
+		require.NoError(t, err)
+
+		until := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		cs, err := s.SetCatSkill(ctx, cat.ID, skill.ID, models.CatSkillRequest{Level: 4, CertifiedUntil: &until})
+		require.NoError(t, err)
+		assert.True(t, cs.Certified)
+
+		_, err = s.SetTargetSkill(ctx, mission.Targets[0].ID, skill.ID, models.TargetSkillRequest{MinLevel: 3, Certified: true})
+		require.NoError(t, err)
+
+		candidates, err := s.GetMissionCandidates(ctx, mission.ID)
+		require.NoError(t, err)
+		i := slices.IndexFunc(candidates, func(c models.Candidate) bool { return c.Cat.ID == cat.ID })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, 1.0, candidates[i].SkillMatch)
+		assert.Equal(t, []string{skill.Name}, candidates[i].MatchedSkills)
+
+		// An assigned cat is not a candidate anymore.
+		_, err = s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+		candidates, err = s.GetMissionCandidates(ctx, newTestMission(t, s, "Olga").ID)
+		require.NoError(t, err)
+		assert.False(t, slices.ContainsFunc(candidates, func(c models.Candidate) bool { return c.Cat.ID == cat.ID }))
+
+		// Deleting the skill deletes the skills of the cats.
+		require.NoError(t, s.DeleteSkill(ctx, skill.ID))
+		skills, err := s.GetCatSkills(ctx, cat.ID)
+		require.NoError(t, err)
+		assert.Empty(t, skills)
+	})
+	t.Run("AutoAssign", func(t *testing.T) {
+		ctx := context.Background()
+
+		mission := newTestMission(t, s, "Ivan")
+		c, err := st.CreateCat(ctx, postgres.CreateCatParams{Name: "Felix", YearsOfExperience: 40, Breed: "Abyssinian", Salary: 100, SalaryCurrency: "USD"})
+		require.NoError(t, err)
+
+		req := models.AutoAssignRequest{Strategy: "hungarian", MinYearsOfExperience: 40, DryRun: true}
+		preview, err := s.AutoAssignMission(ctx, mission.ID, req)
+		require.NoError(t, err)
+		require.Len(t, preview.Assignments, 1)
+		assert.GreaterOrEqual(t, preview.Assignments[0].Cat.YearsOfExperience, int32(40))
+
+		m, err := s.GetMission(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Zero(t, m.Assignee)
+
+		// A budget below the salaries leaves the mission unassigned.
+		budget := int64(0)
+		res, err := s.AutoAssignMission(ctx, mission.ID, models.AutoAssignRequest{MinYearsOfExperience: 40, SalaryBudget: &budget})
+		require.NoError(t, err)
+		assert.Empty(t, res.Assignments)
+		assert.Equal(t, []int32{mission.ID}, res.Unassigned)
+
+		req.DryRun = false
+		res, err = s.AutoAssignMission(ctx, mission.ID, req)
+		require.NoError(t, err)
+		require.Len(t, res.Assignments, 1)
+
+		m, err = s.GetMission(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, res.Assignments[0].Cat.ID, m.Assignee)
+
+		// The cat has an active mission now.
+		if res.Assignments[0].Cat.ID == c.ID {
+			other, err := s.AutoAssignMission(ctx, newTestMission(t, s, "Olga").ID, req)
+			require.NoError(t, err)
+			assert.False(t, slices.ContainsFunc(other.Assignments, func(a models.AutoAssignment) bool { return a.Cat.ID == c.ID }))
+		}
+	})
+	t.Run("Costs", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		mission := newTestMission(t, s, "Ivan")
+
+		_, err := s.SetMissionBudget(ctx, mission.ID, models.MissionBudgetRequest{Amount: 10000, Currency: "eur"})
+		require.NoError(t, err)
+
+		expense, err := s.CreateMissionExpense(ctx, mission.ID, models.CreateExpenseRequest{
+			Category: "travel",
+			Amount:   6000,
+			Currency: "EUR",
+			Receipt:  &models.Receipt{Number: "A-17"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, expense.Warnings)
+		require.NotNil(t, expense.Receipt)
+		assert.Equal(t, "A-17", expense.Receipt.Number)
+
+		// The default policy warns about the expenses over budget.
+		over, err := s.CreateMissionExpense(ctx, mission.ID, models.CreateExpenseRequest{Category: "informants", Amount: 5000, Currency: "EUR"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"costs exceed the budget of the mission by 10.00 EUR"}, over.Warnings)
+
+		_, err = s.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+
+		// The salary of the cat is in USD.
+		_, err = s.GetMissionCosts(ctx, mission.ID)
+		assert.EqualError(t, err, "no exchange rate for EUR")
+		_, err = s.SetExchangeRate(ctx, "EUR", models.SetExchangeRateRequest{Rate: 1.1})
+		require.NoError(t, err)
+
+		costs, err := s.GetMissionCosts(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", costs.Currency)
+		assert.Equal(t, int64(11000), costs.ExpensesTotal)
+		require.NotNil(t, costs.Salary)
+		assert.Equal(t, cat.ID, costs.Salary.CatID)
+		assert.True(t, costs.OverBudget)
+
+		require.NoError(t, s.DeleteMissionExpense(ctx, mission.ID, over.ID))
+		expenses, err := s.GetMissionExpenses(ctx, mission.ID)
+		require.NoError(t, err)
+		require.Len(t, expenses, 1)
+		assert.Equal(t, expense.ID, expenses[0].ID)
+	})
+	t.Run("MoveTarget", func(t *testing.T) {
+		ctx := context.Background()
+
+		from := newTestMission(t, s, "Ivan", "Olga")
+		to := newTestMission(t, s, "Hans")
+
+		res, err := s.MoveTarget(ctx, from.ID, from.Targets[1].ID, models.MoveTargetRequest{Mission: to.ID})
+		require.NoError(t, err)
+		assert.Equal(t, to.ID, res.ID)
+		require.Len(t, res.Targets, 2)
+		assert.Equal(t, "Notes of Olga", res.Targets[1].Notes)
+
+		got, err := s.GetMission(ctx, from.ID)
+		require.NoError(t, err)
+		assert.Len(t, got.Targets, 1)
+
+		moves, err := s.GetTargetMoves(ctx, from.Targets[1].ID)
+		require.NoError(t, err)
+		require.Len(t, moves, 1)
+		assert.Equal(t, from.ID, moves[0].FromMission)
+		assert.Equal(t, to.ID, moves[0].ToMission)
+
+		// The source keeps at least one target.
+		_, err = s.MoveTarget(ctx, from.ID, from.Targets[0].ID, models.MoveTargetRequest{Mission: to.ID})
+		assert.Error(t, err)
+
+		// The target is not in the mission anymore.
+		_, err = s.MoveTarget(ctx, from.ID, from.Targets[1].ID, models.MoveTargetRequest{Mission: to.ID})
+		assert.ErrorIs(t, err, models.ErrNotFound)
+
+		// Can't move into a completed mission.
+		done := newTestMission(t, s, "Anna")
+		_, err = s.CompleteTarget(ctx, done.Targets[0].ID)
+		require.NoError(t, err)
+		_, err = s.CompleteMission(ctx, done.ID)
+		require.NoError(t, err)
+
+		_, err = s.MoveTarget(ctx, to.ID, to.Targets[0].ID, models.MoveTargetRequest{Mission: done.ID})
+		assert.Error(t, err)
+
+		moves, err = s.GetTargetMoves(ctx, to.Targets[0].ID)
+		require.NoError(t, err)
+		assert.Empty(t, moves)
+	})
+	t.Run("Locations", func(t *testing.T) {
+		ctx := context.Background()
+
+		lat, lng := -54.8019, -68.3030
+		created, err := s.CreateMission(ctx, models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Ivan", Country: "AR", Notes: "Notes of Ivan", Latitude: &lat, Longitude: &lng, Address: "Ushuaia"},
+			{Name: "Olga", Country: "AR", Notes: "Notes of Olga"},
+		}})
+		require.NoError(t, err)
+		m, err := s.GetMission(ctx, created.ID)
+		require.NoError(t, err)
+		require.Len(t, m.Targets, 2)
+		assert.Equal(t, &lat, m.Targets[0].Latitude)
+		assert.Nil(t, m.Targets[1].Latitude)
+
+		// Other tests may have left targets around, so only this mission is checked.
+		nearby := func(radius float64) []models.NearbyTarget {
+			res, err := s.GetTargetsNear(ctx, -54.8, -68.3, radius, 100)
+			require.NoError(t, err)
+			return slices.DeleteFunc(res, func(n models.NearbyTarget) bool { return n.MissionID != m.ID })
+		}
+		res := nearby(1)
+		require.Len(t, res, 1)
+		assert.Equal(t, m.Targets[0].ID, res[0].Target.ID)
+		assert.Less(t, res[0].DistanceKm, 1.0)
+
+		olgaLat, olgaLng := -54.9, -68.3
+		_, err = s.UpdateTargetLocation(ctx, m.Targets[1].ID, models.TargetLocationRequest{Latitude: &olgaLat, Longitude: &olgaLng})
+		require.NoError(t, err)
+		res = nearby(20)
+		require.Len(t, res, 2)
+		assert.Equal(t, m.Targets[1].ID, res[1].Target.ID)
+
+		clone, err := s.CloneMission(ctx, m.ID, models.CloneMissionRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "Ushuaia", clone.Targets[0].Address)
+		assert.Equal(t, &olgaLat, clone.Targets[1].Latitude)
+
+		_, err = s.CompleteTarget(ctx, m.Targets[0].ID)
+		require.NoError(t, err)
+		_, err = s.UpdateTargetLocation(ctx, m.Targets[0].ID, models.TargetLocationRequest{})
+		assert.EqualError(t, err, "Can't change location of a completed target")
+	})
+
+	t.Run("Subjects", func(t *testing.T) {
+		ctx := context.Background()
+
+		created, err := s.CreateMission(ctx, models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Zbigniew Brzeczyszczykiewicz", Country: "PL", Notes: "Notes of Zbigniew"},
+		}})
+		require.NoError(t, err)
+		require.Len(t, created.Targets, 1)
+		subjectID := created.Targets[0].SubjectID
+		require.NotZero(t, subjectID)
+
+		similar, err := s.CreateMission(ctx, models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Zbigniew Brzeczyszczykiewic", Country: "PL", Notes: "Notes of Zbigniew"},
+		}})
+		require.NoError(t, err)
+		require.NotEmpty(t, similar.Targets[0].Suggestions)
+		assert.Equal(t, subjectID, similar.Targets[0].Suggestions[0].Subject.ID)
+		assert.NotEqual(t, subjectID, similar.Targets[0].SubjectID)
+
+		added, err := s.AddTarget(ctx, similar.ID, models.CreateTargetRequest{Name: "Z. Brzeczyszczykiewicz", Country: "PL", Notes: "Notes", SubjectID: &subjectID})
+		require.NoError(t, err)
+		assert.Equal(t, subjectID, added.Targets[1].SubjectID)
+		assert.Empty(t, added.Targets[1].Suggestions)
+
+		_, err = s.UpdateTargetNotes(ctx, created.Targets[0].ID, "Moved to Krakow")
+		require.NoError(t, err)
+
+		subject, err := s.GetSubject(ctx, subjectID)
+		require.NoError(t, err)
+		require.Len(t, subject.Missions, 2)
+		assert.Equal(t, created.ID, subject.Missions[0].ID)
+		require.Len(t, subject.NotesHistory, 1)
+		assert.Equal(t, "Notes of Zbigniew", subject.NotesHistory[0].PreviousNotes)
+		assert.Equal(t, created.ID, subject.NotesHistory[0].MissionID)
+
+		merged, err := s.MergeSubjects(ctx, subjectID, models.MergeSubjectsRequest{Subjects: []int32{similar.Targets[0].SubjectID}})
+		require.NoError(t, err)
+		require.Len(t, merged.Missions, 2)
+		assert.Len(t, merged.Missions[1].Targets, 2)
+		_, err = s.GetSubject(ctx, similar.Targets[0].SubjectID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+
+		// The merge is rolled back if a subject is missing.
+		_, err = s.MergeSubjects(ctx, subjectID, models.MergeSubjectsRequest{Subjects: []int32{similar.Targets[0].SubjectID}})
+		assert.EqualError(t, err, fmt.Sprintf("subject %d not found", similar.Targets[0].SubjectID))
+	})
+
+	t.Run("Attachments", func(t *testing.T) {
+		ctx := context.Background()
+
+		m := newTestMission(t, s, "Ivan", "Olga")
+		ivan, olga := m.Targets[0].ID, m.Targets[1].ID
+
+		upload := func(targetId int32, filename, content string) (models.Attachment, error) {
+			return s.UploadAttachment(ctx, targetId, models.AttachmentUpload{Filename: filename, Size: int64(len(content)), Content: strings.NewReader(content)})
+		}
+
+		photo, err := upload(ivan, "photo.png", "\x89PNG\r\n\x1a\nimage data")
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", photo.ContentType)
+		assert.Equal(t, ivan, photo.TargetID)
+		notes, err := upload(ivan, `C:\field\notes.txt`, "Seen at the square")
+		require.NoError(t, err)
+		assert.Equal(t, "notes.txt", notes.Filename)
+		assert.Equal(t, "text/plain; charset=utf-8", notes.ContentType)
+
+		_, err = upload(ivan, "big.txt", strings.Repeat("a", 1<<10+1))
+		assert.EqualError(t, err, "attachment is too large (max 1024 bytes)")
+		_, err = upload(ivan, "page.html", "<html><body>Hi</body></html>")
+		assert.EqualError(t, err, "unsupported content type text/html; charset=utf-8")
+
+		list, err := s.GetTargetAttachments(ctx, ivan)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, photo.ID, list[0].ID)
+
+		got, content, err := s.GetAttachment(ctx, ivan, notes.ID)
+		require.NoError(t, err)
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		require.NoError(t, content.Close())
+		assert.Equal(t, "Seen at the square", string(data))
+		assert.Equal(t, notes, got)
+
+		_, _, err = s.GetAttachment(ctx, olga, notes.ID)
+		assert.ErrorIs(t, err, models.ErrNotFound, "the attachment belongs to another target")
+
+		row, err := st.GetAttachment(ctx, notes.ID)
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteAttachment(ctx, ivan, notes.ID))
+		_, _, err = s.GetAttachment(ctx, ivan, notes.ID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+		_, err = blobStore.Get(ctx, row.BlobKey)
+		assert.ErrorIs(t, err, blobs.ErrNotFound, "the content is deleted")
+
+		// Completed targets are frozen, but their attachments can still be read.
+		_, err = s.CompleteTarget(ctx, ivan)
+		require.NoError(t, err)
+		_, err = upload(ivan, "late.txt", "Too late")
+		assert.EqualError(t, err, "Can't change attachments of a completed target")
+		assert.EqualError(t, s.DeleteAttachment(ctx, ivan, photo.ID), "Can't change attachments of a completed target")
+		_, content, err = s.GetAttachment(ctx, ivan, photo.ID)
+		require.NoError(t, err)
+		content.Close()
+
+		// Deleting the mission deletes the attachments and their content.
+		report, err := upload(olga, "report.pdf", "%PDF-1.4 report")
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", report.ContentType)
+		row, err = st.GetAttachment(ctx, report.ID)
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteMission(ctx, m.ID))
+		_, err = st.GetAttachment(ctx, report.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = blobStore.Get(ctx, row.BlobKey)
+		assert.ErrorIs(t, err, blobs.ErrNotFound)
+	})
+
+	t.Run("Notes", func(t *testing.T) {
+		ctx := context.Background()
+
+		cat, err := st.CreateCat(ctx, postgres.CreateCatParams{Name: "Garfield", YearsOfExperience: 5, Breed: "Persian", Salary: 100, SalaryCurrency: "USD"})
+		require.NoError(t, err)
+
+		m, err := s.CreateMission(ctx, models.CreateMissionRequest{Targets: []models.CreateTargetRequest{
+			{Name: "Ivan", Country: "UA", Notes: "Notes of Ivan"},
+			{Name: "Olga", Country: "PL", Notes: "Notes of Olga"},
+		}})
+		require.NoError(t, err)
+
+		// Longer than the former column, mentioning a missing cat too.
+		const missingCatID = 999999
+		notes := fmt.Sprintf("Seen with @cat:%d near @target:%d and @cat:%d.\n\n%s", cat.ID, m.Targets[1].ID, missingCatID, strings.Repeat("- Watched the square\n", 20))
+		updated, err := s.UpdateTargetNotes(ctx, m.Targets[0].ID, notes)
+		require.NoError(t, err)
+
+		res, err := s.RenderNotes(ctx, []models.Target{updated})
+		require.NoError(t, err)
+		assert.Contains(t, res[0].NotesHTML, fmt.Sprintf(`<p>Seen with <a href="/cats/%d" class="mention" rel="nofollow">@Garfield</a> near <a href="/missions/%d" class="mention" rel="nofollow">@Olga</a> and @cat:%d.</p>`, cat.ID, m.ID, missingCatID))
+		assert.Equal(t, 20, strings.Count(res[0].NotesHTML, "<li>Watched the square</li>"))
+	})
+}
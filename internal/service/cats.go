@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/gofiber/fiber/v3/client"
 	"github.com/jackc/pgx/v5"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
 	"github.com/rsmanito/developstoday-test-assessment/internal/models"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
 )
@@ -33,9 +35,12 @@ func (s Service) GetAllCats(ctx context.Context) ([]models.Cat, error) {
 		}
 	}
 
+	rates := s.reportingRates(ctx, log)
+
 	mappedCats := make([]models.Cat, len(cats))
 	for i, cat := range cats {
 		mappedCats[i] = sqlcCatToModel(cat)
+		mappedCats[i].ReportingSalary = toReporting(rates, cat.Salary, cat.SalaryCurrency)
 	}
 
 	log.Debug("Got cats", "cats", mappedCats)
@@ -50,36 +55,44 @@ func (s Service) CreateCat(ctx context.Context, req models.CreateCatRequest) (mo
 	)
 	log.Debug("Creating a cat")
 
-	if req.YearsOfExperience < 0 {
-		log.Info("Years of experience is less than 0")
-		return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "years of experience must be greater than or equal to 0")
+	r := s.currentRules()
+	if req.YearsOfExperience < r.Cats.MinYearsOfExperience {
+		log.Info("Years of experience is less than the minimum")
+		return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("years of experience must be greater than or equal to %d", r.Cats.MinYearsOfExperience))
+	}
+	salaryCurrency, err := parseSalaryCurrency(req.SalaryCurrency)
+	if err != nil {
+		log.Info("Unknown salary currency")
+		return models.Cat{}, err
+	}
+	if salaryCurrency == "" {
+		salaryCurrency = s.reportingCurrency
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	if err := s.checkMinSalary(ctx, log, r.Cats.MinSalary, req.Salary, salaryCurrency); err != nil {
+		return models.Cat{}, err
+	}
+
 	// Validate breed
 	breedReqCtx, breedCancel := context.WithTimeout(ctx, 2*time.Second)
 	defer breedCancel()
 
-	resChan := make(chan bool, 1)
-	errChan := make(chan error, 1)
-
-	go breedExists(req.Breed, resChan, errChan)
-
-	select {
-	case res := <-resChan:
-		if !res {
-			log.Warn("Unknown breed")
-			return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "Unknown breed")
+	breeds, err := s.breedCatalog.Breeds(breedReqCtx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Debug("Validate cat breed timeout")
+		} else {
+			log.Error("Failed to validate cat breed", "err", err)
 		}
-	case err := <-errChan:
-		log.Error("Failed to validate cat breed", "err", err)
-		return models.Cat{}, errors.New("failed to create a cat")
-	case <-breedReqCtx.Done():
-		log.Debug("Validate cat breed timeout")
 		return models.Cat{}, errors.New("failed to create a cat")
 	}
+	if !breedSet(breeds)[strings.ToLower(req.Breed)] {
+		log.Warn("Unknown breed")
+		return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "Unknown breed")
+	}
 
 	// Create the cat in the database
 	dbCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
@@ -89,7 +102,8 @@ func (s Service) CreateCat(ctx context.Context, req models.CreateCatRequest) (mo
 		Name:              req.Name,
 		Breed:             req.Breed,
 		YearsOfExperience: req.YearsOfExperience,
-		Salary:            req.Salary,
+		Salary:            currency.MinorUnits(int64(req.Salary), salaryCurrency),
+		SalaryCurrency:    salaryCurrency,
 	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -138,17 +152,39 @@ func (s Service) UpdateCatSalary(ctx context.Context, req models.UpdateCatSalary
 
 	log.Debug("Updating cat salary")
 
-	if req.Salary < 0 {
-		log.Info("Salary is less than 0")
-		return models.Cat{}, models.NewError(http.StatusUnprocessableEntity, "salary must be greater than or equal to 0")
+	salaryCurrency, err := parseSalaryCurrency(req.SalaryCurrency)
+	if err != nil {
+		log.Info("Unknown salary currency")
+		return models.Cat{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
+	// An empty currency keeps the currency of the cat.
+	if salaryCurrency == "" {
+		cat, err := s.catStorage.GetCat(ctx, id)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.Cat{}, models.ErrTimeoutExceeded
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				return models.Cat{}, models.ErrNotFound
+			}
+			log.Error("Failed to get cat", "err", err)
+			return models.Cat{}, errors.New("failed to update salary")
+		}
+		salaryCurrency = cat.SalaryCurrency
+	}
+
+	if err := s.checkMinSalary(ctx, log, s.currentRules().Cats.MinSalary, req.Salary, salaryCurrency); err != nil {
+		return models.Cat{}, err
+	}
+
 	res, err := s.catStorage.UpdateCatSalary(ctx, postgres.UpdateCatSalaryParams{
-		ID:     id,
-		Salary: req.Salary,
+		ID:             id,
+		Salary:         currency.MinorUnits(int64(req.Salary), salaryCurrency),
+		SalaryCurrency: salaryCurrency,
 	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -200,18 +236,74 @@ func sqlcCatToModel(c postgres.Cat) models.Cat {
 		Name:              c.Name,
 		Breed:             c.Breed,
 		YearsOfExperience: c.YearsOfExperience,
-		Salary:            c.Salary,
+		Salary:            int32(currency.MajorUnits(c.Salary, c.SalaryCurrency)),
+		SalaryCurrency:    c.SalaryCurrency,
+	}
+}
+
+// checkMinSalary returns an error if the salary, in whole units of its
+// currency, is less than the minimum salary, in the reporting currency.
+func (s Service) checkMinSalary(ctx context.Context, log *slog.Logger, minSalary, salary int32, code string) error {
+	rates := currency.NewRates(s.reportingCurrency, nil)
+	if code != s.reportingCurrency && minSalary > 0 {
+		var err error
+		if rates, err = s.exchangeRates(ctx); err != nil {
+			return ratesError(log, err)
+		}
 	}
+
+	if err := minSalaryError(rates, minSalary, salary, code); err != nil {
+		log.Info("Salary is less than the minimum")
+		return err
+	}
+	return nil
+}
+
+// minSalaryError returns an error if the salary, in whole units of its
+// currency, converted to the base currency of the rates is less than the
+// minimum salary.
+func minSalaryError(rates currency.Rates, minSalary, salary int32, code string) error {
+	base := rates.Base()
+	if code == base || minSalary <= 0 {
+		if salary < minSalary {
+			return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("salary must be greater than or equal to %d", minSalary))
+		}
+		return nil
+	}
+
+	converted, err := rates.Convert(currency.MinorUnits(int64(salary), code), code, base)
+	if err != nil {
+		return conversionError(err)
+	}
+	if converted >= currency.MinorUnits(int64(minSalary), base) {
+		return nil
+	}
+	return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("salary must be greater than or equal to %d %s", minSalary, base))
 }
 
-func breedExists(breedName string, resChan chan<- bool, errChan chan<- error) {
+// parseSalaryCurrency returns the code of the currency of a salary, empty
+// for an empty code.
+func parseSalaryCurrency(code string) (string, error) {
+	if code == "" {
+		return "", nil
+	}
+	code, err := currency.Parse(code)
+	if err != nil {
+		return "", models.NewError(http.StatusUnprocessableEntity, "salary_currency must be an ISO 4217 code")
+	}
+	return code, nil
+}
+
+// theCatAPI is the BreedCatalog of https://thecatapi.com.
+type theCatAPI struct{}
+
+func (theCatAPI) Breeds(ctx context.Context) ([]string, error) {
 	cc := client.New()
 
 	// Get cat breeds.
-	resp, err := cc.Get("https://api.thecatapi.com/v1/breeds")
+	resp, err := cc.R().SetContext(ctx).Get("https://api.thecatapi.com/v1/breeds")
 	if err != nil {
-		errChan <- err
-		return
+		return nil, err
 	}
 
 	type Breed struct {
@@ -220,16 +312,23 @@ func breedExists(breedName string, resChan chan<- bool, errChan chan<- error) {
 
 	var breeds []Breed
 	if err := json.Unmarshal(resp.Body(), &breeds); err != nil {
-		errChan <- err
-		return
+		return nil, err
 	}
 
-	// Check if the breed exists in the list.
-	for _, breed := range breeds {
-		if strings.EqualFold(breed.Name, breedName) {
-			resChan <- true
-			return
-		}
+	names := make([]string, len(breeds))
+	for i, breed := range breeds {
+		names[i] = breed.Name
 	}
-	resChan <- false
+
+	return names, nil
+}
+
+// breedSet returns the lowercase breeds, as breeds are matched case-insensitively.
+func breedSet(breeds []string) map[string]bool {
+	set := make(map[string]bool, len(breeds))
+	for _, b := range breeds {
+		set[strings.ToLower(b)] = true
+	}
+
+	return set
 }
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// The lengths of the columns of the expenses.
+	maxExpenseDescription = 256
+	maxReceiptNumber      = 64
+	maxReceiptVendor      = 128
+	maxReceiptURL         = 2048
+)
+
+// expenseCategories are the categories of the expenses.
+var expenseCategories = []string{"travel", "equipment", "informants"}
+
+// SetMissionBudget sets the budget of the mission. The costs of the mission
+// are converted to the currency of the budget.
+func (s Service) SetMissionBudget(ctx context.Context, missionID int32, req models.MissionBudgetRequest) (models.MissionBudget, error) {
+	log := slog.With(
+		slog.String("op", "service.SetMissionBudget"),
+		slog.Any("missionId", missionID),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Setting mission budget")
+
+	if req.Amount <= 0 {
+		return models.MissionBudget{}, models.NewError(http.StatusUnprocessableEntity, "amount must be positive")
+	}
+	code, err := currency.Parse(req.Currency)
+	if err != nil {
+		return models.MissionBudget{}, models.NewError(http.StatusUnprocessableEntity, "currency must be an ISO 4217 code")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := s.getMission(ctx, log, missionID); err != nil {
+		return models.MissionBudget{}, err
+	}
+
+	res, err := s.costStorage.SetMissionBudget(ctx, postgres.SetMissionBudgetParams{
+		Mission:  missionID,
+		Amount:   req.Amount,
+		Currency: code,
+	})
+	if err != nil {
+		return models.MissionBudget{}, costsError(log, err)
+	}
+
+	log.Debug("Set mission budget")
+
+	return models.MissionBudget{MissionID: res.Mission, Amount: res.Amount, Currency: res.Currency}, nil
+}
+
+func (s Service) DeleteMissionBudget(ctx context.Context, missionID int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteMissionBudget"),
+		slog.Any("missionId", missionID),
+	)
+
+	log.Debug("Deleting mission budget")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.costStorage.DeleteMissionBudget(ctx, missionID)
+	if err != nil {
+		return costsError(log, err)
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	log.Debug("Deleted mission budget")
+
+	return nil
+}
+
+func (s Service) GetMissionExpenses(ctx context.Context, missionID int32) ([]models.Expense, error) {
+	log := slog.With(
+		slog.String("op", "service.GetMissionExpenses"),
+		slog.Any("missionId", missionID),
+	)
+
+	log.Debug("Fetching mission expenses")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := s.getMission(ctx, log, missionID); err != nil {
+		return make([]models.Expense, 0), err
+	}
+
+	res, err := s.costStorage.GetMissionExpenses(ctx, missionID)
+	if err != nil {
+		return make([]models.Expense, 0), costsError(log, err)
+	}
+
+	expenses := make([]models.Expense, len(res))
+	for i, e := range res {
+		expenses[i] = sqlcExpenseToModel(e)
+	}
+
+	return expenses, nil
+}
+
+// CreateMissionExpense records an expense of the mission, in a currency with
+// an exchange rate to the currency of the costs of the mission.
+//
+// The expenses over the budget of the mission are blocked or created with a
+// warning, by the over budget policy of the rules.
+func (s Service) CreateMissionExpense(ctx context.Context, missionID int32, req models.CreateExpenseRequest) (models.Expense, error) {
+	log := slog.With(
+		slog.String("op", "service.CreateMissionExpense"),
+		slog.Any("missionId", missionID),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Creating mission expense")
+
+	if err := validateExpense(&req); err != nil {
+		log.Info("Invalid expense")
+		return models.Expense{}, err
+	}
+	incurredAt := time.Now().UTC()
+	if req.IncurredAt != nil {
+		incurredAt = *req.IncurredAt
+	}
+	var receipt models.Receipt
+	if req.Receipt != nil {
+		receipt = *req.Receipt
+	}
+
+	r := s.currentRules()
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	mission, err := s.getMission(ctx, log, missionID)
+	if err != nil {
+		return models.Expense{}, err
+	}
+
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return models.Expense{}, costsError(log, err)
+	}
+	costs, err := s.missionCosts(ctx, log, r, rates, mission)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	amount, err := rates.Convert(req.Amount, req.Currency, costs.Currency)
+	if err != nil {
+		log.Info("Expense without exchange rate")
+		return models.Expense{}, conversionError(err)
+	}
+
+	var warnings []string
+	if costs.Budget != nil && costs.Total+amount > *costs.Budget {
+		over := "costs exceed the budget of the mission by " + currency.Format(costs.Total+amount-*costs.Budget, costs.Currency)
+		if r.Missions.OverBudget == rules.OverBudgetBlock {
+			log.Info("Expense over budget", "over", over)
+			return models.Expense{}, models.NewError(http.StatusUnprocessableEntity, over)
+		}
+		log.Info("Creating expense over budget", "over", over)
+		warnings = append(warnings, over)
+	}
+
+	res, err := s.costStorage.CreateMissionExpense(ctx, postgres.CreateMissionExpenseParams{
+		Mission:       missionID,
+		Category:      req.Category,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Description:   req.Description,
+		ReceiptNumber: receipt.Number,
+		ReceiptVendor: receipt.Vendor,
+		ReceiptUrl:    receipt.URL,
+		IncurredAt:    pgtype.Timestamptz{Time: incurredAt, Valid: true},
+	})
+	if err != nil {
+		return models.Expense{}, costsError(log, err)
+	}
+
+	log.Debug("Created mission expense", "id", res.ID)
+
+	expense := sqlcExpenseToModel(res)
+	expense.Warnings = warnings
+	return expense, nil
+}
+
+func (s Service) DeleteMissionExpense(ctx context.Context, missionID, id int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteMissionExpense"),
+		slog.Any("missionId", missionID),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Deleting mission expense")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	expense, err := s.costStorage.GetMissionExpense(ctx, id)
+	if err != nil {
+		return costsError(log, err)
+	}
+	if expense.Mission != missionID {
+		log.Debug("Expense of another mission", "mission", expense.Mission)
+		return models.ErrNotFound
+	}
+
+	rows, err := s.costStorage.DeleteMissionExpense(ctx, id)
+	if err != nil {
+		return costsError(log, err)
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	log.Debug("Deleted mission expense")
+
+	return nil
+}
+
+// GetMissionCosts returns the breakdown of the costs of the mission.
+func (s Service) GetMissionCosts(ctx context.Context, missionID int32) (models.MissionCosts, error) {
+	log := slog.With(
+		slog.String("op", "service.GetMissionCosts"),
+		slog.Any("missionId", missionID),
+	)
+
+	log.Debug("Computing mission costs")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	mission, err := s.getMission(ctx, log, missionID)
+	if err != nil {
+		return models.MissionCosts{}, err
+	}
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return models.MissionCosts{}, costsError(log, err)
+	}
+
+	return s.missionCosts(ctx, log, s.currentRules(), rates, mission)
+}
+
+// missionCosts sums the costs of the mission: its expenses and the salary of
+// the assigned cat from the assignment until the completion or now.
+//
+// The costs are in the currency of the budget, or the reporting currency
+// without one. A cost in a currency without an exchange rate fails.
+func (s Service) missionCosts(ctx context.Context, log *slog.Logger, r rules.Rules, rates currency.Rates, mission postgres.Mission) (models.MissionCosts, error) {
+	costs := models.MissionCosts{
+		MissionID: mission.ID,
+		Currency:  s.reportingCurrency,
+		Expenses:  make(map[string]int64, len(expenseCategories)),
+	}
+	for _, c := range expenseCategories {
+		costs.Expenses[c] = 0
+	}
+
+	budget, err := s.costStorage.GetMissionBudget(ctx, mission.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return models.MissionCosts{}, costsError(log, err)
+	}
+	if err == nil {
+		costs.Budget = &budget.Amount
+		costs.Currency = budget.Currency
+	}
+
+	expenses, err := s.costStorage.GetMissionExpenses(ctx, mission.ID)
+	if err != nil {
+		return models.MissionCosts{}, costsError(log, err)
+	}
+	for _, e := range expenses {
+		amount, err := rates.Convert(e.Amount, e.Currency, costs.Currency)
+		if err != nil {
+			return models.MissionCosts{}, conversionError(err)
+		}
+		costs.Expenses[e.Category] += amount
+		costs.ExpensesTotal += amount
+	}
+	costs.Total = costs.ExpensesTotal
+
+	if mission.Assignee.Valid && mission.AssignedAt.Valid {
+		cat, err := s.catStorage.GetCat(ctx, mission.Assignee.Int32)
+		if err != nil {
+			return models.MissionCosts{}, costsError(log, err)
+		}
+
+		end := time.Now()
+		if mission.CompletedAt.Valid {
+			end = mission.CompletedAt.Time
+		}
+		costs.Salary = proratedSalary(cat, mission.AssignedAt.Time, end, r.Missions.SalaryPeriodDays)
+		costs.Salary.Amount, err = rates.Convert(costs.Salary.Amount, cat.SalaryCurrency, costs.Currency)
+		if err != nil {
+			return models.MissionCosts{}, conversionError(err)
+		}
+		costs.Total += costs.Salary.Amount
+	}
+
+	if costs.Budget != nil {
+		remaining := *costs.Budget - costs.Total
+		costs.Remaining = &remaining
+		costs.OverBudget = remaining < 0
+	}
+
+	return costs, nil
+}
+
+// proratedSalary returns the salary of the cat for the days from since until
+// the end, of a salary paying periodDays days, in the currency of the salary.
+func proratedSalary(cat postgres.Cat, since, end time.Time, periodDays int) *models.SalaryCost {
+	days := max(end.Sub(since).Hours()/24, 0)
+
+	return &models.SalaryCost{
+		CatID:          cat.ID,
+		Salary:         int32(currency.MajorUnits(cat.Salary, cat.SalaryCurrency)),
+		SalaryCurrency: cat.SalaryCurrency,
+		Days:           math.Round(days*100) / 100,
+		Amount:         int64(math.Round(float64(cat.Salary) * days / float64(periodDays))),
+	}
+}
+
+func (s Service) getMission(ctx context.Context, log *slog.Logger, id int32) (postgres.Mission, error) {
+	mission, err := s.missionStorage.GetMission(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return postgres.Mission{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Mission not found")
+			return postgres.Mission{}, models.ErrNotFound
+		}
+		log.Error("Failed to get mission", "err", err)
+		return postgres.Mission{}, errors.New("failed to get mission")
+	}
+
+	return mission, nil
+}
+
+// costsError maps a failed query of the costs to the error of the service.
+func costsError(log *slog.Logger, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrTimeoutExceeded
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	log.Error("Failed to query costs", "err", err)
+	return errors.New("failed to query costs")
+}
+
+// validateExpense validates the expense and sets the code of its currency.
+func validateExpense(req *models.CreateExpenseRequest) error {
+	if !slices.Contains(expenseCategories, req.Category) {
+		return models.NewError(http.StatusUnprocessableEntity, "category must be one of "+strings.Join(expenseCategories, ", "))
+	}
+	if req.Amount <= 0 {
+		return models.NewError(http.StatusUnprocessableEntity, "amount must be positive")
+	}
+	code, err := currency.Parse(req.Currency)
+	if err != nil {
+		return models.NewError(http.StatusUnprocessableEntity, "currency must be an ISO 4217 code")
+	}
+	req.Currency = code
+	if len(req.Description) > maxExpenseDescription {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("description must be at most %d characters", maxExpenseDescription))
+	}
+	if req.Receipt == nil {
+		return nil
+	}
+	if len(req.Receipt.Number) > maxReceiptNumber {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("receipt number must be at most %d characters", maxReceiptNumber))
+	}
+	if len(req.Receipt.Vendor) > maxReceiptVendor {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("receipt vendor must be at most %d characters", maxReceiptVendor))
+	}
+	if req.Receipt.URL != "" {
+		u, err := url.Parse(req.Receipt.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(req.Receipt.URL) > maxReceiptURL {
+			return models.NewError(http.StatusUnprocessableEntity, "receipt url must be an http or https URL")
+		}
+	}
+
+	return nil
+}
+
+func sqlcExpenseToModel(e postgres.MissionExpense) models.Expense {
+	expense := models.Expense{
+		ID:          e.ID,
+		MissionID:   e.Mission,
+		Category:    e.Category,
+		Amount:      e.Amount,
+		Currency:    e.Currency,
+		Description: e.Description,
+		IncurredAt:  e.IncurredAt.Time.UTC(),
+	}
+	if e.ReceiptNumber != "" || e.ReceiptVendor != "" || e.ReceiptUrl != "" {
+		expense.Receipt = &models.Receipt{Number: e.ReceiptNumber, Vendor: e.ReceiptVendor, URL: e.ReceiptUrl}
+	}
+
+	return expense
+}
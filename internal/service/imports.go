@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// maxImportRows is the maximum number of rows of a bulk import.
+const maxImportRows = 1000
+
+// importValidator checks the rows like the server checks the request bodies.
+var importValidator = &models.StructValidator{Validator: validator.New()}
+
+// ImportCats validates the cats and creates them in a single transaction.
+//
+// The breeds of all the rows are checked against a single lookup of the
+// breed catalog.
+func (s Service) ImportCats(ctx context.Context, rows []models.CatImportRow, opts models.ImportOptions) (models.ImportResult, error) {
+	log := slog.With(
+		slog.String("op", "service.ImportCats"),
+		slog.Int("rows", len(rows)),
+		slog.Any("opts", opts),
+	)
+
+	log.Debug("Importing cats")
+
+	if err := checkImport(len(rows), opts); err != nil {
+		return models.ImportResult{}, err
+	}
+
+	breedReqCtx, breedCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer breedCancel()
+
+	breeds, err := s.breedCatalog.Breeds(breedReqCtx)
+	if err != nil {
+		log.Error("Failed to get cat breeds", "err", err)
+		return models.ImportResult{}, errors.New("failed to import cats")
+	}
+	known := breedSet(breeds)
+
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return models.ImportResult{}, ratesError(log, err)
+	}
+
+	r := s.currentRules()
+	errs := make([][]string, len(rows))
+	for i, row := range rows {
+		errs[i] = validateCatRow(r, rates, known, row)
+	}
+
+	res := newImportResult(opts, len(rows), func(i int) (int, []string) { return rows[i].Line, errs[i] })
+	if opts.DryRun || (res.Invalid > 0 && opts.Mode == models.ImportAllOrNothing) {
+		return res, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return models.ImportResult{}, errors.New("failed to import cats")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	for i, row := range rows {
+		if res.Rows[i].Status != models.ImportRowValid {
+			continue
+		}
+
+		salaryCurrency, _ := parseSalaryCurrency(row.Cat.SalaryCurrency)
+		if salaryCurrency == "" {
+			salaryCurrency = s.reportingCurrency
+		}
+
+		var cat postgres.Cat
+		cat, err = withTx.CreateCat(ctx, postgres.CreateCatParams{
+			Name:              row.Cat.Name,
+			Breed:             row.Cat.Breed,
+			YearsOfExperience: row.Cat.YearsOfExperience,
+			Salary:            currency.MinorUnits(int64(row.Cat.Salary), salaryCurrency),
+			SalaryCurrency:    salaryCurrency,
+		})
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ImportResult{}, models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to create cat", "line", row.Line, "err", err)
+			return models.ImportResult{}, errors.New("failed to import cats")
+		}
+
+		res.Rows[i].Status, res.Rows[i].ID = models.ImportRowCreated, cat.ID
+		res.Created++
+	}
+
+	log.Debug("Imported cats", "created", res.Created)
+
+	return res, nil
+}
+
+// ImportMissions validates the missions and creates them with their targets
+// in a single transaction.
+//
+// The subjects of all the rows are checked against a single lookup.
+func (s Service) ImportMissions(ctx context.Context, rows []models.MissionImportRow, opts models.ImportOptions) (models.ImportResult, error) {
+	log := slog.With(
+		slog.String("op", "service.ImportMissions"),
+		slog.Int("rows", len(rows)),
+		slog.Any("opts", opts),
+	)
+
+	log.Debug("Importing missions")
+
+	if err := checkImport(len(rows), opts); err != nil {
+		return models.ImportResult{}, err
+	}
+
+	subjects, err := s.knownSubjects(ctx, rows)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ImportResult{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get subjects", "err", err)
+		return models.ImportResult{}, errors.New("failed to import missions")
+	}
+
+	r := s.currentRules()
+	errs := make([][]string, len(rows))
+	for i, row := range rows {
+		errs[i] = validateMissionRow(r, subjects, row)
+	}
+
+	res := newImportResult(opts, len(rows), func(i int) (int, []string) { return rows[i].Line, errs[i] })
+	if opts.DryRun || (res.Invalid > 0 && opts.Mode == models.ImportAllOrNothing) {
+		return res, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return models.ImportResult{}, errors.New("failed to import missions")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	linker := s.newSubjectLinker(withTx)
+
+	for i, row := range rows {
+		if res.Rows[i].Status != models.ImportRowValid {
+			continue
+		}
+
+		var mission postgres.Mission
+		mission, err = withTx.CreateMission(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ImportResult{}, models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to create mission", "line", row.Line, "err", err)
+			return models.ImportResult{}, errors.New("failed to import missions")
+		}
+
+		for _, t := range row.Mission.Targets {
+			var subject pgtype.Int4
+			subject, _, err = linker.link(ctx, t.SubjectID, t.Name, t.Country)
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					return models.ImportResult{}, models.ErrTimeoutExceeded
+				}
+				var modelErr *models.Err
+				if errors.As(err, &modelErr) {
+					log.Info("Invalid subject", "line", row.Line, "err", err)
+					return models.ImportResult{}, err
+				}
+				log.Error("Failed to link subject", "line", row.Line, "err", err)
+				return models.ImportResult{}, errors.New("failed to import missions")
+			}
+
+			_, err = withTx.CreateTarget(ctx, postgres.CreateTargetParams{
+				Mission:   mission.ID,
+				Name:      t.Name,
+				Country:   t.Country,
+				Notes:     t.Notes,
+				Latitude:  ptrToFloat8(t.Latitude),
+				Longitude: ptrToFloat8(t.Longitude),
+				Address:   t.Address,
+				Subject:   subject,
+			})
+			if err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					return models.ImportResult{}, models.ErrTimeoutExceeded
+				}
+				log.Error("Failed to create target", "line", row.Line, "err", err)
+				return models.ImportResult{}, errors.New("failed to import missions")
+			}
+		}
+
+		res.Rows[i].Status, res.Rows[i].ID = models.ImportRowCreated, mission.ID
+		res.Created++
+	}
+
+	log.Debug("Imported missions", "created", res.Created)
+
+	return res, nil
+}
+
+// knownSubjects returns the IDs of the existing subjects set on the targets of
+// the rows. It is nil for a Service without subject storage, which ignores
+// the subjects.
+func (s Service) knownSubjects(ctx context.Context, rows []models.MissionImportRow) (map[int32]bool, error) {
+	if s.subjectStorage == nil {
+		return nil, nil
+	}
+
+	var ids []int32
+	for _, row := range rows {
+		for _, t := range row.Mission.Targets {
+			if t.SubjectID != nil {
+				ids = append(ids, *t.SubjectID)
+			}
+		}
+	}
+
+	known := make(map[int32]bool, len(ids))
+	if len(ids) == 0 {
+		return known, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	subjects, err := s.subjectStorage.GetSubjectsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, subject := range subjects {
+		known[subject.ID] = true
+	}
+
+	return known, nil
+}
+
+// checkImport checks the number of rows and the options of an import.
+func checkImport(n int, opts models.ImportOptions) error {
+	if opts.Mode != models.ImportAllOrNothing && opts.Mode != models.ImportBestEffort {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("unknown import mode: %q", opts.Mode))
+	}
+	if n == 0 {
+		return models.NewError(http.StatusUnprocessableEntity, "nothing to import")
+	}
+	if n > maxImportRows {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("too many rows to import (1..%d)", maxImportRows))
+	}
+	return nil
+}
+
+// newImportResult returns the result of the validation of n rows, where row
+// returns the line and the errors of the i-th row.
+func newImportResult(opts models.ImportOptions, n int, row func(i int) (int, []string)) models.ImportResult {
+	res := models.ImportResult{
+		Mode:   opts.Mode,
+		DryRun: opts.DryRun,
+		Total:  n,
+		Rows:   make([]models.ImportRowResult, n),
+	}
+
+	for i := range n {
+		line, errs := row(i)
+		res.Rows[i] = models.ImportRowResult{Line: line, Status: models.ImportRowValid, Errors: errs}
+		if len(errs) > 0 {
+			res.Rows[i].Status = models.ImportRowInvalid
+			res.Invalid++
+		} else {
+			res.Valid++
+		}
+	}
+
+	return res
+}
+
+// validateCatRow returns the problems of the row, checked like CreateCat does.
+// The salaries are compared to the minimum in the base currency of the rates.
+func validateCatRow(r rules.Rules, rates currency.Rates, breeds map[string]bool, row models.CatImportRow) []string {
+	errs := row.Errors
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if err := importValidator.Validate(row.Cat); err != nil {
+		return append(errs, err.Error())
+	}
+	if row.Cat.YearsOfExperience < r.Cats.MinYearsOfExperience {
+		errs = append(errs, fmt.Sprintf("years of experience must be greater than or equal to %d", r.Cats.MinYearsOfExperience))
+	}
+	salaryCurrency, err := parseSalaryCurrency(row.Cat.SalaryCurrency)
+	if err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		if salaryCurrency == "" {
+			salaryCurrency = rates.Base()
+		}
+		if err := minSalaryError(rates, r.Cats.MinSalary, row.Cat.Salary, salaryCurrency); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if !breeds[strings.ToLower(row.Cat.Breed)] {
+		errs = append(errs, "Unknown breed")
+	}
+
+	return errs
+}
+
+// validateMissionRow returns the problems of the row, checked like CreateMission does.
+// The subjects of the targets must be known, unless subjects is nil.
+func validateMissionRow(r rules.Rules, subjects map[int32]bool, row models.MissionImportRow) []string {
+	errs := row.Errors
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if err := checkTargetCount(r, len(row.Mission.Targets)); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for i, t := range row.Mission.Targets {
+		if err := importValidator.Validate(t); err != nil {
+			errs = append(errs, fmt.Sprintf("target %d: %s", i+1, err))
+			continue
+		}
+		if err := checkNotesLength(r, t.Notes); err != nil {
+			errs = append(errs, fmt.Sprintf("target %d: %s", i+1, err))
+		}
+		if err := checkLocation(t.Latitude, t.Longitude, t.Address); err != nil {
+			errs = append(errs, fmt.Sprintf("target %d: %s", i+1, err))
+		}
+		if subjects != nil && t.SubjectID != nil && !subjects[*t.SubjectID] {
+			errs = append(errs, fmt.Sprintf("target %d: subject %d not found", i+1, *t.SubjectID))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/geo"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// maxNearbyResults is the maximum number of nearby targets returned at once.
+	maxNearbyResults = 100
+	// maxNearbyRadiusKm is the largest radius of a proximity search, half the
+	// circumference of the Earth.
+	maxNearbyRadiusKm = 20016
+	// maxAddressLength is the size of the address column.
+	maxAddressLength = 256
+)
+
+// GetTargetsNear returns the targets within the radius of the point, the
+// nearest first.
+func (s Service) GetTargetsNear(ctx context.Context, lat, lng, radiusKm float64, limit int32) ([]models.NearbyTarget, error) {
+	log := slog.With(
+		slog.String("op", "service.GetTargetsNear"),
+		slog.Float64("lat", lat),
+		slog.Float64("lng", lng),
+		slog.Float64("radiusKm", radiusKm),
+		slog.Any("limit", limit),
+	)
+
+	log.Debug("Getting nearby targets")
+
+	if err := checkCoordinates(&lat, &lng); err != nil {
+		log.Info("Invalid coordinates")
+		return make([]models.NearbyTarget, 0), err
+	}
+	if !(radiusKm > 0 && radiusKm <= maxNearbyRadiusKm) {
+		log.Info("Invalid radius")
+		return make([]models.NearbyTarget, 0), models.NewError(http.StatusUnprocessableEntity, "radius_km must be positive and at most 20016")
+	}
+	if limit <= 0 || limit > maxNearbyResults {
+		log.Info("Invalid limit")
+		return make([]models.NearbyTarget, 0), models.NewError(http.StatusUnprocessableEntity, "limit must be between 1 and 100")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.locationStorage.GetTargetsNear(ctx, postgres.GetTargetsNearParams{
+		Lat:      lat,
+		Lng:      lng,
+		RadiusKm: radiusKm,
+		MaxRows:  limit,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.NearbyTarget, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get nearby targets", "err", err)
+		return make([]models.NearbyTarget, 0), errors.New("failed to get nearby targets")
+	}
+
+	targets := make([]models.NearbyTarget, len(res))
+	for i, r := range res {
+		targets[i] = models.NearbyTarget{
+			Target: models.Target{
+				ID:        r.ID,
+				Name:      r.Name,
+				Country:   r.Country,
+				Notes:     r.Notes,
+				Completed: r.Completed,
+				Latitude:  float8ToPtr(r.Latitude),
+				Longitude: float8ToPtr(r.Longitude),
+				Address:   r.Address,
+			},
+			MissionID:  r.Mission,
+			DistanceKm: r.DistanceKm,
+		}
+	}
+
+	return targets, nil
+}
+
+// UpdateTargetLocation replaces the coordinates and the address of a target.
+// The location of completed targets and missions can't be changed.
+func (s Service) UpdateTargetLocation(ctx context.Context, targetId int32, req models.TargetLocationRequest) (models.Target, error) {
+	log := slog.With(
+		slog.String("op", "service.UpdateTargetLocation"),
+		slog.Any("targetId", targetId),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Updating target location")
+
+	if err := checkLocation(req.Latitude, req.Longitude, req.Address); err != nil {
+		log.Info("Invalid location")
+		return models.Target{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	target, err := s.targetStorage.GetTarget(ctx, targetId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Target{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target not found")
+			return models.Target{}, models.ErrNotFound
+		}
+		log.Error("Failed to get target", "err", err)
+		return models.Target{}, errors.New("failed to update target location")
+	}
+	if target.Completed {
+		log.Debug("Can't change location of a completed target")
+		return models.Target{}, models.NewError(http.StatusUnprocessableEntity, "Can't change location of a completed target")
+	}
+
+	mission, err := s.missionStorage.GetMissionByTargetID(ctx, targetId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Target{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get mission", "err", err)
+		return models.Target{}, errors.New("failed to update target location")
+	}
+	if mission.Completed {
+		log.Debug("Can't change target location of a completed mission")
+		return models.Target{}, models.NewError(http.StatusUnprocessableEntity, "Can't change target location of a completed mission")
+	}
+
+	res, err := s.locationStorage.UpdateTargetLocation(ctx, postgres.UpdateTargetLocationParams{
+		ID:        targetId,
+		Latitude:  ptrToFloat8(req.Latitude),
+		Longitude: ptrToFloat8(req.Longitude),
+		Address:   req.Address,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Target{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target not found")
+			return models.Target{}, models.ErrNotFound
+		}
+		log.Error("Failed to update target location", "err", err)
+		return models.Target{}, errors.New("failed to update target location")
+	}
+
+	return sqlcTargetToModel(res), nil
+}
+
+// checkLocation checks the optional location of a target. The latitude and
+// the longitude must be set together.
+func checkLocation(lat, lng *float64, address string) error {
+	if err := checkCoordinates(lat, lng); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(address) > maxAddressLength {
+		return models.NewError(http.StatusUnprocessableEntity, "address is too long (0..256)")
+	}
+	return nil
+}
+
+func checkCoordinates(lat, lng *float64) error {
+	if (lat == nil) != (lng == nil) {
+		return models.NewError(http.StatusUnprocessableEntity, "latitude and longitude must be set together")
+	}
+	if lat != nil && !geo.ValidLatitude(*lat) {
+		return models.NewError(http.StatusUnprocessableEntity, "latitude must be between -90 and 90")
+	}
+	if lng != nil && !geo.ValidLongitude(*lng) {
+		return models.NewError(http.StatusUnprocessableEntity, "longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+func ptrToFloat8(v *float64) pgtype.Float8 {
+	if v == nil {
+		return pgtype.Float8{}
+	}
+	return pgtype.Float8{Float64: *v, Valid: true}
+}
+
+func float8ToPtr(v pgtype.Float8) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
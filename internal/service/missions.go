@@ -3,17 +3,23 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
 	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
 )
 
+// GetAllMissions returns the missions with their targets, read with a single
+// query for all the missions.
 func (s Service) GetAllMissions(ctx context.Context) ([]models.Mission, error) {
 	log := slog.With(
 		slog.String("op", "service.GetAllMissions"),
@@ -33,9 +39,23 @@ func (s Service) GetAllMissions(ctx context.Context) ([]models.Mission, error) {
 		return make([]models.Mission, 0), err
 	}
 
+	targets, err := s.targetStorage.GetAllTargets(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Mission, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get targets", "err", err)
+		return make([]models.Mission, 0), errors.New("failed to get missions")
+	}
+	targetsOf := make(map[int32][]models.Target)
+	for _, t := range targets {
+		targetsOf[t.Mission] = append(targetsOf[t.Mission], sqlcTargetToModel(t))
+	}
+
 	missions := make([]models.Mission, len(res))
 	for i, m := range res {
 		missions[i] = sqlcMissionToModel(m)
+		missions[i].Targets = targetsOf[m.ID]
 	}
 
 	log.Debug("Fetched all missions", "res", missions)
@@ -44,12 +64,16 @@ func (s Service) GetAllMissions(ctx context.Context) ([]models.Mission, error) {
 }
 
 func (s Service) CreateMission(ctx context.Context, req models.CreateMissionRequest) (models.Mission, error) {
-	if len(req.Targets) == 0 || len(req.Targets) > 3 {
-		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "incorrect number of targets (1..3)")
+	r := s.currentRules()
+	if err := checkTargetCount(r, len(req.Targets)); err != nil {
+		return models.Mission{}, err
 	}
 	for _, target := range req.Targets {
-		if utf8.RuneCountInString(target.Notes) > 256 {
-			return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "incorrect number of targets (0..256)")
+		if err := checkNotesLength(r, target.Notes); err != nil {
+			return models.Mission{}, err
+		}
+		if err := checkLocation(target.Latitude, target.Longitude, target.Address); err != nil {
+			return models.Mission{}, err
 		}
 	}
 
@@ -90,13 +114,39 @@ func (s Service) CreateMission(ctx context.Context, req models.CreateMissionRequ
 
 	log.Debug("Created mission", "id", mission.ID)
 
+	linker := s.newSubjectLinker(withTx)
+
 	// Create targets for mission.
+	res := sqlcMissionToModel(mission)
 	for _, t := range req.Targets {
-		_, err = withTx.CreateTarget(ctx, postgres.CreateTargetParams{
-			Mission: mission.ID,
-			Name:    t.Name,
-			Country: t.Country,
-			Notes:   t.Notes,
+		var (
+			subject     pgtype.Int4
+			suggestions []models.SubjectMatch
+		)
+		subject, suggestions, err = linker.link(ctx, t.SubjectID, t.Name, t.Country)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.Mission{}, models.ErrTimeoutExceeded
+			}
+			var modelErr *models.Err
+			if errors.As(err, &modelErr) {
+				log.Info("Invalid subject", "err", err)
+				return models.Mission{}, err
+			}
+			log.Error("Failed to link subject", "err", err)
+			return models.Mission{}, errors.New("failed to create mission")
+		}
+
+		var target postgres.Target
+		target, err = withTx.CreateTarget(ctx, postgres.CreateTargetParams{
+			Mission:   mission.ID,
+			Name:      t.Name,
+			Country:   t.Country,
+			Notes:     t.Notes,
+			Latitude:  ptrToFloat8(t.Latitude),
+			Longitude: ptrToFloat8(t.Longitude),
+			Address:   t.Address,
+			Subject:   subject,
 		})
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
@@ -105,9 +155,13 @@ func (s Service) CreateMission(ctx context.Context, req models.CreateMissionRequ
 			log.Error("Failed to create target", "err", err)
 			return models.Mission{}, errors.New("failed to create mission")
 		}
+
+		created := sqlcTargetToModel(target)
+		created.Suggestions = suggestions
+		res.Targets = append(res.Targets, created)
 	}
 
-	return sqlcMissionToModel(mission), nil
+	return res, nil
 }
 
 func (s Service) GetMission(ctx context.Context, id int32) (models.Mission, error) {
@@ -155,7 +209,13 @@ func (s Service) GetMission(ctx context.Context, id int32) (models.Mission, erro
 	return mission, nil
 }
 
-func (s Service) AssignCatToMission(ctx context.Context, mission, assignee int32) (models.Mission, error) {
+// AssignCatToMission assigns the cat to the mission, unassigning it from its
+// current one.
+//
+// An unavailable cat is assigned only with an override, and the conflict is
+// returned as a warning of the mission.
+func (s Service) AssignCatToMission(ctx context.Context, mission int32, req models.AssignCatRequest) (models.Mission, error) {
+	assignee := req.Assignee
 	log := slog.With(
 		slog.String("op", "service.AssignCatToMission"),
 		slog.Any("missionId", mission),
@@ -164,6 +224,17 @@ func (s Service) AssignCatToMission(ctx context.Context, mission, assignee int32
 
 	log.Debug("Assigning cat to mission")
 
+	// The cat must be available from now until the end of the mission, or
+	// from now on without one.
+	since := time.Now().UTC()
+	until := openEnded
+	if req.Until != nil {
+		if !req.Until.After(since) {
+			return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "until must be in the future")
+		}
+		until = *req.Until
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -211,6 +282,24 @@ func (s Service) AssignCatToMission(ctx context.Context, mission, assignee int32
 		return sqlcMissionToModel(lastCatMission), nil
 	}
 
+	var warnings []string
+	conflict, err := s.checkCatAvailability(ctx, assignee, since, until)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to check cat availability", "err", err)
+		return models.Mission{}, errors.New("failed to assign cat")
+	}
+	if conflict != "" {
+		if !req.Override {
+			log.Info("Cat is unavailable", "conflict", conflict)
+			return models.Mission{}, models.NewError(http.StatusConflict, conflict)
+		}
+		log.Info("Assigning unavailable cat", "conflict", conflict)
+		warnings = append(warnings, conflict)
+	}
+
 	tx, err := s.txStorage.Begin(ctx)
 	if err != nil {
 		slog.Error("Failed to begin transaction", "err", err)
@@ -228,10 +317,7 @@ func (s Service) AssignCatToMission(ctx context.Context, mission, assignee int32
 	withTx := s.txStorage.WithTx(tx)
 
 	// Assign to new mission.
-	newMission, err := withTx.AssignCat(ctx, postgres.AssignCatParams{
-		ID:       mission,
-		Assignee: pgtype.Int4{Int32: assignee, Valid: true},
-	})
+	newMission, err := assignCat(ctx, withTx, mission, pgtype.Int4{Int32: assignee, Valid: true})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return models.Mission{}, models.ErrTimeoutExceeded
@@ -243,10 +329,7 @@ func (s Service) AssignCatToMission(ctx context.Context, mission, assignee int32
 	if lastCatMission.ID != 0 {
 		log.Debug("Cat has active mission")
 		// Unassign from last mission.
-		_, err = withTx.AssignCat(ctx, postgres.AssignCatParams{
-			ID:       lastCatMission.ID,
-			Assignee: pgtype.Int4{Valid: false},
-		})
+		_, err = assignCat(ctx, withTx, lastCatMission.ID, pgtype.Int4{Valid: false})
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
 				return models.Mission{}, models.ErrTimeoutExceeded
@@ -258,9 +341,47 @@ func (s Service) AssignCatToMission(ctx context.Context, mission, assignee int32
 		log.Debug("Unassigned from last mission", "lastCatMission", lastCatMission.ID)
 	}
 
+	// Record the event within the same transaction.
+	res := sqlcMissionToModel(newMission)
+	err = recordEvent(ctx, withTx, events.CatAssigned, res.ID, res)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		slog.Error("Failed to record event", "err", err)
+		return models.Mission{}, errors.New("failed to assign cat")
+	}
+
 	log.Debug("Assigned to new mission")
 
-	return sqlcMissionToModel(newMission), nil
+	res.Warnings = warnings
+	return res, nil
+}
+
+// assignCat sets the assignee of the mission, or unassigns it without one, and
+// records the change in the history of the assignments of the mission.
+//
+// Must be called with the transaction of the change.
+func assignCat(ctx context.Context, withTx postgres.Querier, mission int32, assignee pgtype.Int4) (postgres.Mission, error) {
+	err := withTx.EndMissionAssignment(ctx, mission)
+	if err != nil {
+		return postgres.Mission{}, err
+	}
+
+	res, err := withTx.AssignCat(ctx, postgres.AssignCatParams{
+		ID:       mission,
+		Assignee: assignee,
+	})
+	if err != nil || !assignee.Valid {
+		return res, err
+	}
+
+	err = withTx.CreateMissionAssignment(ctx, postgres.CreateMissionAssignmentParams{
+		Mission:    mission,
+		Cat:        assignee.Int32,
+		AssignedAt: res.AssignedAt,
+	})
+	return res, err
 }
 
 func (s Service) CompleteMission(ctx context.Context, mission int32) (models.Mission, error) {
@@ -297,8 +418,24 @@ func (s Service) CompleteMission(ctx context.Context, mission int32) (models.Mis
 		}
 	}
 
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Mission{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
 	// Complete mission.
-	completed, err := s.missionStorage.CompleteMission(ctx, mission)
+	completed, err := withTx.CompleteMission(ctx, mission)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return models.Mission{}, models.ErrTimeoutExceeded
@@ -311,7 +448,18 @@ func (s Service) CompleteMission(ctx context.Context, mission int32) (models.Mis
 		return models.Mission{}, errors.New("failed to complete mission")
 	}
 
-	return sqlcMissionToModel(completed), nil
+	// Record the event within the same transaction.
+	res := sqlcMissionToModel(completed)
+	err = recordEvent(ctx, withTx, events.MissionCompleted, res.ID, res)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		slog.Error("Failed to record event", "err", err)
+		return models.Mission{}, errors.New("failed to complete mission")
+	}
+
+	return res, nil
 }
 
 func (s Service) DeleteMission(ctx context.Context, id int32) error {
@@ -339,12 +487,26 @@ func (s Service) DeleteMission(ctx context.Context, id int32) error {
 		return errors.New("failed to delete mission")
 	}
 
-	// Can't delete an assigned mission
-	if mission.Assignee.Valid {
+	// Can't delete an assigned mission, unless the rules allow it.
+	if mission.Assignee.Valid && !s.currentRules().Missions.DeleteAssigned {
 		log.Info("Mission has assignee")
 		return models.NewError(http.StatusUnprocessableEntity, "can't delete an assigned mission")
 	}
 
+	// The rows of the attachments are deleted with the targets, their content
+	// after them.
+	var attachments []postgres.Attachment
+	if s.attachmentStorage != nil {
+		attachments, err = s.attachmentStorage.GetMissionAttachments(ctx, id)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to get attachments", "err", err)
+			return errors.New("failed to delete mission")
+		}
+	}
+
 	// Delete mission
 	rows, err := s.missionStorage.DeleteMission(ctx, id)
 	if err != nil {
@@ -359,11 +521,238 @@ func (s Service) DeleteMission(ctx context.Context, id int32) error {
 		return models.ErrNotFound
 	}
 
+	s.deleteBlobs(log, blobKeys(attachments)...)
+
 	log.Debug("Mission deleted")
 
 	return nil
 }
 
+// CloneMission creates an unassigned mission with copies of the pending
+// targets of the mission.
+func (s Service) CloneMission(ctx context.Context, id int32, req models.CloneMissionRequest) (models.Mission, error) {
+	log := slog.With(
+		slog.String("op", "service.CloneMission"),
+		slog.Any("id", id),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Cloning mission")
+
+	source, err := s.GetMission(ctx, id)
+	if err != nil {
+		return models.Mission{}, err
+	}
+
+	var pending []models.Target
+	for _, t := range source.Targets {
+		if !t.Completed {
+			pending = append(pending, t)
+		}
+	}
+	if len(pending) == 0 {
+		log.Info("Mission has no pending targets")
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission has no pending targets")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Mission{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	mission, err := withTx.CreateMission(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to create mission", "err", err)
+		return models.Mission{}, errors.New("failed to clone mission")
+	}
+
+	res := sqlcMissionToModel(mission)
+	for _, t := range pending {
+		params := postgres.CreateTargetParams{
+			Mission:   mission.ID,
+			Name:      t.Name,
+			Country:   t.Country,
+			Latitude:  ptrToFloat8(t.Latitude),
+			Longitude: ptrToFloat8(t.Longitude),
+			Address:   t.Address,
+			Subject:   pgtype.Int4{Int32: t.SubjectID, Valid: t.SubjectID != 0},
+		}
+		if req.Notes {
+			params.Notes = t.Notes
+		}
+
+		var target postgres.Target
+		target, err = withTx.CreateTarget(ctx, params)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.Mission{}, models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to create target", "err", err)
+			return models.Mission{}, errors.New("failed to clone mission")
+		}
+		res.Targets = append(res.Targets, sqlcTargetToModel(target))
+	}
+
+	log.Debug("Cloned mission", "mission", res.ID)
+
+	return res, nil
+}
+
+// SplitMission moves the targets of the mission into a new unassigned mission.
+//
+// Only pending targets of the mission can be moved, and at least one target
+// must be left.
+func (s Service) SplitMission(ctx context.Context, id int32, req models.SplitMissionRequest) (models.Mission, error) {
+	log := slog.With(
+		slog.String("op", "service.SplitMission"),
+		slog.Any("id", id),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Splitting mission")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Mission{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	// The lock keeps the targets from being added or moved until the split
+	// is done, so the check of the targets left holds.
+	_, err = withTx.GetMissionForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Mission not found")
+			return models.Mission{}, models.ErrNotFound
+		}
+		log.Error("Failed to get mission", "err", err)
+		return models.Mission{}, errors.New("failed to split mission")
+	}
+
+	targets, err := withTx.GetMissionTargets(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get targets", "err", err)
+		return models.Mission{}, errors.New("failed to split mission")
+	}
+
+	if err = validateSplit(s.currentRules(), targets, req.Targets); err != nil {
+		log.Info("Invalid split")
+		return models.Mission{}, err
+	}
+
+	mission, err := withTx.CreateMission(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to create mission", "err", err)
+		return models.Mission{}, errors.New("failed to split mission")
+	}
+
+	res := sqlcMissionToModel(mission)
+	for _, targetID := range req.Targets {
+		var target postgres.Target
+		target, err = withTx.MoveTarget(ctx, postgres.MoveTargetParams{
+			ID:      targetID,
+			Mission: mission.ID,
+		})
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.Mission{}, models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to move target", "target", targetID, "err", err)
+			return models.Mission{}, errors.New("failed to split mission")
+		}
+		res.Targets = append(res.Targets, sqlcTargetToModel(target))
+	}
+
+	log.Debug("Split mission", "mission", res.ID)
+
+	return res, nil
+}
+
+// validateSplit checks the targets to move out of a mission with the targets.
+func validateSplit(r rules.Rules, targets []postgres.Target, move []int32) error {
+	if len(move) == 0 {
+		return models.NewError(http.StatusUnprocessableEntity, "no targets to move")
+	}
+	if err := checkTargetCount(r, len(move)); err != nil {
+		return err
+	}
+
+	seen := make(map[int32]bool, len(move))
+	for _, id := range move {
+		if seen[id] {
+			return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("target %d is listed twice", id))
+		}
+		seen[id] = true
+
+		i := slices.IndexFunc(targets, func(t postgres.Target) bool { return t.ID == id })
+		if i < 0 {
+			return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("target %d doesn't belong to the mission", id))
+		}
+		if targets[i].Completed {
+			return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("can't move completed target %d", id))
+		}
+	}
+
+	if len(targets)-len(move) < r.Targets.Min {
+		return models.NewError(http.StatusUnprocessableEntity, "can't move all targets of the mission")
+	}
+
+	return nil
+}
+
+// checkTargetCount checks the number of targets of a mission against the rules.
+func checkTargetCount(r rules.Rules, n int) error {
+	if n < r.Targets.Min || n > r.Targets.Max {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("incorrect number of targets (%d..%d)", r.Targets.Min, r.Targets.Max))
+	}
+	return nil
+}
+
+// checkNotesLength checks the length of target notes against the rules.
+func checkNotesLength(r rules.Rules, notes string) error {
+	if utf8.RuneCountInString(notes) > r.Targets.MaxNotesLength {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("notes are too long (0..%d)", r.Targets.MaxNotesLength))
+	}
+	return nil
+}
+
 func sqlcTargetToModel(t postgres.Target) models.Target {
 	return models.Target{
 		ID:        t.ID,
@@ -371,6 +760,10 @@ func sqlcTargetToModel(t postgres.Target) models.Target {
 		Country:   t.Country,
 		Notes:     t.Notes,
 		Completed: t.Completed,
+		Latitude:  float8ToPtr(t.Latitude),
+		Longitude: float8ToPtr(t.Longitude),
+		Address:   t.Address,
+		SubjectID: t.Subject.Int32,
 	}
 }
 
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rsmanito/developstoday-test-assessment/internal/markdown"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+)
+
+// maxResolvedMentions is the maximum number of distinct mentions resolved
+// per request, as each takes a query. The others are rendered as written.
+const maxResolvedMentions = 100
+
+// RenderNotes renders the Markdown notes of the targets to sanitized HTML.
+// The mentions of existing cats and targets become links, the others are
+// rendered as written.
+func (s Service) RenderNotes(ctx context.Context, targets []models.Target) ([]models.Target, error) {
+	log := slog.With(
+		slog.String("op", "service.RenderNotes"),
+		slog.Int("targets", len(targets)),
+	)
+
+	log.Debug("Rendering notes")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var mentions []markdown.Mention
+	seen := make(map[markdown.Mention]bool)
+	for _, t := range targets {
+		for _, m := range markdown.Mentions(t.Notes) {
+			if !seen[m] && len(mentions) < maxResolvedMentions {
+				seen[m] = true
+				mentions = append(mentions, m)
+			}
+		}
+	}
+
+	links, err := s.resolveMentions(ctx, mentions)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to resolve mentions", "err", err)
+		return nil, errors.New("failed to render notes")
+	}
+
+	res := make([]models.Target, len(targets))
+	for i, t := range targets {
+		html, err := markdown.Render(t.Notes, links)
+		if err != nil {
+			log.Error("Failed to render notes", "targetId", t.ID, "err", err)
+			return nil, errors.New("failed to render notes")
+		}
+		t.NotesHTML = html
+		res[i] = t
+	}
+
+	return res, nil
+}
+
+// resolveMentions returns the links of the mentions of existing cats and
+// targets. Cats link to themselves and targets to their mission.
+func (s Service) resolveMentions(ctx context.Context, mentions []markdown.Mention) (map[markdown.Mention]markdown.Link, error) {
+	links := make(map[markdown.Mention]markdown.Link, len(mentions))
+
+	for _, m := range mentions {
+		link, err := s.resolveMention(ctx, m)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		links[m] = link
+	}
+
+	return links, nil
+}
+
+func (s Service) resolveMention(ctx context.Context, m markdown.Mention) (markdown.Link, error) {
+	switch m.Kind {
+	case markdown.KindCat:
+		cat, err := s.catStorage.GetCat(ctx, m.ID)
+		if err != nil {
+			return markdown.Link{}, err
+		}
+		return markdown.Link{Href: fmt.Sprintf("/cats/%d", cat.ID), Text: "@" + cat.Name}, nil
+	default:
+		target, err := s.targetStorage.GetTarget(ctx, m.ID)
+		if err != nil {
+			return markdown.Link{}, err
+		}
+		return markdown.Link{Href: fmt.Sprintf("/missions/%d", target.Mission), Text: "@" + target.Name}, nil
+	}
+}
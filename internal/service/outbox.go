@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/outbox"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// maxOutboxEvents is the maximum number of outbox events returned at once.
+const maxOutboxEvents = 1000
+
+func (s Service) GetOutboxEvents(ctx context.Context, fromOffset int64, limit int32) ([]models.OutboxEvent, error) {
+	log := slog.With(
+		slog.String("op", "service.GetOutboxEvents"),
+		slog.Any("fromOffset", fromOffset),
+		slog.Any("limit", limit),
+	)
+
+	log.Debug("Fetching outbox events")
+
+	if limit <= 0 || limit > maxOutboxEvents {
+		log.Info("Invalid limit")
+		return make([]models.OutboxEvent, 0), models.NewError(http.StatusUnprocessableEntity, "limit must be between 1 and 1000")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.outboxStorage.GetOutboxEvents(ctx, postgres.GetOutboxEventsParams{
+		FromOffset: fromOffset,
+		MaxEvents:  limit,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.OutboxEvent, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get outbox events", "err", err)
+		return make([]models.OutboxEvent, 0), errors.New("failed to get outbox events")
+	}
+
+	events := make([]models.OutboxEvent, len(res))
+	for i, e := range res {
+		events[i] = sqlcOutboxToModel(e)
+	}
+
+	return events, nil
+}
+
+func (s Service) ReplayOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	log := slog.With(
+		slog.String("op", "service.ReplayOutboxEvents"),
+		slog.Any("fromOffset", fromOffset),
+	)
+
+	log.Debug("Replaying outbox events")
+
+	if fromOffset < 1 {
+		log.Info("Invalid offset")
+		return 0, models.NewError(http.StatusUnprocessableEntity, "offset must be greater than 0")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The relay picks up the events again once they are unpublished.
+	rows, err := s.outboxStorage.ResetOutboxEvents(ctx, fromOffset)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to reset outbox events", "err", err)
+		return 0, errors.New("failed to replay outbox events")
+	}
+
+	log.Debug("Scheduled events for replay", "count", rows)
+
+	return rows, nil
+}
+
+// recordEvent writes a mission event to the outbox and queues its webhook deliveries.
+//
+// Must be called with the transaction of the change, so the event is published
+// only if the change is committed. The mission is locked first, so the events
+// of a mission are committed in the order of their offsets.
+func recordEvent(ctx context.Context, withTx postgres.Querier, eventType string, missionID int32, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = withTx.GetMissionForUpdate(ctx, missionID)
+	if err != nil {
+		return err
+	}
+
+	err = withTx.CreateOutboxEvent(ctx, postgres.CreateOutboxEventParams{
+		AggregateType: outbox.AggregateMission,
+		AggregateID:   missionID,
+		EventType:     eventType,
+		Payload:       payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return enqueueWebhookDeliveries(ctx, withTx, eventType, data)
+}
+
+func sqlcOutboxToModel(o postgres.Outbox) models.OutboxEvent {
+	return models.OutboxEvent{
+		Offset:        o.ID,
+		AggregateType: o.AggregateType,
+		AggregateID:   o.AggregateID,
+		Type:          o.EventType,
+		Payload:       o.Payload,
+		CreatedAt:     o.CreatedAt.Time,
+		PublishedAt:   timestamptzToPtr(o.PublishedAt),
+	}
+}
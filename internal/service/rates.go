@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// defaultReportingCurrency is the currency the amounts are reported in
+// without WithReportingCurrency.
+const defaultReportingCurrency = "USD"
+
+func (s Service) GetExchangeRates(ctx context.Context) (models.ExchangeRates, error) {
+	log := slog.With(
+		slog.String("op", "service.GetExchangeRates"),
+	)
+
+	log.Debug("Fetching exchange rates")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.rateStorage.GetExchangeRates(ctx)
+	if err != nil {
+		return models.ExchangeRates{}, ratesError(log, err)
+	}
+
+	rates := models.ExchangeRates{
+		ReportingCurrency: s.reportingCurrency,
+		Rates:             make([]models.ExchangeRate, len(res)),
+	}
+	for i, r := range res {
+		rates.Rates[i] = sqlcExchangeRateToModel(r)
+	}
+
+	return rates, nil
+}
+
+// SetExchangeRate sets the rate of the currency to the reporting currency.
+func (s Service) SetExchangeRate(ctx context.Context, code string, req models.SetExchangeRateRequest) (models.ExchangeRate, error) {
+	log := slog.With(
+		slog.String("op", "service.SetExchangeRate"),
+		slog.String("currency", code),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Setting exchange rate")
+
+	code, err := s.validateRate(code, req.Rate)
+	if err != nil {
+		log.Info("Invalid exchange rate")
+		return models.ExchangeRate{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.rateStorage.SetExchangeRate(ctx, postgres.SetExchangeRateParams{Currency: code, Rate: req.Rate})
+	if err != nil {
+		return models.ExchangeRate{}, ratesError(log, err)
+	}
+
+	log.Debug("Set exchange rate")
+
+	return sqlcExchangeRateToModel(res), nil
+}
+
+func (s Service) DeleteExchangeRate(ctx context.Context, code string) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteExchangeRate"),
+		slog.String("currency", code),
+	)
+
+	log.Debug("Deleting exchange rate")
+
+	code, err := currency.Parse(code)
+	if err != nil {
+		return models.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.rateStorage.DeleteExchangeRate(ctx, code)
+	if err != nil {
+		return ratesError(log, err)
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	log.Debug("Deleted exchange rate")
+
+	return nil
+}
+
+// ImportExchangeRates sets the rates of the currencies in a single
+// transaction. The rates of the other currencies are kept.
+func (s Service) ImportExchangeRates(ctx context.Context, rates map[string]float64) (models.ExchangeRates, error) {
+	log := slog.With(
+		slog.String("op", "service.ImportExchangeRates"),
+		slog.Int("rates", len(rates)),
+	)
+
+	log.Debug("Importing exchange rates")
+
+	codes := slices.Sorted(maps.Keys(rates))
+	for _, code := range codes {
+		if _, err := s.validateRate(code, rates[code]); err != nil {
+			log.Info("Invalid exchange rate", "currency", code)
+			return models.ExchangeRates{}, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return models.ExchangeRates{}, errors.New("failed to import exchange rates")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	for _, key := range codes {
+		code, _ := currency.Parse(key)
+		_, err = withTx.SetExchangeRate(ctx, postgres.SetExchangeRateParams{Currency: code, Rate: rates[key]})
+		if err != nil {
+			return models.ExchangeRates{}, ratesError(log, err)
+		}
+	}
+
+	res, err := withTx.GetExchangeRates(ctx)
+	if err != nil {
+		return models.ExchangeRates{}, ratesError(log, err)
+	}
+
+	log.Debug("Imported exchange rates")
+
+	imported := models.ExchangeRates{
+		ReportingCurrency: s.reportingCurrency,
+		Rates:             make([]models.ExchangeRate, len(res)),
+	}
+	for i, r := range res {
+		imported.Rates[i] = sqlcExchangeRateToModel(r)
+	}
+
+	return imported, nil
+}
+
+// validateRate returns the code of the currency of a valid rate.
+func (s Service) validateRate(code string, rate float64) (string, error) {
+	code, err := currency.Parse(code)
+	if err != nil {
+		return "", models.NewError(http.StatusUnprocessableEntity, "currency must be an ISO 4217 code")
+	}
+	if code == s.reportingCurrency {
+		return "", models.NewError(http.StatusUnprocessableEntity, code+" is the reporting currency")
+	}
+	if !currency.ValidRate(rate) {
+		return "", models.NewError(http.StatusUnprocessableEntity, "rate must be a positive number")
+	}
+
+	return code, nil
+}
+
+// exchangeRates returns the exchange rates to the reporting currency.
+func (s Service) exchangeRates(ctx context.Context) (currency.Rates, error) {
+	if s.rateStorage == nil {
+		return currency.NewRates(s.reportingCurrency, nil), nil
+	}
+
+	res, err := s.rateStorage.GetExchangeRates(ctx)
+	if err != nil {
+		return currency.Rates{}, err
+	}
+
+	rates := make(map[string]float64, len(res))
+	for _, r := range res {
+		rates[r.Currency] = r.Rate
+	}
+
+	return currency.NewRates(s.reportingCurrency, rates), nil
+}
+
+// reportingRates returns the exchange rates for the reporting salaries of the
+// cats. Without them, only the salaries in the reporting currency are
+// converted, so a failed query of the rates doesn't fail the listing.
+func (s Service) reportingRates(ctx context.Context, log *slog.Logger) currency.Rates {
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		log.Warn("Failed to query exchange rates, listing the cats without reporting salaries", "err", err)
+		return currency.NewRates(s.reportingCurrency, nil)
+	}
+	return rates
+}
+
+// toReporting returns the amount in the reporting currency, nil if its
+// currency has no exchange rate.
+func toReporting(rates currency.Rates, amount int64, code string) *models.Money {
+	converted, err := rates.Convert(amount, code, rates.Base())
+	if err != nil {
+		return nil
+	}
+	return &models.Money{Amount: converted, Currency: rates.Base()}
+}
+
+// conversionError maps a failed conversion to the error of the service.
+func conversionError(err error) error {
+	var noRate *currency.NoRateError
+	if errors.As(err, &noRate) {
+		return models.NewError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return err
+}
+
+// ratesError maps a failed query of the exchange rates to the error of the
+// service.
+func ratesError(log *slog.Logger, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrTimeoutExceeded
+	}
+	log.Error("Failed to query exchange rates", "err", err)
+	return errors.New("failed to query exchange rates")
+}
+
+func sqlcExchangeRateToModel(r postgres.ExchangeRate) models.ExchangeRate {
+	return models.ExchangeRate{
+		Currency:  r.Currency,
+		Rate:      r.Rate,
+		UpdatedAt: r.UpdatedAt.Time.UTC(),
+	}
+}
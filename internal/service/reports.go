@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/currency"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// reportPageSize is the number of rows read by a query of a report.
+	reportPageSize = 500
+	// reportTimeout bounds the export of a report.
+	reportTimeout = 5 * time.Minute
+)
+
+// The reports are read a page at a time, after the last row of the previous
+// page, so only a page is held in memory. A row created or deleted during an
+// export may or may not be part of it.
+
+// EachCat calls fn with the cats in ascending order of ID.
+func (s Service) EachCat(ctx context.Context, fn func(models.Cat) error) error {
+	log := slog.With(
+		slog.String("op", "service.EachCat"),
+	)
+
+	rates := s.reportingRates(ctx, log)
+
+	var after int32
+	return paginate(ctx, log, func(ctx context.Context) ([]postgres.Cat, error) {
+		cats, err := s.reportStorage.GetCatsPage(ctx, postgres.GetCatsPageParams{After: after, MaxRows: reportPageSize})
+		if len(cats) > 0 {
+			after = cats[len(cats)-1].ID
+		}
+		return cats, err
+	}, func(c postgres.Cat) error {
+		cat := sqlcCatToModel(c)
+		cat.ReportingSalary = toReporting(rates, c.Salary, c.SalaryCurrency)
+		return fn(cat)
+	})
+}
+
+// EachMission calls fn with the missions in ascending order of ID, without
+// their targets.
+func (s Service) EachMission(ctx context.Context, fn func(models.Mission) error) error {
+	log := slog.With(
+		slog.String("op", "service.EachMission"),
+	)
+
+	var after int32
+	return paginate(ctx, log, func(ctx context.Context) ([]postgres.Mission, error) {
+		missions, err := s.reportStorage.GetMissionsPage(ctx, postgres.GetMissionsPageParams{After: after, MaxRows: reportPageSize})
+		if len(missions) > 0 {
+			after = missions[len(missions)-1].ID
+		}
+		return missions, err
+	}, func(m postgres.Mission) error {
+		return fn(sqlcMissionToModel(m))
+	})
+}
+
+// EachMissionTarget calls fn with the targets and their missions, in
+// ascending order of mission and target ID.
+func (s Service) EachMissionTarget(ctx context.Context, fn func(models.MissionTargetRow) error) error {
+	log := slog.With(
+		slog.String("op", "service.EachMissionTarget"),
+	)
+
+	var afterMission, afterTarget int32
+	return paginate(ctx, log, func(ctx context.Context) ([]postgres.GetMissionTargetsPageRow, error) {
+		rows, err := s.reportStorage.GetMissionTargetsPage(ctx, postgres.GetMissionTargetsPageParams{
+			AfterMission: afterMission,
+			AfterTarget:  afterTarget,
+			MaxRows:      reportPageSize,
+		})
+		if len(rows) > 0 {
+			afterMission, afterTarget = rows[len(rows)-1].MissionID, rows[len(rows)-1].TargetID
+		}
+		return rows, err
+	}, func(r postgres.GetMissionTargetsPageRow) error {
+		return fn(models.MissionTargetRow{
+			MissionID:        r.MissionID,
+			Assignee:         r.Assignee.Int32,
+			MissionCompleted: r.MissionCompleted,
+			TargetID:         r.TargetID,
+			Name:             r.Name,
+			Country:          r.Country,
+			Notes:            r.Notes,
+			TargetCompleted:  r.TargetCompleted,
+		})
+	})
+}
+
+// EachCatWorkload calls fn with the workload of the cats in ascending order of ID.
+func (s Service) EachCatWorkload(ctx context.Context, fn func(models.CatWorkload) error) error {
+	log := slog.With(
+		slog.String("op", "service.EachCatWorkload"),
+	)
+
+	rates := s.reportingRates(ctx, log)
+
+	var after int32
+	return paginate(ctx, log, func(ctx context.Context) ([]postgres.GetCatWorkloadPageRow, error) {
+		rows, err := s.reportStorage.GetCatWorkloadPage(ctx, postgres.GetCatWorkloadPageParams{After: after, MaxRows: reportPageSize})
+		if len(rows) > 0 {
+			after = rows[len(rows)-1].ID
+		}
+		return rows, err
+	}, func(r postgres.GetCatWorkloadPageRow) error {
+		return fn(models.CatWorkload{
+			CatID:             r.ID,
+			Name:              r.Name,
+			Breed:             r.Breed,
+			Salary:            int32(currency.MajorUnits(r.Salary, r.SalaryCurrency)),
+			SalaryCurrency:    r.SalaryCurrency,
+			ReportingSalary:   toReporting(rates, r.Salary, r.SalaryCurrency),
+			ActiveMissions:    r.ActiveMissions,
+			CompletedMissions: r.CompletedMissions,
+			OpenTargets:       r.OpenTargets,
+			CompletedTargets:  r.CompletedTargets,
+		})
+	})
+}
+
+// paginate calls fn with the rows of the pages until a page isn't full.
+//
+// The errors of fn are returned as is.
+func paginate[T any](ctx context.Context, log *slog.Logger, page func(ctx context.Context) ([]T, error), fn func(T) error) error {
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+
+	for {
+		rows, err := page(ctx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to read page", "err", err)
+			return errors.New("failed to read report")
+		}
+
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < reportPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/tsquery"
+)
+
+// maxSearchResults is the maximum number of search results returned at once.
+const maxSearchResults = 100
+
+func (s Service) SearchTargets(ctx context.Context, query string, limit int32) ([]models.TargetSearchResult, error) {
+	log := slog.With(
+		slog.String("op", "service.SearchTargets"),
+		slog.String("query", query),
+		slog.Any("limit", limit),
+	)
+
+	log.Debug("Searching targets")
+
+	if limit <= 0 || limit > maxSearchResults {
+		log.Info("Invalid limit")
+		return make([]models.TargetSearchResult, 0), models.NewError(http.StatusUnprocessableEntity, "limit must be between 1 and 100")
+	}
+
+	tsQuery := buildTSQuery(query)
+	if tsQuery == "" {
+		log.Info("Empty query")
+		return make([]models.TargetSearchResult, 0), models.NewError(http.StatusUnprocessableEntity, "query must contain at least one word")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.searchStorage.SearchTargets(ctx, postgres.SearchTargetsParams{
+		Query:      tsQuery,
+		MaxResults: limit,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.TargetSearchResult, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to search targets", "err", err)
+		return make([]models.TargetSearchResult, 0), errors.New("failed to search targets")
+	}
+
+	results := make([]models.TargetSearchResult, len(res))
+	for i, r := range res {
+		results[i] = models.TargetSearchResult{
+			Target: models.Target{
+				ID:        r.ID,
+				Name:      r.Name,
+				Country:   r.Country,
+				Notes:     r.Notes,
+				Completed: r.Completed,
+			},
+			Mission: models.Mission{
+				ID:        r.Mission,
+				Assignee:  r.MissionAssignee.Int32,
+				Completed: r.MissionCompleted,
+			},
+			Rank: r.Rank,
+			// The notes are user input, only the highlighting is markup.
+			Snippet: tsquery.HeadlineHTML(r.Snippet),
+		}
+	}
+
+	return results, nil
+}
+
+// buildTSQuery converts a user search query into a to_tsquery expression.
+//
+// Words are ANDed together, "quoted words" match as a phrase, a trailing *
+// matches by prefix, a leading - excludes the word and OR between two terms
+// matches either of them. Any other punctuation is dropped, so the result is
+// always a valid expression or empty.
+func buildTSQuery(query string) string {
+	var (
+		terms []string
+		op    = " & "
+	)
+
+	add := func(term string) {
+		if term == "" {
+			return
+		}
+		if len(terms) > 0 {
+			terms = append(terms, op)
+		}
+		terms = append(terms, term)
+		op = " & "
+	}
+
+	for i := 0; i < len(query); {
+		switch {
+		case query[i] == ' ' || query[i] == '\t' || query[i] == '\n':
+			i++
+		case query[i] == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			add(tsPhrase(query[i+1:i+1+end], false))
+			i += end + 2
+		default:
+			end := strings.IndexAny(query[i:], " \t\n\"")
+			if end < 0 {
+				end = len(query) - i
+			}
+			word := query[i : i+end]
+			i += end
+
+			if word == "OR" {
+				if len(terms) > 0 {
+					op = " | "
+				}
+				continue
+			}
+
+			negate := strings.HasPrefix(word, "-")
+			prefix := strings.HasSuffix(word, "*")
+
+			term := tsPhrase(word, prefix)
+			if term == "" {
+				continue
+			}
+			if negate {
+				term = "!" + term
+			}
+			add(term)
+		}
+	}
+
+	return strings.Join(terms, "")
+}
+
+// tsPhrase returns the lexemes of s joined by the followed-by operator.
+// If prefix is set, the last lexeme matches by prefix.
+func tsPhrase(s string, prefix bool) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if prefix && len(words) > 0 {
+		words[len(words)-1] += ":*"
+	}
+
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	default:
+		return "(" + strings.Join(words, " <-> ") + ")"
+	}
+}
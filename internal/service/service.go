@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
 )
 
@@ -33,31 +36,366 @@ type MissionStorage interface {
 type TargetStorage interface {
 	GetTarget(ctx context.Context, id int32) (postgres.Target, error)
 	GetMissionTargets(ctx context.Context, missionID int32) ([]postgres.Target, error)
+	GetAllTargets(ctx context.Context) ([]postgres.Target, error)
 	CreateTarget(ctx context.Context, params postgres.CreateTargetParams) (postgres.Target, error)
 	DeleteTarget(ctx context.Context, id int32) (int64, error)
 	UpdateTargetNotes(ctx context.Context, params postgres.UpdateTargetNotesParams) (postgres.Target, error)
 	CompleteTarget(ctx context.Context, id int32) (postgres.Target, error)
+	GetTargetMoves(ctx context.Context, target int32) ([]postgres.TargetMove, error)
 }
 
 // TransactionalStorage controls the transactional storage.
 type TransactionalStorage interface {
-	WithTx(tx pgx.Tx) *postgres.Queries
-	Begin(ctx context.Context) (pgx.Tx, error)
+	WithTx(tx storage.Tx) postgres.Querier
+	Begin(ctx context.Context) (storage.Tx, error)
+	BeginTx(ctx context.Context, opts storage.TxOptions) (storage.Tx, error)
+}
+
+// WebhookStorage controls the webhook storage.
+type WebhookStorage interface {
+	CreateWebhook(ctx context.Context, params postgres.CreateWebhookParams) (postgres.Webhook, error)
+	GetAllWebhooks(ctx context.Context) ([]postgres.Webhook, error)
+	GetWebhook(ctx context.Context, id int32) (postgres.Webhook, error)
+	UpdateWebhook(ctx context.Context, params postgres.UpdateWebhookParams) (postgres.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int32) (int64, error)
+	GetWebhookDeliveries(ctx context.Context, params postgres.GetWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, params postgres.RedeliverWebhookDeliveryParams) (postgres.WebhookDelivery, error)
+}
+
+// OutboxStorage controls the outbox storage.
+type OutboxStorage interface {
+	GetOutboxEvents(ctx context.Context, params postgres.GetOutboxEventsParams) ([]postgres.Outbox, error)
+	ResetOutboxEvents(ctx context.Context, fromOffset int64) (int64, error)
+}
+
+// SearchStorage controls the full-text search storage.
+type SearchStorage interface {
+	SearchTargets(ctx context.Context, params postgres.SearchTargetsParams) ([]postgres.SearchTargetsRow, error)
+}
+
+// TemplateStorage controls the mission template storage.
+type TemplateStorage interface {
+	CreateMissionTemplate(ctx context.Context, params postgres.CreateMissionTemplateParams) (postgres.MissionTemplate, error)
+	GetAllMissionTemplates(ctx context.Context) ([]postgres.MissionTemplate, error)
+	GetMissionTemplate(ctx context.Context, id int32) (postgres.MissionTemplate, error)
+	UpdateMissionTemplate(ctx context.Context, params postgres.UpdateMissionTemplateParams) (postgres.MissionTemplate, error)
+	DeleteMissionTemplate(ctx context.Context, id int32) (int64, error)
+}
+
+// SnapshotStorage controls the storage of the database snapshots.
+type SnapshotStorage interface {
+	SchemaVersion(ctx context.Context) (int64, error)
+}
+
+// ReportStorage controls the storage of the paged reads of the reports.
+type ReportStorage interface {
+	GetCatsPage(ctx context.Context, params postgres.GetCatsPageParams) ([]postgres.Cat, error)
+	GetMissionsPage(ctx context.Context, params postgres.GetMissionsPageParams) ([]postgres.Mission, error)
+	GetMissionTargetsPage(ctx context.Context, params postgres.GetMissionTargetsPageParams) ([]postgres.GetMissionTargetsPageRow, error)
+	GetCatWorkloadPage(ctx context.Context, params postgres.GetCatWorkloadPageParams) ([]postgres.GetCatWorkloadPageRow, error)
+}
+
+// AnalyticsStorage controls the storage of the aggregations of the analytics.
+type AnalyticsStorage interface {
+	GetMissionCompletionRate(ctx context.Context, params postgres.GetMissionCompletionRateParams) ([]postgres.GetMissionCompletionRateRow, error)
+	GetCompletionTimes(ctx context.Context) (postgres.GetCompletionTimesRow, error)
+	GetCatUtilization(ctx context.Context, now pgtype.Timestamptz) ([]postgres.GetCatUtilizationRow, error)
+	GetTargetsPerCountry(ctx context.Context) ([]postgres.GetTargetsPerCountryRow, error)
+	GetSalaryPerformance(ctx context.Context) ([]postgres.GetSalaryPerformanceRow, error)
+}
+
+// AvailabilityStorage controls the storage of the availability of the cats.
+type AvailabilityStorage interface {
+	CreateAvailabilityPeriod(ctx context.Context, params postgres.CreateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error)
+	GetCatAvailabilityPeriods(ctx context.Context, cat int32) ([]postgres.AvailabilityPeriod, error)
+	GetAvailabilityPeriod(ctx context.Context, id int32) (postgres.AvailabilityPeriod, error)
+	UpdateAvailabilityPeriod(ctx context.Context, params postgres.UpdateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error)
+	DeleteAvailabilityPeriod(ctx context.Context, id int32) (int64, error)
+	GetOverlappingAvailabilityPeriods(ctx context.Context, params postgres.GetOverlappingAvailabilityPeriodsParams) ([]postgres.AvailabilityPeriod, error)
+	GetAvailableCats(ctx context.Context, at pgtype.Timestamptz) ([]postgres.Cat, error)
+	GetCatsAvailableBetween(ctx context.Context, params postgres.GetCatsAvailableBetweenParams) ([]postgres.Cat, error)
+}
+
+// SkillStorage controls the storage of the skills of the cats and the targets.
+type SkillStorage interface {
+	CreateSkill(ctx context.Context, name string) (postgres.Skill, error)
+	GetAllSkills(ctx context.Context) ([]postgres.Skill, error)
+	GetSkill(ctx context.Context, id int32) (postgres.Skill, error)
+	DeleteSkill(ctx context.Context, id int32) (int64, error)
+	SetCatSkill(ctx context.Context, params postgres.SetCatSkillParams) (postgres.CatSkill, error)
+	GetCatSkills(ctx context.Context, cat int32) ([]postgres.GetCatSkillsRow, error)
+	DeleteCatSkill(ctx context.Context, params postgres.DeleteCatSkillParams) (int64, error)
+	SetTargetSkill(ctx context.Context, params postgres.SetTargetSkillParams) (postgres.TargetSkill, error)
+	GetTargetSkills(ctx context.Context, target int32) ([]postgres.GetTargetSkillsRow, error)
+	DeleteTargetSkill(ctx context.Context, params postgres.DeleteTargetSkillParams) (int64, error)
+	GetMissionRequiredSkills(ctx context.Context, mission int32) ([]postgres.GetMissionRequiredSkillsRow, error)
+	GetUnassignedCats(ctx context.Context) ([]postgres.Cat, error)
+	GetUnassignedCatSkills(ctx context.Context) ([]postgres.CatSkill, error)
+}
+
+// CostStorage controls the storage of the budgets and the expenses of the missions.
+type CostStorage interface {
+	SetMissionBudget(ctx context.Context, params postgres.SetMissionBudgetParams) (postgres.MissionBudget, error)
+	GetMissionBudget(ctx context.Context, mission int32) (postgres.MissionBudget, error)
+	DeleteMissionBudget(ctx context.Context, mission int32) (int64, error)
+	CreateMissionExpense(ctx context.Context, params postgres.CreateMissionExpenseParams) (postgres.MissionExpense, error)
+	GetMissionExpenses(ctx context.Context, mission int32) ([]postgres.MissionExpense, error)
+	GetMissionExpense(ctx context.Context, id int32) (postgres.MissionExpense, error)
+	DeleteMissionExpense(ctx context.Context, id int32) (int64, error)
+}
+
+// RateStorage controls the storage of the exchange rates.
+type RateStorage interface {
+	SetExchangeRate(ctx context.Context, params postgres.SetExchangeRateParams) (postgres.ExchangeRate, error)
+	GetExchangeRates(ctx context.Context) ([]postgres.ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, currency string) (int64, error)
+}
+
+// LocationStorage controls the storage of the locations of the targets.
+type LocationStorage interface {
+	UpdateTargetLocation(ctx context.Context, params postgres.UpdateTargetLocationParams) (postgres.Target, error)
+	GetTargetsNear(ctx context.Context, params postgres.GetTargetsNearParams) ([]postgres.GetTargetsNearRow, error)
+}
+
+// SubjectStorage controls the storage of the subjects of the targets.
+type SubjectStorage interface {
+	GetSubject(ctx context.Context, id int32) (postgres.Subject, error)
+	GetSubjectsByIDs(ctx context.Context, ids []int32) ([]postgres.Subject, error)
+	GetSubjectTargets(ctx context.Context, subject pgtype.Int4) ([]postgres.Target, error)
+	GetSubjectNotes(ctx context.Context, subject pgtype.Int4) ([]postgres.TargetNote, error)
+}
+
+// AttachmentStorage controls the storage of the attachments of the targets.
+type AttachmentStorage interface {
+	CreateAttachment(ctx context.Context, params postgres.CreateAttachmentParams) (postgres.Attachment, error)
+	GetAttachment(ctx context.Context, id int32) (postgres.Attachment, error)
+	GetTargetAttachments(ctx context.Context, target int32) ([]postgres.Attachment, error)
+	GetMissionAttachments(ctx context.Context, mission int32) ([]postgres.Attachment, error)
+	DeleteAttachment(ctx context.Context, id int32) (int64, error)
+}
+
+// BlobStore stores the content of the attachments by key. Get returns
+// blobs.ErrNotFound for a missing key, and Delete ignores it.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// BreedCatalog lists the known cat breeds.
+type BreedCatalog interface {
+	Breeds(ctx context.Context) ([]string, error)
+}
+
+// RuleSource provides the current business rules.
+type RuleSource interface {
+	Get() rules.Rules
 }
 
 type Service struct {
-	catStorage     CatStorage
-	missionStorage MissionStorage
-	targetStorage  TargetStorage
-	txStorage      TransactionalStorage
+	catStorage      CatStorage
+	missionStorage  MissionStorage
+	targetStorage   TargetStorage
+	txStorage       TransactionalStorage
+	webhookStorage  WebhookStorage
+	outboxStorage   OutboxStorage
+	searchStorage   SearchStorage
+	templateStorage TemplateStorage
+	ruleSource      RuleSource
+	breedCatalog    BreedCatalog
+	snapshotStorage SnapshotStorage
+	reportStorage   ReportStorage
+
+	analyticsStorage AnalyticsStorage
+	analyticsCache   *analyticsCache
+
+	availabilityStorage AvailabilityStorage
+	skillStorage        SkillStorage
+	costStorage         CostStorage
+
+	rateStorage       RateStorage
+	reportingCurrency string
+
+	locationStorage LocationStorage
+	subjectStorage  SubjectStorage
+
+	attachmentStorage AttachmentStorage
+	blobStore         BlobStore
+	maxAttachmentSize int64
+}
+
+// Option configures optional Service dependencies.
+type Option func(*Service)
+
+// WithWebhookStorage sets the storage of webhook subscriptions.
+func WithWebhookStorage(ws WebhookStorage) Option {
+	return func(s *Service) {
+		s.webhookStorage = ws
+	}
+}
+
+// WithOutboxStorage sets the storage of the event outbox.
+func WithOutboxStorage(os OutboxStorage) Option {
+	return func(s *Service) {
+		s.outboxStorage = os
+	}
+}
+
+// WithSearchStorage sets the storage of the full-text search index.
+func WithSearchStorage(ss SearchStorage) Option {
+	return func(s *Service) {
+		s.searchStorage = ss
+	}
+}
+
+// WithTemplateStorage sets the storage of mission templates.
+func WithTemplateStorage(ts TemplateStorage) Option {
+	return func(s *Service) {
+		s.templateStorage = ts
+	}
+}
+
+// WithSnapshotStorage sets the storage of the database snapshots.
+func WithSnapshotStorage(ss SnapshotStorage) Option {
+	return func(s *Service) {
+		s.snapshotStorage = ss
+	}
+}
+
+// WithReportStorage sets the storage of the reports.
+func WithReportStorage(rs ReportStorage) Option {
+	return func(s *Service) {
+		s.reportStorage = rs
+	}
+}
+
+// WithAnalyticsStorage sets the storage of the analytics.
+func WithAnalyticsStorage(as AnalyticsStorage) Option {
+	return func(s *Service) {
+		s.analyticsStorage = as
+	}
+}
+
+// WithAnalyticsCacheTTL sets how long the analytics are cached, a minute by
+// default. A TTL of zero disables the cache.
+func WithAnalyticsCacheTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.analyticsCache.ttl = ttl
+	}
+}
+
+// WithAvailabilityStorage sets the storage of the availability of the cats.
+// Assignments are checked against it when it is set.
+func WithAvailabilityStorage(as AvailabilityStorage) Option {
+	return func(s *Service) {
+		s.availabilityStorage = as
+	}
+}
+
+// WithSkillStorage sets the storage of the skills of the cats and the targets.
+func WithSkillStorage(ss SkillStorage) Option {
+	return func(s *Service) {
+		s.skillStorage = ss
+	}
+}
+
+// WithCostStorage sets the storage of the budgets and the expenses of the missions.
+func WithCostStorage(cs CostStorage) Option {
+	return func(s *Service) {
+		s.costStorage = cs
+	}
+}
+
+// WithRateStorage sets the storage of the exchange rates. Without it, only
+// the amounts in the reporting currency are converted.
+func WithRateStorage(rs RateStorage) Option {
+	return func(s *Service) {
+		s.rateStorage = rs
+	}
+}
+
+// WithReportingCurrency sets the currency the amounts are reported in, USD by
+// default. The code must be of an ISO 4217 currency.
+func WithReportingCurrency(code string) Option {
+	return func(s *Service) {
+		s.reportingCurrency = code
+	}
+}
+
+// WithLocationStorage sets the storage of the locations of the targets.
+func WithLocationStorage(ls LocationStorage) Option {
+	return func(s *Service) {
+		s.locationStorage = ls
+	}
+}
+
+// WithSubjectStorage sets the storage of the subjects of the targets. New
+// targets are linked to subjects and the changes of their notes are recorded
+// when it is set.
+func WithSubjectStorage(ss SubjectStorage) Option {
+	return func(s *Service) {
+		s.subjectStorage = ss
+	}
+}
+
+// WithAttachments sets the storage of the attachments of the targets and the
+// store of their content.
+func WithAttachments(as AttachmentStorage, bs BlobStore) Option {
+	return func(s *Service) {
+		s.attachmentStorage = as
+		s.blobStore = bs
+	}
+}
+
+// WithMaxAttachmentSize sets the size limit of an attachment in bytes, 10MB
+// by default.
+func WithMaxAttachmentSize(size int64) Option {
+	return func(s *Service) {
+		s.maxAttachmentSize = size
+	}
+}
+
+// WithRules sets the source of the business rules. The default rules are
+// used otherwise.
+func WithRules(rs RuleSource) Option {
+	return func(s *Service) {
+		s.ruleSource = rs
+	}
+}
+
+// WithBreedCatalog sets the catalog cat breeds are validated against.
+// The catalog of The Cat API is used otherwise.
+func WithBreedCatalog(bc BreedCatalog) Option {
+	return func(s *Service) {
+		s.breedCatalog = bc
+	}
 }
 
 // New returns a new Service.
-func NewService(cs CatStorage, ms MissionStorage, ts TargetStorage, txs TransactionalStorage) Service {
-	return Service{
+func NewService(cs CatStorage, ms MissionStorage, ts TargetStorage, txs TransactionalStorage, opts ...Option) Service {
+	s := Service{
 		catStorage:     cs,
 		missionStorage: ms,
 		targetStorage:  ts,
 		txStorage:      txs,
+		breedCatalog:   theCatAPI{},
+		analyticsCache: newAnalyticsCache(defaultAnalyticsTTL),
+
+		reportingCurrency: defaultReportingCurrency,
+		maxAttachmentSize: defaultMaxAttachmentSize,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return s
+}
+
+// currentRules returns the business rules to check a request against.
+func (s Service) currentRules() rules.Rules {
+	if s.ruleSource == nil {
+		return rules.Default()
 	}
+	return s.ruleSource.Get()
 }
@@ -1,17 +1,33 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/blobs"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
 	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/outbox"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockStorage struct {
@@ -43,9 +59,25 @@ func (m *MockStorage) DeleteCat(ctx context.Context, id int32) (int64, error) {
 	return args.Get(0).(int64), args.Error(1)
 }
 
+// MockTx is a transaction that commits and rolls back nothing.
+type MockTx struct{}
+
+func (tx *MockTx) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (tx *MockTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
 // Begin is a dummy implementation to satisfy the Storage interface.
-func (m *MockStorage) Begin(ctx context.Context) (pgx.Tx, error) {
-	return nil, nil
+func (m *MockStorage) Begin(ctx context.Context) (storage.Tx, error) {
+	return &MockTx{}, nil
+}
+
+// BeginTx is a dummy implementation to satisfy the Storage interface.
+func (m *MockStorage) BeginTx(ctx context.Context, opts storage.TxOptions) (storage.Tx, error) {
+	return &MockTx{}, nil
 }
 
 // CreateMission is a dummy implementation to satisfy the Storage interface.
@@ -60,9 +92,9 @@ func (m *MockStorage) CreateTarget(ctx context.Context, params postgres.CreateTa
 	return args.Get(0).(postgres.Target), args.Error(1)
 }
 
-// WithTx is a dummy implementation to satisfy the Storage interface.
-func (m *MockStorage) WithTx(tx pgx.Tx) *postgres.Queries {
-	return nil
+// WithTx returns the mock itself, so queries within a transaction use the same expectations.
+func (m *MockStorage) WithTx(tx storage.Tx) postgres.Querier {
+	return m
 }
 
 func (m *MockStorage) GetAllMissions(ctx context.Context) ([]postgres.Mission, error) {
@@ -75,6 +107,11 @@ func (m *MockStorage) GetMission(ctx context.Context, id int32) (postgres.Missio
 	return args.Get(0).(postgres.Mission), args.Error(1)
 }
 
+func (m *MockStorage) GetMissionForUpdate(ctx context.Context, id int32) (postgres.Mission, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.Mission), args.Error(1)
+}
+
 func (m *MockStorage) GetCatMission(ctx context.Context, id pgtype.Int4) (postgres.Mission, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(postgres.Mission), args.Error(1)
@@ -90,6 +127,16 @@ func (m *MockStorage) AssignCat(ctx context.Context, params postgres.AssignCatPa
 	return args.Get(0).(postgres.Mission), args.Error(1)
 }
 
+func (m *MockStorage) CreateMissionAssignment(ctx context.Context, arg postgres.CreateMissionAssignmentParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStorage) EndMissionAssignment(ctx context.Context, mission int32) error {
+	args := m.Called(ctx, mission)
+	return args.Error(0)
+}
+
 func (m *MockStorage) CompleteMission(ctx context.Context, id int32) (postgres.Mission, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(postgres.Mission), args.Error(1)
@@ -115,489 +162,2516 @@ func (m *MockStorage) DeleteTarget(ctx context.Context, id int32) (int64, error)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockStorage) UpdateTargetNotes(ctx context.Context, params postgres.UpdateTargetNotesParams) (postgres.Target, error) {
-	args := m.Called(ctx, params)
-	return args.Get(0).(postgres.Target), args.Error(1)
+func (m *MockStorage) UpdateTargetNotes(ctx context.Context, params postgres.UpdateTargetNotesParams) (postgres.Target, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) CompleteTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) CreateTargetMove(ctx context.Context, params postgres.CreateTargetMoveParams) (postgres.TargetMove, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.TargetMove), args.Error(1)
+}
+
+func (m *MockStorage) GetTargetMoves(ctx context.Context, target int32) ([]postgres.TargetMove, error) {
+	args := m.Called(ctx, target)
+	return args.Get(0).([]postgres.TargetMove), args.Error(1)
+}
+
+func (m *MockStorage) MoveTarget(ctx context.Context, params postgres.MoveTargetParams) (postgres.Target, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) CreateWebhook(ctx context.Context, params postgres.CreateWebhookParams) (postgres.Webhook, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.Webhook), args.Error(1)
+}
+
+func (m *MockStorage) GetAllWebhooks(ctx context.Context) ([]postgres.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Webhook), args.Error(1)
+}
+
+func (m *MockStorage) GetWebhook(ctx context.Context, id int32) (postgres.Webhook, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.Webhook), args.Error(1)
+}
+
+func (m *MockStorage) UpdateWebhook(ctx context.Context, params postgres.UpdateWebhookParams) (postgres.Webhook, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.Webhook), args.Error(1)
+}
+
+func (m *MockStorage) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) EnqueueWebhookDeliveries(ctx context.Context, params postgres.EnqueueWebhookDeliveriesParams) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) ClaimWebhookDeliveries(ctx context.Context, params postgres.ClaimWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]postgres.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockStorage) MarkWebhookDeliverySucceeded(ctx context.Context, params postgres.MarkWebhookDeliverySucceededParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockStorage) MarkWebhookDeliveryFailed(ctx context.Context, params postgres.MarkWebhookDeliveryFailedParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetWebhookDeliveries(ctx context.Context, params postgres.GetWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]postgres.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockStorage) RedeliverWebhookDelivery(ctx context.Context, params postgres.RedeliverWebhookDeliveryParams) (postgres.WebhookDelivery, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockStorage) CreateOutboxEvent(ctx context.Context, params postgres.CreateOutboxEventParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetUnpublishedOutboxEvents(ctx context.Context, arg postgres.GetUnpublishedOutboxEventsParams) ([]postgres.Outbox, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Outbox), args.Error(1)
+}
+
+func (m *MockStorage) MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) GetOutboxEvents(ctx context.Context, params postgres.GetOutboxEventsParams) ([]postgres.Outbox, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]postgres.Outbox), args.Error(1)
+}
+
+func (m *MockStorage) ResetOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	args := m.Called(ctx, fromOffset)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) SearchTargets(ctx context.Context, params postgres.SearchTargetsParams) ([]postgres.SearchTargetsRow, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]postgres.SearchTargetsRow), args.Error(1)
+}
+
+func (m *MockStorage) CreateMissionTemplate(ctx context.Context, params postgres.CreateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.MissionTemplate), args.Error(1)
+}
+
+func (m *MockStorage) GetAllMissionTemplates(ctx context.Context) ([]postgres.MissionTemplate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.MissionTemplate), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionTemplate(ctx context.Context, id int32) (postgres.MissionTemplate, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.MissionTemplate), args.Error(1)
+}
+
+func (m *MockStorage) UpdateMissionTemplate(ctx context.Context, params postgres.UpdateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(postgres.MissionTemplate), args.Error(1)
+}
+
+func (m *MockStorage) DeleteMissionTemplate(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) GetAllTargets(ctx context.Context) ([]postgres.Target, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) RestoreCat(ctx context.Context, params postgres.RestoreCatParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockStorage) RestoreMission(ctx context.Context, params postgres.RestoreMissionParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockStorage) RestoreTarget(ctx context.Context, params postgres.RestoreTargetParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ResetSequences(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetCatsPage(ctx context.Context, arg postgres.GetCatsPageParams) ([]postgres.Cat, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Cat), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionsPage(ctx context.Context, arg postgres.GetMissionsPageParams) ([]postgres.Mission, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Mission), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionTargetsPage(ctx context.Context, arg postgres.GetMissionTargetsPageParams) ([]postgres.GetMissionTargetsPageRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.GetMissionTargetsPageRow), args.Error(1)
+}
+
+func (m *MockStorage) GetCatWorkloadPage(ctx context.Context, arg postgres.GetCatWorkloadPageParams) ([]postgres.GetCatWorkloadPageRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.GetCatWorkloadPageRow), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionCompletionRate(ctx context.Context, arg postgres.GetMissionCompletionRateParams) ([]postgres.GetMissionCompletionRateRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.GetMissionCompletionRateRow), args.Error(1)
+}
+
+func (m *MockStorage) GetCompletionTimes(ctx context.Context) (postgres.GetCompletionTimesRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(postgres.GetCompletionTimesRow), args.Error(1)
+}
+
+func (m *MockStorage) GetCatUtilization(ctx context.Context, now pgtype.Timestamptz) ([]postgres.GetCatUtilizationRow, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]postgres.GetCatUtilizationRow), args.Error(1)
+}
+
+func (m *MockStorage) GetTargetsPerCountry(ctx context.Context) ([]postgres.GetTargetsPerCountryRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.GetTargetsPerCountryRow), args.Error(1)
+}
+
+func (m *MockStorage) GetSalaryPerformance(ctx context.Context) ([]postgres.GetSalaryPerformanceRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.GetSalaryPerformanceRow), args.Error(1)
+}
+
+func (m *MockStorage) CreateAvailabilityPeriod(ctx context.Context, arg postgres.CreateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.AvailabilityPeriod), args.Error(1)
+}
+
+func (m *MockStorage) GetCatAvailabilityPeriods(ctx context.Context, cat int32) ([]postgres.AvailabilityPeriod, error) {
+	args := m.Called(ctx, cat)
+	return args.Get(0).([]postgres.AvailabilityPeriod), args.Error(1)
+}
+
+func (m *MockStorage) GetAvailabilityPeriod(ctx context.Context, id int32) (postgres.AvailabilityPeriod, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.AvailabilityPeriod), args.Error(1)
+}
+
+func (m *MockStorage) UpdateAvailabilityPeriod(ctx context.Context, arg postgres.UpdateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.AvailabilityPeriod), args.Error(1)
+}
+
+func (m *MockStorage) DeleteAvailabilityPeriod(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) GetOverlappingAvailabilityPeriods(ctx context.Context, arg postgres.GetOverlappingAvailabilityPeriodsParams) ([]postgres.AvailabilityPeriod, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.AvailabilityPeriod), args.Error(1)
+}
+
+func (m *MockStorage) GetAvailableCats(ctx context.Context, at pgtype.Timestamptz) ([]postgres.Cat, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]postgres.Cat), args.Error(1)
+}
+
+func (m *MockStorage) GetCatsAvailableBetween(ctx context.Context, arg postgres.GetCatsAvailableBetweenParams) ([]postgres.Cat, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Cat), args.Error(1)
+}
+
+func (m *MockStorage) CreateSkill(ctx context.Context, name string) (postgres.Skill, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(postgres.Skill), args.Error(1)
+}
+
+func (m *MockStorage) GetAllSkills(ctx context.Context) ([]postgres.Skill, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Skill), args.Error(1)
+}
+
+func (m *MockStorage) GetSkill(ctx context.Context, id int32) (postgres.Skill, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.Skill), args.Error(1)
+}
+
+func (m *MockStorage) DeleteSkill(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) SetCatSkill(ctx context.Context, arg postgres.SetCatSkillParams) (postgres.CatSkill, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.CatSkill), args.Error(1)
+}
+
+func (m *MockStorage) GetCatSkills(ctx context.Context, cat int32) ([]postgres.GetCatSkillsRow, error) {
+	args := m.Called(ctx, cat)
+	return args.Get(0).([]postgres.GetCatSkillsRow), args.Error(1)
+}
+
+func (m *MockStorage) DeleteCatSkill(ctx context.Context, arg postgres.DeleteCatSkillParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) SetTargetSkill(ctx context.Context, arg postgres.SetTargetSkillParams) (postgres.TargetSkill, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.TargetSkill), args.Error(1)
+}
+
+func (m *MockStorage) GetTargetSkills(ctx context.Context, target int32) ([]postgres.GetTargetSkillsRow, error) {
+	args := m.Called(ctx, target)
+	return args.Get(0).([]postgres.GetTargetSkillsRow), args.Error(1)
+}
+
+func (m *MockStorage) DeleteTargetSkill(ctx context.Context, arg postgres.DeleteTargetSkillParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionRequiredSkills(ctx context.Context, mission int32) ([]postgres.GetMissionRequiredSkillsRow, error) {
+	args := m.Called(ctx, mission)
+	return args.Get(0).([]postgres.GetMissionRequiredSkillsRow), args.Error(1)
+}
+
+func (m *MockStorage) GetUnassignedCats(ctx context.Context) ([]postgres.Cat, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Cat), args.Error(1)
+}
+
+func (m *MockStorage) GetUnassignedCatSkills(ctx context.Context) ([]postgres.CatSkill, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.CatSkill), args.Error(1)
+}
+
+func (m *MockStorage) SetMissionBudget(ctx context.Context, arg postgres.SetMissionBudgetParams) (postgres.MissionBudget, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.MissionBudget), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionBudget(ctx context.Context, mission int32) (postgres.MissionBudget, error) {
+	args := m.Called(ctx, mission)
+	return args.Get(0).(postgres.MissionBudget), args.Error(1)
+}
+
+func (m *MockStorage) DeleteMissionBudget(ctx context.Context, mission int32) (int64, error) {
+	args := m.Called(ctx, mission)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) CreateMissionExpense(ctx context.Context, arg postgres.CreateMissionExpenseParams) (postgres.MissionExpense, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.MissionExpense), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionExpenses(ctx context.Context, mission int32) ([]postgres.MissionExpense, error) {
+	args := m.Called(ctx, mission)
+	return args.Get(0).([]postgres.MissionExpense), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionExpense(ctx context.Context, id int32) (postgres.MissionExpense, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.MissionExpense), args.Error(1)
+}
+
+func (m *MockStorage) DeleteMissionExpense(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) SetExchangeRate(ctx context.Context, arg postgres.SetExchangeRateParams) (postgres.ExchangeRate, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.ExchangeRate), args.Error(1)
+}
+
+func (m *MockStorage) GetExchangeRates(ctx context.Context) ([]postgres.ExchangeRate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.ExchangeRate), args.Error(1)
+}
+
+func (m *MockStorage) DeleteExchangeRate(ctx context.Context, currency string) (int64, error) {
+	args := m.Called(ctx, currency)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) UpdateTargetLocation(ctx context.Context, arg postgres.UpdateTargetLocationParams) (postgres.Target, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) GetTargetsNear(ctx context.Context, arg postgres.GetTargetsNearParams) ([]postgres.GetTargetsNearRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.GetTargetsNearRow), args.Error(1)
+}
+
+func (m *MockStorage) CreateSubject(ctx context.Context, arg postgres.CreateSubjectParams) (postgres.Subject, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.Subject), args.Error(1)
+}
+
+func (m *MockStorage) GetSubject(ctx context.Context, id int32) (postgres.Subject, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.Subject), args.Error(1)
+}
+
+func (m *MockStorage) GetSubjectsByIDs(ctx context.Context, ids []int32) ([]postgres.Subject, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]postgres.Subject), args.Error(1)
+}
+
+func (m *MockStorage) GetSimilarSubjects(ctx context.Context, arg postgres.GetSimilarSubjectsParams) ([]postgres.Subject, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Subject), args.Error(1)
+}
+
+func (m *MockStorage) GetAllSubjects(ctx context.Context) ([]postgres.Subject, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]postgres.Subject), args.Error(1)
+}
+
+func (m *MockStorage) DeleteSubject(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) GetSubjectTargets(ctx context.Context, subject pgtype.Int4) ([]postgres.Target, error) {
+	args := m.Called(ctx, subject)
+	return args.Get(0).([]postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) MoveSubjectTargets(ctx context.Context, arg postgres.MoveSubjectTargetsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) CreateTargetNote(ctx context.Context, arg postgres.CreateTargetNoteParams) (postgres.TargetNote, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.TargetNote), args.Error(1)
+}
+
+func (m *MockStorage) GetSubjectNotes(ctx context.Context, subject pgtype.Int4) ([]postgres.TargetNote, error) {
+	args := m.Called(ctx, subject)
+	return args.Get(0).([]postgres.TargetNote), args.Error(1)
+}
+
+func (m *MockStorage) GetSubjectsPage(ctx context.Context, arg postgres.GetSubjectsPageParams) ([]postgres.Subject, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Subject), args.Error(1)
+}
+
+func (m *MockStorage) GetTargetsPage(ctx context.Context, arg postgres.GetTargetsPageParams) ([]postgres.Target, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]postgres.Target), args.Error(1)
+}
+
+func (m *MockStorage) RestoreSubject(ctx context.Context, arg postgres.RestoreSubjectParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CreateAttachment(ctx context.Context, arg postgres.CreateAttachmentParams) (postgres.Attachment, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(postgres.Attachment), args.Error(1)
+}
+
+func (m *MockStorage) GetAttachment(ctx context.Context, id int32) (postgres.Attachment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(postgres.Attachment), args.Error(1)
+}
+
+func (m *MockStorage) GetTargetAttachments(ctx context.Context, target int32) ([]postgres.Attachment, error) {
+	args := m.Called(ctx, target)
+	return args.Get(0).([]postgres.Attachment), args.Error(1)
+}
+
+func (m *MockStorage) GetMissionAttachments(ctx context.Context, mission int32) ([]postgres.Attachment, error) {
+	args := m.Called(ctx, mission)
+	return args.Get(0).([]postgres.Attachment), args.Error(1)
+}
+
+func (m *MockStorage) DeleteAttachment(ctx context.Context, id int32) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockStorage) SchemaVersion(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+//-------------------------------------
+// CATS TESTS
+//-------------------------------------
+
+func TestGetAllCats(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		created := newTestCat(t, st)
+
+		cats, err := service.GetAllCats(context.Background())
+		assert.NoError(t, err)
+		i := slices.IndexFunc(cats, func(c models.Cat) bool { return c.ID == created.ID })
+		require.NotEqual(t, -1, i)
+		assert.Equal(t, "Tom", cats[i].Name)
+	})
+}
+
+func TestGetAllCats_MissingExchangeRate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st, WithRateStorage(st))
+		ctx := context.Background()
+		usd := newTestCat(t, st)
+		eur, err := st.CreateCat(ctx, postgres.CreateCatParams{Name: "Felix", Breed: "Siamese", YearsOfExperience: 2, Salary: 20000, SalaryCurrency: "EUR"})
+		require.NoError(t, err)
+
+		cats, err := service.GetAllCats(ctx)
+		require.NoError(t, err)
+		byID := make(map[int32]models.Cat, len(cats))
+		for _, c := range cats {
+			byID[c.ID] = c
+		}
+		assert.Equal(t, &models.Money{Amount: 10000, Currency: "USD"}, byID[usd.ID].ReportingSalary)
+		assert.Nil(t, byID[eur.ID].ReportingSalary, "EUR has no exchange rate")
+		assert.Equal(t, int32(200), byID[eur.ID].Salary)
+	})
+}
+
+func TestGetAllCats_RatesError(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithRateStorage(mockStorage))
+
+	mockStorage.On("GetAllCats", mock.Anything).Return([]postgres.Cat{
+		{ID: 1, Name: "Tom", Salary: 10000, SalaryCurrency: "USD"},
+		{ID: 2, Name: "Felix", Salary: 20000, SalaryCurrency: "EUR"},
+	}, nil)
+	mockStorage.On("GetExchangeRates", mock.Anything).Return([]postgres.ExchangeRate(nil), errors.New("database error"))
+
+	cats, err := service.GetAllCats(context.Background())
+	require.NoError(t, err, "the cats are listed without the exchange rates")
+	require.Len(t, cats, 2)
+	assert.Equal(t, &models.Money{Amount: 10000, Currency: "USD"}, cats[0].ReportingSalary)
+	assert.Nil(t, cats[1].ReportingSalary)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetAllCats_StorageError(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+
+	mockStorage.On("GetAllCats", mock.Anything).Return([]postgres.Cat{}, errors.New("database error"))
+
+	cats, err := service.GetAllCats(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []models.Cat{}, cats)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetCat(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		created := newTestCat(t, st)
+
+		cat, err := service.GetCat(context.Background(), created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, created, cat)
+	})
+}
+
+func TestGetCat_NotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		cat, err := service.GetCat(context.Background(), missingID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+		assert.Equal(t, models.Cat{}, cat)
+	})
+}
+
+// staticBreeds is a BreedCatalog of fixed breeds that counts its lookups.
+type staticBreeds struct {
+	breeds  []string
+	lookups *int
+}
+
+func newStaticBreeds(breeds ...string) staticBreeds {
+	return staticBreeds{breeds: breeds, lookups: new(int)}
+}
+
+func (b staticBreeds) Breeds(ctx context.Context) ([]string, error) {
+	*b.lookups++
+	return b.breeds, nil
+}
+
+func TestCreateCat(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st, WithBreedCatalog(newStaticBreeds("Siamese")))
+		ctx := context.Background()
+
+		cat, err := service.CreateCat(ctx, models.CreateCatRequest{
+			Name:              "Tom",
+			Breed:             "Siamese",
+			YearsOfExperience: 5,
+			Salary:            5000,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Tom", cat.Name)
+		assert.Equal(t, int32(5000), cat.Salary)
+		assert.Equal(t, "USD", cat.SalaryCurrency)
+
+		stored, err := service.GetCat(ctx, cat.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cat, stored)
+
+		row, err := st.GetCat(ctx, cat.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(500000), row.Salary, "the salary is stored in cents")
+	})
+}
+
+func TestCreateCat_MinSalaryInReportingCurrency(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		r := rules.Default()
+		r.Cats.MinSalary = 1000
+		service := NewService(st, st, st, st, WithBreedCatalog(newStaticBreeds("Siamese")), WithRateStorage(st), WithRules(staticRules(r)))
+		ctx := context.Background()
+
+		// 100000 JPY is 670 USD, less than the minimum of 1000 USD.
+		req := models.CreateCatRequest{Name: "Tom", Breed: "Siamese", YearsOfExperience: 5, Salary: 100000, SalaryCurrency: "JPY"}
+		_, err := service.CreateCat(ctx, req)
+		assert.EqualError(t, err, "no exchange rate for JPY")
+
+		_, err = service.SetExchangeRate(ctx, "JPY", models.SetExchangeRateRequest{Rate: 0.0067})
+		require.NoError(t, err)
+		_, err = service.CreateCat(ctx, req)
+		assert.EqualError(t, err, "salary must be greater than or equal to 1000 USD")
+
+		// 900 EUR is 990 USD, and 1000 EUR is 1100 USD.
+		_, err = service.SetExchangeRate(ctx, "EUR", models.SetExchangeRateRequest{Rate: 1.1})
+		require.NoError(t, err)
+		_, err = service.CreateCat(ctx, models.CreateCatRequest{Name: "Tom", Breed: "Siamese", YearsOfExperience: 5, Salary: 900, SalaryCurrency: "EUR"})
+		assert.EqualError(t, err, "salary must be greater than or equal to 1000 USD")
+		cat, err := service.CreateCat(ctx, models.CreateCatRequest{Name: "Tom", Breed: "Siamese", YearsOfExperience: 5, Salary: 1000, SalaryCurrency: "EUR"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1000), cat.Salary)
+	})
+}
+
+func TestCreateCat_SalaryCurrency(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st, WithBreedCatalog(newStaticBreeds("Siamese")), WithReportingCurrency("EUR"))
+		ctx := context.Background()
+
+		req := models.CreateCatRequest{Name: "Tom", Breed: "Siamese", YearsOfExperience: 5, Salary: 5000, SalaryCurrency: "jpy"}
+		cat, err := service.CreateCat(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, "JPY", cat.SalaryCurrency)
+
+		before, err := st.GetAllCats(ctx)
+		require.NoError(t, err)
+
+		req.SalaryCurrency = "YEN"
+		_, err = service.CreateCat(ctx, req)
+		assert.EqualError(t, err, "salary_currency must be an ISO 4217 code")
+
+		after, err := st.GetAllCats(ctx)
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+}
+
+func TestCreateCat_UnknownBreed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st, WithBreedCatalog(newStaticBreeds("Siamese")))
+		ctx := context.Background()
+
+		before, err := st.GetAllCats(ctx)
+		require.NoError(t, err)
+
+		_, err = service.CreateCat(ctx, models.CreateCatRequest{
+			Name:              "Tom",
+			Breed:             "Sphinx",
+			YearsOfExperience: 5,
+			Salary:            5000,
+		})
+		assert.EqualError(t, err, "Unknown breed")
+
+		after, err := st.GetAllCats(ctx)
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+}
+
+func TestUpdateCatSalary(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		created := newTestCat(t, st)
+
+		cat, err := service.UpdateCatSalary(ctx, models.UpdateCatSalaryRequest{
+			Salary: 6000,
+		}, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(6000), cat.Salary)
+		assert.Equal(t, "USD", cat.SalaryCurrency)
+
+		stored, err := service.GetCat(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cat, stored)
+	})
+}
+
+func TestUpdateCatSalary_Currency(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		created := newTestCat(t, st)
+
+		cat, err := service.UpdateCatSalary(ctx, models.UpdateCatSalaryRequest{Salary: 6000, SalaryCurrency: "eur"}, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "EUR", cat.SalaryCurrency)
+
+		_, err = service.UpdateCatSalary(ctx, models.UpdateCatSalaryRequest{Salary: 7000, SalaryCurrency: "euro"}, created.ID)
+		assert.EqualError(t, err, "salary_currency must be an ISO 4217 code")
+
+		stored, err := service.GetCat(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cat, stored)
+	})
+}
+
+func TestUpdateCatSalary_InvalidID(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		_, err := service.UpdateCatSalary(context.Background(), models.UpdateCatSalaryRequest{Salary: 7000}, missingID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
+
+func TestUpdateCatSalary_NegativeSalary(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		created := newTestCat(t, st)
+
+		_, err := service.UpdateCatSalary(context.Background(), models.UpdateCatSalaryRequest{Salary: -500}, created.ID)
+		assert.EqualError(t, err, "salary must be greater than or equal to 0")
+
+		_, err = service.UpdateCatSalary(context.Background(), models.UpdateCatSalaryRequest{Salary: -500, SalaryCurrency: "EUR"}, created.ID)
+		assert.EqualError(t, err, "salary must be greater than or equal to 0")
+	})
+}
+
+func TestUpdateCatSalary_BelowMinSalary(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		r := rules.Default()
+		r.Cats.MinSalary = 1000
+		service := NewService(st, st, st, st, WithRateStorage(st), WithRules(staticRules(r)))
+		ctx := context.Background()
+		created := newTestCat(t, st)
+
+		_, err := service.UpdateCatSalary(ctx, models.UpdateCatSalaryRequest{Salary: 500}, created.ID)
+		assert.EqualError(t, err, "salary must be greater than or equal to 1000")
+
+		// The salary is compared in the reporting currency, also when the
+		// cat keeps its currency: 1000 EUR is 900 USD.
+		_, err = service.SetExchangeRate(ctx, "EUR", models.SetExchangeRateRequest{Rate: 0.9})
+		require.NoError(t, err)
+		_, err = service.UpdateCatSalary(ctx, models.UpdateCatSalaryRequest{Salary: 1200, SalaryCurrency: "EUR"}, created.ID)
+		require.NoError(t, err)
+		_, err = service.UpdateCatSalary(ctx, models.UpdateCatSalaryRequest{Salary: 1000}, created.ID)
+		assert.EqualError(t, err, "salary must be greater than or equal to 1000 USD")
+	})
+}
+
+func TestDeleteCat(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		cat := newTestCat(t, st)
+
+		err := service.DeleteCat(ctx, cat.ID)
+		assert.NoError(t, err)
+
+		_, err = service.GetCat(ctx, cat.ID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
+
+func TestDeleteCat_NotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		err := service.DeleteCat(context.Background(), missingID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
+
+//-------------------------------------
+// MISSION TESTS
+//-------------------------------------
+
+func TestGetAllMissions_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		cat := newTestCat(t, st)
+		assigned := newTestMission(t, service, "Ivan")
+		_, err := service.AssignCatToMission(ctx, assigned.ID, models.AssignCatRequest{Assignee: cat.ID})
+		require.NoError(t, err)
+		unassigned := newTestMission(t, service, "Olga")
+
+		missions, err := service.GetAllMissions(ctx)
+		assert.NoError(t, err)
+
+		byID := make(map[int32]models.Mission)
+		for _, m := range missions {
+			byID[m.ID] = m
+		}
+		assert.Equal(t, cat.ID, byID[assigned.ID].Assignee)
+		assert.Contains(t, byID, unassigned.ID)
+		assert.Zero(t, byID[unassigned.ID].Assignee)
+
+		// The targets are listed with their missions.
+		assert.Equal(t, assigned.Targets, byID[assigned.ID].Targets)
+		assert.Equal(t, unassigned.Targets, byID[unassigned.ID].Targets)
+	})
+}
+
+func TestGetAllMissions_StorageError(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+
+	mockStorage.On("GetAllMissions", mock.Anything).Return([]postgres.Mission{}, errors.New("db error"))
+
+	missions, err := service.GetAllMissions(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, missions, 0)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetMission_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		created := newTestMission(t, service, "Target1", "Target2")
+
+		mission, err := service.GetMission(context.Background(), created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, mission.ID)
+		assert.Len(t, mission.Targets, 2)
+	})
+}
+
+func TestGetMission_NotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		mission, err := service.GetMission(context.Background(), missingID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+		assert.Equal(t, models.Mission{}, mission)
+	})
+}
+
+func TestCreateMission_InvalidTargetsCount(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+
+	// Zero targets.
+	reqZero := models.CreateMissionRequest{
+		Targets: []models.CreateTargetRequest{},
+	}
+	_, err := service.CreateMission(context.Background(), reqZero)
+	assert.Error(t, err)
+
+	// Too many targets.
+	reqTooMany := models.CreateMissionRequest{
+		Targets: []models.CreateTargetRequest{
+			{Name: "A", Country: "X", Notes: "N"},
+			{Name: "B", Country: "Y", Notes: "N"},
+			{Name: "C", Country: "Z", Notes: "N"},
+			{Name: "D", Country: "W", Notes: "N"},
+		},
+	}
+	_, err = service.CreateMission(context.Background(), reqTooMany)
+	assert.Error(t, err)
+}
+
+func TestCreateMission_TargetNotesTooLong(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+
+	// Target note longer than the 4096 characters of the default rules.
+	tooLong := strings.Repeat("a", 4100)
+
+	assert.True(t, utf8.RuneCountInString(tooLong) > 4096)
+
+	req := models.CreateMissionRequest{
+		Targets: []models.CreateTargetRequest{
+			{Name: "Alpha", Country: "CountryX", Notes: tooLong},
+		},
+	}
+	_, err := service.CreateMission(context.Background(), req)
+	assert.Error(t, err)
+}
+
+func TestDeleteMission_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		mission := newTestMission(t, service, "Ivan")
+
+		err := service.DeleteMission(ctx, mission.ID)
+		assert.NoError(t, err)
+
+		_, err = service.GetMission(ctx, mission.ID)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
+
+func TestDeleteMission_AssignedMission(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		// Cannot delete a mission that has an assignee.
+		mission := newTestMission(t, service, "Ivan")
+		_, err := service.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: newTestCat(t, st).ID})
+		require.NoError(t, err)
+
+		err = service.DeleteMission(ctx, mission.ID)
+		assert.Error(t, err)
+
+		_, err = service.GetMission(ctx, mission.ID)
+		assert.NoError(t, err)
+	})
+}
+
+// staticRules is a RuleSource of fixed rules.
+type staticRules rules.Rules
+
+func (r staticRules) Get() rules.Rules {
+	return rules.Rules(r)
+}
+
+func TestCreateMission_Rules(t *testing.T) {
+	mockStorage := new(MockStorage)
+	r := rules.Default()
+	r.Targets.Max = 1
+	r.Targets.MaxNotesLength = 4
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithRules(staticRules(r)))
+
+	_, err := service.CreateMission(context.Background(), models.CreateMissionRequest{
+		Targets: []models.CreateTargetRequest{
+			{Name: "A", Country: "X", Notes: "N"},
+			{Name: "B", Country: "Y", Notes: "N"},
+		},
+	})
+	assert.EqualError(t, err, "incorrect number of targets (1..1)")
+
+	_, err = service.CreateMission(context.Background(), models.CreateMissionRequest{
+		Targets: []models.CreateTargetRequest{{Name: "A", Country: "X", Notes: "Notes"}},
+	})
+	assert.EqualError(t, err, "notes are too long (0..4)")
+}
+
+func TestDeleteMission_AssignedMissionAllowed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		r := rules.Default()
+		r.Missions.DeleteAssigned = true
+		service := NewService(st, st, st, st, WithRules(staticRules(r)))
+		ctx := context.Background()
+
+		mission := newTestMission(t, service, "Ivan")
+		_, err := service.AssignCatToMission(ctx, mission.ID, models.AssignCatRequest{Assignee: newTestCat(t, st).ID})
+		require.NoError(t, err)
+
+		err = service.DeleteMission(ctx, mission.ID)
+		assert.NoError(t, err)
+	})
+}
+
+//-------------------------------------
+// TARGET TESTS
+//-------------------------------------
+
+func TestAddTarget_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		created := newTestMission(t, service, "Ivan")
+
+		req := models.CreateTargetRequest{
+			Name:    "TargetX",
+			Country: "CountryX",
+			Notes:   "Note",
+		}
+		mission, err := service.AddTarget(context.Background(), created.ID, req)
+		assert.NoError(t, err)
+		require.Len(t, mission.Targets, 2)
+		assert.Equal(t, "TargetX", mission.Targets[1].Name)
+	})
+}
+
+func TestAddTarget_TooManyTargets(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		// Mission with three existing targets.
+		created := newTestMission(t, service, "Ivan", "Olga", "Hans")
+
+		req := models.CreateTargetRequest{
+			Name:    "ExtraTarget",
+			Country: "CountryY",
+			Notes:   "Note",
+		}
+		_, err := service.AddTarget(ctx, created.ID, req)
+		assert.Error(t, err)
+
+		mission, err := service.GetMission(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Len(t, mission.Targets, 3)
+	})
+}
+
+func TestCloneMission_NoPendingTargets(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		// Mission with completed targets only.
+		mission := newTestMission(t, service, "Ivan")
+		_, err := service.CompleteTarget(ctx, mission.Targets[0].ID)
+		require.NoError(t, err)
+
+		before, err := st.GetAllMissions(ctx)
+		require.NoError(t, err)
+
+		_, err = service.CloneMission(ctx, mission.ID, models.CloneMissionRequest{})
+		assert.Error(t, err)
+
+		after, err := st.GetAllMissions(ctx)
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+}
+
+func TestValidateSplit(t *testing.T) {
+	targets := []postgres.Target{{ID: 1}, {ID: 2, Completed: true}, {ID: 3}}
+
+	tests := []struct {
+		name    string
+		move    []int32
+		wantErr string
+	}{
+		{name: "pending target", move: []int32{1}},
+		{name: "pending targets", move: []int32{3, 1}},
+		{name: "no targets", move: nil, wantErr: "no targets to move"},
+		{name: "over the limit", move: []int32{1, 3, 4, 5}, wantErr: "incorrect number of targets (1..3)"},
+		{name: "completed target", move: []int32{2}, wantErr: "can't move completed target 2"},
+		{name: "other mission", move: []int32{4}, wantErr: "target 4 doesn't belong to the mission"},
+		{name: "duplicate", move: []int32{1, 1}, wantErr: "target 1 is listed twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSplit(rules.Default(), targets, tt.move)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	// At least the minimum of targets is left.
+	assert.EqualError(t, validateSplit(rules.Default(), targets[:1], []int32{1}), "can't move all targets of the mission")
+
+	r := rules.Default()
+	r.Targets.Min = 2
+	assert.EqualError(t, validateSplit(r, targets, []int32{1, 3}), "can't move all targets of the mission")
+}
+
+func TestSplitMission_NotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		_, err := service.SplitMission(context.Background(), missingID, models.SplitMissionRequest{Targets: []int32{1}})
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
+
+func TestMoveTarget_DestinationFull(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		from := newTestMission(t, service, "Ivan", "Olga")
+		to := newTestMission(t, service, "Hans", "Anna", "Piotr")
+		target := from.Targets[0].ID
+
+		_, err := service.MoveTarget(ctx, from.ID, target, models.MoveTargetRequest{Mission: to.ID})
+		assert.EqualError(t, err, "mission has maximum targets (3)")
+
+		mission, err := service.GetMission(ctx, from.ID)
+		require.NoError(t, err)
+		assert.Len(t, mission.Targets, 2)
+
+		moves, err := service.GetTargetMoves(ctx, target)
+		require.NoError(t, err)
+		assert.Empty(t, moves)
+	})
+}
+
+func TestMoveTarget_RecordsMove(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		from := newTestMission(t, service, "Ivan", "Olga")
+		to := newTestMission(t, service, "Hans")
+		target := from.Targets[0].ID
+
+		mission, err := service.MoveTarget(ctx, from.ID, target, models.MoveTargetRequest{Mission: to.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, to.ID, mission.ID)
+		assert.Len(t, mission.Targets, 2)
+
+		moves, err := service.GetTargetMoves(ctx, target)
+		require.NoError(t, err)
+		require.Len(t, moves, 1)
+		assert.Equal(t, from.ID, moves[0].FromMission)
+		assert.Equal(t, to.ID, moves[0].ToMission)
+	})
+}
+
+func TestMoveTarget_Concurrent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+
+		// The destination has room for one of the targets only.
+		first := newTestMission(t, service, "Ivan", "Olga")
+		second := newTestMission(t, service, "Piotr", "Marta")
+		to := newTestMission(t, service, "Hans", "Anna")
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, from := range []models.Mission{first, second} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = service.MoveTarget(ctx, from.ID, from.Targets[0].ID, models.MoveTargetRequest{Mission: to.ID})
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, slices.DeleteFunc(errs, func(err error) bool { return err == nil }), 1, "one of the moves fails")
+
+		mission, err := service.GetMission(ctx, to.ID)
+		require.NoError(t, err)
+		assert.Len(t, mission.Targets, 3)
+	})
+}
+
+func TestDeleteTarget_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		created := newTestMission(t, service, "Ivan", "Olga")
+
+		err := service.DeleteTarget(ctx, created.Targets[1].ID)
+		assert.NoError(t, err)
+
+		mission, err := service.GetMission(ctx, created.ID)
+		require.NoError(t, err)
+		require.Len(t, mission.Targets, 1)
+		assert.Equal(t, created.Targets[0].ID, mission.Targets[0].ID)
+	})
+}
+
+func TestDeleteTarget_NotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		err := service.DeleteTarget(context.Background(), missingID)
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateTargetNotes_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		mission := newTestMission(t, service, "Ivan")
+
+		target, err := service.UpdateTargetNotes(ctx, mission.Targets[0].ID, "New Notes")
+		assert.NoError(t, err)
+		assert.Equal(t, "New Notes", target.Notes)
+
+		mission, err = service.GetMission(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "New Notes", mission.Targets[0].Notes)
+	})
+}
+
+func TestUpdateTargetNotes_Empty(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+
+	ctx := context.Background()
+
+	// Can't update target notes to empty.
+	target, err := service.UpdateTargetNotes(ctx, 150, "")
+	assert.Error(t, err)
+	assert.Equal(t, models.Target{}, target)
+}
+
+func TestCompleteTarget_Success(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		mission := newTestMission(t, service, "Ivan")
+
+		target, err := service.CompleteTarget(context.Background(), mission.Targets[0].ID)
+		assert.NoError(t, err)
+		assert.True(t, target.Completed)
+	})
+}
+
+func TestCompleteTarget_NotFound(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+
+		target, err := service.CompleteTarget(context.Background(), missingID)
+		assert.Error(t, err)
+		assert.Equal(t, models.Target{}, target)
+	})
+}
+
+func TestCompleteTarget_RecordsEvent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		service := NewService(st, st, st, st)
+		ctx := context.Background()
+		mission := newTestMission(t, service, "Ivan")
+		target := mission.Targets[0]
+
+		_, err := service.CompleteTarget(ctx, target.ID)
+		assert.NoError(t, err)
+
+		// The event is written to the outbox in the same transaction.
+		rows, err := st.GetOutboxEvents(ctx, postgres.GetOutboxEventsParams{MaxEvents: math.MaxInt32})
+		require.NoError(t, err)
+		i := slices.IndexFunc(rows, func(e postgres.Outbox) bool {
+			return e.AggregateID == mission.ID && e.EventType == events.TargetCompleted
+		})
+		require.NotEqual(t, -1, i)
+		assert.Equal(t, outbox.AggregateMission, rows[i].AggregateType)
+		assert.JSONEq(t, fmt.Sprintf(`{"id":%d,"name":"Ivan","country":"UA","notes":"Notes of Ivan","completed":true}`, target.ID), string(rows[i].Payload))
+	})
+}
+
+//-------------------------------------
+// SEARCH TESTS
+//-------------------------------------
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"embassy", "embassy"},
+		{"Red Square", "red & square"},
+		{`"red square" moscow`, "(red <-> square) & moscow"},
+		{"ivan*", "ivan:*"},
+		{"paris OR berlin", "paris | berlin"},
+		{"embassy -closed", "embassy & !closed"},
+		{"o'neil*", "(o <-> neil:*)"},
+		{"& | ! :*", ""},
+		{`"unterminated phrase`, "(unterminated <-> phrase)"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, buildTSQuery(tt.query), tt.query)
+	}
+}
+
+func TestSearchTargets_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSearchStorage(mockStorage))
+
+	ctx := context.Background()
+
+	mockStorage.On("SearchTargets", mock.Anything, postgres.SearchTargetsParams{
+		Query:      "embass:*",
+		MaxResults: 20,
+	}).Return([]postgres.SearchTargetsRow{
+		{ID: 3, Mission: 1, Name: "Ivan", Country: "RU", Notes: "Near the embassy <script>", MissionAssignee: pgtype.Int4{Int32: 5, Valid: true}, Rank: 0.1, Snippet: "Near the \ue000embassy\ue001 <script>"},
+	}, nil)
+
+	res, err := service.SearchTargets(ctx, "embass*", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TargetSearchResult{{
+		Target:  models.Target{ID: 3, Name: "Ivan", Country: "RU", Notes: "Near the embassy <script>"},
+		Mission: models.Mission{ID: 1, Assignee: 5},
+		Rank:    0.1,
+		Snippet: "Near the <mark>embassy</mark> &lt;script&gt;",
+	}}, res)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestSearchTargets_EmptyQuery(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSearchStorage(mockStorage))
+
+	_, err := service.SearchTargets(context.Background(), " !! ", 20)
+	assert.Error(t, err)
+
+	mockStorage.AssertNotCalled(t, "SearchTargets")
+}
+
+//-------------------------------------
+// MISSION TEMPLATES TESTS
+//-------------------------------------
+
+func TestCreateMissionTemplate_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithTemplateStorage(mockStorage))
+
+	targets := `[{"name":"{{name}}","country":"UA","notes":"Watch {{ place }} in {{city}}"},{"name":"Courier","country":"UA","notes":"Meets {{name}}"}]`
+
+	mockStorage.On("CreateMissionTemplate", mock.Anything, postgres.CreateMissionTemplateParams{
+		Name:    "Surveillance",
+		Targets: []byte(targets),
+	}).Return(postgres.MissionTemplate{ID: 1, Name: "Surveillance", Targets: []byte(targets)}, nil)
+
+	res, err := service.CreateMissionTemplate(context.Background(), models.CreateMissionTemplateRequest{
+		Name: "Surveillance",
+		Targets: []models.TargetBlueprint{
+			{Name: "{{name}}", Country: "UA", Notes: "Watch {{ place }} in {{city}}"},
+			{Name: "Courier", Country: "UA", Notes: "Meets {{name}}"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name", "place", "city"}, res.Variables)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestCreateMissionTemplate_Invalid(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithTemplateStorage(mockStorage))
+
+	tests := []struct {
+		name    string
+		targets []models.TargetBlueprint
+		want    string
+	}{
+		{"Surveillance", nil, "incorrect number of targets (1..3)"},
+		{"", []models.TargetBlueprint{{Name: "Ivan", Country: "UA", Notes: "N"}}, "name must be between 1 and 128 characters long"},
+		{"Surveillance", []models.TargetBlueprint{{Name: "Ivan", Notes: "N"}}, "target 1: missing field: country"},
+		{"Surveillance", []models.TargetBlueprint{{Name: "Ivan", Country: "UA", Notes: "N"}, {Name: "{{first name}}", Country: "UA", Notes: "N"}}, "target 2: invalid placeholder in name"},
+		{"Surveillance", []models.TargetBlueprint{{Name: "Ivan", Country: "UA", Notes: "Seen at {{place"}}, "target 1: invalid placeholder in notes"},
+	}
+
+	for _, tt := range tests {
+		_, err := service.CreateMissionTemplate(context.Background(), models.CreateMissionTemplateRequest{Name: tt.name, Targets: tt.targets})
+		assert.EqualError(t, err, tt.want)
+	}
+
+	mockStorage.AssertNotCalled(t, "CreateMissionTemplate")
+}
+
+func TestInstantiateMissionTemplate(t *testing.T) {
+	template := models.MissionTemplate{
+		Targets: []models.TargetBlueprint{
+			{Name: "{{name}}", Country: "{{country}}", Notes: "Watch {{ place }}, report to {{name}}"},
+		},
+		Variables: []string{"name", "country", "place"},
+	}
+
+	req, err := instantiateMissionTemplate(template, map[string]string{"name": "Ivan", "country": "UA", "place": "the harbour"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.CreateTargetRequest{
+		{Name: "Ivan", Country: "UA", Notes: "Watch the harbour, report to Ivan"},
+	}, req.Targets)
+
+	_, err = instantiateMissionTemplate(template, map[string]string{"name": "Ivan"})
+	assert.EqualError(t, err, "missing variables: country, place")
+
+	_, err = instantiateMissionTemplate(template, map[string]string{"name": "Ivan", "country": "UA", "place": "the harbour", "city": "Kyiv"})
+	assert.EqualError(t, err, "unknown variables: city")
+
+	_, err = instantiateMissionTemplate(template, map[string]string{"name": " ", "country": "UA", "place": "the harbour"})
+	assert.EqualError(t, err, "target 1: name can't be empty")
+}
+
+func TestCreateMissionFromTemplate_NotesTooLong(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithTemplateStorage(mockStorage))
+
+	mockStorage.On("GetMissionTemplate", mock.Anything, int32(1)).Return(postgres.MissionTemplate{
+		ID:      1,
+		Targets: []byte(`[{"name":"Ivan","country":"UA","notes":"{{notes}}"}]`),
+	}, nil)
+
+	// The mission is validated like in CreateMission.
+	_, err := service.CreateMissionFromTemplate(context.Background(), 1, models.CreateMissionFromTemplateRequest{
+		Variables: map[string]string{"notes": strings.Repeat("a", 4097)},
+	})
+	assert.Error(t, err)
+
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "CreateMission")
+}
+
+func TestCreateMissionFromTemplate_NotFound(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithTemplateStorage(mockStorage))
+
+	mockStorage.On("GetMissionTemplate", mock.Anything, int32(9)).Return(postgres.MissionTemplate{}, pgx.ErrNoRows)
+
+	_, err := service.CreateMissionFromTemplate(context.Background(), 9, models.CreateMissionFromTemplateRequest{})
+	assert.ErrorIs(t, err, models.ErrNotFound)
+
+	mockStorage.AssertExpectations(t)
+}
+
+var importCatRows = []models.CatImportRow{
+	{Line: 2, Cat: models.CreateCatRequest{Name: "Tom", Breed: "siamese", YearsOfExperience: 5, Salary: 5000}},
+	{Line: 3, Cat: models.CreateCatRequest{Name: "Kitty", Breed: "Sphinx", YearsOfExperience: 1, Salary: 100}},
+	{Line: 4, Cat: models.CreateCatRequest{Breed: "Siamese", YearsOfExperience: 1, Salary: 100}},
+	{Line: 5, Cat: models.CreateCatRequest{Name: "Leo", Breed: "Siamese"}, Errors: []string{"invalid value for field: salary"}},
+}
+
+func TestImportCats_DryRun(t *testing.T) {
+	mockStorage := new(MockStorage)
+	breeds := newStaticBreeds("Siamese")
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithBreedCatalog(breeds))
+
+	res, err := service.ImportCats(context.Background(), importCatRows, models.ImportOptions{Mode: models.ImportBestEffort, DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, *breeds.lookups, "the breeds of all rows are checked with a single lookup")
+	assert.Equal(t, 4, res.Total)
+	assert.Equal(t, 1, res.Valid)
+	assert.Equal(t, 3, res.Invalid)
+	assert.Equal(t, 0, res.Created)
+	assert.Equal(t, []models.ImportRowResult{
+		{Line: 2, Status: models.ImportRowValid},
+		{Line: 3, Status: models.ImportRowInvalid, Errors: []string{"Unknown breed"}},
+		{Line: 4, Status: models.ImportRowInvalid, Errors: []string{"missing field: name"}},
+		{Line: 5, Status: models.ImportRowInvalid, Errors: []string{"invalid value for field: salary"}},
+	}, res.Rows)
+
+	mockStorage.AssertNotCalled(t, "CreateCat", mock.Anything, mock.Anything)
+}
+
+func TestImportCats_AllOrNothing(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithBreedCatalog(newStaticBreeds("Siamese")))
+
+	res, err := service.ImportCats(context.Background(), importCatRows, models.ImportOptions{Mode: models.ImportAllOrNothing})
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.Created)
+	assert.Equal(t, models.ImportRowValid, res.Rows[0].Status)
+
+	mockStorage.AssertNotCalled(t, "CreateCat", mock.Anything, mock.Anything)
+}
+
+func TestImportCats_BestEffort(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithBreedCatalog(newStaticBreeds("Siamese")))
+
+	mockStorage.On("CreateCat", mock.Anything, postgres.CreateCatParams{
+		Name:              "Tom",
+		Breed:             "siamese",
+		YearsOfExperience: 5,
+		Salary:            500000,
+		SalaryCurrency:    "USD",
+	}).Return(postgres.Cat{ID: 7, Name: "Tom"}, nil).Once()
+
+	res, err := service.ImportCats(context.Background(), importCatRows, models.ImportOptions{Mode: models.ImportBestEffort})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Created)
+	assert.Equal(t, models.ImportRowResult{Line: 2, Status: models.ImportRowCreated, ID: 7}, res.Rows[0])
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestImportCats_Invalid(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithBreedCatalog(newStaticBreeds("Siamese")))
+
+	_, err := service.ImportCats(context.Background(), nil, models.ImportOptions{Mode: models.ImportBestEffort})
+	assert.EqualError(t, err, "nothing to import")
+
+	_, err = service.ImportCats(context.Background(), importCatRows, models.ImportOptions{Mode: "some"})
+	assert.EqualError(t, err, `unknown import mode: "some"`)
+
+	_, err = service.ImportCats(context.Background(), make([]models.CatImportRow, maxImportRows+1), models.ImportOptions{Mode: models.ImportBestEffort})
+	assert.EqualError(t, err, "too many rows to import (1..1000)")
+}
+
+func TestImportMissions(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+
+	rows := []models.MissionImportRow{
+		{Line: 2, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{{Name: "Ivan", Country: "UA", Notes: "N"}}}},
+		{Line: 3, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{{Name: "Olga", Country: "PL"}}}},
+		{Line: 4},
+	}
+
+	mockStorage.On("CreateMission", mock.Anything).Return(postgres.Mission{ID: 3}, nil).Once()
+	mockStorage.On("CreateTarget", mock.Anything, postgres.CreateTargetParams{Mission: 3, Name: "Ivan", Country: "UA", Notes: "N"}).Return(postgres.Target{ID: 4}, nil).Once()
+
+	res, err := service.ImportMissions(context.Background(), rows, models.ImportOptions{Mode: models.ImportBestEffort})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ImportRowResult{
+		{Line: 2, Status: models.ImportRowCreated, ID: 3},
+		{Line: 3, Status: models.ImportRowInvalid, Errors: []string{"target 1: missing field: notes"}},
+		{Line: 4, Status: models.ImportRowInvalid, Errors: []string{"incorrect number of targets (1..3)"}},
+	}, res.Rows)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestImportMissions_UnknownSubject(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSubjectStorage(mockStorage))
+
+	known, missing := int32(5), int32(9)
+	rows := []models.MissionImportRow{
+		{Line: 2, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{{Name: "Ivan", Country: "UA", Notes: "N", SubjectID: &known}}}},
+		{Line: 3, Mission: models.CreateMissionRequest{Targets: []models.CreateTargetRequest{{Name: "Olga", Country: "PL", Notes: "N", SubjectID: &missing}}}},
+	}
+
+	mockStorage.On("GetSubjectsByIDs", mock.Anything, []int32{known, missing}).Return([]postgres.Subject{{ID: known}}, nil).Once()
+	mockStorage.On("CreateMission", mock.Anything).Return(postgres.Mission{ID: 3}, nil).Once()
+	mockStorage.On("GetSubject", mock.Anything, known).Return(postgres.Subject{ID: known}, nil).Once()
+	mockStorage.On("CreateTarget", mock.Anything, postgres.CreateTargetParams{Mission: 3, Name: "Ivan", Country: "UA", Notes: "N", Subject: pgtype.Int4{Int32: known, Valid: true}}).Return(postgres.Target{ID: 4}, nil).Once()
+
+	// The unknown subject makes its row invalid instead of failing the import.
+	res, err := service.ImportMissions(context.Background(), rows, models.ImportOptions{Mode: models.ImportBestEffort})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ImportRowResult{
+		{Line: 2, Status: models.ImportRowCreated, ID: 3},
+		{Line: 3, Status: models.ImportRowInvalid, Errors: []string{"target 1: subject 9 not found"}},
+	}, res.Rows)
+
+	mockStorage.AssertExpectations(t)
+}
+
+const snapshotMetadata = `{"kind":"metadata","data":{"format_version":1,"schema_version":20250315120000}}` + "\n"
+
+func TestRestoreSnapshot_SchemaVersion(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSnapshotStorage(mockStorage))
+
+	mockStorage.On("SchemaVersion", mock.Anything).Return(int64(20250401120000), nil)
+
+	_, err := service.RestoreSnapshot(context.Background(), strings.NewReader(snapshotMetadata+`{"kind":"end","data":{}}`))
+	assert.EqualError(t, err, "snapshot schema version 20250315120000 doesn't match the database schema version 20250401120000")
+
+	mockStorage.AssertNotCalled(t, "GetAllCats", mock.Anything)
+}
+
+func TestRestoreSnapshot_Invalid(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSnapshotStorage(mockStorage))
+
+	mockStorage.On("SchemaVersion", mock.Anything).Return(int64(20250315120000), nil)
+	mockStorage.On("GetAllCats", mock.Anything).Return([]postgres.Cat{}, nil)
+	mockStorage.On("GetAllSubjects", mock.Anything).Return([]postgres.Subject{}, nil)
+	mockStorage.On("GetAllMissions", mock.Anything).Return([]postgres.Mission{}, nil)
+	mockStorage.On("RestoreCat", mock.Anything, postgres.RestoreCatParams{ID: 4, Name: "Tom"}).Return(nil)
+
+	// The archive is truncated after the cat.
+	_, err := service.RestoreSnapshot(context.Background(), strings.NewReader(snapshotMetadata+`{"kind":"cat","data":{"id":4,"name":"Tom"}}`))
+	assert.EqualError(t, err, "invalid snapshot: truncated archive, no end record")
+
+	_, err = service.RestoreSnapshot(context.Background(), strings.NewReader(`{"kind":"cat"}`))
+	assert.EqualError(t, err, `invalid snapshot: line 1: expected metadata, got "cat"`)
+
+	mockStorage.AssertNotCalled(t, "ResetSequences", mock.Anything)
+}
+
+func TestRestoreSnapshot_NotEmpty(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSnapshotStorage(mockStorage))
+
+	mockStorage.On("SchemaVersion", mock.Anything).Return(int64(20250315120000), nil)
+	mockStorage.On("GetAllCats", mock.Anything).Return([]postgres.Cat{{ID: 1}}, nil)
+	mockStorage.On("GetAllSubjects", mock.Anything).Return([]postgres.Subject{}, nil)
+	mockStorage.On("GetAllMissions", mock.Anything).Return([]postgres.Mission{}, nil)
+
+	_, err := service.RestoreSnapshot(context.Background(), strings.NewReader(snapshotMetadata+`{"kind":"end","data":{}}`))
+	assert.EqualError(t, err, "database is not empty")
+
+	mockStorage.AssertNotCalled(t, "RestoreCat", mock.Anything, mock.Anything)
+}
+
+func TestRestoreSnapshot_ConstraintViolations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, st storage.Backend) {
+		ctx := context.Background()
+		if cats, err := st.GetAllCats(ctx); err != nil || len(cats) > 0 {
+			t.Skip("the database is not empty")
+		}
+		service := NewService(st, st, st, st, WithSnapshotStorage(st))
+
+		version, err := st.SchemaVersion(ctx)
+		require.NoError(t, err)
+		metadata := fmt.Sprintf(`{"kind":"metadata","data":{"format_version":1,"schema_version":%d}}`+"\n", version)
+
+		for name, archive := range map[string]string{
+			"DuplicateID": `{"kind":"cat","data":{"id":1,"name":"Tom","salary_currency":"USD"}}` + "\n" +
+				`{"kind":"cat","data":{"id":1,"name":"Tom","salary_currency":"USD"}}` + "\n" +
+				`{"kind":"end","data":{"cats":2}}`,
+			"MissingMission": `{"kind":"target","data":{"id":1,"mission":7,"name":"Ivan","country":"UA"}}` + "\n" +
+				`{"kind":"end","data":{"targets":1}}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := service.RestoreSnapshot(ctx, strings.NewReader(metadata+archive))
+				var modelErr *models.Err
+				require.ErrorAs(t, err, &modelErr)
+				assert.Equal(t, http.StatusUnprocessableEntity, modelErr.Code)
+				assert.Contains(t, err.Error(), "invalid snapshot: line ")
+			})
+		}
+	})
+}
+
+//-------------------------------------
+// REPORTS TESTS
+//-------------------------------------
+
+func TestEachCat_Pages(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithReportStorage(mockStorage))
+
+	full := make([]postgres.Cat, reportPageSize)
+	for i := range full {
+		full[i] = postgres.Cat{ID: int32(i + 1), Name: "Tom"}
+	}
+	mockStorage.On("GetCatsPage", mock.Anything, postgres.GetCatsPageParams{After: 0, MaxRows: reportPageSize}).Return(full, nil)
+	mockStorage.On("GetCatsPage", mock.Anything, postgres.GetCatsPageParams{After: reportPageSize, MaxRows: reportPageSize}).Return([]postgres.Cat{{ID: 900, Name: "Leo"}}, nil)
+
+	var ids []int32
+	err := service.EachCat(context.Background(), func(c models.Cat) error {
+		ids = append(ids, c.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ids, reportPageSize+1)
+	assert.Equal(t, int32(900), ids[len(ids)-1])
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestEachMissionTarget_Stops(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithReportStorage(mockStorage))
+
+	mockStorage.On("GetMissionTargetsPage", mock.Anything, postgres.GetMissionTargetsPageParams{MaxRows: reportPageSize}).Return([]postgres.GetMissionTargetsPageRow{
+		{MissionID: 1, Assignee: pgtype.Int4{Int32: 2, Valid: true}, TargetID: 3, Name: "Ivan"},
+		{MissionID: 1, TargetID: 4, Name: "Olga"},
+	}, nil)
+
+	errWrite := errors.New("connection closed")
+	var rows []models.MissionTargetRow
+	err := service.EachMissionTarget(context.Background(), func(r models.MissionTargetRow) error {
+		rows = append(rows, r)
+		return errWrite
+	})
+	assert.ErrorIs(t, err, errWrite)
+	assert.Equal(t, []models.MissionTargetRow{{MissionID: 1, Assignee: 2, TargetID: 3, Name: "Ivan"}}, rows)
+}
+
+func TestEachCatWorkload_StorageError(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithReportStorage(mockStorage))
+
+	mockStorage.On("GetCatWorkloadPage", mock.Anything, mock.Anything).Return([]postgres.GetCatWorkloadPageRow(nil), errors.New("connection refused"))
+
+	err := service.EachCatWorkload(context.Background(), func(models.CatWorkload) error { return nil })
+	assert.EqualError(t, err, "failed to read report")
+}
+
+func TestGetCompletionRate_FillsPeriods(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAnalyticsStorage(mockStorage))
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	mockStorage.On("GetMissionCompletionRate", mock.Anything, postgres.GetMissionCompletionRateParams{
+		TimeWindow: "month",
+		Since:      pgtype.Timestamptz{Time: month.AddDate(0, -2, 0), Valid: true},
+	}).Return([]postgres.GetMissionCompletionRateRow{
+		{Period: pgtype.Timestamptz{Time: month, Valid: true}, Created: 4, Completed: 1},
+	}, nil)
+
+	rate, err := service.GetCompletionRate(context.Background(), "month", 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.CompletionRate{Window: "month", Periods: []models.CompletionRatePeriod{
+		{Start: month.AddDate(0, -2, 0)},
+		{Start: month.AddDate(0, -1, 0)},
+		{Start: month, Created: 4, Completed: 1, Rate: 0.25},
+	}}, rate)
+}
+
+func TestGetCompletionRate_Validation(t *testing.T) {
+	service := NewService(nil, nil, nil, nil)
+
+	_, err := service.GetCompletionRate(context.Background(), "year", 3)
+	assert.EqualError(t, err, "window must be one of day, week, month")
+
+	_, err = service.GetCompletionRate(context.Background(), "week", 0)
+	assert.EqualError(t, err, "periods must be between 1 and 366")
+}
+
+func TestPeriodStart_Week(t *testing.T) {
+	// 2025-03-02 is a Sunday, the last day of the week of Monday 2025-02-24.
+	got := periodStart(time.Date(2025, 3, 2, 23, 0, 0, 0, time.UTC), "week")
+	assert.Equal(t, time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC), got)
+}
+
+func TestGetTargetsPerCountry_Cached(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAnalyticsStorage(mockStorage))
+
+	mockStorage.On("GetTargetsPerCountry", mock.Anything).Return([]postgres.GetTargetsPerCountryRow{{Country: "UA", Targets: 2, Completed: 1}}, nil).Once()
+
+	for range 2 {
+		countries, err := service.GetTargetsPerCountry(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []models.CountryTargets{{Country: "UA", Targets: 2, Completed: 1}}, countries)
+	}
+	mockStorage.AssertNumberOfCalls(t, "GetTargetsPerCountry", 1)
+}
+
+func TestGetTargetsPerCountry_ErrorsNotCached(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAnalyticsStorage(mockStorage))
+
+	mockStorage.On("GetTargetsPerCountry", mock.Anything).Return([]postgres.GetTargetsPerCountryRow(nil), errors.New("connection refused")).Once()
+	mockStorage.On("GetTargetsPerCountry", mock.Anything).Return([]postgres.GetTargetsPerCountryRow{}, nil).Once()
+
+	_, err := service.GetTargetsPerCountry(context.Background())
+	assert.EqualError(t, err, "failed to compute analytics")
+
+	countries, err := service.GetTargetsPerCountry(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, countries)
+}
+
+func TestGetCompletionTimes_CacheDisabled(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAnalyticsStorage(mockStorage), WithAnalyticsCacheTTL(0))
+
+	mockStorage.On("GetCompletionTimes", mock.Anything).Return(postgres.GetCompletionTimesRow{CompletedMissions: 1, MissionSeconds: 60}, nil)
+
+	for range 2 {
+		_, err := service.GetCompletionTimes(context.Background())
+		require.NoError(t, err)
+	}
+	mockStorage.AssertNumberOfCalls(t, "GetCompletionTimes", 2)
 }
 
-func (m *MockStorage) CompleteTarget(ctx context.Context, id int32) (postgres.Target, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(postgres.Target), args.Error(1)
+func TestGetSalaryPerformance(t *testing.T) {
+	tests := []struct {
+		name     string
+		salaries []float64
+		missions []float64
+		// currencies of the salaries, USD if nil.
+		currencies []string
+		want       float64
+		// undefined is whether there is no correlation.
+		undefined bool
+		excluded  int64
+	}{
+		{name: "positive", salaries: []float64{100, 200, 300}, missions: []float64{1, 2, 3}, want: 1},
+		{name: "converted", salaries: []float64{100, 100, 300}, missions: []float64{1, 2, 3}, currencies: []string{"USD", "EUR", "USD"}, want: 1},
+		{name: "minor units", salaries: []float64{100, 200, 300}, missions: []float64{1, 2, 3}, currencies: []string{"USD", "JPY", "USD"}, want: 1},
+		{name: "without rate", salaries: []float64{100, 200, 300}, missions: []float64{1, 2, 3}, currencies: []string{"USD", "GBP", "USD"}, want: 1, excluded: 1},
+		{name: "negative", salaries: []float64{100, 200, 300}, missions: []float64{3, 2, 1}, want: -1},
+		{name: "partial", salaries: []float64{100, 200, 300}, missions: []float64{1, 3, 2}, want: 0.5},
+		{name: "same salaries", salaries: []float64{100, 100}, missions: []float64{1, 2}, undefined: true},
+		{name: "one cat", salaries: []float64{100}, missions: []float64{1}, undefined: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := new(MockStorage)
+			service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAnalyticsStorage(mockStorage), WithRateStorage(mockStorage))
+
+			var rows []postgres.GetSalaryPerformanceRow
+			for i, s := range tt.salaries {
+				code := "USD"
+				if tt.currencies != nil {
+					code = tt.currencies[i]
+				}
+				j := slices.IndexFunc(rows, func(r postgres.GetSalaryPerformanceRow) bool { return r.SalaryCurrency == code })
+				if j < 0 {
+					rows = append(rows, postgres.GetSalaryPerformanceRow{SalaryCurrency: code})
+					j = len(rows) - 1
+				}
+				m := tt.missions[i]
+				rows[j].Cats++
+				rows[j].SumSalary += s
+				rows[j].SumSalarySquares += s * s
+				rows[j].SumMissions += m
+				rows[j].SumMissionsSquares += m * m
+				rows[j].SumSalaryMissions += s * m
+			}
+			mockStorage.On("GetSalaryPerformance", mock.Anything).Return(rows, nil)
+			// 1.00 EUR is 2.00 USD and 200 JPY, without minor units, are 2.00 USD.
+			mockStorage.On("GetExchangeRates", mock.Anything).Return([]postgres.ExchangeRate{{Currency: "EUR", Rate: 2}, {Currency: "JPY", Rate: 0.01}}, nil)
+
+			got, err := service.GetSalaryPerformance(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.salaries))-tt.excluded, got.Cats)
+			assert.Equal(t, tt.excluded, got.ExcludedCats)
+			if tt.undefined {
+				assert.Nil(t, got.MissionsCorrelation)
+			} else if assert.NotNil(t, got.MissionsCorrelation) {
+				assert.InDelta(t, tt.want, *got.MissionsCorrelation, 1e-9)
+			}
+			// There are no completed targets.
+			assert.Nil(t, got.TargetsCorrelation)
+		})
+	}
 }
 
 //-------------------------------------
-// CATS TESTS
+// AVAILABILITY TESTS
 //-------------------------------------
 
-func TestGetAllCats(t *testing.T) {
-	mockStorage := &MockStorage{}
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+func TestCreateAvailabilityPeriod_Invalid(t *testing.T) {
+	service := NewService(nil, nil, nil, nil)
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
 
-	mockStorage.On("GetAllCats", mock.Anything).Return([]postgres.Cat{
-		{ID: 1, Name: "Tom", Breed: "Siamese", YearsOfExperience: 5, Salary: 5000},
-	}, nil)
+	_, err := service.CreateAvailabilityPeriod(context.Background(), 1, models.AvailabilityPeriodRequest{Kind: "nap", StartsAt: start, EndsAt: start.Add(time.Hour)})
+	assert.EqualError(t, err, "kind must be one of vacation, sick, training")
 
-	cats, err := service.GetAllCats(context.Background())
-	assert.NoError(t, err)
-	assert.Len(t, cats, 1)
-	assert.Equal(t, "Tom", cats[0].Name)
+	_, err = service.CreateAvailabilityPeriod(context.Background(), 1, models.AvailabilityPeriodRequest{Kind: "sick", StartsAt: start})
+	assert.EqualError(t, err, "starts_at and ends_at are required")
 
-	mockStorage.AssertExpectations(t)
+	_, err = service.CreateAvailabilityPeriod(context.Background(), 1, models.AvailabilityPeriodRequest{Kind: "sick", StartsAt: start, EndsAt: start})
+	assert.EqualError(t, err, "ends_at must be after starts_at")
 }
 
-func TestGetAllCats_StorageError(t *testing.T) {
+func TestUpdateAvailabilityPeriod_OtherCat(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAvailabilityStorage(mockStorage))
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
 
-	mockStorage.On("GetAllCats", mock.Anything).Return([]postgres.Cat{}, errors.New("database error"))
+	mockStorage.On("GetAvailabilityPeriod", mock.Anything, int32(5)).Return(postgres.AvailabilityPeriod{ID: 5, Cat: 2}, nil)
 
-	cats, err := service.GetAllCats(context.Background())
-	assert.Error(t, err)
-	assert.Equal(t, []models.Cat{}, cats)
+	_, err := service.UpdateAvailabilityPeriod(context.Background(), 1, 5, models.AvailabilityPeriodRequest{Kind: "sick", StartsAt: start, EndsAt: start.Add(time.Hour)})
+	assert.ErrorIs(t, err, models.ErrNotFound)
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "UpdateAvailabilityPeriod", mock.Anything, mock.Anything)
 }
 
-func TestGetCat(t *testing.T) {
+// withUnavailableCat mocks cat 1 on vacation and the unassigned mission 2.
+func withUnavailableCat(mockStorage *MockStorage) {
+	mockStorage.On("GetCat", mock.Anything, int32(1)).Return(postgres.Cat{ID: 1, Name: "Tom"}, nil)
+	mockStorage.On("GetMission", mock.Anything, int32(2)).Return(postgres.Mission{ID: 2}, nil)
+	mockStorage.On("GetMissionTargets", mock.Anything, int32(2)).Return([]postgres.Target{}, nil)
+	mockStorage.On("GetCatMission", mock.Anything, pgtype.Int4{Int32: 1, Valid: true}).Return(postgres.Mission{}, pgx.ErrNoRows)
+	mockStorage.On("GetOverlappingAvailabilityPeriods", mock.Anything, mock.Anything).Return([]postgres.AvailabilityPeriod{{
+		ID:       5,
+		Cat:      1,
+		Kind:     "vacation",
+		StartsAt: pgtype.Timestamptz{Time: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), Valid: true},
+	}}, nil)
+}
+
+func TestAssignCatToMission_Unavailable(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAvailabilityStorage(mockStorage))
+	withUnavailableCat(mockStorage)
 
-	mockStorage.On("GetCat", mock.Anything, int32(1)).Return(postgres.Cat{
-		ID:                1,
-		Name:              "Tom",
-		Breed:             "Siamese",
-		YearsOfExperience: 5,
-		Salary:            5000,
-	}, nil)
+	_, err := service.AssignCatToMission(context.Background(), 2, models.AssignCatRequest{Assignee: 1})
+	assert.EqualError(t, err, "cat is unavailable: vacation from 2025-03-01T00:00:00Z to 2025-03-08T00:00:00Z")
 
-	cat, err := service.GetCat(context.Background(), 1)
-	assert.NoError(t, err)
-	assert.Equal(t, "Tom", cat.Name)
+	mockStorage.AssertNotCalled(t, "Begin", mock.Anything)
+}
 
-	mockStorage.AssertExpectations(t)
+func TestAssignCatToMission_UntilInThePast(t *testing.T) {
+	service := NewService(nil, nil, nil, nil)
+	until := time.Now().Add(-time.Hour)
+
+	_, err := service.AssignCatToMission(context.Background(), 2, models.AssignCatRequest{Assignee: 1, Until: &until})
+	assert.EqualError(t, err, "until must be in the future")
 }
 
-func TestGetCat_NotFound(t *testing.T) {
+func TestCreateSkill_Duplicate(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSkillStorage(mockStorage))
 
-	mockStorage.On("GetCat", mock.Anything, int32(999)).Return(postgres.Cat{}, errors.New("not found"))
+	mockStorage.On("CreateSkill", mock.Anything, "Lockpicking").Return(postgres.Skill{}, &pgconn.PgError{Code: "23505"})
 
-	cat, err := service.GetCat(context.Background(), 999)
-	assert.Error(t, err)
-	assert.Equal(t, models.Cat{}, cat)
+	_, err := service.CreateSkill(context.Background(), models.CreateSkillRequest{Name: " Lockpicking "})
+	assert.EqualError(t, err, "skill already exists")
+}
 
-	mockStorage.AssertExpectations(t)
+func TestSetCatSkill_InvalidLevel(t *testing.T) {
+	service := NewService(nil, nil, nil, nil)
+
+	_, err := service.SetCatSkill(context.Background(), 1, 2, models.CatSkillRequest{Level: 6})
+	assert.EqualError(t, err, "level must be between 1 and 5")
 }
 
-func TestCreateCat(t *testing.T) {
+func TestRankCandidate(t *testing.T) {
+	now := time.Date(2025, 3, 18, 0, 0, 0, 0, time.UTC)
+	required := []postgres.GetMissionRequiredSkillsRow{
+		{Skill: 1, Name: "Lockpicking", MinLevel: 3},
+		{Skill: 2, Name: "Russian", MinLevel: 2, Certified: true},
+		{Skill: 3, Name: "Disguise", MinLevel: 1},
+	}
+	skills := map[int32]postgres.CatSkill{
+		1: {Skill: 1, Level: 4},
+		// The certification has expired.
+		2: {Skill: 2, Level: 5, CertifiedUntil: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}},
+	}
+
+	c := rankCandidate(models.Cat{ID: 1}, required, skills, now)
+	assert.InDelta(t, 1.0/3, c.SkillMatch, 1e-9)
+	assert.Equal(t, []string{"Lockpicking"}, c.MatchedSkills)
+	assert.Equal(t, []string{"Russian", "Disguise"}, c.MissingSkills)
+
+	c = rankCandidate(models.Cat{ID: 1}, nil, nil, now)
+	assert.Equal(t, 1.0, c.SkillMatch)
+}
+
+func TestAutoAssignMission_Validation(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSkillStorage(mockStorage))
 
-	mockStorage.On("CreateCat", mock.Anything, postgres.CreateCatParams{
-		Name:              "Tom",
-		Breed:             "Siamese",
-		YearsOfExperience: 5,
-		Salary:            5000,
-	}).Return(postgres.Cat{
-		ID:                1,
-		Name:              "Tom",
-		Breed:             "Siamese",
-		YearsOfExperience: 5,
-		Salary:            5000,
-	}, nil)
+	mockStorage.On("GetMission", mock.Anything, int32(1)).Return(postgres.Mission{ID: 1}, nil)
+	mockStorage.On("GetMission", mock.Anything, int32(2)).Return(postgres.Mission{ID: 2, Assignee: pgtype.Int4{Int32: 3, Valid: true}}, nil)
 
-	cat, err := service.CreateCat(context.Background(), models.CreateCatRequest{
-		Name:              "Tom",
-		Breed:             "Siamese",
-		YearsOfExperience: 5,
-		Salary:            5000,
-	})
-	assert.NoError(t, err)
-	assert.Equal(t, "Tom", cat.Name)
+	_, err := service.AutoAssignMission(context.Background(), 1, models.AutoAssignRequest{Strategy: "random"})
+	assert.EqualError(t, err, "unknown strategy, expected greedy or hungarian")
 
-	mockStorage.AssertExpectations(t)
+	budget := int64(-1)
+	_, err = service.AutoAssignMission(context.Background(), 1, models.AutoAssignRequest{SalaryBudget: &budget})
+	assert.EqualError(t, err, "salary_budget must not be negative")
+
+	budget = 100
+	_, err = service.AutoAssignMission(context.Background(), 1, models.AutoAssignRequest{SalaryBudget: &budget, SalaryBudgetCurrency: "euro"})
+	assert.EqualError(t, err, "salary_budget_currency must be an ISO 4217 code")
+
+	_, err = service.AutoAssignMission(context.Background(), 2, models.AutoAssignRequest{})
+	assert.EqualError(t, err, "mission is already assigned")
+
+	mockStorage.AssertNotCalled(t, "GetUnassignedCats", mock.Anything)
 }
 
-func TestUpdateCatSalary(t *testing.T) {
+func TestAutoAssignMissions_DryRun(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSkillStorage(mockStorage))
 
-	mockStorage.On("UpdateCatSalary", mock.Anything, postgres.UpdateCatSalaryParams{
-		ID:     1,
-		Salary: 6000,
-	}).Return(postgres.Cat{
-		ID:                1,
-		Name:              "Tom",
-		Breed:             "Siamese",
-		YearsOfExperience: 5,
-		Salary:            6000,
+	mockStorage.On("GetAllMissions", mock.Anything).Return([]postgres.Mission{
+		{ID: 2},
+		{ID: 1},
+		{ID: 3, Completed: true},
+	}, nil)
+	mockStorage.On("GetUnassignedCats", mock.Anything).Return([]postgres.Cat{
+		{ID: 10, YearsOfExperience: 2, Salary: 100, SalaryCurrency: "USD"},
+		{ID: 11, YearsOfExperience: 9, Salary: 300, SalaryCurrency: "USD"},
 	}, nil)
+	mockStorage.On("GetUnassignedCatSkills", mock.Anything).Return([]postgres.CatSkill{{Cat: 10, Skill: 7, Level: 3}}, nil)
+	mockStorage.On("GetMissionRequiredSkills", mock.Anything, int32(1)).Return([]postgres.GetMissionRequiredSkillsRow(nil), nil)
+	mockStorage.On("GetMissionRequiredSkills", mock.Anything, int32(2)).Return([]postgres.GetMissionRequiredSkillsRow{{Skill: 7, Name: "Lockpicking", MinLevel: 2}}, nil)
+
+	res, err := service.AutoAssignMissions(context.Background(), models.AutoAssignRequest{Strategy: "hungarian", DryRun: true})
+	require.NoError(t, err)
+
+	// Only cat 10 meets the skills of mission 2.
+	require.Len(t, res.Assignments, 2)
+	assert.Equal(t, int32(1), res.Assignments[0].MissionID)
+	assert.Equal(t, int32(11), res.Assignments[0].Cat.ID)
+	assert.Equal(t, int32(2), res.Assignments[1].MissionID)
+	assert.Equal(t, int32(10), res.Assignments[1].Cat.ID)
+	assert.Equal(t, int64(400), res.TotalSalary)
+	assert.Equal(t, "USD", res.Currency)
+	assert.Empty(t, res.Unassigned)
+
+	mockStorage.AssertNotCalled(t, "Begin", mock.Anything)
+}
 
-	cat, err := service.UpdateCatSalary(context.Background(), models.UpdateCatSalaryRequest{
-		Salary: 6000,
-	}, 1)
-	assert.NoError(t, err)
-	assert.Equal(t, int32(6000), cat.Salary)
+func TestAutoAssignMissions_Currencies(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithSkillStorage(mockStorage), WithRateStorage(mockStorage))
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.On("GetAllMissions", mock.Anything).Return([]postgres.Mission{{ID: 1}}, nil)
+	mockStorage.On("GetUnassignedCats", mock.Anything).Return([]postgres.Cat{
+		{ID: 10, Salary: 100, SalaryCurrency: "EUR"},
+		{ID: 11, Salary: 150, SalaryCurrency: "USD"},
+	}, nil)
+	mockStorage.On("GetUnassignedCatSkills", mock.Anything).Return([]postgres.CatSkill(nil), nil)
+	mockStorage.On("GetMissionRequiredSkills", mock.Anything, int32(1)).Return([]postgres.GetMissionRequiredSkillsRow(nil), nil)
+	mockStorage.On("GetExchangeRates", mock.Anything).Return([]postgres.ExchangeRate{{Currency: "EUR", Rate: 2}}, nil).Twice()
+
+	// The 1.00 EUR of cat 10 are 2.00 USD, over the budget.
+	budget := int64(180)
+	res, err := service.AutoAssignMissions(context.Background(), models.AutoAssignRequest{SalaryBudget: &budget, DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, res.Assignments, 1)
+	assert.Equal(t, int32(11), res.Assignments[0].Cat.ID)
+	assert.Equal(t, int64(150), res.TotalSalary)
+
+	// A budget of 0.70 EUR is 1.40 USD, below both salaries.
+	budget = 70
+	res, err = service.AutoAssignMissions(context.Background(), models.AutoAssignRequest{SalaryBudget: &budget, SalaryBudgetCurrency: "eur", DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, res.Assignments)
+	assert.Equal(t, []int32{1}, res.Unassigned)
+
+	mockStorage.On("GetExchangeRates", mock.Anything).Return([]postgres.ExchangeRate(nil), nil)
+	_, err = service.AutoAssignMissions(context.Background(), models.AutoAssignRequest{DryRun: true})
+	assert.EqualError(t, err, "no exchange rate for EUR")
+
+	_, err = service.AutoAssignMissions(context.Background(), models.AutoAssignRequest{SalaryBudget: &budget, SalaryBudgetCurrency: "GBP", DryRun: true})
+	assert.EqualError(t, err, "no exchange rate for GBP")
 }
 
-func TestUpdateCatSalary_InvalidID(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+func TestProratedSalary(t *testing.T) {
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
 
-	mockStorage.On("UpdateCatSalary", mock.Anything, mock.Anything).Return(postgres.Cat{}, errors.New("not found"))
+	cost := proratedSalary(postgres.Cat{ID: 1, Salary: 3000, SalaryCurrency: "USD"}, since, since.Add(36*time.Hour), 30)
+	assert.Equal(t, &models.SalaryCost{CatID: 1, Salary: 30, SalaryCurrency: "USD", Days: 1.5, Amount: 150}, cost)
 
-	_, err := service.UpdateCatSalary(context.Background(), models.UpdateCatSalaryRequest{Salary: 7000}, 999)
-	assert.Error(t, err)
+	// A completion before the assignment costs nothing.
+	cost = proratedSalary(postgres.Cat{ID: 1, Salary: 3000, SalaryCurrency: "USD"}, since, since.Add(-time.Hour), 30)
+	assert.Zero(t, cost.Amount)
 }
 
-func TestUpdateCatSalary_NegativeSalary(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+// withMissionCosts mocks the mission 2 of cat 1, assigned 10 days ago with a
+// salary of 300.00 USD for 30 days, a budget of 150.00 USD and a travel
+// expense of 40.00 USD.
+func withMissionCosts(mockStorage *MockStorage) {
+	assignedAt := time.Now().Add(-10 * 24 * time.Hour)
+	mockStorage.On("GetMission", mock.Anything, int32(2)).Return(postgres.Mission{
+		ID:         2,
+		Assignee:   pgtype.Int4{Int32: 1, Valid: true},
+		AssignedAt: pgtype.Timestamptz{Time: assignedAt, Valid: true},
+	}, nil)
+	mockStorage.On("GetCat", mock.Anything, int32(1)).Return(postgres.Cat{ID: 1, Salary: 30000, SalaryCurrency: "USD"}, nil)
+	mockStorage.On("GetMissionBudget", mock.Anything, int32(2)).Return(postgres.MissionBudget{Mission: 2, Amount: 15000, Currency: "USD"}, nil)
+	mockStorage.On("GetMissionExpenses", mock.Anything, int32(2)).Return([]postgres.MissionExpense{
+		{ID: 5, Mission: 2, Category: "travel", Amount: 4000, Currency: "USD"},
+	}, nil)
+}
 
-	_, err := service.UpdateCatSalary(context.Background(), models.UpdateCatSalaryRequest{Salary: -500}, 1)
-	assert.Error(t, err)
+func TestGetMissionCosts(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithCostStorage(mockStorage))
+	withMissionCosts(mockStorage)
+
+	costs, err := service.GetMissionCosts(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", costs.Currency)
+	assert.Equal(t, int64(4000), costs.Expenses["travel"])
+	assert.Equal(t, int64(0), costs.Expenses["informants"])
+	require.NotNil(t, costs.Salary)
+	assert.InDelta(t, 10000, costs.Salary.Amount, 1)
+	assert.InDelta(t, 14000, costs.Total, 1)
+	assert.InDelta(t, 1000, *costs.Remaining, 1)
+	assert.False(t, costs.OverBudget)
 }
 
-func TestDeleteCat(t *testing.T) {
+func TestCreateMissionExpense_OverBudget(t *testing.T) {
+	r := rules.Default()
+	r.Missions.OverBudget = rules.OverBudgetBlock
+
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithCostStorage(mockStorage), WithRules(staticRules(r)))
+	withMissionCosts(mockStorage)
 
-	mockStorage.On("DeleteCat", mock.Anything, int32(999)).Return(int64(1), nil)
+	_, err := service.CreateMissionExpense(context.Background(), 2, models.CreateExpenseRequest{Category: "equipment", Amount: 5000, Currency: "usd"})
+	assert.ErrorContains(t, err, "costs exceed the budget of the mission by")
 
-	err := service.DeleteCat(context.Background(), 999)
-	assert.NoError(t, err)
+	_, err = service.CreateMissionExpense(context.Background(), 2, models.CreateExpenseRequest{Category: "equipment", Amount: 500, Currency: "EUR"})
+	assert.EqualError(t, err, "no exchange rate for EUR")
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "CreateMissionExpense", mock.Anything, mock.Anything)
 }
 
-func TestDeleteCat_NotFound(t *testing.T) {
+func TestCreateMissionExpense_Converted(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	mockStorage.On("DeleteCat", mock.Anything, int32(999)).Return(int64(0), nil)
-
-	err := service.DeleteCat(context.Background(), 999)
-	assert.Error(t, err)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithCostStorage(mockStorage), WithRateStorage(mockStorage))
+	withMissionCosts(mockStorage)
+	mockStorage.On("GetExchangeRates", mock.Anything).Return([]postgres.ExchangeRate{{Currency: "EUR", Rate: 1.25}}, nil)
+	mockStorage.On("CreateMissionExpense", mock.Anything, mock.MatchedBy(func(arg postgres.CreateMissionExpenseParams) bool {
+		return arg.Amount == 2000 && arg.Currency == "EUR"
+	})).Return(postgres.MissionExpense{ID: 6, Mission: 2, Category: "equipment", Amount: 2000, Currency: "EUR"}, nil)
+
+	// The 20.00 EUR are 25.00 USD, over the 10.00 USD remaining.
+	expense, err := service.CreateMissionExpense(context.Background(), 2, models.CreateExpenseRequest{Category: "equipment", Amount: 2000, Currency: "eur"})
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", expense.Currency)
+	require.Len(t, expense.Warnings, 1)
+	assert.Contains(t, expense.Warnings[0], "costs exceed the budget of the mission by 15.00 USD")
+}
 
-	mockStorage.AssertExpectations(t)
+func TestCreateMissionExpense_Invalid(t *testing.T) {
+	service := NewService(nil, nil, nil, nil)
+
+	tests := []struct {
+		req     models.CreateExpenseRequest
+		wantErr string
+	}{
+		{models.CreateExpenseRequest{Category: "snacks", Amount: 1, Currency: "USD"}, "category must be one of travel, equipment, informants"},
+		{models.CreateExpenseRequest{Category: "travel", Amount: -1, Currency: "USD"}, "amount must be positive"},
+		{models.CreateExpenseRequest{Category: "travel", Amount: 1, Currency: "dollars"}, "currency must be an ISO 4217 code"},
+		{models.CreateExpenseRequest{Category: "travel", Amount: 1, Currency: "USD", Receipt: &models.Receipt{URL: "ftp://example.com/r.pdf"}}, "receipt url must be an http or https URL"},
+	}
+	for _, tt := range tests {
+		_, err := service.CreateMissionExpense(context.Background(), 2, tt.req)
+		assert.EqualError(t, err, tt.wantErr)
+	}
 }
 
 //-------------------------------------
-// MISSION TESTS
+// EXCHANGE RATES TESTS
 //-------------------------------------
 
-func TestGetAllMissions_Success(t *testing.T) {
+func TestSetExchangeRate_Invalid(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	mockMissions := []postgres.Mission{
-		{ID: 1, Assignee: pgtype.Int4{Int32: 2, Valid: true}, Completed: false},
-		{ID: 2, Assignee: pgtype.Int4{Int32: 0, Valid: false}, Completed: true},
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithRateStorage(mockStorage))
+
+	tests := []struct {
+		code    string
+		rate    float64
+		wantErr string
+	}{
+		{"euro", 1.1, "currency must be an ISO 4217 code"},
+		{"usd", 1, "USD is the reporting currency"},
+		{"EUR", -1, "rate must be a positive number"},
+	}
+	for _, tt := range tests {
+		_, err := service.SetExchangeRate(context.Background(), tt.code, models.SetExchangeRateRequest{Rate: tt.rate})
+		assert.EqualError(t, err, tt.wantErr)
 	}
-	mockStorage.On("GetAllMissions", mock.Anything).Return(mockMissions, nil)
-
-	missions, err := service.GetAllMissions(context.Background())
-	assert.NoError(t, err)
-	assert.Len(t, missions, 2)
-	assert.Equal(t, int32(1), missions[0].ID)
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "SetExchangeRate", mock.Anything, mock.Anything)
 }
 
-func TestGetAllMissions_StorageError(t *testing.T) {
+func TestImportExchangeRates(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithRateStorage(mockStorage), WithReportingCurrency("EUR"))
 
-	mockStorage.On("GetAllMissions", mock.Anything).Return([]postgres.Mission{}, errors.New("db error"))
+	mockStorage.On("SetExchangeRate", mock.Anything, postgres.SetExchangeRateParams{Currency: "GBP", Rate: 1.19}).Return(postgres.ExchangeRate{Currency: "GBP", Rate: 1.19}, nil).Once()
+	mockStorage.On("SetExchangeRate", mock.Anything, postgres.SetExchangeRateParams{Currency: "USD", Rate: 0.92}).Return(postgres.ExchangeRate{Currency: "USD", Rate: 0.92}, nil).Once()
+	mockStorage.On("GetExchangeRates", mock.Anything).Return([]postgres.ExchangeRate{{Currency: "GBP", Rate: 1.19}, {Currency: "USD", Rate: 0.92}}, nil)
 
-	missions, err := service.GetAllMissions(context.Background())
-	assert.Error(t, err)
-	assert.Len(t, missions, 0)
+	rates, err := service.ImportExchangeRates(context.Background(), map[string]float64{"usd": 0.92, "GBP": 1.19})
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", rates.ReportingCurrency)
+	assert.Len(t, rates.Rates, 2)
+
+	// A single invalid rate imports nothing.
+	_, err = service.ImportExchangeRates(context.Background(), map[string]float64{"GBP": 1.2, "EUR": 1})
+	assert.EqualError(t, err, "EUR is the reporting currency")
 
 	mockStorage.AssertExpectations(t)
 }
 
-func TestGetMission_Success(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	missionRecord := postgres.Mission{ID: 1, Assignee: pgtype.Int4{Int32: 2, Valid: true}, Completed: false}
-	targetRecords := []postgres.Target{
-		{ID: 10, Name: "Target1", Country: "CountryA", Notes: "Note", Completed: true},
-		{ID: 11, Name: "Target2", Country: "CountryB", Notes: "Note", Completed: true},
-	}
+//-------------------------------------
+// LOCATIONS TESTS
+//-------------------------------------
 
-	mockStorage.On("GetMission", mock.Anything, int32(1)).Return(missionRecord, nil)
-	mockStorage.On("GetMissionTargets", mock.Anything, int32(1)).Return(targetRecords, nil)
+func TestUpdateTargetLocation_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithLocationStorage(mockStorage))
+
+	lat, lng := 50.4501, 30.5234
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150}, nil)
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10}, nil)
+	mockStorage.On("UpdateTargetLocation", mock.Anything, postgres.UpdateTargetLocationParams{
+		ID:        150,
+		Latitude:  pgtype.Float8{Float64: lat, Valid: true},
+		Longitude: pgtype.Float8{Float64: lng, Valid: true},
+		Address:   "Kyiv",
+	}).Return(postgres.Target{
+		ID:        150,
+		Latitude:  pgtype.Float8{Float64: lat, Valid: true},
+		Longitude: pgtype.Float8{Float64: lng, Valid: true},
+		Address:   "Kyiv",
+	}, nil)
 
-	mission, err := service.GetMission(context.Background(), 1)
-	assert.NoError(t, err)
-	assert.Equal(t, int32(1), mission.ID)
-	assert.Len(t, mission.Targets, 2)
+	target, err := service.UpdateTargetLocation(context.Background(), 150, models.TargetLocationRequest{Latitude: &lat, Longitude: &lng, Address: "Kyiv"})
+	require.NoError(t, err)
+	assert.Equal(t, &lat, target.Latitude)
+	assert.Equal(t, &lng, target.Longitude)
+	assert.Equal(t, "Kyiv", target.Address)
 
 	mockStorage.AssertExpectations(t)
 }
 
-func TestGetMission_NotFound(t *testing.T) {
+func TestUpdateTargetLocation_CompletedMission(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithLocationStorage(mockStorage))
 
-	mockStorage.On("GetMission", mock.Anything, int32(999)).Return(postgres.Mission{}, errors.New("not found"))
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150}, nil)
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10, Completed: true}, nil)
 
-	mission, err := service.GetMission(context.Background(), 999)
-	assert.Error(t, err)
-	assert.Equal(t, models.Mission{}, mission)
+	_, err := service.UpdateTargetLocation(context.Background(), 150, models.TargetLocationRequest{Address: "Kyiv"})
+	assert.EqualError(t, err, "Can't change target location of a completed mission")
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "UpdateTargetLocation", mock.Anything, mock.Anything)
 }
 
-func TestCreateMission_InvalidTargetsCount(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	// Zero targets.
-	reqZero := models.CreateMissionRequest{
-		Targets: []models.CreateTargetRequest{},
+func TestCheckLocation(t *testing.T) {
+	ptr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		lat, lng *float64
+		address  string
+		wantErr  string
+	}{
+		{nil, nil, "", ""},
+		{ptr(-90), ptr(180), "North Pole", ""},
+		{ptr(50), nil, "", "latitude and longitude must be set together"},
+		{nil, ptr(30), "", "latitude and longitude must be set together"},
+		{ptr(90.1), ptr(30), "", "latitude must be between -90 and 90"},
+		{ptr(50), ptr(-180.5), "", "longitude must be between -180 and 180"},
+		{nil, nil, strings.Repeat("a", 257), "address is too long (0..256)"},
 	}
-	_, err := service.CreateMission(context.Background(), reqZero)
-	assert.Error(t, err)
-
-	// Too many targets.
-	reqTooMany := models.CreateMissionRequest{
-		Targets: []models.CreateTargetRequest{
-			{Name: "A", Country: "X", Notes: "N"},
-			{Name: "B", Country: "Y", Notes: "N"},
-			{Name: "C", Country: "Z", Notes: "N"},
-			{Name: "D", Country: "W", Notes: "N"},
-		},
+	for _, tt := range tests {
+		err := checkLocation(tt.lat, tt.lng, tt.address)
+		if tt.wantErr == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.wantErr)
+		}
 	}
-	_, err = service.CreateMission(context.Background(), reqTooMany)
-	assert.Error(t, err)
 }
 
-func TestCreateMission_TargetNotesTooLong(t *testing.T) {
+func TestGetTargetsNear_Invalid(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	// Target note longer than 256 characters.
-	tooLong := ""
-	for i := 0; i < 300; i++ {
-		tooLong += "a"
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithLocationStorage(mockStorage))
+
+	tests := []struct {
+		lat, lng, radius float64
+		limit            int32
+		wantErr          string
+	}{
+		{91, 0, 10, 10, "latitude must be between -90 and 90"},
+		{0, 0, 0, 10, "radius_km must be positive and at most 20016"},
+		{0, 0, 30000, 10, "radius_km must be positive and at most 20016"},
+		{0, 0, 10, 101, "limit must be between 1 and 100"},
 	}
-
-	assert.True(t, utf8.RuneCountInString(tooLong) > 256)
-
-	req := models.CreateMissionRequest{
-		Targets: []models.CreateTargetRequest{
-			{Name: "Alpha", Country: "CountryX", Notes: tooLong},
-		},
+	for _, tt := range tests {
+		res, err := service.GetTargetsNear(context.Background(), tt.lat, tt.lng, tt.radius, tt.limit)
+		assert.EqualError(t, err, tt.wantErr)
+		assert.Empty(t, res)
 	}
-	_, err := service.CreateMission(context.Background(), req)
-	assert.Error(t, err)
-}
-
-func TestDeleteMission_Success(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	ctx := context.Background()
-
-	// Get mission before deletion.
-	missionRecord := postgres.Mission{ID: 1, Assignee: pgtype.Int4{Valid: false}}
-	mockStorage.On("GetMission", mock.Anything, int32(1)).Return(missionRecord, nil)
-	mockStorage.On("DeleteMission", mock.Anything, int32(1)).Return(int64(1), nil)
 
-	err := service.DeleteMission(ctx, 1)
-	assert.NoError(t, err)
-
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "GetTargetsNear", mock.Anything, mock.Anything)
 }
 
-func TestDeleteMission_AssignedMission(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	ctx := context.Background()
+func TestMatchSubjects(t *testing.T) {
+	subjects := []postgres.Subject{
+		{ID: 1, Name: "Ivan Petrenko", Country: "UA"},
+		{ID: 2, Name: "Olga Kovalenko", Country: "UA"},
+		{ID: 3, Name: "Ivan Petrenko", Country: "PL"},
+		{ID: 4, Name: "Petrenko Ivan", Country: "ua"},
+	}
 
-	// Cannot delete a mission that has an assignee.
-	missionRecord := postgres.Mission{ID: 2, Assignee: pgtype.Int4{Int32: 10, Valid: true}}
-	mockStorage.On("GetMission", mock.Anything, int32(2)).Return(missionRecord, nil)
+	res := matchSubjects(subjects, "Ivan Petrenko", "UA")
+	require.Len(t, res, 3)
+	// The case of the country doesn't matter, nor the order of the words.
+	assert.Equal(t, int32(1), res[0].Subject.ID)
+	assert.Equal(t, 1.0, res[0].Score)
+	assert.Equal(t, int32(4), res[1].Subject.ID)
+	assert.Equal(t, 1.0, res[1].Score)
+	// Another country only costs its weight.
+	assert.Equal(t, int32(3), res[2].Subject.ID)
+	assert.Equal(t, 0.8, res[2].Score)
+
+	assert.Empty(t, matchSubjects(subjects, "Anna", "UA"))
+	assert.Empty(t, matchSubjects(nil, "Ivan Petrenko", "UA"))
+}
 
-	err := service.DeleteMission(ctx, 2)
-	assert.Error(t, err)
+func TestValidateMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		merged  []int32
+		wantErr string
+	}{
+		{name: "subjects", merged: []int32{2, 3}},
+		{name: "no subjects", merged: nil, wantErr: "no subjects to merge"},
+		{name: "itself", merged: []int32{2, 1}, wantErr: "can't merge a subject into itself"},
+		{name: "duplicate", merged: []int32{2, 2}, wantErr: "subject 2 is listed twice"},
+		{name: "too many", merged: make([]int32, maxMergedSubjects+1), wantErr: "too many subjects to merge (1..100)"},
+	}
 
-	mockStorage.AssertExpectations(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMerge(1, tt.merged)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
 }
 
 //-------------------------------------
-// TARGET TESTS
+// ATTACHMENTS TESTS
 //-------------------------------------
 
-func TestAddTarget_Success(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	ctx := context.Background()
+// memBlobs is a BlobStore in memory.
+type memBlobs map[string][]byte
 
-	// Mission with no targets.
-	missionRecord := postgres.Mission{ID: 1, Assignee: pgtype.Int4{Valid: false}, Completed: false}
-	mockStorage.On("GetMission", mock.Anything, int32(1)).Return(missionRecord, nil)
-	mockStorage.On("GetMissionTargets", mock.Anything, int32(1)).Return([]postgres.Target{}, nil).Once()
+func (b memBlobs) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	b[key] = data
+	return err
+}
 
-	newTargetParams := postgres.CreateTargetParams{
-		Mission: 1,
-		Name:    "TargetX",
-		Country: "CountryX",
-		Notes:   "Note",
+func (b memBlobs) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := b[key]
+	if !ok {
+		return nil, blobs.ErrNotFound
 	}
-	newTarget := postgres.Target{ID: 100, Name: "TargetX", Country: "CountryX", Notes: "Note", Completed: false}
-	mockStorage.On("CreateTarget", mock.Anything, newTargetParams).Return(newTarget, nil)
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b memBlobs) Delete(ctx context.Context, key string) error {
+	delete(b, key)
+	return nil
+}
 
-	// Get mission after adding target.
-	mockStorage.On("GetMissionTargets", mock.Anything, int32(1)).Return([]postgres.Target{newTarget}, nil).Once()
+func upload(filename, content string) models.AttachmentUpload {
+	return models.AttachmentUpload{Filename: filename, Size: int64(len(content)), Content: strings.NewReader(content)}
+}
 
-	req := models.CreateTargetRequest{
-		Name:    "TargetX",
-		Country: "CountryX",
-		Notes:   "Note",
+func TestUploadAttachment_Success(t *testing.T) {
+	mockStorage := new(MockStorage)
+	blobStore := memBlobs{}
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, blobStore))
+
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150, Mission: 10}, nil)
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10}, nil)
+	mockStorage.On("GetMissionForUpdate", mock.Anything, int32(10)).Return(postgres.Mission{ID: 10}, nil)
+	mockStorage.On("CreateAttachment", mock.Anything, mock.MatchedBy(func(arg postgres.CreateAttachmentParams) bool {
+		return arg.Target == 150 && arg.Filename == "photo.png" && arg.ContentType == "image/png" && arg.Size == 12 &&
+			strings.HasPrefix(arg.BlobKey, "targets/150/")
+	})).Return(postgres.Attachment{ID: 1, Target: 150, Filename: "photo.png", ContentType: "image/png", Size: 12}, nil)
+
+	res, err := service.UploadAttachment(context.Background(), 150, upload("photos/photo.png", "\x89PNG\r\n\x1a\ndata"))
+	require.NoError(t, err)
+	assert.Equal(t, models.Attachment{ID: 1, TargetID: 150, Filename: "photo.png", ContentType: "image/png", Size: 12, CreatedAt: res.CreatedAt}, res)
+
+	// The whole content is stored, the sniffed bytes included.
+	require.Len(t, blobStore, 1)
+	for _, data := range blobStore {
+		assert.Equal(t, "\x89PNG\r\n\x1a\ndata", string(data))
 	}
-	mission, err := service.AddTarget(ctx, 1, req)
-	assert.NoError(t, err)
-	assert.Len(t, mission.Targets, 1)
-	assert.Equal(t, "TargetX", mission.Targets[0].Name)
 
 	mockStorage.AssertExpectations(t)
 }
 
-func TestAddTarget_TooManyTargets(t *testing.T) {
+func TestUploadAttachment_Invalid(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	ctx := context.Background()
-
-	// Mission with three existing targets.
-	missionRecord := postgres.Mission{ID: 1, Assignee: pgtype.Int4{Valid: false}, Completed: false}
-	targets := []postgres.Target{
-		{ID: 1}, {ID: 2}, {ID: 3},
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, memBlobs{}), WithMaxAttachmentSize(16))
+
+	tests := []struct {
+		upload  models.AttachmentUpload
+		wantErr string
+	}{
+		{upload("empty.txt", ""), "attachment is empty"},
+		{upload("big.txt", strings.Repeat("a", 17)), "attachment is too large (max 16 bytes)"},
+		{upload("", "notes"), "filename is required"},
+		{upload("/", "notes"), "filename is required"},
+		{upload(strings.Repeat("a", 257), "notes"), "filename is too long (1..256)"},
+		{upload("page.html", "<html></html>"), "unsupported content type text/html; charset=utf-8"},
+		{upload("app.zip", "PK\x03\x04zip data"), "unsupported content type application/zip"},
 	}
-	mockStorage.On("GetMission", mock.Anything, int32(1)).Return(missionRecord, nil)
-	mockStorage.On("GetMissionTargets", mock.Anything, int32(1)).Return(targets, nil)
-
-	req := models.CreateTargetRequest{
-		Name:    "ExtraTarget",
-		Country: "CountryY",
-		Notes:   "Note",
+	for _, tt := range tests {
+		_, err := service.UploadAttachment(context.Background(), 150, tt.upload)
+		assert.EqualError(t, err, tt.wantErr)
 	}
-	_, err := service.AddTarget(ctx, 1, req)
-	assert.Error(t, err)
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "GetTarget", mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything)
 }
 
-func TestDeleteTarget_Success(t *testing.T) {
+func TestUploadAttachment_CompletedMission(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
-
-	ctx := context.Background()
+	blobStore := memBlobs{}
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, blobStore))
 
-	mockStorage.On("DeleteTarget", mock.Anything, int32(100)).Return(int64(1), nil)
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150}, nil)
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10, Completed: true}, nil)
 
-	err := service.DeleteTarget(ctx, 100)
-	assert.NoError(t, err)
+	_, err := service.UploadAttachment(context.Background(), 150, upload("notes.txt", "Seen at the square"))
+	assert.EqualError(t, err, "Can't change target attachments of a completed mission")
+	assert.Empty(t, blobStore)
 
-	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything)
 }
 
-func TestDeleteTarget_NotFound(t *testing.T) {
+func TestUploadAttachment_StorageError(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	blobStore := memBlobs{}
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, blobStore))
 
-	ctx := context.Background()
-
-	mockStorage.On("DeleteTarget", mock.Anything, int32(200)).Return(int64(0), nil)
-
-	err := service.DeleteTarget(ctx, 200)
-	assert.Error(t, err)
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150, Mission: 10}, nil)
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10}, nil)
+	mockStorage.On("GetMissionForUpdate", mock.Anything, int32(10)).Return(postgres.Mission{ID: 10}, nil)
+	mockStorage.On("CreateAttachment", mock.Anything, mock.Anything).Return(postgres.Attachment{}, errors.New("db error"))
 
-	mockStorage.AssertExpectations(t)
+	_, err := service.UploadAttachment(context.Background(), 150, upload("notes.txt", "Seen at the square"))
+	assert.EqualError(t, err, "failed to upload attachment")
+	assert.Empty(t, blobStore, "the content of the failed upload is deleted")
 }
 
-func TestUpdateTargetNotes_Success(t *testing.T) {
+func TestUploadAttachment_CompletedMeanwhile(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	blobStore := memBlobs{}
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, blobStore))
 
-	ctx := context.Background()
+	// The target is completed between the check and the lock of its mission.
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150, Mission: 10}, nil).Once()
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150, Mission: 10, Completed: true}, nil).Once()
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10}, nil)
+	mockStorage.On("GetMissionForUpdate", mock.Anything, int32(10)).Return(postgres.Mission{ID: 10}, nil)
 
-	// Pending target.
-	targetRecord := postgres.Target{ID: 150, Name: "Target150", Country: "Country150", Notes: "Old", Completed: false}
-	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(targetRecord, nil)
+	_, err := service.UploadAttachment(context.Background(), 150, upload("notes.txt", "Seen at the square"))
+	assert.EqualError(t, err, "Can't change attachments of a completed target")
+	assert.Empty(t, blobStore, "the content of the rejected upload is deleted")
 
-	// Pending mission.
-	missionRow := postgres.GetMissionByTargetIDRow{MissionID: 10, Completed: false}
-	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(missionRow, nil)
+	mockStorage.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything)
+}
 
-	updateParams := postgres.UpdateTargetNotesParams{
-		ID:    150,
-		Notes: "New Notes",
-	}
-	updatedTarget := postgres.Target{ID: 150, Name: "Target150", Country: "Country150", Notes: "New Notes", Completed: false}
-	mockStorage.On("UpdateTargetNotes", mock.Anything, updateParams).Return(updatedTarget, nil)
+func TestDeleteAttachment_CompletedMeanwhile(t *testing.T) {
+	mockStorage := new(MockStorage)
+	blobStore := memBlobs{"targets/150/a": []byte("Seen at the square")}
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, blobStore))
 
-	target, err := service.UpdateTargetNotes(ctx, 150, "New Notes")
-	assert.NoError(t, err)
-	assert.Equal(t, "New Notes", target.Notes)
+	// The mission is completed between the check and its lock.
+	mockStorage.On("GetTarget", mock.Anything, int32(150)).Return(postgres.Target{ID: 150, Mission: 10}, nil)
+	mockStorage.On("GetMissionByTargetID", mock.Anything, int32(150)).Return(postgres.GetMissionByTargetIDRow{MissionID: 10}, nil)
+	mockStorage.On("GetAttachment", mock.Anything, int32(1)).Return(postgres.Attachment{ID: 1, Target: 150, BlobKey: "targets/150/a"}, nil)
+	mockStorage.On("GetMissionForUpdate", mock.Anything, int32(10)).Return(postgres.Mission{ID: 10, Completed: true}, nil)
 
-	mockStorage.AssertExpectations(t)
+	err := service.DeleteAttachment(context.Background(), 150, 1)
+	assert.EqualError(t, err, "Can't change target attachments of a completed mission")
+	assert.Len(t, blobStore, 1, "the content of the kept attachment is kept")
+
+	mockStorage.AssertNotCalled(t, "DeleteAttachment", mock.Anything, mock.Anything)
 }
 
-func TestUpdateTargetNotes_Empty(t *testing.T) {
+func TestGetAttachment_OtherTarget(t *testing.T) {
 	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage, WithAttachments(mockStorage, memBlobs{}))
 
-	ctx := context.Background()
+	mockStorage.On("GetAttachment", mock.Anything, int32(1)).Return(postgres.Attachment{ID: 1, Target: 151, BlobKey: "targets/151/a"}, nil)
 
-	// Can't update target notes to empty.
-	target, err := service.UpdateTargetNotes(ctx, 150, "")
-	assert.Error(t, err)
-	assert.Equal(t, models.Target{}, target)
+	_, _, err := service.GetAttachment(context.Background(), 150, 1)
+	assert.ErrorIs(t, err, models.ErrNotFound)
 }
 
-func TestCompleteTarget_Success(t *testing.T) {
-	mockStorage := new(MockStorage)
-	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
+func TestCleanFilename(t *testing.T) {
+	tests := map[string]string{
+		"photo.jpg":             "photo.jpg",
+		"/home/cat/photo.jpg":   "photo.jpg",
+		`C:\Users\cat\scan.pdf`: "scan.pdf",
+		" notes.txt ":           "notes.txt",
+		"":                      "",
+		"/":                     "",
+		"..":                    "",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, cleanFilename(name), name)
+	}
+}
 
-	ctx := context.Background()
+//-------------------------------------
+// NOTES TESTS
+//-------------------------------------
 
-	// Pending target.
-	targetRecord := postgres.Target{ID: 170, Completed: false}
-	mockStorage.On("GetTarget", mock.Anything, int32(170)).Return(targetRecord, nil)
+func TestRenderNotes(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
 
-	// Pending mission.
-	completedTarget := postgres.Target{ID: 170, Completed: true}
-	mockStorage.On("CompleteTarget", mock.Anything, int32(170)).Return(completedTarget, nil)
+	mockStorage.On("GetCat", mock.Anything, int32(12)).Return(postgres.Cat{ID: 12, Name: "Tom"}, nil).Once()
+	mockStorage.On("GetCat", mock.Anything, int32(13)).Return(postgres.Cat{}, pgx.ErrNoRows).Once()
+	mockStorage.On("GetTarget", mock.Anything, int32(7)).Return(postgres.Target{ID: 7, Mission: 2, Name: "Olga"}, nil).Once()
 
-	target, err := service.CompleteTarget(ctx, 170)
-	assert.NoError(t, err)
-	assert.True(t, target.Completed)
+	res, err := service.RenderNotes(context.Background(), []models.Target{
+		{ID: 5, Notes: "**Seen** with @cat:12 and @cat:13"},
+		{ID: 6, Notes: "Near @target:7, with @cat:12 again"},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "**Seen** with @cat:12 and @cat:13", res[0].Notes, "the Markdown is kept")
+	assert.Equal(t, `<p><strong>Seen</strong> with <a href="/cats/12" class="mention" rel="nofollow">@Tom</a> and @cat:13</p>`+"\n", res[0].NotesHTML)
+	assert.Equal(t, `<p>Near <a href="/missions/2" class="mention" rel="nofollow">@Olga</a>, with <a href="/cats/12" class="mention" rel="nofollow">@Tom</a> again</p>`+"\n", res[1].NotesHTML)
 
+	// Each mention is resolved once.
 	mockStorage.AssertExpectations(t)
 }
 
-func TestCompleteTarget_NotFound(t *testing.T) {
+func TestRenderNotes_StorageError(t *testing.T) {
 	mockStorage := new(MockStorage)
 	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
 
-	ctx := context.Background()
+	mockStorage.On("GetCat", mock.Anything, int32(12)).Return(postgres.Cat{}, errors.New("db error"))
 
-	// Target not found.
-	mockStorage.On("GetTarget", mock.Anything, int32(180)).Return(postgres.Target{}, errors.New("not found"))
+	_, err := service.RenderNotes(context.Background(), []models.Target{{ID: 5, Notes: "With @cat:12"}})
+	assert.EqualError(t, err, "failed to render notes")
+}
 
-	target, err := service.CompleteTarget(ctx, 180)
-	assert.Error(t, err)
-	assert.Equal(t, models.Target{}, target)
+func TestRenderNotes_MaxMentions(t *testing.T) {
+	mockStorage := new(MockStorage)
+	service := NewService(mockStorage, mockStorage, mockStorage, mockStorage)
 
-	mockStorage.AssertExpectations(t)
+	var notes strings.Builder
+	for i := range maxResolvedMentions + 1 {
+		fmt.Fprintf(&notes, "@cat:%d ", i+1)
+	}
+	mockStorage.On("GetCat", mock.Anything, mock.Anything).Return(postgres.Cat{}, pgx.ErrNoRows)
+
+	_, err := service.RenderNotes(context.Background(), []models.Target{{ID: 5, Notes: notes.String()}})
+	require.NoError(t, err)
+	mockStorage.AssertNumberOfCalls(t, "GetCat", maxResolvedMentions)
 }
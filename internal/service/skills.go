@@ -0,0 +1,501 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// maxSkillName bounds the names of the skills, like the column.
+	maxSkillName = 64
+	// minSkillLevel and maxSkillLevel bound the levels of the skills.
+	minSkillLevel = 1
+	maxSkillLevel = 5
+)
+
+func (s Service) GetAllSkills(ctx context.Context) ([]models.Skill, error) {
+	log := slog.With(
+		slog.String("op", "service.GetAllSkills"),
+	)
+
+	log.Debug("Fetching skills")
+
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	res, err := s.skillStorage.GetAllSkills(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Skill, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get skills", "err", err)
+		return make([]models.Skill, 0), errors.New("failed to fetch skills")
+	}
+
+	skills := make([]models.Skill, len(res))
+	for i, sk := range res {
+		skills[i] = models.Skill{ID: sk.ID, Name: sk.Name}
+	}
+
+	return skills, nil
+}
+
+func (s Service) CreateSkill(ctx context.Context, req models.CreateSkillRequest) (models.Skill, error) {
+	log := slog.With(
+		slog.String("op", "service.CreateSkill"),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Creating skill")
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxSkillName {
+		return models.Skill{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("name must be 1 to %d characters", maxSkillName))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.skillStorage.CreateSkill(ctx, name)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Skill{}, models.ErrTimeoutExceeded
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			log.Debug("Skill already exists")
+			return models.Skill{}, models.NewError(http.StatusConflict, "skill already exists")
+		}
+		log.Error("Failed to create skill", "err", err)
+		return models.Skill{}, errors.New("failed to create skill")
+	}
+
+	log.Debug("Created skill", "id", res.ID)
+
+	return models.Skill{ID: res.ID, Name: res.Name}, nil
+}
+
+// DeleteSkill deletes the skill from the catalog, the cats and the targets.
+func (s Service) DeleteSkill(ctx context.Context, id int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteSkill"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Deleting skill")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.skillStorage.DeleteSkill(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete skill", "err", err)
+		return errors.New("failed to delete skill")
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s Service) GetCatSkills(ctx context.Context, catID int32) ([]models.CatSkill, error) {
+	log := slog.With(
+		slog.String("op", "service.GetCatSkills"),
+		slog.Any("catId", catID),
+	)
+
+	log.Debug("Fetching cat skills")
+
+	if _, err := s.GetCat(ctx, catID); err != nil {
+		return make([]models.CatSkill, 0), err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.skillStorage.GetCatSkills(ctx, catID)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.CatSkill, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get cat skills", "err", err)
+		return make([]models.CatSkill, 0), errors.New("failed to get cat skills")
+	}
+
+	now := time.Now()
+	skills := make([]models.CatSkill, len(res))
+	for i, r := range res {
+		skills[i] = catSkillToModel(r.Skill, r.Name, r.Level, r.CertifiedUntil, now)
+	}
+
+	return skills, nil
+}
+
+// SetCatSkill adds the skill to the cat, or updates its level and certification.
+func (s Service) SetCatSkill(ctx context.Context, catID, skillID int32, req models.CatSkillRequest) (models.CatSkill, error) {
+	log := slog.With(
+		slog.String("op", "service.SetCatSkill"),
+		slog.Any("catId", catID),
+		slog.Any("skillId", skillID),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Setting cat skill")
+
+	if err := validateSkillLevel("level", req.Level); err != nil {
+		return models.CatSkill{}, err
+	}
+
+	if _, err := s.GetCat(ctx, catID); err != nil {
+		return models.CatSkill{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	skill, err := s.getSkill(ctx, log, skillID)
+	if err != nil {
+		return models.CatSkill{}, err
+	}
+
+	res, err := s.skillStorage.SetCatSkill(ctx, postgres.SetCatSkillParams{
+		Cat:            catID,
+		Skill:          skillID,
+		Level:          req.Level,
+		CertifiedUntil: ptrToTimestamptz(req.CertifiedUntil),
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.CatSkill{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to set cat skill", "err", err)
+		return models.CatSkill{}, errors.New("failed to set cat skill")
+	}
+
+	return catSkillToModel(res.Skill, skill.Name, res.Level, res.CertifiedUntil, time.Now()), nil
+}
+
+func (s Service) DeleteCatSkill(ctx context.Context, catID, skillID int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteCatSkill"),
+		slog.Any("catId", catID),
+		slog.Any("skillId", skillID),
+	)
+
+	log.Debug("Deleting cat skill")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.skillStorage.DeleteCatSkill(ctx, postgres.DeleteCatSkillParams{Cat: catID, Skill: skillID})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete cat skill", "err", err)
+		return errors.New("failed to delete cat skill")
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s Service) GetTargetSkills(ctx context.Context, targetID int32) ([]models.TargetSkill, error) {
+	log := slog.With(
+		slog.String("op", "service.GetTargetSkills"),
+		slog.Any("targetId", targetID),
+	)
+
+	log.Debug("Fetching target skills")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := s.getTarget(ctx, log, targetID); err != nil {
+		return make([]models.TargetSkill, 0), err
+	}
+
+	res, err := s.skillStorage.GetTargetSkills(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.TargetSkill, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get target skills", "err", err)
+		return make([]models.TargetSkill, 0), errors.New("failed to get target skills")
+	}
+
+	skills := make([]models.TargetSkill, len(res))
+	for i, r := range res {
+		skills[i] = models.TargetSkill{SkillID: r.Skill, Name: r.Name, MinLevel: r.MinLevel, Certified: r.Certified}
+	}
+
+	return skills, nil
+}
+
+// SetTargetSkill requires the skill for the target, or updates the requirement.
+func (s Service) SetTargetSkill(ctx context.Context, targetID, skillID int32, req models.TargetSkillRequest) (models.TargetSkill, error) {
+	log := slog.With(
+		slog.String("op", "service.SetTargetSkill"),
+		slog.Any("targetId", targetID),
+		slog.Any("skillId", skillID),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Setting target skill")
+
+	if err := validateSkillLevel("min_level", req.MinLevel); err != nil {
+		return models.TargetSkill{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	target, err := s.getTarget(ctx, log, targetID)
+	if err != nil {
+		return models.TargetSkill{}, err
+	}
+	if target.Completed {
+		log.Debug("Target is completed")
+		return models.TargetSkill{}, models.NewError(http.StatusUnprocessableEntity, "can't change the skills of a completed target")
+	}
+
+	skill, err := s.getSkill(ctx, log, skillID)
+	if err != nil {
+		return models.TargetSkill{}, err
+	}
+
+	res, err := s.skillStorage.SetTargetSkill(ctx, postgres.SetTargetSkillParams{
+		Target:    targetID,
+		Skill:     skillID,
+		MinLevel:  req.MinLevel,
+		Certified: req.Certified,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.TargetSkill{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to set target skill", "err", err)
+		return models.TargetSkill{}, errors.New("failed to set target skill")
+	}
+
+	return models.TargetSkill{SkillID: res.Skill, Name: skill.Name, MinLevel: res.MinLevel, Certified: res.Certified}, nil
+}
+
+func (s Service) DeleteTargetSkill(ctx context.Context, targetID, skillID int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteTargetSkill"),
+		slog.Any("targetId", targetID),
+		slog.Any("skillId", skillID),
+	)
+
+	log.Debug("Deleting target skill")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.skillStorage.DeleteTargetSkill(ctx, postgres.DeleteTargetSkillParams{Target: targetID, Skill: skillID})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete target skill", "err", err)
+		return errors.New("failed to delete target skill")
+	}
+	if rows == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetMissionCandidates ranks the cats without an active mission for the
+// mission: the cats available now first, then by the fraction of the skills
+// required by the pending targets they meet, then by experience.
+func (s Service) GetMissionCandidates(ctx context.Context, missionID int32) ([]models.Candidate, error) {
+	log := slog.With(
+		slog.String("op", "service.GetMissionCandidates"),
+		slog.Any("missionId", missionID),
+	)
+
+	log.Debug("Ranking mission candidates")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	fail := func(err error) ([]models.Candidate, error) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Candidate, 0), models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Mission not found")
+			return make([]models.Candidate, 0), models.ErrNotFound
+		}
+		log.Error("Failed to rank candidates", "err", err)
+		return make([]models.Candidate, 0), errors.New("failed to rank candidates")
+	}
+
+	mission, err := s.missionStorage.GetMission(ctx, missionID)
+	if err != nil {
+		return fail(err)
+	}
+	if mission.Completed {
+		return make([]models.Candidate, 0), models.NewError(http.StatusUnprocessableEntity, "mission is completed")
+	}
+
+	required, err := s.skillStorage.GetMissionRequiredSkills(ctx, missionID)
+	if err != nil {
+		return fail(err)
+	}
+	cats, err := s.skillStorage.GetUnassignedCats(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	catSkills, err := s.skillStorage.GetUnassignedCatSkills(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	now := time.Now()
+
+	// Without an availability storage, all the cats are available.
+	var available map[int32]bool
+	if s.availabilityStorage != nil {
+		res, err := s.availabilityStorage.GetAvailableCats(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+		if err != nil {
+			return fail(err)
+		}
+		available = make(map[int32]bool, len(res))
+		for _, c := range res {
+			available[c.ID] = true
+		}
+	}
+
+	skillsOf := make(map[int32]map[int32]postgres.CatSkill)
+	for _, cs := range catSkills {
+		if skillsOf[cs.Cat] == nil {
+			skillsOf[cs.Cat] = make(map[int32]postgres.CatSkill)
+		}
+		skillsOf[cs.Cat][cs.Skill] = cs
+	}
+
+	candidates := make([]models.Candidate, len(cats))
+	for i, c := range cats {
+		candidates[i] = rankCandidate(sqlcCatToModel(c), required, skillsOf[c.ID], now)
+		candidates[i].Available = available == nil || available[c.ID]
+	}
+
+	slices.SortStableFunc(candidates, func(a, b models.Candidate) int {
+		if a.Available != b.Available {
+			if a.Available {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.SkillMatch, a.SkillMatch); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Cat.YearsOfExperience, a.Cat.YearsOfExperience)
+	})
+
+	return candidates, nil
+}
+
+// rankCandidate matches the skills of the cat with the required skills.
+func rankCandidate(cat models.Cat, required []postgres.GetMissionRequiredSkillsRow, skills map[int32]postgres.CatSkill, now time.Time) models.Candidate {
+	c := models.Candidate{
+		Cat:           cat,
+		SkillMatch:    1,
+		MatchedSkills: make([]string, 0),
+		MissingSkills: make([]string, 0),
+	}
+
+	for _, r := range required {
+		cs, ok := skills[r.Skill]
+		if ok && cs.Level >= r.MinLevel && (!r.Certified || certified(cs.CertifiedUntil, now)) {
+			c.MatchedSkills = append(c.MatchedSkills, r.Name)
+		} else {
+			c.MissingSkills = append(c.MissingSkills, r.Name)
+		}
+	}
+	if len(required) > 0 {
+		c.SkillMatch = float64(len(c.MatchedSkills)) / float64(len(required))
+	}
+
+	return c
+}
+
+// certified tells whether a certification valid until the time is valid now.
+func certified(until pgtype.Timestamptz, now time.Time) bool {
+	return until.Valid && until.Time.After(now)
+}
+
+func (s Service) getSkill(ctx context.Context, log *slog.Logger, id int32) (postgres.Skill, error) {
+	skill, err := s.skillStorage.GetSkill(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return postgres.Skill{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Skill not found")
+			return postgres.Skill{}, models.NewError(http.StatusNotFound, "skill not found")
+		}
+		log.Error("Failed to get skill", "err", err)
+		return postgres.Skill{}, errors.New("failed to get skill")
+	}
+
+	return skill, nil
+}
+
+func (s Service) getTarget(ctx context.Context, log *slog.Logger, id int32) (postgres.Target, error) {
+	target, err := s.targetStorage.GetTarget(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return postgres.Target{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target not found")
+			return postgres.Target{}, models.ErrNotFound
+		}
+		log.Error("Failed to get target", "err", err)
+		return postgres.Target{}, errors.New("failed to get target")
+	}
+
+	return target, nil
+}
+
+func validateSkillLevel(field string, level int32) error {
+	if level < minSkillLevel || level > maxSkillLevel {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("%s must be between %d and %d", field, minSkillLevel, maxSkillLevel))
+	}
+
+	return nil
+}
+
+func catSkillToModel(skill int32, name string, level int32, until pgtype.Timestamptz, now time.Time) models.CatSkill {
+	return models.CatSkill{
+		SkillID:        skill,
+		Name:           name,
+		Level:          level,
+		CertifiedUntil: timestamptzToPtr(until),
+		Certified:      certified(until, now),
+	}
+}
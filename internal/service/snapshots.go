@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/snapshot"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// snapshotTimeout bounds the export and the restore of a snapshot.
+	snapshotTimeout = 5 * time.Minute
+	// snapshotPageSize is the number of entities read by a query of an export.
+	snapshotPageSize = 500
+)
+
+// ExportSnapshot writes a snapshot of the cats, the subjects, the missions and
+// the targets.
+//
+// The entities are read a page at a time and written as they are read, in a
+// read-only transaction whose queries all see the database as of the first
+// one, so the targets of the snapshot belong to its missions.
+func (s Service) ExportSnapshot(ctx context.Context, w io.Writer) error {
+	log := slog.With(
+		slog.String("op", "service.ExportSnapshot"),
+	)
+
+	log.Debug("Exporting snapshot")
+
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	version, err := s.snapshotStorage.SchemaVersion(ctx)
+	if err != nil {
+		log.Error("Failed to get schema version", "err", err)
+		return errors.New("failed to export snapshot")
+	}
+
+	tx, err := s.txStorage.BeginTx(ctx, storage.TxOptions{RepeatableRead: true, ReadOnly: true})
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return errors.New("failed to export snapshot")
+	}
+	// Nothing is written, the transaction only gives a consistent view.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sw, err := snapshot.NewWriter(w, snapshot.Metadata{
+		FormatVersion: snapshot.FormatVersion,
+		SchemaVersion: version,
+		CreatedAt:     time.Now().UTC(),
+	})
+	if err == nil {
+		err = writeSnapshot(ctx, s.txStorage.WithTx(tx), sw)
+	}
+	if err != nil {
+		log.Error("Failed to write snapshot", "err", err)
+		return errors.New("failed to export snapshot")
+	}
+
+	log.Debug("Exported snapshot")
+
+	return nil
+}
+
+// writeSnapshot writes the entities and the end of the archive.
+func writeSnapshot(ctx context.Context, q postgres.Querier, sw *snapshot.Writer) error {
+	err := eachPage(func(after int32) ([]postgres.Cat, error) {
+		return q.GetCatsPage(ctx, postgres.GetCatsPageParams{After: after, MaxRows: snapshotPageSize})
+	}, func(c postgres.Cat) int32 { return c.ID }, func(c postgres.Cat) error {
+		return sw.WriteCat(snapshot.Cat{
+			ID:                c.ID,
+			Name:              c.Name,
+			Breed:             c.Breed,
+			YearsOfExperience: c.YearsOfExperience,
+			Salary:            c.Salary,
+			SalaryCurrency:    c.SalaryCurrency,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("cats: %w", err)
+	}
+
+	err = eachPage(func(after int32) ([]postgres.Subject, error) {
+		return q.GetSubjectsPage(ctx, postgres.GetSubjectsPageParams{After: after, MaxRows: snapshotPageSize})
+	}, func(s postgres.Subject) int32 { return s.ID }, func(s postgres.Subject) error {
+		return sw.WriteSubject(snapshot.Subject{
+			ID:        s.ID,
+			Name:      s.Name,
+			Country:   s.Country,
+			CreatedAt: s.CreatedAt.Time,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("subjects: %w", err)
+	}
+
+	err = eachPage(func(after int32) ([]postgres.Mission, error) {
+		return q.GetMissionsPage(ctx, postgres.GetMissionsPageParams{After: after, MaxRows: snapshotPageSize})
+	}, func(m postgres.Mission) int32 { return m.ID }, func(m postgres.Mission) error {
+		return sw.WriteMission(snapshot.Mission{
+			ID:          m.ID,
+			Assignee:    int4ToPtr(m.Assignee),
+			Completed:   m.Completed,
+			CreatedAt:   m.CreatedAt.Time,
+			AssignedAt:  timestamptzToPtr(m.AssignedAt),
+			CompletedAt: timestamptzToPtr(m.CompletedAt),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("missions: %w", err)
+	}
+
+	err = eachPage(func(after int32) ([]postgres.Target, error) {
+		return q.GetTargetsPage(ctx, postgres.GetTargetsPageParams{After: after, MaxRows: snapshotPageSize})
+	}, func(t postgres.Target) int32 { return t.ID }, func(t postgres.Target) error {
+		return sw.WriteTarget(snapshot.Target{
+			ID:          t.ID,
+			Mission:     t.Mission,
+			Name:        t.Name,
+			Country:     t.Country,
+			Notes:       t.Notes,
+			Completed:   t.Completed,
+			CreatedAt:   t.CreatedAt.Time,
+			CompletedAt: timestamptzToPtr(t.CompletedAt),
+			Latitude:    float8ToPtr(t.Latitude),
+			Longitude:   float8ToPtr(t.Longitude),
+			Address:     t.Address,
+			Subject:     int4ToPtr(t.Subject),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("targets: %w", err)
+	}
+
+	return sw.Close()
+}
+
+// eachPage calls fn with the rows of the pages, each read after the ID of the
+// last row of the previous one, until a page isn't full.
+func eachPage[T any](page func(after int32) ([]T, error), id func(T) int32, fn func(T) error) error {
+	var after int32
+	for {
+		rows, err := page(after)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < snapshotPageSize {
+			return nil
+		}
+		after = id(rows[len(rows)-1])
+	}
+}
+
+// RestoreSnapshot restores a snapshot into an empty database in a single
+// transaction, preserving the IDs of the entities.
+//
+// The snapshot must have been exported from a database of the same schema version.
+func (s Service) RestoreSnapshot(ctx context.Context, r io.Reader) (models.RestoreResult, error) {
+	log := slog.With(
+		slog.String("op", "service.RestoreSnapshot"),
+	)
+
+	log.Debug("Restoring snapshot")
+
+	sr, md, err := snapshot.NewReader(r)
+	if err != nil {
+		log.Info("Invalid snapshot", "err", err)
+		return models.RestoreResult{}, models.NewError(http.StatusUnprocessableEntity, "invalid snapshot: "+err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	version, err := s.snapshotStorage.SchemaVersion(ctx)
+	if err != nil {
+		log.Error("Failed to get schema version", "err", err)
+		return models.RestoreResult{}, errors.New("failed to restore snapshot")
+	}
+	if md.SchemaVersion != version {
+		log.Info("Schema version mismatch", "snapshot", md.SchemaVersion, "database", version)
+		return models.RestoreResult{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("snapshot schema version %d doesn't match the database schema version %d", md.SchemaVersion, version))
+	}
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return models.RestoreResult{}, errors.New("failed to restore snapshot")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	var cats []postgres.Cat
+	var subjects []postgres.Subject
+	var missions []postgres.Mission
+	cats, err = withTx.GetAllCats(ctx)
+	if err == nil {
+		subjects, err = withTx.GetAllSubjects(ctx)
+	}
+	if err == nil {
+		missions, err = withTx.GetAllMissions(ctx)
+	}
+	if err != nil {
+		log.Error("Failed to check the database is empty", "err", err)
+		return models.RestoreResult{}, errors.New("failed to restore snapshot")
+	}
+	// Targets belong to missions, so there are none either.
+	if len(cats) > 0 || len(subjects) > 0 || len(missions) > 0 {
+		err = models.NewError(http.StatusConflict, "database is not empty")
+		return models.RestoreResult{}, err
+	}
+
+	res := models.RestoreResult{SchemaVersion: version}
+	for {
+		var entity any
+		entity, err = sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Info("Invalid snapshot", "err", err)
+			err = models.NewError(http.StatusUnprocessableEntity, "invalid snapshot: "+err.Error())
+			return models.RestoreResult{}, err
+		}
+
+		switch e := entity.(type) {
+		case snapshot.Cat:
+			err = withTx.RestoreCat(ctx, postgres.RestoreCatParams{
+				ID:                e.ID,
+				Name:              e.Name,
+				YearsOfExperience: e.YearsOfExperience,
+				Breed:             e.Breed,
+				Salary:            e.Salary,
+				SalaryCurrency:    e.SalaryCurrency,
+			})
+			res.Cats++
+		case snapshot.Subject:
+			err = withTx.RestoreSubject(ctx, postgres.RestoreSubjectParams{
+				ID:        e.ID,
+				Name:      e.Name,
+				Country:   e.Country,
+				CreatedAt: pgtype.Timestamptz{Time: e.CreatedAt, Valid: true},
+			})
+			res.Subjects++
+		case snapshot.Mission:
+			var assignee pgtype.Int4
+			if e.Assignee != nil {
+				assignee = pgtype.Int4{Int32: *e.Assignee, Valid: true}
+			}
+			err = withTx.RestoreMission(ctx, postgres.RestoreMissionParams{
+				ID:          e.ID,
+				Assignee:    assignee,
+				Completed:   e.Completed,
+				CreatedAt:   pgtype.Timestamptz{Time: e.CreatedAt, Valid: true},
+				AssignedAt:  ptrToTimestamptz(e.AssignedAt),
+				CompletedAt: ptrToTimestamptz(e.CompletedAt),
+			})
+			// The snapshot has no history of the assignments, the current one
+			// is its first record.
+			if err == nil && assignee.Valid && e.AssignedAt != nil {
+				err = withTx.CreateMissionAssignment(ctx, postgres.CreateMissionAssignmentParams{
+					Mission:    e.ID,
+					Cat:        assignee.Int32,
+					AssignedAt: ptrToTimestamptz(e.AssignedAt),
+				})
+			}
+			res.Missions++
+		case snapshot.Target:
+			err = withTx.RestoreTarget(ctx, postgres.RestoreTargetParams{
+				ID:          e.ID,
+				Mission:     e.Mission,
+				Name:        e.Name,
+				Country:     e.Country,
+				Notes:       e.Notes,
+				Completed:   e.Completed,
+				CreatedAt:   pgtype.Timestamptz{Time: e.CreatedAt, Valid: true},
+				CompletedAt: ptrToTimestamptz(e.CompletedAt),
+				Latitude:    ptrToFloat8(e.Latitude),
+				Longitude:   ptrToFloat8(e.Longitude),
+				Address:     e.Address,
+				Subject:     ptrToInt4(e.Subject),
+			})
+			res.Targets++
+		}
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.RestoreResult{}, models.ErrTimeoutExceeded
+			}
+			// Duplicate IDs and references to missing entities.
+			if msg, ok := invalidData(err); ok {
+				log.Info("Invalid snapshot", "line", sr.Line(), "err", err)
+				err = models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("invalid snapshot: line %d: %s", sr.Line(), msg))
+				return models.RestoreResult{}, err
+			}
+			log.Error("Failed to restore entity", "line", sr.Line(), "err", err)
+			return models.RestoreResult{}, errors.New("failed to restore snapshot")
+		}
+	}
+
+	if err = withTx.ResetSequences(ctx); err != nil {
+		log.Error("Failed to reset sequences", "err", err)
+		return models.RestoreResult{}, errors.New("failed to restore snapshot")
+	}
+
+	log.Info("Restored snapshot", "cats", res.Cats, "subjects", res.Subjects, "missions", res.Missions, "targets", res.Targets)
+
+	return res, nil
+}
+
+func ptrToInt4(v *int32) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *v, Valid: true}
+}
+
+func ptrToTimestamptz(v *time.Time) pgtype.Timestamptz {
+	if v == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *v, Valid: true}
+}
+
+// invalidData returns the message of the error of the storage if it is a
+// violated constraint or a data exception, which all the backends return as a
+// *pgconn.PgError of the class 23 or 22 of Postgres.
+func invalidData(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "23") || strings.HasPrefix(pgErr.Code, "22")) {
+		return pgErr.Message, true
+	}
+	return "", false
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/fuzzy"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+const (
+	// minSubjectScore is the lowest score of a suggested subject.
+	minSubjectScore = 0.6
+	// maxSubjectSuggestions is the maximum number of subjects suggested for a target.
+	maxSubjectSuggestions = 5
+	// maxMergedSubjects is the maximum number of subjects merged at once.
+	maxMergedSubjects = 100
+)
+
+// GetSubject returns the subject with the missions of its targets and the
+// history of their notes.
+func (s Service) GetSubject(ctx context.Context, id int32) (models.SubjectDetails, error) {
+	log := slog.With(
+		slog.String("op", "service.GetSubject"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Getting subject")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	subject, err := s.subjectStorage.GetSubject(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.SubjectDetails{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Subject not found")
+			return models.SubjectDetails{}, models.ErrNotFound
+		}
+		log.Error("Failed to get subject", "err", err)
+		return models.SubjectDetails{}, errors.New("failed to get subject")
+	}
+
+	ref := pgtype.Int4{Int32: id, Valid: true}
+
+	targets, err := s.subjectStorage.GetSubjectTargets(ctx, ref)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.SubjectDetails{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get subject targets", "err", err)
+		return models.SubjectDetails{}, errors.New("failed to get subject")
+	}
+
+	notes, err := s.subjectStorage.GetSubjectNotes(ctx, ref)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.SubjectDetails{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get subject notes", "err", err)
+		return models.SubjectDetails{}, errors.New("failed to get subject")
+	}
+
+	// Group the targets by mission, in the order of the missions.
+	missionOf := make(map[int32]int32, len(targets))
+	byMission := make(map[int32][]models.Target)
+	for _, t := range targets {
+		missionOf[t.ID] = t.Mission
+		byMission[t.Mission] = append(byMission[t.Mission], sqlcTargetToModel(t))
+	}
+
+	res := models.SubjectDetails{
+		Subject:      sqlcSubjectToModel(subject),
+		Missions:     make([]models.Mission, 0, len(byMission)),
+		NotesHistory: make([]models.TargetNoteChange, len(notes)),
+	}
+	for _, missionID := range sortedKeys(byMission) {
+		var m postgres.Mission
+		m, err = s.missionStorage.GetMission(ctx, missionID)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.SubjectDetails{}, models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to get mission", "mission", missionID, "err", err)
+			return models.SubjectDetails{}, errors.New("failed to get subject")
+		}
+
+		mission := sqlcMissionToModel(m)
+		mission.Targets = byMission[missionID]
+		res.Missions = append(res.Missions, mission)
+	}
+	for i, n := range notes {
+		res.NotesHistory[i] = models.TargetNoteChange{
+			TargetID:      n.Target,
+			MissionID:     missionOf[n.Target],
+			PreviousNotes: n.PreviousNotes,
+			Notes:         n.Notes,
+			UpdatedAt:     n.UpdatedAt.Time.UTC(),
+		}
+	}
+
+	return res, nil
+}
+
+// MergeSubjects merges the subjects into the subject id, which takes over
+// their targets, and deletes them.
+func (s Service) MergeSubjects(ctx context.Context, id int32, req models.MergeSubjectsRequest) (models.SubjectDetails, error) {
+	log := slog.With(
+		slog.String("op", "service.MergeSubjects"),
+		slog.Any("id", id),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Merging subjects")
+
+	if err := validateMerge(id, req.Subjects); err != nil {
+		log.Info("Invalid merge")
+		return models.SubjectDetails{}, err
+	}
+
+	if err := s.mergeSubjects(ctx, log, id, req.Subjects); err != nil {
+		return models.SubjectDetails{}, err
+	}
+
+	log.Info("Merged subjects")
+
+	return s.GetSubject(ctx, id)
+}
+
+func (s Service) mergeSubjects(ctx context.Context, log *slog.Logger, id int32, merged []int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		log.Error("Failed to begin transaction", "err", err)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	_, err = withTx.GetSubject(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Subject not found")
+			return models.ErrNotFound
+		}
+		log.Error("Failed to get subject", "err", err)
+		return errors.New("failed to merge subjects")
+	}
+
+	for _, from := range merged {
+		_, err = withTx.MoveSubjectTargets(ctx, postgres.MoveSubjectTargetsParams{
+			ToSubject:   pgtype.Int4{Int32: id, Valid: true},
+			FromSubject: pgtype.Int4{Int32: from, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to move subject targets", "subject", from, "err", err)
+			return errors.New("failed to merge subjects")
+		}
+
+		var rows int64
+		rows, err = withTx.DeleteSubject(ctx, from)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to delete subject", "subject", from, "err", err)
+			return errors.New("failed to merge subjects")
+		}
+		if rows == 0 {
+			log.Info("Merged subject not found", "subject", from)
+			err = models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("subject %d not found", from))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateMerge checks the subjects to merge into the subject id.
+func validateMerge(id int32, merged []int32) error {
+	if len(merged) == 0 {
+		return models.NewError(http.StatusUnprocessableEntity, "no subjects to merge")
+	}
+	if len(merged) > maxMergedSubjects {
+		return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("too many subjects to merge (1..%d)", maxMergedSubjects))
+	}
+
+	seen := make(map[int32]bool, len(merged))
+	for _, from := range merged {
+		if from == id {
+			return models.NewError(http.StatusUnprocessableEntity, "can't merge a subject into itself")
+		}
+		if seen[from] {
+			return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("subject %d is listed twice", from))
+		}
+		seen[from] = true
+	}
+
+	return nil
+}
+
+// subjectLinker links the new targets of a request to their subjects within
+// its transaction, so the targets of a request are matched with each other
+// too. A nil linker links nothing, for a Service without subject storage.
+type subjectLinker struct {
+	q postgres.Querier
+}
+
+// newSubjectLinker returns a linker looking up the subjects with q.
+func (s Service) newSubjectLinker(q postgres.Querier) *subjectLinker {
+	if s.subjectStorage == nil {
+		return nil
+	}
+
+	return &subjectLinker{q: q}
+}
+
+// link returns the subject of a new target with the known subjects similar to
+// it. The subject must exist if it is set, and no subjects are suggested for
+// it. A new subject is created for the target otherwise.
+func (l *subjectLinker) link(ctx context.Context, subjectID *int32, name, country string) (pgtype.Int4, []models.SubjectMatch, error) {
+	if l == nil {
+		return pgtype.Int4{}, nil, nil
+	}
+
+	if subjectID != nil {
+		_, err := l.q.GetSubject(ctx, *subjectID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return pgtype.Int4{}, nil, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("subject %d not found", *subjectID))
+			}
+			return pgtype.Int4{}, nil, err
+		}
+		return pgtype.Int4{Int32: *subjectID, Valid: true}, nil, nil
+	}
+
+	similar, err := l.q.GetSimilarSubjects(ctx, postgres.GetSimilarSubjectsParams{
+		Name:        name,
+		Country:     country,
+		MaxSubjects: maxSubjectSuggestions,
+	})
+	if err != nil {
+		return pgtype.Int4{}, nil, err
+	}
+	suggestions := matchSubjects(similar, name, country)
+
+	subject, err := l.q.CreateSubject(ctx, postgres.CreateSubjectParams{
+		Name:    name,
+		Country: country,
+	})
+	if err != nil {
+		return pgtype.Int4{}, nil, err
+	}
+
+	return pgtype.Int4{Int32: subject.ID, Valid: true}, suggestions, nil
+}
+
+// matchSubjects returns the subjects similar to the name and the country, the
+// most similar first, out of the candidates found by the storage.
+//
+// The score weighs the similarity of the names by 0.8 and the one of the
+// countries by 0.2.
+func matchSubjects(subjects []postgres.Subject, name, country string) []models.SubjectMatch {
+	var matches []models.SubjectMatch
+	for _, subject := range subjects {
+		countryScore := 1.0
+		if !strings.EqualFold(subject.Country, country) {
+			countryScore = fuzzy.Similarity(subject.Country, country)
+		}
+
+		score := 0.8*fuzzy.Similarity(subject.Name, name) + 0.2*countryScore
+		score = math.Round(score*100) / 100
+		if score < minSubjectScore {
+			continue
+		}
+
+		matches = append(matches, models.SubjectMatch{
+			Subject: sqlcSubjectToModel(subject),
+			Score:   score,
+		})
+	}
+
+	slices.SortStableFunc(matches, func(a, b models.SubjectMatch) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return matches[:min(len(matches), maxSubjectSuggestions)]
+}
+
+// sortedKeys returns the keys of the map in ascending order.
+func sortedKeys[V any](m map[int32]V) []int32 {
+	keys := make([]int32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func sqlcSubjectToModel(s postgres.Subject) models.Subject {
+	return models.Subject{
+		ID:        s.ID,
+		Name:      s.Name,
+		Country:   s.Country,
+		CreatedAt: s.CreatedAt.Time.UTC(),
+	}
+}
@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
 	"github.com/rsmanito/developstoday-test-assessment/internal/models"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
 )
@@ -21,11 +23,37 @@ func (s Service) AddTarget(ctx context.Context, missionId int32, req models.Crea
 
 	log.Debug("Adding target")
 
+	r := s.currentRules()
+	if err := checkNotesLength(r, req.Notes); err != nil {
+		log.Info("Notes are too long")
+		return models.Mission{}, err
+	}
+	if err := checkLocation(req.Latitude, req.Longitude, req.Address); err != nil {
+		log.Info("Invalid location")
+		return models.Mission{}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	// Get mission.
-	m, err := s.missionStorage.GetMission(ctx, missionId)
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Mission{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	// Get mission, locked until the target is added so concurrent additions
+	// can't exceed the maximum of targets.
+	m, err := withTx.GetMissionForUpdate(ctx, missionId)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return models.Mission{}, models.ErrTimeoutExceeded
@@ -39,7 +67,7 @@ func (s Service) AddTarget(ctx context.Context, missionId int32, req models.Crea
 	}
 
 	// Get targets for mission
-	targets, err := s.targetStorage.GetMissionTargets(ctx, missionId)
+	targets, err := withTx.GetMissionTargets(ctx, missionId)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return models.Mission{}, models.ErrTimeoutExceeded
@@ -48,18 +76,39 @@ func (s Service) AddTarget(ctx context.Context, missionId int32, req models.Crea
 		return models.Mission{}, errors.New("failed to add target")
 	}
 
-	// Check if mission already has 3 targets.
-	if len(targets) >= 3 {
-		log.Info("Mission already has 3 targets")
-		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "mission has maximum targets (3)")
+	// Check if mission already has the maximum of targets.
+	if len(targets) >= r.Targets.Max {
+		log.Info("Mission already has the maximum of targets")
+		err = models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("mission has maximum targets (%d)", r.Targets.Max))
+		return models.Mission{}, err
+	}
+
+	// Link the target to its subject.
+	linker := s.newSubjectLinker(withTx)
+	subject, suggestions, err := linker.link(ctx, req.SubjectID, req.Name, req.Country)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		var modelErr *models.Err
+		if errors.As(err, &modelErr) {
+			log.Info("Invalid subject", "err", err)
+			return models.Mission{}, err
+		}
+		log.Error("Failed to link subject", "err", err)
+		return models.Mission{}, errors.New("failed to add target")
 	}
 
 	// Create new target.
-	_, err = s.targetStorage.CreateTarget(ctx, postgres.CreateTargetParams{
-		Mission: missionId,
-		Name:    req.Name,
-		Country: req.Country,
-		Notes:   req.Notes,
+	created, err := withTx.CreateTarget(ctx, postgres.CreateTargetParams{
+		Mission:   missionId,
+		Name:      req.Name,
+		Country:   req.Country,
+		Notes:     req.Notes,
+		Latitude:  ptrToFloat8(req.Latitude),
+		Longitude: ptrToFloat8(req.Longitude),
+		Address:   req.Address,
+		Subject:   subject,
 	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -70,7 +119,7 @@ func (s Service) AddTarget(ctx context.Context, missionId int32, req models.Crea
 	}
 
 	// Get updated targets.
-	targets, err = s.targetStorage.GetMissionTargets(ctx, missionId)
+	targets, err = withTx.GetMissionTargets(ctx, missionId)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return models.Mission{}, models.ErrTimeoutExceeded
@@ -82,7 +131,11 @@ func (s Service) AddTarget(ctx context.Context, missionId int32, req models.Crea
 	// Populate mission with new targets.
 	mission := sqlcMissionToModel(m)
 	for _, t := range targets {
-		mission.Targets = append(mission.Targets, sqlcTargetToModel(t))
+		target := sqlcTargetToModel(t)
+		if t.ID == created.ID {
+			target.Suggestions = suggestions
+		}
+		mission.Targets = append(mission.Targets, target)
 	}
 
 	log.Info("Added target")
@@ -101,6 +154,21 @@ func (s Service) DeleteTarget(ctx context.Context, targetId int32) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
+	// The rows of the attachments are deleted with the target, their content
+	// after it.
+	var attachments []postgres.Attachment
+	if s.attachmentStorage != nil {
+		var err error
+		attachments, err = s.attachmentStorage.GetTargetAttachments(ctx, targetId)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.ErrTimeoutExceeded
+			}
+			log.Error("Failed to get attachments", "err", err)
+			return errors.New("failed to delete target")
+		}
+	}
+
 	rows, err := s.targetStorage.DeleteTarget(ctx, targetId)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -114,6 +182,8 @@ func (s Service) DeleteTarget(ctx context.Context, targetId int32) error {
 		return models.ErrNotFound
 	}
 
+	s.deleteBlobs(log, blobKeys(attachments)...)
+
 	log.Debug("Target deleted")
 
 	return nil
@@ -136,6 +206,10 @@ func (s Service) UpdateTargetNotes(ctx context.Context, targetId int32, notes st
 		log.Debug("Notes are empty")
 		return models.Target{}, models.NewError(http.StatusUnprocessableEntity, "notes can't be empty")
 	}
+	if err := checkNotesLength(s.currentRules(), notes); err != nil {
+		log.Debug("Notes are too long")
+		return models.Target{}, err
+	}
 
 	// Get target.
 	target, err := s.targetStorage.GetTarget(ctx, targetId)
@@ -172,7 +246,24 @@ func (s Service) UpdateTargetNotes(ctx context.Context, targetId int32, notes st
 		log.Debug("Can't change target notes of a completed mission")
 		return models.Target{}, models.NewError(http.StatusUnprocessableEntity, "Can't change target notes of a completed mission")
 	}
-	res, err := s.targetStorage.UpdateTargetNotes(ctx, postgres.UpdateTargetNotesParams{
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Target{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	res, err := withTx.UpdateTargetNotes(ctx, postgres.UpdateTargetNotesParams{
 		ID:    targetId,
 		Notes: notes,
 	})
@@ -184,7 +275,34 @@ func (s Service) UpdateTargetNotes(ctx context.Context, targetId int32, notes st
 		return models.Target{}, errors.New("failed to delete target")
 	}
 
-	return sqlcTargetToModel(res), nil
+	// Record the change in the history of the notes of the subject.
+	if s.subjectStorage != nil {
+		_, err = withTx.CreateTargetNote(ctx, postgres.CreateTargetNoteParams{
+			Target:        targetId,
+			PreviousNotes: target.Notes,
+			Notes:         notes,
+		})
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.Target{}, models.ErrTimeoutExceeded
+			}
+			slog.Error("Failed to record notes change", "err", err)
+			return models.Target{}, errors.New("failed to update target")
+		}
+	}
+
+	// Record the event within the same transaction.
+	updated := sqlcTargetToModel(res)
+	err = recordEvent(ctx, withTx, events.TargetNotesUpdated, res.Mission, updated)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Target{}, models.ErrTimeoutExceeded
+		}
+		slog.Error("Failed to record event", "err", err)
+		return models.Target{}, errors.New("failed to update target")
+	}
+
+	return updated, nil
 }
 
 func (s Service) CompleteTarget(ctx context.Context, targetId int32) (models.Target, error) {
@@ -212,8 +330,24 @@ func (s Service) CompleteTarget(ctx context.Context, targetId int32) (models.Tar
 		return models.Target{}, errors.New("failed to complete target")
 	}
 
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Target{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
 	// Set target as completed.
-	target, err := s.targetStorage.CompleteTarget(ctx, targetId)
+	target, err := withTx.CompleteTarget(ctx, targetId)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return models.Target{}, models.ErrTimeoutExceeded
@@ -222,5 +356,213 @@ func (s Service) CompleteTarget(ctx context.Context, targetId int32) (models.Tar
 		return models.Target{}, errors.New("failed to complete target")
 	}
 
-	return sqlcTargetToModel(target), nil
+	// Record the event within the same transaction.
+	completed := sqlcTargetToModel(target)
+	err = recordEvent(ctx, withTx, events.TargetCompleted, target.Mission, completed)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Target{}, models.ErrTimeoutExceeded
+		}
+		slog.Error("Failed to record event", "err", err)
+		return models.Target{}, errors.New("failed to complete target")
+	}
+
+	return completed, nil
+}
+
+// MoveTarget moves the target of the mission into another mission and records
+// the move in the audit log, in a single transaction.
+//
+// Both missions must be incomplete, the destination must have room for the
+// target, and the source must keep at least one target.
+func (s Service) MoveTarget(ctx context.Context, missionId, targetId int32, req models.MoveTargetRequest) (models.Mission, error) {
+	log := slog.With(
+		slog.String("op", "service.MoveTarget"),
+		slog.Any("missionId", missionId),
+		slog.Any("targetId", targetId),
+		slog.Any("destination", req.Mission),
+	)
+
+	log.Debug("Moving target")
+
+	r := s.currentRules()
+	if req.Mission == missionId {
+		log.Info("Target is already in the mission")
+		return models.Mission{}, models.NewError(http.StatusUnprocessableEntity, "target is already in the mission")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tx, err := s.txStorage.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "err", err)
+		return models.Mission{}, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		} else {
+			_ = tx.Commit(ctx)
+		}
+	}()
+
+	withTx := s.txStorage.WithTx(tx)
+
+	// Lock both missions before counting their targets, in ascending order
+	// of ID so concurrent moves between them don't deadlock.
+	var source, destination postgres.Mission
+	for _, id := range []int32{min(missionId, req.Mission), max(missionId, req.Mission)} {
+		var m postgres.Mission
+		m, err = withTx.GetMissionForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return models.Mission{}, models.ErrTimeoutExceeded
+			}
+			if errors.Is(err, pgx.ErrNoRows) && id == req.Mission {
+				log.Debug("Destination mission not found")
+				return models.Mission{}, models.NewError(http.StatusNotFound, "destination mission not found")
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				log.Debug("Mission not found")
+				return models.Mission{}, models.ErrNotFound
+			}
+			log.Error("Failed to get mission", "err", err)
+			return models.Mission{}, errors.New("failed to move target")
+		}
+		if id == missionId {
+			source = m
+		} else {
+			destination = m
+		}
+	}
+
+	// Get target, it must belong to the mission.
+	target, err := withTx.GetTarget(ctx, targetId)
+	if err == nil && target.Mission != missionId {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Target not found")
+			return models.Mission{}, models.ErrNotFound
+		}
+		log.Error("Failed to get target", "err", err)
+		return models.Mission{}, errors.New("failed to move target")
+	}
+
+	// Can't move targets of or into a completed mission.
+	if source.Completed || destination.Completed {
+		log.Info("Mission is completed")
+		err = models.NewError(http.StatusUnprocessableEntity, "can't move targets of a completed mission")
+		return models.Mission{}, err
+	}
+
+	sourceTargets, err := withTx.GetMissionTargets(ctx, missionId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get targets", "err", err)
+		return models.Mission{}, errors.New("failed to move target")
+	}
+	if len(sourceTargets)-1 < r.Targets.Min {
+		log.Info("Mission has the minimum of targets")
+		err = models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("mission must keep at least %d of its targets", r.Targets.Min))
+		return models.Mission{}, err
+	}
+
+	destinationTargets, err := withTx.GetMissionTargets(ctx, req.Mission)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get destination targets", "err", err)
+		return models.Mission{}, errors.New("failed to move target")
+	}
+	if len(destinationTargets) >= r.Targets.Max {
+		log.Info("Destination mission already has the maximum of targets")
+		err = models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("mission has maximum targets (%d)", r.Targets.Max))
+		return models.Mission{}, err
+	}
+
+	moved, err := withTx.MoveTarget(ctx, postgres.MoveTargetParams{
+		ID:      targetId,
+		Mission: req.Mission,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to move target", "err", err)
+		return models.Mission{}, errors.New("failed to move target")
+	}
+
+	// Record the move within the same transaction.
+	_, err = withTx.CreateTargetMove(ctx, postgres.CreateTargetMoveParams{
+		Target:      targetId,
+		FromMission: missionId,
+		ToMission:   req.Mission,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Mission{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to record target move", "err", err)
+		return models.Mission{}, errors.New("failed to move target")
+	}
+
+	log.Debug("Moved target")
+
+	mission := sqlcMissionToModel(destination)
+	for _, t := range append(destinationTargets, moved) {
+		mission.Targets = append(mission.Targets, sqlcTargetToModel(t))
+	}
+
+	return mission, nil
+}
+
+// GetTargetMoves returns the moves of the target, oldest first.
+//
+// The moves are kept after the target is deleted.
+func (s Service) GetTargetMoves(ctx context.Context, targetId int32) ([]models.TargetMove, error) {
+	log := slog.With(
+		slog.String("op", "service.GetTargetMoves"),
+		slog.Any("targetId", targetId),
+	)
+
+	log.Debug("Fetching target moves")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.targetStorage.GetTargetMoves(ctx, targetId)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.TargetMove, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get target moves", "err", err)
+		return make([]models.TargetMove, 0), errors.New("failed to get target moves")
+	}
+
+	moves := make([]models.TargetMove, len(res))
+	for i, m := range res {
+		moves[i] = sqlcTargetMoveToModel(m)
+	}
+
+	return moves, nil
+}
+
+func sqlcTargetMoveToModel(m postgres.TargetMove) models.TargetMove {
+	return models.TargetMove{
+		ID:          m.ID,
+		Target:      m.Target,
+		FromMission: m.FromMission,
+		ToMission:   m.ToMission,
+		MovedAt:     m.MovedAt.Time,
+	}
 }
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/rules"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// placeholderRe matches a {{variable}} placeholder of a target blueprint.
+var placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+func (s Service) GetAllMissionTemplates(ctx context.Context) ([]models.MissionTemplate, error) {
+	log := slog.With(
+		slog.String("op", "service.GetAllMissionTemplates"),
+	)
+
+	log.Debug("Fetching all mission templates")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.templateStorage.GetAllMissionTemplates(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.MissionTemplate, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get mission templates", "err", err)
+		return make([]models.MissionTemplate, 0), errors.New("failed to get mission templates")
+	}
+
+	templates := make([]models.MissionTemplate, len(res))
+	for i, t := range res {
+		templates[i], err = sqlcMissionTemplateToModel(t)
+		if err != nil {
+			log.Error("Failed to decode mission template", "id", t.ID, "err", err)
+			return make([]models.MissionTemplate, 0), errors.New("failed to get mission templates")
+		}
+	}
+
+	return templates, nil
+}
+
+func (s Service) CreateMissionTemplate(ctx context.Context, req models.CreateMissionTemplateRequest) (models.MissionTemplate, error) {
+	log := slog.With(
+		slog.String("op", "service.CreateMissionTemplate"),
+		slog.Any("req", req),
+	)
+
+	log.Debug("Creating mission template")
+
+	if err := validateMissionTemplate(s.currentRules(), req.Name, req.Targets); err != nil {
+		log.Info("Invalid mission template")
+		return models.MissionTemplate{}, err
+	}
+
+	targets, err := json.Marshal(req.Targets)
+	if err != nil {
+		log.Error("Failed to encode targets", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to create mission template")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.templateStorage.CreateMissionTemplate(ctx, postgres.CreateMissionTemplateParams{
+		Name:    req.Name,
+		Targets: targets,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.MissionTemplate{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to create mission template", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to create mission template")
+	}
+
+	log.Debug("Created mission template", "id", res.ID)
+
+	template, err := sqlcMissionTemplateToModel(res)
+	if err != nil {
+		log.Error("Failed to decode mission template", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to create mission template")
+	}
+
+	return template, nil
+}
+
+func (s Service) GetMissionTemplate(ctx context.Context, id int32) (models.MissionTemplate, error) {
+	log := slog.With(
+		slog.String("op", "service.GetMissionTemplate"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Fetching mission template")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.templateStorage.GetMissionTemplate(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.MissionTemplate{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Mission template not found")
+			return models.MissionTemplate{}, models.ErrNotFound
+		}
+		log.Error("Failed to get mission template", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to get mission template")
+	}
+
+	template, err := sqlcMissionTemplateToModel(res)
+	if err != nil {
+		log.Error("Failed to decode mission template", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to get mission template")
+	}
+
+	return template, nil
+}
+
+func (s Service) UpdateMissionTemplate(ctx context.Context, id int32, req models.UpdateMissionTemplateRequest) (models.MissionTemplate, error) {
+	log := slog.With(
+		slog.String("op", "service.UpdateMissionTemplate"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Updating mission template")
+
+	current, err := s.GetMissionTemplate(ctx, id)
+	if err != nil {
+		return models.MissionTemplate{}, err
+	}
+
+	// Only change the provided fields.
+	name, blueprints := current.Name, current.Targets
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Targets != nil {
+		blueprints = req.Targets
+	}
+
+	if err := validateMissionTemplate(s.currentRules(), name, blueprints); err != nil {
+		log.Info("Invalid mission template")
+		return models.MissionTemplate{}, err
+	}
+
+	targets, err := json.Marshal(blueprints)
+	if err != nil {
+		log.Error("Failed to encode targets", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to update mission template")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.templateStorage.UpdateMissionTemplate(ctx, postgres.UpdateMissionTemplateParams{
+		ID:      id,
+		Name:    name,
+		Targets: targets,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.MissionTemplate{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.MissionTemplate{}, models.ErrNotFound
+		}
+		log.Error("Failed to update mission template", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to update mission template")
+	}
+
+	log.Debug("Updated mission template")
+
+	template, err := sqlcMissionTemplateToModel(res)
+	if err != nil {
+		log.Error("Failed to decode mission template", "err", err)
+		return models.MissionTemplate{}, errors.New("failed to update mission template")
+	}
+
+	return template, nil
+}
+
+func (s Service) DeleteMissionTemplate(ctx context.Context, id int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteMissionTemplate"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Deleting mission template")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.templateStorage.DeleteMissionTemplate(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete mission template", "err", err)
+		return errors.New("failed to delete mission template")
+	}
+	if rows == 0 {
+		log.Debug("Mission template not found")
+		return models.ErrNotFound
+	}
+
+	log.Debug("Deleted mission template")
+
+	return nil
+}
+
+// CreateMissionFromTemplate creates a mission from the template, substituting
+// the placeholders of the targets with the variables.
+//
+// The mission is validated like the one of CreateMission.
+func (s Service) CreateMissionFromTemplate(ctx context.Context, id int32, req models.CreateMissionFromTemplateRequest) (models.Mission, error) {
+	log := slog.With(
+		slog.String("op", "service.CreateMissionFromTemplate"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Creating mission from template")
+
+	template, err := s.GetMissionTemplate(ctx, id)
+	if err != nil {
+		return models.Mission{}, err
+	}
+
+	mission, err := instantiateMissionTemplate(template, req.Variables)
+	if err != nil {
+		log.Info("Invalid variables")
+		return models.Mission{}, err
+	}
+
+	created, err := s.CreateMission(ctx, mission)
+	if err != nil {
+		return models.Mission{}, err
+	}
+
+	log.Debug("Created mission from template", "mission", created.ID)
+
+	return s.GetMission(ctx, created.ID)
+}
+
+// instantiateMissionTemplate substitutes the placeholders of the template.
+//
+// All the variables of the template must be given, and only them.
+func instantiateMissionTemplate(template models.MissionTemplate, variables map[string]string) (models.CreateMissionRequest, error) {
+	var missing, unknown []string
+	for _, v := range template.Variables {
+		if _, ok := variables[v]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	for v := range variables {
+		if !slices.Contains(template.Variables, v) {
+			unknown = append(unknown, v)
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(missing) > 0 {
+		return models.CreateMissionRequest{}, models.NewError(http.StatusUnprocessableEntity, "missing variables: "+strings.Join(missing, ", "))
+	}
+	if len(unknown) > 0 {
+		return models.CreateMissionRequest{}, models.NewError(http.StatusUnprocessableEntity, "unknown variables: "+strings.Join(unknown, ", "))
+	}
+
+	substitute := func(s string) string {
+		return placeholderRe.ReplaceAllStringFunc(s, func(p string) string {
+			return variables[placeholderRe.FindStringSubmatch(p)[1]]
+		})
+	}
+
+	req := models.CreateMissionRequest{Targets: make([]models.CreateTargetRequest, len(template.Targets))}
+	for i, t := range template.Targets {
+		target := models.CreateTargetRequest{
+			Name:    substitute(t.Name),
+			Country: substitute(t.Country),
+			Notes:   substitute(t.Notes),
+		}
+
+		// The fields are required, like in a request to create a mission.
+		for _, f := range []struct{ name, value string }{{"name", target.Name}, {"country", target.Country}, {"notes", target.Notes}} {
+			if strings.TrimSpace(f.value) == "" {
+				return models.CreateMissionRequest{}, models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("target %d: %s can't be empty", i+1, f.name))
+			}
+		}
+
+		req.Targets[i] = target
+	}
+
+	return req, nil
+}
+
+// validateMissionTemplate checks the name and the target blueprints of a template.
+func validateMissionTemplate(r rules.Rules, name string, targets []models.TargetBlueprint) error {
+	if name == "" || utf8.RuneCountInString(name) > 128 {
+		return models.NewError(http.StatusUnprocessableEntity, "name must be between 1 and 128 characters long")
+	}
+
+	if err := checkTargetCount(r, len(targets)); err != nil {
+		return err
+	}
+
+	for i, t := range targets {
+		for _, f := range []struct{ name, value string }{{"name", t.Name}, {"country", t.Country}, {"notes", t.Notes}} {
+			if f.value == "" {
+				return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("target %d: missing field: %s", i+1, f.name))
+			}
+
+			// Braces left after removing the placeholders are malformed placeholders.
+			if strings.Contains(placeholderRe.ReplaceAllString(f.value, ""), "{{") {
+				return models.NewError(http.StatusUnprocessableEntity, fmt.Sprintf("target %d: invalid placeholder in %s", i+1, f.name))
+			}
+		}
+	}
+
+	return nil
+}
+
+// templateVariables returns the variables of the placeholders in order of appearance.
+func templateVariables(targets []models.TargetBlueprint) []string {
+	variables := make([]string, 0)
+	for _, t := range targets {
+		for _, s := range []string{t.Name, t.Country, t.Notes} {
+			for _, m := range placeholderRe.FindAllStringSubmatch(s, -1) {
+				if !slices.Contains(variables, m[1]) {
+					variables = append(variables, m[1])
+				}
+			}
+		}
+	}
+
+	return variables
+}
+
+func sqlcMissionTemplateToModel(t postgres.MissionTemplate) (models.MissionTemplate, error) {
+	var targets []models.TargetBlueprint
+	if err := json.Unmarshal(t.Targets, &targets); err != nil {
+		return models.MissionTemplate{}, err
+	}
+
+	return models.MissionTemplate{
+		ID:        t.ID,
+		Name:      t.Name,
+		Targets:   targets,
+		Variables: templateVariables(targets),
+		CreatedAt: t.CreatedAt.Time,
+	}, nil
+}
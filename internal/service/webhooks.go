@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/events"
+	"github.com/rsmanito/developstoday-test-assessment/internal/models"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// webhookEventTypes are the event types webhooks can subscribe to.
+var webhookEventTypes = map[string]bool{
+	events.CatAssigned:      true,
+	events.MissionCompleted: true,
+}
+
+// maxWebhookDeliveries is the number of most recent deliveries returned per webhook.
+const maxWebhookDeliveries = 100
+
+// webhookPayload is the body sent to webhook subscribers.
+type webhookPayload struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+func (s Service) GetAllWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	log := slog.With(
+		slog.String("op", "service.GetAllWebhooks"),
+	)
+
+	log.Debug("Fetching all webhooks")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.webhookStorage.GetAllWebhooks(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.Webhook, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get webhooks", "err", err)
+		return make([]models.Webhook, 0), errors.New("failed to get webhooks")
+	}
+
+	webhooks := make([]models.Webhook, len(res))
+	for i, w := range res {
+		webhooks[i] = sqlcWebhookToModel(w)
+	}
+
+	return webhooks, nil
+}
+
+func (s Service) CreateWebhook(ctx context.Context, req models.CreateWebhookRequest) (models.Webhook, error) {
+	log := slog.With(
+		slog.String("op", "service.CreateWebhook"),
+		slog.String("url", req.URL),
+		slog.Any("eventTypes", req.EventTypes),
+	)
+
+	log.Debug("Creating webhook")
+
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		log.Info("Invalid event types")
+		return models.Webhook{}, err
+	}
+
+	if len(req.Secret) > 128 {
+		log.Info("Secret is too long")
+		return models.Webhook{}, models.NewError(http.StatusUnprocessableEntity, "secret must be at most 128 characters long")
+	}
+
+	// Generate a secret if the subscriber didn't provide one.
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			log.Error("Failed to generate secret", "err", err)
+			return models.Webhook{}, errors.New("failed to create webhook")
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.webhookStorage.CreateWebhook(ctx, postgres.CreateWebhookParams{
+		Url:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Webhook{}, models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to create webhook", "err", err)
+		return models.Webhook{}, errors.New("failed to create webhook")
+	}
+
+	log.Debug("Created webhook", "id", res.ID)
+
+	// The secret is only revealed once, on creation.
+	webhook := sqlcWebhookToModel(res)
+	webhook.Secret = res.Secret
+
+	return webhook, nil
+}
+
+func (s Service) GetWebhook(ctx context.Context, id int32) (models.Webhook, error) {
+	log := slog.With(
+		slog.String("op", "service.GetWebhook"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Fetching webhook")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.webhookStorage.GetWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Webhook{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Webhook not found")
+			return models.Webhook{}, models.ErrNotFound
+		}
+		log.Error("Failed to get webhook", "err", err)
+		return models.Webhook{}, errors.New("failed to get webhook")
+	}
+
+	return sqlcWebhookToModel(res), nil
+}
+
+func (s Service) UpdateWebhook(ctx context.Context, id int32, req models.UpdateWebhookRequest) (models.Webhook, error) {
+	log := slog.With(
+		slog.String("op", "service.UpdateWebhook"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Updating webhook")
+
+	if req.EventTypes != nil {
+		if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+			log.Info("Invalid event types")
+			return models.Webhook{}, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	current, err := s.webhookStorage.GetWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Webhook{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Webhook not found")
+			return models.Webhook{}, models.ErrNotFound
+		}
+		log.Error("Failed to get webhook", "err", err)
+		return models.Webhook{}, errors.New("failed to update webhook")
+	}
+
+	// Only change the provided fields.
+	params := postgres.UpdateWebhookParams{
+		ID:         id,
+		Url:        current.Url,
+		EventTypes: current.EventTypes,
+		Active:     current.Active,
+	}
+	if req.URL != nil {
+		params.Url = *req.URL
+	}
+	if req.EventTypes != nil {
+		params.EventTypes = req.EventTypes
+	}
+	if req.Active != nil {
+		params.Active = *req.Active
+	}
+
+	res, err := s.webhookStorage.UpdateWebhook(ctx, params)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Webhook{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Webhook{}, models.ErrNotFound
+		}
+		log.Error("Failed to update webhook", "err", err)
+		return models.Webhook{}, errors.New("failed to update webhook")
+	}
+
+	log.Debug("Updated webhook")
+
+	return sqlcWebhookToModel(res), nil
+}
+
+func (s Service) DeleteWebhook(ctx context.Context, id int32) error {
+	log := slog.With(
+		slog.String("op", "service.DeleteWebhook"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Deleting webhook")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := s.webhookStorage.DeleteWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to delete webhook", "err", err)
+		return errors.New("failed to delete webhook")
+	}
+	if rows == 0 {
+		log.Debug("Webhook not found")
+		return models.ErrNotFound
+	}
+
+	log.Debug("Deleted webhook")
+
+	return nil
+}
+
+func (s Service) GetWebhookDeliveries(ctx context.Context, id int32) ([]models.WebhookDelivery, error) {
+	log := slog.With(
+		slog.String("op", "service.GetWebhookDeliveries"),
+		slog.Any("id", id),
+	)
+
+	log.Debug("Fetching webhook deliveries")
+
+	// Make sure the webhook exists.
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return make([]models.WebhookDelivery, 0), err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.webhookStorage.GetWebhookDeliveries(ctx, postgres.GetWebhookDeliveriesParams{
+		Webhook: id,
+		Limit:   maxWebhookDeliveries,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return make([]models.WebhookDelivery, 0), models.ErrTimeoutExceeded
+		}
+		log.Error("Failed to get webhook deliveries", "err", err)
+		return make([]models.WebhookDelivery, 0), errors.New("failed to get webhook deliveries")
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(res))
+	for i, d := range res {
+		deliveries[i] = sqlcWebhookDeliveryToModel(d)
+	}
+
+	return deliveries, nil
+}
+
+func (s Service) RedeliverWebhookDelivery(ctx context.Context, id, deliveryID int32) (models.WebhookDelivery, error) {
+	log := slog.With(
+		slog.String("op", "service.RedeliverWebhookDelivery"),
+		slog.Any("id", id),
+		slog.Any("deliveryId", deliveryID),
+	)
+
+	log.Debug("Scheduling redelivery")
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := s.webhookStorage.RedeliverWebhookDelivery(ctx, postgres.RedeliverWebhookDeliveryParams{
+		ID:      deliveryID,
+		Webhook: id,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.WebhookDelivery{}, models.ErrTimeoutExceeded
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug("Delivery not found")
+			return models.WebhookDelivery{}, models.ErrNotFound
+		}
+		log.Error("Failed to schedule redelivery", "err", err)
+		return models.WebhookDelivery{}, errors.New("failed to redeliver")
+	}
+
+	log.Debug("Scheduled redelivery")
+
+	return sqlcWebhookDeliveryToModel(res), nil
+}
+
+// enqueueWebhookDeliveries queues a delivery of the event for every active subscriber.
+//
+// Must be called with the transaction of the change that triggered the event.
+func enqueueWebhookDeliveries(ctx context.Context, withTx postgres.Querier, eventType string, data any) error {
+	payload, err := json.Marshal(webhookPayload{
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = withTx.EnqueueWebhookDeliveries(ctx, postgres.EnqueueWebhookDeliveriesParams{
+		EventType: eventType,
+		Payload:   payload,
+	})
+	return err
+}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return models.NewError(http.StatusUnprocessableEntity, "at least one event type is required")
+	}
+	for _, t := range eventTypes {
+		if !webhookEventTypes[t] {
+			return models.NewError(http.StatusUnprocessableEntity, "unknown event type: "+t)
+		}
+	}
+	return nil
+}
+
+func sqlcWebhookToModel(w postgres.Webhook) models.Webhook {
+	return models.Webhook{
+		ID:         w.ID,
+		URL:        w.Url,
+		EventTypes: w.EventTypes,
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt.Time,
+	}
+}
+
+func sqlcWebhookDeliveryToModel(d postgres.WebhookDelivery) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:               d.ID,
+		Webhook:          d.Webhook,
+		EventType:        d.EventType,
+		Payload:          d.Payload,
+		Status:           d.Status,
+		Attempts:         d.Attempts,
+		NextAttemptAt:    d.NextAttemptAt.Time,
+		LastError:        d.LastError,
+		LastResponseCode: int4ToPtr(d.LastResponseCode),
+		CreatedAt:        d.CreatedAt.Time,
+		DeliveredAt:      timestamptzToPtr(d.DeliveredAt),
+	}
+}
+
+func int4ToPtr(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func timestamptzToPtr(v pgtype.Timestamptz) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
@@ -0,0 +1,285 @@
+// Package snapshot reads and writes the archives of the database snapshots.
+//
+// An archive is NDJSON: a record per line, tagged with its kind. The first
+// record is the metadata, then come the cats, the subjects, the missions and
+// the targets, in this order so they can be restored in a single pass, and the
+// last record is the end, which counts the entities. A truncated archive has
+// no end.
+//
+//	{"kind":"metadata","data":{"format_version":1,"schema_version":20250315120000,"created_at":"..."}}
+//	{"kind":"cat","data":{"id":1,"name":"Tom",...}}
+//	{"kind":"end","data":{"cats":1,"subjects":0,"missions":0,"targets":0}}
+package snapshot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FormatVersion is the version of the archive format.
+const FormatVersion = 1
+
+// Kinds of the records.
+const (
+	KindMetadata = "metadata"
+	KindCat      = "cat"
+	KindSubject  = "subject"
+	KindMission  = "mission"
+	KindTarget   = "target"
+	KindEnd      = "end"
+)
+
+// maxLineLength is the maximum length of a record.
+const maxLineLength = 1 << 20
+
+type Metadata struct {
+	FormatVersion int `json:"format_version"`
+	// SchemaVersion is the version of the last migration of the database.
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type Cat struct {
+	ID                int32  `json:"id"`
+	Name              string `json:"name"`
+	Breed             string `json:"breed"`
+	YearsOfExperience int32  `json:"years_of_experience"`
+	// Salary is in the minor units of its currency, like the database.
+	Salary         int64  `json:"salary"`
+	SalaryCurrency string `json:"salary_currency"`
+}
+
+type Subject struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	Country   string    `json:"country"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Mission struct {
+	ID          int32      `json:"id"`
+	Assignee    *int32     `json:"assignee"`
+	Completed   bool       `json:"completed"`
+	CreatedAt   time.Time  `json:"created_at"`
+	AssignedAt  *time.Time `json:"assigned_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type Target struct {
+	ID          int32      `json:"id"`
+	Mission     int32      `json:"mission"`
+	Name        string     `json:"name"`
+	Country     string     `json:"country"`
+	Notes       string     `json:"notes"`
+	Completed   bool       `json:"completed"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Latitude    *float64   `json:"latitude"`
+	Longitude   *float64   `json:"longitude"`
+	Address     string     `json:"address"`
+	Subject     *int32     `json:"subject"`
+}
+
+// End counts the entities of the archive.
+type End struct {
+	Cats     int `json:"cats"`
+	Subjects int `json:"subjects"`
+	Missions int `json:"missions"`
+	Targets  int `json:"targets"`
+}
+
+type record struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Writer writes an archive.
+type Writer struct {
+	enc   *json.Encoder
+	count End
+}
+
+// NewWriter writes the metadata and returns a Writer of the entities.
+func NewWriter(w io.Writer, md Metadata) (*Writer, error) {
+	sw := &Writer{enc: json.NewEncoder(w)}
+	if err := sw.write(KindMetadata, md); err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+func (w *Writer) WriteCat(c Cat) error {
+	w.count.Cats++
+	return w.write(KindCat, c)
+}
+
+func (w *Writer) WriteSubject(s Subject) error {
+	w.count.Subjects++
+	return w.write(KindSubject, s)
+}
+
+func (w *Writer) WriteMission(m Mission) error {
+	w.count.Missions++
+	return w.write(KindMission, m)
+}
+
+func (w *Writer) WriteTarget(t Target) error {
+	w.count.Targets++
+	return w.write(KindTarget, t)
+}
+
+// Close writes the end of the archive.
+func (w *Writer) Close() error {
+	return w.write(KindEnd, w.count)
+}
+
+func (w *Writer) write(kind string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return w.enc.Encode(record{Kind: kind, Data: data})
+}
+
+// Reader reads an archive.
+type Reader struct {
+	sc    *bufio.Scanner
+	line  int
+	kind  int
+	count End
+	end   bool
+}
+
+// Entities of a kind must follow the ones of the previous kinds.
+var order = map[string]int{KindCat: 1, KindSubject: 2, KindMission: 3, KindTarget: 4}
+
+// NewReader reads the metadata and returns a Reader of the entities.
+func NewReader(r io.Reader) (*Reader, Metadata, error) {
+	sr := &Reader{sc: bufio.NewScanner(r)}
+	sr.sc.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+
+	rec, err := sr.next()
+	if err == io.EOF {
+		return nil, Metadata{}, errors.New("empty archive")
+	}
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	if rec.Kind != KindMetadata {
+		return nil, Metadata{}, sr.errorf("expected metadata, got %q", rec.Kind)
+	}
+
+	var md Metadata
+	if err := sr.decode(rec, &md); err != nil {
+		return nil, Metadata{}, err
+	}
+	if md.FormatVersion != FormatVersion {
+		return nil, Metadata{}, fmt.Errorf("unsupported format version %d, expected %d", md.FormatVersion, FormatVersion)
+	}
+
+	return sr, md, nil
+}
+
+// Next returns the next entity: a Cat, a Subject, a Mission or a Target.
+//
+// It returns io.EOF after the end of the archive, and an error if the
+// archive is truncated or the counts of the end don't match.
+func (r *Reader) Next() (any, error) {
+	rec, err := r.next()
+	if err == io.EOF {
+		if !r.end {
+			return nil, errors.New("truncated archive, no end record")
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.end {
+		return nil, r.errorf("record after the end")
+	}
+
+	if rec.Kind == KindEnd {
+		var end End
+		if err := r.decode(rec, &end); err != nil {
+			return nil, err
+		}
+		if end != r.count {
+			return nil, r.errorf("the end counts %+v, read %+v", end, r.count)
+		}
+		r.end = true
+
+		return r.Next()
+	}
+
+	kind, ok := order[rec.Kind]
+	if !ok {
+		return nil, r.errorf("unknown kind %q", rec.Kind)
+	}
+	if kind < r.kind {
+		return nil, r.errorf("%s out of order", rec.Kind)
+	}
+	r.kind = kind
+
+	switch rec.Kind {
+	case KindCat:
+		var c Cat
+		r.count.Cats++
+		return c, r.decode(rec, &c)
+	case KindSubject:
+		var s Subject
+		r.count.Subjects++
+		return s, r.decode(rec, &s)
+	case KindMission:
+		var m Mission
+		r.count.Missions++
+		return m, r.decode(rec, &m)
+	default:
+		var t Target
+		r.count.Targets++
+		return t, r.decode(rec, &t)
+	}
+}
+
+// Line returns the line of the last record read.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+func (r *Reader) next() (record, error) {
+	for r.sc.Scan() {
+		r.line++
+		if len(r.sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(r.sc.Bytes(), &rec); err != nil {
+			return record{}, r.errorf("invalid record")
+		}
+
+		return rec, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return record{}, err
+	}
+
+	return record{}, io.EOF
+}
+
+func (r *Reader) decode(rec record, v any) error {
+	if err := json.Unmarshal(rec.Data, v); err != nil {
+		return r.errorf("invalid %s", rec.Kind)
+	}
+	return nil
+}
+
+func (r *Reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
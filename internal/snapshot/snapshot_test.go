@@ -0,0 +1,85 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var metadata = Metadata{FormatVersion: FormatVersion, SchemaVersion: 20250315120000, CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, metadata)
+	require.NoError(t, err)
+
+	assignee := int32(1)
+	require.NoError(t, w.WriteCat(Cat{ID: 1, Name: "Tom", Breed: "Abyssinian", YearsOfExperience: 3, Salary: 100}))
+	subject := int32(4)
+	require.NoError(t, w.WriteSubject(Subject{ID: 4, Name: "Ivan", Country: "UA"}))
+	require.NoError(t, w.WriteMission(Mission{ID: 2, Assignee: &assignee}))
+	require.NoError(t, w.WriteMission(Mission{ID: 5, Completed: true}))
+	require.NoError(t, w.WriteTarget(Target{ID: 3, Mission: 2, Name: "Ivan", Country: "UA", Notes: "Notes of Ivan", Subject: &subject}))
+	require.NoError(t, w.Close())
+
+	r, md, err := NewReader(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, md)
+
+	var got []any
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, e)
+	}
+
+	assert.Equal(t, []any{
+		Cat{ID: 1, Name: "Tom", Breed: "Abyssinian", YearsOfExperience: 3, Salary: 100},
+		Subject{ID: 4, Name: "Ivan", Country: "UA"},
+		Mission{ID: 2, Assignee: &assignee},
+		Mission{ID: 5, Completed: true},
+		Target{ID: 3, Mission: 2, Name: "Ivan", Country: "UA", Notes: "Notes of Ivan", Subject: &subject},
+	}, got)
+}
+
+func TestReader_Errors(t *testing.T) {
+	const md = `{"kind":"metadata","data":{"format_version":1,"schema_version":1}}` + "\n"
+	const cat = `{"kind":"cat","data":{"id":1}}` + "\n"
+	const mission = `{"kind":"mission","data":{"id":1}}` + "\n"
+	const subject = `{"kind":"subject","data":{"id":1}}` + "\n"
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "empty", data: "", wantErr: "empty archive"},
+		{name: "no metadata", data: cat, wantErr: `line 1: expected metadata, got "cat"`},
+		{name: "format version", data: `{"kind":"metadata","data":{"format_version":2}}`, wantErr: "unsupported format version 2"},
+		{name: "malformed", data: md + "{", wantErr: "line 2: invalid record"},
+		{name: "unknown kind", data: md + `{"kind":"dog","data":{}}`, wantErr: `line 2: unknown kind "dog"`},
+		{name: "invalid entity", data: md + `{"kind":"cat","data":{"id":"one"}}`, wantErr: "line 2: invalid cat"},
+		{name: "out of order", data: md + mission + cat, wantErr: "line 3: cat out of order"},
+		{name: "subject after missions", data: md + mission + subject, wantErr: "line 3: subject out of order"},
+		{name: "truncated", data: md + cat, wantErr: "truncated archive"},
+		{name: "count mismatch", data: md + cat + `{"kind":"end","data":{"cats":2}}`, wantErr: "line 3: the end counts"},
+		{name: "after the end", data: md + `{"kind":"end","data":{}}` + "\n" + cat, wantErr: "line 3: record after the end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _, err := NewReader(strings.NewReader(tt.data))
+			for err == nil {
+				_, err = r.Next()
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
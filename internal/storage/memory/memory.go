@@ -0,0 +1,335 @@
+// Package memory implements the storage in memory.
+//
+// Committed data is an immutable snapshot. A transaction works on a copy of the
+// last snapshot, which replaces it on commit and is dropped on rollback, so
+// reads never see uncommitted changes. Writers are serialized: a transaction
+// holds the write lock until it ends, and a query outside of a transaction runs
+// in a transaction of its own.
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+type Storage struct {
+	// writer is held by the open transaction.
+	writer chan struct{}
+	db     atomic.Pointer[db]
+}
+
+var _ storage.Backend = (*Storage)(nil)
+
+// New returns an empty Storage.
+func New() *Storage {
+	s := &Storage{
+		writer: make(chan struct{}, 1),
+	}
+	s.db.Store(newDB())
+
+	return s
+}
+
+// Begin starts a transaction.
+//
+// It blocks until the open transaction ends or ctx is done.
+func (s *Storage) Begin(ctx context.Context) (storage.Tx, error) {
+	return s.begin(ctx)
+}
+
+// BeginTx starts a transaction with the options. Transactions see the
+// snapshot of their start, whatever the options.
+//
+// A read-only transaction doesn't wait for the open transaction, and its
+// changes are discarded.
+func (s *Storage) BeginTx(ctx context.Context, opts storage.TxOptions) (storage.Tx, error) {
+	if !opts.ReadOnly {
+		return s.begin(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &tx{
+		s:        s,
+		readOnly: true,
+		q: &queries{
+			db:  s.db.Load().clone(),
+			now: time.Now(),
+		},
+	}, nil
+}
+
+func (s *Storage) begin(ctx context.Context) (*tx, error) {
+	select {
+	case s.writer <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &tx{
+		s: s,
+		q: &queries{
+			db:  s.db.Load().clone(),
+			now: time.Now(),
+		},
+	}, nil
+}
+
+// WithTx returns the queries bound to the transaction.
+//
+// The transaction must have been started by the same Storage.
+func (s *Storage) WithTx(t storage.Tx) postgres.Querier {
+	return t.(*tx).q
+}
+
+// SchemaVersion is the version of the last migration of the other backends,
+// whose schema the memory backend mirrors. Bump it with the migrations.
+const SchemaVersion int64 = 20250326120000
+
+// SchemaVersion returns the version of the schema.
+func (s *Storage) SchemaVersion(ctx context.Context) (int64, error) {
+	return SchemaVersion, nil
+}
+
+// Close is a no-op, the data lives as long as the Storage.
+func (s *Storage) Close() {}
+
+type tx struct {
+	s *Storage
+	q *queries
+	// readOnly is set if the transaction doesn't hold the write lock.
+	readOnly bool
+	mu       sync.Mutex
+	done     bool
+}
+
+// Commit makes the changes of the transaction visible.
+func (t *tx) Commit(ctx context.Context) error {
+	return t.end(true)
+}
+
+// Rollback discards the changes of the transaction.
+func (t *tx) Rollback(ctx context.Context) error {
+	return t.end(false)
+}
+
+func (t *tx) end(commit bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return pgx.ErrTxClosed
+	}
+	t.done = true
+
+	if t.readOnly {
+		return nil
+	}
+	if commit {
+		t.s.db.Store(t.q.db)
+	}
+	<-t.s.writer
+
+	return nil
+}
+
+// view runs a read-only query against the last committed snapshot.
+func view[T any](ctx context.Context, s *Storage, fn func(q *queries) (T, error)) (T, error) {
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return fn(&queries{db: s.db.Load(), now: time.Now()})
+}
+
+// update runs a query in a transaction of its own.
+func update[T any](ctx context.Context, s *Storage, fn func(q *queries) (T, error)) (T, error) {
+	var zero T
+
+	t, err := s.begin(ctx)
+	if err != nil {
+		return zero, err
+	}
+
+	res, err := fn(t.q)
+	if err != nil {
+		_ = t.Rollback(ctx)
+		return zero, err
+	}
+
+	return res, t.Commit(ctx)
+}
+
+// db is a snapshot of all the tables.
+//
+// Rows are stored by value and their slices are never modified in place, so a
+// copy of the maps is enough to take a snapshot.
+type db struct {
+	cats       table[postgres.Cat]
+	missions   table[postgres.Mission]
+	targets    table[postgres.Target]
+	webhooks   table[postgres.Webhook]
+	deliveries table[postgres.WebhookDelivery]
+	outbox     table[postgres.Outbox]
+	templates  table[postgres.MissionTemplate]
+	moves      table[postgres.TargetMove]
+	periods    table[postgres.AvailabilityPeriod]
+	skills     table[postgres.Skill]
+	// catSkills and targetSkills are keyed by pairKey.
+	catSkills    table[postgres.CatSkill]
+	targetSkills table[postgres.TargetSkill]
+	// budgets are keyed by mission.
+	budgets  table[postgres.MissionBudget]
+	expenses table[postgres.MissionExpense]
+	// rates are keyed by currencyKey.
+	rates       table[postgres.ExchangeRate]
+	subjects    table[postgres.Subject]
+	targetNotes table[postgres.TargetNote]
+	attachments table[postgres.Attachment]
+	assignments table[postgres.MissionAssignment]
+}
+
+func newDB() *db {
+	return &db{
+		cats:         newTable[postgres.Cat](),
+		missions:     newTable[postgres.Mission](),
+		targets:      newTable[postgres.Target](),
+		webhooks:     newTable[postgres.Webhook](),
+		deliveries:   newTable[postgres.WebhookDelivery](),
+		outbox:       newTable[postgres.Outbox](),
+		templates:    newTable[postgres.MissionTemplate](),
+		moves:        newTable[postgres.TargetMove](),
+		periods:      newTable[postgres.AvailabilityPeriod](),
+		skills:       newTable[postgres.Skill](),
+		catSkills:    newTable[postgres.CatSkill](),
+		targetSkills: newTable[postgres.TargetSkill](),
+		budgets:      newTable[postgres.MissionBudget](),
+		expenses:     newTable[postgres.MissionExpense](),
+		rates:        newTable[postgres.ExchangeRate](),
+		subjects:     newTable[postgres.Subject](),
+		targetNotes:  newTable[postgres.TargetNote](),
+		attachments:  newTable[postgres.Attachment](),
+		assignments:  newTable[postgres.MissionAssignment](),
+	}
+}
+
+func (d *db) clone() *db {
+	return &db{
+		cats:         d.cats.clone(),
+		missions:     d.missions.clone(),
+		targets:      d.targets.clone(),
+		webhooks:     d.webhooks.clone(),
+		deliveries:   d.deliveries.clone(),
+		outbox:       d.outbox.clone(),
+		templates:    d.templates.clone(),
+		moves:        d.moves.clone(),
+		periods:      d.periods.clone(),
+		skills:       d.skills.clone(),
+		catSkills:    d.catSkills.clone(),
+		targetSkills: d.targetSkills.clone(),
+		budgets:      d.budgets.clone(),
+		expenses:     d.expenses.clone(),
+		rates:        d.rates.clone(),
+		subjects:     d.subjects.clone(),
+		targetNotes:  d.targetNotes.clone(),
+		attachments:  d.attachments.clone(),
+		assignments:  d.assignments.clone(),
+	}
+}
+
+// pairKey returns the key of a row with a composite primary key of two IDs,
+// which sorts like the pair.
+func pairKey(a, b int32) int64 {
+	return int64(a)<<32 | int64(uint32(b))
+}
+
+// currencyKey returns the key of a row with a currency code as its primary
+// key, which sorts like the code.
+func currencyKey(code string) int64 {
+	var key int64
+	for i := range 3 {
+		key <<= 8
+		if i < len(code) {
+			key |= int64(code[i])
+		}
+	}
+	return key
+}
+
+// table holds rows by their serial ID.
+type table[T any] struct {
+	rows map[int64]T
+	seq  int64
+}
+
+func newTable[T any]() table[T] {
+	return table[T]{rows: make(map[int64]T)}
+}
+
+func (t table[T]) clone() table[T] {
+	return table[T]{rows: maps.Clone(t.rows), seq: t.seq}
+}
+
+// next returns the next serial ID.
+func (t *table[T]) next() int64 {
+	t.seq++
+	return t.seq
+}
+
+func (t *table[T]) get(id int64) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *table[T]) put(id int64, row T) {
+	t.rows[id] = row
+}
+
+// reset sets the serial ID to the largest ID, like setval in Postgres.
+func (t *table[T]) reset() {
+	t.seq = 0
+	for id := range t.rows {
+		t.seq = max(t.seq, id)
+	}
+}
+
+func (t *table[T]) delete(id int64) bool {
+	_, ok := t.rows[id]
+	delete(t.rows, id)
+	return ok
+}
+
+// ids returns the IDs of the rows matching fn in ascending order.
+func (t *table[T]) ids(fn func(T) bool) []int64 {
+	ids := make([]int64, 0, len(t.rows))
+	for id, row := range t.rows {
+		if fn == nil || fn(row) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+// filter returns the rows matching fn in ascending order of ID.
+func (t *table[T]) filter(fn func(T) bool) []T {
+	ids := t.ids(fn)
+
+	rows := make([]T, len(ids))
+	for i, id := range ids {
+		rows[i] = t.rows[id]
+	}
+
+	return rows
+}
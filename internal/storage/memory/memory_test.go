@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, New())
+}
+
+func TestTx_Rollback(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	mission, err := s.CreateMission(ctx)
+	require.NoError(t, err)
+
+	tx, err := s.Begin(ctx)
+	require.NoError(t, err)
+
+	withTx := s.WithTx(tx)
+	_, err = withTx.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: "Ivan", Country: "RU", Notes: "notes"})
+	require.NoError(t, err)
+	_, err = withTx.CompleteMission(ctx, mission.ID)
+	require.NoError(t, err)
+
+	// Uncommitted changes are visible only within the transaction.
+	targets, err := s.GetMissionTargets(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	require.NoError(t, tx.Rollback(ctx))
+	assert.ErrorIs(t, tx.Commit(ctx), pgx.ErrTxClosed)
+
+	got, err := s.GetMission(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Completed)
+
+	targets, err = s.GetMissionTargets(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+}
+
+func TestTx_Commit(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	tx, err := s.Begin(ctx)
+	require.NoError(t, err)
+
+	cat, err := s.WithTx(tx).CreateCat(ctx, postgres.CreateCatParams{Name: "Tom", Breed: "Abyssinian", YearsOfExperience: 3, Salary: 100})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+
+	got, err := s.GetCat(ctx, cat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cat, got)
+}
+
+func TestTx_BlocksWriters(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	tx, err := s.Begin(ctx)
+	require.NoError(t, err)
+
+	// Another writer waits for the open transaction.
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	_, err = s.CreateMission(timeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, tx.Rollback(ctx))
+
+	_, err = s.CreateMission(ctx)
+	assert.NoError(t, err)
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CreateMission(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	missions, err := s.GetAllMissions(ctx)
+	require.NoError(t, err)
+	assert.Len(t, missions, 50)
+}
+
+func TestForeignKeys(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	cat, err := s.CreateCat(ctx, postgres.CreateCatParams{Name: "Tom", Breed: "Abyssinian", Salary: 100})
+	require.NoError(t, err)
+	mission, err := s.CreateMission(ctx)
+	require.NoError(t, err)
+	target, err := s.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: "Ivan", Country: "RU", Notes: "notes"})
+	require.NoError(t, err)
+
+	_, err = s.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: pgtype.Int4{Int32: 42, Valid: true}})
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr))
+	assert.Equal(t, "23503", pgErr.Code)
+
+	_, err = s.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: pgtype.Int4{Int32: cat.ID, Valid: true}})
+	require.NoError(t, err)
+
+	// ON DELETE SET NULL
+	_, err = s.DeleteCat(ctx, cat.ID)
+	require.NoError(t, err)
+	got, err := s.GetMission(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Assignee.Valid)
+
+	// ON DELETE CASCADE
+	_, err = s.DeleteMission(ctx, mission.ID)
+	require.NoError(t, err)
+	_, err = s.GetTarget(ctx, target.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestSearchTargets(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	mission, err := s.CreateMission(ctx)
+	require.NoError(t, err)
+	for _, p := range []postgres.CreateTargetParams{
+		{Mission: mission.ID, Name: "Ivan", Country: "RU", Notes: "Seen near the red square"},
+		{Mission: mission.ID, Name: "Square", Country: "DE", Notes: "Red car"},
+		{Mission: mission.ID, Name: "Hans", Country: "DE", Notes: "Embassy guard"},
+	} {
+		_, err := s.CreateTarget(ctx, p)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		query string
+		want  []int32
+	}{
+		{"red <-> square", []int32{1}},
+		{"red & square", []int32{2, 1}},
+		{"emb:*", []int32{3}},
+		{"de & !red", []int32{3}},
+		{"ivan | hans", []int32{1, 3}},
+	}
+
+	for _, tt := range tests {
+		rows, err := s.SearchTargets(ctx, postgres.SearchTargetsParams{Query: tt.query, MaxResults: 10})
+		require.NoError(t, err, tt.query)
+
+		ids := make([]int32, len(rows))
+		for i, r := range rows {
+			ids[i] = r.ID
+		}
+		assert.Equal(t, tt.want, ids, tt.query)
+	}
+
+	rows, err := s.SearchTargets(ctx, postgres.SearchTargetsParams{Query: "red <-> square", MaxResults: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "Seen near the \ue000red\ue001 \ue000square\ue001", rows[0].Snippet)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	// The schema must match the one of the last migration of the other backends.
+	for _, dir := range []string{"../migrations", "../sqlite/migrations"} {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+
+		last := entries[len(entries)-1].Name()
+		version, err := strconv.ParseInt(strings.SplitN(last, "_", 2)[0], 10, 64)
+		require.NoError(t, err)
+
+		got, err := New().SchemaVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, version, got, "bump SchemaVersion to the version of %s", last)
+	}
+}
@@ -0,0 +1,1784 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/fuzzy"
+	"github.com/rsmanito/developstoday-test-assessment/internal/geo"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/tsquery"
+)
+
+// queries implements the queries on a snapshot.
+//
+// Errors match the ones returned by Postgres: pgx.ErrNoRows when a single row
+// is not found and a *pgconn.PgError when a constraint is violated.
+type queries struct {
+	db *db
+	// now is the start time of the transaction, like now() in Postgres.
+	now time.Time
+}
+
+var _ postgres.Querier = (*queries)(nil)
+
+// foreignKeyViolation returns the error of a violated foreign key constraint.
+func foreignKeyViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// uniqueViolation returns the error of a violated unique constraint.
+func uniqueViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func (q *queries) timestamp() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: q.now, Valid: true}
+}
+
+//-------------------------------------
+// CATS
+//-------------------------------------
+
+func (q *queries) GetAllCats(ctx context.Context) ([]postgres.Cat, error) {
+	return q.db.cats.filter(nil), nil
+}
+
+func (q *queries) CreateCat(ctx context.Context, arg postgres.CreateCatParams) (postgres.Cat, error) {
+	cat := postgres.Cat{
+		ID:                int32(q.db.cats.next()),
+		Name:              arg.Name,
+		YearsOfExperience: arg.YearsOfExperience,
+		Breed:             arg.Breed,
+		Salary:            arg.Salary,
+		SalaryCurrency:    arg.SalaryCurrency,
+	}
+	q.db.cats.put(int64(cat.ID), cat)
+
+	return cat, nil
+}
+
+func (q *queries) GetCat(ctx context.Context, id int32) (postgres.Cat, error) {
+	cat, ok := q.db.cats.get(int64(id))
+	if !ok {
+		return postgres.Cat{}, pgx.ErrNoRows
+	}
+
+	return cat, nil
+}
+
+func (q *queries) UpdateCatSalary(ctx context.Context, arg postgres.UpdateCatSalaryParams) (postgres.Cat, error) {
+	cat, ok := q.db.cats.get(int64(arg.ID))
+	if !ok {
+		return postgres.Cat{}, pgx.ErrNoRows
+	}
+
+	cat.Salary = arg.Salary
+	if arg.SalaryCurrency != "" {
+		cat.SalaryCurrency = arg.SalaryCurrency
+	}
+	q.db.cats.put(int64(cat.ID), cat)
+
+	return cat, nil
+}
+
+func (q *queries) DeleteCat(ctx context.Context, id int32) (int64, error) {
+	if !q.db.cats.delete(int64(id)) {
+		return 0, nil
+	}
+
+	// ON DELETE SET NULL
+	for _, m := range q.db.missions.filter(func(m postgres.Mission) bool {
+		return m.Assignee.Valid && m.Assignee.Int32 == id
+	}) {
+		m.Assignee = pgtype.Int4{}
+		q.db.missions.put(int64(m.ID), m)
+	}
+
+	// ON DELETE CASCADE
+	for _, pid := range q.db.periods.ids(func(p postgres.AvailabilityPeriod) bool {
+		return p.Cat == id
+	}) {
+		q.db.periods.delete(pid)
+	}
+	for _, key := range q.db.catSkills.ids(func(cs postgres.CatSkill) bool {
+		return cs.Cat == id
+	}) {
+		q.db.catSkills.delete(key)
+	}
+	for _, aid := range q.db.assignments.ids(func(a postgres.MissionAssignment) bool {
+		return a.Cat == id
+	}) {
+		q.db.assignments.delete(aid)
+	}
+
+	return 1, nil
+}
+
+//-------------------------------------
+// MISSIONS
+//-------------------------------------
+
+func (q *queries) GetAllMissions(ctx context.Context) ([]postgres.Mission, error) {
+	return q.db.missions.filter(nil), nil
+}
+
+func (q *queries) CreateMission(ctx context.Context) (postgres.Mission, error) {
+	mission := postgres.Mission{
+		ID:        int32(q.db.missions.next()),
+		CreatedAt: q.timestamp(),
+	}
+	q.db.missions.put(int64(mission.ID), mission)
+
+	return mission, nil
+}
+
+func (q *queries) GetMission(ctx context.Context, id int32) (postgres.Mission, error) {
+	mission, ok := q.db.missions.get(int64(id))
+	if !ok {
+		return postgres.Mission{}, pgx.ErrNoRows
+	}
+
+	return mission, nil
+}
+
+// GetMissionForUpdate is GetMission: a transaction holds the write lock until
+// it ends, so the mission is already locked.
+func (q *queries) GetMissionForUpdate(ctx context.Context, id int32) (postgres.Mission, error) {
+	return q.GetMission(ctx, id)
+}
+
+func (q *queries) DeleteMission(ctx context.Context, id int32) (int64, error) {
+	if !q.db.missions.delete(int64(id)) {
+		return 0, nil
+	}
+
+	// ON DELETE CASCADE
+	for _, tid := range q.db.targets.ids(func(t postgres.Target) bool {
+		return t.Mission == id
+	}) {
+		q.db.targets.delete(tid)
+		q.deleteTargetSkills(int32(tid))
+		q.deleteTargetAttachments(int32(tid))
+	}
+	q.db.budgets.delete(int64(id))
+	for _, eid := range q.db.expenses.ids(func(e postgres.MissionExpense) bool {
+		return e.Mission == id
+	}) {
+		q.db.expenses.delete(eid)
+	}
+	for _, aid := range q.db.assignments.ids(func(a postgres.MissionAssignment) bool {
+		return a.Mission == id
+	}) {
+		q.db.assignments.delete(aid)
+	}
+
+	return 1, nil
+}
+
+func (q *queries) AssignCat(ctx context.Context, arg postgres.AssignCatParams) (postgres.Mission, error) {
+	mission, ok := q.db.missions.get(int64(arg.ID))
+	if !ok {
+		return postgres.Mission{}, pgx.ErrNoRows
+	}
+
+	if arg.Assignee.Valid {
+		if _, ok := q.db.cats.get(int64(arg.Assignee.Int32)); !ok {
+			return postgres.Mission{}, foreignKeyViolation("missions", "missions_assignee_fkey")
+		}
+	}
+
+	switch {
+	case !arg.Assignee.Valid:
+		mission.AssignedAt = pgtype.Timestamptz{}
+	case mission.Assignee != arg.Assignee:
+		mission.AssignedAt = q.timestamp()
+	}
+	mission.Assignee = arg.Assignee
+	q.db.missions.put(int64(mission.ID), mission)
+
+	return mission, nil
+}
+
+func (q *queries) GetCatMission(ctx context.Context, assignee pgtype.Int4) (postgres.Mission, error) {
+	missions := q.db.missions.filter(func(m postgres.Mission) bool {
+		return assignee.Valid && m.Assignee == assignee
+	})
+	if len(missions) == 0 {
+		return postgres.Mission{}, pgx.ErrNoRows
+	}
+
+	return missions[0], nil
+}
+
+func (q *queries) CompleteMission(ctx context.Context, id int32) (postgres.Mission, error) {
+	mission, ok := q.db.missions.get(int64(id))
+	if !ok {
+		return postgres.Mission{}, pgx.ErrNoRows
+	}
+
+	mission.Completed = true
+	if !mission.CompletedAt.Valid {
+		mission.CompletedAt = q.timestamp()
+	}
+	q.db.missions.put(int64(mission.ID), mission)
+
+	return mission, nil
+}
+
+func (q *queries) CreateMissionAssignment(ctx context.Context, arg postgres.CreateMissionAssignmentParams) error {
+	if _, ok := q.db.missions.get(int64(arg.Mission)); !ok {
+		return foreignKeyViolation("mission_assignments", "mission_assignments_mission_fkey")
+	}
+	if _, ok := q.db.cats.get(int64(arg.Cat)); !ok {
+		return foreignKeyViolation("mission_assignments", "mission_assignments_cat_fkey")
+	}
+
+	id := q.db.assignments.next()
+	q.db.assignments.put(id, postgres.MissionAssignment{
+		ID:         int32(id),
+		Mission:    arg.Mission,
+		Cat:        arg.Cat,
+		AssignedAt: arg.AssignedAt,
+	})
+
+	return nil
+}
+
+func (q *queries) EndMissionAssignment(ctx context.Context, mission int32) error {
+	for _, a := range q.db.assignments.filter(func(a postgres.MissionAssignment) bool {
+		return a.Mission == mission && !a.UnassignedAt.Valid
+	}) {
+		a.UnassignedAt = q.timestamp()
+		q.db.assignments.put(int64(a.ID), a)
+	}
+
+	return nil
+}
+
+func (q *queries) GetMissionByTargetID(ctx context.Context, id int32) (postgres.GetMissionByTargetIDRow, error) {
+	target, ok := q.db.targets.get(int64(id))
+	if !ok {
+		return postgres.GetMissionByTargetIDRow{}, pgx.ErrNoRows
+	}
+
+	mission, ok := q.db.missions.get(int64(target.Mission))
+	if !ok {
+		return postgres.GetMissionByTargetIDRow{}, pgx.ErrNoRows
+	}
+
+	return postgres.GetMissionByTargetIDRow{
+		MissionID: mission.ID,
+		Assignee:  mission.Assignee,
+		Completed: mission.Completed,
+	}, nil
+}
+
+//-------------------------------------
+// TARGETS
+//-------------------------------------
+
+func (q *queries) CreateTarget(ctx context.Context, arg postgres.CreateTargetParams) (postgres.Target, error) {
+	if _, ok := q.db.missions.get(int64(arg.Mission)); !ok {
+		return postgres.Target{}, foreignKeyViolation("targets", "targets_mission_fkey")
+	}
+
+	if err := q.checkSubject(arg.Subject); err != nil {
+		return postgres.Target{}, err
+	}
+
+	target := postgres.Target{
+		ID:        int32(q.db.targets.next()),
+		Mission:   arg.Mission,
+		Name:      arg.Name,
+		Country:   arg.Country,
+		Notes:     arg.Notes,
+		Latitude:  arg.Latitude,
+		Longitude: arg.Longitude,
+		Address:   arg.Address,
+		Subject:   arg.Subject,
+		CreatedAt: q.timestamp(),
+	}
+	q.db.targets.put(int64(target.ID), target)
+
+	return target, nil
+}
+
+func (q *queries) GetMissionTargets(ctx context.Context, mission int32) ([]postgres.Target, error) {
+	return q.db.targets.filter(func(t postgres.Target) bool {
+		return t.Mission == mission
+	}), nil
+}
+
+func (q *queries) GetTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	target, ok := q.db.targets.get(int64(id))
+	if !ok {
+		return postgres.Target{}, pgx.ErrNoRows
+	}
+
+	return target, nil
+}
+
+func (q *queries) DeleteTarget(ctx context.Context, id int32) (int64, error) {
+	if !q.db.targets.delete(int64(id)) {
+		return 0, nil
+	}
+	q.deleteTargetSkills(id)
+	q.deleteTargetAttachments(id)
+
+	return 1, nil
+}
+
+func (q *queries) UpdateTargetNotes(ctx context.Context, arg postgres.UpdateTargetNotesParams) (postgres.Target, error) {
+	target, ok := q.db.targets.get(int64(arg.ID))
+	if !ok {
+		return postgres.Target{}, pgx.ErrNoRows
+	}
+
+	target.Notes = arg.Notes
+	q.db.targets.put(int64(target.ID), target)
+
+	return target, nil
+}
+
+func (q *queries) UpdateTargetLocation(ctx context.Context, arg postgres.UpdateTargetLocationParams) (postgres.Target, error) {
+	target, ok := q.db.targets.get(int64(arg.ID))
+	if !ok {
+		return postgres.Target{}, pgx.ErrNoRows
+	}
+
+	target.Latitude = arg.Latitude
+	target.Longitude = arg.Longitude
+	target.Address = arg.Address
+	q.db.targets.put(int64(target.ID), target)
+
+	return target, nil
+}
+
+func (q *queries) CompleteTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	target, ok := q.db.targets.get(int64(id))
+	if !ok {
+		return postgres.Target{}, pgx.ErrNoRows
+	}
+
+	target.Completed = true
+	if !target.CompletedAt.Valid {
+		target.CompletedAt = q.timestamp()
+	}
+	q.db.targets.put(int64(target.ID), target)
+
+	return target, nil
+}
+
+func (q *queries) MoveTarget(ctx context.Context, arg postgres.MoveTargetParams) (postgres.Target, error) {
+	target, ok := q.db.targets.get(int64(arg.ID))
+	if !ok {
+		return postgres.Target{}, pgx.ErrNoRows
+	}
+	if _, ok := q.db.missions.get(int64(arg.Mission)); !ok {
+		return postgres.Target{}, foreignKeyViolation("targets", "targets_mission_fkey")
+	}
+
+	target.Mission = arg.Mission
+	q.db.targets.put(int64(target.ID), target)
+
+	return target, nil
+}
+
+func (q *queries) SearchTargets(ctx context.Context, arg postgres.SearchTargetsParams) ([]postgres.SearchTargetsRow, error) {
+	query, err := tsquery.Parse(arg.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []postgres.SearchTargetsRow
+	for _, t := range q.db.targets.filter(nil) {
+		var doc tsquery.Document
+		doc.Add(t.Name, tsquery.WeightA)
+		doc.Add(t.Country, tsquery.WeightB)
+		doc.Add(t.Notes, tsquery.WeightC)
+
+		if !query.Match(&doc) {
+			continue
+		}
+
+		mission, _ := q.db.missions.get(int64(t.Mission))
+		rows = append(rows, postgres.SearchTargetsRow{
+			ID:               t.ID,
+			Mission:          t.Mission,
+			Name:             t.Name,
+			Country:          t.Country,
+			Notes:            t.Notes,
+			Completed:        t.Completed,
+			MissionAssignee:  mission.Assignee,
+			MissionCompleted: mission.Completed,
+			Rank:             query.Rank(&doc),
+			Snippet:          query.Headline(t.Notes),
+		})
+	}
+
+	// ORDER BY rank DESC, t.id
+	slices.SortStableFunc(rows, func(a, b postgres.SearchTargetsRow) int {
+		switch {
+		case a.Rank > b.Rank:
+			return -1
+		case a.Rank < b.Rank:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return limit(rows, arg.MaxResults), nil
+}
+
+//-------------------------------------
+// WEBHOOKS
+//-------------------------------------
+
+func (q *queries) CreateWebhook(ctx context.Context, arg postgres.CreateWebhookParams) (postgres.Webhook, error) {
+	webhook := postgres.Webhook{
+		ID:         int32(q.db.webhooks.next()),
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: slices.Clone(arg.EventTypes),
+		Active:     true,
+		CreatedAt:  q.timestamp(),
+	}
+	q.db.webhooks.put(int64(webhook.ID), webhook)
+
+	return cloneWebhook(webhook), nil
+}
+
+func (q *queries) GetAllWebhooks(ctx context.Context) ([]postgres.Webhook, error) {
+	webhooks := q.db.webhooks.filter(nil)
+	for i := range webhooks {
+		webhooks[i] = cloneWebhook(webhooks[i])
+	}
+
+	return webhooks, nil
+}
+
+func (q *queries) GetWebhook(ctx context.Context, id int32) (postgres.Webhook, error) {
+	webhook, ok := q.db.webhooks.get(int64(id))
+	if !ok {
+		return postgres.Webhook{}, pgx.ErrNoRows
+	}
+
+	return cloneWebhook(webhook), nil
+}
+
+func (q *queries) UpdateWebhook(ctx context.Context, arg postgres.UpdateWebhookParams) (postgres.Webhook, error) {
+	webhook, ok := q.db.webhooks.get(int64(arg.ID))
+	if !ok {
+		return postgres.Webhook{}, pgx.ErrNoRows
+	}
+
+	webhook.Url = arg.Url
+	webhook.EventTypes = slices.Clone(arg.EventTypes)
+	webhook.Active = arg.Active
+	q.db.webhooks.put(int64(webhook.ID), webhook)
+
+	return cloneWebhook(webhook), nil
+}
+
+func (q *queries) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	if !q.db.webhooks.delete(int64(id)) {
+		return 0, nil
+	}
+
+	// ON DELETE CASCADE
+	for _, did := range q.db.deliveries.ids(func(d postgres.WebhookDelivery) bool {
+		return d.Webhook == id
+	}) {
+		q.db.deliveries.delete(did)
+	}
+
+	return 1, nil
+}
+
+func (q *queries) EnqueueWebhookDeliveries(ctx context.Context, arg postgres.EnqueueWebhookDeliveriesParams) (int64, error) {
+	webhooks := q.db.webhooks.filter(func(w postgres.Webhook) bool {
+		return w.Active && slices.Contains(w.EventTypes, arg.EventType)
+	})
+
+	for _, w := range webhooks {
+		delivery := postgres.WebhookDelivery{
+			ID:            int32(q.db.deliveries.next()),
+			Webhook:       w.ID,
+			EventType:     arg.EventType,
+			Payload:       slices.Clone(arg.Payload),
+			Status:        "pending",
+			NextAttemptAt: q.timestamp(),
+			CreatedAt:     q.timestamp(),
+		}
+		q.db.deliveries.put(int64(delivery.ID), delivery)
+	}
+
+	return int64(len(webhooks)), nil
+}
+
+func (q *queries) ClaimWebhookDeliveries(ctx context.Context, arg postgres.ClaimWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	deliveries := limit(q.db.deliveries.filter(func(d postgres.WebhookDelivery) bool {
+		w, ok := q.db.webhooks.get(int64(d.Webhook))
+		return d.Status == "pending" && !d.NextAttemptAt.Time.After(q.now) && ok && w.Active
+	}), arg.BatchSize)
+
+	for i, d := range deliveries {
+		d.NextAttemptAt = arg.LeaseUntil
+		q.db.deliveries.put(int64(d.ID), d)
+		deliveries[i] = cloneDelivery(d)
+	}
+
+	return deliveries, nil
+}
+
+func (q *queries) MarkWebhookDeliverySucceeded(ctx context.Context, arg postgres.MarkWebhookDeliverySucceededParams) error {
+	delivery, ok := q.db.deliveries.get(int64(arg.ID))
+	if !ok {
+		return nil
+	}
+
+	delivery.Status = "succeeded"
+	delivery.Attempts++
+	delivery.LastError = ""
+	delivery.LastResponseCode = arg.LastResponseCode
+	delivery.DeliveredAt = q.timestamp()
+	q.db.deliveries.put(int64(delivery.ID), delivery)
+
+	return nil
+}
+
+func (q *queries) MarkWebhookDeliveryFailed(ctx context.Context, arg postgres.MarkWebhookDeliveryFailedParams) error {
+	delivery, ok := q.db.deliveries.get(int64(arg.ID))
+	if !ok {
+		return nil
+	}
+
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	delivery.LastError = arg.LastError
+	delivery.LastResponseCode = arg.LastResponseCode
+	q.db.deliveries.put(int64(delivery.ID), delivery)
+
+	return nil
+}
+
+func (q *queries) GetWebhookDeliveries(ctx context.Context, arg postgres.GetWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	deliveries := q.db.deliveries.filter(func(d postgres.WebhookDelivery) bool {
+		return d.Webhook == arg.Webhook
+	})
+
+	// ORDER BY id DESC
+	slices.Reverse(deliveries)
+	deliveries = limit(deliveries, arg.Limit)
+	for i := range deliveries {
+		deliveries[i] = cloneDelivery(deliveries[i])
+	}
+
+	return deliveries, nil
+}
+
+func (q *queries) RedeliverWebhookDelivery(ctx context.Context, arg postgres.RedeliverWebhookDeliveryParams) (postgres.WebhookDelivery, error) {
+	delivery, ok := q.db.deliveries.get(int64(arg.ID))
+	if !ok || delivery.Webhook != arg.Webhook {
+		return postgres.WebhookDelivery{}, pgx.ErrNoRows
+	}
+
+	delivery.Status = "pending"
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = q.timestamp()
+	delivery.LastError = ""
+	q.db.deliveries.put(int64(delivery.ID), delivery)
+
+	return cloneDelivery(delivery), nil
+}
+
+//-------------------------------------
+// OUTBOX
+//-------------------------------------
+
+func (q *queries) CreateOutboxEvent(ctx context.Context, arg postgres.CreateOutboxEventParams) error {
+	event := postgres.Outbox{
+		ID:            q.db.outbox.next(),
+		AggregateType: arg.AggregateType,
+		AggregateID:   arg.AggregateID,
+		EventType:     arg.EventType,
+		Payload:       slices.Clone(arg.Payload),
+		CreatedAt:     q.timestamp(),
+	}
+	q.db.outbox.put(event.ID, event)
+
+	return nil
+}
+
+func (q *queries) GetUnpublishedOutboxEvents(ctx context.Context, arg postgres.GetUnpublishedOutboxEventsParams) ([]postgres.Outbox, error) {
+	events := limit(q.db.outbox.filter(func(e postgres.Outbox) bool {
+		return !e.PublishedAt.Valid && e.ID > arg.AfterOffset
+	}), arg.MaxEvents)
+	for i := range events {
+		events[i] = cloneOutbox(events[i])
+	}
+
+	return events, nil
+}
+
+func (q *queries) MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error) {
+	var rows int64
+	for _, id := range ids {
+		event, ok := q.db.outbox.get(id)
+		if !ok {
+			continue
+		}
+
+		event.PublishedAt = q.timestamp()
+		q.db.outbox.put(id, event)
+		rows++
+	}
+
+	return rows, nil
+}
+
+func (q *queries) GetOutboxEvents(ctx context.Context, arg postgres.GetOutboxEventsParams) ([]postgres.Outbox, error) {
+	events := limit(q.db.outbox.filter(func(e postgres.Outbox) bool {
+		return e.ID >= arg.FromOffset
+	}), arg.MaxEvents)
+	for i := range events {
+		events[i] = cloneOutbox(events[i])
+	}
+
+	return events, nil
+}
+
+func (q *queries) ResetOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	events := q.db.outbox.filter(func(e postgres.Outbox) bool {
+		return e.ID >= fromOffset
+	})
+
+	for _, e := range events {
+		e.PublishedAt = pgtype.Timestamptz{}
+		q.db.outbox.put(e.ID, e)
+	}
+
+	return int64(len(events)), nil
+}
+
+//-------------------------------------
+// MISSION TEMPLATES
+//-------------------------------------
+
+func (q *queries) CreateMissionTemplate(ctx context.Context, arg postgres.CreateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	template := postgres.MissionTemplate{
+		ID:        int32(q.db.templates.next()),
+		Name:      arg.Name,
+		Targets:   slices.Clone(arg.Targets),
+		CreatedAt: q.timestamp(),
+	}
+	q.db.templates.put(int64(template.ID), template)
+
+	return cloneTemplate(template), nil
+}
+
+func (q *queries) GetAllMissionTemplates(ctx context.Context) ([]postgres.MissionTemplate, error) {
+	templates := q.db.templates.filter(nil)
+	for i := range templates {
+		templates[i] = cloneTemplate(templates[i])
+	}
+
+	return templates, nil
+}
+
+func (q *queries) GetMissionTemplate(ctx context.Context, id int32) (postgres.MissionTemplate, error) {
+	template, ok := q.db.templates.get(int64(id))
+	if !ok {
+		return postgres.MissionTemplate{}, pgx.ErrNoRows
+	}
+
+	return cloneTemplate(template), nil
+}
+
+func (q *queries) UpdateMissionTemplate(ctx context.Context, arg postgres.UpdateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	template, ok := q.db.templates.get(int64(arg.ID))
+	if !ok {
+		return postgres.MissionTemplate{}, pgx.ErrNoRows
+	}
+
+	template.Name = arg.Name
+	template.Targets = slices.Clone(arg.Targets)
+	q.db.templates.put(int64(template.ID), template)
+
+	return cloneTemplate(template), nil
+}
+
+func (q *queries) DeleteMissionTemplate(ctx context.Context, id int32) (int64, error) {
+	if !q.db.templates.delete(int64(id)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+//-------------------------------------
+// TARGET MOVES
+//-------------------------------------
+
+func (q *queries) CreateTargetMove(ctx context.Context, arg postgres.CreateTargetMoveParams) (postgres.TargetMove, error) {
+	move := postgres.TargetMove{
+		ID:          int32(q.db.moves.next()),
+		Target:      arg.Target,
+		FromMission: arg.FromMission,
+		ToMission:   arg.ToMission,
+		MovedAt:     q.timestamp(),
+	}
+	q.db.moves.put(int64(move.ID), move)
+
+	return move, nil
+}
+
+func (q *queries) GetTargetMoves(ctx context.Context, target int32) ([]postgres.TargetMove, error) {
+	return q.db.moves.filter(func(m postgres.TargetMove) bool {
+		return m.Target == target
+	}), nil
+}
+
+//-------------------------------------
+// SNAPSHOTS
+//-------------------------------------
+
+func (q *queries) GetAllTargets(ctx context.Context) ([]postgres.Target, error) {
+	return q.db.targets.filter(nil), nil
+}
+
+// The rows are restored with their IDs, which don't advance the serial IDs
+// until ResetSequences.
+
+func (q *queries) RestoreCat(ctx context.Context, arg postgres.RestoreCatParams) error {
+	if _, ok := q.db.cats.get(int64(arg.ID)); ok {
+		return uniqueViolation("cats", "cats_pkey")
+	}
+
+	q.db.cats.put(int64(arg.ID), postgres.Cat{
+		ID:                arg.ID,
+		Name:              arg.Name,
+		YearsOfExperience: arg.YearsOfExperience,
+		Breed:             arg.Breed,
+		Salary:            arg.Salary,
+		SalaryCurrency:    arg.SalaryCurrency,
+	})
+
+	return nil
+}
+
+func (q *queries) RestoreMission(ctx context.Context, arg postgres.RestoreMissionParams) error {
+	if _, ok := q.db.missions.get(int64(arg.ID)); ok {
+		return uniqueViolation("missions", "missions_pkey")
+	}
+	if arg.Assignee.Valid {
+		if _, ok := q.db.cats.get(int64(arg.Assignee.Int32)); !ok {
+			return foreignKeyViolation("missions", "missions_assignee_fkey")
+		}
+	}
+
+	q.db.missions.put(int64(arg.ID), postgres.Mission{
+		ID:          arg.ID,
+		Assignee:    arg.Assignee,
+		Completed:   arg.Completed,
+		CreatedAt:   arg.CreatedAt,
+		AssignedAt:  arg.AssignedAt,
+		CompletedAt: arg.CompletedAt,
+	})
+
+	return nil
+}
+
+func (q *queries) RestoreTarget(ctx context.Context, arg postgres.RestoreTargetParams) error {
+	if _, ok := q.db.targets.get(int64(arg.ID)); ok {
+		return uniqueViolation("targets", "targets_pkey")
+	}
+	if _, ok := q.db.missions.get(int64(arg.Mission)); !ok {
+		return foreignKeyViolation("targets", "targets_mission_fkey")
+	}
+	if err := q.checkSubject(arg.Subject); err != nil {
+		return err
+	}
+
+	q.db.targets.put(int64(arg.ID), postgres.Target{
+		ID:          arg.ID,
+		Mission:     arg.Mission,
+		Name:        arg.Name,
+		Country:     arg.Country,
+		Notes:       arg.Notes,
+		Completed:   arg.Completed,
+		CreatedAt:   arg.CreatedAt,
+		CompletedAt: arg.CompletedAt,
+		Latitude:    arg.Latitude,
+		Longitude:   arg.Longitude,
+		Address:     arg.Address,
+		Subject:     arg.Subject,
+	})
+
+	return nil
+}
+
+func (q *queries) GetAllSubjects(ctx context.Context) ([]postgres.Subject, error) {
+	return q.db.subjects.filter(nil), nil
+}
+
+func (q *queries) GetSubjectsPage(ctx context.Context, arg postgres.GetSubjectsPageParams) ([]postgres.Subject, error) {
+	return limit(q.db.subjects.filter(func(s postgres.Subject) bool {
+		return s.ID > arg.After
+	}), arg.MaxRows), nil
+}
+
+func (q *queries) GetTargetsPage(ctx context.Context, arg postgres.GetTargetsPageParams) ([]postgres.Target, error) {
+	return limit(q.db.targets.filter(func(t postgres.Target) bool {
+		return t.ID > arg.After
+	}), arg.MaxRows), nil
+}
+
+func (q *queries) RestoreSubject(ctx context.Context, arg postgres.RestoreSubjectParams) error {
+	if _, ok := q.db.subjects.get(int64(arg.ID)); ok {
+		return uniqueViolation("subjects", "subjects_pkey")
+	}
+
+	q.db.subjects.put(int64(arg.ID), postgres.Subject{
+		ID:        arg.ID,
+		Name:      arg.Name,
+		Country:   arg.Country,
+		CreatedAt: arg.CreatedAt,
+	})
+
+	return nil
+}
+
+func (q *queries) ResetSequences(ctx context.Context) error {
+	q.db.cats.reset()
+	q.db.missions.reset()
+	q.db.targets.reset()
+	q.db.subjects.reset()
+
+	return nil
+}
+
+//-------------------------------------
+// REPORTS
+//-------------------------------------
+
+func (q *queries) GetCatsPage(ctx context.Context, arg postgres.GetCatsPageParams) ([]postgres.Cat, error) {
+	return limit(q.db.cats.filter(func(c postgres.Cat) bool {
+		return c.ID > arg.After
+	}), arg.MaxRows), nil
+}
+
+func (q *queries) GetMissionsPage(ctx context.Context, arg postgres.GetMissionsPageParams) ([]postgres.Mission, error) {
+	return limit(q.db.missions.filter(func(m postgres.Mission) bool {
+		return m.ID > arg.After
+	}), arg.MaxRows), nil
+}
+
+func (q *queries) GetMissionTargetsPage(ctx context.Context, arg postgres.GetMissionTargetsPageParams) ([]postgres.GetMissionTargetsPageRow, error) {
+	targets := q.db.targets.filter(func(t postgres.Target) bool {
+		return t.Mission > arg.AfterMission || t.Mission == arg.AfterMission && t.ID > arg.AfterTarget
+	})
+	slices.SortStableFunc(targets, func(a, b postgres.Target) int {
+		return cmp.Compare(a.Mission, b.Mission)
+	})
+
+	rows := make([]postgres.GetMissionTargetsPageRow, 0, len(targets))
+	for _, t := range limit(targets, arg.MaxRows) {
+		mission, ok := q.db.missions.get(int64(t.Mission))
+		if !ok {
+			continue
+		}
+		rows = append(rows, postgres.GetMissionTargetsPageRow{
+			MissionID:        mission.ID,
+			Assignee:         mission.Assignee,
+			MissionCompleted: mission.Completed,
+			TargetID:         t.ID,
+			Name:             t.Name,
+			Country:          t.Country,
+			Notes:            t.Notes,
+			TargetCompleted:  t.Completed,
+		})
+	}
+
+	return rows, nil
+}
+
+func (q *queries) GetCatWorkloadPage(ctx context.Context, arg postgres.GetCatWorkloadPageParams) ([]postgres.GetCatWorkloadPageRow, error) {
+	cats := limit(q.db.cats.filter(func(c postgres.Cat) bool {
+		return c.ID > arg.After
+	}), arg.MaxRows)
+
+	rows := make([]postgres.GetCatWorkloadPageRow, len(cats))
+	for i, c := range cats {
+		row := postgres.GetCatWorkloadPageRow{ID: c.ID, Name: c.Name, Breed: c.Breed, Salary: c.Salary, SalaryCurrency: c.SalaryCurrency}
+		missions := q.db.missions.filter(func(m postgres.Mission) bool {
+			return m.Assignee.Valid && m.Assignee.Int32 == c.ID
+		})
+		for _, m := range missions {
+			if m.Completed {
+				row.CompletedMissions++
+			} else {
+				row.ActiveMissions++
+			}
+			for _, t := range q.db.targets.filter(func(t postgres.Target) bool { return t.Mission == m.ID }) {
+				if t.Completed {
+					row.CompletedTargets++
+				} else {
+					row.OpenTargets++
+				}
+			}
+		}
+		rows[i] = row
+	}
+
+	return rows, nil
+}
+
+//-------------------------------------
+// ANALYTICS
+//-------------------------------------
+
+func (q *queries) GetMissionCompletionRate(ctx context.Context, arg postgres.GetMissionCompletionRateParams) ([]postgres.GetMissionCompletionRateRow, error) {
+	var rows []postgres.GetMissionCompletionRateRow
+	for _, m := range q.db.missions.filter(func(m postgres.Mission) bool {
+		return !m.CreatedAt.Time.Before(arg.Since.Time)
+	}) {
+		period := truncate(m.CreatedAt.Time, arg.TimeWindow)
+		i := slices.IndexFunc(rows, func(r postgres.GetMissionCompletionRateRow) bool {
+			return r.Period.Time.Equal(period)
+		})
+		if i < 0 {
+			rows = append(rows, postgres.GetMissionCompletionRateRow{Period: pgtype.Timestamptz{Time: period, Valid: true}})
+			i = len(rows) - 1
+		}
+		rows[i].Created++
+		if m.Completed {
+			rows[i].Completed++
+		}
+	}
+	slices.SortFunc(rows, func(a, b postgres.GetMissionCompletionRateRow) int {
+		return a.Period.Time.Compare(b.Period.Time)
+	})
+
+	return rows, nil
+}
+
+// truncate returns the start of the window of t in UTC, like date_trunc in
+// Postgres. The weeks start on Monday.
+func truncate(t time.Time, window string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch window {
+	case "day":
+		return day
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func (q *queries) GetCompletionTimes(ctx context.Context) (postgres.GetCompletionTimesRow, error) {
+	var row postgres.GetCompletionTimesRow
+
+	var missionSeconds, targetSeconds float64
+	for _, m := range q.db.missions.filter(func(m postgres.Mission) bool { return m.CompletedAt.Valid }) {
+		row.CompletedMissions++
+		missionSeconds += m.CompletedAt.Time.Sub(m.CreatedAt.Time).Seconds()
+	}
+	for _, t := range q.db.targets.filter(func(t postgres.Target) bool { return t.CompletedAt.Valid }) {
+		row.CompletedTargets++
+		targetSeconds += t.CompletedAt.Time.Sub(t.CreatedAt.Time).Seconds()
+	}
+	if row.CompletedMissions > 0 {
+		row.MissionSeconds = missionSeconds / float64(row.CompletedMissions)
+	}
+	if row.CompletedTargets > 0 {
+		row.TargetSeconds = targetSeconds / float64(row.CompletedTargets)
+	}
+
+	return row, nil
+}
+
+func (q *queries) GetCatUtilization(ctx context.Context, now pgtype.Timestamptz) ([]postgres.GetCatUtilizationRow, error) {
+	cats := q.db.cats.filter(nil)
+
+	rows := make([]postgres.GetCatUtilizationRow, len(cats))
+	for i, c := range cats {
+		rows[i] = postgres.GetCatUtilizationRow{ID: c.ID, Name: c.Name}
+		missions := make(map[int32]bool)
+		for _, a := range q.db.assignments.filter(func(a postgres.MissionAssignment) bool {
+			return a.Cat == c.ID
+		}) {
+			end := now.Time
+			if a.UnassignedAt.Valid && a.UnassignedAt.Time.Before(end) {
+				end = a.UnassignedAt.Time
+			}
+			if m, ok := q.db.missions.get(int64(a.Mission)); ok && m.CompletedAt.Valid && m.CompletedAt.Time.Before(end) {
+				end = m.CompletedAt.Time
+			}
+			missions[a.Mission] = true
+			rows[i].DaysOnMission += end.Sub(a.AssignedAt.Time).Hours() / 24
+		}
+		rows[i].Missions = int64(len(missions))
+	}
+
+	return rows, nil
+}
+
+func (q *queries) GetTargetsPerCountry(ctx context.Context) ([]postgres.GetTargetsPerCountryRow, error) {
+	var rows []postgres.GetTargetsPerCountryRow
+	for _, t := range q.db.targets.filter(nil) {
+		i := slices.IndexFunc(rows, func(r postgres.GetTargetsPerCountryRow) bool { return r.Country == t.Country })
+		if i < 0 {
+			rows = append(rows, postgres.GetTargetsPerCountryRow{Country: t.Country})
+			i = len(rows) - 1
+		}
+		rows[i].Targets++
+		if t.Completed {
+			rows[i].Completed++
+		}
+	}
+	slices.SortFunc(rows, func(a, b postgres.GetTargetsPerCountryRow) int {
+		return cmp.Or(cmp.Compare(b.Targets, a.Targets), cmp.Compare(a.Country, b.Country))
+	})
+
+	return rows, nil
+}
+
+func (q *queries) GetSalaryPerformance(ctx context.Context) ([]postgres.GetSalaryPerformanceRow, error) {
+	rows := make(map[string]*postgres.GetSalaryPerformanceRow)
+	for _, c := range q.db.cats.filter(nil) {
+		var missions, targets float64
+		for _, m := range q.db.missions.filter(func(m postgres.Mission) bool {
+			return m.Assignee.Valid && m.Assignee.Int32 == c.ID
+		}) {
+			if m.Completed {
+				missions++
+			}
+			for _, t := range q.db.targets.filter(func(t postgres.Target) bool { return t.Mission == m.ID }) {
+				if t.Completed {
+					targets++
+				}
+			}
+		}
+
+		row, ok := rows[c.SalaryCurrency]
+		if !ok {
+			row = &postgres.GetSalaryPerformanceRow{SalaryCurrency: c.SalaryCurrency}
+			rows[c.SalaryCurrency] = row
+		}
+
+		salary := float64(c.Salary)
+		row.Cats++
+		row.SumSalary += salary
+		row.SumSalarySquares += salary * salary
+		row.SumMissions += missions
+		row.SumMissionsSquares += missions * missions
+		row.SumSalaryMissions += salary * missions
+		row.SumTargets += targets
+		row.SumTargetsSquares += targets * targets
+		row.SumSalaryTargets += salary * targets
+	}
+
+	res := make([]postgres.GetSalaryPerformanceRow, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, *row)
+	}
+	slices.SortFunc(res, func(a, b postgres.GetSalaryPerformanceRow) int {
+		return cmp.Compare(a.SalaryCurrency, b.SalaryCurrency)
+	})
+
+	return res, nil
+}
+
+//-------------------------------------
+// AVAILABILITY
+//-------------------------------------
+
+func (q *queries) CreateAvailabilityPeriod(ctx context.Context, arg postgres.CreateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	if _, ok := q.db.cats.get(int64(arg.Cat)); !ok {
+		return postgres.AvailabilityPeriod{}, foreignKeyViolation("availability_periods", "availability_periods_cat_fkey")
+	}
+
+	period := postgres.AvailabilityPeriod{
+		ID:       int32(q.db.periods.next()),
+		Cat:      arg.Cat,
+		Kind:     arg.Kind,
+		StartsAt: arg.StartsAt,
+		EndsAt:   arg.EndsAt,
+	}
+	q.db.periods.put(int64(period.ID), period)
+
+	return period, nil
+}
+
+func (q *queries) GetCatAvailabilityPeriods(ctx context.Context, cat int32) ([]postgres.AvailabilityPeriod, error) {
+	return sortPeriods(q.db.periods.filter(func(p postgres.AvailabilityPeriod) bool {
+		return p.Cat == cat
+	})), nil
+}
+
+func (q *queries) GetAvailabilityPeriod(ctx context.Context, id int32) (postgres.AvailabilityPeriod, error) {
+	period, ok := q.db.periods.get(int64(id))
+	if !ok {
+		return postgres.AvailabilityPeriod{}, pgx.ErrNoRows
+	}
+
+	return period, nil
+}
+
+func (q *queries) UpdateAvailabilityPeriod(ctx context.Context, arg postgres.UpdateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	period, ok := q.db.periods.get(int64(arg.ID))
+	if !ok {
+		return postgres.AvailabilityPeriod{}, pgx.ErrNoRows
+	}
+
+	period.Kind = arg.Kind
+	period.StartsAt = arg.StartsAt
+	period.EndsAt = arg.EndsAt
+	q.db.periods.put(int64(period.ID), period)
+
+	return period, nil
+}
+
+func (q *queries) DeleteAvailabilityPeriod(ctx context.Context, id int32) (int64, error) {
+	if !q.db.periods.delete(int64(id)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (q *queries) GetOverlappingAvailabilityPeriods(ctx context.Context, arg postgres.GetOverlappingAvailabilityPeriodsParams) ([]postgres.AvailabilityPeriod, error) {
+	return sortPeriods(q.db.periods.filter(func(p postgres.AvailabilityPeriod) bool {
+		return p.Cat == arg.Cat && p.EndsAt.Time.After(arg.Since.Time) && !p.StartsAt.Time.After(arg.Until.Time)
+	})), nil
+}
+
+func (q *queries) GetAvailableCats(ctx context.Context, at pgtype.Timestamptz) ([]postgres.Cat, error) {
+	unavailable := make(map[int32]bool)
+	for _, p := range q.db.periods.filter(func(p postgres.AvailabilityPeriod) bool {
+		return !p.StartsAt.Time.After(at.Time) && p.EndsAt.Time.After(at.Time)
+	}) {
+		unavailable[p.Cat] = true
+	}
+
+	return q.db.cats.filter(func(c postgres.Cat) bool { return !unavailable[c.ID] }), nil
+}
+
+func (q *queries) GetCatsAvailableBetween(ctx context.Context, arg postgres.GetCatsAvailableBetweenParams) ([]postgres.Cat, error) {
+	unavailable := make(map[int32]bool)
+	for _, p := range q.db.periods.filter(func(p postgres.AvailabilityPeriod) bool {
+		return p.EndsAt.Time.After(arg.Since.Time) && !p.StartsAt.Time.After(arg.Until.Time)
+	}) {
+		unavailable[p.Cat] = true
+	}
+
+	return q.db.cats.filter(func(c postgres.Cat) bool { return !unavailable[c.ID] }), nil
+}
+
+// sortPeriods sorts the periods by their start, then by ID.
+func sortPeriods(periods []postgres.AvailabilityPeriod) []postgres.AvailabilityPeriod {
+	slices.SortStableFunc(periods, func(a, b postgres.AvailabilityPeriod) int {
+		return a.StartsAt.Time.Compare(b.StartsAt.Time)
+	})
+	return periods
+}
+
+//-------------------------------------
+// SKILLS
+//-------------------------------------
+
+func (q *queries) CreateSkill(ctx context.Context, name string) (postgres.Skill, error) {
+	if len(q.db.skills.filter(func(s postgres.Skill) bool { return s.Name == name })) > 0 {
+		return postgres.Skill{}, uniqueViolation("skills", "skills_name_key")
+	}
+
+	skill := postgres.Skill{ID: int32(q.db.skills.next()), Name: name}
+	q.db.skills.put(int64(skill.ID), skill)
+
+	return skill, nil
+}
+
+func (q *queries) GetAllSkills(ctx context.Context) ([]postgres.Skill, error) {
+	skills := q.db.skills.filter(nil)
+	slices.SortStableFunc(skills, func(a, b postgres.Skill) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return skills, nil
+}
+
+func (q *queries) GetSkill(ctx context.Context, id int32) (postgres.Skill, error) {
+	skill, ok := q.db.skills.get(int64(id))
+	if !ok {
+		return postgres.Skill{}, pgx.ErrNoRows
+	}
+
+	return skill, nil
+}
+
+func (q *queries) DeleteSkill(ctx context.Context, id int32) (int64, error) {
+	if !q.db.skills.delete(int64(id)) {
+		return 0, nil
+	}
+
+	// ON DELETE CASCADE
+	for _, key := range q.db.catSkills.ids(func(cs postgres.CatSkill) bool {
+		return cs.Skill == id
+	}) {
+		q.db.catSkills.delete(key)
+	}
+	for _, key := range q.db.targetSkills.ids(func(ts postgres.TargetSkill) bool {
+		return ts.Skill == id
+	}) {
+		q.db.targetSkills.delete(key)
+	}
+
+	return 1, nil
+}
+
+func (q *queries) SetCatSkill(ctx context.Context, arg postgres.SetCatSkillParams) (postgres.CatSkill, error) {
+	if _, ok := q.db.cats.get(int64(arg.Cat)); !ok {
+		return postgres.CatSkill{}, foreignKeyViolation("cat_skills", "cat_skills_cat_fkey")
+	}
+	if _, ok := q.db.skills.get(int64(arg.Skill)); !ok {
+		return postgres.CatSkill{}, foreignKeyViolation("cat_skills", "cat_skills_skill_fkey")
+	}
+
+	cs := postgres.CatSkill{
+		Cat:            arg.Cat,
+		Skill:          arg.Skill,
+		Level:          arg.Level,
+		CertifiedUntil: arg.CertifiedUntil,
+	}
+	q.db.catSkills.put(pairKey(cs.Cat, cs.Skill), cs)
+
+	return cs, nil
+}
+
+func (q *queries) GetCatSkills(ctx context.Context, cat int32) ([]postgres.GetCatSkillsRow, error) {
+	var rows []postgres.GetCatSkillsRow
+	for _, cs := range q.db.catSkills.filter(func(cs postgres.CatSkill) bool { return cs.Cat == cat }) {
+		skill, _ := q.db.skills.get(int64(cs.Skill))
+		rows = append(rows, postgres.GetCatSkillsRow{
+			Cat:            cs.Cat,
+			Skill:          cs.Skill,
+			Level:          cs.Level,
+			CertifiedUntil: cs.CertifiedUntil,
+			Name:           skill.Name,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b postgres.GetCatSkillsRow) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return rows, nil
+}
+
+func (q *queries) DeleteCatSkill(ctx context.Context, arg postgres.DeleteCatSkillParams) (int64, error) {
+	if !q.db.catSkills.delete(pairKey(arg.Cat, arg.Skill)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (q *queries) SetTargetSkill(ctx context.Context, arg postgres.SetTargetSkillParams) (postgres.TargetSkill, error) {
+	if _, ok := q.db.targets.get(int64(arg.Target)); !ok {
+		return postgres.TargetSkill{}, foreignKeyViolation("target_skills", "target_skills_target_fkey")
+	}
+	if _, ok := q.db.skills.get(int64(arg.Skill)); !ok {
+		return postgres.TargetSkill{}, foreignKeyViolation("target_skills", "target_skills_skill_fkey")
+	}
+
+	ts := postgres.TargetSkill{
+		Target:    arg.Target,
+		Skill:     arg.Skill,
+		MinLevel:  arg.MinLevel,
+		Certified: arg.Certified,
+	}
+	q.db.targetSkills.put(pairKey(ts.Target, ts.Skill), ts)
+
+	return ts, nil
+}
+
+func (q *queries) GetTargetSkills(ctx context.Context, target int32) ([]postgres.GetTargetSkillsRow, error) {
+	var rows []postgres.GetTargetSkillsRow
+	for _, ts := range q.db.targetSkills.filter(func(ts postgres.TargetSkill) bool { return ts.Target == target }) {
+		skill, _ := q.db.skills.get(int64(ts.Skill))
+		rows = append(rows, postgres.GetTargetSkillsRow{
+			Target:    ts.Target,
+			Skill:     ts.Skill,
+			MinLevel:  ts.MinLevel,
+			Certified: ts.Certified,
+			Name:      skill.Name,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b postgres.GetTargetSkillsRow) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return rows, nil
+}
+
+func (q *queries) DeleteTargetSkill(ctx context.Context, arg postgres.DeleteTargetSkillParams) (int64, error) {
+	if !q.db.targetSkills.delete(pairKey(arg.Target, arg.Skill)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+// deleteTargetSkills deletes the required skills of a deleted target, like ON DELETE CASCADE.
+func (q *queries) deleteTargetSkills(target int32) {
+	for _, key := range q.db.targetSkills.ids(func(ts postgres.TargetSkill) bool {
+		return ts.Target == target
+	}) {
+		q.db.targetSkills.delete(key)
+	}
+}
+
+func (q *queries) GetMissionRequiredSkills(ctx context.Context, mission int32) ([]postgres.GetMissionRequiredSkillsRow, error) {
+	required := make(map[int32]*postgres.GetMissionRequiredSkillsRow)
+	var rows []*postgres.GetMissionRequiredSkillsRow
+	for _, ts := range q.db.targetSkills.filter(func(ts postgres.TargetSkill) bool {
+		t, ok := q.db.targets.get(int64(ts.Target))
+		return ok && t.Mission == mission && !t.Completed
+	}) {
+		r, ok := required[ts.Skill]
+		if !ok {
+			skill, _ := q.db.skills.get(int64(ts.Skill))
+			r = &postgres.GetMissionRequiredSkillsRow{Skill: ts.Skill, Name: skill.Name}
+			required[ts.Skill] = r
+			rows = append(rows, r)
+		}
+		r.MinLevel = max(r.MinLevel, ts.MinLevel)
+		r.Certified = r.Certified || ts.Certified
+	}
+
+	res := make([]postgres.GetMissionRequiredSkillsRow, len(rows))
+	for i, r := range rows {
+		res[i] = *r
+	}
+	slices.SortStableFunc(res, func(a, b postgres.GetMissionRequiredSkillsRow) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return res, nil
+}
+
+func (q *queries) GetUnassignedCats(ctx context.Context) ([]postgres.Cat, error) {
+	assigned := q.activeAssignees()
+	return q.db.cats.filter(func(c postgres.Cat) bool { return !assigned[c.ID] }), nil
+}
+
+func (q *queries) GetUnassignedCatSkills(ctx context.Context) ([]postgres.CatSkill, error) {
+	assigned := q.activeAssignees()
+	return q.db.catSkills.filter(func(cs postgres.CatSkill) bool { return !assigned[cs.Cat] }), nil
+}
+
+// activeAssignees returns the cats assigned to an active mission.
+func (q *queries) activeAssignees() map[int32]bool {
+	assigned := make(map[int32]bool)
+	for _, m := range q.db.missions.filter(func(m postgres.Mission) bool {
+		return m.Assignee.Valid && !m.Completed
+	}) {
+		assigned[m.Assignee.Int32] = true
+	}
+
+	return assigned
+}
+
+//-------------------------------------
+// COSTS
+//-------------------------------------
+
+func (q *queries) SetMissionBudget(ctx context.Context, arg postgres.SetMissionBudgetParams) (postgres.MissionBudget, error) {
+	if _, ok := q.db.missions.get(int64(arg.Mission)); !ok {
+		return postgres.MissionBudget{}, foreignKeyViolation("mission_budgets", "mission_budgets_mission_fkey")
+	}
+
+	budget := postgres.MissionBudget{
+		Mission:  arg.Mission,
+		Amount:   arg.Amount,
+		Currency: arg.Currency,
+	}
+	q.db.budgets.put(int64(budget.Mission), budget)
+
+	return budget, nil
+}
+
+func (q *queries) GetMissionBudget(ctx context.Context, mission int32) (postgres.MissionBudget, error) {
+	budget, ok := q.db.budgets.get(int64(mission))
+	if !ok {
+		return postgres.MissionBudget{}, pgx.ErrNoRows
+	}
+
+	return budget, nil
+}
+
+func (q *queries) DeleteMissionBudget(ctx context.Context, mission int32) (int64, error) {
+	if !q.db.budgets.delete(int64(mission)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (q *queries) CreateMissionExpense(ctx context.Context, arg postgres.CreateMissionExpenseParams) (postgres.MissionExpense, error) {
+	if _, ok := q.db.missions.get(int64(arg.Mission)); !ok {
+		return postgres.MissionExpense{}, foreignKeyViolation("mission_expenses", "mission_expenses_mission_fkey")
+	}
+
+	expense := postgres.MissionExpense{
+		ID:            int32(q.db.expenses.next()),
+		Mission:       arg.Mission,
+		Category:      arg.Category,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Description:   arg.Description,
+		ReceiptNumber: arg.ReceiptNumber,
+		ReceiptVendor: arg.ReceiptVendor,
+		ReceiptUrl:    arg.ReceiptUrl,
+		IncurredAt:    arg.IncurredAt,
+	}
+	q.db.expenses.put(int64(expense.ID), expense)
+
+	return expense, nil
+}
+
+func (q *queries) GetMissionExpenses(ctx context.Context, mission int32) ([]postgres.MissionExpense, error) {
+	expenses := q.db.expenses.filter(func(e postgres.MissionExpense) bool { return e.Mission == mission })
+	slices.SortStableFunc(expenses, func(a, b postgres.MissionExpense) int {
+		return a.IncurredAt.Time.Compare(b.IncurredAt.Time)
+	})
+
+	return expenses, nil
+}
+
+func (q *queries) GetMissionExpense(ctx context.Context, id int32) (postgres.MissionExpense, error) {
+	expense, ok := q.db.expenses.get(int64(id))
+	if !ok {
+		return postgres.MissionExpense{}, pgx.ErrNoRows
+	}
+
+	return expense, nil
+}
+
+func (q *queries) DeleteMissionExpense(ctx context.Context, id int32) (int64, error) {
+	if !q.db.expenses.delete(int64(id)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+// limit returns at most n of the rows, like LIMIT in Postgres.
+func limit[T any](rows []T, n int32) []T {
+	if n >= 0 && len(rows) > int(n) {
+		return rows[:n]
+	}
+
+	return rows
+}
+
+// The slices of the stored rows are shared by the snapshots, so the rows are
+// copied before they leave the storage.
+
+func cloneWebhook(w postgres.Webhook) postgres.Webhook {
+	w.EventTypes = slices.Clone(w.EventTypes)
+	return w
+}
+
+func cloneDelivery(d postgres.WebhookDelivery) postgres.WebhookDelivery {
+	d.Payload = slices.Clone(d.Payload)
+	return d
+}
+
+func cloneOutbox(e postgres.Outbox) postgres.Outbox {
+	e.Payload = slices.Clone(e.Payload)
+	return e
+}
+
+func cloneTemplate(t postgres.MissionTemplate) postgres.MissionTemplate {
+	t.Targets = slices.Clone(t.Targets)
+	return t
+}
+
+//-------------------------------------
+// EXCHANGE RATES
+//-------------------------------------
+
+func (q *queries) SetExchangeRate(ctx context.Context, arg postgres.SetExchangeRateParams) (postgres.ExchangeRate, error) {
+	rate := postgres.ExchangeRate{
+		Currency:  arg.Currency,
+		Rate:      arg.Rate,
+		UpdatedAt: q.timestamp(),
+	}
+	q.db.rates.put(currencyKey(rate.Currency), rate)
+
+	return rate, nil
+}
+
+func (q *queries) GetExchangeRates(ctx context.Context) ([]postgres.ExchangeRate, error) {
+	return q.db.rates.filter(nil), nil
+}
+
+func (q *queries) DeleteExchangeRate(ctx context.Context, currency string) (int64, error) {
+	if !q.db.rates.delete(currencyKey(currency)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+//-------------------------------------
+// LOCATIONS
+//-------------------------------------
+
+func (q *queries) GetTargetsNear(ctx context.Context, arg postgres.GetTargetsNearParams) ([]postgres.GetTargetsNearRow, error) {
+	var rows []postgres.GetTargetsNearRow
+	for _, t := range q.db.targets.filter(nil) {
+		if !t.Latitude.Valid || !t.Longitude.Valid {
+			continue
+		}
+		distance := geo.Distance(arg.Lat, arg.Lng, t.Latitude.Float64, t.Longitude.Float64)
+		if distance > arg.RadiusKm {
+			continue
+		}
+		rows = append(rows, postgres.GetTargetsNearRow{
+			ID:         t.ID,
+			Mission:    t.Mission,
+			Name:       t.Name,
+			Country:    t.Country,
+			Notes:      t.Notes,
+			Completed:  t.Completed,
+			Latitude:   t.Latitude,
+			Longitude:  t.Longitude,
+			Address:    t.Address,
+			DistanceKm: distance,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b postgres.GetTargetsNearRow) int {
+		return cmp.Compare(a.DistanceKm, b.DistanceKm)
+	})
+
+	return rows[:min(len(rows), int(arg.MaxRows))], nil
+}
+
+//-------------------------------------
+// SUBJECTS
+//-------------------------------------
+
+func (q *queries) CreateSubject(ctx context.Context, arg postgres.CreateSubjectParams) (postgres.Subject, error) {
+	subject := postgres.Subject{
+		ID:        int32(q.db.subjects.next()),
+		Name:      arg.Name,
+		Country:   arg.Country,
+		CreatedAt: q.timestamp(),
+	}
+	q.db.subjects.put(int64(subject.ID), subject)
+
+	return subject, nil
+}
+
+func (q *queries) GetSubject(ctx context.Context, id int32) (postgres.Subject, error) {
+	subject, ok := q.db.subjects.get(int64(id))
+	if !ok {
+		return postgres.Subject{}, pgx.ErrNoRows
+	}
+
+	return subject, nil
+}
+
+func (q *queries) GetSubjectsByIDs(ctx context.Context, ids []int32) ([]postgres.Subject, error) {
+	return q.db.subjects.filter(func(s postgres.Subject) bool {
+		return slices.Contains(ids, s.ID)
+	}), nil
+}
+
+// similarSubjectThreshold is the default threshold of the % operator of pg_trgm.
+const similarSubjectThreshold = 0.3
+
+func (q *queries) GetSimilarSubjects(ctx context.Context, arg postgres.GetSimilarSubjectsParams) ([]postgres.Subject, error) {
+	subjects := q.db.subjects.filter(func(s postgres.Subject) bool {
+		return fuzzy.Similarity(s.Name, arg.Name) >= similarSubjectThreshold
+	})
+
+	score := func(s postgres.Subject) float64 {
+		countryScore := 1.0
+		if !strings.EqualFold(s.Country, arg.Country) {
+			countryScore = fuzzy.Similarity(s.Country, arg.Country)
+		}
+		return 0.8*fuzzy.Similarity(s.Name, arg.Name) + 0.2*countryScore
+	}
+	slices.SortStableFunc(subjects, func(a, b postgres.Subject) int {
+		return cmp.Compare(score(b), score(a))
+	})
+
+	return limit(subjects, arg.MaxSubjects), nil
+}
+
+func (q *queries) DeleteSubject(ctx context.Context, id int32) (int64, error) {
+	if !q.db.subjects.delete(int64(id)) {
+		return 0, nil
+	}
+
+	// ON DELETE SET NULL
+	q.moveSubjectTargets(id, pgtype.Int4{})
+
+	return 1, nil
+}
+
+func (q *queries) GetSubjectTargets(ctx context.Context, subject pgtype.Int4) ([]postgres.Target, error) {
+	return q.db.targets.filter(func(t postgres.Target) bool {
+		return subject.Valid && t.Subject == subject
+	}), nil
+}
+
+func (q *queries) MoveSubjectTargets(ctx context.Context, arg postgres.MoveSubjectTargetsParams) (int64, error) {
+	if !arg.FromSubject.Valid {
+		return 0, nil
+	}
+	if err := q.checkSubject(arg.ToSubject); err != nil {
+		return 0, err
+	}
+
+	return q.moveSubjectTargets(arg.FromSubject.Int32, arg.ToSubject), nil
+}
+
+func (q *queries) moveSubjectTargets(from int32, to pgtype.Int4) int64 {
+	var n int64
+	for _, t := range q.db.targets.filter(func(t postgres.Target) bool {
+		return t.Subject.Valid && t.Subject.Int32 == from
+	}) {
+		t.Subject = to
+		q.db.targets.put(int64(t.ID), t)
+		n++
+	}
+
+	return n
+}
+
+// checkSubject checks the foreign key of a target to its subject.
+func (q *queries) checkSubject(subject pgtype.Int4) error {
+	if !subject.Valid {
+		return nil
+	}
+	if _, ok := q.db.subjects.get(int64(subject.Int32)); !ok {
+		return foreignKeyViolation("targets", "targets_subject_fkey")
+	}
+
+	return nil
+}
+
+func (q *queries) CreateTargetNote(ctx context.Context, arg postgres.CreateTargetNoteParams) (postgres.TargetNote, error) {
+	note := postgres.TargetNote{
+		ID:            int32(q.db.targetNotes.next()),
+		Target:        arg.Target,
+		PreviousNotes: arg.PreviousNotes,
+		Notes:         arg.Notes,
+		UpdatedAt:     q.timestamp(),
+	}
+	q.db.targetNotes.put(int64(note.ID), note)
+
+	return note, nil
+}
+
+func (q *queries) GetSubjectNotes(ctx context.Context, subject pgtype.Int4) ([]postgres.TargetNote, error) {
+	return q.db.targetNotes.filter(func(n postgres.TargetNote) bool {
+		t, ok := q.db.targets.get(int64(n.Target))
+		return ok && subject.Valid && t.Subject == subject
+	}), nil
+}
+
+//-------------------------------------
+// ATTACHMENTS
+//-------------------------------------
+
+func (q *queries) CreateAttachment(ctx context.Context, arg postgres.CreateAttachmentParams) (postgres.Attachment, error) {
+	if _, ok := q.db.targets.get(int64(arg.Target)); !ok {
+		return postgres.Attachment{}, foreignKeyViolation("attachments", "attachments_target_fkey")
+	}
+	for _, a := range q.db.attachments.rows {
+		if a.BlobKey == arg.BlobKey {
+			return postgres.Attachment{}, uniqueViolation("attachments", "attachments_blob_key_key")
+		}
+	}
+
+	attachment := postgres.Attachment{
+		ID:          int32(q.db.attachments.next()),
+		Target:      arg.Target,
+		Filename:    arg.Filename,
+		ContentType: arg.ContentType,
+		Size:        arg.Size,
+		BlobKey:     arg.BlobKey,
+		CreatedAt:   q.timestamp(),
+	}
+	q.db.attachments.put(int64(attachment.ID), attachment)
+
+	return attachment, nil
+}
+
+func (q *queries) GetAttachment(ctx context.Context, id int32) (postgres.Attachment, error) {
+	attachment, ok := q.db.attachments.get(int64(id))
+	if !ok {
+		return postgres.Attachment{}, pgx.ErrNoRows
+	}
+
+	return attachment, nil
+}
+
+func (q *queries) GetTargetAttachments(ctx context.Context, target int32) ([]postgres.Attachment, error) {
+	return q.db.attachments.filter(func(a postgres.Attachment) bool {
+		return a.Target == target
+	}), nil
+}
+
+func (q *queries) GetMissionAttachments(ctx context.Context, mission int32) ([]postgres.Attachment, error) {
+	return q.db.attachments.filter(func(a postgres.Attachment) bool {
+		t, ok := q.db.targets.get(int64(a.Target))
+		return ok && t.Mission == mission
+	}), nil
+}
+
+func (q *queries) DeleteAttachment(ctx context.Context, id int32) (int64, error) {
+	if !q.db.attachments.delete(int64(id)) {
+		return 0, nil
+	}
+
+	return 1, nil
+}
+
+func (q *queries) deleteTargetAttachments(target int32) {
+	for _, id := range q.db.attachments.ids(func(a postgres.Attachment) bool {
+		return a.Target == target
+	}) {
+		q.db.attachments.delete(id)
+	}
+}
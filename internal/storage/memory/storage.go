@@ -0,0 +1,692 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// Reads run against the last committed snapshot, writes in a transaction of their own.
+
+func (s *Storage) AssignCat(ctx context.Context, arg postgres.AssignCatParams) (postgres.Mission, error) {
+	return update(ctx, s, func(q *queries) (postgres.Mission, error) {
+		return q.AssignCat(ctx, arg)
+	})
+}
+
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, arg postgres.ClaimWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	return update(ctx, s, func(q *queries) ([]postgres.WebhookDelivery, error) {
+		return q.ClaimWebhookDeliveries(ctx, arg)
+	})
+}
+
+func (s *Storage) CompleteMission(ctx context.Context, id int32) (postgres.Mission, error) {
+	return update(ctx, s, func(q *queries) (postgres.Mission, error) {
+		return q.CompleteMission(ctx, id)
+	})
+}
+
+func (s *Storage) CompleteTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	return update(ctx, s, func(q *queries) (postgres.Target, error) {
+		return q.CompleteTarget(ctx, id)
+	})
+}
+
+func (s *Storage) CreateCat(ctx context.Context, arg postgres.CreateCatParams) (postgres.Cat, error) {
+	return update(ctx, s, func(q *queries) (postgres.Cat, error) {
+		return q.CreateCat(ctx, arg)
+	})
+}
+
+func (s *Storage) CreateMission(ctx context.Context) (postgres.Mission, error) {
+	return update(ctx, s, func(q *queries) (postgres.Mission, error) {
+		return q.CreateMission(ctx)
+	})
+}
+
+func (s *Storage) CreateMissionAssignment(ctx context.Context, arg postgres.CreateMissionAssignmentParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.CreateMissionAssignment(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) CreateOutboxEvent(ctx context.Context, arg postgres.CreateOutboxEventParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.CreateOutboxEvent(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) CreateTarget(ctx context.Context, arg postgres.CreateTargetParams) (postgres.Target, error) {
+	return update(ctx, s, func(q *queries) (postgres.Target, error) {
+		return q.CreateTarget(ctx, arg)
+	})
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, arg postgres.CreateWebhookParams) (postgres.Webhook, error) {
+	return update(ctx, s, func(q *queries) (postgres.Webhook, error) {
+		return q.CreateWebhook(ctx, arg)
+	})
+}
+
+func (s *Storage) DeleteCat(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteCat(ctx, id)
+	})
+}
+
+func (s *Storage) DeleteMission(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteMission(ctx, id)
+	})
+}
+
+func (s *Storage) DeleteTarget(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteTarget(ctx, id)
+	})
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteWebhook(ctx, id)
+	})
+}
+
+func (s *Storage) EndMissionAssignment(ctx context.Context, mission int32) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.EndMissionAssignment(ctx, mission)
+	})
+	return err
+}
+
+func (s *Storage) EnqueueWebhookDeliveries(ctx context.Context, arg postgres.EnqueueWebhookDeliveriesParams) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.EnqueueWebhookDeliveries(ctx, arg)
+	})
+}
+
+func (s *Storage) GetAllCats(ctx context.Context) ([]postgres.Cat, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Cat, error) {
+		return q.GetAllCats(ctx)
+	})
+}
+
+func (s *Storage) GetAllMissions(ctx context.Context) ([]postgres.Mission, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Mission, error) {
+		return q.GetAllMissions(ctx)
+	})
+}
+
+func (s *Storage) GetAllWebhooks(ctx context.Context) ([]postgres.Webhook, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Webhook, error) {
+		return q.GetAllWebhooks(ctx)
+	})
+}
+
+func (s *Storage) GetCat(ctx context.Context, id int32) (postgres.Cat, error) {
+	return view(ctx, s, func(q *queries) (postgres.Cat, error) {
+		return q.GetCat(ctx, id)
+	})
+}
+
+func (s *Storage) GetCatMission(ctx context.Context, assignee pgtype.Int4) (postgres.Mission, error) {
+	return view(ctx, s, func(q *queries) (postgres.Mission, error) {
+		return q.GetCatMission(ctx, assignee)
+	})
+}
+
+func (s *Storage) GetMission(ctx context.Context, id int32) (postgres.Mission, error) {
+	return view(ctx, s, func(q *queries) (postgres.Mission, error) {
+		return q.GetMission(ctx, id)
+	})
+}
+
+func (s *Storage) GetMissionForUpdate(ctx context.Context, id int32) (postgres.Mission, error) {
+	return view(ctx, s, func(q *queries) (postgres.Mission, error) {
+		return q.GetMissionForUpdate(ctx, id)
+	})
+}
+
+func (s *Storage) GetMissionByTargetID(ctx context.Context, id int32) (postgres.GetMissionByTargetIDRow, error) {
+	return view(ctx, s, func(q *queries) (postgres.GetMissionByTargetIDRow, error) {
+		return q.GetMissionByTargetID(ctx, id)
+	})
+}
+
+func (s *Storage) GetMissionTargets(ctx context.Context, mission int32) ([]postgres.Target, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Target, error) {
+		return q.GetMissionTargets(ctx, mission)
+	})
+}
+
+func (s *Storage) GetOutboxEvents(ctx context.Context, arg postgres.GetOutboxEventsParams) ([]postgres.Outbox, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Outbox, error) {
+		return q.GetOutboxEvents(ctx, arg)
+	})
+}
+
+func (s *Storage) GetTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	return view(ctx, s, func(q *queries) (postgres.Target, error) {
+		return q.GetTarget(ctx, id)
+	})
+}
+
+func (s *Storage) GetUnpublishedOutboxEvents(ctx context.Context, arg postgres.GetUnpublishedOutboxEventsParams) ([]postgres.Outbox, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Outbox, error) {
+		return q.GetUnpublishedOutboxEvents(ctx, arg)
+	})
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, id int32) (postgres.Webhook, error) {
+	return view(ctx, s, func(q *queries) (postgres.Webhook, error) {
+		return q.GetWebhook(ctx, id)
+	})
+}
+
+func (s *Storage) GetWebhookDeliveries(ctx context.Context, arg postgres.GetWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.WebhookDelivery, error) {
+		return q.GetWebhookDeliveries(ctx, arg)
+	})
+}
+
+func (s *Storage) MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.MarkOutboxEventsPublished(ctx, ids)
+	})
+}
+
+func (s *Storage) MarkWebhookDeliveryFailed(ctx context.Context, arg postgres.MarkWebhookDeliveryFailedParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.MarkWebhookDeliveryFailed(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) MarkWebhookDeliverySucceeded(ctx context.Context, arg postgres.MarkWebhookDeliverySucceededParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.MarkWebhookDeliverySucceeded(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) MoveTarget(ctx context.Context, arg postgres.MoveTargetParams) (postgres.Target, error) {
+	return update(ctx, s, func(q *queries) (postgres.Target, error) {
+		return q.MoveTarget(ctx, arg)
+	})
+}
+
+func (s *Storage) RedeliverWebhookDelivery(ctx context.Context, arg postgres.RedeliverWebhookDeliveryParams) (postgres.WebhookDelivery, error) {
+	return update(ctx, s, func(q *queries) (postgres.WebhookDelivery, error) {
+		return q.RedeliverWebhookDelivery(ctx, arg)
+	})
+}
+
+func (s *Storage) ResetOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.ResetOutboxEvents(ctx, fromOffset)
+	})
+}
+
+func (s *Storage) SearchTargets(ctx context.Context, arg postgres.SearchTargetsParams) ([]postgres.SearchTargetsRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.SearchTargetsRow, error) {
+		return q.SearchTargets(ctx, arg)
+	})
+}
+
+func (s *Storage) UpdateCatSalary(ctx context.Context, arg postgres.UpdateCatSalaryParams) (postgres.Cat, error) {
+	return update(ctx, s, func(q *queries) (postgres.Cat, error) {
+		return q.UpdateCatSalary(ctx, arg)
+	})
+}
+
+func (s *Storage) UpdateTargetNotes(ctx context.Context, arg postgres.UpdateTargetNotesParams) (postgres.Target, error) {
+	return update(ctx, s, func(q *queries) (postgres.Target, error) {
+		return q.UpdateTargetNotes(ctx, arg)
+	})
+}
+
+func (s *Storage) UpdateTargetLocation(ctx context.Context, arg postgres.UpdateTargetLocationParams) (postgres.Target, error) {
+	return update(ctx, s, func(q *queries) (postgres.Target, error) {
+		return q.UpdateTargetLocation(ctx, arg)
+	})
+}
+
+func (s *Storage) GetTargetsNear(ctx context.Context, arg postgres.GetTargetsNearParams) ([]postgres.GetTargetsNearRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetTargetsNearRow, error) {
+		return q.GetTargetsNear(ctx, arg)
+	})
+}
+
+func (s *Storage) UpdateWebhook(ctx context.Context, arg postgres.UpdateWebhookParams) (postgres.Webhook, error) {
+	return update(ctx, s, func(q *queries) (postgres.Webhook, error) {
+		return q.UpdateWebhook(ctx, arg)
+	})
+}
+
+func (s *Storage) CreateMissionTemplate(ctx context.Context, arg postgres.CreateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	return update(ctx, s, func(q *queries) (postgres.MissionTemplate, error) {
+		return q.CreateMissionTemplate(ctx, arg)
+	})
+}
+
+func (s *Storage) GetAllMissionTemplates(ctx context.Context) ([]postgres.MissionTemplate, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.MissionTemplate, error) {
+		return q.GetAllMissionTemplates(ctx)
+	})
+}
+
+func (s *Storage) GetMissionTemplate(ctx context.Context, id int32) (postgres.MissionTemplate, error) {
+	return view(ctx, s, func(q *queries) (postgres.MissionTemplate, error) {
+		return q.GetMissionTemplate(ctx, id)
+	})
+}
+
+func (s *Storage) UpdateMissionTemplate(ctx context.Context, arg postgres.UpdateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	return update(ctx, s, func(q *queries) (postgres.MissionTemplate, error) {
+		return q.UpdateMissionTemplate(ctx, arg)
+	})
+}
+
+func (s *Storage) DeleteMissionTemplate(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteMissionTemplate(ctx, id)
+	})
+}
+
+func (s *Storage) CreateTargetMove(ctx context.Context, arg postgres.CreateTargetMoveParams) (postgres.TargetMove, error) {
+	return update(ctx, s, func(q *queries) (postgres.TargetMove, error) {
+		return q.CreateTargetMove(ctx, arg)
+	})
+}
+
+func (s *Storage) GetTargetMoves(ctx context.Context, target int32) ([]postgres.TargetMove, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.TargetMove, error) {
+		return q.GetTargetMoves(ctx, target)
+	})
+}
+
+func (s *Storage) GetAllTargets(ctx context.Context) ([]postgres.Target, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Target, error) {
+		return q.GetAllTargets(ctx)
+	})
+}
+
+func (s *Storage) RestoreCat(ctx context.Context, arg postgres.RestoreCatParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.RestoreCat(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) RestoreMission(ctx context.Context, arg postgres.RestoreMissionParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.RestoreMission(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) GetAllSubjects(ctx context.Context) ([]postgres.Subject, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Subject, error) {
+		return q.GetAllSubjects(ctx)
+	})
+}
+
+func (s *Storage) GetSubjectsPage(ctx context.Context, arg postgres.GetSubjectsPageParams) ([]postgres.Subject, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Subject, error) {
+		return q.GetSubjectsPage(ctx, arg)
+	})
+}
+
+func (s *Storage) GetTargetsPage(ctx context.Context, arg postgres.GetTargetsPageParams) ([]postgres.Target, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Target, error) {
+		return q.GetTargetsPage(ctx, arg)
+	})
+}
+
+func (s *Storage) RestoreSubject(ctx context.Context, arg postgres.RestoreSubjectParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.RestoreSubject(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) RestoreTarget(ctx context.Context, arg postgres.RestoreTargetParams) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.RestoreTarget(ctx, arg)
+	})
+	return err
+}
+
+func (s *Storage) ResetSequences(ctx context.Context) error {
+	_, err := update(ctx, s, func(q *queries) (struct{}, error) {
+		return struct{}{}, q.ResetSequences(ctx)
+	})
+	return err
+}
+
+func (s *Storage) GetCatsPage(ctx context.Context, arg postgres.GetCatsPageParams) ([]postgres.Cat, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Cat, error) {
+		return q.GetCatsPage(ctx, arg)
+	})
+}
+
+func (s *Storage) GetMissionsPage(ctx context.Context, arg postgres.GetMissionsPageParams) ([]postgres.Mission, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Mission, error) {
+		return q.GetMissionsPage(ctx, arg)
+	})
+}
+
+func (s *Storage) GetMissionTargetsPage(ctx context.Context, arg postgres.GetMissionTargetsPageParams) ([]postgres.GetMissionTargetsPageRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetMissionTargetsPageRow, error) {
+		return q.GetMissionTargetsPage(ctx, arg)
+	})
+}
+
+func (s *Storage) GetCatWorkloadPage(ctx context.Context, arg postgres.GetCatWorkloadPageParams) ([]postgres.GetCatWorkloadPageRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetCatWorkloadPageRow, error) {
+		return q.GetCatWorkloadPage(ctx, arg)
+	})
+}
+
+func (s *Storage) GetMissionCompletionRate(ctx context.Context, arg postgres.GetMissionCompletionRateParams) ([]postgres.GetMissionCompletionRateRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetMissionCompletionRateRow, error) {
+		return q.GetMissionCompletionRate(ctx, arg)
+	})
+}
+
+func (s *Storage) GetCompletionTimes(ctx context.Context) (postgres.GetCompletionTimesRow, error) {
+	return view(ctx, s, func(q *queries) (postgres.GetCompletionTimesRow, error) {
+		return q.GetCompletionTimes(ctx)
+	})
+}
+
+func (s *Storage) GetCatUtilization(ctx context.Context, now pgtype.Timestamptz) ([]postgres.GetCatUtilizationRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetCatUtilizationRow, error) {
+		return q.GetCatUtilization(ctx, now)
+	})
+}
+
+func (s *Storage) GetTargetsPerCountry(ctx context.Context) ([]postgres.GetTargetsPerCountryRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetTargetsPerCountryRow, error) {
+		return q.GetTargetsPerCountry(ctx)
+	})
+}
+
+func (s *Storage) GetSalaryPerformance(ctx context.Context) ([]postgres.GetSalaryPerformanceRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetSalaryPerformanceRow, error) {
+		return q.GetSalaryPerformance(ctx)
+	})
+}
+
+func (s *Storage) CreateAvailabilityPeriod(ctx context.Context, arg postgres.CreateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	return update(ctx, s, func(q *queries) (postgres.AvailabilityPeriod, error) {
+		return q.CreateAvailabilityPeriod(ctx, arg)
+	})
+}
+
+func (s *Storage) GetCatAvailabilityPeriods(ctx context.Context, cat int32) ([]postgres.AvailabilityPeriod, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.AvailabilityPeriod, error) {
+		return q.GetCatAvailabilityPeriods(ctx, cat)
+	})
+}
+
+func (s *Storage) GetAvailabilityPeriod(ctx context.Context, id int32) (postgres.AvailabilityPeriod, error) {
+	return view(ctx, s, func(q *queries) (postgres.AvailabilityPeriod, error) {
+		return q.GetAvailabilityPeriod(ctx, id)
+	})
+}
+
+func (s *Storage) UpdateAvailabilityPeriod(ctx context.Context, arg postgres.UpdateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	return update(ctx, s, func(q *queries) (postgres.AvailabilityPeriod, error) {
+		return q.UpdateAvailabilityPeriod(ctx, arg)
+	})
+}
+
+func (s *Storage) DeleteAvailabilityPeriod(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteAvailabilityPeriod(ctx, id)
+	})
+}
+
+func (s *Storage) GetOverlappingAvailabilityPeriods(ctx context.Context, arg postgres.GetOverlappingAvailabilityPeriodsParams) ([]postgres.AvailabilityPeriod, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.AvailabilityPeriod, error) {
+		return q.GetOverlappingAvailabilityPeriods(ctx, arg)
+	})
+}
+
+func (s *Storage) GetAvailableCats(ctx context.Context, at pgtype.Timestamptz) ([]postgres.Cat, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Cat, error) {
+		return q.GetAvailableCats(ctx, at)
+	})
+}
+
+func (s *Storage) GetCatsAvailableBetween(ctx context.Context, arg postgres.GetCatsAvailableBetweenParams) ([]postgres.Cat, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Cat, error) {
+		return q.GetCatsAvailableBetween(ctx, arg)
+	})
+}
+
+func (s *Storage) CreateSkill(ctx context.Context, name string) (postgres.Skill, error) {
+	return update(ctx, s, func(q *queries) (postgres.Skill, error) {
+		return q.CreateSkill(ctx, name)
+	})
+}
+
+func (s *Storage) GetAllSkills(ctx context.Context) ([]postgres.Skill, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Skill, error) {
+		return q.GetAllSkills(ctx)
+	})
+}
+
+func (s *Storage) GetSkill(ctx context.Context, id int32) (postgres.Skill, error) {
+	return view(ctx, s, func(q *queries) (postgres.Skill, error) {
+		return q.GetSkill(ctx, id)
+	})
+}
+
+func (s *Storage) DeleteSkill(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteSkill(ctx, id)
+	})
+}
+
+func (s *Storage) SetCatSkill(ctx context.Context, arg postgres.SetCatSkillParams) (postgres.CatSkill, error) {
+	return update(ctx, s, func(q *queries) (postgres.CatSkill, error) {
+		return q.SetCatSkill(ctx, arg)
+	})
+}
+
+func (s *Storage) GetCatSkills(ctx context.Context, cat int32) ([]postgres.GetCatSkillsRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetCatSkillsRow, error) {
+		return q.GetCatSkills(ctx, cat)
+	})
+}
+
+func (s *Storage) DeleteCatSkill(ctx context.Context, arg postgres.DeleteCatSkillParams) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteCatSkill(ctx, arg)
+	})
+}
+
+func (s *Storage) SetTargetSkill(ctx context.Context, arg postgres.SetTargetSkillParams) (postgres.TargetSkill, error) {
+	return update(ctx, s, func(q *queries) (postgres.TargetSkill, error) {
+		return q.SetTargetSkill(ctx, arg)
+	})
+}
+
+func (s *Storage) GetTargetSkills(ctx context.Context, target int32) ([]postgres.GetTargetSkillsRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetTargetSkillsRow, error) {
+		return q.GetTargetSkills(ctx, target)
+	})
+}
+
+func (s *Storage) DeleteTargetSkill(ctx context.Context, arg postgres.DeleteTargetSkillParams) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteTargetSkill(ctx, arg)
+	})
+}
+
+func (s *Storage) GetMissionRequiredSkills(ctx context.Context, mission int32) ([]postgres.GetMissionRequiredSkillsRow, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.GetMissionRequiredSkillsRow, error) {
+		return q.GetMissionRequiredSkills(ctx, mission)
+	})
+}
+
+func (s *Storage) GetUnassignedCats(ctx context.Context) ([]postgres.Cat, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Cat, error) {
+		return q.GetUnassignedCats(ctx)
+	})
+}
+
+func (s *Storage) GetUnassignedCatSkills(ctx context.Context) ([]postgres.CatSkill, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.CatSkill, error) {
+		return q.GetUnassignedCatSkills(ctx)
+	})
+}
+
+func (s *Storage) SetMissionBudget(ctx context.Context, arg postgres.SetMissionBudgetParams) (postgres.MissionBudget, error) {
+	return update(ctx, s, func(q *queries) (postgres.MissionBudget, error) {
+		return q.SetMissionBudget(ctx, arg)
+	})
+}
+
+func (s *Storage) GetMissionBudget(ctx context.Context, mission int32) (postgres.MissionBudget, error) {
+	return view(ctx, s, func(q *queries) (postgres.MissionBudget, error) {
+		return q.GetMissionBudget(ctx, mission)
+	})
+}
+
+func (s *Storage) DeleteMissionBudget(ctx context.Context, mission int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteMissionBudget(ctx, mission)
+	})
+}
+
+func (s *Storage) CreateMissionExpense(ctx context.Context, arg postgres.CreateMissionExpenseParams) (postgres.MissionExpense, error) {
+	return update(ctx, s, func(q *queries) (postgres.MissionExpense, error) {
+		return q.CreateMissionExpense(ctx, arg)
+	})
+}
+
+func (s *Storage) GetMissionExpenses(ctx context.Context, mission int32) ([]postgres.MissionExpense, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.MissionExpense, error) {
+		return q.GetMissionExpenses(ctx, mission)
+	})
+}
+
+func (s *Storage) GetMissionExpense(ctx context.Context, id int32) (postgres.MissionExpense, error) {
+	return view(ctx, s, func(q *queries) (postgres.MissionExpense, error) {
+		return q.GetMissionExpense(ctx, id)
+	})
+}
+
+func (s *Storage) DeleteMissionExpense(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteMissionExpense(ctx, id)
+	})
+}
+
+func (s *Storage) SetExchangeRate(ctx context.Context, arg postgres.SetExchangeRateParams) (postgres.ExchangeRate, error) {
+	return update(ctx, s, func(q *queries) (postgres.ExchangeRate, error) {
+		return q.SetExchangeRate(ctx, arg)
+	})
+}
+
+func (s *Storage) GetExchangeRates(ctx context.Context) ([]postgres.ExchangeRate, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.ExchangeRate, error) {
+		return q.GetExchangeRates(ctx)
+	})
+}
+
+func (s *Storage) DeleteExchangeRate(ctx context.Context, currency string) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteExchangeRate(ctx, currency)
+	})
+}
+
+func (s *Storage) CreateSubject(ctx context.Context, arg postgres.CreateSubjectParams) (postgres.Subject, error) {
+	return update(ctx, s, func(q *queries) (postgres.Subject, error) {
+		return q.CreateSubject(ctx, arg)
+	})
+}
+
+func (s *Storage) GetSubject(ctx context.Context, id int32) (postgres.Subject, error) {
+	return view(ctx, s, func(q *queries) (postgres.Subject, error) {
+		return q.GetSubject(ctx, id)
+	})
+}
+
+func (s *Storage) GetSubjectsByIDs(ctx context.Context, ids []int32) ([]postgres.Subject, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Subject, error) {
+		return q.GetSubjectsByIDs(ctx, ids)
+	})
+}
+
+func (s *Storage) GetSimilarSubjects(ctx context.Context, arg postgres.GetSimilarSubjectsParams) ([]postgres.Subject, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Subject, error) {
+		return q.GetSimilarSubjects(ctx, arg)
+	})
+}
+
+func (s *Storage) DeleteSubject(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteSubject(ctx, id)
+	})
+}
+
+func (s *Storage) GetSubjectTargets(ctx context.Context, subject pgtype.Int4) ([]postgres.Target, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Target, error) {
+		return q.GetSubjectTargets(ctx, subject)
+	})
+}
+
+func (s *Storage) MoveSubjectTargets(ctx context.Context, arg postgres.MoveSubjectTargetsParams) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.MoveSubjectTargets(ctx, arg)
+	})
+}
+
+func (s *Storage) CreateTargetNote(ctx context.Context, arg postgres.CreateTargetNoteParams) (postgres.TargetNote, error) {
+	return update(ctx, s, func(q *queries) (postgres.TargetNote, error) {
+		return q.CreateTargetNote(ctx, arg)
+	})
+}
+
+func (s *Storage) GetSubjectNotes(ctx context.Context, subject pgtype.Int4) ([]postgres.TargetNote, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.TargetNote, error) {
+		return q.GetSubjectNotes(ctx, subject)
+	})
+}
+
+func (s *Storage) CreateAttachment(ctx context.Context, arg postgres.CreateAttachmentParams) (postgres.Attachment, error) {
+	return update(ctx, s, func(q *queries) (postgres.Attachment, error) {
+		return q.CreateAttachment(ctx, arg)
+	})
+}
+
+func (s *Storage) GetAttachment(ctx context.Context, id int32) (postgres.Attachment, error) {
+	return view(ctx, s, func(q *queries) (postgres.Attachment, error) {
+		return q.GetAttachment(ctx, id)
+	})
+}
+
+func (s *Storage) GetTargetAttachments(ctx context.Context, target int32) ([]postgres.Attachment, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Attachment, error) {
+		return q.GetTargetAttachments(ctx, target)
+	})
+}
+
+func (s *Storage) GetMissionAttachments(ctx context.Context, mission int32) ([]postgres.Attachment, error) {
+	return view(ctx, s, func(q *queries) ([]postgres.Attachment, error) {
+		return q.GetMissionAttachments(ctx, mission)
+	})
+}
+
+func (s *Storage) DeleteAttachment(ctx context.Context, id int32) (int64, error) {
+	return update(ctx, s, func(q *queries) (int64, error) {
+		return q.DeleteAttachment(ctx, id)
+	})
+}
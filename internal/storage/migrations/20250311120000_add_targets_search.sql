@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The generated column is recomputed by Postgres whenever a target is created or its notes are updated.
+ALTER TABLE targets
+  ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', name), 'A') ||
+    setweight(to_tsvector('simple', country), 'B') ||
+    setweight(to_tsvector('simple', notes), 'C')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS targets_search_vector_idx ON targets USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS targets_search_vector_idx;
+ALTER TABLE targets DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
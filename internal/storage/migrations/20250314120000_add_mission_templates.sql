@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS mission_templates (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  targets JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mission_templates;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The audit log of targets moved between missions. It has no foreign keys, so
+-- the records outlive the targets and the missions.
+CREATE TABLE IF NOT EXISTS target_moves (
+  id SERIAL PRIMARY KEY,
+  target INTEGER NOT NULL,
+  from_mission INTEGER NOT NULL,
+  to_mission INTEGER NOT NULL,
+  moved_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS target_moves_target_idx ON target_moves (target);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS target_moves;
+-- +goose StatementEnd
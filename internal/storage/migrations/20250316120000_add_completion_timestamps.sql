@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The timestamps of the analytics. The missions and the targets created
+-- before them count as created by the migration, and their past assignments
+-- and completions have no timestamps.
+ALTER TABLE missions
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ DEFAULT NULL;
+
+ALTER TABLE targets
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ DEFAULT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE targets
+  DROP COLUMN IF EXISTS created_at,
+  DROP COLUMN IF EXISTS completed_at;
+
+ALTER TABLE missions
+  DROP COLUMN IF EXISTS created_at,
+  DROP COLUMN IF EXISTS assigned_at,
+  DROP COLUMN IF EXISTS completed_at;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The periods a cat is unavailable for missions, from starts_at up to ends_at.
+CREATE TABLE IF NOT EXISTS availability_periods (
+  id SERIAL PRIMARY KEY,
+  cat INTEGER NOT NULL REFERENCES cats(id) ON DELETE CASCADE,
+  kind VARCHAR(16) NOT NULL,
+  starts_at TIMESTAMPTZ NOT NULL,
+  ends_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS availability_periods_cat_idx ON availability_periods (cat, starts_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS availability_periods;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS skills (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(64) NOT NULL UNIQUE
+);
+
+-- The skills of the cats, from level 1 to 5. A certification is valid until
+-- certified_until.
+CREATE TABLE IF NOT EXISTS cat_skills (
+  cat INTEGER NOT NULL REFERENCES cats(id) ON DELETE CASCADE,
+  skill INTEGER NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+  level INTEGER NOT NULL,
+  certified_until TIMESTAMPTZ DEFAULT NULL,
+  PRIMARY KEY (cat, skill)
+);
+
+-- The skills required by the targets, at least at min_level and, if certified,
+-- with a valid certification.
+CREATE TABLE IF NOT EXISTS target_skills (
+  target INTEGER NOT NULL REFERENCES targets(id) ON DELETE CASCADE,
+  skill INTEGER NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+  min_level INTEGER NOT NULL,
+  certified BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (target, skill)
+);
+
+CREATE INDEX IF NOT EXISTS cat_skills_skill_idx ON cat_skills (skill);
+CREATE INDEX IF NOT EXISTS target_skills_skill_idx ON target_skills (skill);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS target_skills;
+DROP TABLE IF EXISTS cat_skills;
+DROP TABLE IF EXISTS skills;
+-- +goose StatementEnd
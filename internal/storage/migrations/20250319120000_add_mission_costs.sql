@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The budgets of the missions. The amounts are in the minor units of the
+-- currency, like cents.
+CREATE TABLE IF NOT EXISTS mission_budgets (
+  mission INTEGER PRIMARY KEY REFERENCES missions(id) ON DELETE CASCADE,
+  amount BIGINT NOT NULL,
+  currency CHAR(3) NOT NULL
+);
+
+-- The expenses of the missions, with the metadata of their receipts.
+CREATE TABLE IF NOT EXISTS mission_expenses (
+  id SERIAL PRIMARY KEY,
+  mission INTEGER NOT NULL REFERENCES missions(id) ON DELETE CASCADE,
+  category VARCHAR(16) NOT NULL,
+  amount BIGINT NOT NULL,
+  currency CHAR(3) NOT NULL,
+  description VARCHAR(256) NOT NULL DEFAULT '',
+  receipt_number VARCHAR(64) NOT NULL DEFAULT '',
+  receipt_vendor VARCHAR(128) NOT NULL DEFAULT '',
+  receipt_url VARCHAR(2048) NOT NULL DEFAULT '',
+  incurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS mission_expenses_mission_idx ON mission_expenses (mission, incurred_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mission_expenses;
+DROP TABLE IF EXISTS mission_budgets;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The salaries of the cats are in the minor units of their currency, like
+-- cents. The salaries before them were in whole US dollars. The column is
+-- widened first, so the salaries over 21 million dollars fit.
+ALTER TABLE cats ADD COLUMN salary_currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE cats ALTER COLUMN salary TYPE BIGINT;
+UPDATE cats SET salary = salary * 100;
+
+-- The exchange rates to the reporting currency: the value of a major unit of
+-- the currency in the reporting currency.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  currency CHAR(3) PRIMARY KEY,
+  rate DOUBLE PRECISION NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exchange_rates;
+
+UPDATE cats SET salary = salary / 100;
+ALTER TABLE cats ALTER COLUMN salary TYPE INT;
+ALTER TABLE cats DROP COLUMN salary_currency;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The optional coordinates of the targets, in degrees, and their address.
+-- The proximity queries prefilter the targets on the latitude.
+ALTER TABLE targets
+  ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION DEFAULT NULL,
+  ADD COLUMN IF NOT EXISTS address VARCHAR(256) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS targets_latitude_idx ON targets (latitude) WHERE latitude IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS targets_latitude_idx;
+
+ALTER TABLE targets
+  DROP COLUMN IF EXISTS latitude,
+  DROP COLUMN IF EXISTS longitude,
+  DROP COLUMN IF EXISTS address;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The subjects are the people behind the targets, which may show up in
+-- several missions. The existing targets get a subject per name and country.
+CREATE TABLE IF NOT EXISTS subjects (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(30) NOT NULL,
+  country VARCHAR(30) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE targets ADD COLUMN IF NOT EXISTS subject INTEGER DEFAULT NULL REFERENCES subjects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS targets_subject_idx ON targets (subject);
+
+INSERT INTO subjects (name, country)
+SELECT name, country
+FROM targets
+GROUP BY name, country
+ORDER BY MIN(id);
+
+UPDATE targets t
+SET subject = s.id
+FROM subjects s
+WHERE s.name = t.name AND s.country = t.country;
+
+-- The audit log of the notes of the targets. Like the moves, it has no
+-- foreign keys.
+CREATE TABLE IF NOT EXISTS target_notes (
+  id SERIAL PRIMARY KEY,
+  target INTEGER NOT NULL,
+  previous_notes TEXT NOT NULL,
+  notes TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS target_notes_target_idx ON target_notes (target);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS target_notes;
+
+DROP INDEX IF EXISTS targets_subject_idx;
+
+ALTER TABLE targets DROP COLUMN IF EXISTS subject;
+
+DROP TABLE IF EXISTS subjects;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The files attached to the targets. The content lives in the blob store under
+-- blob_key, only the metadata is kept here.
+CREATE TABLE IF NOT EXISTS attachments (
+  id SERIAL PRIMARY KEY,
+  target INTEGER NOT NULL REFERENCES targets(id) ON DELETE CASCADE,
+  filename VARCHAR(256) NOT NULL,
+  content_type VARCHAR(128) NOT NULL,
+  size BIGINT NOT NULL,
+  blob_key VARCHAR(256) NOT NULL UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS attachments_target_idx ON attachments (target);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS attachments;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The notes are Markdown, limited by the rules instead of the column. The
+-- search vector is generated from the notes, so it's recreated around the
+-- change of their type. The existing notes are kept as they are: plain text
+-- is valid Markdown.
+DROP INDEX IF EXISTS targets_search_vector_idx;
+ALTER TABLE targets DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE targets ALTER COLUMN notes TYPE TEXT;
+
+ALTER TABLE targets
+  ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', name), 'A') ||
+    setweight(to_tsvector('simple', country), 'B') ||
+    setweight(to_tsvector('simple', notes), 'C')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS targets_search_vector_idx ON targets USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Longer notes are truncated.
+DROP INDEX IF EXISTS targets_search_vector_idx;
+ALTER TABLE targets DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE targets ALTER COLUMN notes TYPE VARCHAR(256) USING left(notes, 256);
+
+ALTER TABLE targets
+  ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', name), 'A') ||
+    setweight(to_tsvector('simple', country), 'B') ||
+    setweight(to_tsvector('simple', notes), 'C')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS targets_search_vector_idx ON targets USING GIN (search_vector);
+-- +goose StatementEnd
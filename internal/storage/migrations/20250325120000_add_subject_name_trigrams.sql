@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The subjects similar to a new target are looked up by the trigrams of
+-- their names.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS subjects_name_trgm_idx ON subjects USING GIN (name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS subjects_name_trgm_idx;
+-- +goose StatementEnd
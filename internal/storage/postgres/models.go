@@ -8,25 +8,169 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Attachment struct {
+	ID          int32
+	Target      int32
+	Filename    string
+	ContentType string
+	Size        int64
+	BlobKey     string
+	CreatedAt   pgtype.Timestamptz
+}
+
+type AvailabilityPeriod struct {
+	ID       int32
+	Cat      int32
+	Kind     string
+	StartsAt pgtype.Timestamptz
+	EndsAt   pgtype.Timestamptz
+}
+
 type Cat struct {
 	ID                int32
 	Name              string
 	YearsOfExperience int32
 	Breed             string
-	Salary            int32
+	Salary            int64
+	SalaryCurrency    string
+}
+
+type CatSkill struct {
+	Cat            int32
+	Skill          int32
+	Level          int32
+	CertifiedUntil pgtype.Timestamptz
+}
+
+type ExchangeRate struct {
+	Currency  string
+	Rate      float64
+	UpdatedAt pgtype.Timestamptz
 }
 
 type Mission struct {
+	ID          int32
+	Assignee    pgtype.Int4
+	Completed   bool
+	CreatedAt   pgtype.Timestamptz
+	AssignedAt  pgtype.Timestamptz
+	CompletedAt pgtype.Timestamptz
+}
+
+type MissionAssignment struct {
+	ID           int32
+	Mission      int32
+	Cat          int32
+	AssignedAt   pgtype.Timestamptz
+	UnassignedAt pgtype.Timestamptz
+}
+
+type MissionBudget struct {
+	Mission  int32
+	Amount   int64
+	Currency string
+}
+
+type MissionExpense struct {
+	ID            int32
+	Mission       int32
+	Category      string
+	Amount        int64
+	Currency      string
+	Description   string
+	ReceiptNumber string
+	ReceiptVendor string
+	ReceiptUrl    string
+	IncurredAt    pgtype.Timestamptz
+}
+
+type MissionTemplate struct {
 	ID        int32
-	Assignee  pgtype.Int4
-	Completed bool
+	Name      string
+	Targets   []byte
+	CreatedAt pgtype.Timestamptz
 }
 
-type Target struct {
+type Outbox struct {
+	ID            int64
+	AggregateType string
+	AggregateID   int32
+	EventType     string
+	Payload       []byte
+	CreatedAt     pgtype.Timestamptz
+	PublishedAt   pgtype.Timestamptz
+}
+
+type Skill struct {
+	ID   int32
+	Name string
+}
+
+type Subject struct {
 	ID        int32
-	Mission   int32
 	Name      string
 	Country   string
-	Notes     string
-	Completed bool
+	CreatedAt pgtype.Timestamptz
+}
+
+type Target struct {
+	ID           int32
+	Mission      int32
+	Name         string
+	Country      string
+	Notes        string
+	Completed    bool
+	SearchVector interface{}
+	CreatedAt    pgtype.Timestamptz
+	CompletedAt  pgtype.Timestamptz
+	Latitude     pgtype.Float8
+	Longitude    pgtype.Float8
+	Address      string
+	Subject      pgtype.Int4
+}
+
+type TargetMove struct {
+	ID          int32
+	Target      int32
+	FromMission int32
+	ToMission   int32
+	MovedAt     pgtype.Timestamptz
+}
+
+type TargetNote struct {
+	ID            int32
+	Target        int32
+	PreviousNotes string
+	Notes         string
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type TargetSkill struct {
+	Target    int32
+	Skill     int32
+	MinLevel  int32
+	Certified bool
+}
+
+type Webhook struct {
+	ID         int32
+	Url        string
+	Secret     string
+	EventTypes []string
+	Active     bool
+	CreatedAt  pgtype.Timestamptz
+}
+
+type WebhookDelivery struct {
+	ID               int32
+	Webhook          int32
+	EventType        string
+	Payload          []byte
+	Status           string
+	Attempts         int32
+	NextAttemptAt    pgtype.Timestamptz
+	LastError        string
+	LastResponseCode pgtype.Int4
+	CreatedAt        pgtype.Timestamptz
+	DeliveredAt      pgtype.Timestamptz
 }
@@ -13,9 +13,15 @@ import (
 
 const assignCat = `-- name: AssignCat :one
 UPDATE missions
-SET assignee = $2
+SET
+  assignee = $2,
+  assigned_at = CASE
+    WHEN $2 IS NULL THEN NULL
+    WHEN assignee IS NOT DISTINCT FROM $2 THEN assigned_at
+    ELSE now()
+  END
 WHERE id = $1
-RETURNING id, assignee, completed
+RETURNING id, assignee, completed, created_at, assigned_at, completed_at
 `
 
 type AssignCatParams struct {
@@ -26,29 +32,95 @@ type AssignCatParams struct {
 func (q *Queries) AssignCat(ctx context.Context, arg AssignCatParams) (Mission, error) {
 	row := q.db.QueryRow(ctx, assignCat, arg.ID, arg.Assignee)
 	var i Mission
-	err := row.Scan(&i.ID, &i.Assignee, &i.Completed)
+	err := row.Scan(
+		&i.ID,
+		&i.Assignee,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.AssignedAt,
+		&i.CompletedAt,
+	)
 	return i, err
 }
 
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = $1
+WHERE id IN (
+  SELECT d.id
+  FROM webhook_deliveries d
+  JOIN webhooks w ON w.id = d.webhook
+  WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND w.active
+  ORDER BY d.id
+  LIMIT $2
+  FOR UPDATE OF d SKIP LOCKED
+)
+RETURNING id, webhook, event_type, payload, status, attempts, next_attempt_at, last_error, last_response_code, created_at, delivered_at
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseUntil pgtype.Timestamptz
+	BatchSize  int32
+}
+
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, claimWebhookDeliveries, arg.LeaseUntil, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.Webhook,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.LastResponseCode,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const completeMission = `-- name: CompleteMission :one
 UPDATE missions
-SET completed = true
+SET completed = true, completed_at = COALESCE(completed_at, now())
 WHERE id = $1
-RETURNING id, assignee, completed
+RETURNING id, assignee, completed, created_at, assigned_at, completed_at
 `
 
 func (q *Queries) CompleteMission(ctx context.Context, id int32) (Mission, error) {
 	row := q.db.QueryRow(ctx, completeMission, id)
 	var i Mission
-	err := row.Scan(&i.ID, &i.Assignee, &i.Completed)
+	err := row.Scan(
+		&i.ID,
+		&i.Assignee,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.AssignedAt,
+		&i.CompletedAt,
+	)
 	return i, err
 }
 
 const completeTarget = `-- name: CompleteTarget :one
 UPDATE targets
-SET completed = true
+SET completed = true, completed_at = COALESCE(completed_at, now())
 WHERE id = $1
-RETURNING id, mission, name, country, notes, completed
+RETURNING id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
 `
 
 func (q *Queries) CompleteTarget(ctx context.Context, id int32) (Target, error) {
@@ -61,22 +133,98 @@ func (q *Queries) CompleteTarget(ctx context.Context, id int32) (Target, error)
 		&i.Country,
 		&i.Notes,
 		&i.Completed,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Address,
+		&i.Subject,
+	)
+	return i, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (
+  target, filename, content_type, size, blob_key
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING id, target, filename, content_type, size, blob_key, created_at
+`
+
+type CreateAttachmentParams struct {
+	Target      int32
+	Filename    string
+	ContentType string
+	Size        int64
+	BlobKey     string
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, createAttachment,
+		arg.Target,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.BlobKey,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.Target,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.BlobKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAvailabilityPeriod = `-- name: CreateAvailabilityPeriod :one
+INSERT INTO availability_periods (
+  cat, kind, starts_at, ends_at
+) VALUES ( $1, $2, $3, $4 )
+RETURNING id, cat, kind, starts_at, ends_at
+`
+
+type CreateAvailabilityPeriodParams struct {
+	Cat      int32
+	Kind     string
+	StartsAt pgtype.Timestamptz
+	EndsAt   pgtype.Timestamptz
+}
+
+func (q *Queries) CreateAvailabilityPeriod(ctx context.Context, arg CreateAvailabilityPeriodParams) (AvailabilityPeriod, error) {
+	row := q.db.QueryRow(ctx, createAvailabilityPeriod,
+		arg.Cat,
+		arg.Kind,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+	var i AvailabilityPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Cat,
+		&i.Kind,
+		&i.StartsAt,
+		&i.EndsAt,
 	)
 	return i, err
 }
 
 const createCat = `-- name: CreateCat :one
 INSERT INTO cats (
-  name, years_of_experience, breed, salary
-) VALUES ( $1, $2, $3, $4)
-RETURNING id, name, years_of_experience, breed, salary
+  name, years_of_experience, breed, salary, salary_currency
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING id, name, years_of_experience, breed, salary, salary_currency
 `
 
 type CreateCatParams struct {
 	Name              string
 	YearsOfExperience int32
 	Breed             string
-	Salary            int32
+	Salary            int64
+	SalaryCurrency    string
 }
 
 func (q *Queries) CreateCat(ctx context.Context, arg CreateCatParams) (Cat, error) {
@@ -85,6 +233,7 @@ func (q *Queries) CreateCat(ctx context.Context, arg CreateCatParams) (Cat, erro
 		arg.YearsOfExperience,
 		arg.Breed,
 		arg.Salary,
+		arg.SalaryCurrency,
 	)
 	var i Cat
 	err := row.Scan(
@@ -93,6 +242,7 @@ func (q *Queries) CreateCat(ctx context.Context, arg CreateCatParams) (Cat, erro
 		&i.YearsOfExperience,
 		&i.Breed,
 		&i.Salary,
+		&i.SalaryCurrency,
 	)
 	return i, err
 }
@@ -100,28 +250,190 @@ func (q *Queries) CreateCat(ctx context.Context, arg CreateCatParams) (Cat, erro
 const createMission = `-- name: CreateMission :one
 INSERT INTO missions
 DEFAULT VALUES
-RETURNING id, assignee, completed
+RETURNING id, assignee, completed, created_at, assigned_at, completed_at
 `
 
 func (q *Queries) CreateMission(ctx context.Context) (Mission, error) {
 	row := q.db.QueryRow(ctx, createMission)
 	var i Mission
-	err := row.Scan(&i.ID, &i.Assignee, &i.Completed)
+	err := row.Scan(
+		&i.ID,
+		&i.Assignee,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.AssignedAt,
+		&i.CompletedAt,
+	)
 	return i, err
 }
 
-const createTarget = `-- name: CreateTarget :one
-INSERT INTO targets (
-  mission, name, country, notes
+const createMissionAssignment = `-- name: CreateMissionAssignment :exec
+-- Records the assignment of the cat to the mission, at assigned_at of the mission.
+INSERT INTO mission_assignments (
+  mission, cat, assigned_at
+) VALUES ( $1, $2, $3 )
+`
+
+type CreateMissionAssignmentParams struct {
+	Mission    int32
+	Cat        int32
+	AssignedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateMissionAssignment(ctx context.Context, arg CreateMissionAssignmentParams) error {
+	_, err := q.db.Exec(ctx, createMissionAssignment, arg.Mission, arg.Cat, arg.AssignedAt)
+	return err
+}
+
+const createMissionExpense = `-- name: CreateMissionExpense :one
+INSERT INTO mission_expenses (
+  mission, category, amount, currency, description, receipt_number, receipt_vendor, receipt_url, incurred_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING id, mission, category, amount, currency, description, receipt_number, receipt_vendor, receipt_url, incurred_at
+`
+
+type CreateMissionExpenseParams struct {
+	Mission       int32
+	Category      string
+	Amount        int64
+	Currency      string
+	Description   string
+	ReceiptNumber string
+	ReceiptVendor string
+	ReceiptUrl    string
+	IncurredAt    pgtype.Timestamptz
+}
+
+func (q *Queries) CreateMissionExpense(ctx context.Context, arg CreateMissionExpenseParams) (MissionExpense, error) {
+	row := q.db.QueryRow(ctx, createMissionExpense,
+		arg.Mission,
+		arg.Category,
+		arg.Amount,
+		arg.Currency,
+		arg.Description,
+		arg.ReceiptNumber,
+		arg.ReceiptVendor,
+		arg.ReceiptUrl,
+		arg.IncurredAt,
+	)
+	var i MissionExpense
+	err := row.Scan(
+		&i.ID,
+		&i.Mission,
+		&i.Category,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.ReceiptNumber,
+		&i.ReceiptVendor,
+		&i.ReceiptUrl,
+		&i.IncurredAt,
+	)
+	return i, err
+}
+
+const createMissionTemplate = `-- name: CreateMissionTemplate :one
+INSERT INTO mission_templates (
+  name, targets
+) VALUES ( $1, $2 )
+RETURNING id, name, targets, created_at
+`
+
+type CreateMissionTemplateParams struct {
+	Name    string
+	Targets []byte
+}
+
+func (q *Queries) CreateMissionTemplate(ctx context.Context, arg CreateMissionTemplateParams) (MissionTemplate, error) {
+	row := q.db.QueryRow(ctx, createMissionTemplate, arg.Name, arg.Targets)
+	var i MissionTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Targets,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (
+  aggregate_type, aggregate_id, event_type, payload
 ) VALUES ( $1, $2, $3, $4 )
-RETURNING id, mission, name, country, notes, completed
 `
 
-type CreateTargetParams struct {
-	Mission int32
+type CreateOutboxEventParams struct {
+	AggregateType string
+	AggregateID   int32
+	EventType     string
+	Payload       []byte
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, createOutboxEvent,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.EventType,
+		arg.Payload,
+	)
+	return err
+}
+
+const createSkill = `-- name: CreateSkill :one
+INSERT INTO skills (name)
+VALUES ($1)
+RETURNING id, name
+`
+
+func (q *Queries) CreateSkill(ctx context.Context, name string) (Skill, error) {
+	row := q.db.QueryRow(ctx, createSkill, name)
+	var i Skill
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const createSubject = `-- name: CreateSubject :one
+INSERT INTO subjects (
+  name, country
+) VALUES ( $1, $2 )
+RETURNING id, name, country, created_at
+`
+
+type CreateSubjectParams struct {
 	Name    string
 	Country string
-	Notes   string
+}
+
+func (q *Queries) CreateSubject(ctx context.Context, arg CreateSubjectParams) (Subject, error) {
+	row := q.db.QueryRow(ctx, createSubject, arg.Name, arg.Country)
+	var i Subject
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Country,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTarget = `-- name: CreateTarget :one
+INSERT INTO targets (
+  mission, name, country, notes, latitude, longitude, address, subject
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8 )
+RETURNING id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+`
+
+type CreateTargetParams struct {
+	Mission   int32
+	Name      string
+	Country   string
+	Notes     string
+	Latitude  pgtype.Float8
+	Longitude pgtype.Float8
+	Address   string
+	Subject   pgtype.Int4
 }
 
 func (q *Queries) CreateTarget(ctx context.Context, arg CreateTargetParams) (Target, error) {
@@ -130,6 +442,10 @@ func (q *Queries) CreateTarget(ctx context.Context, arg CreateTargetParams) (Tar
 		arg.Name,
 		arg.Country,
 		arg.Notes,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Address,
+		arg.Subject,
 	)
 	var i Target
 	err := row.Scan(
@@ -139,10 +455,123 @@ func (q *Queries) CreateTarget(ctx context.Context, arg CreateTargetParams) (Tar
 		&i.Country,
 		&i.Notes,
 		&i.Completed,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Address,
+		&i.Subject,
+	)
+	return i, err
+}
+
+const createTargetMove = `-- name: CreateTargetMove :one
+INSERT INTO target_moves (
+  target, from_mission, to_mission
+) VALUES ( $1, $2, $3 )
+RETURNING id, target, from_mission, to_mission, moved_at
+`
+
+type CreateTargetMoveParams struct {
+	Target      int32
+	FromMission int32
+	ToMission   int32
+}
+
+func (q *Queries) CreateTargetMove(ctx context.Context, arg CreateTargetMoveParams) (TargetMove, error) {
+	row := q.db.QueryRow(ctx, createTargetMove, arg.Target, arg.FromMission, arg.ToMission)
+	var i TargetMove
+	err := row.Scan(
+		&i.ID,
+		&i.Target,
+		&i.FromMission,
+		&i.ToMission,
+		&i.MovedAt,
+	)
+	return i, err
+}
+
+const createTargetNote = `-- name: CreateTargetNote :one
+INSERT INTO target_notes (
+  target, previous_notes, notes
+) VALUES ( $1, $2, $3 )
+RETURNING id, target, previous_notes, notes, updated_at
+`
+
+type CreateTargetNoteParams struct {
+	Target        int32
+	PreviousNotes string
+	Notes         string
+}
+
+func (q *Queries) CreateTargetNote(ctx context.Context, arg CreateTargetNoteParams) (TargetNote, error) {
+	row := q.db.QueryRow(ctx, createTargetNote, arg.Target, arg.PreviousNotes, arg.Notes)
+	var i TargetNote
+	err := row.Scan(
+		&i.ID,
+		&i.Target,
+		&i.PreviousNotes,
+		&i.Notes,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  url, secret, event_types
+) VALUES ( $1, $2, $3 )
+RETURNING id, url, secret, event_types, active, created_at
+`
+
+type CreateWebhookParams struct {
+	Url        string
+	Secret     string
+	EventTypes []string
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, createWebhook, arg.Url, arg.Secret, arg.EventTypes)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Active,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const deleteAttachment = `-- name: DeleteAttachment :execrows
+DELETE FROM attachments
+WHERE id = $1
+`
+
+func (q *Queries) DeleteAttachment(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAttachment, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteAvailabilityPeriod = `-- name: DeleteAvailabilityPeriod :execrows
+DELETE
+FROM availability_periods
+WHERE id = $1
+`
+
+func (q *Queries) DeleteAvailabilityPeriod(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAvailabilityPeriod, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteCat = `-- name: DeleteCat :execrows
 DELETE 
 FROM cats
@@ -157,6 +586,40 @@ func (q *Queries) DeleteCat(ctx context.Context, id int32) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
+const deleteCatSkill = `-- name: DeleteCatSkill :execrows
+DELETE
+FROM cat_skills
+WHERE cat = $1
+  AND skill = $2
+`
+
+type DeleteCatSkillParams struct {
+	Cat   int32
+	Skill int32
+}
+
+func (q *Queries) DeleteCatSkill(ctx context.Context, arg DeleteCatSkillParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCatSkill, arg.Cat, arg.Skill)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExchangeRate = `-- name: DeleteExchangeRate :execrows
+DELETE
+FROM exchange_rates
+WHERE currency = $1
+`
+
+func (q *Queries) DeleteExchangeRate(ctx context.Context, currency string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExchangeRate, currency)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteMission = `-- name: DeleteMission :execrows
 DELETE
 FROM missions
@@ -171,161 +634,2453 @@ func (q *Queries) DeleteMission(ctx context.Context, id int32) (int64, error) {
 	return result.RowsAffected(), nil
 }
 
-const deleteTarget = `-- name: DeleteTarget :execrows
-DELETE 
-FROM targets
-WHERE id = $1
+const deleteMissionBudget = `-- name: DeleteMissionBudget :execrows
+DELETE
+FROM mission_budgets
+WHERE mission = $1
 `
 
-func (q *Queries) DeleteTarget(ctx context.Context, id int32) (int64, error) {
-	result, err := q.db.Exec(ctx, deleteTarget, id)
+func (q *Queries) DeleteMissionBudget(ctx context.Context, mission int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMissionBudget, mission)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
 
-const getAllCats = `-- name: GetAllCats :many
-SELECT id, name, years_of_experience, breed, salary
-FROM cats
+const deleteMissionExpense = `-- name: DeleteMissionExpense :execrows
+DELETE
+FROM mission_expenses
+WHERE id = $1
 `
 
-func (q *Queries) GetAllCats(ctx context.Context) ([]Cat, error) {
-	rows, err := q.db.Query(ctx, getAllCats)
+func (q *Queries) DeleteMissionExpense(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMissionExpense, id)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Cat
-	for rows.Next() {
-		var i Cat
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.YearsOfExperience,
-			&i.Breed,
-			&i.Salary,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getAllMissions = `-- name: GetAllMissions :many
-SELECT id, assignee, completed
-FROM missions
+const deleteMissionTemplate = `-- name: DeleteMissionTemplate :execrows
+DELETE
+FROM mission_templates
+WHERE id = $1
 `
 
-func (q *Queries) GetAllMissions(ctx context.Context) ([]Mission, error) {
-	rows, err := q.db.Query(ctx, getAllMissions)
+func (q *Queries) DeleteMissionTemplate(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMissionTemplate, id)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Mission
-	for rows.Next() {
-		var i Mission
-		if err := rows.Scan(&i.ID, &i.Assignee, &i.Completed); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getCat = `-- name: GetCat :one
-SELECT id, name, years_of_experience, breed, salary
-FROM cats 
+const deleteSkill = `-- name: DeleteSkill :execrows
+DELETE
+FROM skills
 WHERE id = $1
-LIMIT 1
 `
 
-func (q *Queries) GetCat(ctx context.Context, id int32) (Cat, error) {
-	row := q.db.QueryRow(ctx, getCat, id)
-	var i Cat
-	err := row.Scan(
+func (q *Queries) DeleteSkill(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSkill, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSubject = `-- name: DeleteSubject :execrows
+DELETE FROM subjects
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSubject(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSubject, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTarget = `-- name: DeleteTarget :execrows
+DELETE 
+FROM targets
+WHERE id = $1
+`
+
+func (q *Queries) DeleteTarget(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTarget, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTargetSkill = `-- name: DeleteTargetSkill :execrows
+DELETE
+FROM target_skills
+WHERE target = $1
+  AND skill = $2
+`
+
+type DeleteTargetSkillParams struct {
+	Target int32
+	Skill  int32
+}
+
+func (q *Queries) DeleteTargetSkill(ctx context.Context, arg DeleteTargetSkillParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteTargetSkill, arg.Target, arg.Skill)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE
+FROM webhooks
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhook, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const endMissionAssignment = `-- name: EndMissionAssignment :exec
+-- Ends the open assignment of the mission, if any.
+UPDATE mission_assignments
+SET unassigned_at = now()
+WHERE mission = $1 AND unassigned_at IS NULL
+`
+
+func (q *Queries) EndMissionAssignment(ctx context.Context, mission int32) error {
+	_, err := q.db.Exec(ctx, endMissionAssignment, mission)
+	return err
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+  webhook, event_type, payload
+)
+SELECT id, $1::text, $2::jsonb
+FROM webhooks
+WHERE active AND $1::text = ANY(event_types)
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventType string
+	Payload   []byte
+}
+
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueWebhookDeliveries, arg.EventType, arg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAllCats = `-- name: GetAllCats :many
+SELECT id, name, years_of_experience, breed, salary, salary_currency
+FROM cats
+`
+
+func (q *Queries) GetAllCats(ctx context.Context) ([]Cat, error) {
+	rows, err := q.db.Query(ctx, getAllCats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cat
+	for rows.Next() {
+		var i Cat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YearsOfExperience,
+			&i.Breed,
+			&i.Salary,
+			&i.SalaryCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllMissionTemplates = `-- name: GetAllMissionTemplates :many
+SELECT id, name, targets, created_at
+FROM mission_templates
+ORDER BY id
+`
+
+func (q *Queries) GetAllMissionTemplates(ctx context.Context) ([]MissionTemplate, error) {
+	rows, err := q.db.Query(ctx, getAllMissionTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MissionTemplate
+	for rows.Next() {
+		var i MissionTemplate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Targets,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllMissions = `-- name: GetAllMissions :many
+SELECT id, assignee, completed, created_at, assigned_at, completed_at
+FROM missions
+`
+
+func (q *Queries) GetAllMissions(ctx context.Context) ([]Mission, error) {
+	rows, err := q.db.Query(ctx, getAllMissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mission
+	for rows.Next() {
+		var i Mission
+		if err := rows.Scan(
+			&i.ID,
+			&i.Assignee,
+			&i.Completed,
+			&i.CreatedAt,
+			&i.AssignedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllSkills = `-- name: GetAllSkills :many
+SELECT id, name
+FROM skills
+ORDER BY name
+`
+
+func (q *Queries) GetAllSkills(ctx context.Context) ([]Skill, error) {
+	rows, err := q.db.Query(ctx, getAllSkills)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Skill
+	for rows.Next() {
+		var i Skill
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllSubjects = `-- name: GetAllSubjects :many
+SELECT id, name, country, created_at
+FROM subjects
+ORDER BY id
+`
+
+func (q *Queries) GetAllSubjects(ctx context.Context) ([]Subject, error) {
+	rows, err := q.db.Query(ctx, getAllSubjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subject
+	for rows.Next() {
+		var i Subject
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Country,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllTargets = `-- name: GetAllTargets :many
+SELECT id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+FROM targets
+ORDER BY id
+`
+
+func (q *Queries) GetAllTargets(ctx context.Context) ([]Target, error) {
+	rows, err := q.db.Query(ctx, getAllTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Target
+	for rows.Next() {
+		var i Target
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mission,
+			&i.Name,
+			&i.Country,
+			&i.Notes,
+			&i.Completed,
+			&i.SearchVector,
+			&i.CreatedAt,
+			&i.CompletedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Address,
+			&i.Subject,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllWebhooks = `-- name: GetAllWebhooks :many
+SELECT id, url, secret, event_types, active, created_at
+FROM webhooks
+ORDER BY id
+`
+
+func (q *Queries) GetAllWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := q.db.Query(ctx, getAllWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.EventTypes,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT id, target, filename, content_type, size, blob_key, created_at
+FROM attachments
+WHERE id = $1
+`
+
+func (q *Queries) GetAttachment(ctx context.Context, id int32) (Attachment, error) {
+	row := q.db.QueryRow(ctx, getAttachment, id)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.Target,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.BlobKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAvailabilityPeriod = `-- name: GetAvailabilityPeriod :one
+SELECT id, cat, kind, starts_at, ends_at
+FROM availability_periods
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetAvailabilityPeriod(ctx context.Context, id int32) (AvailabilityPeriod, error) {
+	row := q.db.QueryRow(ctx, getAvailabilityPeriod, id)
+	var i AvailabilityPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Cat,
+		&i.Kind,
+		&i.StartsAt,
+		&i.EndsAt,
+	)
+	return i, err
+}
+
+const getAvailableCats = `-- name: GetAvailableCats :many
+SELECT id, name, years_of_experience, breed, salary, salary_currency
+FROM cats
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM availability_periods p
+    WHERE p.cat = cats.id
+      AND p.starts_at <= $1
+      AND p.ends_at > $1
+)
+ORDER BY id
+`
+
+func (q *Queries) GetAvailableCats(ctx context.Context, at pgtype.Timestamptz) ([]Cat, error) {
+	rows, err := q.db.Query(ctx, getAvailableCats, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cat
+	for rows.Next() {
+		var i Cat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YearsOfExperience,
+			&i.Breed,
+			&i.Salary,
+			&i.SalaryCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCat = `-- name: GetCat :one
+SELECT id, name, years_of_experience, breed, salary, salary_currency
+FROM cats 
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCat(ctx context.Context, id int32) (Cat, error) {
+	row := q.db.QueryRow(ctx, getCat, id)
+	var i Cat
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.YearsOfExperience,
+		&i.Breed,
+		&i.Salary,
+		&i.SalaryCurrency,
+	)
+	return i, err
+}
+
+const getCatAvailabilityPeriods = `-- name: GetCatAvailabilityPeriods :many
+SELECT id, cat, kind, starts_at, ends_at
+FROM availability_periods
+WHERE cat = $1
+ORDER BY starts_at, id
+`
+
+func (q *Queries) GetCatAvailabilityPeriods(ctx context.Context, cat int32) ([]AvailabilityPeriod, error) {
+	rows, err := q.db.Query(ctx, getCatAvailabilityPeriods, cat)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AvailabilityPeriod
+	for rows.Next() {
+		var i AvailabilityPeriod
+		if err := rows.Scan(
+			&i.ID,
+			&i.Cat,
+			&i.Kind,
+			&i.StartsAt,
+			&i.EndsAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatMission = `-- name: GetCatMission :one
+SELECT id, assignee, completed, created_at, assigned_at, completed_at
+FROM missions
+WHERE assignee = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCatMission(ctx context.Context, assignee pgtype.Int4) (Mission, error) {
+	row := q.db.QueryRow(ctx, getCatMission, assignee)
+	var i Mission
+	err := row.Scan(
+		&i.ID,
+		&i.Assignee,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.AssignedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getCatSkills = `-- name: GetCatSkills :many
+SELECT cs.cat, cs.skill, cs.level, cs.certified_until, s.name
+FROM cat_skills cs
+  JOIN skills s ON s.id = cs.skill
+WHERE cs.cat = $1
+ORDER BY s.name
+`
+
+type GetCatSkillsRow struct {
+	Cat            int32
+	Skill          int32
+	Level          int32
+	CertifiedUntil pgtype.Timestamptz
+	Name           string
+}
+
+func (q *Queries) GetCatSkills(ctx context.Context, cat int32) ([]GetCatSkillsRow, error) {
+	rows, err := q.db.Query(ctx, getCatSkills, cat)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCatSkillsRow
+	for rows.Next() {
+		var i GetCatSkillsRow
+		if err := rows.Scan(
+			&i.Cat,
+			&i.Skill,
+			&i.Level,
+			&i.CertifiedUntil,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatUtilization = `-- name: GetCatUtilization :many
+SELECT
+    c.id,
+    c.name,
+    COUNT(DISTINCT a.mission) AS missions,
+    (COALESCE(SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(a.unassigned_at, $1::timestamptz), COALESCE(m.completed_at, $1::timestamptz)) - a.assigned_at)), 0) / 86400)::float8 AS days_on_mission
+FROM cats c
+LEFT JOIN mission_assignments a ON a.cat = c.id
+LEFT JOIN missions m ON m.id = a.mission
+GROUP BY c.id
+ORDER BY c.id
+`
+
+type GetCatUtilizationRow struct {
+	ID            int32
+	Name          string
+	Missions      int64
+	DaysOnMission float64
+}
+
+func (q *Queries) GetCatUtilization(ctx context.Context, now pgtype.Timestamptz) ([]GetCatUtilizationRow, error) {
+	rows, err := q.db.Query(ctx, getCatUtilization, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCatUtilizationRow
+	for rows.Next() {
+		var i GetCatUtilizationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Missions,
+			&i.DaysOnMission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatWorkloadPage = `-- name: GetCatWorkloadPage :many
+SELECT
+    c.id,
+    c.name,
+    c.breed,
+    c.salary,
+    c.salary_currency,
+    COUNT(DISTINCT m.id) FILTER (WHERE NOT m.completed) AS active_missions,
+    COUNT(DISTINCT m.id) FILTER (WHERE m.completed) AS completed_missions,
+    COUNT(t.id) FILTER (WHERE NOT t.completed) AS open_targets,
+    COUNT(t.id) FILTER (WHERE t.completed) AS completed_targets
+FROM cats c
+LEFT JOIN missions m ON m.assignee = c.id
+LEFT JOIN targets t ON t.mission = m.id
+WHERE c.id > $1
+GROUP BY c.id
+ORDER BY c.id
+LIMIT $2
+`
+
+type GetCatWorkloadPageRow struct {
+	ID                int32
+	Name              string
+	Breed             string
+	Salary            int64
+	SalaryCurrency    string
+	ActiveMissions    int64
+	CompletedMissions int64
+	OpenTargets       int64
+	CompletedTargets  int64
+}
+
+type GetCatWorkloadPageParams struct {
+	After   int32
+	MaxRows int32
+}
+
+func (q *Queries) GetCatWorkloadPage(ctx context.Context, arg GetCatWorkloadPageParams) ([]GetCatWorkloadPageRow, error) {
+	rows, err := q.db.Query(ctx, getCatWorkloadPage, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCatWorkloadPageRow
+	for rows.Next() {
+		var i GetCatWorkloadPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Breed,
+			&i.Salary,
+			&i.SalaryCurrency,
+			&i.ActiveMissions,
+			&i.CompletedMissions,
+			&i.OpenTargets,
+			&i.CompletedTargets,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatsAvailableBetween = `-- name: GetCatsAvailableBetween :many
+-- The cats without any availability period overlapping the window from since up to until.
+SELECT id, name, years_of_experience, breed, salary, salary_currency
+FROM cats
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM availability_periods p
+    WHERE p.cat = cats.id
+      AND p.ends_at > $1
+      AND p.starts_at <= $2
+)
+ORDER BY id
+`
+
+type GetCatsAvailableBetweenParams struct {
+	Since pgtype.Timestamptz
+	Until pgtype.Timestamptz
+}
+
+func (q *Queries) GetCatsAvailableBetween(ctx context.Context, arg GetCatsAvailableBetweenParams) ([]Cat, error) {
+	rows, err := q.db.Query(ctx, getCatsAvailableBetween, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cat
+	for rows.Next() {
+		var i Cat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YearsOfExperience,
+			&i.Breed,
+			&i.Salary,
+			&i.SalaryCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCatsPage = `-- name: GetCatsPage :many
+SELECT id, name, years_of_experience, breed, salary, salary_currency
+FROM cats
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type GetCatsPageParams struct {
+	After   int32
+	MaxRows int32
+}
+
+func (q *Queries) GetCatsPage(ctx context.Context, arg GetCatsPageParams) ([]Cat, error) {
+	rows, err := q.db.Query(ctx, getCatsPage, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cat
+	for rows.Next() {
+		var i Cat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YearsOfExperience,
+			&i.Breed,
+			&i.Salary,
+			&i.SalaryCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCompletionTimes = `-- name: GetCompletionTimes :one
+SELECT
+    (SELECT COUNT(*) FROM missions WHERE completed_at IS NOT NULL) AS completed_missions,
+    (SELECT COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - created_at)), 0)::float8 FROM missions WHERE completed_at IS NOT NULL) AS mission_seconds,
+    (SELECT COUNT(*) FROM targets WHERE completed_at IS NOT NULL) AS completed_targets,
+    (SELECT COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - created_at)), 0)::float8 FROM targets WHERE completed_at IS NOT NULL) AS target_seconds
+`
+
+type GetCompletionTimesRow struct {
+	CompletedMissions int64
+	MissionSeconds    float64
+	CompletedTargets  int64
+	TargetSeconds     float64
+}
+
+func (q *Queries) GetCompletionTimes(ctx context.Context) (GetCompletionTimesRow, error) {
+	row := q.db.QueryRow(ctx, getCompletionTimes)
+	var i GetCompletionTimesRow
+	err := row.Scan(
+		&i.CompletedMissions,
+		&i.MissionSeconds,
+		&i.CompletedTargets,
+		&i.TargetSeconds,
+	)
+	return i, err
+}
+
+const getExchangeRates = `-- name: GetExchangeRates :many
+SELECT currency, rate, updated_at
+FROM exchange_rates
+ORDER BY currency
+`
+
+func (q *Queries) GetExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	rows, err := q.db.Query(ctx, getExchangeRates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExchangeRate
+	for rows.Next() {
+		var i ExchangeRate
+		if err := rows.Scan(&i.Currency, &i.Rate, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMission = `-- name: GetMission :one
+SELECT id, assignee, completed, created_at, assigned_at, completed_at
+FROM missions
+WHERE missions.id = $1
+`
+
+func (q *Queries) GetMission(ctx context.Context, id int32) (Mission, error) {
+	row := q.db.QueryRow(ctx, getMission, id)
+	var i Mission
+	err := row.Scan(
+		&i.ID,
+		&i.Assignee,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.AssignedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getMissionAttachments = `-- name: GetMissionAttachments :many
+SELECT a.id, a.target, a.filename, a.content_type, a.size, a.blob_key, a.created_at
+FROM attachments a
+JOIN targets t ON t.id = a.target
+WHERE t.mission = $1
+ORDER BY a.id
+`
+
+func (q *Queries) GetMissionAttachments(ctx context.Context, mission int32) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, getMissionAttachments, mission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Target,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.BlobKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissionBudget = `-- name: GetMissionBudget :one
+SELECT mission, amount, currency
+FROM mission_budgets
+WHERE mission = $1
+LIMIT 1
+`
+
+func (q *Queries) GetMissionBudget(ctx context.Context, mission int32) (MissionBudget, error) {
+	row := q.db.QueryRow(ctx, getMissionBudget, mission)
+	var i MissionBudget
+	err := row.Scan(&i.Mission, &i.Amount, &i.Currency)
+	return i, err
+}
+
+const getMissionByTargetID = `-- name: GetMissionByTargetID :one
+SELECT 
+    m.id AS mission_id,
+    m.assignee,
+    m.completed
+FROM missions m
+JOIN targets t ON m.id = t.mission
+WHERE t.id = $1
+`
+
+type GetMissionByTargetIDRow struct {
+	MissionID int32
+	Assignee  pgtype.Int4
+	Completed bool
+}
+
+func (q *Queries) GetMissionByTargetID(ctx context.Context, id int32) (GetMissionByTargetIDRow, error) {
+	row := q.db.QueryRow(ctx, getMissionByTargetID, id)
+	var i GetMissionByTargetIDRow
+	err := row.Scan(&i.MissionID, &i.Assignee, &i.Completed)
+	return i, err
+}
+
+const getMissionCompletionRate = `-- name: GetMissionCompletionRate :many
+SELECT
+    date_trunc($1::text, created_at, 'UTC')::timestamptz AS period,
+    COUNT(*) AS created,
+    COUNT(*) FILTER (WHERE completed) AS completed
+FROM missions
+WHERE created_at >= $2
+GROUP BY period
+ORDER BY period
+`
+
+type GetMissionCompletionRateRow struct {
+	Period    pgtype.Timestamptz
+	Created   int64
+	Completed int64
+}
+
+type GetMissionCompletionRateParams struct {
+	TimeWindow string
+	Since      pgtype.Timestamptz
+}
+
+func (q *Queries) GetMissionCompletionRate(ctx context.Context, arg GetMissionCompletionRateParams) ([]GetMissionCompletionRateRow, error) {
+	rows, err := q.db.Query(ctx, getMissionCompletionRate, arg.TimeWindow, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMissionCompletionRateRow
+	for rows.Next() {
+		var i GetMissionCompletionRateRow
+		if err := rows.Scan(&i.Period, &i.Created, &i.Completed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissionExpense = `-- name: GetMissionExpense :one
+SELECT id, mission, category, amount, currency, description, receipt_number, receipt_vendor, receipt_url, incurred_at
+FROM mission_expenses
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetMissionExpense(ctx context.Context, id int32) (MissionExpense, error) {
+	row := q.db.QueryRow(ctx, getMissionExpense, id)
+	var i MissionExpense
+	err := row.Scan(
+		&i.ID,
+		&i.Mission,
+		&i.Category,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.ReceiptNumber,
+		&i.ReceiptVendor,
+		&i.ReceiptUrl,
+		&i.IncurredAt,
+	)
+	return i, err
+}
+
+const getMissionExpenses = `-- name: GetMissionExpenses :many
+SELECT id, mission, category, amount, currency, description, receipt_number, receipt_vendor, receipt_url, incurred_at
+FROM mission_expenses
+WHERE mission = $1
+ORDER BY incurred_at, id
+`
+
+func (q *Queries) GetMissionExpenses(ctx context.Context, mission int32) ([]MissionExpense, error) {
+	rows, err := q.db.Query(ctx, getMissionExpenses, mission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MissionExpense
+	for rows.Next() {
+		var i MissionExpense
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mission,
+			&i.Category,
+			&i.Amount,
+			&i.Currency,
+			&i.Description,
+			&i.ReceiptNumber,
+			&i.ReceiptVendor,
+			&i.ReceiptUrl,
+			&i.IncurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissionForUpdate = `-- name: GetMissionForUpdate :one
+-- The mission, locked until the end of the transaction.
+SELECT id, assignee, completed, created_at, assigned_at, completed_at
+FROM missions
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetMissionForUpdate(ctx context.Context, id int32) (Mission, error) {
+	row := q.db.QueryRow(ctx, getMissionForUpdate, id)
+	var i Mission
+	err := row.Scan(
+		&i.ID,
+		&i.Assignee,
+		&i.Completed,
+		&i.CreatedAt,
+		&i.AssignedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getMissionRequiredSkills = `-- name: GetMissionRequiredSkills :many
+-- The skills required by the pending targets of the mission, at the highest
+-- level any of them requires.
+SELECT ts.skill, s.name, MAX(ts.min_level)::INTEGER AS min_level, BOOL_OR(ts.certified) AS certified
+FROM target_skills ts
+  JOIN targets t ON t.id = ts.target
+  JOIN skills s ON s.id = ts.skill
+WHERE t.mission = $1
+  AND NOT t.completed
+GROUP BY ts.skill, s.name
+ORDER BY s.name
+`
+
+type GetMissionRequiredSkillsRow struct {
+	Skill     int32
+	Name      string
+	MinLevel  int32
+	Certified bool
+}
+
+func (q *Queries) GetMissionRequiredSkills(ctx context.Context, mission int32) ([]GetMissionRequiredSkillsRow, error) {
+	rows, err := q.db.Query(ctx, getMissionRequiredSkills, mission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMissionRequiredSkillsRow
+	for rows.Next() {
+		var i GetMissionRequiredSkillsRow
+		if err := rows.Scan(
+			&i.Skill,
+			&i.Name,
+			&i.MinLevel,
+			&i.Certified,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissionTargets = `-- name: GetMissionTargets :many
+SELECT id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+FROM targets
+WHERE mission = $1
+`
+
+func (q *Queries) GetMissionTargets(ctx context.Context, mission int32) ([]Target, error) {
+	rows, err := q.db.Query(ctx, getMissionTargets, mission)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Target
+	for rows.Next() {
+		var i Target
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mission,
+			&i.Name,
+			&i.Country,
+			&i.Notes,
+			&i.Completed,
+			&i.SearchVector,
+			&i.CreatedAt,
+			&i.CompletedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Address,
+			&i.Subject,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissionTargetsPage = `-- name: GetMissionTargetsPage :many
+SELECT
+    m.id AS mission_id,
+    m.assignee,
+    m.completed AS mission_completed,
+    t.id AS target_id,
+    t.name,
+    t.country,
+    t.notes,
+    t.completed AS target_completed
+FROM targets t
+JOIN missions m ON m.id = t.mission
+WHERE (t.mission, t.id) > ($1::int, $2::int)
+ORDER BY t.mission, t.id
+LIMIT $3
+`
+
+type GetMissionTargetsPageRow struct {
+	MissionID        int32
+	Assignee         pgtype.Int4
+	MissionCompleted bool
+	TargetID         int32
+	Name             string
+	Country          string
+	Notes            string
+	TargetCompleted  bool
+}
+
+type GetMissionTargetsPageParams struct {
+	AfterMission int32
+	AfterTarget  int32
+	MaxRows      int32
+}
+
+func (q *Queries) GetMissionTargetsPage(ctx context.Context, arg GetMissionTargetsPageParams) ([]GetMissionTargetsPageRow, error) {
+	rows, err := q.db.Query(ctx, getMissionTargetsPage, arg.AfterMission, arg.AfterTarget, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMissionTargetsPageRow
+	for rows.Next() {
+		var i GetMissionTargetsPageRow
+		if err := rows.Scan(
+			&i.MissionID,
+			&i.Assignee,
+			&i.MissionCompleted,
+			&i.TargetID,
+			&i.Name,
+			&i.Country,
+			&i.Notes,
+			&i.TargetCompleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMissionTemplate = `-- name: GetMissionTemplate :one
+SELECT id, name, targets, created_at
+FROM mission_templates
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetMissionTemplate(ctx context.Context, id int32) (MissionTemplate, error) {
+	row := q.db.QueryRow(ctx, getMissionTemplate, id)
+	var i MissionTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Targets,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getMissionsPage = `-- name: GetMissionsPage :many
+SELECT id, assignee, completed, created_at, assigned_at, completed_at
+FROM missions
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type GetMissionsPageParams struct {
+	After   int32
+	MaxRows int32
+}
+
+func (q *Queries) GetMissionsPage(ctx context.Context, arg GetMissionsPageParams) ([]Mission, error) {
+	rows, err := q.db.Query(ctx, getMissionsPage, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mission
+	for rows.Next() {
+		var i Mission
+		if err := rows.Scan(
+			&i.ID,
+			&i.Assignee,
+			&i.Completed,
+			&i.CreatedAt,
+			&i.AssignedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutboxEvents = `-- name: GetOutboxEvents :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at
+FROM outbox
+WHERE id >= $1
+ORDER BY id
+LIMIT $2
+`
+
+type GetOutboxEventsParams struct {
+	FromOffset int64
+	MaxEvents  int32
+}
+
+func (q *Queries) GetOutboxEvents(ctx context.Context, arg GetOutboxEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, getOutboxEvents, arg.FromOffset, arg.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOverlappingAvailabilityPeriods = `-- name: GetOverlappingAvailabilityPeriods :many
+-- The periods of the cat overlapping the window from since up to until.
+SELECT id, cat, kind, starts_at, ends_at
+FROM availability_periods
+WHERE cat = $1
+  AND ends_at > $2
+  AND starts_at <= $3
+ORDER BY starts_at, id
+`
+
+type GetOverlappingAvailabilityPeriodsParams struct {
+	Cat   int32
+	Since pgtype.Timestamptz
+	Until pgtype.Timestamptz
+}
+
+func (q *Queries) GetOverlappingAvailabilityPeriods(ctx context.Context, arg GetOverlappingAvailabilityPeriodsParams) ([]AvailabilityPeriod, error) {
+	rows, err := q.db.Query(ctx, getOverlappingAvailabilityPeriods, arg.Cat, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AvailabilityPeriod
+	for rows.Next() {
+		var i AvailabilityPeriod
+		if err := rows.Scan(
+			&i.ID,
+			&i.Cat,
+			&i.Kind,
+			&i.StartsAt,
+			&i.EndsAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalaryPerformance = `-- name: GetSalaryPerformance :many
+-- The sums of the cats of each salary currency.
+WITH performance AS (
+    SELECT
+        c.salary_currency,
+        c.salary::float8 AS salary,
+        (COUNT(DISTINCT m.id) FILTER (WHERE m.completed))::float8 AS missions,
+        (COUNT(t.id) FILTER (WHERE t.completed))::float8 AS targets
+    FROM cats c
+    LEFT JOIN missions m ON m.assignee = c.id
+    LEFT JOIN targets t ON t.mission = m.id
+    GROUP BY c.id
+)
+SELECT
+    salary_currency,
+    COUNT(*) AS cats,
+    COALESCE(SUM(salary), 0)::float8 AS sum_salary,
+    COALESCE(SUM(salary * salary), 0)::float8 AS sum_salary_squares,
+    COALESCE(SUM(missions), 0)::float8 AS sum_missions,
+    COALESCE(SUM(missions * missions), 0)::float8 AS sum_missions_squares,
+    COALESCE(SUM(salary * missions), 0)::float8 AS sum_salary_missions,
+    COALESCE(SUM(targets), 0)::float8 AS sum_targets,
+    COALESCE(SUM(targets * targets), 0)::float8 AS sum_targets_squares,
+    COALESCE(SUM(salary * targets), 0)::float8 AS sum_salary_targets
+FROM performance
+GROUP BY salary_currency
+ORDER BY salary_currency
+`
+
+type GetSalaryPerformanceRow struct {
+	SalaryCurrency     string
+	Cats               int64
+	SumSalary          float64
+	SumSalarySquares   float64
+	SumMissions        float64
+	SumMissionsSquares float64
+	SumSalaryMissions  float64
+	SumTargets         float64
+	SumTargetsSquares  float64
+	SumSalaryTargets   float64
+}
+
+func (q *Queries) GetSalaryPerformance(ctx context.Context) ([]GetSalaryPerformanceRow, error) {
+	rows, err := q.db.Query(ctx, getSalaryPerformance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSalaryPerformanceRow
+	for rows.Next() {
+		var i GetSalaryPerformanceRow
+		if err := rows.Scan(
+			&i.SalaryCurrency,
+			&i.Cats,
+			&i.SumSalary,
+			&i.SumSalarySquares,
+			&i.SumMissions,
+			&i.SumMissionsSquares,
+			&i.SumSalaryMissions,
+			&i.SumTargets,
+			&i.SumTargetsSquares,
+			&i.SumSalaryTargets,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSimilarSubjects = `-- name: GetSimilarSubjects :many
+-- The subjects with a name similar to the name by the % operator of pg_trgm,
+-- the most similar first. The names weigh 0.8 and the countries 0.2.
+SELECT id, name, country, created_at
+FROM subjects
+WHERE name % $1::text
+ORDER BY 0.8 * similarity(name, $1::text) + 0.2 * (
+    CASE WHEN lower(country) = lower($2::text) THEN 1 ELSE similarity(country, $2::text) END
+  ) DESC, id
+LIMIT $3
+`
+
+type GetSimilarSubjectsParams struct {
+	Name        string
+	Country     string
+	MaxSubjects int32
+}
+
+func (q *Queries) GetSimilarSubjects(ctx context.Context, arg GetSimilarSubjectsParams) ([]Subject, error) {
+	rows, err := q.db.Query(ctx, getSimilarSubjects, arg.Name, arg.Country, arg.MaxSubjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subject
+	for rows.Next() {
+		var i Subject
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Country,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSkill = `-- name: GetSkill :one
+SELECT id, name
+FROM skills
+WHERE id = $1
+`
+
+func (q *Queries) GetSkill(ctx context.Context, id int32) (Skill, error) {
+	row := q.db.QueryRow(ctx, getSkill, id)
+	var i Skill
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const getSubject = `-- name: GetSubject :one
+SELECT id, name, country, created_at
+FROM subjects
+WHERE id = $1
+`
+
+func (q *Queries) GetSubject(ctx context.Context, id int32) (Subject, error) {
+	row := q.db.QueryRow(ctx, getSubject, id)
+	var i Subject
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Country,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSubjectNotes = `-- name: GetSubjectNotes :many
+SELECT n.id, n.target, n.previous_notes, n.notes, n.updated_at
+FROM target_notes n
+JOIN targets t ON t.id = n.target
+WHERE t.subject = $1
+ORDER BY n.id
+`
+
+func (q *Queries) GetSubjectNotes(ctx context.Context, subject pgtype.Int4) ([]TargetNote, error) {
+	rows, err := q.db.Query(ctx, getSubjectNotes, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TargetNote
+	for rows.Next() {
+		var i TargetNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.Target,
+			&i.PreviousNotes,
+			&i.Notes,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSubjectTargets = `-- name: GetSubjectTargets :many
+SELECT id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+FROM targets
+WHERE subject = $1
+ORDER BY id
+`
+
+func (q *Queries) GetSubjectTargets(ctx context.Context, subject pgtype.Int4) ([]Target, error) {
+	rows, err := q.db.Query(ctx, getSubjectTargets, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Target
+	for rows.Next() {
+		var i Target
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mission,
+			&i.Name,
+			&i.Country,
+			&i.Notes,
+			&i.Completed,
+			&i.SearchVector,
+			&i.CreatedAt,
+			&i.CompletedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Address,
+			&i.Subject,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSubjectsByIDs = `-- name: GetSubjectsByIDs :many
+SELECT id, name, country, created_at
+FROM subjects
+WHERE id = ANY($1::int[])
+ORDER BY id
+`
+
+func (q *Queries) GetSubjectsByIDs(ctx context.Context, ids []int32) ([]Subject, error) {
+	rows, err := q.db.Query(ctx, getSubjectsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subject
+	for rows.Next() {
+		var i Subject
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Country,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSubjectsPage = `-- name: GetSubjectsPage :many
+SELECT id, name, country, created_at
+FROM subjects
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type GetSubjectsPageParams struct {
+	After   int32
+	MaxRows int32
+}
+
+func (q *Queries) GetSubjectsPage(ctx context.Context, arg GetSubjectsPageParams) ([]Subject, error) {
+	rows, err := q.db.Query(ctx, getSubjectsPage, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subject
+	for rows.Next() {
+		var i Subject
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Country,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTarget = `-- name: GetTarget :one
+SELECT id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+FROM targets
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetTarget(ctx context.Context, id int32) (Target, error) {
+	row := q.db.QueryRow(ctx, getTarget, id)
+	var i Target
+	err := row.Scan(
+		&i.ID,
+		&i.Mission,
+		&i.Name,
+		&i.Country,
+		&i.Notes,
+		&i.Completed,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Address,
+		&i.Subject,
+	)
+	return i, err
+}
+
+const getTargetAttachments = `-- name: GetTargetAttachments :many
+SELECT id, target, filename, content_type, size, blob_key, created_at
+FROM attachments
+WHERE target = $1
+ORDER BY id
+`
+
+func (q *Queries) GetTargetAttachments(ctx context.Context, target int32) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, getTargetAttachments, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Target,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.BlobKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTargetMoves = `-- name: GetTargetMoves :many
+SELECT id, target, from_mission, to_mission, moved_at
+FROM target_moves
+WHERE target = $1
+ORDER BY id
+`
+
+func (q *Queries) GetTargetMoves(ctx context.Context, target int32) ([]TargetMove, error) {
+	rows, err := q.db.Query(ctx, getTargetMoves, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TargetMove
+	for rows.Next() {
+		var i TargetMove
+		if err := rows.Scan(
+			&i.ID,
+			&i.Target,
+			&i.FromMission,
+			&i.ToMission,
+			&i.MovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTargetSkills = `-- name: GetTargetSkills :many
+SELECT ts.target, ts.skill, ts.min_level, ts.certified, s.name
+FROM target_skills ts
+  JOIN skills s ON s.id = ts.skill
+WHERE ts.target = $1
+ORDER BY s.name
+`
+
+type GetTargetSkillsRow struct {
+	Target    int32
+	Skill     int32
+	MinLevel  int32
+	Certified bool
+	Name      string
+}
+
+func (q *Queries) GetTargetSkills(ctx context.Context, target int32) ([]GetTargetSkillsRow, error) {
+	rows, err := q.db.Query(ctx, getTargetSkills, target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTargetSkillsRow
+	for rows.Next() {
+		var i GetTargetSkillsRow
+		if err := rows.Scan(
+			&i.Target,
+			&i.Skill,
+			&i.MinLevel,
+			&i.Certified,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTargetsNear = `-- name: GetTargetsNear :many
+-- The targets within radius_km of the point, nearest first, by the haversine
+-- distance on a sphere of the mean radius of the Earth. The latitudes are
+-- prefiltered on the degrees of the radius, 111.195 km each.
+SELECT id, mission, name, country, notes, completed, latitude, longitude, address, distance_km
+FROM (
+  SELECT
+    t.id, t.mission, t.name, t.country, t.notes, t.completed, t.latitude, t.longitude, t.address,
+    (2 * 6371.0088 * asin(least(1, sqrt(
+      power(sin(radians(t.latitude - $1::float8) / 2), 2) +
+      cos(radians($1)) * cos(radians(t.latitude)) * power(sin(radians(t.longitude - $2::float8) / 2), 2)
+    ))))::float8 AS distance_km
+  FROM targets t
+  WHERE t.latitude BETWEEN $1 - $3::float8 / 111.195 AND $1 + $3 / 111.195
+    AND t.longitude IS NOT NULL
+) near
+WHERE distance_km <= $3
+ORDER BY distance_km, id
+LIMIT $4
+`
+
+type GetTargetsNearRow struct {
+	ID         int32
+	Mission    int32
+	Name       string
+	Country    string
+	Notes      string
+	Completed  bool
+	Latitude   pgtype.Float8
+	Longitude  pgtype.Float8
+	Address    string
+	DistanceKm float64
+}
+
+type GetTargetsNearParams struct {
+	Lat      float64
+	Lng      float64
+	RadiusKm float64
+	MaxRows  int32
+}
+
+func (q *Queries) GetTargetsNear(ctx context.Context, arg GetTargetsNearParams) ([]GetTargetsNearRow, error) {
+	rows, err := q.db.Query(ctx, getTargetsNear,
+		arg.Lat,
+		arg.Lng,
+		arg.RadiusKm,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTargetsNearRow
+	for rows.Next() {
+		var i GetTargetsNearRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mission,
+			&i.Name,
+			&i.Country,
+			&i.Notes,
+			&i.Completed,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Address,
+			&i.DistanceKm,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTargetsPage = `-- name: GetTargetsPage :many
+SELECT id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+FROM targets
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type GetTargetsPageParams struct {
+	After   int32
+	MaxRows int32
+}
+
+func (q *Queries) GetTargetsPage(ctx context.Context, arg GetTargetsPageParams) ([]Target, error) {
+	rows, err := q.db.Query(ctx, getTargetsPage, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Target
+	for rows.Next() {
+		var i Target
+		if err := rows.Scan(
+			&i.ID,
+			&i.Mission,
+			&i.Name,
+			&i.Country,
+			&i.Notes,
+			&i.Completed,
+			&i.SearchVector,
+			&i.CreatedAt,
+			&i.CompletedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Address,
+			&i.Subject,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTargetsPerCountry = `-- name: GetTargetsPerCountry :many
+SELECT
+    country,
+    COUNT(*) AS targets,
+    COUNT(*) FILTER (WHERE completed) AS completed
+FROM targets
+GROUP BY country
+ORDER BY targets DESC, country
+`
+
+type GetTargetsPerCountryRow struct {
+	Country   string
+	Targets   int64
+	Completed int64
+}
+
+func (q *Queries) GetTargetsPerCountry(ctx context.Context) ([]GetTargetsPerCountryRow, error) {
+	rows, err := q.db.Query(ctx, getTargetsPerCountry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTargetsPerCountryRow
+	for rows.Next() {
+		var i GetTargetsPerCountryRow
+		if err := rows.Scan(&i.Country, &i.Targets, &i.Completed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnassignedCatSkills = `-- name: GetUnassignedCatSkills :many
+SELECT cs.cat, cs.skill, cs.level, cs.certified_until
+FROM cat_skills cs
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM missions m
+    WHERE m.assignee = cs.cat
+      AND NOT m.completed
+)
+ORDER BY cs.cat, cs.skill
+`
+
+func (q *Queries) GetUnassignedCatSkills(ctx context.Context) ([]CatSkill, error) {
+	rows, err := q.db.Query(ctx, getUnassignedCatSkills)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CatSkill
+	for rows.Next() {
+		var i CatSkill
+		if err := rows.Scan(
+			&i.Cat,
+			&i.Skill,
+			&i.Level,
+			&i.CertifiedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnassignedCats = `-- name: GetUnassignedCats :many
+-- The cats without an active mission.
+SELECT id, name, years_of_experience, breed, salary, salary_currency
+FROM cats
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM missions m
+    WHERE m.assignee = cats.id
+      AND NOT m.completed
+)
+ORDER BY id
+`
+
+func (q *Queries) GetUnassignedCats(ctx context.Context) ([]Cat, error) {
+	rows, err := q.db.Query(ctx, getUnassignedCats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Cat
+	for rows.Next() {
+		var i Cat
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YearsOfExperience,
+			&i.Breed,
+			&i.Salary,
+			&i.SalaryCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnpublishedOutboxEvents = `-- name: GetUnpublishedOutboxEvents :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at
+FROM outbox
+WHERE published_at IS NULL AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type GetUnpublishedOutboxEventsParams struct {
+	AfterOffset int64
+	MaxEvents   int32
+}
+
+func (q *Queries) GetUnpublishedOutboxEvents(ctx context.Context, arg GetUnpublishedOutboxEventsParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, getUnpublishedOutboxEvents, arg.AfterOffset, arg.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, url, secret, event_types, active, created_at
+FROM webhooks
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id int32) (Webhook, error) {
+	row := q.db.QueryRow(ctx, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDeliveries = `-- name: GetWebhookDeliveries :many
+SELECT id, webhook, event_type, payload, status, attempts, next_attempt_at, last_error, last_response_code, created_at, delivered_at
+FROM webhook_deliveries
+WHERE webhook = $1
+ORDER BY id DESC
+LIMIT $2
+`
+
+type GetWebhookDeliveriesParams struct {
+	Webhook int32
+	Limit   int32
+}
+
+func (q *Queries) GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, getWebhookDeliveries, arg.Webhook, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.Webhook,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.LastResponseCode,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventsPublished = `-- name: MarkOutboxEventsPublished :execrows
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error) {
+	result, err := q.db.Exec(ctx, markOutboxEventsPublished, ids)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markWebhookDeliveryFailed = `-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    next_attempt_at = $3,
+    last_error = $4,
+    last_response_code = $5
+WHERE id = $1
+`
+
+type MarkWebhookDeliveryFailedParams struct {
+	ID               int32
+	Status           string
+	NextAttemptAt    pgtype.Timestamptz
+	LastError        string
+	LastResponseCode pgtype.Int4
+}
+
+func (q *Queries) MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error {
+	_, err := q.db.Exec(ctx, markWebhookDeliveryFailed,
+		arg.ID,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.LastError,
+		arg.LastResponseCode,
+	)
+	return err
+}
+
+const markWebhookDeliverySucceeded = `-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_error = '',
+    last_response_code = $2,
+    delivered_at = now()
+WHERE id = $1
+`
+
+type MarkWebhookDeliverySucceededParams struct {
+	ID               int32
+	LastResponseCode pgtype.Int4
+}
+
+func (q *Queries) MarkWebhookDeliverySucceeded(ctx context.Context, arg MarkWebhookDeliverySucceededParams) error {
+	_, err := q.db.Exec(ctx, markWebhookDeliverySucceeded, arg.ID, arg.LastResponseCode)
+	return err
+}
+
+const moveSubjectTargets = `-- name: MoveSubjectTargets :execrows
+UPDATE targets
+SET subject = $1
+WHERE subject = $2
+`
+
+type MoveSubjectTargetsParams struct {
+	ToSubject   pgtype.Int4
+	FromSubject pgtype.Int4
+}
+
+func (q *Queries) MoveSubjectTargets(ctx context.Context, arg MoveSubjectTargetsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveSubjectTargets, arg.ToSubject, arg.FromSubject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const moveTarget = `-- name: MoveTarget :one
+UPDATE targets
+SET mission = $2
+WHERE id = $1
+RETURNING id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+`
+
+type MoveTargetParams struct {
+	ID      int32
+	Mission int32
+}
+
+func (q *Queries) MoveTarget(ctx context.Context, arg MoveTargetParams) (Target, error) {
+	row := q.db.QueryRow(ctx, moveTarget, arg.ID, arg.Mission)
+	var i Target
+	err := row.Scan(
 		&i.ID,
+		&i.Mission,
 		&i.Name,
-		&i.YearsOfExperience,
-		&i.Breed,
-		&i.Salary,
+		&i.Country,
+		&i.Notes,
+		&i.Completed,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Address,
+		&i.Subject,
 	)
 	return i, err
 }
 
-const getCatMission = `-- name: GetCatMission :one
-SELECT id, assignee, completed
-FROM missions
-WHERE assignee = $1
-LIMIT 1
+const redeliverWebhookDelivery = `-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = now(),
+    last_error = ''
+WHERE id = $1 AND webhook = $2
+RETURNING id, webhook, event_type, payload, status, attempts, next_attempt_at, last_error, last_response_code, created_at, delivered_at
 `
 
-func (q *Queries) GetCatMission(ctx context.Context, assignee pgtype.Int4) (Mission, error) {
-	row := q.db.QueryRow(ctx, getCatMission, assignee)
-	var i Mission
-	err := row.Scan(&i.ID, &i.Assignee, &i.Completed)
+type RedeliverWebhookDeliveryParams struct {
+	ID      int32
+	Webhook int32
+}
+
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, arg RedeliverWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, redeliverWebhookDelivery, arg.ID, arg.Webhook)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.Webhook,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.LastResponseCode,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
 	return i, err
 }
 
-const getMission = `-- name: GetMission :one
-SELECT id, assignee, completed
-FROM missions
-WHERE missions.id = $1
+const resetOutboxEvents = `-- name: ResetOutboxEvents :execrows
+UPDATE outbox
+SET published_at = NULL
+WHERE id >= $1
 `
 
-func (q *Queries) GetMission(ctx context.Context, id int32) (Mission, error) {
-	row := q.db.QueryRow(ctx, getMission, id)
-	var i Mission
-	err := row.Scan(&i.ID, &i.Assignee, &i.Completed)
-	return i, err
+func (q *Queries) ResetOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	result, err := q.db.Exec(ctx, resetOutboxEvents, fromOffset)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const getMissionByTargetID = `-- name: GetMissionByTargetID :one
-SELECT 
-    m.id AS mission_id,
-    m.assignee,
-    m.completed
-FROM missions m
-JOIN targets t ON m.id = t.mission
-WHERE t.id = $1
+const resetSequences = `-- name: ResetSequences :exec
+SELECT
+  setval(pg_get_serial_sequence('cats', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM cats), false),
+  setval(pg_get_serial_sequence('missions', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM missions), false),
+  setval(pg_get_serial_sequence('targets', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM targets), false),
+  setval(pg_get_serial_sequence('subjects', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM subjects), false)
 `
 
-type GetMissionByTargetIDRow struct {
-	MissionID int32
-	Assignee  pgtype.Int4
-	Completed bool
+func (q *Queries) ResetSequences(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, resetSequences)
+	return err
 }
 
-func (q *Queries) GetMissionByTargetID(ctx context.Context, id int32) (GetMissionByTargetIDRow, error) {
-	row := q.db.QueryRow(ctx, getMissionByTargetID, id)
-	var i GetMissionByTargetIDRow
-	err := row.Scan(&i.MissionID, &i.Assignee, &i.Completed)
-	return i, err
+const restoreCat = `-- name: RestoreCat :exec
+INSERT INTO cats (
+  id, name, years_of_experience, breed, salary, salary_currency
+) VALUES ( $1, $2, $3, $4, $5, $6 )
+`
+
+type RestoreCatParams struct {
+	ID                int32
+	Name              string
+	YearsOfExperience int32
+	Breed             string
+	Salary            int64
+	SalaryCurrency    string
 }
 
-const getMissionTargets = `-- name: GetMissionTargets :many
-SELECT id, mission, name, country, notes, completed
-FROM targets
-WHERE mission = $1
+func (q *Queries) RestoreCat(ctx context.Context, arg RestoreCatParams) error {
+	_, err := q.db.Exec(ctx, restoreCat,
+		arg.ID,
+		arg.Name,
+		arg.YearsOfExperience,
+		arg.Breed,
+		arg.Salary,
+		arg.SalaryCurrency,
+	)
+	return err
+}
+
+const restoreMission = `-- name: RestoreMission :exec
+INSERT INTO missions (
+  id, assignee, completed, created_at, assigned_at, completed_at
+) VALUES ( $1, $2, $3, $4, $5, $6 )
 `
 
-func (q *Queries) GetMissionTargets(ctx context.Context, mission int32) ([]Target, error) {
-	rows, err := q.db.Query(ctx, getMissionTargets, mission)
+type RestoreMissionParams struct {
+	ID          int32
+	Assignee    pgtype.Int4
+	Completed   bool
+	CreatedAt   pgtype.Timestamptz
+	AssignedAt  pgtype.Timestamptz
+	CompletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) RestoreMission(ctx context.Context, arg RestoreMissionParams) error {
+	_, err := q.db.Exec(ctx, restoreMission,
+		arg.ID,
+		arg.Assignee,
+		arg.Completed,
+		arg.CreatedAt,
+		arg.AssignedAt,
+		arg.CompletedAt,
+	)
+	return err
+}
+
+const restoreSubject = `-- name: RestoreSubject :exec
+INSERT INTO subjects (
+  id, name, country, created_at
+) VALUES ( $1, $2, $3, $4 )
+`
+
+type RestoreSubjectParams struct {
+	ID        int32
+	Name      string
+	Country   string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) RestoreSubject(ctx context.Context, arg RestoreSubjectParams) error {
+	_, err := q.db.Exec(ctx, restoreSubject,
+		arg.ID,
+		arg.Name,
+		arg.Country,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const restoreTarget = `-- name: RestoreTarget :exec
+INSERT INTO targets (
+  id, mission, name, country, notes, completed, created_at, completed_at, latitude, longitude, address, subject
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12 )
+`
+
+type RestoreTargetParams struct {
+	ID          int32
+	Mission     int32
+	Name        string
+	Country     string
+	Notes       string
+	Completed   bool
+	CreatedAt   pgtype.Timestamptz
+	CompletedAt pgtype.Timestamptz
+	Latitude    pgtype.Float8
+	Longitude   pgtype.Float8
+	Address     string
+	Subject     pgtype.Int4
+}
+
+func (q *Queries) RestoreTarget(ctx context.Context, arg RestoreTargetParams) error {
+	_, err := q.db.Exec(ctx, restoreTarget,
+		arg.ID,
+		arg.Mission,
+		arg.Name,
+		arg.Country,
+		arg.Notes,
+		arg.Completed,
+		arg.CreatedAt,
+		arg.CompletedAt,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Address,
+		arg.Subject,
+	)
+	return err
+}
+
+const searchTargets = `-- name: SearchTargets :many
+SELECT
+    t.id,
+    t.mission,
+    t.name,
+    t.country,
+    t.notes,
+    t.completed,
+    m.assignee AS mission_assignee,
+    m.completed AS mission_completed,
+    ts_rank(t.search_vector, to_tsquery('simple', $1::text)) AS rank,
+    ts_headline('simple', translate(t.notes, chr(57344) || chr(57345), ''), to_tsquery('simple', $1::text), 'StartSel=' || chr(57344) || ', StopSel=' || chr(57345) || ', MaxFragments=2') AS snippet
+FROM targets t
+JOIN missions m ON m.id = t.mission
+WHERE t.search_vector @@ to_tsquery('simple', $1::text)
+ORDER BY rank DESC, t.id
+LIMIT $2
+`
+
+type SearchTargetsRow struct {
+	ID               int32
+	Mission          int32
+	Name             string
+	Country          string
+	Notes            string
+	Completed        bool
+	MissionAssignee  pgtype.Int4
+	MissionCompleted bool
+	Rank             float32
+	Snippet          string
+}
+
+type SearchTargetsParams struct {
+	Query      string
+	MaxResults int32
+}
+
+func (q *Queries) SearchTargets(ctx context.Context, arg SearchTargetsParams) ([]SearchTargetsRow, error) {
+	rows, err := q.db.Query(ctx, searchTargets, arg.Query, arg.MaxResults)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Target
+	var items []SearchTargetsRow
 	for rows.Next() {
-		var i Target
+		var i SearchTargetsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Mission,
@@ -333,6 +3088,10 @@ func (q *Queries) GetMissionTargets(ctx context.Context, mission int32) ([]Targe
 			&i.Country,
 			&i.Notes,
 			&i.Completed,
+			&i.MissionAssignee,
+			&i.MissionCompleted,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -344,41 +3103,170 @@ func (q *Queries) GetMissionTargets(ctx context.Context, mission int32) ([]Targe
 	return items, nil
 }
 
-const getTarget = `-- name: GetTarget :one
-SELECT id, mission, name, country, notes, completed
-FROM targets
+const setCatSkill = `-- name: SetCatSkill :one
+INSERT INTO cat_skills (cat, skill, level, certified_until)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (cat, skill) DO UPDATE
+SET
+  level = excluded.level,
+  certified_until = excluded.certified_until
+RETURNING cat, skill, level, certified_until
+`
+
+type SetCatSkillParams struct {
+	Cat            int32
+	Skill          int32
+	Level          int32
+	CertifiedUntil pgtype.Timestamptz
+}
+
+func (q *Queries) SetCatSkill(ctx context.Context, arg SetCatSkillParams) (CatSkill, error) {
+	row := q.db.QueryRow(ctx, setCatSkill,
+		arg.Cat,
+		arg.Skill,
+		arg.Level,
+		arg.CertifiedUntil,
+	)
+	var i CatSkill
+	err := row.Scan(
+		&i.Cat,
+		&i.Skill,
+		&i.Level,
+		&i.CertifiedUntil,
+	)
+	return i, err
+}
+
+const setExchangeRate = `-- name: SetExchangeRate :one
+INSERT INTO exchange_rates (currency, rate, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (currency) DO UPDATE
+SET
+  rate = excluded.rate,
+  updated_at = excluded.updated_at
+RETURNING currency, rate, updated_at
+`
+
+type SetExchangeRateParams struct {
+	Currency string
+	Rate     float64
+}
+
+func (q *Queries) SetExchangeRate(ctx context.Context, arg SetExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRow(ctx, setExchangeRate, arg.Currency, arg.Rate)
+	var i ExchangeRate
+	err := row.Scan(&i.Currency, &i.Rate, &i.UpdatedAt)
+	return i, err
+}
+
+const setMissionBudget = `-- name: SetMissionBudget :one
+INSERT INTO mission_budgets (mission, amount, currency)
+VALUES ($1, $2, $3)
+ON CONFLICT (mission) DO UPDATE
+SET
+  amount = excluded.amount,
+  currency = excluded.currency
+RETURNING mission, amount, currency
+`
+
+type SetMissionBudgetParams struct {
+	Mission  int32
+	Amount   int64
+	Currency string
+}
+
+func (q *Queries) SetMissionBudget(ctx context.Context, arg SetMissionBudgetParams) (MissionBudget, error) {
+	row := q.db.QueryRow(ctx, setMissionBudget, arg.Mission, arg.Amount, arg.Currency)
+	var i MissionBudget
+	err := row.Scan(&i.Mission, &i.Amount, &i.Currency)
+	return i, err
+}
+
+const setTargetSkill = `-- name: SetTargetSkill :one
+INSERT INTO target_skills (target, skill, min_level, certified)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (target, skill) DO UPDATE
+SET
+  min_level = excluded.min_level,
+  certified = excluded.certified
+RETURNING target, skill, min_level, certified
+`
+
+type SetTargetSkillParams struct {
+	Target    int32
+	Skill     int32
+	MinLevel  int32
+	Certified bool
+}
+
+func (q *Queries) SetTargetSkill(ctx context.Context, arg SetTargetSkillParams) (TargetSkill, error) {
+	row := q.db.QueryRow(ctx, setTargetSkill,
+		arg.Target,
+		arg.Skill,
+		arg.MinLevel,
+		arg.Certified,
+	)
+	var i TargetSkill
+	err := row.Scan(
+		&i.Target,
+		&i.Skill,
+		&i.MinLevel,
+		&i.Certified,
+	)
+	return i, err
+}
+
+const updateAvailabilityPeriod = `-- name: UpdateAvailabilityPeriod :one
+UPDATE availability_periods
+SET kind = $2,
+    starts_at = $3,
+    ends_at = $4
 WHERE id = $1
-LIMIT 1
+RETURNING id, cat, kind, starts_at, ends_at
 `
 
-func (q *Queries) GetTarget(ctx context.Context, id int32) (Target, error) {
-	row := q.db.QueryRow(ctx, getTarget, id)
-	var i Target
+type UpdateAvailabilityPeriodParams struct {
+	ID       int32
+	Kind     string
+	StartsAt pgtype.Timestamptz
+	EndsAt   pgtype.Timestamptz
+}
+
+func (q *Queries) UpdateAvailabilityPeriod(ctx context.Context, arg UpdateAvailabilityPeriodParams) (AvailabilityPeriod, error) {
+	row := q.db.QueryRow(ctx, updateAvailabilityPeriod,
+		arg.ID,
+		arg.Kind,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+	var i AvailabilityPeriod
 	err := row.Scan(
 		&i.ID,
-		&i.Mission,
-		&i.Name,
-		&i.Country,
-		&i.Notes,
-		&i.Completed,
+		&i.Cat,
+		&i.Kind,
+		&i.StartsAt,
+		&i.EndsAt,
 	)
 	return i, err
 }
 
 const updateCatSalary = `-- name: UpdateCatSalary :one
 UPDATE cats
-SET salary = $2
+SET
+  salary = $2,
+  salary_currency = COALESCE(NULLIF($3, ''), salary_currency)
 WHERE id = $1
-RETURNING id, name, years_of_experience, breed, salary
+RETURNING id, name, years_of_experience, breed, salary, salary_currency
 `
 
 type UpdateCatSalaryParams struct {
-	ID     int32
-	Salary int32
+	ID             int32
+	Salary         int64
+	SalaryCurrency string
 }
 
 func (q *Queries) UpdateCatSalary(ctx context.Context, arg UpdateCatSalaryParams) (Cat, error) {
-	row := q.db.QueryRow(ctx, updateCatSalary, arg.ID, arg.Salary)
+	row := q.db.QueryRow(ctx, updateCatSalary, arg.ID, arg.Salary, arg.SalaryCurrency)
 	var i Cat
 	err := row.Scan(
 		&i.ID,
@@ -386,6 +3274,73 @@ func (q *Queries) UpdateCatSalary(ctx context.Context, arg UpdateCatSalaryParams
 		&i.YearsOfExperience,
 		&i.Breed,
 		&i.Salary,
+		&i.SalaryCurrency,
+	)
+	return i, err
+}
+
+const updateMissionTemplate = `-- name: UpdateMissionTemplate :one
+UPDATE mission_templates
+SET name = $2,
+    targets = $3
+WHERE id = $1
+RETURNING id, name, targets, created_at
+`
+
+type UpdateMissionTemplateParams struct {
+	ID      int32
+	Name    string
+	Targets []byte
+}
+
+func (q *Queries) UpdateMissionTemplate(ctx context.Context, arg UpdateMissionTemplateParams) (MissionTemplate, error) {
+	row := q.db.QueryRow(ctx, updateMissionTemplate, arg.ID, arg.Name, arg.Targets)
+	var i MissionTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Targets,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateTargetLocation = `-- name: UpdateTargetLocation :one
+UPDATE targets
+SET latitude = $2, longitude = $3, address = $4
+WHERE id = $1
+RETURNING id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
+`
+
+type UpdateTargetLocationParams struct {
+	ID        int32
+	Latitude  pgtype.Float8
+	Longitude pgtype.Float8
+	Address   string
+}
+
+func (q *Queries) UpdateTargetLocation(ctx context.Context, arg UpdateTargetLocationParams) (Target, error) {
+	row := q.db.QueryRow(ctx, updateTargetLocation,
+		arg.ID,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Address,
+	)
+	var i Target
+	err := row.Scan(
+		&i.ID,
+		&i.Mission,
+		&i.Name,
+		&i.Country,
+		&i.Notes,
+		&i.Completed,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Address,
+		&i.Subject,
 	)
 	return i, err
 }
@@ -394,7 +3349,7 @@ const updateTargetNotes = `-- name: UpdateTargetNotes :one
 UPDATE targets
 SET notes = $2
 WHERE id = $1
-RETURNING id, mission, name, country, notes, completed
+RETURNING id, mission, name, country, notes, completed, search_vector, created_at, completed_at, latitude, longitude, address, subject
 `
 
 type UpdateTargetNotesParams struct {
@@ -412,6 +3367,48 @@ func (q *Queries) UpdateTargetNotes(ctx context.Context, arg UpdateTargetNotesPa
 		&i.Country,
 		&i.Notes,
 		&i.Completed,
+		&i.SearchVector,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Address,
+		&i.Subject,
+	)
+	return i, err
+}
+
+const updateWebhook = `-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url = $2,
+    event_types = $3,
+    active = $4
+WHERE id = $1
+RETURNING id, url, secret, event_types, active, created_at
+`
+
+type UpdateWebhookParams struct {
+	ID         int32
+	Url        string
+	EventTypes []string
+	Active     bool
+}
+
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, updateWebhook,
+		arg.ID,
+		arg.Url,
+		arg.EventTypes,
+		arg.Active,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Active,
+		&i.CreatedAt,
 	)
 	return i, err
 }
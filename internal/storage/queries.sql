@@ -4,8 +4,8 @@ FROM cats;
 
 -- name: CreateCat :one
 INSERT INTO cats (
-  name, years_of_experience, breed, salary
-) VALUES ( $1, $2, $3, $4)
+  name, years_of_experience, breed, salary, salary_currency
+) VALUES ( $1, $2, $3, $4, $5 )
 RETURNING *;
 
 -- name: GetCat :one
@@ -16,7 +16,9 @@ LIMIT 1;
 
 -- name: UpdateCatSalary :one 
 UPDATE cats
-SET salary = $2
+SET
+  salary = $2,
+  salary_currency = COALESCE(NULLIF($3, ''), salary_currency)
 WHERE id = $1
 RETURNING *;
 
@@ -39,6 +41,13 @@ SELECT *
 FROM missions
 WHERE missions.id = $1;
 
+-- name: GetMissionForUpdate :one
+-- The mission, locked until the end of the transaction.
+SELECT *
+FROM missions
+WHERE id = $1
+FOR UPDATE;
+
 -- name: DeleteMission :execrows
 DELETE
 FROM missions
@@ -46,7 +55,13 @@ WHERE id = $1;
 
 -- name: AssignCat :one
 UPDATE missions
-SET assignee = $2
+SET
+  assignee = $2,
+  assigned_at = CASE
+    WHEN $2 IS NULL THEN NULL
+    WHEN assignee IS NOT DISTINCT FROM $2 THEN assigned_at
+    ELSE now()
+  END
 WHERE id = $1
 RETURNING *;
 
@@ -58,14 +73,26 @@ LIMIT 1;
 
 -- name: CompleteMission :one
 UPDATE missions
-SET completed = true
+SET completed = true, completed_at = COALESCE(completed_at, now())
 WHERE id = $1
 RETURNING *;
 
+-- name: CreateMissionAssignment :exec
+-- Records the assignment of the cat to the mission, at assigned_at of the mission.
+INSERT INTO mission_assignments (
+  mission, cat, assigned_at
+) VALUES ( $1, $2, $3 );
+
+-- name: EndMissionAssignment :exec
+-- Ends the open assignment of the mission, if any.
+UPDATE mission_assignments
+SET unassigned_at = now()
+WHERE mission = $1 AND unassigned_at IS NULL;
+
 -- name: CreateTarget :one
 INSERT INTO targets (
-  mission, name, country, notes
-) VALUES ( $1, $2, $3, $4 )
+  mission, name, country, notes, latitude, longitude, address, subject
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8 )
 RETURNING *;
 
 -- name: GetMissionTargets :many
@@ -84,6 +111,12 @@ SET notes = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateTargetLocation :one
+UPDATE targets
+SET latitude = $2, longitude = $3, address = $4
+WHERE id = $1
+RETURNING *;
+
 -- name: GetMissionByTargetID :one
 SELECT 
     m.id AS mission_id,
@@ -101,6 +134,684 @@ LIMIT 1;
 
 -- name: CompleteTarget :one
 UPDATE targets
-SET completed = true
+SET completed = true, completed_at = COALESCE(completed_at, now())
+WHERE id = $1
+RETURNING *;
+
+-- name: MoveTarget :one
+UPDATE targets
+SET mission = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  url, secret, event_types
+) VALUES ( $1, $2, $3 )
+RETURNING *;
+
+-- name: GetAllWebhooks :many
+SELECT *
+FROM webhooks
+ORDER BY id;
+
+-- name: GetWebhook :one
+SELECT *
+FROM webhooks
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url = $2,
+    event_types = $3,
+    active = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhook :execrows
+DELETE
+FROM webhooks
+WHERE id = $1;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (
+  webhook, event_type, payload
+)
+SELECT id, @event_type::text, @payload::jsonb
+FROM webhooks
+WHERE active AND @event_type::text = ANY(event_types);
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = @lease_until
+WHERE id IN (
+  SELECT d.id
+  FROM webhook_deliveries d
+  JOIN webhooks w ON w.id = d.webhook
+  WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND w.active
+  ORDER BY d.id
+  LIMIT @batch_size
+  FOR UPDATE OF d SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded',
+    attempts = attempts + 1,
+    last_error = '',
+    last_response_code = $2,
+    delivered_at = now()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    next_attempt_at = $3,
+    last_error = $4,
+    last_response_code = $5
+WHERE id = $1;
+
+-- name: GetWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook = $1
+ORDER BY id DESC
+LIMIT $2;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = now(),
+    last_error = ''
+WHERE id = $1 AND webhook = $2
+RETURNING *;
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox (
+  aggregate_type, aggregate_id, event_type, payload
+) VALUES ( $1, $2, $3, $4 );
+
+-- name: GetUnpublishedOutboxEvents :many
+SELECT *
+FROM outbox
+WHERE published_at IS NULL AND id > @after_offset
+ORDER BY id
+LIMIT @max_events;
+
+-- name: MarkOutboxEventsPublished :execrows
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY(@ids::bigint[]);
+
+-- name: GetOutboxEvents :many
+SELECT *
+FROM outbox
+WHERE id >= @from_offset
+ORDER BY id
+LIMIT @max_events;
+
+-- name: ResetOutboxEvents :execrows
+UPDATE outbox
+SET published_at = NULL
+WHERE id >= @from_offset;
+
+-- name: SearchTargets :many
+SELECT
+    t.id,
+    t.mission,
+    t.name,
+    t.country,
+    t.notes,
+    t.completed,
+    m.assignee AS mission_assignee,
+    m.completed AS mission_completed,
+    ts_rank(t.search_vector, to_tsquery('simple', @query::text)) AS rank,
+    ts_headline('simple', translate(t.notes, chr(57344) || chr(57345), ''), to_tsquery('simple', @query::text), 'StartSel=' || chr(57344) || ', StopSel=' || chr(57345) || ', MaxFragments=2') AS snippet
+FROM targets t
+JOIN missions m ON m.id = t.mission
+WHERE t.search_vector @@ to_tsquery('simple', @query::text)
+ORDER BY rank DESC, t.id
+LIMIT @max_results;
+
+-- name: CreateMissionTemplate :one
+INSERT INTO mission_templates (
+  name, targets
+) VALUES ( $1, $2 )
+RETURNING *;
+
+-- name: GetAllMissionTemplates :many
+SELECT *
+FROM mission_templates
+ORDER BY id;
+
+-- name: GetMissionTemplate :one
+SELECT *
+FROM mission_templates
 WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateMissionTemplate :one
+UPDATE mission_templates
+SET name = $2,
+    targets = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteMissionTemplate :execrows
+DELETE
+FROM mission_templates
+WHERE id = $1;
+
+-- name: CreateTargetMove :one
+INSERT INTO target_moves (
+  target, from_mission, to_mission
+) VALUES ( $1, $2, $3 )
 RETURNING *;
+
+-- name: GetTargetMoves :many
+SELECT *
+FROM target_moves
+WHERE target = $1
+ORDER BY id;
+
+-- name: GetAllTargets :many
+SELECT *
+FROM targets
+ORDER BY id;
+
+-- name: GetAllSubjects :many
+SELECT *
+FROM subjects
+ORDER BY id;
+
+-- name: GetSubjectsPage :many
+SELECT *
+FROM subjects
+WHERE id > @after
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: GetTargetsPage :many
+SELECT *
+FROM targets
+WHERE id > @after
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: RestoreCat :exec
+INSERT INTO cats (
+  id, name, years_of_experience, breed, salary, salary_currency
+) VALUES ( $1, $2, $3, $4, $5, $6 );
+
+-- name: RestoreMission :exec
+INSERT INTO missions (
+  id, assignee, completed, created_at, assigned_at, completed_at
+) VALUES ( $1, $2, $3, $4, $5, $6 );
+
+-- name: RestoreSubject :exec
+INSERT INTO subjects (
+  id, name, country, created_at
+) VALUES ( $1, $2, $3, $4 );
+
+-- name: RestoreTarget :exec
+INSERT INTO targets (
+  id, mission, name, country, notes, completed, created_at, completed_at, latitude, longitude, address, subject
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12 );
+
+-- name: ResetSequences :exec
+SELECT
+  setval(pg_get_serial_sequence('cats', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM cats), false),
+  setval(pg_get_serial_sequence('missions', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM missions), false),
+  setval(pg_get_serial_sequence('targets', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM targets), false),
+  setval(pg_get_serial_sequence('subjects', 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM subjects), false);
+
+-- name: GetCatsPage :many
+SELECT *
+FROM cats
+WHERE id > @after
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: GetMissionsPage :many
+SELECT *
+FROM missions
+WHERE id > @after
+ORDER BY id
+LIMIT @max_rows;
+
+-- name: GetMissionTargetsPage :many
+SELECT
+    m.id AS mission_id,
+    m.assignee,
+    m.completed AS mission_completed,
+    t.id AS target_id,
+    t.name,
+    t.country,
+    t.notes,
+    t.completed AS target_completed
+FROM targets t
+JOIN missions m ON m.id = t.mission
+WHERE (t.mission, t.id) > (@after_mission::int, @after_target::int)
+ORDER BY t.mission, t.id
+LIMIT @max_rows;
+
+-- name: GetCatWorkloadPage :many
+SELECT
+    c.id,
+    c.name,
+    c.breed,
+    c.salary,
+    c.salary_currency,
+    COUNT(DISTINCT m.id) FILTER (WHERE NOT m.completed) AS active_missions,
+    COUNT(DISTINCT m.id) FILTER (WHERE m.completed) AS completed_missions,
+    COUNT(t.id) FILTER (WHERE NOT t.completed) AS open_targets,
+    COUNT(t.id) FILTER (WHERE t.completed) AS completed_targets
+FROM cats c
+LEFT JOIN missions m ON m.assignee = c.id
+LEFT JOIN targets t ON t.mission = m.id
+WHERE c.id > @after
+GROUP BY c.id
+ORDER BY c.id
+LIMIT @max_rows;
+
+-- name: GetMissionCompletionRate :many
+SELECT
+    date_trunc(@time_window::text, created_at, 'UTC')::timestamptz AS period,
+    COUNT(*) AS created,
+    COUNT(*) FILTER (WHERE completed) AS completed
+FROM missions
+WHERE created_at >= @since
+GROUP BY period
+ORDER BY period;
+
+-- name: GetCompletionTimes :one
+SELECT
+    (SELECT COUNT(*) FROM missions WHERE completed_at IS NOT NULL) AS completed_missions,
+    (SELECT COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - created_at)), 0)::float8 FROM missions WHERE completed_at IS NOT NULL) AS mission_seconds,
+    (SELECT COUNT(*) FROM targets WHERE completed_at IS NOT NULL) AS completed_targets,
+    (SELECT COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - created_at)), 0)::float8 FROM targets WHERE completed_at IS NOT NULL) AS target_seconds;
+
+-- name: GetCatUtilization :many
+SELECT
+    c.id,
+    c.name,
+    COUNT(DISTINCT a.mission) AS missions,
+    (COALESCE(SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(a.unassigned_at, @now::timestamptz), COALESCE(m.completed_at, @now::timestamptz)) - a.assigned_at)), 0) / 86400)::float8 AS days_on_mission
+FROM cats c
+LEFT JOIN mission_assignments a ON a.cat = c.id
+LEFT JOIN missions m ON m.id = a.mission
+GROUP BY c.id
+ORDER BY c.id;
+
+-- name: GetTargetsPerCountry :many
+SELECT
+    country,
+    COUNT(*) AS targets,
+    COUNT(*) FILTER (WHERE completed) AS completed
+FROM targets
+GROUP BY country
+ORDER BY targets DESC, country;
+
+-- name: GetSalaryPerformance :many
+-- The sums of the cats of each salary currency.
+WITH performance AS (
+    SELECT
+        c.salary_currency,
+        c.salary::float8 AS salary,
+        (COUNT(DISTINCT m.id) FILTER (WHERE m.completed))::float8 AS missions,
+        (COUNT(t.id) FILTER (WHERE t.completed))::float8 AS targets
+    FROM cats c
+    LEFT JOIN missions m ON m.assignee = c.id
+    LEFT JOIN targets t ON t.mission = m.id
+    GROUP BY c.id
+)
+SELECT
+    salary_currency,
+    COUNT(*) AS cats,
+    COALESCE(SUM(salary), 0)::float8 AS sum_salary,
+    COALESCE(SUM(salary * salary), 0)::float8 AS sum_salary_squares,
+    COALESCE(SUM(missions), 0)::float8 AS sum_missions,
+    COALESCE(SUM(missions * missions), 0)::float8 AS sum_missions_squares,
+    COALESCE(SUM(salary * missions), 0)::float8 AS sum_salary_missions,
+    COALESCE(SUM(targets), 0)::float8 AS sum_targets,
+    COALESCE(SUM(targets * targets), 0)::float8 AS sum_targets_squares,
+    COALESCE(SUM(salary * targets), 0)::float8 AS sum_salary_targets
+FROM performance
+GROUP BY salary_currency
+ORDER BY salary_currency;
+
+-- name: CreateAvailabilityPeriod :one
+INSERT INTO availability_periods (
+  cat, kind, starts_at, ends_at
+) VALUES ( $1, $2, $3, $4 )
+RETURNING *;
+
+-- name: GetCatAvailabilityPeriods :many
+SELECT *
+FROM availability_periods
+WHERE cat = $1
+ORDER BY starts_at, id;
+
+-- name: GetAvailabilityPeriod :one
+SELECT *
+FROM availability_periods
+WHERE id = $1
+LIMIT 1;
+
+-- name: UpdateAvailabilityPeriod :one
+UPDATE availability_periods
+SET kind = $2,
+    starts_at = $3,
+    ends_at = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteAvailabilityPeriod :execrows
+DELETE
+FROM availability_periods
+WHERE id = $1;
+
+-- name: GetOverlappingAvailabilityPeriods :many
+-- The periods of the cat overlapping the window from since up to until.
+SELECT *
+FROM availability_periods
+WHERE cat = @cat
+  AND ends_at > @since
+  AND starts_at <= @until
+ORDER BY starts_at, id;
+
+-- name: GetAvailableCats :many
+SELECT *
+FROM cats
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM availability_periods p
+    WHERE p.cat = cats.id
+      AND p.starts_at <= @at
+      AND p.ends_at > @at
+)
+ORDER BY id;
+
+-- name: GetCatsAvailableBetween :many
+-- The cats without any availability period overlapping the window from since up to until.
+SELECT *
+FROM cats
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM availability_periods p
+    WHERE p.cat = cats.id
+      AND p.ends_at > @since
+      AND p.starts_at <= @until
+)
+ORDER BY id;
+
+-- name: CreateSkill :one
+INSERT INTO skills (name)
+VALUES ($1)
+RETURNING *;
+
+-- name: GetAllSkills :many
+SELECT *
+FROM skills
+ORDER BY name;
+
+-- name: GetSkill :one
+SELECT *
+FROM skills
+WHERE id = $1;
+
+-- name: DeleteSkill :execrows
+DELETE
+FROM skills
+WHERE id = $1;
+
+-- name: SetCatSkill :one
+INSERT INTO cat_skills (cat, skill, level, certified_until)
+VALUES (@cat, @skill, @level, @certified_until)
+ON CONFLICT (cat, skill) DO UPDATE
+SET
+  level = excluded.level,
+  certified_until = excluded.certified_until
+RETURNING *;
+
+-- name: GetCatSkills :many
+SELECT cs.*, s.name
+FROM cat_skills cs
+  JOIN skills s ON s.id = cs.skill
+WHERE cs.cat = $1
+ORDER BY s.name;
+
+-- name: DeleteCatSkill :execrows
+DELETE
+FROM cat_skills
+WHERE cat = $1
+  AND skill = $2;
+
+-- name: SetTargetSkill :one
+INSERT INTO target_skills (target, skill, min_level, certified)
+VALUES (@target, @skill, @min_level, @certified)
+ON CONFLICT (target, skill) DO UPDATE
+SET
+  min_level = excluded.min_level,
+  certified = excluded.certified
+RETURNING *;
+
+-- name: GetTargetSkills :many
+SELECT ts.*, s.name
+FROM target_skills ts
+  JOIN skills s ON s.id = ts.skill
+WHERE ts.target = $1
+ORDER BY s.name;
+
+-- name: DeleteTargetSkill :execrows
+DELETE
+FROM target_skills
+WHERE target = $1
+  AND skill = $2;
+
+-- name: GetMissionRequiredSkills :many
+-- The skills required by the pending targets of the mission, at the highest
+-- level any of them requires.
+SELECT ts.skill, s.name, MAX(ts.min_level)::INTEGER AS min_level, BOOL_OR(ts.certified) AS certified
+FROM target_skills ts
+  JOIN targets t ON t.id = ts.target
+  JOIN skills s ON s.id = ts.skill
+WHERE t.mission = $1
+  AND NOT t.completed
+GROUP BY ts.skill, s.name
+ORDER BY s.name;
+
+-- name: GetUnassignedCats :many
+-- The cats without an active mission.
+SELECT *
+FROM cats
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM missions m
+    WHERE m.assignee = cats.id
+      AND NOT m.completed
+)
+ORDER BY id;
+
+-- name: GetUnassignedCatSkills :many
+SELECT cs.*
+FROM cat_skills cs
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM missions m
+    WHERE m.assignee = cs.cat
+      AND NOT m.completed
+)
+ORDER BY cs.cat, cs.skill;
+
+-- name: SetMissionBudget :one
+INSERT INTO mission_budgets (mission, amount, currency)
+VALUES (@mission, @amount, @currency)
+ON CONFLICT (mission) DO UPDATE
+SET
+  amount = excluded.amount,
+  currency = excluded.currency
+RETURNING *;
+
+-- name: GetMissionBudget :one
+SELECT *
+FROM mission_budgets
+WHERE mission = $1
+LIMIT 1;
+
+-- name: DeleteMissionBudget :execrows
+DELETE
+FROM mission_budgets
+WHERE mission = $1;
+
+-- name: CreateMissionExpense :one
+INSERT INTO mission_expenses (
+  mission, category, amount, currency, description, receipt_number, receipt_vendor, receipt_url, incurred_at
+) VALUES (
+  @mission, @category, @amount, @currency, @description, @receipt_number, @receipt_vendor, @receipt_url, @incurred_at
+)
+RETURNING *;
+
+-- name: GetMissionExpenses :many
+SELECT *
+FROM mission_expenses
+WHERE mission = $1
+ORDER BY incurred_at, id;
+
+-- name: GetMissionExpense :one
+SELECT *
+FROM mission_expenses
+WHERE id = $1
+LIMIT 1;
+
+-- name: DeleteMissionExpense :execrows
+DELETE
+FROM mission_expenses
+WHERE id = $1;
+
+-- name: SetExchangeRate :one
+INSERT INTO exchange_rates (currency, rate, updated_at)
+VALUES (@currency, @rate, now())
+ON CONFLICT (currency) DO UPDATE
+SET
+  rate = excluded.rate,
+  updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: GetExchangeRates :many
+SELECT *
+FROM exchange_rates
+ORDER BY currency;
+
+-- name: DeleteExchangeRate :execrows
+DELETE
+FROM exchange_rates
+WHERE currency = $1;
+
+-- name: GetTargetsNear :many
+-- The targets within radius_km of the point, nearest first, by the haversine
+-- distance on a sphere of the mean radius of the Earth. The latitudes are
+-- prefiltered on the degrees of the radius, 111.195 km each.
+SELECT id, mission, name, country, notes, completed, latitude, longitude, address, distance_km
+FROM (
+  SELECT
+    t.id, t.mission, t.name, t.country, t.notes, t.completed, t.latitude, t.longitude, t.address,
+    (2 * 6371.0088 * asin(least(1, sqrt(
+      power(sin(radians(t.latitude - @lat::float8) / 2), 2) +
+      cos(radians(@lat)) * cos(radians(t.latitude)) * power(sin(radians(t.longitude - @lng::float8) / 2), 2)
+    ))))::float8 AS distance_km
+  FROM targets t
+  WHERE t.latitude BETWEEN @lat - @radius_km::float8 / 111.195 AND @lat + @radius_km / 111.195
+    AND t.longitude IS NOT NULL
+) near
+WHERE distance_km <= @radius_km
+ORDER BY distance_km, id
+LIMIT @max_rows;
+
+-- name: CreateSubject :one
+INSERT INTO subjects (
+  name, country
+) VALUES ( $1, $2 )
+RETURNING *;
+
+-- name: GetSubject :one
+SELECT *
+FROM subjects
+WHERE id = $1;
+
+-- name: GetSimilarSubjects :many
+-- The subjects with a name similar to the name by the % operator of pg_trgm,
+-- the most similar first. The names weigh 0.8 and the countries 0.2.
+SELECT *
+FROM subjects
+WHERE name % @name::text
+ORDER BY 0.8 * similarity(name, @name::text) + 0.2 * (
+    CASE WHEN lower(country) = lower(@country::text) THEN 1 ELSE similarity(country, @country::text) END
+  ) DESC, id
+LIMIT @max_subjects;
+
+-- name: GetSubjectsByIDs :many
+SELECT *
+FROM subjects
+WHERE id = ANY(@ids::int[])
+ORDER BY id;
+
+-- name: DeleteSubject :execrows
+DELETE FROM subjects
+WHERE id = $1;
+
+-- name: GetSubjectTargets :many
+SELECT *
+FROM targets
+WHERE subject = $1
+ORDER BY id;
+
+-- name: MoveSubjectTargets :execrows
+UPDATE targets
+SET subject = @to_subject
+WHERE subject = @from_subject;
+
+-- name: CreateTargetNote :one
+INSERT INTO target_notes (
+  target, previous_notes, notes
+) VALUES ( $1, $2, $3 )
+RETURNING *;
+
+-- name: GetSubjectNotes :many
+SELECT n.*
+FROM target_notes n
+JOIN targets t ON t.id = n.target
+WHERE t.subject = $1
+ORDER BY n.id;
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (
+  target, filename, content_type, size, blob_key
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING *;
+
+-- name: GetAttachment :one
+SELECT *
+FROM attachments
+WHERE id = $1;
+
+-- name: GetTargetAttachments :many
+SELECT *
+FROM attachments
+WHERE target = $1
+ORDER BY id;
+
+-- name: GetMissionAttachments :many
+SELECT a.*
+FROM attachments a
+JOIN targets t ON t.id = a.target
+WHERE t.mission = $1
+ORDER BY a.id;
+
+-- name: DeleteAttachment :execrows
+DELETE FROM attachments
+WHERE id = $1;
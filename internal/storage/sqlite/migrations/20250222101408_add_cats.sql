@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS cats (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(30) NOT NULL,
+  years_of_experience INTEGER NOT NULL DEFAULT 0,
+  breed VARCHAR(30) NOT NULL,
+  salary INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS cats;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The index is kept in sync with the targets by the triggers.
+CREATE VIRTUAL TABLE IF NOT EXISTS targets_fts USING fts4(
+  content="targets", name, country, notes, tokenize=unicode61 "remove_diacritics=0"
+);
+
+CREATE TRIGGER IF NOT EXISTS targets_fts_bu BEFORE UPDATE ON targets BEGIN
+  DELETE FROM targets_fts WHERE docid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS targets_fts_bd BEFORE DELETE ON targets BEGIN
+  DELETE FROM targets_fts WHERE docid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS targets_fts_au AFTER UPDATE ON targets BEGIN
+  INSERT INTO targets_fts (docid, name, country, notes) VALUES (new.id, new.name, new.country, new.notes);
+END;
+
+CREATE TRIGGER IF NOT EXISTS targets_fts_ai AFTER INSERT ON targets BEGIN
+  INSERT INTO targets_fts (docid, name, country, notes) VALUES (new.id, new.name, new.country, new.notes);
+END;
+
+INSERT INTO targets_fts (targets_fts) VALUES ('rebuild');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS targets_fts_ai;
+DROP TRIGGER IF EXISTS targets_fts_au;
+DROP TRIGGER IF EXISTS targets_fts_bd;
+DROP TRIGGER IF EXISTS targets_fts_bu;
+DROP TABLE IF EXISTS targets_fts;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Targets are a JSON array of target blueprints.
+CREATE TABLE IF NOT EXISTS mission_templates (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(128) NOT NULL,
+  targets BLOB NOT NULL,
+  created_at INTEGER NOT NULL DEFAULT (CAST(unixepoch('subsec')*1000 AS INTEGER))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mission_templates;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The audit log of targets moved between missions. It has no foreign keys, so
+-- the records outlive the targets and the missions.
+CREATE TABLE IF NOT EXISTS target_moves (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  target INTEGER NOT NULL,
+  from_mission INTEGER NOT NULL,
+  to_mission INTEGER NOT NULL,
+  moved_at INTEGER NOT NULL DEFAULT (CAST(unixepoch('subsec')*1000 AS INTEGER))
+);
+
+CREATE INDEX IF NOT EXISTS target_moves_target_idx ON target_moves (target);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS target_moves;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The timestamps of the analytics. The missions and the targets created
+-- before them count as created by the migration, and their past assignments
+-- and completions have no timestamps.
+--
+-- A column added by ALTER TABLE can't default to the current time, so the
+-- inserts set created_at.
+ALTER TABLE missions ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE missions ADD COLUMN assigned_at INTEGER DEFAULT NULL;
+ALTER TABLE missions ADD COLUMN completed_at INTEGER DEFAULT NULL;
+UPDATE missions SET created_at = CAST(unixepoch('subsec')*1000 AS INTEGER);
+
+ALTER TABLE targets ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE targets ADD COLUMN completed_at INTEGER DEFAULT NULL;
+UPDATE targets SET created_at = CAST(unixepoch('subsec')*1000 AS INTEGER);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE targets DROP COLUMN completed_at;
+ALTER TABLE targets DROP COLUMN created_at;
+
+ALTER TABLE missions DROP COLUMN completed_at;
+ALTER TABLE missions DROP COLUMN assigned_at;
+ALTER TABLE missions DROP COLUMN created_at;
+-- +goose StatementEnd
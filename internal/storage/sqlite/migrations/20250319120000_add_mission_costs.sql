@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The budgets of the missions. The amounts are in the minor units of the
+-- currency, like cents.
+CREATE TABLE IF NOT EXISTS mission_budgets (
+  mission INTEGER PRIMARY KEY REFERENCES missions(id) ON DELETE CASCADE,
+  amount INTEGER NOT NULL,
+  currency TEXT NOT NULL
+);
+
+-- The expenses of the missions, with the metadata of their receipts.
+CREATE TABLE IF NOT EXISTS mission_expenses (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  mission INTEGER NOT NULL REFERENCES missions(id) ON DELETE CASCADE,
+  category TEXT NOT NULL,
+  amount INTEGER NOT NULL,
+  currency TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  receipt_number TEXT NOT NULL DEFAULT '',
+  receipt_vendor TEXT NOT NULL DEFAULT '',
+  receipt_url TEXT NOT NULL DEFAULT '',
+  incurred_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS mission_expenses_mission_idx ON mission_expenses (mission, incurred_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mission_expenses;
+DROP TABLE IF EXISTS mission_budgets;
+-- +goose StatementEnd
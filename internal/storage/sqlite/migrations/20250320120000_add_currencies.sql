@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The salaries of the cats are in the minor units of their currency, like
+-- cents. The salaries before them were in whole US dollars. SQLite integers
+-- are 64-bit, so the column isn't widened like in Postgres.
+ALTER TABLE cats ADD COLUMN salary_currency TEXT NOT NULL DEFAULT 'USD';
+UPDATE cats SET salary = salary * 100;
+
+-- The exchange rates to the reporting currency: the value of a major unit of
+-- the currency in the reporting currency.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  currency TEXT PRIMARY KEY,
+  rate REAL NOT NULL,
+  updated_at INTEGER NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exchange_rates;
+
+UPDATE cats SET salary = salary / 100;
+ALTER TABLE cats DROP COLUMN salary_currency;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The optional coordinates of the targets, in degrees, and their address.
+-- The proximity queries prefilter the targets on the latitude.
+ALTER TABLE targets ADD COLUMN latitude REAL DEFAULT NULL;
+ALTER TABLE targets ADD COLUMN longitude REAL DEFAULT NULL;
+ALTER TABLE targets ADD COLUMN address TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS targets_latitude_idx ON targets (latitude) WHERE latitude IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS targets_latitude_idx;
+
+ALTER TABLE targets DROP COLUMN address;
+ALTER TABLE targets DROP COLUMN longitude;
+ALTER TABLE targets DROP COLUMN latitude;
+-- +goose StatementEnd
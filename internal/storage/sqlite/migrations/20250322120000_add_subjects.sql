@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The subjects are the people behind the targets, which may show up in
+-- several missions. The existing targets get a subject per name and country.
+CREATE TABLE IF NOT EXISTS subjects (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(30) NOT NULL,
+  country VARCHAR(30) NOT NULL,
+  created_at INTEGER NOT NULL DEFAULT (CAST(unixepoch('subsec')*1000 AS INTEGER))
+);
+
+ALTER TABLE targets ADD COLUMN subject INTEGER DEFAULT NULL REFERENCES subjects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS targets_subject_idx ON targets (subject);
+
+INSERT INTO subjects (name, country)
+SELECT name, country
+FROM targets
+GROUP BY name, country
+ORDER BY MIN(id);
+
+UPDATE targets
+SET subject = (SELECT s.id FROM subjects s WHERE s.name = targets.name AND s.country = targets.country);
+
+-- The audit log of the notes of the targets. Like the moves, it has no
+-- foreign keys.
+CREATE TABLE IF NOT EXISTS target_notes (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  target INTEGER NOT NULL,
+  previous_notes TEXT NOT NULL,
+  notes TEXT NOT NULL,
+  updated_at INTEGER NOT NULL DEFAULT (CAST(unixepoch('subsec')*1000 AS INTEGER))
+);
+
+CREATE INDEX IF NOT EXISTS target_notes_target_idx ON target_notes (target);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS target_notes;
+
+DROP INDEX IF EXISTS targets_subject_idx;
+
+ALTER TABLE targets DROP COLUMN subject;
+
+DROP TABLE IF EXISTS subjects;
+-- +goose StatementEnd
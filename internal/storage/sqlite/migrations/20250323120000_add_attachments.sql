@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The files attached to the targets. The content lives in the blob store under
+-- blob_key, only the metadata is kept here.
+CREATE TABLE IF NOT EXISTS attachments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  target INTEGER NOT NULL REFERENCES targets(id) ON DELETE CASCADE,
+  filename VARCHAR(256) NOT NULL,
+  content_type VARCHAR(128) NOT NULL,
+  size INTEGER NOT NULL,
+  blob_key VARCHAR(256) NOT NULL UNIQUE,
+  created_at INTEGER NOT NULL DEFAULT (CAST(unixepoch('subsec')*1000 AS INTEGER))
+);
+
+CREATE INDEX IF NOT EXISTS attachments_target_idx ON attachments (target);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS attachments;
+-- +goose StatementEnd
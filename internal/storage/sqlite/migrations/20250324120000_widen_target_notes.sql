@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite doesn't enforce the length of VARCHAR columns, so the notes already
+-- take Markdown of any length. The migration keeps the schema versions of
+-- the backends in step.
+SELECT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite has no trigram index, the similar subjects are found with the
+-- similarity function of the driver. The migration keeps the schema versions
+-- of the backends in step.
+SELECT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
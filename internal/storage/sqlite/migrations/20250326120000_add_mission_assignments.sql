@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The history of the assignments of the cats to the missions, from
+-- assigned_at up to unassigned_at, or the completion of the mission while it
+-- is open. It starts with the current assignments of the missions.
+CREATE TABLE IF NOT EXISTS mission_assignments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  mission INTEGER NOT NULL REFERENCES missions(id) ON DELETE CASCADE,
+  cat INTEGER NOT NULL REFERENCES cats(id) ON DELETE CASCADE,
+  assigned_at INTEGER NOT NULL,
+  unassigned_at INTEGER DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS mission_assignments_cat_idx ON mission_assignments (cat);
+CREATE UNIQUE INDEX IF NOT EXISTS mission_assignments_open_idx ON mission_assignments (mission) WHERE unassigned_at IS NULL;
+
+INSERT INTO mission_assignments (mission, cat, assigned_at)
+SELECT id, assignee, assigned_at
+FROM missions
+WHERE assignee IS NOT NULL AND assigned_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mission_assignments;
+-- +goose StatementEnd
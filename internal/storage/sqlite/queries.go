@@ -0,0 +1,1341 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mattn/go-sqlite3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/sqlite/sqlitedb"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/tsquery"
+)
+
+// querier adapts the SQLite queries to postgres.Querier.
+//
+// Errors are translated to the ones returned by Postgres: pgx.ErrNoRows when a
+// single row is not found and a *pgconn.PgError with the SQLSTATE of Postgres
+// when a constraint is violated.
+type querier struct {
+	q *sqlitedb.Queries
+}
+
+var _ postgres.Querier = (*querier)(nil)
+
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		// integrity_constraint_violation, unless there is a closer one.
+		code := "23000"
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintForeignKey:
+			code = "23503"
+		case sqlite3.ErrConstraintPrimaryKey, sqlite3.ErrConstraintUnique:
+			code = "23505"
+		case sqlite3.ErrConstraintNotNull:
+			code = "23502"
+		case sqlite3.ErrConstraintCheck:
+			code = "23514"
+		}
+		return &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     code,
+			Message:  sqliteErr.Error(),
+		}
+	}
+
+	return err
+}
+
+//-------------------------------------
+// CATS
+//-------------------------------------
+
+func (q *querier) GetAllCats(ctx context.Context) ([]postgres.Cat, error) {
+	res, err := q.q.GetAllCats(ctx)
+	return convertAll(res, toCat), translateError(err)
+}
+
+func (q *querier) CreateCat(ctx context.Context, arg postgres.CreateCatParams) (postgres.Cat, error) {
+	res, err := q.q.CreateCat(ctx, sqlitedb.CreateCatParams{
+		Name:              arg.Name,
+		YearsOfExperience: int64(arg.YearsOfExperience),
+		Breed:             arg.Breed,
+		Salary:            arg.Salary,
+		SalaryCurrency:    arg.SalaryCurrency,
+	})
+	return toCat(res), translateError(err)
+}
+
+func (q *querier) GetCat(ctx context.Context, id int32) (postgres.Cat, error) {
+	res, err := q.q.GetCat(ctx, int64(id))
+	return toCat(res), translateError(err)
+}
+
+func (q *querier) UpdateCatSalary(ctx context.Context, arg postgres.UpdateCatSalaryParams) (postgres.Cat, error) {
+	res, err := q.q.UpdateCatSalary(ctx, sqlitedb.UpdateCatSalaryParams{
+		ID:             int64(arg.ID),
+		Salary:         arg.Salary,
+		SalaryCurrency: arg.SalaryCurrency,
+	})
+	return toCat(res), translateError(err)
+}
+
+func (q *querier) DeleteCat(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteCat(ctx, int64(id))
+	return res, translateError(err)
+}
+
+//-------------------------------------
+// MISSIONS
+//-------------------------------------
+
+func (q *querier) GetAllMissions(ctx context.Context) ([]postgres.Mission, error) {
+	res, err := q.q.GetAllMissions(ctx)
+	return convertAll(res, toMission), translateError(err)
+}
+
+func (q *querier) CreateMission(ctx context.Context) (postgres.Mission, error) {
+	res, err := q.q.CreateMission(ctx)
+	return toMission(res), translateError(err)
+}
+
+func (q *querier) GetMission(ctx context.Context, id int32) (postgres.Mission, error) {
+	res, err := q.q.GetMission(ctx, int64(id))
+	return toMission(res), translateError(err)
+}
+
+// GetMissionForUpdate is GetMission: a transaction takes the write lock of
+// the database when it begins, so the mission is already locked.
+func (q *querier) GetMissionForUpdate(ctx context.Context, id int32) (postgres.Mission, error) {
+	return q.GetMission(ctx, id)
+}
+
+func (q *querier) DeleteMission(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteMission(ctx, int64(id))
+	return res, translateError(err)
+}
+
+func (q *querier) AssignCat(ctx context.Context, arg postgres.AssignCatParams) (postgres.Mission, error) {
+	res, err := q.q.AssignCat(ctx, sqlitedb.AssignCatParams{
+		ID:       int64(arg.ID),
+		Assignee: fromInt4(arg.Assignee),
+	})
+	return toMission(res), translateError(err)
+}
+
+func (q *querier) GetCatMission(ctx context.Context, assignee pgtype.Int4) (postgres.Mission, error) {
+	res, err := q.q.GetCatMission(ctx, fromInt4(assignee))
+	return toMission(res), translateError(err)
+}
+
+func (q *querier) CompleteMission(ctx context.Context, id int32) (postgres.Mission, error) {
+	res, err := q.q.CompleteMission(ctx, int64(id))
+	return toMission(res), translateError(err)
+}
+
+func (q *querier) CreateMissionAssignment(ctx context.Context, arg postgres.CreateMissionAssignmentParams) error {
+	err := q.q.CreateMissionAssignment(ctx, sqlitedb.CreateMissionAssignmentParams{
+		Mission:    int64(arg.Mission),
+		Cat:        int64(arg.Cat),
+		AssignedAt: fromTimestamptz(arg.AssignedAt),
+	})
+	return translateError(err)
+}
+
+func (q *querier) EndMissionAssignment(ctx context.Context, mission int32) error {
+	return translateError(q.q.EndMissionAssignment(ctx, int64(mission)))
+}
+
+func (q *querier) GetMissionByTargetID(ctx context.Context, id int32) (postgres.GetMissionByTargetIDRow, error) {
+	res, err := q.q.GetMissionByTargetID(ctx, int64(id))
+	return postgres.GetMissionByTargetIDRow{
+		MissionID: int32(res.MissionID),
+		Assignee:  toInt4(res.Assignee),
+		Completed: res.Completed,
+	}, translateError(err)
+}
+
+//-------------------------------------
+// TARGETS
+//-------------------------------------
+
+func (q *querier) CreateTarget(ctx context.Context, arg postgres.CreateTargetParams) (postgres.Target, error) {
+	res, err := q.q.CreateTarget(ctx, sqlitedb.CreateTargetParams{
+		Mission:   int64(arg.Mission),
+		Name:      arg.Name,
+		Country:   arg.Country,
+		Notes:     arg.Notes,
+		Latitude:  fromFloat8(arg.Latitude),
+		Longitude: fromFloat8(arg.Longitude),
+		Address:   arg.Address,
+		Subject:   fromInt4(arg.Subject),
+	})
+	return toTarget(res), translateError(err)
+}
+
+func (q *querier) GetMissionTargets(ctx context.Context, mission int32) ([]postgres.Target, error) {
+	res, err := q.q.GetMissionTargets(ctx, int64(mission))
+	return convertAll(res, toTarget), translateError(err)
+}
+
+func (q *querier) GetTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	res, err := q.q.GetTarget(ctx, int64(id))
+	return toTarget(res), translateError(err)
+}
+
+func (q *querier) DeleteTarget(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteTarget(ctx, int64(id))
+	return res, translateError(err)
+}
+
+func (q *querier) UpdateTargetNotes(ctx context.Context, arg postgres.UpdateTargetNotesParams) (postgres.Target, error) {
+	res, err := q.q.UpdateTargetNotes(ctx, sqlitedb.UpdateTargetNotesParams{
+		ID:    int64(arg.ID),
+		Notes: arg.Notes,
+	})
+	return toTarget(res), translateError(err)
+}
+
+func (q *querier) CompleteTarget(ctx context.Context, id int32) (postgres.Target, error) {
+	res, err := q.q.CompleteTarget(ctx, int64(id))
+	return toTarget(res), translateError(err)
+}
+
+func (q *querier) MoveTarget(ctx context.Context, arg postgres.MoveTargetParams) (postgres.Target, error) {
+	res, err := q.q.MoveTarget(ctx, sqlitedb.MoveTargetParams{
+		ID:      int64(arg.ID),
+		Mission: int64(arg.Mission),
+	})
+	return toTarget(res), translateError(err)
+}
+
+// SearchTargets finds the candidates with the full-text index and evaluates the
+// query on them, as the index doesn't support the whole tsquery syntax.
+func (q *querier) SearchTargets(ctx context.Context, arg postgres.SearchTargetsParams) ([]postgres.SearchTargetsRow, error) {
+	query, err := tsquery.Parse(arg.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []sqlitedb.SearchTargetCandidatesRow
+	if match := ftsMatch(query); match != "" {
+		candidates, err = q.q.SearchTargetCandidates(ctx, match)
+	} else {
+		// Negations only, any target may match.
+		var all []sqlitedb.GetAllTargetCandidatesRow
+		all, err = q.q.GetAllTargetCandidates(ctx)
+		candidates = convertAll(all, func(r sqlitedb.GetAllTargetCandidatesRow) sqlitedb.SearchTargetCandidatesRow {
+			return sqlitedb.SearchTargetCandidatesRow(r)
+		})
+	}
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	var rows []postgres.SearchTargetsRow
+	for _, t := range candidates {
+		var doc tsquery.Document
+		doc.Add(t.Name, tsquery.WeightA)
+		doc.Add(t.Country, tsquery.WeightB)
+		doc.Add(t.Notes, tsquery.WeightC)
+
+		if !query.Match(&doc) {
+			continue
+		}
+
+		rows = append(rows, postgres.SearchTargetsRow{
+			ID:               int32(t.ID),
+			Mission:          int32(t.Mission),
+			Name:             t.Name,
+			Country:          t.Country,
+			Notes:            t.Notes,
+			Completed:        t.Completed,
+			MissionAssignee:  toInt4(t.MissionAssignee),
+			MissionCompleted: t.MissionCompleted,
+			Rank:             query.Rank(&doc),
+			Snippet:          query.Headline(t.Notes),
+		})
+	}
+
+	// ORDER BY rank DESC, t.id
+	slices.SortStableFunc(rows, func(a, b postgres.SearchTargetsRow) int {
+		switch {
+		case a.Rank > b.Rank:
+			return -1
+		case a.Rank < b.Rank:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	if len(rows) > int(arg.MaxResults) {
+		rows = rows[:arg.MaxResults]
+	}
+
+	return rows, nil
+}
+
+// ftsMatch returns an FTS MATCH expression of any of the lexemes of the query,
+// or an empty string if it has none.
+func ftsMatch(query *tsquery.Query) string {
+	var terms []string
+	for _, l := range query.Lexemes() {
+		term := strings.ReplaceAll(l.Word, `"`, `""`)
+		if l.Prefix {
+			term += "*"
+		}
+		terms = append(terms, `"`+term+`"`)
+	}
+
+	return strings.Join(terms, " OR ")
+}
+
+//-------------------------------------
+// WEBHOOKS
+//-------------------------------------
+
+func (q *querier) CreateWebhook(ctx context.Context, arg postgres.CreateWebhookParams) (postgres.Webhook, error) {
+	res, err := q.q.CreateWebhook(ctx, sqlitedb.CreateWebhookParams{
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: fromStrings(arg.EventTypes),
+	})
+	return toWebhook(res), translateError(err)
+}
+
+func (q *querier) GetAllWebhooks(ctx context.Context) ([]postgres.Webhook, error) {
+	res, err := q.q.GetAllWebhooks(ctx)
+	return convertAll(res, toWebhook), translateError(err)
+}
+
+func (q *querier) GetWebhook(ctx context.Context, id int32) (postgres.Webhook, error) {
+	res, err := q.q.GetWebhook(ctx, int64(id))
+	return toWebhook(res), translateError(err)
+}
+
+func (q *querier) UpdateWebhook(ctx context.Context, arg postgres.UpdateWebhookParams) (postgres.Webhook, error) {
+	res, err := q.q.UpdateWebhook(ctx, sqlitedb.UpdateWebhookParams{
+		ID:         int64(arg.ID),
+		Url:        arg.Url,
+		EventTypes: fromStrings(arg.EventTypes),
+		Active:     arg.Active,
+	})
+	return toWebhook(res), translateError(err)
+}
+
+func (q *querier) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteWebhook(ctx, int64(id))
+	return res, translateError(err)
+}
+
+func (q *querier) EnqueueWebhookDeliveries(ctx context.Context, arg postgres.EnqueueWebhookDeliveriesParams) (int64, error) {
+	res, err := q.q.EnqueueWebhookDeliveries(ctx, sqlitedb.EnqueueWebhookDeliveriesParams{
+		EventType: arg.EventType,
+		Payload:   arg.Payload,
+	})
+	return res, translateError(err)
+}
+
+func (q *querier) ClaimWebhookDeliveries(ctx context.Context, arg postgres.ClaimWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	res, err := q.q.ClaimWebhookDeliveries(ctx, sqlitedb.ClaimWebhookDeliveriesParams{
+		LeaseUntil: fromTimestamptz(arg.LeaseUntil),
+		BatchSize:  int64(arg.BatchSize),
+	})
+	return convertAll(res, toWebhookDelivery), translateError(err)
+}
+
+func (q *querier) MarkWebhookDeliverySucceeded(ctx context.Context, arg postgres.MarkWebhookDeliverySucceededParams) error {
+	err := q.q.MarkWebhookDeliverySucceeded(ctx, sqlitedb.MarkWebhookDeliverySucceededParams{
+		ID:               int64(arg.ID),
+		LastResponseCode: fromInt4(arg.LastResponseCode),
+	})
+	return translateError(err)
+}
+
+func (q *querier) MarkWebhookDeliveryFailed(ctx context.Context, arg postgres.MarkWebhookDeliveryFailedParams) error {
+	err := q.q.MarkWebhookDeliveryFailed(ctx, sqlitedb.MarkWebhookDeliveryFailedParams{
+		ID:               int64(arg.ID),
+		Status:           arg.Status,
+		NextAttemptAt:    fromTimestamptz(arg.NextAttemptAt),
+		LastError:        arg.LastError,
+		LastResponseCode: fromInt4(arg.LastResponseCode),
+	})
+	return translateError(err)
+}
+
+func (q *querier) GetWebhookDeliveries(ctx context.Context, arg postgres.GetWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error) {
+	res, err := q.q.GetWebhookDeliveries(ctx, sqlitedb.GetWebhookDeliveriesParams{
+		Webhook: int64(arg.Webhook),
+		Limit:   int64(arg.Limit),
+	})
+	return convertAll(res, toWebhookDelivery), translateError(err)
+}
+
+func (q *querier) RedeliverWebhookDelivery(ctx context.Context, arg postgres.RedeliverWebhookDeliveryParams) (postgres.WebhookDelivery, error) {
+	res, err := q.q.RedeliverWebhookDelivery(ctx, sqlitedb.RedeliverWebhookDeliveryParams{
+		ID:      int64(arg.ID),
+		Webhook: int64(arg.Webhook),
+	})
+	return toWebhookDelivery(res), translateError(err)
+}
+
+//-------------------------------------
+// OUTBOX
+//-------------------------------------
+
+func (q *querier) CreateOutboxEvent(ctx context.Context, arg postgres.CreateOutboxEventParams) error {
+	err := q.q.CreateOutboxEvent(ctx, sqlitedb.CreateOutboxEventParams{
+		AggregateType: arg.AggregateType,
+		AggregateID:   int64(arg.AggregateID),
+		EventType:     arg.EventType,
+		Payload:       arg.Payload,
+	})
+	return translateError(err)
+}
+
+func (q *querier) GetUnpublishedOutboxEvents(ctx context.Context, arg postgres.GetUnpublishedOutboxEventsParams) ([]postgres.Outbox, error) {
+	res, err := q.q.GetUnpublishedOutboxEvents(ctx, sqlitedb.GetUnpublishedOutboxEventsParams{
+		AfterOffset: arg.AfterOffset,
+		MaxEvents:   int64(arg.MaxEvents),
+	})
+	return convertAll(res, toOutbox), translateError(err)
+}
+
+func (q *querier) MarkOutboxEventsPublished(ctx context.Context, ids []int64) (int64, error) {
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := q.q.MarkOutboxEventsPublished(ctx, string(encoded))
+	return res, translateError(err)
+}
+
+func (q *querier) GetOutboxEvents(ctx context.Context, arg postgres.GetOutboxEventsParams) ([]postgres.Outbox, error) {
+	res, err := q.q.GetOutboxEvents(ctx, sqlitedb.GetOutboxEventsParams{
+		FromOffset: arg.FromOffset,
+		MaxEvents:  int64(arg.MaxEvents),
+	})
+	return convertAll(res, toOutbox), translateError(err)
+}
+
+func (q *querier) ResetOutboxEvents(ctx context.Context, fromOffset int64) (int64, error) {
+	res, err := q.q.ResetOutboxEvents(ctx, fromOffset)
+	return res, translateError(err)
+}
+
+//-------------------------------------
+// MISSION TEMPLATES
+//-------------------------------------
+
+func (q *querier) CreateMissionTemplate(ctx context.Context, arg postgres.CreateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	res, err := q.q.CreateMissionTemplate(ctx, sqlitedb.CreateMissionTemplateParams{
+		Name:    arg.Name,
+		Targets: arg.Targets,
+	})
+	return toMissionTemplate(res), translateError(err)
+}
+
+func (q *querier) GetAllMissionTemplates(ctx context.Context) ([]postgres.MissionTemplate, error) {
+	res, err := q.q.GetAllMissionTemplates(ctx)
+	return convertAll(res, toMissionTemplate), translateError(err)
+}
+
+func (q *querier) GetMissionTemplate(ctx context.Context, id int32) (postgres.MissionTemplate, error) {
+	res, err := q.q.GetMissionTemplate(ctx, int64(id))
+	return toMissionTemplate(res), translateError(err)
+}
+
+func (q *querier) UpdateMissionTemplate(ctx context.Context, arg postgres.UpdateMissionTemplateParams) (postgres.MissionTemplate, error) {
+	res, err := q.q.UpdateMissionTemplate(ctx, sqlitedb.UpdateMissionTemplateParams{
+		ID:      int64(arg.ID),
+		Name:    arg.Name,
+		Targets: arg.Targets,
+	})
+	return toMissionTemplate(res), translateError(err)
+}
+
+func (q *querier) DeleteMissionTemplate(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteMissionTemplate(ctx, int64(id))
+	return res, translateError(err)
+}
+
+//-------------------------------------
+// TARGET MOVES
+//-------------------------------------
+
+func (q *querier) CreateTargetMove(ctx context.Context, arg postgres.CreateTargetMoveParams) (postgres.TargetMove, error) {
+	res, err := q.q.CreateTargetMove(ctx, sqlitedb.CreateTargetMoveParams{
+		Target:      int64(arg.Target),
+		FromMission: int64(arg.FromMission),
+		ToMission:   int64(arg.ToMission),
+	})
+	return toTargetMove(res), translateError(err)
+}
+
+func (q *querier) GetTargetMoves(ctx context.Context, target int32) ([]postgres.TargetMove, error) {
+	res, err := q.q.GetTargetMoves(ctx, int64(target))
+	return convertAll(res, toTargetMove), translateError(err)
+}
+
+//-------------------------------------
+// SNAPSHOTS
+//-------------------------------------
+
+func (q *querier) GetAllTargets(ctx context.Context) ([]postgres.Target, error) {
+	res, err := q.q.GetAllTargets(ctx)
+	return convertAll(res, toTarget), translateError(err)
+}
+
+func (q *querier) RestoreCat(ctx context.Context, arg postgres.RestoreCatParams) error {
+	err := q.q.RestoreCat(ctx, sqlitedb.RestoreCatParams{
+		ID:                int64(arg.ID),
+		Name:              arg.Name,
+		YearsOfExperience: int64(arg.YearsOfExperience),
+		Breed:             arg.Breed,
+		Salary:            arg.Salary,
+		SalaryCurrency:    arg.SalaryCurrency,
+	})
+	return translateError(err)
+}
+
+func (q *querier) RestoreMission(ctx context.Context, arg postgres.RestoreMissionParams) error {
+	err := q.q.RestoreMission(ctx, sqlitedb.RestoreMissionParams{
+		ID:          int64(arg.ID),
+		Assignee:    fromInt4(arg.Assignee),
+		Completed:   arg.Completed,
+		CreatedAt:   fromTimestamptz(arg.CreatedAt),
+		AssignedAt:  fromNullTimestamptz(arg.AssignedAt),
+		CompletedAt: fromNullTimestamptz(arg.CompletedAt),
+	})
+	return translateError(err)
+}
+
+func (q *querier) RestoreTarget(ctx context.Context, arg postgres.RestoreTargetParams) error {
+	err := q.q.RestoreTarget(ctx, sqlitedb.RestoreTargetParams{
+		ID:          int64(arg.ID),
+		Mission:     int64(arg.Mission),
+		Name:        arg.Name,
+		Country:     arg.Country,
+		Notes:       arg.Notes,
+		Completed:   arg.Completed,
+		CreatedAt:   fromTimestamptz(arg.CreatedAt),
+		CompletedAt: fromNullTimestamptz(arg.CompletedAt),
+		Latitude:    fromFloat8(arg.Latitude),
+		Longitude:   fromFloat8(arg.Longitude),
+		Address:     arg.Address,
+		Subject:     fromInt4(arg.Subject),
+	})
+	return translateError(err)
+}
+
+func (q *querier) GetAllSubjects(ctx context.Context) ([]postgres.Subject, error) {
+	res, err := q.q.GetAllSubjects(ctx)
+	return convertAll(res, toSubject), translateError(err)
+}
+
+func (q *querier) GetSubjectsPage(ctx context.Context, arg postgres.GetSubjectsPageParams) ([]postgres.Subject, error) {
+	res, err := q.q.GetSubjectsPage(ctx, sqlitedb.GetSubjectsPageParams{
+		After:   int64(arg.After),
+		MaxRows: int64(arg.MaxRows),
+	})
+	return convertAll(res, toSubject), translateError(err)
+}
+
+func (q *querier) GetTargetsPage(ctx context.Context, arg postgres.GetTargetsPageParams) ([]postgres.Target, error) {
+	res, err := q.q.GetTargetsPage(ctx, sqlitedb.GetTargetsPageParams{
+		After:   int64(arg.After),
+		MaxRows: int64(arg.MaxRows),
+	})
+	return convertAll(res, toTarget), translateError(err)
+}
+
+func (q *querier) RestoreSubject(ctx context.Context, arg postgres.RestoreSubjectParams) error {
+	err := q.q.RestoreSubject(ctx, sqlitedb.RestoreSubjectParams{
+		ID:        int64(arg.ID),
+		Name:      arg.Name,
+		Country:   arg.Country,
+		CreatedAt: fromTimestamptz(arg.CreatedAt),
+	})
+	return translateError(err)
+}
+
+// ResetSequences is a no-op: the AUTOINCREMENT of SQLite keeps its sequences
+// at the largest ID inserted, restored ones included.
+func (q *querier) ResetSequences(ctx context.Context) error {
+	return nil
+}
+
+//-------------------------------------
+// REPORTS
+//-------------------------------------
+
+func (q *querier) GetCatsPage(ctx context.Context, arg postgres.GetCatsPageParams) ([]postgres.Cat, error) {
+	res, err := q.q.GetCatsPage(ctx, sqlitedb.GetCatsPageParams{
+		After:   int64(arg.After),
+		MaxRows: int64(arg.MaxRows),
+	})
+	return convertAll(res, toCat), translateError(err)
+}
+
+func (q *querier) GetMissionsPage(ctx context.Context, arg postgres.GetMissionsPageParams) ([]postgres.Mission, error) {
+	res, err := q.q.GetMissionsPage(ctx, sqlitedb.GetMissionsPageParams{
+		After:   int64(arg.After),
+		MaxRows: int64(arg.MaxRows),
+	})
+	return convertAll(res, toMission), translateError(err)
+}
+
+func (q *querier) GetMissionTargetsPage(ctx context.Context, arg postgres.GetMissionTargetsPageParams) ([]postgres.GetMissionTargetsPageRow, error) {
+	res, err := q.q.GetMissionTargetsPage(ctx, sqlitedb.GetMissionTargetsPageParams{
+		AfterMission: int64(arg.AfterMission),
+		AfterTarget:  int64(arg.AfterTarget),
+		MaxRows:      int64(arg.MaxRows),
+	})
+	return convertAll(res, func(r sqlitedb.GetMissionTargetsPageRow) postgres.GetMissionTargetsPageRow {
+		return postgres.GetMissionTargetsPageRow{
+			MissionID:        int32(r.MissionID),
+			Assignee:         toInt4(r.Assignee),
+			MissionCompleted: r.MissionCompleted,
+			TargetID:         int32(r.TargetID),
+			Name:             r.Name,
+			Country:          r.Country,
+			Notes:            r.Notes,
+			TargetCompleted:  r.TargetCompleted,
+		}
+	}), translateError(err)
+}
+
+func (q *querier) GetCatWorkloadPage(ctx context.Context, arg postgres.GetCatWorkloadPageParams) ([]postgres.GetCatWorkloadPageRow, error) {
+	res, err := q.q.GetCatWorkloadPage(ctx, sqlitedb.GetCatWorkloadPageParams{
+		After:   int64(arg.After),
+		MaxRows: int64(arg.MaxRows),
+	})
+	return convertAll(res, func(r sqlitedb.GetCatWorkloadPageRow) postgres.GetCatWorkloadPageRow {
+		return postgres.GetCatWorkloadPageRow{
+			ID:                int32(r.ID),
+			Name:              r.Name,
+			Breed:             r.Breed,
+			Salary:            r.Salary,
+			SalaryCurrency:    r.SalaryCurrency,
+			ActiveMissions:    r.ActiveMissions,
+			CompletedMissions: r.CompletedMissions,
+			OpenTargets:       r.OpenTargets,
+			CompletedTargets:  r.CompletedTargets,
+		}
+	}), translateError(err)
+}
+
+//-------------------------------------
+// ANALYTICS
+//-------------------------------------
+
+func (q *querier) GetMissionCompletionRate(ctx context.Context, arg postgres.GetMissionCompletionRateParams) ([]postgres.GetMissionCompletionRateRow, error) {
+	res, err := q.q.GetMissionCompletionRate(ctx, sqlitedb.GetMissionCompletionRateParams{
+		TimeWindow: arg.TimeWindow,
+		Since:      fromTimestamptz(arg.Since),
+	})
+	return convertAll(res, func(r sqlitedb.GetMissionCompletionRateRow) postgres.GetMissionCompletionRateRow {
+		return postgres.GetMissionCompletionRateRow{
+			Period:    toTimestamptz(r.Period),
+			Created:   r.Created,
+			Completed: r.Completed,
+		}
+	}), translateError(err)
+}
+
+func (q *querier) GetCompletionTimes(ctx context.Context) (postgres.GetCompletionTimesRow, error) {
+	res, err := q.q.GetCompletionTimes(ctx)
+	return postgres.GetCompletionTimesRow(res), translateError(err)
+}
+
+func (q *querier) GetCatUtilization(ctx context.Context, now pgtype.Timestamptz) ([]postgres.GetCatUtilizationRow, error) {
+	res, err := q.q.GetCatUtilization(ctx, fromTimestamptz(now))
+	return convertAll(res, func(r sqlitedb.GetCatUtilizationRow) postgres.GetCatUtilizationRow {
+		return postgres.GetCatUtilizationRow{
+			ID:            int32(r.ID),
+			Name:          r.Name,
+			Missions:      r.Missions,
+			DaysOnMission: r.DaysOnMission,
+		}
+	}), translateError(err)
+}
+
+func (q *querier) GetTargetsPerCountry(ctx context.Context) ([]postgres.GetTargetsPerCountryRow, error) {
+	res, err := q.q.GetTargetsPerCountry(ctx)
+	return convertAll(res, func(r sqlitedb.GetTargetsPerCountryRow) postgres.GetTargetsPerCountryRow {
+		return postgres.GetTargetsPerCountryRow(r)
+	}), translateError(err)
+}
+
+func (q *querier) GetSalaryPerformance(ctx context.Context) ([]postgres.GetSalaryPerformanceRow, error) {
+	res, err := q.q.GetSalaryPerformance(ctx)
+	return convertAll(res, func(r sqlitedb.GetSalaryPerformanceRow) postgres.GetSalaryPerformanceRow {
+		return postgres.GetSalaryPerformanceRow(r)
+	}), translateError(err)
+}
+
+//-------------------------------------
+// AVAILABILITY
+//-------------------------------------
+
+func (q *querier) CreateAvailabilityPeriod(ctx context.Context, arg postgres.CreateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	res, err := q.q.CreateAvailabilityPeriod(ctx, sqlitedb.CreateAvailabilityPeriodParams{
+		Cat:      int64(arg.Cat),
+		Kind:     arg.Kind,
+		StartsAt: fromTimestamptz(arg.StartsAt),
+		EndsAt:   fromTimestamptz(arg.EndsAt),
+	})
+	return toAvailabilityPeriod(res), translateError(err)
+}
+
+func (q *querier) GetCatAvailabilityPeriods(ctx context.Context, cat int32) ([]postgres.AvailabilityPeriod, error) {
+	res, err := q.q.GetCatAvailabilityPeriods(ctx, int64(cat))
+	return convertAll(res, toAvailabilityPeriod), translateError(err)
+}
+
+func (q *querier) GetAvailabilityPeriod(ctx context.Context, id int32) (postgres.AvailabilityPeriod, error) {
+	res, err := q.q.GetAvailabilityPeriod(ctx, int64(id))
+	return toAvailabilityPeriod(res), translateError(err)
+}
+
+func (q *querier) UpdateAvailabilityPeriod(ctx context.Context, arg postgres.UpdateAvailabilityPeriodParams) (postgres.AvailabilityPeriod, error) {
+	res, err := q.q.UpdateAvailabilityPeriod(ctx, sqlitedb.UpdateAvailabilityPeriodParams{
+		ID:       int64(arg.ID),
+		Kind:     arg.Kind,
+		StartsAt: fromTimestamptz(arg.StartsAt),
+		EndsAt:   fromTimestamptz(arg.EndsAt),
+	})
+	return toAvailabilityPeriod(res), translateError(err)
+}
+
+func (q *querier) DeleteAvailabilityPeriod(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteAvailabilityPeriod(ctx, int64(id))
+	return res, translateError(err)
+}
+
+func (q *querier) GetOverlappingAvailabilityPeriods(ctx context.Context, arg postgres.GetOverlappingAvailabilityPeriodsParams) ([]postgres.AvailabilityPeriod, error) {
+	res, err := q.q.GetOverlappingAvailabilityPeriods(ctx, sqlitedb.GetOverlappingAvailabilityPeriodsParams{
+		Cat:   int64(arg.Cat),
+		Since: fromTimestamptz(arg.Since),
+		Until: fromTimestamptz(arg.Until),
+	})
+	return convertAll(res, toAvailabilityPeriod), translateError(err)
+}
+
+func (q *querier) GetAvailableCats(ctx context.Context, at pgtype.Timestamptz) ([]postgres.Cat, error) {
+	res, err := q.q.GetAvailableCats(ctx, fromTimestamptz(at))
+	return convertAll(res, toCat), translateError(err)
+}
+
+func (q *querier) GetCatsAvailableBetween(ctx context.Context, arg postgres.GetCatsAvailableBetweenParams) ([]postgres.Cat, error) {
+	res, err := q.q.GetCatsAvailableBetween(ctx, sqlitedb.GetCatsAvailableBetweenParams{
+		Since: fromTimestamptz(arg.Since),
+		Until: fromTimestamptz(arg.Until),
+	})
+	return convertAll(res, toCat), translateError(err)
+}
+
+//-------------------------------------
+// SKILLS
+//-------------------------------------
+
+func (q *querier) CreateSkill(ctx context.Context, name string) (postgres.Skill, error) {
+	res, err := q.q.CreateSkill(ctx, name)
+	return toSkill(res), translateError(err)
+}
+
+func (q *querier) GetAllSkills(ctx context.Context) ([]postgres.Skill, error) {
+	res, err := q.q.GetAllSkills(ctx)
+	return convertAll(res, toSkill), translateError(err)
+}
+
+func (q *querier) GetSkill(ctx context.Context, id int32) (postgres.Skill, error) {
+	res, err := q.q.GetSkill(ctx, int64(id))
+	return toSkill(res), translateError(err)
+}
+
+func (q *querier) DeleteSkill(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteSkill(ctx, int64(id))
+	return res, translateError(err)
+}
+
+func (q *querier) SetCatSkill(ctx context.Context, arg postgres.SetCatSkillParams) (postgres.CatSkill, error) {
+	res, err := q.q.SetCatSkill(ctx, sqlitedb.SetCatSkillParams{
+		Cat:            int64(arg.Cat),
+		Skill:          int64(arg.Skill),
+		Level:          int64(arg.Level),
+		CertifiedUntil: fromNullTimestamptz(arg.CertifiedUntil),
+	})
+	return toCatSkill(res), translateError(err)
+}
+
+func (q *querier) GetCatSkills(ctx context.Context, cat int32) ([]postgres.GetCatSkillsRow, error) {
+	res, err := q.q.GetCatSkills(ctx, int64(cat))
+	return convertAll(res, func(r sqlitedb.GetCatSkillsRow) postgres.GetCatSkillsRow {
+		return postgres.GetCatSkillsRow{
+			Cat:            int32(r.Cat),
+			Skill:          int32(r.Skill),
+			Level:          int32(r.Level),
+			CertifiedUntil: toNullTimestamptz(r.CertifiedUntil),
+			Name:           r.Name,
+		}
+	}), translateError(err)
+}
+
+func (q *querier) DeleteCatSkill(ctx context.Context, arg postgres.DeleteCatSkillParams) (int64, error) {
+	res, err := q.q.DeleteCatSkill(ctx, sqlitedb.DeleteCatSkillParams{
+		Cat:   int64(arg.Cat),
+		Skill: int64(arg.Skill),
+	})
+	return res, translateError(err)
+}
+
+func (q *querier) SetTargetSkill(ctx context.Context, arg postgres.SetTargetSkillParams) (postgres.TargetSkill, error) {
+	res, err := q.q.SetTargetSkill(ctx, sqlitedb.SetTargetSkillParams{
+		Target:    int64(arg.Target),
+		Skill:     int64(arg.Skill),
+		MinLevel:  int64(arg.MinLevel),
+		Certified: arg.Certified,
+	})
+	return toTargetSkill(res), translateError(err)
+}
+
+func (q *querier) GetTargetSkills(ctx context.Context, target int32) ([]postgres.GetTargetSkillsRow, error) {
+	res, err := q.q.GetTargetSkills(ctx, int64(target))
+	return convertAll(res, func(r sqlitedb.GetTargetSkillsRow) postgres.GetTargetSkillsRow {
+		return postgres.GetTargetSkillsRow{
+			Target:    int32(r.Target),
+			Skill:     int32(r.Skill),
+			MinLevel:  int32(r.MinLevel),
+			Certified: r.Certified,
+			Name:      r.Name,
+		}
+	}), translateError(err)
+}
+
+func (q *querier) DeleteTargetSkill(ctx context.Context, arg postgres.DeleteTargetSkillParams) (int64, error) {
+	res, err := q.q.DeleteTargetSkill(ctx, sqlitedb.DeleteTargetSkillParams{
+		Target: int64(arg.Target),
+		Skill:  int64(arg.Skill),
+	})
+	return res, translateError(err)
+}
+
+func (q *querier) GetMissionRequiredSkills(ctx context.Context, mission int32) ([]postgres.GetMissionRequiredSkillsRow, error) {
+	res, err := q.q.GetMissionRequiredSkills(ctx, int64(mission))
+	return convertAll(res, func(r sqlitedb.GetMissionRequiredSkillsRow) postgres.GetMissionRequiredSkillsRow {
+		return postgres.GetMissionRequiredSkillsRow{
+			Skill:     int32(r.Skill),
+			Name:      r.Name,
+			MinLevel:  int32(r.MinLevel),
+			Certified: r.Certified,
+		}
+	}), translateError(err)
+}
+
+func (q *querier) GetUnassignedCats(ctx context.Context) ([]postgres.Cat, error) {
+	res, err := q.q.GetUnassignedCats(ctx)
+	return convertAll(res, toCat), translateError(err)
+}
+
+func (q *querier) GetUnassignedCatSkills(ctx context.Context) ([]postgres.CatSkill, error) {
+	res, err := q.q.GetUnassignedCatSkills(ctx)
+	return convertAll(res, toCatSkill), translateError(err)
+}
+
+//-------------------------------------
+// COSTS
+//-------------------------------------
+
+func (q *querier) SetMissionBudget(ctx context.Context, arg postgres.SetMissionBudgetParams) (postgres.MissionBudget, error) {
+	res, err := q.q.SetMissionBudget(ctx, sqlitedb.SetMissionBudgetParams{
+		Mission:  int64(arg.Mission),
+		Amount:   arg.Amount,
+		Currency: arg.Currency,
+	})
+	return toMissionBudget(res), translateError(err)
+}
+
+func (q *querier) GetMissionBudget(ctx context.Context, mission int32) (postgres.MissionBudget, error) {
+	res, err := q.q.GetMissionBudget(ctx, int64(mission))
+	return toMissionBudget(res), translateError(err)
+}
+
+func (q *querier) DeleteMissionBudget(ctx context.Context, mission int32) (int64, error) {
+	res, err := q.q.DeleteMissionBudget(ctx, int64(mission))
+	return res, translateError(err)
+}
+
+func (q *querier) CreateMissionExpense(ctx context.Context, arg postgres.CreateMissionExpenseParams) (postgres.MissionExpense, error) {
+	res, err := q.q.CreateMissionExpense(ctx, sqlitedb.CreateMissionExpenseParams{
+		Mission:       int64(arg.Mission),
+		Category:      arg.Category,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Description:   arg.Description,
+		ReceiptNumber: arg.ReceiptNumber,
+		ReceiptVendor: arg.ReceiptVendor,
+		ReceiptUrl:    arg.ReceiptUrl,
+		IncurredAt:    fromTimestamptz(arg.IncurredAt),
+	})
+	return toMissionExpense(res), translateError(err)
+}
+
+func (q *querier) GetMissionExpenses(ctx context.Context, mission int32) ([]postgres.MissionExpense, error) {
+	res, err := q.q.GetMissionExpenses(ctx, int64(mission))
+	return convertAll(res, toMissionExpense), translateError(err)
+}
+
+func (q *querier) GetMissionExpense(ctx context.Context, id int32) (postgres.MissionExpense, error) {
+	res, err := q.q.GetMissionExpense(ctx, int64(id))
+	return toMissionExpense(res), translateError(err)
+}
+
+func (q *querier) DeleteMissionExpense(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteMissionExpense(ctx, int64(id))
+	return res, translateError(err)
+}
+
+//-------------------------------------
+// EXCHANGE RATES
+//-------------------------------------
+
+func (q *querier) SetExchangeRate(ctx context.Context, arg postgres.SetExchangeRateParams) (postgres.ExchangeRate, error) {
+	res, err := q.q.SetExchangeRate(ctx, sqlitedb.SetExchangeRateParams(arg))
+	return toExchangeRate(res), translateError(err)
+}
+
+func (q *querier) GetExchangeRates(ctx context.Context) ([]postgres.ExchangeRate, error) {
+	res, err := q.q.GetExchangeRates(ctx)
+	return convertAll(res, toExchangeRate), translateError(err)
+}
+
+func (q *querier) DeleteExchangeRate(ctx context.Context, currency string) (int64, error) {
+	res, err := q.q.DeleteExchangeRate(ctx, currency)
+	return res, translateError(err)
+}
+
+//-------------------------------------
+// LOCATIONS
+//-------------------------------------
+
+func (q *querier) UpdateTargetLocation(ctx context.Context, arg postgres.UpdateTargetLocationParams) (postgres.Target, error) {
+	res, err := q.q.UpdateTargetLocation(ctx, sqlitedb.UpdateTargetLocationParams{
+		ID:        int64(arg.ID),
+		Latitude:  fromFloat8(arg.Latitude),
+		Longitude: fromFloat8(arg.Longitude),
+		Address:   arg.Address,
+	})
+	return toTarget(res), translateError(err)
+}
+
+func (q *querier) GetTargetsNear(ctx context.Context, arg postgres.GetTargetsNearParams) ([]postgres.GetTargetsNearRow, error) {
+	res, err := q.q.GetTargetsNear(ctx, sqlitedb.GetTargetsNearParams{
+		Lat:      arg.Lat,
+		Lng:      arg.Lng,
+		RadiusKm: arg.RadiusKm,
+		MaxRows:  int64(arg.MaxRows),
+	})
+	return convertAll(res, toTargetsNearRow), translateError(err)
+}
+
+//-------------------------------------
+// SUBJECTS
+//-------------------------------------
+
+func (q *querier) CreateSubject(ctx context.Context, arg postgres.CreateSubjectParams) (postgres.Subject, error) {
+	res, err := q.q.CreateSubject(ctx, sqlitedb.CreateSubjectParams{
+		Name:    arg.Name,
+		Country: arg.Country,
+	})
+	return toSubject(res), translateError(err)
+}
+
+func (q *querier) GetSubject(ctx context.Context, id int32) (postgres.Subject, error) {
+	res, err := q.q.GetSubject(ctx, int64(id))
+	return toSubject(res), translateError(err)
+}
+
+func (q *querier) GetSimilarSubjects(ctx context.Context, arg postgres.GetSimilarSubjectsParams) ([]postgres.Subject, error) {
+	res, err := q.q.GetSimilarSubjects(ctx, sqlitedb.GetSimilarSubjectsParams{
+		Name:        arg.Name,
+		Country:     arg.Country,
+		MaxSubjects: int64(arg.MaxSubjects),
+	})
+	return convertAll(res, toSubject), translateError(err)
+}
+
+func (q *querier) GetSubjectsByIDs(ctx context.Context, ids []int32) ([]postgres.Subject, error) {
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := q.q.GetSubjectsByIDs(ctx, string(encoded))
+	return convertAll(res, toSubject), translateError(err)
+}
+
+func (q *querier) DeleteSubject(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteSubject(ctx, int64(id))
+	return res, translateError(err)
+}
+
+func (q *querier) GetSubjectTargets(ctx context.Context, subject pgtype.Int4) ([]postgres.Target, error) {
+	res, err := q.q.GetSubjectTargets(ctx, fromInt4(subject))
+	return convertAll(res, toTarget), translateError(err)
+}
+
+func (q *querier) MoveSubjectTargets(ctx context.Context, arg postgres.MoveSubjectTargetsParams) (int64, error) {
+	res, err := q.q.MoveSubjectTargets(ctx, sqlitedb.MoveSubjectTargetsParams{
+		ToSubject:   fromInt4(arg.ToSubject),
+		FromSubject: fromInt4(arg.FromSubject),
+	})
+	return res, translateError(err)
+}
+
+func (q *querier) CreateTargetNote(ctx context.Context, arg postgres.CreateTargetNoteParams) (postgres.TargetNote, error) {
+	res, err := q.q.CreateTargetNote(ctx, sqlitedb.CreateTargetNoteParams{
+		Target:        int64(arg.Target),
+		PreviousNotes: arg.PreviousNotes,
+		Notes:         arg.Notes,
+	})
+	return toTargetNote(res), translateError(err)
+}
+
+func (q *querier) GetSubjectNotes(ctx context.Context, subject pgtype.Int4) ([]postgres.TargetNote, error) {
+	res, err := q.q.GetSubjectNotes(ctx, fromInt4(subject))
+	return convertAll(res, toTargetNote), translateError(err)
+}
+
+//-------------------------------------
+// ATTACHMENTS
+//-------------------------------------
+
+func (q *querier) CreateAttachment(ctx context.Context, arg postgres.CreateAttachmentParams) (postgres.Attachment, error) {
+	res, err := q.q.CreateAttachment(ctx, sqlitedb.CreateAttachmentParams{
+		Target:      int64(arg.Target),
+		Filename:    arg.Filename,
+		ContentType: arg.ContentType,
+		Size:        arg.Size,
+		BlobKey:     arg.BlobKey,
+	})
+	return toAttachment(res), translateError(err)
+}
+
+func (q *querier) GetAttachment(ctx context.Context, id int32) (postgres.Attachment, error) {
+	res, err := q.q.GetAttachment(ctx, int64(id))
+	return toAttachment(res), translateError(err)
+}
+
+func (q *querier) GetTargetAttachments(ctx context.Context, target int32) ([]postgres.Attachment, error) {
+	res, err := q.q.GetTargetAttachments(ctx, int64(target))
+	return convertAll(res, toAttachment), translateError(err)
+}
+
+func (q *querier) GetMissionAttachments(ctx context.Context, mission int32) ([]postgres.Attachment, error) {
+	res, err := q.q.GetMissionAttachments(ctx, int64(mission))
+	return convertAll(res, toAttachment), translateError(err)
+}
+
+func (q *querier) DeleteAttachment(ctx context.Context, id int32) (int64, error) {
+	res, err := q.q.DeleteAttachment(ctx, int64(id))
+	return res, translateError(err)
+}
+
+//-------------------------------------
+// CONVERSIONS
+//-------------------------------------
+
+func convertAll[S, T any](rows []S, convert func(S) T) []T {
+	if rows == nil {
+		return nil
+	}
+
+	res := make([]T, len(rows))
+	for i, r := range rows {
+		res[i] = convert(r)
+	}
+
+	return res
+}
+
+func toInt4(n sql.NullInt64) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32(n.Int64), Valid: n.Valid}
+}
+
+func fromInt4(n pgtype.Int4) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n.Int32), Valid: n.Valid}
+}
+
+// Timestamps are stored as milliseconds since the Unix epoch.
+
+func toTimestamptz(ms int64) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.UnixMilli(ms), Valid: true}
+}
+
+func toNullTimestamptz(ms sql.NullInt64) pgtype.Timestamptz {
+	if !ms.Valid {
+		return pgtype.Timestamptz{}
+	}
+
+	return toTimestamptz(ms.Int64)
+}
+
+func fromTimestamptz(t pgtype.Timestamptz) int64 {
+	return t.Time.UnixMilli()
+}
+
+func fromNullTimestamptz(t pgtype.Timestamptz) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.Time.UnixMilli(), Valid: t.Valid}
+}
+
+func toFloat8(f sql.NullFloat64) pgtype.Float8 {
+	return pgtype.Float8{Float64: f.Float64, Valid: f.Valid}
+}
+
+func fromFloat8(f pgtype.Float8) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f.Float64, Valid: f.Valid}
+}
+
+// Lists are stored as JSON arrays.
+
+func toStrings(s string) []string {
+	var res []string
+	if err := json.Unmarshal([]byte(s), &res); err != nil {
+		return nil
+	}
+
+	return res
+}
+
+func fromStrings(s []string) string {
+	if s == nil {
+		s = []string{}
+	}
+
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+func toCat(c sqlitedb.Cat) postgres.Cat {
+	return postgres.Cat{
+		ID:                int32(c.ID),
+		Name:              c.Name,
+		YearsOfExperience: int32(c.YearsOfExperience),
+		Breed:             c.Breed,
+		Salary:            c.Salary,
+		SalaryCurrency:    c.SalaryCurrency,
+	}
+}
+
+func toMission(m sqlitedb.Mission) postgres.Mission {
+	return postgres.Mission{
+		ID:          int32(m.ID),
+		Assignee:    toInt4(m.Assignee),
+		Completed:   m.Completed,
+		CreatedAt:   toTimestamptz(m.CreatedAt),
+		AssignedAt:  toNullTimestamptz(m.AssignedAt),
+		CompletedAt: toNullTimestamptz(m.CompletedAt),
+	}
+}
+
+func toTarget(t sqlitedb.Target) postgres.Target {
+	return postgres.Target{
+		ID:          int32(t.ID),
+		Mission:     int32(t.Mission),
+		Name:        t.Name,
+		Country:     t.Country,
+		Notes:       t.Notes,
+		Completed:   t.Completed,
+		CreatedAt:   toTimestamptz(t.CreatedAt),
+		CompletedAt: toNullTimestamptz(t.CompletedAt),
+		Latitude:    toFloat8(t.Latitude),
+		Longitude:   toFloat8(t.Longitude),
+		Address:     t.Address,
+		Subject:     toInt4(t.Subject),
+	}
+}
+
+func toTargetsNearRow(t sqlitedb.GetTargetsNearRow) postgres.GetTargetsNearRow {
+	return postgres.GetTargetsNearRow{
+		ID:         int32(t.ID),
+		Mission:    int32(t.Mission),
+		Name:       t.Name,
+		Country:    t.Country,
+		Notes:      t.Notes,
+		Completed:  t.Completed,
+		Latitude:   toFloat8(t.Latitude),
+		Longitude:  toFloat8(t.Longitude),
+		Address:    t.Address,
+		DistanceKm: t.DistanceKm,
+	}
+}
+
+func toWebhook(w sqlitedb.Webhook) postgres.Webhook {
+	return postgres.Webhook{
+		ID:         int32(w.ID),
+		Url:        w.Url,
+		Secret:     w.Secret,
+		EventTypes: toStrings(w.EventTypes),
+		Active:     w.Active,
+		CreatedAt:  toTimestamptz(w.CreatedAt),
+	}
+}
+
+func toWebhookDelivery(d sqlitedb.WebhookDelivery) postgres.WebhookDelivery {
+	return postgres.WebhookDelivery{
+		ID:               int32(d.ID),
+		Webhook:          int32(d.Webhook),
+		EventType:        d.EventType,
+		Payload:          d.Payload,
+		Status:           d.Status,
+		Attempts:         int32(d.Attempts),
+		NextAttemptAt:    toTimestamptz(d.NextAttemptAt),
+		LastError:        d.LastError,
+		LastResponseCode: toInt4(d.LastResponseCode),
+		CreatedAt:        toTimestamptz(d.CreatedAt),
+		DeliveredAt:      toNullTimestamptz(d.DeliveredAt),
+	}
+}
+
+func toOutbox(e sqlitedb.Outbox) postgres.Outbox {
+	return postgres.Outbox{
+		ID:            e.ID,
+		AggregateType: e.AggregateType,
+		AggregateID:   int32(e.AggregateID),
+		EventType:     e.EventType,
+		Payload:       e.Payload,
+		CreatedAt:     toTimestamptz(e.CreatedAt),
+		PublishedAt:   toNullTimestamptz(e.PublishedAt),
+	}
+}
+
+func toMissionTemplate(t sqlitedb.MissionTemplate) postgres.MissionTemplate {
+	return postgres.MissionTemplate{
+		ID:        int32(t.ID),
+		Name:      t.Name,
+		Targets:   t.Targets,
+		CreatedAt: toTimestamptz(t.CreatedAt),
+	}
+}
+
+func toSubject(s sqlitedb.Subject) postgres.Subject {
+	return postgres.Subject{
+		ID:        int32(s.ID),
+		Name:      s.Name,
+		Country:   s.Country,
+		CreatedAt: toTimestamptz(s.CreatedAt),
+	}
+}
+
+func toTargetNote(n sqlitedb.TargetNote) postgres.TargetNote {
+	return postgres.TargetNote{
+		ID:            int32(n.ID),
+		Target:        int32(n.Target),
+		PreviousNotes: n.PreviousNotes,
+		Notes:         n.Notes,
+		UpdatedAt:     toTimestamptz(n.UpdatedAt),
+	}
+}
+
+func toAttachment(a sqlitedb.Attachment) postgres.Attachment {
+	return postgres.Attachment{
+		ID:          int32(a.ID),
+		Target:      int32(a.Target),
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		BlobKey:     a.BlobKey,
+		CreatedAt:   toTimestamptz(a.CreatedAt),
+	}
+}
+
+func toTargetMove(m sqlitedb.TargetMove) postgres.TargetMove {
+	return postgres.TargetMove{
+		ID:          int32(m.ID),
+		Target:      int32(m.Target),
+		FromMission: int32(m.FromMission),
+		ToMission:   int32(m.ToMission),
+		MovedAt:     toTimestamptz(m.MovedAt),
+	}
+}
+
+func toAvailabilityPeriod(p sqlitedb.AvailabilityPeriod) postgres.AvailabilityPeriod {
+	return postgres.AvailabilityPeriod{
+		ID:       int32(p.ID),
+		Cat:      int32(p.Cat),
+		Kind:     p.Kind,
+		StartsAt: toTimestamptz(p.StartsAt),
+		EndsAt:   toTimestamptz(p.EndsAt),
+	}
+}
+
+func toSkill(s sqlitedb.Skill) postgres.Skill {
+	return postgres.Skill{
+		ID:   int32(s.ID),
+		Name: s.Name,
+	}
+}
+
+func toCatSkill(cs sqlitedb.CatSkill) postgres.CatSkill {
+	return postgres.CatSkill{
+		Cat:            int32(cs.Cat),
+		Skill:          int32(cs.Skill),
+		Level:          int32(cs.Level),
+		CertifiedUntil: toNullTimestamptz(cs.CertifiedUntil),
+	}
+}
+
+func toTargetSkill(ts sqlitedb.TargetSkill) postgres.TargetSkill {
+	return postgres.TargetSkill{
+		Target:    int32(ts.Target),
+		Skill:     int32(ts.Skill),
+		MinLevel:  int32(ts.MinLevel),
+		Certified: ts.Certified,
+	}
+}
+
+func toMissionBudget(b sqlitedb.MissionBudget) postgres.MissionBudget {
+	return postgres.MissionBudget{
+		Mission:  int32(b.Mission),
+		Amount:   b.Amount,
+		Currency: b.Currency,
+	}
+}
+
+func toMissionExpense(e sqlitedb.MissionExpense) postgres.MissionExpense {
+	return postgres.MissionExpense{
+		ID:            int32(e.ID),
+		Mission:       int32(e.Mission),
+		Category:      e.Category,
+		Amount:        e.Amount,
+		Currency:      e.Currency,
+		Description:   e.Description,
+		ReceiptNumber: e.ReceiptNumber,
+		ReceiptVendor: e.ReceiptVendor,
+		ReceiptUrl:    e.ReceiptUrl,
+		IncurredAt:    toTimestamptz(e.IncurredAt),
+	}
+}
+
+func toExchangeRate(r sqlitedb.ExchangeRate) postgres.ExchangeRate {
+	return postgres.ExchangeRate{
+		Currency:  r.Currency,
+		Rate:      r.Rate,
+		UpdatedAt: toTimestamptz(r.UpdatedAt),
+	}
+}
@@ -0,0 +1,169 @@
+// Package sqlite implements the storage on SQLite for single-node deployments.
+//
+// The queries in sqlitedb are generated from queries.sql and adapted to the
+// types and errors of the Postgres queries, so the service can use both.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"log/slog"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"github.com/rsmanito/developstoday-test-assessment/internal/config"
+	"github.com/rsmanito/developstoday-test-assessment/internal/fuzzy"
+	"github.com/rsmanito/developstoday-test-assessment/internal/geo"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/sqlite/sqlitedb"
+)
+
+type Storage struct {
+	*querier
+	db *sql.DB
+}
+
+var _ storage.Backend = (*Storage)(nil)
+
+// driverName is the SQLite driver with the functions the queries need, as
+// the build of SQLite doesn't include the math functions, and similarity as
+// in pg_trgm.
+const driverName = "sqlite3_geo"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("haversine_km", haversineKm, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("similarity", fuzzy.Similarity, true)
+		},
+	})
+}
+
+// haversineKm is geo.Distance as an SQL function. It is NULL if an argument
+// isn't a number, as the math functions of SQLite.
+func haversineKm(lat1, lng1, lat2, lng2 any) any {
+	var coords [4]float64
+	for i, v := range []any{lat1, lng1, lat2, lng2} {
+		switch v := v.(type) {
+		case float64:
+			coords[i] = v
+		case int64:
+			coords[i] = float64(v)
+		default:
+			return nil
+		}
+	}
+
+	return geo.Distance(coords[0], coords[1], coords[2], coords[3])
+}
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+func (s *Storage) Migrate() {
+	slog.Info("Migrating database")
+
+	goose.SetBaseFS(embedMigrations)
+
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		panic(err)
+	}
+
+	if err := goose.Up(s.db, "migrations"); err != nil {
+		panic(err)
+	}
+
+	slog.Info("Database migrated")
+}
+
+func New(cfg *config.Config) *Storage {
+	// Transactions take the write lock when they begin, so concurrent writers
+	// wait for each other instead of failing to upgrade their lock.
+	db, err := sql.Open(
+		driverName,
+		"file:"+cfg.SqlitePath+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate",
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		panic(err)
+	}
+
+	st := &Storage{
+		querier: &querier{q: sqlitedb.New(db)},
+		db:      db,
+	}
+	st.Migrate()
+
+	return st
+}
+
+// Begin starts a transaction.
+func (s *Storage) Begin(ctx context.Context) (storage.Tx, error) {
+	t, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx{t}, nil
+}
+
+// BeginTx starts a transaction with the options. The transactions of SQLite
+// are serializable, so every query sees the data as of the first one.
+func (s *Storage) BeginTx(ctx context.Context, opts storage.TxOptions) (storage.Tx, error) {
+	t, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx{t}, nil
+}
+
+// WithTx returns the queries bound to the transaction.
+//
+// The transaction must have been started by the same Storage.
+func (s *Storage) WithTx(t storage.Tx) postgres.Querier {
+	return &querier{q: s.q.WithTx(t.(tx).Tx)}
+}
+
+// SchemaVersion returns the version of the last applied migration.
+func (s *Storage) SchemaVersion(ctx context.Context) (int64, error) {
+	migrations, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := goose.NewProvider(goose.DialectSQLite3, s.db, migrations)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.GetDBVersion(ctx)
+}
+
+func (s *Storage) Close() {
+	if err := s.db.Close(); err != nil {
+		slog.Error("Failed to close database", "err", err)
+	}
+}
+
+// tx adapts sql.Tx to storage.Tx.
+type tx struct {
+	*sql.Tx
+}
+
+func (t tx) Commit(ctx context.Context) error {
+	return t.Tx.Commit()
+}
+
+func (t tx) Rollback(ctx context.Context) error {
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,16 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/config"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/storagetest"
+)
+
+func TestContract(t *testing.T) {
+	st := New(&config.Config{SqlitePath: filepath.Join(t.TempDir(), "sca.db")})
+	t.Cleanup(st.Close)
+
+	storagetest.Run(t, st)
+}
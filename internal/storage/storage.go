@@ -4,27 +4,95 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"io/fs"
 	"log/slog"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 	"github.com/rsmanito/developstoday-test-assessment/internal/config"
 	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
 )
 
+// Tx is a storage transaction.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// TxOptions are the options of a transaction.
+type TxOptions struct {
+	// RepeatableRead makes every query of the transaction see the data as of
+	// its first query. Each query sees the data as of its own start otherwise.
+	RepeatableRead bool
+	// ReadOnly rejects the writes of the transaction.
+	ReadOnly bool
+}
+
+// Backend is a storage implementation of all the queries.
+type Backend interface {
+	postgres.Querier
+	Begin(ctx context.Context) (Tx, error)
+	BeginTx(ctx context.Context, opts TxOptions) (Tx, error)
+	WithTx(tx Tx) postgres.Querier
+	// SchemaVersion returns the version of the last applied migration.
+	SchemaVersion(ctx context.Context) (int64, error)
+	Close()
+}
+
 type Storage struct {
 	*postgres.Queries
-	*pgx.Conn
+	*pgxpool.Pool
 }
 
-func (s *Storage) BeginTx(ctx context.Context) (pgx.Tx, error) {
-	return s.Begin(ctx)
+// Begin starts a transaction.
+func (s *Storage) Begin(ctx context.Context) (Tx, error) {
+	return s.Pool.Begin(ctx)
+}
+
+// BeginTx starts a transaction with the options.
+func (s *Storage) BeginTx(ctx context.Context, opts TxOptions) (Tx, error) {
+	var txOpts pgx.TxOptions
+	if opts.RepeatableRead {
+		txOpts.IsoLevel = pgx.RepeatableRead
+	}
+	if opts.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+
+	return s.Pool.BeginTx(ctx, txOpts)
+}
+
+// WithTx returns the queries bound to the transaction.
+//
+// The transaction must have been started by the same Storage.
+func (s *Storage) WithTx(tx Tx) postgres.Querier {
+	return s.Queries.WithTx(tx.(pgx.Tx))
 }
 
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
+// SchemaVersion returns the version of the last applied migration.
+func (s *Storage) SchemaVersion(ctx context.Context) (int64, error) {
+	migrations, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	db := stdlib.OpenDBFromPool(s.Pool)
+	defer db.Close()
+
+	p, err := goose.NewProvider(goose.DialectPostgres, db, migrations)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.GetDBVersion(ctx)
+}
+
 func (s *Storage) Migrate(cfg *config.Config) {
 	slog.Info("Migrating database")
 
@@ -51,7 +119,8 @@ func (s *Storage) Migrate(cfg *config.Config) {
 }
 
 func New(cfg *config.Config) *Storage {
-	conn, err := pgx.Connect(
+	// A pool lets request handlers and background workers query concurrently.
+	pool, err := pgxpool.New(
 		context.Background(),
 		cfg.DbConnUrl,
 	)
@@ -59,16 +128,16 @@ func New(cfg *config.Config) *Storage {
 		panic(err)
 	}
 
-	err = conn.Ping(context.Background())
+	err = pool.Ping(context.Background())
 	if err != nil {
 		panic(err)
 	}
 
-	queries := postgres.New(conn)
+	queries := postgres.New(pool)
 
 	st := &Storage{
 		queries,
-		conn,
+		pool,
 	}
 	st.Migrate(cfg)
 
@@ -0,0 +1,23 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rsmanito/developstoday-test-assessment/internal/config"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/storagetest"
+)
+
+// TestContract runs on the database of DB_CONN_URL, which must be a local one.
+func TestContract(t *testing.T) {
+	url := os.Getenv("DB_CONN_URL")
+	if url == "" {
+		t.Skip("DB_CONN_URL is not set")
+	}
+
+	st := storage.New(&config.Config{DbConnUrl: url})
+	t.Cleanup(st.Close)
+
+	storagetest.Run(t, st)
+}
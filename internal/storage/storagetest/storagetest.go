@@ -0,0 +1,1948 @@
+// Package storagetest implements a contract test suite for storage backends.
+//
+// Every backend must behave like the Postgres queries, including the foreign
+// keys and the errors, so the service works the same on all of them:
+//
+//	func TestContract(t *testing.T) {
+//		storagetest.Run(t, New())
+//	}
+//
+// The suite doesn't expect an empty database and can run on a shared one.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/tsquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// missingID is an id which is never used by the tests.
+const missingID = 1<<31 - 1
+
+// Run runs the contract suite against the backend.
+func Run(t *testing.T, st storage.Backend) {
+	t.Run("Cats", func(t *testing.T) { testCats(t, st) })
+	t.Run("Missions", func(t *testing.T) { testMissions(t, st) })
+	t.Run("Targets", func(t *testing.T) { testTargets(t, st) })
+	t.Run("MissionTemplates", func(t *testing.T) { testMissionTemplates(t, st) })
+	t.Run("TargetMoves", func(t *testing.T) { testTargetMoves(t, st) })
+	t.Run("Restore", func(t *testing.T) { testRestore(t, st) })
+	t.Run("Reports", func(t *testing.T) { testReports(t, st) })
+	t.Run("Analytics", func(t *testing.T) { testAnalytics(t, st) })
+	t.Run("Availability", func(t *testing.T) { testAvailability(t, st) })
+	t.Run("Skills", func(t *testing.T) { testSkills(t, st) })
+	t.Run("Costs", func(t *testing.T) { testCosts(t, st) })
+	t.Run("ExchangeRates", func(t *testing.T) { testExchangeRates(t, st) })
+	t.Run("Locations", func(t *testing.T) { testLocations(t, st) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, st) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, st) })
+	t.Run("Outbox", func(t *testing.T) { testOutbox(t, st) })
+	t.Run("Subjects", func(t *testing.T) { testSubjects(t, st) })
+	t.Run("Attachments", func(t *testing.T) { testAttachments(t, st) })
+	t.Run("ForeignKeys", func(t *testing.T) { testForeignKeys(t, st) })
+	t.Run("Rollback", func(t *testing.T) { testRollback(t, st) })
+	t.Run("Commit", func(t *testing.T) { testCommit(t, st) })
+	t.Run("ReadOnlyTransaction", func(t *testing.T) { testReadOnlyTransaction(t, st) })
+	t.Run("ConcurrentTransactions", func(t *testing.T) { testConcurrentTransactions(t, st) })
+	t.Run("ConcurrentAssignments", func(t *testing.T) { testConcurrentAssignments(t, st) })
+	t.Run("ConcurrentTargetCounts", func(t *testing.T) { testConcurrentTargetCounts(t, st) })
+}
+
+func createCat(t *testing.T, q postgres.Querier, name string) postgres.Cat {
+	t.Helper()
+
+	cat, err := q.CreateCat(context.Background(), postgres.CreateCatParams{
+		Name:              name,
+		YearsOfExperience: 3,
+		Breed:             "Abyssinian",
+		Salary:            100,
+		SalaryCurrency:    "USD",
+	})
+	require.NoError(t, err)
+
+	return cat
+}
+
+func createTarget(t *testing.T, q postgres.Querier, mission int32, name string) postgres.Target {
+	t.Helper()
+
+	target, err := q.CreateTarget(context.Background(), postgres.CreateTargetParams{
+		Mission: mission,
+		Name:    name,
+		Country: "UA",
+		Notes:   "Notes of " + name,
+	})
+	require.NoError(t, err)
+
+	return target
+}
+
+func assignee(id int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: id, Valid: true}
+}
+
+func subjectID(id int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: id, Valid: true}
+}
+
+func float8(f float64) pgtype.Float8 {
+	return pgtype.Float8{Float64: f, Valid: true}
+}
+
+func assertForeignKeyViolation(t *testing.T, err error) {
+	t.Helper()
+
+	var pgErr *pgconn.PgError
+	if assert.True(t, errors.As(err, &pgErr), "want *pgconn.PgError, got %v", err) {
+		assert.Equal(t, "23503", pgErr.Code)
+	}
+}
+
+func assertUniqueViolation(t *testing.T, err error) {
+	t.Helper()
+
+	var pgErr *pgconn.PgError
+	if assert.True(t, errors.As(err, &pgErr), "want *pgconn.PgError, got %v", err) {
+		assert.Equal(t, "23505", pgErr.Code)
+	}
+}
+
+func testCats(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	cat := createCat(t, st, "Tom")
+	assert.NotZero(t, cat.ID)
+	assert.Equal(t, "Tom", cat.Name)
+	assert.Equal(t, int32(3), cat.YearsOfExperience)
+	assert.Equal(t, "Abyssinian", cat.Breed)
+	assert.Equal(t, int64(100), cat.Salary)
+	assert.Equal(t, "USD", cat.SalaryCurrency)
+
+	got, err := st.GetCat(ctx, cat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cat, got)
+
+	all, err := st.GetAllCats(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, all, cat)
+
+	updated, err := st.UpdateCatSalary(ctx, postgres.UpdateCatSalaryParams{ID: cat.ID, Salary: 250})
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), updated.Salary)
+	assert.Equal(t, "USD", updated.SalaryCurrency, "an empty currency keeps the currency")
+
+	updated, err = st.UpdateCatSalary(ctx, postgres.UpdateCatSalaryParams{ID: cat.ID, Salary: 300, SalaryCurrency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", updated.SalaryCurrency)
+
+	_, err = st.UpdateCatSalary(ctx, postgres.UpdateCatSalaryParams{ID: missingID, Salary: 250})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	deleted, err := st.DeleteCat(ctx, cat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = st.DeleteCat(ctx, cat.ID)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	_, err = st.GetCat(ctx, cat.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testMissions(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	assert.NotZero(t, mission.ID)
+	assert.False(t, mission.Assignee.Valid)
+	assert.False(t, mission.Completed)
+
+	got, err := st.GetMission(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.Equal(t, mission, got)
+
+	all, err := st.GetAllMissions(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, all, mission)
+
+	cat := createCat(t, st, "Tom")
+
+	_, err = st.GetCatMission(ctx, assignee(cat.ID))
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	assigned, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: assignee(cat.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, assignee(cat.ID), assigned.Assignee)
+
+	got, err = st.GetCatMission(ctx, assignee(cat.ID))
+	require.NoError(t, err)
+	assert.Equal(t, mission.ID, got.ID)
+
+	// Unassign.
+	unassigned, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID})
+	require.NoError(t, err)
+	assert.False(t, unassigned.Assignee.Valid)
+
+	completed, err := st.CompleteMission(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.True(t, completed.Completed)
+
+	_, err = st.CompleteMission(ctx, missingID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	deleted, err := st.DeleteMission(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = st.GetMission(ctx, mission.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testTargets(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	ivan := createTarget(t, st, mission.ID, "Ivan")
+	olga := createTarget(t, st, mission.ID, "Olga")
+	assert.Equal(t, mission.ID, ivan.Mission)
+	assert.Equal(t, "Ivan", ivan.Name)
+	assert.Equal(t, "UA", ivan.Country)
+	assert.Equal(t, "Notes of Ivan", ivan.Notes)
+	assert.False(t, ivan.Completed)
+
+	got, err := st.GetTarget(ctx, ivan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ivan.ID, got.ID)
+	assert.Equal(t, ivan.Name, got.Name)
+
+	targets, err := st.GetMissionTargets(ctx, mission.ID)
+	require.NoError(t, err)
+	ids := make([]int32, len(targets))
+	for i, target := range targets {
+		ids[i] = target.ID
+	}
+	// The order is not defined.
+	assert.ElementsMatch(t, []int32{ivan.ID, olga.ID}, ids)
+
+	row, err := st.GetMissionByTargetID(ctx, olga.ID)
+	require.NoError(t, err)
+	assert.Equal(t, mission.ID, row.MissionID)
+
+	updated, err := st.UpdateTargetNotes(ctx, postgres.UpdateTargetNotesParams{ID: ivan.ID, Notes: "new notes"})
+	require.NoError(t, err)
+	assert.Equal(t, "new notes", updated.Notes)
+
+	// The notes are Markdown limited by the rules, not by the column.
+	long := "## Sightings\n\n" + strings.Repeat("- Seen at the square with @cat:1\n", 100)
+	updated, err = st.UpdateTargetNotes(ctx, postgres.UpdateTargetNotesParams{ID: olga.ID, Notes: long})
+	require.NoError(t, err)
+	assert.Equal(t, long, updated.Notes)
+
+	completed, err := st.CompleteTarget(ctx, ivan.ID)
+	require.NoError(t, err)
+	assert.True(t, completed.Completed)
+
+	_, err = st.CompleteTarget(ctx, missingID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	other, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	moved, err := st.MoveTarget(ctx, postgres.MoveTargetParams{ID: ivan.ID, Mission: other.ID})
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, moved.Mission)
+	assert.True(t, moved.Completed)
+
+	targets, err = st.GetMissionTargets(ctx, other.ID)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, ivan.ID, targets[0].ID)
+
+	_, err = st.MoveTarget(ctx, postgres.MoveTargetParams{ID: missingID, Mission: other.ID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	deleted, err := st.DeleteTarget(ctx, olga.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = st.GetTarget(ctx, olga.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testMissionTemplates(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	template, err := st.CreateMissionTemplate(ctx, postgres.CreateMissionTemplateParams{
+		Name:    "Surveillance",
+		Targets: []byte(`[{"name":"{{name}}"}]`),
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, template.ID)
+	assert.True(t, template.CreatedAt.Valid)
+
+	got, err := st.GetMissionTemplate(ctx, template.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Surveillance", got.Name)
+	assert.JSONEq(t, `[{"name":"{{name}}"}]`, string(got.Targets))
+
+	all, err := st.GetAllMissionTemplates(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, all)
+
+	updated, err := st.UpdateMissionTemplate(ctx, postgres.UpdateMissionTemplateParams{
+		ID:      template.ID,
+		Name:    "Night surveillance",
+		Targets: []byte(`[]`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Night surveillance", updated.Name)
+	assert.JSONEq(t, `[]`, string(updated.Targets))
+
+	_, err = st.UpdateMissionTemplate(ctx, postgres.UpdateMissionTemplateParams{ID: missingID, Targets: []byte(`[]`)})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	deleted, err := st.DeleteMissionTemplate(ctx, template.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = st.GetMissionTemplate(ctx, template.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testTargetMoves(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	from, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	to, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	target := createTarget(t, st, from.ID, "Ivan")
+
+	first, err := st.CreateTargetMove(ctx, postgres.CreateTargetMoveParams{Target: target.ID, FromMission: from.ID, ToMission: to.ID})
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+	assert.Equal(t, target.ID, first.Target)
+	assert.Equal(t, from.ID, first.FromMission)
+	assert.Equal(t, to.ID, first.ToMission)
+	assert.True(t, first.MovedAt.Valid)
+
+	second, err := st.CreateTargetMove(ctx, postgres.CreateTargetMoveParams{Target: target.ID, FromMission: to.ID, ToMission: from.ID})
+	require.NoError(t, err)
+
+	moves, err := st.GetTargetMoves(ctx, target.ID)
+	require.NoError(t, err)
+	require.Len(t, moves, 2)
+	assert.Equal(t, first.ID, moves[0].ID)
+	assert.Equal(t, second.ID, moves[1].ID)
+
+	// The moves outlive the target.
+	_, err = st.DeleteTarget(ctx, target.ID)
+	require.NoError(t, err)
+
+	moves, err = st.GetTargetMoves(ctx, target.ID)
+	require.NoError(t, err)
+	assert.Len(t, moves, 2)
+
+	moves, err = st.GetTargetMoves(ctx, missingID)
+	require.NoError(t, err)
+	assert.Empty(t, moves)
+}
+
+func testReports(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	tom := createCat(t, st, "Tom")
+	leo := createCat(t, st, "Leo")
+
+	done, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	doneTarget := createTarget(t, st, done.ID, "Ivan")
+	_, err = st.CompleteTarget(ctx, doneTarget.ID)
+	require.NoError(t, err)
+	_, err = st.AssignCat(ctx, postgres.AssignCatParams{ID: done.ID, Assignee: assignee(tom.ID)})
+	require.NoError(t, err)
+	_, err = st.CompleteMission(ctx, done.ID)
+	require.NoError(t, err)
+
+	active, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	olga := createTarget(t, st, active.ID, "Olga")
+	anna := createTarget(t, st, active.ID, "Anna")
+	_, err = st.AssignCat(ctx, postgres.AssignCatParams{ID: active.ID, Assignee: assignee(tom.ID)})
+	require.NoError(t, err)
+	// A target added to the first mission later sorts before the ones of the second.
+	hans := createTarget(t, st, done.ID, "Hans")
+
+	t.Run("Cats", func(t *testing.T) {
+		page, err := st.GetCatsPage(ctx, postgres.GetCatsPageParams{After: tom.ID - 1, MaxRows: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []postgres.Cat{tom}, page)
+
+		page, err = st.GetCatsPage(ctx, postgres.GetCatsPageParams{After: tom.ID, MaxRows: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []postgres.Cat{leo}, page)
+	})
+
+	t.Run("Missions", func(t *testing.T) {
+		page, err := st.GetMissionsPage(ctx, postgres.GetMissionsPageParams{After: done.ID, MaxRows: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, active.ID, page[0].ID)
+	})
+
+	t.Run("MissionTargets", func(t *testing.T) {
+		page, err := st.GetMissionTargetsPage(ctx, postgres.GetMissionTargetsPageParams{AfterMission: done.ID, AfterTarget: 0, MaxRows: 3})
+		require.NoError(t, err)
+		require.Len(t, page, 3)
+		assert.Equal(t, []int32{doneTarget.ID, hans.ID, olga.ID}, []int32{page[0].TargetID, page[1].TargetID, page[2].TargetID})
+		assert.Equal(t, postgres.GetMissionTargetsPageRow{
+			MissionID:        done.ID,
+			Assignee:         assignee(tom.ID),
+			MissionCompleted: true,
+			TargetID:         doneTarget.ID,
+			Name:             "Ivan",
+			Country:          doneTarget.Country,
+			Notes:            doneTarget.Notes,
+			TargetCompleted:  true,
+		}, page[0])
+
+		page, err = st.GetMissionTargetsPage(ctx, postgres.GetMissionTargetsPageParams{AfterMission: active.ID, AfterTarget: olga.ID, MaxRows: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, anna.ID, page[0].TargetID)
+	})
+
+	t.Run("Targets", func(t *testing.T) {
+		page, err := st.GetTargetsPage(ctx, postgres.GetTargetsPageParams{After: doneTarget.ID, MaxRows: 2})
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, []int32{olga.ID, anna.ID}, []int32{page[0].ID, page[1].ID})
+		assert.Equal(t, active.ID, page[0].Mission)
+	})
+
+	t.Run("Subjects", func(t *testing.T) {
+		ivan, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Ivan", Country: "UA"})
+		require.NoError(t, err)
+		olga, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Olga", Country: "PL"})
+		require.NoError(t, err)
+
+		page, err := st.GetSubjectsPage(ctx, postgres.GetSubjectsPageParams{After: ivan.ID - 1, MaxRows: 1})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, ivan.ID, page[0].ID)
+
+		page, err = st.GetSubjectsPage(ctx, postgres.GetSubjectsPageParams{After: ivan.ID, MaxRows: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, "Olga", page[0].Name)
+		assert.Equal(t, olga.ID, page[0].ID)
+	})
+
+	t.Run("CatWorkload", func(t *testing.T) {
+		page, err := st.GetCatWorkloadPage(ctx, postgres.GetCatWorkloadPageParams{After: tom.ID - 1, MaxRows: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []postgres.GetCatWorkloadPageRow{
+			{ID: tom.ID, Name: "Tom", Breed: tom.Breed, Salary: tom.Salary, SalaryCurrency: "USD", ActiveMissions: 1, CompletedMissions: 1, OpenTargets: 3, CompletedTargets: 1},
+			{ID: leo.ID, Name: "Leo", Breed: leo.Breed, Salary: leo.Salary, SalaryCurrency: "USD"},
+		}, page)
+	})
+}
+
+func testAnalytics(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	// The database may be shared, so the aggregations are compared before and
+	// after the rows of the test.
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rateBefore, err := st.GetMissionCompletionRate(ctx, postgres.GetMissionCompletionRateParams{TimeWindow: "day", Since: pgtype.Timestamptz{Time: today, Valid: true}})
+	require.NoError(t, err)
+	timesBefore, err := st.GetCompletionTimes(ctx)
+	require.NoError(t, err)
+	countriesBefore, err := st.GetTargetsPerCountry(ctx)
+	require.NoError(t, err)
+	salaryBefore, err := st.GetSalaryPerformance(ctx)
+	require.NoError(t, err)
+
+	tom := createCat(t, st, "Tom")
+
+	done, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	assert.True(t, done.CreatedAt.Valid)
+	ivan, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: done.ID, Name: "Ivan", Country: "ZZ"})
+	require.NoError(t, err)
+	_, err = st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: done.ID, Name: "Olga", Country: "ZZ"})
+	require.NoError(t, err)
+
+	assigned, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: done.ID, Assignee: assignee(tom.ID)})
+	require.NoError(t, err)
+	assert.True(t, assigned.AssignedAt.Valid)
+	// Reassigning the same cat keeps the time of the assignment.
+	reassigned, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: done.ID, Assignee: assignee(tom.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, assigned.AssignedAt, reassigned.AssignedAt)
+	// Felix was assigned two days before Tom took over the mission.
+	felix := createCat(t, st, "Felix")
+	felixSince := pgtype.Timestamptz{Time: assigned.AssignedAt.Time.Add(-48 * time.Hour), Valid: true}
+	require.NoError(t, st.CreateMissionAssignment(ctx, postgres.CreateMissionAssignmentParams{Mission: done.ID, Cat: felix.ID, AssignedAt: felixSince}))
+	require.NoError(t, st.EndMissionAssignment(ctx, done.ID))
+	require.NoError(t, st.CreateMissionAssignment(ctx, postgres.CreateMissionAssignmentParams{Mission: done.ID, Cat: tom.ID, AssignedAt: assigned.AssignedAt}))
+
+	completedTarget, err := st.CompleteTarget(ctx, ivan.ID)
+	require.NoError(t, err)
+	assert.True(t, completedTarget.CompletedAt.Valid)
+	completed, err := st.CompleteMission(ctx, done.ID)
+	require.NoError(t, err)
+	assert.True(t, completed.CompletedAt.Valid)
+
+	_, err = st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	t.Run("CompletionRate", func(t *testing.T) {
+		rate, err := st.GetMissionCompletionRate(ctx, postgres.GetMissionCompletionRateParams{TimeWindow: "day", Since: pgtype.Timestamptz{Time: today, Valid: true}})
+		require.NoError(t, err)
+		require.Len(t, rate, 1)
+		assert.True(t, today.Equal(rate[0].Period.Time), "period %v", rate[0].Period.Time)
+
+		var created, completed int64
+		if len(rateBefore) > 0 {
+			created, completed = rateBefore[0].Created, rateBefore[0].Completed
+		}
+		assert.Equal(t, created+2, rate[0].Created)
+		assert.Equal(t, completed+1, rate[0].Completed)
+	})
+
+	t.Run("CompletionTimes", func(t *testing.T) {
+		times, err := st.GetCompletionTimes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, timesBefore.CompletedMissions+1, times.CompletedMissions)
+		assert.Equal(t, timesBefore.CompletedTargets+1, times.CompletedTargets)
+		assert.GreaterOrEqual(t, times.MissionSeconds, 0.0)
+	})
+
+	t.Run("CatUtilization", func(t *testing.T) {
+		// A day after the completion, which ends the time on the mission.
+		rows, err := st.GetCatUtilization(ctx, pgtype.Timestamptz{Time: completed.CompletedAt.Time.Add(24 * time.Hour), Valid: true})
+		require.NoError(t, err)
+
+		i := slices.IndexFunc(rows, func(r postgres.GetCatUtilizationRow) bool { return r.ID == tom.ID })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, "Tom", rows[i].Name)
+		assert.Equal(t, int64(1), rows[i].Missions)
+		assert.InDelta(t, completed.CompletedAt.Time.Sub(assigned.AssignedAt.Time).Hours()/24, rows[i].DaysOnMission, 1e-6)
+
+		// The previous assignee keeps the time until Tom took over.
+		i = slices.IndexFunc(rows, func(r postgres.GetCatUtilizationRow) bool { return r.ID == felix.ID })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, int64(1), rows[i].Missions)
+		assert.InDelta(t, 2.0, rows[i].DaysOnMission, 0.01)
+	})
+
+	t.Run("TargetsPerCountry", func(t *testing.T) {
+		rows, err := st.GetTargetsPerCountry(ctx)
+		require.NoError(t, err)
+
+		var before postgres.GetTargetsPerCountryRow
+		if i := slices.IndexFunc(countriesBefore, func(r postgres.GetTargetsPerCountryRow) bool { return r.Country == "ZZ" }); i >= 0 {
+			before = countriesBefore[i]
+		}
+		i := slices.IndexFunc(rows, func(r postgres.GetTargetsPerCountryRow) bool { return r.Country == "ZZ" })
+		require.GreaterOrEqual(t, i, 0)
+		assert.Equal(t, postgres.GetTargetsPerCountryRow{Country: "ZZ", Targets: before.Targets + 2, Completed: before.Completed + 1}, rows[i])
+		assert.True(t, slices.IsSortedFunc(rows, func(a, b postgres.GetTargetsPerCountryRow) int { return int(b.Targets - a.Targets) }))
+	})
+
+	t.Run("SalaryPerformance", func(t *testing.T) {
+		rows, err := st.GetSalaryPerformance(ctx)
+		require.NoError(t, err)
+		assert.True(t, slices.IsSortedFunc(rows, func(a, b postgres.GetSalaryPerformanceRow) int {
+			return strings.Compare(a.SalaryCurrency, b.SalaryCurrency)
+		}))
+
+		// Tom and Felix are paid in USD, and Tom has the completed mission.
+		row, salaryBefore := salaryPerformance(rows, "USD"), salaryPerformance(salaryBefore, "USD")
+		assert.Equal(t, salaryBefore.Cats+2, row.Cats)
+		assert.InDelta(t, salaryBefore.SumSalary+200, row.SumSalary, 1e-6)
+		assert.InDelta(t, salaryBefore.SumMissions+1, row.SumMissions, 1e-6)
+		assert.InDelta(t, salaryBefore.SumTargets+1, row.SumTargets, 1e-6)
+		assert.InDelta(t, salaryBefore.SumSalaryMissions+100, row.SumSalaryMissions, 1e-6)
+		assert.InDelta(t, salaryBefore.SumSalaryTargets+100, row.SumSalaryTargets, 1e-6)
+	})
+}
+
+// salaryPerformance returns the row of the currency, a zero row if there is none.
+func salaryPerformance(rows []postgres.GetSalaryPerformanceRow, currency string) postgres.GetSalaryPerformanceRow {
+	for _, r := range rows {
+		if r.SalaryCurrency == currency {
+			return r
+		}
+	}
+	return postgres.GetSalaryPerformanceRow{SalaryCurrency: currency}
+}
+
+func testAvailability(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	at := func(day int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	tom := createCat(t, st, "Tom")
+	vacation, err := st.CreateAvailabilityPeriod(ctx, postgres.CreateAvailabilityPeriodParams{Cat: tom.ID, Kind: "vacation", StartsAt: at(10), EndsAt: at(15)})
+	require.NoError(t, err)
+	assert.True(t, at(10).Time.Equal(vacation.StartsAt.Time))
+	training, err := st.CreateAvailabilityPeriod(ctx, postgres.CreateAvailabilityPeriodParams{Cat: tom.ID, Kind: "training", StartsAt: at(1), EndsAt: at(2)})
+	require.NoError(t, err)
+
+	t.Run("List", func(t *testing.T) {
+		periods, err := st.GetCatAvailabilityPeriods(ctx, tom.ID)
+		require.NoError(t, err)
+		require.Len(t, periods, 2)
+		assert.Equal(t, training.ID, periods[0].ID)
+		assert.Equal(t, vacation.ID, periods[1].ID)
+	})
+
+	t.Run("Overlapping", func(t *testing.T) {
+		overlapping := func(since, until int) []int32 {
+			periods, err := st.GetOverlappingAvailabilityPeriods(ctx, postgres.GetOverlappingAvailabilityPeriodsParams{Cat: tom.ID, Since: at(since), Until: at(until)})
+			require.NoError(t, err)
+			ids := make([]int32, len(periods))
+			for i, p := range periods {
+				ids[i] = p.ID
+			}
+			return ids
+		}
+
+		assert.Equal(t, []int32{vacation.ID}, overlapping(12, 12))
+		assert.Equal(t, []int32{training.ID, vacation.ID}, overlapping(1, 10))
+		// The periods end exclusively.
+		assert.Empty(t, overlapping(15, 20))
+		assert.Empty(t, overlapping(5, 9))
+	})
+
+	t.Run("AvailableCats", func(t *testing.T) {
+		available := func(day int) bool {
+			cats, err := st.GetAvailableCats(ctx, at(day))
+			require.NoError(t, err)
+			return slices.ContainsFunc(cats, func(c postgres.Cat) bool { return c.ID == tom.ID })
+		}
+
+		assert.False(t, available(12))
+		assert.True(t, available(15))
+	})
+
+	t.Run("AvailableBetween", func(t *testing.T) {
+		available := func(since, until int) bool {
+			cats, err := st.GetCatsAvailableBetween(ctx, postgres.GetCatsAvailableBetweenParams{Since: at(since), Until: at(until)})
+			require.NoError(t, err)
+			return slices.ContainsFunc(cats, func(c postgres.Cat) bool { return c.ID == tom.ID })
+		}
+
+		assert.False(t, available(5, 20))
+		assert.False(t, available(12, 12))
+		assert.True(t, available(5, 9))
+		assert.True(t, available(15, 20))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		updated, err := st.UpdateAvailabilityPeriod(ctx, postgres.UpdateAvailabilityPeriodParams{ID: training.ID, Kind: "sick", StartsAt: at(3), EndsAt: at(4)})
+		require.NoError(t, err)
+		assert.Equal(t, "sick", updated.Kind)
+		assert.Equal(t, tom.ID, updated.Cat)
+
+		_, err = st.UpdateAvailabilityPeriod(ctx, postgres.UpdateAvailabilityPeriodParams{ID: missingID, Kind: "sick", StartsAt: at(3), EndsAt: at(4)})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rows, err := st.DeleteAvailabilityPeriod(ctx, training.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+
+		_, err = st.GetAvailabilityPeriod(ctx, training.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("DeleteCatCascades", func(t *testing.T) {
+		_, err := st.DeleteCat(ctx, tom.ID)
+		require.NoError(t, err)
+
+		_, err = st.GetAvailabilityPeriod(ctx, vacation.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("MissingCat", func(t *testing.T) {
+		_, err := st.CreateAvailabilityPeriod(ctx, postgres.CreateAvailabilityPeriodParams{Cat: missingID, Kind: "sick", StartsAt: at(1), EndsAt: at(2)})
+		assertForeignKeyViolation(t, err)
+	})
+}
+
+func testSkills(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	// The names of the skills are unique in a shared database too.
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	lockpicking, err := st.CreateSkill(ctx, "lockpicking "+suffix)
+	require.NoError(t, err)
+	climbing, err := st.CreateSkill(ctx, "climbing "+suffix)
+	require.NoError(t, err)
+
+	tom := createCat(t, st, "Tom")
+	felix := createCat(t, st, "Felix")
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	ivan := createTarget(t, st, mission.ID, "Ivan")
+	olga := createTarget(t, st, mission.ID, "Olga")
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		_, err := st.CreateSkill(ctx, lockpicking.Name)
+		assertUniqueViolation(t, err)
+	})
+
+	t.Run("CatSkills", func(t *testing.T) {
+		until := pgtype.Timestamptz{Time: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+		_, err := st.SetCatSkill(ctx, postgres.SetCatSkillParams{Cat: tom.ID, Skill: lockpicking.ID, Level: 2})
+		require.NoError(t, err)
+		// Setting the skill again updates it.
+		cs, err := st.SetCatSkill(ctx, postgres.SetCatSkillParams{Cat: tom.ID, Skill: lockpicking.ID, Level: 4, CertifiedUntil: until})
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), cs.Level)
+		_, err = st.SetCatSkill(ctx, postgres.SetCatSkillParams{Cat: tom.ID, Skill: climbing.ID, Level: 1})
+		require.NoError(t, err)
+
+		skills, err := st.GetCatSkills(ctx, tom.ID)
+		require.NoError(t, err)
+		require.Len(t, skills, 2)
+		assert.Equal(t, climbing.Name, skills[0].Name)
+		assert.False(t, skills[0].CertifiedUntil.Valid)
+		assert.Equal(t, lockpicking.Name, skills[1].Name)
+		assert.True(t, until.Time.Equal(skills[1].CertifiedUntil.Time))
+
+		_, err = st.SetCatSkill(ctx, postgres.SetCatSkillParams{Cat: tom.ID, Skill: missingID, Level: 1})
+		assertForeignKeyViolation(t, err)
+	})
+
+	t.Run("RequiredSkills", func(t *testing.T) {
+		_, err := st.SetTargetSkill(ctx, postgres.SetTargetSkillParams{Target: ivan.ID, Skill: lockpicking.ID, MinLevel: 2})
+		require.NoError(t, err)
+		_, err = st.SetTargetSkill(ctx, postgres.SetTargetSkillParams{Target: olga.ID, Skill: lockpicking.ID, MinLevel: 3, Certified: true})
+		require.NoError(t, err)
+		_, err = st.SetTargetSkill(ctx, postgres.SetTargetSkillParams{Target: olga.ID, Skill: climbing.ID, MinLevel: 5})
+		require.NoError(t, err)
+
+		skills, err := st.GetTargetSkills(ctx, olga.ID)
+		require.NoError(t, err)
+		assert.Len(t, skills, 2)
+
+		// The highest level of the pending targets.
+		required, err := st.GetMissionRequiredSkills(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []postgres.GetMissionRequiredSkillsRow{
+			{Skill: climbing.ID, Name: climbing.Name, MinLevel: 5},
+			{Skill: lockpicking.ID, Name: lockpicking.Name, MinLevel: 3, Certified: true},
+		}, required)
+
+		_, err = st.CompleteTarget(ctx, olga.ID)
+		require.NoError(t, err)
+
+		required, err = st.GetMissionRequiredSkills(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []postgres.GetMissionRequiredSkillsRow{
+			{Skill: lockpicking.ID, Name: lockpicking.Name, MinLevel: 2},
+		}, required)
+	})
+
+	t.Run("Unassigned", func(t *testing.T) {
+		unassigned := func() ([]int32, []int32) {
+			cats, err := st.GetUnassignedCats(ctx)
+			require.NoError(t, err)
+			skills, err := st.GetUnassignedCatSkills(ctx)
+			require.NoError(t, err)
+
+			var catIDs, skillCats []int32
+			for _, c := range cats {
+				if c.ID == tom.ID || c.ID == felix.ID {
+					catIDs = append(catIDs, c.ID)
+				}
+			}
+			for _, cs := range skills {
+				if cs.Cat == tom.ID || cs.Cat == felix.ID {
+					skillCats = append(skillCats, cs.Cat)
+				}
+			}
+			return catIDs, skillCats
+		}
+
+		cats, skillCats := unassigned()
+		assert.Equal(t, []int32{tom.ID, felix.ID}, cats)
+		assert.Equal(t, []int32{tom.ID, tom.ID}, skillCats)
+
+		_, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: assignee(tom.ID)})
+		require.NoError(t, err)
+
+		cats, skillCats = unassigned()
+		assert.Equal(t, []int32{felix.ID}, cats)
+		assert.Empty(t, skillCats)
+
+		// The cats of the completed missions are unassigned.
+		_, err = st.CompleteTarget(ctx, ivan.ID)
+		require.NoError(t, err)
+		_, err = st.CompleteMission(ctx, mission.ID)
+		require.NoError(t, err)
+
+		cats, _ = unassigned()
+		assert.Equal(t, []int32{tom.ID, felix.ID}, cats)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rows, err := st.DeleteCatSkill(ctx, postgres.DeleteCatSkillParams{Cat: tom.ID, Skill: climbing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+		rows, err = st.DeleteTargetSkill(ctx, postgres.DeleteTargetSkillParams{Target: ivan.ID, Skill: climbing.ID})
+		require.NoError(t, err)
+		assert.Zero(t, rows)
+	})
+
+	t.Run("DeleteCascades", func(t *testing.T) {
+		_, err := st.DeleteSkill(ctx, lockpicking.ID)
+		require.NoError(t, err)
+
+		skills, err := st.GetCatSkills(ctx, tom.ID)
+		require.NoError(t, err)
+		assert.Empty(t, skills)
+		targetSkills, err := st.GetTargetSkills(ctx, ivan.ID)
+		require.NoError(t, err)
+		assert.Empty(t, targetSkills)
+
+		_, err = st.DeleteMission(ctx, mission.ID)
+		require.NoError(t, err)
+		targetSkills, err = st.GetTargetSkills(ctx, olga.ID)
+		require.NoError(t, err)
+		assert.Empty(t, targetSkills)
+	})
+}
+
+func testRestore(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	version, err := st.SchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, version)
+
+	// The restored IDs leave a gap after the last ones, so the sequences must
+	// be reset to create entities after them. The rows are rolled back, only
+	// the Postgres sequences keep the gap.
+	last := createCat(t, st, "Tom")
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	target := createTarget(t, st, mission.ID, "Ivan")
+
+	tx, err := st.Begin(ctx)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	withTx := st.WithTx(tx)
+
+	cat := postgres.RestoreCatParams{ID: last.ID + 100, Name: "Luna", YearsOfExperience: 2, Breed: "Bengal", Salary: 90, SalaryCurrency: "GBP"}
+	require.NoError(t, withTx.RestoreCat(ctx, cat))
+	createdAt := pgtype.Timestamptz{Time: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), Valid: true}
+	completedAt := pgtype.Timestamptz{Time: time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC), Valid: true}
+	require.NoError(t, withTx.RestoreMission(ctx, postgres.RestoreMissionParams{
+		ID:          mission.ID + 100,
+		Assignee:    assignee(cat.ID),
+		Completed:   true,
+		CreatedAt:   createdAt,
+		AssignedAt:  createdAt,
+		CompletedAt: completedAt,
+	}))
+	subjects, err := withTx.GetAllSubjects(ctx)
+	require.NoError(t, err)
+	var lastSubject int32
+	if len(subjects) > 0 {
+		lastSubject = subjects[len(subjects)-1].ID
+	}
+	require.NoError(t, withTx.RestoreSubject(ctx, postgres.RestoreSubjectParams{
+		ID:        lastSubject + 100,
+		Name:      "Olga",
+		Country:   "PL",
+		CreatedAt: createdAt,
+	}))
+	require.NoError(t, withTx.RestoreTarget(ctx, postgres.RestoreTargetParams{
+		ID:          target.ID + 100,
+		Mission:     mission.ID + 100,
+		Name:        "Olga",
+		Country:     "PL",
+		Notes:       "Notes of Olga",
+		Completed:   true,
+		CreatedAt:   createdAt,
+		CompletedAt: completedAt,
+		Latitude:    float8(52.2297),
+		Longitude:   float8(21.0122),
+		Address:     "Warsaw",
+		Subject:     subjectID(lastSubject + 100),
+	}))
+
+	gotCat, err := withTx.GetCat(ctx, cat.ID)
+	require.NoError(t, err)
+	assert.Equal(t, postgres.Cat{ID: cat.ID, Name: "Luna", YearsOfExperience: 2, Breed: "Bengal", Salary: 90, SalaryCurrency: "GBP"}, gotCat)
+
+	gotMission, err := withTx.GetMission(ctx, mission.ID+100)
+	require.NoError(t, err)
+	assert.Equal(t, assignee(cat.ID), gotMission.Assignee)
+	assert.True(t, gotMission.Completed)
+	assert.True(t, createdAt.Time.Equal(gotMission.CreatedAt.Time))
+	assert.True(t, createdAt.Time.Equal(gotMission.AssignedAt.Time))
+	assert.True(t, completedAt.Time.Equal(gotMission.CompletedAt.Time))
+
+	targets, err := withTx.GetAllTargets(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, targets)
+	assert.Equal(t, target.ID+100, targets[len(targets)-1].ID, "targets are ordered by ID")
+	assert.True(t, targets[len(targets)-1].Completed)
+	assert.Equal(t, float8(52.2297), targets[len(targets)-1].Latitude)
+	assert.Equal(t, float8(21.0122), targets[len(targets)-1].Longitude)
+	assert.Equal(t, "Warsaw", targets[len(targets)-1].Address)
+	assert.Equal(t, subjectID(lastSubject+100), targets[len(targets)-1].Subject)
+
+	subjects, err = withTx.GetAllSubjects(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, subjects)
+	assert.Equal(t, "Olga", subjects[len(subjects)-1].Name)
+	assert.True(t, createdAt.Time.Equal(subjects[len(subjects)-1].CreatedAt.Time))
+
+	require.NoError(t, withTx.ResetSequences(ctx))
+
+	next := createCat(t, withTx, "Max")
+	assert.Greater(t, next.ID, cat.ID)
+	nextMission, err := withTx.CreateMission(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, nextMission.ID, mission.ID+100)
+	nextTarget := createTarget(t, withTx, nextMission.ID, "Anna")
+	assert.Greater(t, nextTarget.ID, target.ID+100)
+	nextSubject, err := withTx.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Anna", Country: "UA"})
+	require.NoError(t, err)
+	assert.Greater(t, nextSubject.ID, lastSubject+100)
+
+	require.NoError(t, tx.Rollback(ctx))
+
+	// A failed statement aborts a Postgres transaction, so the violations
+	// are checked outside of it.
+	t.Run("DuplicateID", func(t *testing.T) {
+		err := st.RestoreCat(ctx, postgres.RestoreCatParams{ID: last.ID, Name: "Tom", Breed: "Abyssinian"})
+		assertUniqueViolation(t, err)
+	})
+
+	t.Run("MissingCat", func(t *testing.T) {
+		err := st.RestoreMission(ctx, postgres.RestoreMissionParams{ID: missingID, Assignee: assignee(missingID), CreatedAt: createdAt})
+		assertForeignKeyViolation(t, err)
+	})
+
+	t.Run("MissingMission", func(t *testing.T) {
+		err := st.RestoreTarget(ctx, postgres.RestoreTargetParams{ID: missingID, Mission: missingID, Name: "Ivan", Country: "UA", CreatedAt: createdAt})
+		assertForeignKeyViolation(t, err)
+	})
+}
+
+func testCosts(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	at := func(day int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.Date(2025, 3, day, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	t.Run("Budget", func(t *testing.T) {
+		_, err := st.GetMissionBudget(ctx, mission.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		_, err = st.SetMissionBudget(ctx, postgres.SetMissionBudgetParams{Mission: mission.ID, Amount: 1000, Currency: "USD"})
+		require.NoError(t, err)
+		budget, err := st.SetMissionBudget(ctx, postgres.SetMissionBudgetParams{Mission: mission.ID, Amount: 5000, Currency: "EUR"})
+		require.NoError(t, err)
+		assert.Equal(t, postgres.MissionBudget{Mission: mission.ID, Amount: 5000, Currency: "EUR"}, budget)
+
+		got, err := st.GetMissionBudget(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Equal(t, budget, got)
+	})
+
+	flight, err := st.CreateMissionExpense(ctx, postgres.CreateMissionExpenseParams{
+		Mission:       mission.ID,
+		Category:      "travel",
+		Amount:        12050,
+		Currency:      "EUR",
+		Description:   "Flight to Kyiv",
+		ReceiptNumber: "A-17",
+		ReceiptVendor: "Airline",
+		ReceiptUrl:    "https://example.com/a-17.pdf",
+		IncurredAt:    at(5),
+	})
+	require.NoError(t, err)
+	assert.True(t, at(5).Time.Equal(flight.IncurredAt.Time))
+	rope, err := st.CreateMissionExpense(ctx, postgres.CreateMissionExpenseParams{Mission: mission.ID, Category: "equipment", Amount: 900, Currency: "EUR", IncurredAt: at(1)})
+	require.NoError(t, err)
+
+	t.Run("Expenses", func(t *testing.T) {
+		expenses, err := st.GetMissionExpenses(ctx, mission.ID)
+		require.NoError(t, err)
+		require.Len(t, expenses, 2)
+		assert.Equal(t, rope.ID, expenses[0].ID)
+		assert.Equal(t, flight, expenses[1])
+
+		got, err := st.GetMissionExpense(ctx, flight.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "A-17", got.ReceiptNumber)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rows, err := st.DeleteMissionExpense(ctx, rope.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+
+		rows, err = st.DeleteMissionExpense(ctx, rope.ID)
+		require.NoError(t, err)
+		assert.Zero(t, rows)
+	})
+
+	t.Run("DeleteMissionCascades", func(t *testing.T) {
+		_, err := st.DeleteMission(ctx, mission.ID)
+		require.NoError(t, err)
+
+		_, err = st.GetMissionBudget(ctx, mission.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = st.GetMissionExpense(ctx, flight.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("MissingMission", func(t *testing.T) {
+		_, err := st.SetMissionBudget(ctx, postgres.SetMissionBudgetParams{Mission: missingID, Amount: 1, Currency: "USD"})
+		assertForeignKeyViolation(t, err)
+		_, err = st.CreateMissionExpense(ctx, postgres.CreateMissionExpenseParams{Mission: missingID, Category: "travel", Amount: 1, Currency: "USD", IncurredAt: at(1)})
+		assertForeignKeyViolation(t, err)
+	})
+}
+
+func testExchangeRates(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	_, err := st.SetExchangeRate(ctx, postgres.SetExchangeRateParams{Currency: "JPY", Rate: 0.01})
+	require.NoError(t, err)
+	jpy, err := st.SetExchangeRate(ctx, postgres.SetExchangeRateParams{Currency: "JPY", Rate: 0.0067})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0067, jpy.Rate)
+	assert.True(t, jpy.UpdatedAt.Valid)
+	eur, err := st.SetExchangeRate(ctx, postgres.SetExchangeRateParams{Currency: "EUR", Rate: 1.08})
+	require.NoError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		rates, err := st.GetExchangeRates(ctx)
+		require.NoError(t, err)
+		rates = slices.DeleteFunc(rates, func(r postgres.ExchangeRate) bool { return r.Currency != "EUR" && r.Currency != "JPY" })
+		require.Len(t, rates, 2)
+		assert.Equal(t, "EUR", rates[0].Currency)
+		assert.Equal(t, 1.08, rates[0].Rate)
+		assert.True(t, eur.UpdatedAt.Time.Equal(rates[0].UpdatedAt.Time))
+		assert.Equal(t, "JPY", rates[1].Currency)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		for _, currency := range []string{"EUR", "JPY"} {
+			rows, err := st.DeleteExchangeRate(ctx, currency)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), rows)
+		}
+
+		rows, err := st.DeleteExchangeRate(ctx, "JPY")
+		require.NoError(t, err)
+		assert.Zero(t, rows)
+	})
+}
+
+func testLocations(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	kyiv, err := st.CreateTarget(ctx, postgres.CreateTargetParams{
+		Mission:   mission.ID,
+		Name:      "Ivan",
+		Country:   "UA",
+		Latitude:  float8(50.4501),
+		Longitude: float8(30.5234),
+		Address:   "Khreshchatyk 1, Kyiv",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, float8(50.4501), kyiv.Latitude)
+	assert.Equal(t, float8(30.5234), kyiv.Longitude)
+	assert.Equal(t, "Khreshchatyk 1, Kyiv", kyiv.Address)
+
+	lviv := createTarget(t, st, mission.ID, "Olga")
+	assert.False(t, lviv.Latitude.Valid)
+	assert.False(t, lviv.Longitude.Valid)
+	unlocated := createTarget(t, st, mission.ID, "Anna")
+
+	t.Run("Update", func(t *testing.T) {
+		lviv, err = st.UpdateTargetLocation(ctx, postgres.UpdateTargetLocationParams{
+			ID:        lviv.ID,
+			Latitude:  float8(49.8397),
+			Longitude: float8(24.0297),
+			Address:   "Lviv",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, float8(49.8397), lviv.Latitude)
+		assert.Equal(t, "Lviv", lviv.Address)
+
+		got, err := st.GetTarget(ctx, lviv.ID)
+		require.NoError(t, err)
+		assert.Equal(t, lviv, got)
+
+		_, err = st.UpdateTargetLocation(ctx, postgres.UpdateTargetLocationParams{ID: missingID})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	// The database is shared, so only the targets of the test are checked.
+	near := func(t *testing.T, radius float64) []postgres.GetTargetsNearRow {
+		t.Helper()
+
+		rows, err := st.GetTargetsNear(ctx, postgres.GetTargetsNearParams{Lat: 50.4547, Lng: 30.5238, RadiusKm: radius, MaxRows: 1000})
+		require.NoError(t, err)
+		for i := 1; i < len(rows); i++ {
+			assert.LessOrEqual(t, rows[i-1].DistanceKm, rows[i].DistanceKm, "rows are ordered by distance")
+		}
+
+		return slices.DeleteFunc(rows, func(r postgres.GetTargetsNearRow) bool {
+			return r.ID != kyiv.ID && r.ID != lviv.ID && r.ID != unlocated.ID
+		})
+	}
+
+	t.Run("Near", func(t *testing.T) {
+		rows := near(t, 1)
+		require.Len(t, rows, 1)
+		assert.Equal(t, kyiv.ID, rows[0].ID)
+		assert.Equal(t, mission.ID, rows[0].Mission)
+		assert.Equal(t, "Khreshchatyk 1, Kyiv", rows[0].Address)
+		assert.InDelta(t, 0.51, rows[0].DistanceKm, 0.01)
+
+		rows = near(t, 500)
+		require.Len(t, rows, 2)
+		assert.Equal(t, kyiv.ID, rows[0].ID)
+		assert.Equal(t, lviv.ID, rows[1].ID)
+		assert.InDelta(t, 468, rows[1].DistanceKm, 1)
+	})
+
+	t.Run("MaxRows", func(t *testing.T) {
+		rows, err := st.GetTargetsNear(ctx, postgres.GetTargetsNearParams{Lat: 50.4501, Lng: 30.5234, RadiusKm: 1, MaxRows: 1})
+		require.NoError(t, err)
+		assert.Len(t, rows, 1)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		_, err := st.UpdateTargetLocation(ctx, postgres.UpdateTargetLocationParams{ID: kyiv.ID})
+		require.NoError(t, err)
+
+		assert.Len(t, near(t, 500), 1)
+	})
+}
+
+func testSubjects(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	ivan, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Ivan", Country: "UA"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ivan", ivan.Name)
+	assert.Equal(t, "UA", ivan.Country)
+	assert.True(t, ivan.CreatedAt.Valid)
+
+	got, err := st.GetSubject(ctx, ivan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ivan.ID, got.ID)
+
+	_, err = st.GetSubject(ctx, missingID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	ivanov, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Ivan Ivanov", Country: "UA"})
+	require.NoError(t, err)
+
+	first, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	second, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	a, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: first.ID, Name: "Ivan", Country: "UA", Subject: subjectID(ivan.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, subjectID(ivan.ID), a.Subject)
+	b, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: second.ID, Name: "Ivan Ivanov", Country: "UA", Subject: subjectID(ivanov.ID)})
+	require.NoError(t, err)
+	unlinked := createTarget(t, st, first.ID, "Olga")
+	assert.False(t, unlinked.Subject.Valid)
+
+	t.Run("ByIDs", func(t *testing.T) {
+		subjects, err := st.GetSubjectsByIDs(ctx, []int32{ivanov.ID, missingID, ivan.ID})
+		require.NoError(t, err)
+		require.Len(t, subjects, 2)
+		assert.Equal(t, ivan.ID, subjects[0].ID)
+		assert.Equal(t, ivanov.ID, subjects[1].ID)
+	})
+
+	t.Run("Similar", func(t *testing.T) {
+		pl, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Bohdan Khmelnytskyi", Country: "PL"})
+		require.NoError(t, err)
+		ua, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Bohdan Khmelnytskyi", Country: "UA"})
+		require.NoError(t, err)
+
+		// The country breaks the tie of the names.
+		similar, err := st.GetSimilarSubjects(ctx, postgres.GetSimilarSubjectsParams{Name: "bohdan khmelnytsky", Country: "ua", MaxSubjects: 2})
+		require.NoError(t, err)
+		require.Len(t, similar, 2)
+		assert.Equal(t, ua.ID, similar[0].ID)
+		assert.Equal(t, pl.ID, similar[1].ID)
+
+		similar, err = st.GetSimilarSubjects(ctx, postgres.GetSimilarSubjectsParams{Name: "Bohdan Khmelnytskyi", Country: "PL", MaxSubjects: 1})
+		require.NoError(t, err)
+		require.Len(t, similar, 1)
+		assert.Equal(t, pl.ID, similar[0].ID)
+
+		similar, err = st.GetSimilarSubjects(ctx, postgres.GetSimilarSubjectsParams{Name: "Zzyzx", Country: "UA", MaxSubjects: 5})
+		require.NoError(t, err)
+		assert.Empty(t, similar)
+	})
+
+	t.Run("Targets", func(t *testing.T) {
+		targets, err := st.GetSubjectTargets(ctx, subjectID(ivan.ID))
+		require.NoError(t, err)
+		require.Len(t, targets, 1)
+		assert.Equal(t, a.ID, targets[0].ID)
+
+		targets, err = st.GetSubjectTargets(ctx, pgtype.Int4{})
+		require.NoError(t, err)
+		assert.Empty(t, targets, "NULL matches no subject")
+	})
+
+	t.Run("Notes", func(t *testing.T) {
+		_, err := st.CreateTargetNote(ctx, postgres.CreateTargetNoteParams{Target: a.ID, PreviousNotes: "", Notes: "Seen in Kyiv"})
+		require.NoError(t, err)
+		note, err := st.CreateTargetNote(ctx, postgres.CreateTargetNoteParams{Target: b.ID, PreviousNotes: "", Notes: "Seen in Lviv"})
+		require.NoError(t, err)
+		assert.Equal(t, b.ID, note.Target)
+		assert.True(t, note.UpdatedAt.Valid)
+		_, err = st.CreateTargetNote(ctx, postgres.CreateTargetNoteParams{Target: unlinked.ID, Notes: "Unrelated"})
+		require.NoError(t, err)
+
+		notes, err := st.GetSubjectNotes(ctx, subjectID(ivan.ID))
+		require.NoError(t, err)
+		require.Len(t, notes, 1)
+		assert.Equal(t, "Seen in Kyiv", notes[0].Notes)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		n, err := st.MoveSubjectTargets(ctx, postgres.MoveSubjectTargetsParams{ToSubject: subjectID(ivan.ID), FromSubject: subjectID(ivanov.ID)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = st.DeleteSubject(ctx, ivanov.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		targets, err := st.GetSubjectTargets(ctx, subjectID(ivan.ID))
+		require.NoError(t, err)
+		require.Len(t, targets, 2)
+		assert.Equal(t, a.ID, targets[0].ID)
+		assert.Equal(t, b.ID, targets[1].ID)
+
+		notes, err := st.GetSubjectNotes(ctx, subjectID(ivan.ID))
+		require.NoError(t, err)
+		require.Len(t, notes, 2, "the notes follow their targets")
+		assert.Equal(t, "Seen in Lviv", notes[1].Notes)
+
+		n, err = st.DeleteSubject(ctx, ivanov.ID)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("DeleteSetsNull", func(t *testing.T) {
+		olga, err := st.CreateSubject(ctx, postgres.CreateSubjectParams{Name: "Olga", Country: "UA"})
+		require.NoError(t, err)
+		target, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: first.ID, Name: "Olga", Country: "UA", Subject: subjectID(olga.ID)})
+		require.NoError(t, err)
+
+		_, err = st.DeleteSubject(ctx, olga.ID)
+		require.NoError(t, err)
+
+		got, err := st.GetTarget(ctx, target.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Subject.Valid)
+	})
+
+	t.Run("MissingSubject", func(t *testing.T) {
+		_, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: first.ID, Name: "Ivan", Country: "UA", Subject: subjectID(missingID)})
+		assertForeignKeyViolation(t, err)
+	})
+}
+
+func testAttachments(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+	ivan := createTarget(t, st, mission.ID, "Ivan")
+	olga := createTarget(t, st, mission.ID, "Olga")
+
+	photo, err := st.CreateAttachment(ctx, postgres.CreateAttachmentParams{
+		Target:      ivan.ID,
+		Filename:    "photo.jpg",
+		ContentType: "image/jpeg",
+		Size:        5 << 20,
+		BlobKey:     "storagetest/photo",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ivan.ID, photo.Target)
+	assert.Equal(t, int64(5<<20), photo.Size)
+	assert.True(t, photo.CreatedAt.Valid)
+
+	report, err := st.CreateAttachment(ctx, postgres.CreateAttachmentParams{Target: ivan.ID, Filename: "report.pdf", ContentType: "application/pdf", Size: 10, BlobKey: "storagetest/report"})
+	require.NoError(t, err)
+	_, err = st.CreateAttachment(ctx, postgres.CreateAttachmentParams{Target: olga.ID, Filename: "notes.txt", ContentType: "text/plain; charset=utf-8", Size: 1, BlobKey: "storagetest/notes"})
+	require.NoError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		got, err := st.GetAttachment(ctx, photo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, photo, got)
+
+		_, err = st.GetAttachment(ctx, missingID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Target", func(t *testing.T) {
+		attachments, err := st.GetTargetAttachments(ctx, ivan.ID)
+		require.NoError(t, err)
+		require.Len(t, attachments, 2)
+		assert.Equal(t, photo.ID, attachments[0].ID)
+		assert.Equal(t, report.ID, attachments[1].ID)
+
+		attachments, err = st.GetMissionAttachments(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Len(t, attachments, 3)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		n, err := st.DeleteAttachment(ctx, report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		n, err = st.DeleteAttachment(ctx, report.ID)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("DuplicateBlobKey", func(t *testing.T) {
+		_, err := st.CreateAttachment(ctx, postgres.CreateAttachmentParams{Target: olga.ID, Filename: "photo.jpg", ContentType: "image/jpeg", Size: 1, BlobKey: photo.BlobKey})
+		assertUniqueViolation(t, err)
+	})
+
+	t.Run("MissingTarget", func(t *testing.T) {
+		_, err := st.CreateAttachment(ctx, postgres.CreateAttachmentParams{Target: missingID, Filename: "photo.jpg", ContentType: "image/jpeg", Size: 1, BlobKey: "storagetest/missing"})
+		assertForeignKeyViolation(t, err)
+	})
+
+	t.Run("DeleteTargetCascades", func(t *testing.T) {
+		_, err := st.DeleteTarget(ctx, ivan.ID)
+		require.NoError(t, err)
+
+		attachments, err := st.GetMissionAttachments(ctx, mission.ID)
+		require.NoError(t, err)
+		require.Len(t, attachments, 1)
+		assert.Equal(t, olga.ID, attachments[0].Target)
+
+		_, err = st.DeleteMission(ctx, mission.ID)
+		require.NoError(t, err)
+		attachments, err = st.GetTargetAttachments(ctx, olga.ID)
+		require.NoError(t, err)
+		assert.Empty(t, attachments)
+	})
+}
+
+func testForeignKeys(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	t.Run("MissingCat", func(t *testing.T) {
+		_, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: assignee(missingID)})
+		assertForeignKeyViolation(t, err)
+	})
+
+	t.Run("MissingMission", func(t *testing.T) {
+		_, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: missingID, Name: "Ivan", Country: "UA"})
+		assertForeignKeyViolation(t, err)
+	})
+
+	t.Run("MoveToMissingMission", func(t *testing.T) {
+		target := createTarget(t, st, mission.ID, "Ivan")
+		_, err := st.MoveTarget(ctx, postgres.MoveTargetParams{ID: target.ID, Mission: missingID})
+		assertForeignKeyViolation(t, err)
+	})
+
+	t.Run("DeleteCatSetsNull", func(t *testing.T) {
+		cat := createCat(t, st, "Tom")
+
+		_, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: assignee(cat.ID)})
+		require.NoError(t, err)
+
+		_, err = st.DeleteCat(ctx, cat.ID)
+		require.NoError(t, err)
+
+		got, err := st.GetMission(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.False(t, got.Assignee.Valid)
+	})
+
+	t.Run("DeleteMissionCascades", func(t *testing.T) {
+		target := createTarget(t, st, mission.ID, "Ivan")
+
+		_, err := st.DeleteMission(ctx, mission.ID)
+		require.NoError(t, err)
+
+		_, err = st.GetTarget(ctx, target.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		targets, err := st.GetMissionTargets(ctx, mission.ID)
+		require.NoError(t, err)
+		assert.Empty(t, targets)
+	})
+}
+
+func testRollback(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	tx, err := st.Begin(ctx)
+	require.NoError(t, err)
+
+	withTx := st.WithTx(tx)
+	cat := createCat(t, withTx, "Tom")
+	target := createTarget(t, withTx, mission.ID, "Ivan")
+	_, err = withTx.CompleteMission(ctx, mission.ID)
+	require.NoError(t, err)
+
+	// The changes are visible within the transaction.
+	_, err = withTx.GetCat(ctx, cat.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Rollback(ctx))
+
+	_, err = st.GetCat(ctx, cat.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = st.GetTarget(ctx, target.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	got, err := st.GetMission(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Completed)
+}
+
+func testCommit(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	tx, err := st.Begin(ctx)
+	require.NoError(t, err)
+
+	withTx := st.WithTx(tx)
+	mission, err := withTx.CreateMission(ctx)
+	require.NoError(t, err)
+	target := createTarget(t, withTx, mission.ID, "Ivan")
+
+	require.NoError(t, tx.Commit(ctx))
+
+	got, err := st.GetTarget(ctx, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, mission.ID, got.Mission)
+}
+
+func testReadOnlyTransaction(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	tx, err := st.BeginTx(ctx, storage.TxOptions{RepeatableRead: true, ReadOnly: true})
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	withTx := st.WithTx(tx)
+	_, err = withTx.GetMission(ctx, mission.ID)
+	require.NoError(t, err)
+
+	// A target committed after the first query isn't seen by the next ones.
+	// SQLite holds the write until the transaction ends.
+	created := make(chan postgres.Target, 1)
+	go func() {
+		target, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: "Ivan", Country: "UA"})
+		assert.NoError(t, err)
+		created <- target
+	}()
+	var target postgres.Target
+	select {
+	case target = <-created:
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	targets, err := withTx.GetMissionTargets(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+
+	require.NoError(t, tx.Rollback(ctx))
+	if target.ID == 0 {
+		target = <-created
+	}
+
+	got, err := st.GetTarget(ctx, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, mission.ID, got.Mission)
+}
+
+func testConcurrentTransactions(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	const n = 20
+
+	ids := make([]int32, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tx, err := st.Begin(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			withTx := st.WithTx(tx)
+
+			mission, err := withTx.CreateMission(ctx)
+			if assert.NoError(t, err) {
+				for _, name := range []string{"Ivan", "Olga"} {
+					_, err = withTx.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: name, Country: "UA"})
+					if !assert.NoError(t, err) {
+						break
+					}
+				}
+			}
+
+			if err != nil {
+				assert.NoError(t, tx.Rollback(ctx))
+				return
+			}
+			if assert.NoError(t, tx.Commit(ctx)) {
+				ids[i] = mission.ID
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[int32]bool)
+	for _, id := range ids {
+		require.NotZero(t, id)
+		assert.False(t, seen[id], "mission id %d is used twice", id)
+		seen[id] = true
+
+		targets, err := st.GetMissionTargets(ctx, id)
+		require.NoError(t, err)
+		assert.Len(t, targets, 2)
+	}
+}
+
+func testConcurrentAssignments(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	const n = 10
+
+	cats := make([]int32, n)
+	for i := range cats {
+		cats[i] = createCat(t, st, "Tom").ID
+	}
+
+	// The last assignment wins, and it must be one of the cats.
+	var wg sync.WaitGroup
+	for _, id := range cats {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := st.AssignCat(ctx, postgres.AssignCatParams{ID: mission.ID, Assignee: assignee(id)})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	got, err := st.GetMission(ctx, mission.ID)
+	require.NoError(t, err)
+	require.True(t, got.Assignee.Valid)
+	assert.Contains(t, cats, got.Assignee.Int32)
+}
+
+func testConcurrentTargetCounts(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	const n, maxTargets = 10, 3
+
+	// Each transaction adds a target if the mission has room for it. The lock
+	// of the mission makes the count and the insert atomic.
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tx, err := st.Begin(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() { _ = tx.Rollback(ctx) }()
+			withTx := st.WithTx(tx)
+
+			_, err = withTx.GetMissionForUpdate(ctx, mission.ID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			targets, err := withTx.GetMissionTargets(ctx, mission.ID)
+			if !assert.NoError(t, err) || len(targets) >= maxTargets {
+				return
+			}
+			_, err = withTx.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: "Ivan", Country: "UA"})
+			if assert.NoError(t, err) {
+				assert.NoError(t, tx.Commit(ctx))
+			}
+		}()
+	}
+	wg.Wait()
+
+	targets, err := st.GetMissionTargets(ctx, mission.ID)
+	require.NoError(t, err)
+	assert.Len(t, targets, maxTargets)
+
+	_, err = st.GetMissionForUpdate(ctx, missingID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testSearch(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	// A word of no other target, as the database may be shared.
+	word := "qz" + strconv.Itoa(int(mission.ID))
+	inNotes, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: "Olga", Country: "PL", Notes: "Meets the courier near the " + word})
+	require.NoError(t, err)
+	inName, err := st.CreateTarget(ctx, postgres.CreateTargetParams{Mission: mission.ID, Name: "Ivan " + word, Country: "UA", Notes: "Nothing"})
+	require.NoError(t, err)
+	createTarget(t, st, mission.ID, "Anna")
+
+	// The names weigh more than the notes.
+	rows, err := st.SearchTargets(ctx, postgres.SearchTargetsParams{Query: word, MaxResults: 10})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, inName.ID, rows[0].ID)
+	assert.Equal(t, inNotes.ID, rows[1].ID)
+	assert.Greater(t, rows[0].Rank, rows[1].Rank)
+	assert.Equal(t, mission.ID, rows[1].Mission)
+	assert.False(t, rows[1].MissionCompleted)
+	assert.Contains(t, rows[1].Snippet, tsquery.StartSel+word+tsquery.StopSel)
+
+	rows, err = st.SearchTargets(ctx, postgres.SearchTargetsParams{Query: word, MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, inName.ID, rows[0].ID)
+
+	rows, err = st.SearchTargets(ctx, postgres.SearchTargetsParams{Query: word + " & !courier", MaxResults: 10})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, inName.ID, rows[0].ID)
+
+	// The index follows the notes.
+	_, err = st.UpdateTargetNotes(ctx, postgres.UpdateTargetNotesParams{ID: inNotes.ID, Notes: "Left the city"})
+	require.NoError(t, err)
+
+	rows, err = st.SearchTargets(ctx, postgres.SearchTargetsParams{Query: word + " & courier", MaxResults: 10})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func testWebhooks(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	// An event type of no other webhook, as the database may be shared.
+	eventType := "storagetest." + strconv.FormatInt(time.Now().UnixNano(), 36)
+	createWebhook := func() postgres.Webhook {
+		w, err := st.CreateWebhook(ctx, postgres.CreateWebhookParams{Url: "http://localhost/hook", Secret: "secret", EventTypes: []string{eventType}})
+		require.NoError(t, err)
+		t.Cleanup(func() { _, _ = st.DeleteWebhook(ctx, w.ID) })
+		return w
+	}
+	setActive := func(w postgres.Webhook, active bool) {
+		_, err := st.UpdateWebhook(ctx, postgres.UpdateWebhookParams{ID: w.ID, Url: w.Url, EventTypes: w.EventTypes, Active: active})
+		require.NoError(t, err)
+	}
+	enqueue := func() {
+		_, err := st.EnqueueWebhookDeliveries(ctx, postgres.EnqueueWebhookDeliveriesParams{EventType: eventType, Payload: []byte(`{"a": 1}`)})
+		require.NoError(t, err)
+	}
+	// claim returns the deliveries of the webhooks claimed until the lease,
+	// leaving the ones of other tests out.
+	claim := func(lease time.Duration, webhooks ...postgres.Webhook) []postgres.WebhookDelivery {
+		claimed, err := st.ClaimWebhookDeliveries(ctx, postgres.ClaimWebhookDeliveriesParams{
+			LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(lease), Valid: true},
+			BatchSize:  1000,
+		})
+		require.NoError(t, err)
+		return slices.DeleteFunc(claimed, func(d postgres.WebhookDelivery) bool {
+			return !slices.ContainsFunc(webhooks, func(w postgres.Webhook) bool { return w.ID == d.Webhook })
+		})
+	}
+
+	active, inactive := createWebhook(), createWebhook()
+	setActive(inactive, false)
+
+	t.Run("Enqueue", func(t *testing.T) {
+		n, err := st.EnqueueWebhookDeliveries(ctx, postgres.EnqueueWebhookDeliveriesParams{EventType: eventType, Payload: []byte(`{"a": 1}`)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n, "inactive webhooks get no delivery")
+
+		deliveries, err := st.GetWebhookDeliveries(ctx, postgres.GetWebhookDeliveriesParams{Webhook: active.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "pending", deliveries[0].Status)
+		assert.Equal(t, int32(0), deliveries[0].Attempts)
+		assert.JSONEq(t, `{"a": 1}`, string(deliveries[0].Payload))
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		claimed := claim(time.Hour, active)
+		require.Len(t, claimed, 1)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), claimed[0].NextAttemptAt.Time, time.Minute)
+
+		// Leased until the claim expires.
+		assert.Empty(t, claim(time.Hour, active))
+
+		// A failed attempt is due after its backoff, a dead one never.
+		require.NoError(t, st.MarkWebhookDeliveryFailed(ctx, postgres.MarkWebhookDeliveryFailedParams{
+			ID:               claimed[0].ID,
+			Status:           "pending",
+			NextAttemptAt:    pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true},
+			LastError:        "unexpected status code 500",
+			LastResponseCode: pgtype.Int4{Int32: 500, Valid: true},
+		}))
+		retried := claim(-time.Second, active)
+		require.Len(t, retried, 1)
+		assert.Equal(t, int32(1), retried[0].Attempts)
+		assert.Equal(t, "unexpected status code 500", retried[0].LastError)
+
+		// The expired lease makes it due again.
+		require.Len(t, claim(time.Hour, active), 1)
+
+		require.NoError(t, st.MarkWebhookDeliverySucceeded(ctx, postgres.MarkWebhookDeliverySucceededParams{
+			ID:               claimed[0].ID,
+			LastResponseCode: pgtype.Int4{Int32: 200, Valid: true},
+		}))
+		deliveries, err := st.GetWebhookDeliveries(ctx, postgres.GetWebhookDeliveriesParams{Webhook: active.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "succeeded", deliveries[0].Status)
+		assert.Equal(t, int32(2), deliveries[0].Attempts)
+		assert.True(t, deliveries[0].DeliveredAt.Valid)
+
+		enqueue()
+		deliveries, err = st.GetWebhookDeliveries(ctx, postgres.GetWebhookDeliveriesParams{Webhook: active.ID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.NoError(t, st.MarkWebhookDeliveryFailed(ctx, postgres.MarkWebhookDeliveryFailedParams{
+			ID:            deliveries[0].ID,
+			Status:        "dead",
+			NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true},
+		}))
+		assert.Empty(t, claim(time.Hour, active))
+	})
+
+	t.Run("InactiveWebhook", func(t *testing.T) {
+		w := createWebhook()
+		enqueue()
+		setActive(w, false)
+
+		// The delivery stays pending until the webhook is active again.
+		assert.Empty(t, claim(time.Hour, w))
+
+		setActive(w, true)
+		assert.Len(t, claim(time.Hour, w), 1)
+	})
+
+	t.Run("ConcurrentClaims", func(t *testing.T) {
+		w := createWebhook()
+		setActive(active, false)
+		defer setActive(active, true)
+
+		const n = 10
+		for range n {
+			enqueue()
+		}
+
+		// Each delivery is claimed once, whatever the claims running at the
+		// same time.
+		var (
+			mu      sync.Mutex
+			claimed []int32
+			wg      sync.WaitGroup
+		)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				got, err := st.ClaimWebhookDeliveries(ctx, postgres.ClaimWebhookDeliveriesParams{
+					LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+					BatchSize:  3,
+				})
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				for _, d := range got {
+					if d.Webhook == w.ID {
+						claimed = append(claimed, d.ID)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		claimed = append(claimed, ids(claim(time.Hour, w))...)
+		assert.Len(t, claimed, n)
+		slices.Sort(claimed)
+		assert.Len(t, slices.Compact(claimed), n, "no delivery is claimed twice")
+	})
+}
+
+func ids(deliveries []postgres.WebhookDelivery) []int32 {
+	res := make([]int32, len(deliveries))
+	for i, d := range deliveries {
+		res[i] = d.ID
+	}
+	return res
+}
+
+func testOutbox(t *testing.T, st storage.Backend) {
+	ctx := context.Background()
+
+	mission, err := st.CreateMission(ctx)
+	require.NoError(t, err)
+
+	// The events of the mission, as the database may be shared.
+	events := func() []postgres.Outbox {
+		var res []postgres.Outbox
+		var from int64
+		for {
+			rows, err := st.GetOutboxEvents(ctx, postgres.GetOutboxEventsParams{FromOffset: from, MaxEvents: 100})
+			require.NoError(t, err)
+			for _, row := range rows {
+				if row.AggregateType == "storagetest" && row.AggregateID == mission.ID {
+					res = append(res, row)
+				}
+			}
+			if len(rows) < 100 {
+				return res
+			}
+			from = rows[len(rows)-1].ID + 1
+		}
+	}
+
+	for _, eventType := range []string{"first", "second", "third"} {
+		require.NoError(t, st.CreateOutboxEvent(ctx, postgres.CreateOutboxEventParams{
+			AggregateType: "storagetest",
+			AggregateID:   mission.ID,
+			EventType:     eventType,
+			Payload:       []byte(`{"a": 1}`),
+		}))
+	}
+
+	created := events()
+	require.Len(t, created, 3)
+	assert.Equal(t, "first", created[0].EventType)
+	assert.Equal(t, "third", created[2].EventType)
+	assert.Less(t, created[0].ID, created[1].ID, "offsets increase")
+	assert.JSONEq(t, `{"a": 1}`, string(created[0].Payload))
+	assert.True(t, created[0].CreatedAt.Valid)
+	assert.False(t, created[0].PublishedAt.Valid)
+
+	// unpublished returns the offsets of the unpublished events after the
+	// offset.
+	unpublished := func(after int64, max int32) []int64 {
+		rows, err := st.GetUnpublishedOutboxEvents(ctx, postgres.GetUnpublishedOutboxEventsParams{AfterOffset: after, MaxEvents: max})
+		require.NoError(t, err)
+		res := make([]int64, len(rows))
+		for i, row := range rows {
+			assert.Greater(t, row.ID, after)
+			assert.False(t, row.PublishedAt.Valid)
+			res[i] = row.ID
+		}
+		assert.True(t, slices.IsSorted(res), "ordered by offset")
+		return res
+	}
+
+	page := unpublished(created[0].ID, 1)
+	require.Len(t, page, 1)
+	assert.NotContains(t, unpublished(created[0].ID, 1000), created[0].ID)
+	assert.Contains(t, unpublished(created[0].ID, 1000), created[2].ID)
+
+	n, err := st.MarkOutboxEventsPublished(ctx, []int64{created[0].ID, created[1].ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	offsets := unpublished(created[0].ID-1, 1000)
+	assert.NotContains(t, offsets, created[0].ID)
+	assert.NotContains(t, offsets, created[1].ID)
+	assert.Contains(t, offsets, created[2].ID)
+	published := events()
+	assert.True(t, published[0].PublishedAt.Valid)
+	assert.False(t, published[2].PublishedAt.Valid)
+
+	// A replay publishes the events from the offset again.
+	n, err = st.ResetOutboxEvents(ctx, created[1].ID)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, int64(2))
+
+	offsets = unpublished(created[0].ID-1, 1000)
+	assert.NotContains(t, offsets, created[0].ID)
+	assert.Contains(t, offsets, created[1].ID)
+}
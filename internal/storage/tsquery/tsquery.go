@@ -0,0 +1,344 @@
+// Package tsquery evaluates Postgres to_tsquery expressions outside of Postgres.
+//
+// It is used by the storage backends without full-text search of their own and
+// mimics the 'simple' text search configuration: words are lowercased runs of
+// letters and digits.
+package tsquery
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// StartSel and StopSel delimit the matches in a headline. They are private use
+// characters removed from the text, so the matches are told apart from the
+// text without escaping it.
+const (
+	StartSel = "\uE000"
+	StopSel  = "\uE001"
+)
+
+// Weights of the document fields, like the defaults of ts_rank.
+const (
+	WeightA = 1.0
+	WeightB = 0.4
+	WeightC = 0.2
+)
+
+// Document is a text tokenized like to_tsvector('simple', ...).
+type Document struct {
+	words   []string
+	weights []float32
+}
+
+// Add appends the words of text with the given weight.
+func (d *Document) Add(text string, weight float32) {
+	for _, w := range Words(text) {
+		d.words = append(d.words, w)
+		d.weights = append(d.weights, weight)
+	}
+}
+
+// Words returns the lowercased words of text.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Query is a parsed to_tsquery expression.
+type Query struct {
+	root node
+}
+
+// Lexeme is a word of a query.
+type Lexeme struct {
+	Word string
+	// Prefix is set if the lexeme matches words by prefix.
+	Prefix bool
+}
+
+// Matches reports whether the lowercased word matches the lexeme.
+func (l *Lexeme) Matches(word string) bool {
+	if l.Prefix {
+		return strings.HasPrefix(word, l.Word)
+	}
+
+	return word == l.Word
+}
+
+func (l *Lexeme) positions(d *Document) []int {
+	var pos []int
+	for i, w := range d.words {
+		if l.Matches(w) {
+			pos = append(pos, i)
+		}
+	}
+
+	return pos
+}
+
+// Match reports whether the document matches the query.
+func (q *Query) Match(d *Document) bool {
+	ok, _ := q.root.eval(d)
+	return ok
+}
+
+// Lexemes returns the lexemes of the query which are not negated.
+func (q *Query) Lexemes() []*Lexeme {
+	return q.root.lexemes(nil)
+}
+
+// Rank sums the weights of the words of the document matched by the query.
+func (q *Query) Rank(d *Document) float32 {
+	var rank float32
+	for _, l := range q.Lexemes() {
+		for _, i := range l.positions(d) {
+			rank += d.weights[i]
+		}
+	}
+
+	return rank
+}
+
+// Headline wraps the words of text matched by the query in StartSel and
+// StopSel, like ts_headline.
+func (q *Query) Headline(text string) string {
+	lexemes := q.Lexemes()
+
+	var (
+		b    strings.Builder
+		word strings.Builder
+	)
+
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+
+		w := word.String()
+		word.Reset()
+
+		for _, l := range lexemes {
+			if l.Matches(strings.ToLower(w)) {
+				b.WriteString(StartSel + w + StopSel)
+				return
+			}
+		}
+		b.WriteString(w)
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+		if s := string(r); s != StartSel && s != StopSel {
+			b.WriteRune(r)
+		}
+	}
+	flush()
+
+	return b.String()
+}
+
+// HeadlineHTML returns the headline as HTML, escaping the text and wrapping
+// the matches in <mark>.
+func HeadlineHTML(headline string) string {
+	return strings.NewReplacer(StartSel, "<mark>", StopSel, "</mark>").Replace(html.EscapeString(headline))
+}
+
+// node is a node of a parsed expression.
+type node interface {
+	// eval reports whether the document matches, and the positions of the last
+	// matched words for the followed-by operator.
+	eval(d *Document) (bool, []int)
+	// lexemes appends the lexemes which are not negated.
+	lexemes(acc []*Lexeme) []*Lexeme
+}
+
+func (l *Lexeme) eval(d *Document) (bool, []int) {
+	pos := l.positions(d)
+	return len(pos) > 0, pos
+}
+
+func (l *Lexeme) lexemes(acc []*Lexeme) []*Lexeme {
+	return append(acc, l)
+}
+
+type not struct {
+	node node
+}
+
+func (n *not) eval(d *Document) (bool, []int) {
+	ok, _ := n.node.eval(d)
+	return !ok, nil
+}
+
+func (n *not) lexemes(acc []*Lexeme) []*Lexeme {
+	return acc
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (n *binary) eval(d *Document) (bool, []int) {
+	lok, lpos := n.left.eval(d)
+	rok, rpos := n.right.eval(d)
+
+	switch n.op {
+	case "&":
+		return lok && rok, append(lpos, rpos...)
+	case "|":
+		return lok || rok, append(lpos, rpos...)
+	default:
+		// <->: the right side must follow the left side.
+		var pos []int
+		for _, r := range rpos {
+			for _, l := range lpos {
+				if l == r-1 {
+					pos = append(pos, r)
+					break
+				}
+			}
+		}
+		return len(pos) > 0, pos
+	}
+}
+
+func (n *binary) lexemes(acc []*Lexeme) []*Lexeme {
+	return n.right.lexemes(n.left.lexemes(acc))
+}
+
+// Parse parses a to_tsquery expression.
+//
+// Precedence from lowest to highest: |, &, <->, !.
+func Parse(query string) (*Query, error) {
+	p := &parser{tokens: tokenize(query)}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "" {
+		return nil, fmt.Errorf("syntax error in tsquery: %q", query)
+	}
+
+	return &Query{root: root}, nil
+}
+
+// tokenize splits an expression into operators and lexemes.
+func tokenize(query string) []string {
+	var tokens []string
+
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.HasPrefix(query[i:], "<->"):
+			tokens = append(tokens, "<->")
+			i += 3
+		case strings.ContainsRune("()&|!", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			end := strings.IndexAny(query[i:], " \t\n()&|!<")
+			if end < 0 {
+				end = len(query) - i
+			}
+			if end == 0 {
+				end = 1
+			}
+			tokens = append(tokens, query[i:i+end])
+			i += end
+		}
+	}
+
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+}
+
+func (p *parser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+
+	return p.tokens[0]
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary("|", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary("&", p.parsePhrase)
+}
+
+func (p *parser) parsePhrase() (node, error) {
+	return p.parseBinary("<->", p.parseUnary)
+}
+
+func (p *parser) parseBinary(op string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == op {
+		p.next()
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch t := p.next(); t {
+	case "!":
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &not{node: n}, nil
+	case "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("syntax error in tsquery: missing )")
+		}
+		return n, nil
+	case "", ")", "&", "|", "<->":
+		return nil, fmt.Errorf("syntax error in tsquery: unexpected %q", t)
+	default:
+		word, prefix := strings.CutSuffix(t, ":*")
+		word = strings.ToLower(strings.Trim(word, "'"))
+		if word == "" {
+			return nil, fmt.Errorf("syntax error in tsquery: empty lexeme")
+		}
+		return &Lexeme{Word: word, Prefix: prefix}, nil
+	}
+}
@@ -0,0 +1,221 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3/client"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-SCA-Event"
+	HeaderDelivery  = "X-SCA-Delivery"
+	HeaderTimestamp = "X-SCA-Timestamp"
+	HeaderSignature = "X-SCA-Signature"
+)
+
+// DeliveryStorage controls the webhook delivery queue.
+type DeliveryStorage interface {
+	ClaimWebhookDeliveries(ctx context.Context, params postgres.ClaimWebhookDeliveriesParams) ([]postgres.WebhookDelivery, error)
+	GetWebhook(ctx context.Context, id int32) (postgres.Webhook, error)
+	MarkWebhookDeliverySucceeded(ctx context.Context, params postgres.MarkWebhookDeliverySucceededParams) error
+	MarkWebhookDeliveryFailed(ctx context.Context, params postgres.MarkWebhookDeliveryFailedParams) error
+}
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int32
+	MaxAttempts  int32
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+}
+
+// Dispatcher sends queued webhook deliveries.
+//
+// Failed deliveries are retried with exponential backoff and dead-lettered
+// after the maximum number of attempts. The deliveries of an inactive webhook
+// stay pending until it is active again.
+type Dispatcher struct {
+	storage DeliveryStorage
+	cfg     Config
+	client  *client.Client
+}
+
+// NewDispatcher returns a new Dispatcher.
+func NewDispatcher(st DeliveryStorage, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		storage: st,
+		cfg:     cfg,
+		client:  client.New().SetTimeout(cfg.Timeout),
+	}
+}
+
+// Run polls the queue until the context is canceled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.Info("Webhook dispatcher started")
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch claims a batch of due deliveries and sends them.
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	log := slog.With(
+		slog.String("op", "webhooks.dispatch"),
+	)
+
+	// Claimed deliveries become due again once the lease expires,
+	// so a crash mid-batch doesn't lose them.
+	lease := time.Duration(d.cfg.BatchSize) * d.cfg.Timeout
+	claimed, err := d.storage.ClaimWebhookDeliveries(ctx, postgres.ClaimWebhookDeliveriesParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(lease), Valid: true},
+		BatchSize:  d.cfg.BatchSize,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error("Failed to claim deliveries", "err", err)
+		}
+		return
+	}
+
+	webhooks := make(map[int32]postgres.Webhook)
+	for _, delivery := range claimed {
+		w, ok := webhooks[delivery.Webhook]
+		if !ok {
+			w, err = d.storage.GetWebhook(ctx, delivery.Webhook)
+			if err != nil {
+				// The webhook was deleted along with its deliveries.
+				log.Warn("Failed to get webhook", "err", err, "webhook", delivery.Webhook)
+				continue
+			}
+			webhooks[w.ID] = w
+		}
+
+		d.deliver(ctx, w, delivery)
+	}
+}
+
+// deliver sends a single delivery and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, w postgres.Webhook, delivery postgres.WebhookDelivery) {
+	log := slog.With(
+		slog.String("op", "webhooks.deliver"),
+		slog.Any("webhook", w.ID),
+		slog.Any("delivery", delivery.ID),
+	)
+
+	if !w.Active {
+		// Deactivated since the claim. The delivery is claimed again once the
+		// lease expires if the webhook is active again, without an attempt.
+		log.Debug("Webhook is inactive")
+		return
+	}
+
+	timestamp := time.Now().Unix()
+
+	resp, err := d.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(HeaderEvent, delivery.EventType).
+		SetHeader(HeaderDelivery, strconv.Itoa(int(delivery.ID))).
+		SetHeader(HeaderTimestamp, strconv.FormatInt(timestamp, 10)).
+		SetHeader(HeaderSignature, Sign(w.Secret, timestamp, delivery.Payload)).
+		SetRawBody(delivery.Payload).
+		Post(w.Url)
+	if err != nil {
+		log.Info("Delivery failed", "err", err)
+		d.fail(ctx, delivery, delivery.Attempts+1, err.Error(), pgtype.Int4{})
+		return
+	}
+
+	code := resp.StatusCode()
+	resp.Close()
+
+	if code < 200 || code >= 300 {
+		log.Info("Delivery rejected", "status", code)
+		d.fail(ctx, delivery, delivery.Attempts+1, fmt.Sprintf("unexpected status code %d", code), pgtype.Int4{Int32: int32(code), Valid: true})
+		return
+	}
+
+	err = d.storage.MarkWebhookDeliverySucceeded(ctx, postgres.MarkWebhookDeliverySucceededParams{
+		ID:               delivery.ID,
+		LastResponseCode: pgtype.Int4{Int32: int32(code), Valid: true},
+	})
+	if err != nil {
+		log.Error("Failed to mark delivery as succeeded", "err", err)
+		return
+	}
+
+	log.Debug("Delivered")
+}
+
+// fail schedules a retry, or dead-letters the delivery once attempts are exhausted.
+func (d *Dispatcher) fail(ctx context.Context, delivery postgres.WebhookDelivery, attempts int32, reason string, code pgtype.Int4) {
+	status := StatusPending
+	if attempts >= d.cfg.MaxAttempts {
+		status = StatusDead
+	}
+
+	err := d.storage.MarkWebhookDeliveryFailed(ctx, postgres.MarkWebhookDeliveryFailedParams{
+		ID:               delivery.ID,
+		Status:           status,
+		NextAttemptAt:    pgtype.Timestamptz{Time: time.Now().Add(Backoff(attempts, d.cfg.BaseBackoff, d.cfg.MaxBackoff)), Valid: true},
+		LastError:        reason,
+		LastResponseCode: code,
+	})
+	if err != nil {
+		slog.Error("Failed to mark delivery as failed", "err", err, "delivery", delivery.ID)
+	}
+}
+
+// Backoff returns the delay before the next attempt, doubling from base up to max.
+func Backoff(attempts int32, base, max time.Duration) time.Duration {
+	delay := base
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
+
+// Sign returns the signature of a delivery.
+//
+// It is "sha256=" followed by the hex-encoded HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the webhook secret.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,182 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/memory"
+	"github.com/rsmanito/developstoday-test-assessment/internal/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(1, 10*time.Second, time.Hour))
+	assert.Equal(t, 20*time.Second, Backoff(2, 10*time.Second, time.Hour))
+	assert.Equal(t, 80*time.Second, Backoff(4, 10*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(20, 10*time.Second, time.Hour))
+}
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"type":"mission.completed"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, Sign("secret", 1700000000, payload))
+	assert.NotEqual(t, expected, Sign("other", 1700000000, payload))
+}
+
+// newTestDispatcher returns a Dispatcher of the memory storage and a webhook
+// posting to the handler, with a delivery queued for it.
+func newTestDispatcher(t *testing.T, cfg Config, handler http.HandlerFunc) (*Dispatcher, *memory.Storage, postgres.Webhook) {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	st := memory.New()
+	ctx := context.Background()
+
+	w, err := st.CreateWebhook(ctx, postgres.CreateWebhookParams{
+		Url:        srv.URL,
+		Secret:     "secret",
+		EventTypes: []string{"mission.completed"},
+	})
+	require.NoError(t, err)
+	_, err = st.EnqueueWebhookDeliveries(ctx, postgres.EnqueueWebhookDeliveriesParams{
+		EventType: "mission.completed",
+		Payload:   []byte(`{"type":"mission.completed"}`),
+	})
+	require.NoError(t, err)
+
+	cfg.BatchSize, cfg.Timeout = 10, time.Second
+	return NewDispatcher(st, cfg), st, w
+}
+
+// onlyDelivery returns the single delivery of the webhook.
+func onlyDelivery(t *testing.T, st *memory.Storage, w postgres.Webhook) postgres.WebhookDelivery {
+	deliveries, err := st.GetWebhookDeliveries(context.Background(), postgres.GetWebhookDeliveriesParams{Webhook: w.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	return deliveries[0]
+}
+
+func TestDispatcher_Success(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	d, st, w := newTestDispatcher(t, Config{MaxAttempts: 3}, func(rw http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	})
+
+	d.dispatch(context.Background())
+
+	require.NotNil(t, got)
+	timestamp, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "mission.completed", got.Header.Get(HeaderEvent))
+	assert.Equal(t, Sign("secret", timestamp, body), got.Header.Get(HeaderSignature))
+
+	delivery := onlyDelivery(t, st, w)
+	assert.Equal(t, StatusSucceeded, delivery.Status)
+	assert.Equal(t, int32(1), delivery.Attempts)
+	assert.Equal(t, int32(http.StatusOK), delivery.LastResponseCode.Int32)
+	assert.True(t, delivery.DeliveredAt.Valid)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	calls := 0
+	d, st, w := newTestDispatcher(t, Config{MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: time.Hour}, func(rw http.ResponseWriter, r *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	d.dispatch(context.Background())
+
+	delivery := onlyDelivery(t, st, w)
+	assert.Equal(t, StatusPending, delivery.Status)
+	assert.Equal(t, int32(1), delivery.Attempts)
+	assert.Equal(t, "unexpected status code 503", delivery.LastError)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), delivery.NextAttemptAt.Time, time.Minute)
+
+	// The retry isn't due yet.
+	d.dispatch(context.Background())
+	assert.Equal(t, 1, calls)
+}
+
+func TestDispatcher_DeadLettersAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	d, st, w := newTestDispatcher(t, Config{MaxAttempts: 2}, func(rw http.ResponseWriter, r *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusInternalServerError)
+	})
+
+	for range 3 {
+		d.dispatch(context.Background())
+	}
+
+	assert.Equal(t, 2, calls)
+	delivery := onlyDelivery(t, st, w)
+	assert.Equal(t, StatusDead, delivery.Status)
+	assert.Equal(t, int32(2), delivery.Attempts)
+}
+
+func TestDispatcher_LeaseExpiry(t *testing.T) {
+	calls := 0
+	d, st, w := newTestDispatcher(t, Config{MaxAttempts: 3}, func(rw http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+	ctx := context.Background()
+
+	// Another dispatcher holds the delivery.
+	claimed, err := st.ClaimWebhookDeliveries(ctx, postgres.ClaimWebhookDeliveriesParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(50 * time.Millisecond), Valid: true},
+		BatchSize:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	d.dispatch(ctx)
+	assert.Equal(t, 0, calls)
+
+	// It crashed and its lease expired.
+	time.Sleep(60 * time.Millisecond)
+
+	d.dispatch(ctx)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, StatusSucceeded, onlyDelivery(t, st, w).Status)
+}
+
+func TestDispatcher_InactiveWebhook(t *testing.T) {
+	calls := 0
+	d, st, w := newTestDispatcher(t, Config{MaxAttempts: 1}, func(rw http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+	ctx := context.Background()
+
+	_, err := st.UpdateWebhook(ctx, postgres.UpdateWebhookParams{ID: w.ID, Url: w.Url, EventTypes: w.EventTypes, Active: false})
+	require.NoError(t, err)
+
+	d.dispatch(ctx)
+
+	assert.Equal(t, 0, calls)
+	delivery := onlyDelivery(t, st, w)
+	assert.Equal(t, StatusPending, delivery.Status)
+	assert.Equal(t, int32(0), delivery.Attempts)
+
+	// Delivered once the webhook is active again.
+	_, err = st.UpdateWebhook(ctx, postgres.UpdateWebhookParams{ID: w.ID, Url: w.Url, EventTypes: w.EventTypes, Active: true})
+	require.NoError(t, err)
+
+	d.dispatch(ctx)
+	assert.Equal(t, 1, calls)
+}